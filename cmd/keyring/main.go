@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"gin-notebook/configs"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/keyring"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	asynqSingleton "gin-notebook/internal/tasks/asynq/singleton"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+)
+
+const usage = `集成密钥的密钥管理
+
+用法:
+  keyring list              列出数据密钥及仍在使用的记录数
+  keyring generate [-rotate=true]
+                            生成新的主用密钥，原主用密钥降为只解密；默认随后投递重新加密任务
+  keyring rotate            投递重新加密任务
+  keyring retire <kid>      停用只解密的密钥，仍有记录使用时拒绝
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	config := configs.Load()
+	logger.InitLogger(*config)
+	database.ConnectDB(config, false)
+	algorithm.NewSnowflake(1)
+
+	ctx := context.Background()
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(ctx)
+	case "generate":
+		fs := flag.NewFlagSet("generate", flag.ExitOnError)
+		rotate := fs.Bool("rotate", true, "生成后投递重新加密任务")
+		fs.Parse(os.Args[2:])
+		err = generate(ctx, config, *rotate)
+	case "rotate":
+		err = rotate(ctx, config)
+	case "retire":
+		if len(os.Args) < 3 {
+			fmt.Print(usage)
+			os.Exit(2)
+		}
+		err = retire(ctx, os.Args[2])
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		os.Exit(1)
+	}
+}
+
+func list(ctx context.Context) error {
+	repo := repository.NewEncryptionKeyRepository(database.DB)
+	keys, err := repo.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-20s %-8s %-8s %s\n", "KID", "STATUS", "RECORDS", "CREATED")
+	for _, k := range keys {
+		n, err := repo.CountKeyUsage(ctx, k.KID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-20s %-8s %-8d %s\n", k.KID, k.Status, n, k.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+func generate(ctx context.Context, config *configs.Config, rotateAfter bool) error {
+	if config.Secret.MasterKey == "" {
+		return keyring.ErrDisabled
+	}
+	ring, err := keyring.New(config.Secret.MasterKey, repository.NewEncryptionKeyRepository(database.DB))
+	if err != nil {
+		return err
+	}
+	key, err := ring.Generate(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println("已生成主用密钥:", key.KID)
+	if !rotateAfter {
+		return nil
+	}
+	return rotate(ctx, config)
+}
+
+// rotate 由 worker 执行，服务端与 worker 最迟一分钟后改用新的主用密钥
+func rotate(ctx context.Context, config *configs.Config) error {
+	asynqSingleton.InitGlobal(config.Cache.Host, config.Cache.Port, config.Cache.Password, config.Cache.DB)
+	defer asynqSingleton.Close()
+
+	id, err := enqueue.SecretRotate(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println("已投递重新加密任务:", id)
+	return nil
+}
+
+func retire(ctx context.Context, kid string) error {
+	repo := repository.NewEncryptionKeyRepository(database.DB)
+	key, err := repo.GetKey(ctx, kid)
+	if err != nil {
+		return err
+	}
+	n, err := repo.CountKeyUsage(ctx, key.KID)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("仍有 %d 条记录使用密钥 %s，请先执行 rotate 并等待完成", n, kid)
+	}
+	ok, err := repo.RetireKey(ctx, key.KID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("密钥 %s 状态为 %s，只能停用只解密的密钥", kid, key.Status)
+	}
+	fmt.Println("已停用密钥:", kid)
+	return nil
+}
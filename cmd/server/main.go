@@ -60,6 +60,12 @@ func main() {
 		panic(err)
 	}
 
+	// 加载集成密钥的密钥环
+	if err := startup.InitKeyring(config); err != nil {
+		logger.LogError(err, "keyring init error")
+		panic(err)
+	}
+
 	// 注册验证器
 	validator.RegisterValidator()
 
@@ -0,0 +1,14 @@
+package startup
+
+import (
+	"context"
+	"gin-notebook/configs"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/keyring"
+	"gin-notebook/internal/repository"
+)
+
+// InitKeyring 加载集成密钥加密所用的密钥环，需在连接数据库之后调用
+func InitKeyring(c *configs.Config) error {
+	return keyring.Init(context.Background(), c.Secret.MasterKey, repository.NewEncryptionKeyRepository(database.DB))
+}
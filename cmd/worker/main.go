@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"gin-notebook/cmd/startup"
 	"gin-notebook/configs"
 	"gin-notebook/internal/pkg/cache"
 	"gin-notebook/internal/pkg/database"
@@ -35,6 +36,17 @@ func main() {
 	algorithm.NewSnowflake(1)
 	logger.LogInfo("Snowflake init success", nil)
 
+	// 加载集成密钥的密钥环
+	if err := startup.InitKeyring(config); err != nil {
+		logger.LogError(err, "keyring init error")
+		panic(err)
+	}
+
+	// 初始化系统设置与对象存储（回收站清理需要删除附件）
+	if err := startup.Init(); err != nil {
+		logger.LogError(err, "startup init failed")
+	}
+
 	// 启动asynq服务
 	logger.LogInfo("configs loaded: ", configs.Configs.Cache.Host+":"+configs.Configs.Cache.Port)
 	srv := asq.NewServer(asq.ServerConfig{
@@ -2,6 +2,7 @@ package configs
 
 import (
 	"os"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -52,6 +53,21 @@ type Config struct {
 		Repo  string `toml:"repo"`
 		Owner string `toml:"owner"`
 	}
+	Trash struct {
+		RetentionDays int `toml:"retention_days"` // 回收站保留天数，超过后由定时任务彻底删除
+		PurgeBatch    int `toml:"purge_batch"`    // 每轮清理的最大条数
+	} `toml:"trash"`
+	Notion struct {
+		BaseURL string `toml:"base_url"` // 留空使用官方地址
+	} `toml:"notion"`
+	Secret struct {
+		MasterKey   string `toml:"master_key"`   // base64 编码的 32 字节主密钥，用于加密数据密钥；留空时集成密钥明文存储
+		RotateBatch int    `toml:"rotate_batch"` // 重新加密任务每批处理的记录数
+	} `toml:"secret"`
+	Feishu struct {
+		AppURL        string `toml:"app_url"`        // 前端访问地址，用于通知卡片中的跳转链接
+		DeadlineAhead int    `toml:"deadline_ahead"` // 截止前多少小时提醒负责人
+	} `toml:"feishu"`
 }
 
 var Configs *Config
@@ -65,3 +81,37 @@ func Load() *Config {
 
 	return Configs
 }
+
+// TrashRetention 回收站保留时长，未配置时默认 30 天
+func (c *Config) TrashRetention() time.Duration {
+	days := 30
+	if c != nil && c.Trash.RetentionDays > 0 {
+		days = c.Trash.RetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// TrashPurgeBatch 定时清理每轮处理的条数，未配置时默认 200
+func (c *Config) TrashPurgeBatch() int {
+	if c != nil && c.Trash.PurgeBatch > 0 {
+		return c.Trash.PurgeBatch
+	}
+	return 200
+}
+
+// SecretRotateBatch 重新加密任务每批处理的条数，未配置时默认 100
+func (c *Config) SecretRotateBatch() int {
+	if c != nil && c.Secret.RotateBatch > 0 {
+		return c.Secret.RotateBatch
+	}
+	return 100
+}
+
+// FeishuDeadlineAhead 截止提醒的提前量，未配置时默认 24 小时
+func (c *Config) FeishuDeadlineAhead() time.Duration {
+	hours := 24
+	if c != nil && c.Feishu.DeadlineAhead > 0 {
+		hours = c.Feishu.DeadlineAhead
+	}
+	return time.Duration(hours) * time.Hour
+}
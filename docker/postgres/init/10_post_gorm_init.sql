@@ -126,24 +126,155 @@ BEGIN
 END $$;
 
 -- 6) RLS 开启
+--    应用角色即表的所有者，不加 FORCE 时所有策略对其不生效
 ALTER TABLE rag_documents ENABLE ROW LEVEL SECURITY;
 ALTER TABLE rag_chunks    ENABLE ROW LEVEL SECURITY;
+ALTER TABLE rag_documents FORCE ROW LEVEL SECURITY;
+ALTER TABLE rag_chunks    FORCE ROW LEVEL SECURITY;
+
+-- 6.0 笔记 ACL：与 noteService.resolveNoteRole 保持一致
+--     返回 0=无权限 1=viewer 2=commenter 3=editor 4=owner
+--     SECURITY DEFINER：策略内部读取 notes/note_permissions 时不再递归触发 RLS
+CREATE OR REPLACE FUNCTION app_note_role(p_note_id bigint) RETURNS int
+LANGUAGE plpgsql STABLE SECURITY DEFINER SET search_path = public AS $$
+DECLARE
+  v_user  bigint := NULLIF(current_setting('app.user_id', true), '')::bigint;
+  v_ws    bigint := NULLIF(current_setting('app.workspace_id', true), '')::bigint;
+  v_note  record;
+  v_mem   record;
+  v_level int := 0;
+BEGIN
+  IF v_user IS NULL THEN
+    RETURN 0;
+  END IF;
+
+  SELECT id, workspace_id, owner_id, status, allow_edit, allow_comment INTO v_note
+    FROM notes WHERE id = p_note_id AND deleted_at IS NULL;
+  IF NOT FOUND OR (v_ws IS NOT NULL AND v_note.workspace_id <> v_ws) THEN
+    RETURN 0;
+  END IF;
+
+  SELECT id, role::jsonb AS roles INTO v_mem
+    FROM workspace_members
+   WHERE workspace_id = v_note.workspace_id AND user_id = v_user AND deleted_at IS NULL;
+  IF NOT FOUND THEN
+    RETURN 0;
+  END IF;
+
+  -- 1) 创建者 / 工作区管理员
+  IF v_note.owner_id = v_user OR v_mem.roles ? 'admin' THEN
+    RETURN 4;
+  END IF;
+
+  -- 2) 成员授权与分组授权取最高
+  SELECT COALESCE(MAX(CASE p.role
+           WHEN 'owner' THEN 4 WHEN 'editor' THEN 3
+           WHEN 'commenter' THEN 2 WHEN 'viewer' THEN 1 ELSE 0 END), 0)
+    INTO v_level
+    FROM note_permissions p
+   WHERE p.note_id = v_note.id
+     AND p.deleted_at IS NULL
+     AND ((p.grantee_type = 'member' AND p.grantee_id = v_mem.id)
+       OR (p.grantee_type = 'group'  AND v_mem.roles ? p.grantee_group));
+
+  -- 3) 无授权时公开笔记按开关给默认角色
+  IF v_level = 0 AND v_note.status = 'public' THEN
+    v_level := CASE
+      WHEN COALESCE(v_note.allow_edit, true)    THEN 3
+      WHEN COALESCE(v_note.allow_comment, true) THEN 2
+      ELSE 1 END;
+  END IF;
+
+  -- 4) 开关上限（owner 不受限）
+  IF v_level = 3 AND NOT COALESCE(v_note.allow_edit, true) THEN
+    v_level := 2;
+  END IF;
+  IF v_level = 2 AND NOT COALESCE(v_note.allow_comment, true) THEN
+    v_level := 1;
+  END IF;
+  RETURN v_level;
+END $$;
+
+-- 后台任务（清理、向量化）通过 repository.BypassRLS 在事务内显式声明 app.bypass_rls，
+-- rag 表只认这个开关，未设置身份的会话一律按无权限处理
+CREATE OR REPLACE FUNCTION app_rls_bypass() RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on';
+$$;
+
+-- rag 文档若来自笔记（external_id = 'note:<id>'），返回对应笔记 ID，否则 NULL
+CREATE OR REPLACE FUNCTION app_document_note_id(p_document_id bigint) RETURNS bigint
+LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public AS $$
+  SELECT substring(external_id FROM 6)::bigint
+    FROM rag_documents
+   WHERE id = p_document_id AND external_id ~ '^note:[0-9]+$';
+$$;
+
+-- 6.0.1 notes / note_permissions 的 RLS
+--       未设置 app.user_id 的会话（后台任务、迁移等）不受限制，
+--       repository.BeginWithRLS 设置身份后才按笔记 ACL 收窄
+ALTER TABLE notes            ENABLE ROW LEVEL SECURITY;
+ALTER TABLE note_permissions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE notes            FORCE ROW LEVEL SECURITY;
+ALTER TABLE note_permissions FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS notes_read ON notes;
+CREATE POLICY notes_read ON notes FOR SELECT
+USING (NULLIF(current_setting('app.user_id', true), '') IS NULL OR app_note_role(id) >= 1);
+
+DROP POLICY IF EXISTS notes_insert ON notes;
+CREATE POLICY notes_insert ON notes FOR INSERT
+WITH CHECK (
+  NULLIF(current_setting('app.user_id', true), '') IS NULL
+  OR owner_id = current_setting('app.user_id', true)::bigint
+);
+
+-- 软删除也是 UPDATE，删除需要 owner 由应用层校验
+DROP POLICY IF EXISTS notes_update ON notes;
+CREATE POLICY notes_update ON notes FOR UPDATE
+USING (NULLIF(current_setting('app.user_id', true), '') IS NULL OR app_note_role(id) >= 3);
+
+DROP POLICY IF EXISTS notes_delete ON notes;
+CREATE POLICY notes_delete ON notes FOR DELETE
+USING (NULLIF(current_setting('app.user_id', true), '') IS NULL OR app_note_role(id) >= 4);
+
+DROP POLICY IF EXISTS note_permissions_read ON note_permissions;
+CREATE POLICY note_permissions_read ON note_permissions FOR SELECT
+USING (NULLIF(current_setting('app.user_id', true), '') IS NULL OR app_note_role(note_id) >= 1);
+
+DROP POLICY IF EXISTS note_permissions_write ON note_permissions;
+CREATE POLICY note_permissions_write ON note_permissions FOR ALL
+USING (NULLIF(current_setting('app.user_id', true), '') IS NULL OR app_note_role(note_id) >= 4)
+WITH CHECK (NULLIF(current_setting('app.user_id', true), '') IS NULL OR app_note_role(note_id) >= 4);
 
 -- 6.1 读策略（DROP + CREATE 兼容老版本）
 DROP POLICY IF EXISTS rag_documents_read ON rag_documents;
-CREATE POLICY rag_documents_read ON rag_documents
+-- 来自笔记的文档按笔记 ACL 判断（AI 检索只能命中有 viewer 以上权限的笔记），其余沿用可见性规则
+CREATE POLICY rag_documents_read ON rag_documents FOR SELECT
 USING (
-  (owner_user_id = current_setting('app.user_id', true)::bigint AND visibility IN ('private','workspace','public'))
-  OR (visibility = 'workspace' AND workspace_id = current_setting('app.workspace_id', true)::bigint)
-  OR (visibility = 'public')
+  app_rls_bypass()
+  OR (NULLIF(current_setting('app.user_id', true), '') IS NOT NULL
+    AND CASE WHEN external_id ~ '^note:[0-9]+$'
+      THEN app_note_role(substring(external_id FROM 6)::bigint) >= 1
+    ELSE
+      (owner_user_id = current_setting('app.user_id', true)::bigint AND visibility IN ('private','workspace','public'))
+      OR (visibility = 'workspace' AND workspace_id = current_setting('app.workspace_id', true)::bigint)
+      OR (visibility = 'public')
+    END)
 );
 
 DROP POLICY IF EXISTS rag_chunks_read ON rag_chunks;
-CREATE POLICY rag_chunks_read ON rag_chunks
+CREATE POLICY rag_chunks_read ON rag_chunks FOR SELECT
 USING (
-  (owner_user_id = current_setting('app.user_id', true)::bigint AND visibility IN ('private','workspace','public'))
-  OR (visibility = 'workspace' AND workspace_id = current_setting('app.workspace_id', true)::bigint)
-  OR (visibility = 'public')
+  app_rls_bypass()
+  OR (NULLIF(current_setting('app.user_id', true), '') IS NOT NULL
+    AND CASE WHEN app_document_note_id(document_id) IS NOT NULL
+      THEN app_note_role(app_document_note_id(document_id)) >= 1
+    ELSE
+      (owner_user_id = current_setting('app.user_id', true)::bigint AND visibility IN ('private','workspace','public'))
+      OR (visibility = 'workspace' AND workspace_id = current_setting('app.workspace_id', true)::bigint)
+      OR (visibility = 'public')
+    END)
 );
 
 -- 6.2 写策略（INSERT 约束）
@@ -161,6 +292,35 @@ FOR INSERT WITH CHECK (
   AND workspace_id = current_setting('app.workspace_id', true)::bigint
 );
 
+-- 6.2.1 维护策略：声明 app.bypass_rls 的后台任务与文档所有者可以更新、删除，
+--       入库任务会下线笔记在其他工作区的旧文档，因此不限制工作区
+DROP POLICY IF EXISTS rag_documents_maintain ON rag_documents;
+CREATE POLICY rag_documents_maintain ON rag_documents FOR UPDATE
+USING (
+  app_rls_bypass()
+  OR owner_user_id = current_setting('app.user_id', true)::bigint
+);
+
+DROP POLICY IF EXISTS rag_documents_delete ON rag_documents;
+CREATE POLICY rag_documents_delete ON rag_documents FOR DELETE
+USING (
+  app_rls_bypass()
+  OR owner_user_id = current_setting('app.user_id', true)::bigint
+);
+
+DROP POLICY IF EXISTS rag_chunks_maintain ON rag_chunks;
+CREATE POLICY rag_chunks_maintain ON rag_chunks FOR UPDATE
+USING (
+  app_rls_bypass()
+  OR owner_user_id = current_setting('app.user_id', true)::bigint
+);
+
+DROP POLICY IF EXISTS rag_chunks_delete ON rag_chunks;
+CREATE POLICY rag_chunks_delete ON rag_chunks FOR DELETE
+USING (
+  app_rls_bypass()
+  OR owner_user_id = current_setting('app.user_id', true)::bigint
+);
 
 -- 6.3 文档→块 冗余同步（无外键）
 -- a) rag_chunks 的 UPDATE 策略（触发器需要能更新）
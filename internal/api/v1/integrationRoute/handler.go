@@ -7,9 +7,11 @@ import (
 	"gin-notebook/internal/model"
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/service/projectService"
 	"gin-notebook/pkg/utils/tools"
 	"gin-notebook/pkg/utils/validator"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -76,18 +78,223 @@ func FeishuOAuthCallbackApi(c *gin.Context) {
 	}
 
 	responseCode, _ := integrationService.HandleFeishuOAuthCallback(c.Request.Context(), params)
-	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(makeAuthResultHTML(true, responseCode, params.Origin)))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(makeAuthResultHTML("feishu", true, responseCode, params.Origin)))
 }
 
-func makeAuthResultHTML(ok bool, responseCode int, targetOrigin *string) string {
+// FeishuEventApi 飞书事件订阅的请求地址，由飞书服务端调用，不经过登录校验
+func FeishuEventApi(c *gin.Context) {
+	params := &dto.FeishuEventDTO{}
+
+	if err := c.ShouldBindHeader(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.Body = body
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := integrationService.HandleFeishuEvent(c.Request.Context(), params)
+	switch {
+	case responseCode == message.ERROR_FEISHU_EVENT_SIGNATURE_INVALID || responseCode == message.ERROR_FEISHU_EVENT_TOKEN_INVALID:
+		c.JSON(http.StatusUnauthorized, response.Response(responseCode, nil))
+	case data != nil:
+		// 飞书要求 challenge 原样出现在响应顶层
+		c.JSON(http.StatusOK, data)
+	default:
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+	}
+}
+
+func NotionOAuthCallbackApi(c *gin.Context) {
+	params := &dto.NotionOAuthCallbackDTO{
+		UserID: c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, _ := integrationService.HandleNotionOAuthCallback(c.Request.Context(), params)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(makeAuthResultHTML("notion", responseCode == message.SUCCESS, responseCode, params.Origin)))
+}
+
+func LinkNotionAccountApi(c *gin.Context) {
+	params := &dto.NotionTokenLinkDTO{
+		UserID: c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := integrationService.LinkNotionAccount(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func LinkJiraAccountApi(c *gin.Context) {
+	params := &dto.JiraTokenLinkDTO{
+		UserID: c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := integrationService.LinkJiraAccount(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+// JiraWebhookApi Jira webhook 的回调地址，每个项目链接一个地址，通过链接的 secret 校验签名
+func JiraWebhookApi(c *gin.Context) {
+	linkID, err := strconv.ParseInt(c.Param("linkID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params := &dto.JiraWebhookDTO{
+		LinkID: linkID,
+	}
+
+	if err := c.ShouldBindHeader(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.Body = body
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := integrationService.HandleJiraWebhook(c.Request.Context(), params)
+	switch responseCode {
+	case message.ERROR_JIRA_WEBHOOK_SIGNATURE_INVALID:
+		c.JSON(http.StatusUnauthorized, response.Response(responseCode, nil))
+	case message.ERROR_JIRA_LINK_NOT_FOUND:
+		c.JSON(http.StatusNotFound, response.Response(responseCode, nil))
+	default:
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+	}
+}
+
+func GitHubWebhookApi(c *gin.Context) {
+	linkID, err := strconv.ParseInt(c.Param("linkID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params := &dto.GitHubWebhookDTO{
+		LinkID: linkID,
+	}
+
+	if err := c.ShouldBindHeader(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.Body = body
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := integrationService.HandleGitHubWebhook(c.Request.Context(), params)
+	switch responseCode {
+	case message.ERROR_GITHUB_SIGN_INVALID:
+		c.JSON(http.StatusUnauthorized, response.Response(responseCode, nil))
+	case message.ERROR_GITHUB_LINK_NOT_FOUND:
+		c.JSON(http.StatusNotFound, response.Response(responseCode, nil))
+	case message.ERROR_GITHUB_EVENT_INVALID:
+		c.JSON(http.StatusBadRequest, response.Response(responseCode, nil))
+	default:
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+	}
+}
+
+// IncomingWebhookApi 项目接收 webhook，由外部系统调用；令牌放在 X-Webhook-Token 请求头，
+// 无法设置请求头时也可以放在查询参数 token 中
+func IncomingWebhookApi(c *gin.Context) {
+	linkID, err := strconv.ParseInt(c.Param("linkID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params := &dto.IncomingTaskDTO{
+		LinkID: linkID,
+		Token:  c.GetHeader("X-Webhook-Token"),
+	}
+	if params.Token == "" {
+		params.Token = c.Query("token")
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := projectService.HandleIncomingTask(c.Request.Context(), params)
+	switch responseCode {
+	case message.ERROR_INCOMING_TOKEN_INVALID:
+		c.JSON(http.StatusUnauthorized, response.Response(responseCode, nil))
+	case message.ERROR_INCOMING_NOT_FOUND:
+		c.JSON(http.StatusNotFound, response.Response(responseCode, nil))
+	case message.ERROR_INCOMING_BUSY:
+		c.JSON(http.StatusConflict, response.Response(responseCode, nil))
+	default:
+		c.JSON(http.StatusOK, response.Response(responseCode, data))
+	}
+}
+
+func makeAuthResultHTML(provider string, ok bool, responseCode int, targetOrigin *string) string {
 	var errMsg string
 	if responseCode != message.SUCCESS {
 		errMsg = message.CodeMsg[responseCode]
 	}
 
-	msg := fmt.Sprintf(`{provider:"feishu", ok:%v, error:%q}`, ok, errMsg)
+	msg := fmt.Sprintf(`{provider:%q, ok:%v, error:%q}`, provider, ok, errMsg)
 	return fmt.Sprintf(`<!DOCTYPE html>
-<html><head><meta charset="utf-8"><title>Feishu Auth</title></head>
+<html><head><meta charset="utf-8"><title>Auth</title></head>
 <body>
 <script>
 (function(){
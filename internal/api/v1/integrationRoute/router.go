@@ -7,6 +7,15 @@ import (
 )
 
 func IntegrationRoutes(r *gin.RouterGroup) {
+	// 外部平台的事件回调，通过签名与校验令牌鉴权
+	eventGroup := r.Group("/integration/events")
+	{
+		eventGroup.POST("/feishu", FeishuEventApi)
+		eventGroup.POST("/jira/:linkID", JiraWebhookApi)
+		eventGroup.POST("/github/:linkID", GitHubWebhookApi)
+		eventGroup.POST("/incoming/:linkID", IncomingWebhookApi)
+	}
+
 	integrationGroup := r.Group("/integration")
 	integrationGroup.Use(middleware.JWTAuth())
 	integrationGroup.Use(middleware.RBACMiddleware())
@@ -15,6 +24,9 @@ func IntegrationRoutes(r *gin.RouterGroup) {
 		integrationGroup.POST("/app", CreateIntegrationAppApi)
 		integrationGroup.GET("/app", GetIntegrationAppListApi)
 		integrationGroup.GET("/feishu/callback", FeishuOAuthCallbackApi)
+		integrationGroup.GET("/notion/callback", NotionOAuthCallbackApi)
+		integrationGroup.POST("/notion/token", LinkNotionAccountApi)
+		integrationGroup.POST("/jira/token", LinkJiraAccountApi)
 		integrationGroup.GET("/accounts", GetIntegrationAccountListApi)
 		integrationGroup.DELETE("/account", UnlinkIntegrationAccountApi)
 	}
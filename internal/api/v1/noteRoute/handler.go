@@ -59,26 +59,112 @@ func GetFavoriteNoteApi(c *gin.Context) {
 }
 
 func CreateNoteCommentApi(c *gin.Context) {
-	// params := &dto.CreateNoteCommentDTO{
-	// 	UserID: c.MustGet("userID").(int64),
-	// }
-	// if err := c.ShouldBindJSON(params); err != nil {
-	// 	log.Printf("params %s", err)
-	// 	c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
-	// 	return
-	// }
-	// if err := validator.ValidateStruct(params); err != nil {
-	// 	logger.LogError(err, "验证失败：")
-	// 	c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
-	// 	return
-	// }
-	// responseCode, data := noteService.CreateNoteComment(params)
-	c.JSON(http.StatusCreated, nil)
+	params := &dto.CreateNoteCommentDTO{
+		UserID:   c.MustGet("userID").(int64),
+		MemberID: c.MustGet("workspaceMemberID").(int64),
+	}
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	responseCode, data := noteService.CreateNoteComment(c.Request.Context(), params)
+	if data == nil {
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+		return
+	}
+	c.JSON(http.StatusCreated, response.Response(responseCode, data))
+}
+
+func GetNoteCommentsApi(c *gin.Context) {
+	params := &dto.GetNoteCommentsDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteComments(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func UpdateNoteCommentApi(c *gin.Context) {
+	params := &dto.UpdateNoteCommentDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.UpdateNoteComment(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteNoteCommentApi(c *gin.Context) {
+	params := &dto.DeleteNoteCommentDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := noteService.DeleteNoteComment(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func ResolveNoteCommentApi(c *gin.Context) {
+	params := &dto.ResolveNoteCommentDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.ResolveNoteComment(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
 }
 
 func CreateTemplateNoteApi(c *gin.Context) {
 	params := &dto.CreateTemplateNoteDTO{
-		OwnerID: c.MustGet("userID").(int64),
+		OwnerID:     c.MustGet("userID").(int64),
+		WorkspaceID: c.MustGet("workspaceID").(int64),
 	}
 
 	if err := c.ShouldBindJSON(params); err != nil {
@@ -104,7 +190,8 @@ func CreateTemplateNoteApi(c *gin.Context) {
 
 func GetTemplateNotesApi(c *gin.Context) {
 	params := &dto.GetTemplateNotesDTO{
-		UserID: c.MustGet("userID").(int64),
+		UserID:      c.MustGet("userID").(int64),
+		WorkspaceID: c.MustGet("workspaceID").(int64),
 	}
 
 	if err := c.ShouldBindQuery(params); err != nil {
@@ -127,6 +214,94 @@ func GetTemplateNotesApi(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Response(responseCode, data))
 }
 
+func UpdateTemplateNoteApi(c *gin.Context) {
+	params := &dto.UpdateTemplateNoteDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.UpdateTemplateNote(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteTemplateNoteApi(c *gin.Context) {
+	params := &dto.DeleteTemplateNoteDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := noteService.DeleteTemplateNote(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func SaveNoteAsTemplateApi(c *gin.Context) {
+	params := &dto.SaveNoteAsTemplateDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.SaveNoteAsTemplate(c.Request.Context(), params)
+	if data == nil {
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+		return
+	}
+	c.JSON(http.StatusCreated, response.Response(responseCode, data))
+}
+
+func InstantiateTemplateApi(c *gin.Context) {
+	params := &dto.InstantiateTemplateDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.InstantiateTemplate(c.Request.Context(), params)
+	if data == nil {
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+		return
+	}
+	c.JSON(http.StatusCreated, response.Response(responseCode, data))
+}
+
 func AddNoteSyncApi(c *gin.Context) {
 	params := &dto.AddNoteSyncDTO{
 		MemberID: c.MustGet("workspaceMemberID").(int64),
@@ -197,3 +372,605 @@ func DeleteNoteSyncApi(c *gin.Context) {
 	responseCode := noteService.DeleteSync(c.Request.Context(), params)
 	c.JSON(http.StatusOK, response.Response(responseCode, nil))
 }
+
+func GetNoteSyncConflictsApi(c *gin.Context) {
+	params := &dto.GetNoteSyncConflictsDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.ListNoteSyncConflicts(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func ResolveNoteSyncConflictApi(c *gin.Context) {
+	params := &dto.ResolveNoteSyncConflictDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.ResolveNoteSyncConflict(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetNoteSyncHealthApi(c *gin.Context) {
+	params := &dto.GetNoteSyncHealthDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteSyncHealth(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetSyncOutboxApi(c *gin.Context) {
+	params := &dto.GetSyncOutboxDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetSyncOutbox(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func RetrySyncLinkApi(c *gin.Context) {
+	params := &dto.SyncLinkOperateDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.RetrySyncLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func SkipSyncOutboxApi(c *gin.Context) {
+	params := &dto.SyncLinkOperateDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.SkipSyncOutbox(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func RebuildSyncLinkApi(c *gin.Context) {
+	params := &dto.SyncLinkOperateDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.RebuildSyncLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetSyncDashboardApi(c *gin.Context) {
+	params := &dto.GetSyncDashboardDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetSyncDashboard(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetNoteBacklinksApi(c *gin.Context) {
+	params := &dto.NoteBacklinkQueryDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	params.UserID = c.MustGet("userID").(int64)
+	responseCode, data := noteService.GetNoteBacklinks(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetNoteLinkGraphApi(c *gin.Context) {
+	params := &dto.NoteGraphQueryDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	params.UserID = c.MustGet("userID").(int64)
+	responseCode, data := noteService.GetNoteLinkGraph(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func CreateNoteShareApi(c *gin.Context) {
+	params := &dto.CreateNoteShareDTO{
+		MemberID: c.MustGet("workspaceMemberID").(int64),
+		UserID:   c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.CreateNoteShare(c.Request.Context(), params)
+	if data == nil {
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+		return
+	}
+	c.JSON(http.StatusCreated, response.Response(responseCode, data))
+}
+
+func GetNoteSharesApi(c *gin.Context) {
+	params := &dto.GetNoteSharesDTO{
+		UserID: c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteShares(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func RevokeNoteShareApi(c *gin.Context) {
+	params := &dto.RevokeNoteShareDTO{
+		UserID: c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := noteService.RevokeNoteShare(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func GetNotePermissionsApi(c *gin.Context) {
+	params := &dto.GetNotePermissionsDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNotePermissions(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func SetNotePermissionApi(c *gin.Context) {
+	params := &dto.SetNotePermissionDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.SetNotePermission(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteNotePermissionApi(c *gin.Context) {
+	params := &dto.DeleteNotePermissionDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := noteService.DeleteNotePermission(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func GetDailyNoteApi(c *gin.Context) {
+	params := &dto.GetDailyNoteDTO{
+		WorkspaceID: c.MustGet("workspaceID").(int64),
+		UserID:      c.MustGet("userID").(int64),
+		MemberID:    c.MustGet("workspaceMemberID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetDailyNote(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetDailyNoteCalendarApi(c *gin.Context) {
+	params := &dto.DailyNoteCalendarDTO{
+		WorkspaceID: c.MustGet("workspaceID").(int64),
+		UserID:      c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetDailyNoteCalendar(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetDailyNoteSettingApi(c *gin.Context) {
+	responseCode, data := noteService.GetDailyNoteSetting(
+		c.Request.Context(),
+		c.MustGet("workspaceID").(int64),
+		c.MustGet("userID").(int64),
+	)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func UpdateDailyNoteSettingApi(c *gin.Context) {
+	params := &dto.UpdateDailyNoteSettingDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.WorkspaceID = c.MustGet("workspaceID").(int64)
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.UpdateDailyNoteSetting(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func CreateTasksFromChecklistApi(c *gin.Context) {
+	params := &dto.ChecklistToTasksDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.CreateTasksFromChecklist(c.Request.Context(), params)
+	if data == nil {
+		c.JSON(http.StatusOK, response.Response(responseCode, nil))
+		return
+	}
+	c.JSON(http.StatusCreated, response.Response(responseCode, data))
+}
+
+func GetNoteChecklistTasksApi(c *gin.Context) {
+	params := &dto.NoteChecklistTasksQueryDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteChecklistTasks(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func UnlinkChecklistTaskApi(c *gin.Context) {
+	params := &dto.UnlinkChecklistTaskDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := noteService.UnlinkChecklistTask(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func BulkNoteOperationApi(c *gin.Context) {
+	params := &dto.BulkNoteDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.BulkNoteOperation(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func TransferNoteApi(c *gin.Context) {
+	params := &dto.TransferNoteDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.TransferNote(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func RecordNoteViewApi(c *gin.Context) {
+	params := &dto.RecordNoteViewDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.RecordNoteView(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetNoteReadersApi(c *gin.Context) {
+	params := &dto.NoteReadersQueryDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteReaders(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetNoteViewReportApi(c *gin.Context) {
+	params := &dto.NoteViewReportDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteViewReport(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func AcquireNoteLockApi(c *gin.Context) {
+	params := &dto.AcquireNoteLockDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+	params.MemberID = c.MustGet("workspaceMemberID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.AcquireNoteLock(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func ReleaseNoteLockApi(c *gin.Context) {
+	params := &dto.ReleaseNoteLockDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.ReleaseNoteLock(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetNoteLockApi(c *gin.Context) {
+	params := &dto.NoteLockQueryDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.UserID = c.MustGet("userID").(int64)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetNoteLock(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
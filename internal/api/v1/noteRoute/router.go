@@ -16,10 +16,49 @@ func RegisterNoteRoutes(r *gin.RouterGroup) {
 		noteGroup.POST("/favorite", FavoriteNoteApi)
 		noteGroup.GET("/favorite", GetFavoriteNoteApi)
 		noteGroup.POST("/comments", CreateNoteCommentApi)
+		noteGroup.GET("/comments", GetNoteCommentsApi)
+		noteGroup.PUT("/comment", UpdateNoteCommentApi)
+		noteGroup.DELETE("/comment", DeleteNoteCommentApi)
+		noteGroup.PUT("/comment/resolve", ResolveNoteCommentApi)
 		noteGroup.POST("/template", CreateTemplateNoteApi)
 		noteGroup.GET("/templates", GetTemplateNotesApi)
+		noteGroup.PUT("/template", UpdateTemplateNoteApi)
+		noteGroup.DELETE("/template", DeleteTemplateNoteApi)
+		noteGroup.POST("/template/from-note", SaveNoteAsTemplateApi)
+		noteGroup.POST("/template/instantiate", InstantiateTemplateApi)
 		noteGroup.POST("/sync", AddNoteSyncApi)
 		noteGroup.GET("/sync", GetNoteSyncListApi)
 		noteGroup.DELETE("/sync", DeleteNoteSyncApi)
+		noteGroup.GET("/sync/conflicts", GetNoteSyncConflictsApi)
+		noteGroup.PUT("/sync/conflict/resolve", ResolveNoteSyncConflictApi)
+		noteGroup.GET("/sync/health", GetNoteSyncHealthApi)
+		noteGroup.GET("/sync/outbox", GetSyncOutboxApi)
+		noteGroup.POST("/sync/retry", RetrySyncLinkApi)
+		noteGroup.POST("/sync/skip", SkipSyncOutboxApi)
+		noteGroup.POST("/sync/rebuild", RebuildSyncLinkApi)
+		noteGroup.GET("/sync/dashboard", GetSyncDashboardApi)
+		noteGroup.GET("/backlinks", GetNoteBacklinksApi)
+		noteGroup.GET("/graph", GetNoteLinkGraphApi)
+		noteGroup.POST("/share", CreateNoteShareApi)
+		noteGroup.GET("/shares", GetNoteSharesApi)
+		noteGroup.DELETE("/share", RevokeNoteShareApi)
+		noteGroup.GET("/permissions", GetNotePermissionsApi)
+		noteGroup.PUT("/permission", SetNotePermissionApi)
+		noteGroup.DELETE("/permission", DeleteNotePermissionApi)
+		noteGroup.GET("/daily", GetDailyNoteApi)
+		noteGroup.GET("/daily/calendar", GetDailyNoteCalendarApi)
+		noteGroup.GET("/daily/settings", GetDailyNoteSettingApi)
+		noteGroup.PUT("/daily/settings", UpdateDailyNoteSettingApi)
+		noteGroup.POST("/checklist/tasks", CreateTasksFromChecklistApi)
+		noteGroup.GET("/checklist/tasks", GetNoteChecklistTasksApi)
+		noteGroup.DELETE("/checklist/task", UnlinkChecklistTaskApi)
+		noteGroup.POST("/bulk", BulkNoteOperationApi)
+		noteGroup.POST("/transfer", TransferNoteApi)
+		noteGroup.POST("/view", RecordNoteViewApi)
+		noteGroup.GET("/readers", GetNoteReadersApi)
+		noteGroup.GET("/views/report", GetNoteViewReportApi)
+		noteGroup.GET("/lock", GetNoteLockApi)
+		noteGroup.POST("/lock", AcquireNoteLockApi)
+		noteGroup.DELETE("/lock", ReleaseNoteLockApi)
 	}
 }
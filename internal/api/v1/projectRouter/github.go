@@ -0,0 +1,122 @@
+package projectRouter
+
+import (
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/http/response"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateGitHubProjectLinkApi(c *gin.Context) {
+	projectID, _, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.CreateGitHubProjectLinkDTO{
+		ProjectID: projectID,
+		MemberID:  c.MustGet("workspaceMemberID").(int64),
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind JSON parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Validation failed for CreateGitHubProjectLinkDTO")
+		return
+	}
+
+	responseCode, data := integrationService.CreateGitHubProjectLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetGitHubProjectLinksApi(c *gin.Context) {
+	projectID, _, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.ProjectLinkQueryDTO{
+		ProjectID: projectID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := integrationService.GetGitHubProjectLinks(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func UpdateGitHubProjectLinkApi(c *gin.Context) {
+	projectID, linkID, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.UpdateGitHubProjectLinkDTO{
+		ProjectID: projectID,
+		LinkID:    linkID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind JSON parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Validation failed for UpdateGitHubProjectLinkDTO")
+		return
+	}
+
+	responseCode, data := integrationService.UpdateGitHubProjectLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteGitHubProjectLinkApi(c *gin.Context) {
+	projectID, linkID, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.ProjectLinkQueryDTO{
+		ProjectID: projectID,
+		LinkID:    linkID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil || params.LinkID == 0 {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := integrationService.DeleteGitHubProjectLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
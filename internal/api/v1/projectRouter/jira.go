@@ -0,0 +1,167 @@
+package projectRouter
+
+import (
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/http/response"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseProjectLinkParams 解析路径中的项目 ID 与链接 ID，链接 ID 可选
+func parseProjectLinkParams(c *gin.Context) (projectID, linkID int64, ok bool) {
+	projectID, err := strconv.ParseInt(c.Param("projectID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PROJECT_ID, nil))
+		return 0, 0, false
+	}
+	if raw := c.Param("linkID"); raw != "" {
+		linkID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+			return 0, 0, false
+		}
+	}
+	return projectID, linkID, true
+}
+
+func CreateJiraProjectLinkApi(c *gin.Context) {
+	projectID, _, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.CreateJiraProjectLinkDTO{
+		ProjectID: projectID,
+		MemberID:  c.MustGet("workspaceMemberID").(int64),
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind JSON parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Validation failed for CreateJiraProjectLinkDTO")
+		return
+	}
+
+	responseCode, data := integrationService.CreateJiraProjectLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetJiraProjectLinksApi(c *gin.Context) {
+	projectID, _, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.ProjectLinkQueryDTO{
+		ProjectID: projectID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := integrationService.GetJiraProjectLinks(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func UpdateJiraProjectLinkApi(c *gin.Context) {
+	projectID, linkID, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.UpdateJiraProjectLinkDTO{
+		ProjectID: projectID,
+		LinkID:    linkID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind JSON parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Validation failed for UpdateJiraProjectLinkDTO")
+		return
+	}
+
+	responseCode, data := integrationService.UpdateJiraProjectLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteJiraProjectLinkApi(c *gin.Context) {
+	projectID, linkID, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.ProjectLinkQueryDTO{
+		ProjectID: projectID,
+		LinkID:    linkID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil || params.LinkID == 0 {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := integrationService.DeleteJiraProjectLink(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func GetJiraProjectStatusesApi(c *gin.Context) {
+	projectID, linkID, ok := parseProjectLinkParams(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.ProjectLinkQueryDTO{
+		ProjectID: projectID,
+		LinkID:    linkID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil || params.LinkID == 0 {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := integrationService.GetJiraProjectStatuses(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
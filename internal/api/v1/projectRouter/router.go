@@ -30,6 +30,23 @@ func ProjectRoutes(r *gin.RouterGroup) {
 		projectGroup.GET("/task/:taskID/activities", GetProjectTaskActivityApi)
 		projectGroup.POST("/task/:taskID/comment/:commentID/like", LikeTaskCommentApi)
 		projectGroup.DELETE("/task/:taskID/comment/:commentID/like", DeleteLikeTaskCommentApi)
+		projectGroup.POST("/:projectID/jira", CreateJiraProjectLinkApi)
+		projectGroup.GET("/:projectID/jira", GetJiraProjectLinksApi)
+		projectGroup.PUT("/:projectID/jira/:linkID", UpdateJiraProjectLinkApi)
+		projectGroup.DELETE("/:projectID/jira/:linkID", DeleteJiraProjectLinkApi)
+		projectGroup.GET("/:projectID/jira/:linkID/statuses", GetJiraProjectStatusesApi)
+		projectGroup.POST("/:projectID/github", CreateGitHubProjectLinkApi)
+		projectGroup.GET("/:projectID/github", GetGitHubProjectLinksApi)
+		projectGroup.PUT("/:projectID/github/:linkID", UpdateGitHubProjectLinkApi)
+		projectGroup.DELETE("/:projectID/github/:linkID", DeleteGitHubProjectLinkApi)
+		projectGroup.POST("/:projectID/incoming", CreateIncomingHookApi)
+		projectGroup.GET("/:projectID/incoming", GetIncomingHooksApi)
+		projectGroup.PUT("/:projectID/incoming/:linkID", UpdateIncomingHookApi)
+		projectGroup.DELETE("/:projectID/incoming/:linkID", DeleteIncomingHookApi)
+		projectGroup.POST("/:projectID/feishu", CreateFeishuChatApi)
+		projectGroup.GET("/:projectID/feishu", GetFeishuChatsApi)
+		projectGroup.PUT("/:projectID/feishu/:linkID", UpdateFeishuChatApi)
+		projectGroup.DELETE("/:projectID/feishu/:linkID", DeleteFeishuChatApi)
 
 	}
 }
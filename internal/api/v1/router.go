@@ -10,8 +10,10 @@ import (
 	"gin-notebook/internal/api/v1/projectRouter"
 	"gin-notebook/internal/api/v1/realtimeRoute"
 	"gin-notebook/internal/api/v1/settingsRoute"
+	"gin-notebook/internal/api/v1/shareRoute"
 	"gin-notebook/internal/api/v1/uploadRoute"
 	"gin-notebook/internal/api/v1/userRoute"
+	"gin-notebook/internal/api/v1/webhookRoute"
 	"gin-notebook/internal/api/v1/workspaceRoute"
 	"gin-notebook/internal/pkg/realtime/bus"
 
@@ -31,6 +33,8 @@ func RegisterV1Routes(r *gin.RouterGroup) {
 	projectRouter.ProjectRoutes(group)
 	integrationRoute.IntegrationRoutes(group)
 	metricsRoute.RegisterMetricsRoutes(group)
+	shareRoute.RegisterShareRoutes(group)
+	webhookRoute.RegisterWebhookRoutes(group)
 	if broker := bus.Default(); broker != nil {
 		realtimeRoute.RealTimeRoute(group, realtimeRoute.New(broker))
 	}
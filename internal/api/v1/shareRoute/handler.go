@@ -0,0 +1,77 @@
+package shareRoute
+
+import (
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/http/response"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/noteService"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sharePasswordHeader = "X-Share-Password"
+
+func GetSharedNoteApi(c *gin.Context) {
+	params := &dto.GetSharedNoteDTO{}
+
+	if err := c.ShouldBindUri(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	// 密码走请求头，避免出现在访问日志的 URL 中
+	params.Password = c.GetHeader(sharePasswordHeader)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetSharedNote(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetSharedNoteCommentsApi(c *gin.Context) {
+	params := &dto.GetSharedNoteDTO{}
+
+	if err := c.ShouldBindUri(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.Password = c.GetHeader(sharePasswordHeader)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.GetSharedNoteComments(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func CreateSharedNoteCommentApi(c *gin.Context) {
+	params := &dto.CreateSharedNoteCommentDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	if err := c.ShouldBindUri(params); err != nil {
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+	params.Password = c.GetHeader(sharePasswordHeader)
+
+	if err := validator.ValidateStruct(params); err != nil {
+		logger.LogError(err, "验证失败：")
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := noteService.CreateSharedNoteComment(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
@@ -0,0 +1,15 @@
+package shareRoute
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterShareRoutes 公开分享访问，不经过登录与工作区校验
+func RegisterShareRoutes(r *gin.RouterGroup) {
+	shareGroup := r.Group("/share")
+	{
+		shareGroup.GET("/:token", GetSharedNoteApi)
+		shareGroup.GET("/:token/comments", GetSharedNoteCommentsApi)
+		shareGroup.POST("/:token/comments", CreateSharedNoteCommentApi)
+	}
+}
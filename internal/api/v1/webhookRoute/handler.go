@@ -0,0 +1,171 @@
+package webhookRoute
+
+import (
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/http/response"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/webhookService"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parseWebhookID(c *gin.Context) (int64, bool) {
+	webhookID, err := strconv.ParseInt(c.Param("webhookID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return 0, false
+	}
+	return webhookID, true
+}
+
+func CreateWebhookApi(c *gin.Context) {
+	params := &dto.CreateWebhookDTO{
+		MemberID: c.MustGet("workspaceMemberID").(int64),
+		UserID:   c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind JSON parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Validation failed for CreateWebhookDTO")
+		return
+	}
+
+	responseCode, data := webhookService.CreateWebhook(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func GetWebhooksApi(c *gin.Context) {
+	params := &dto.WebhookQueryDTO{
+		UserID: c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := webhookService.GetWebhooks(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func UpdateWebhookApi(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.UpdateWebhookDTO{
+		WebhookID: webhookID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind JSON parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Validation failed for UpdateWebhookDTO")
+		return
+	}
+
+	responseCode, data := webhookService.UpdateWebhook(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteWebhookApi(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.WebhookQueryDTO{
+		WebhookID: webhookID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode := webhookService.DeleteWebhook(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, nil))
+}
+
+func GetWebhookDeliveriesApi(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.WebhookDeliveryQueryDTO{
+		WebhookID: webhookID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := webhookService.GetWebhookDeliveries(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+// SendTestWebhookApi workspace_id 放在查询参数中
+func SendTestWebhookApi(c *gin.Context) {
+	webhookID, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	params := &dto.WebhookQueryDTO{
+		WebhookID: webhookID,
+		UserID:    c.MustGet("userID").(int64),
+	}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		logger.LogError(err, "Failed to bind query parameters")
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusBadRequest, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	responseCode, data := webhookService.SendTestWebhook(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
@@ -0,0 +1,22 @@
+package webhookRoute
+
+import (
+	"gin-notebook/internal/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWebhookRoutes 工作区外发 webhook 的管理，权限在服务层按工作区管理员校验
+func RegisterWebhookRoutes(r *gin.RouterGroup) {
+	webhookGroup := r.Group("/webhook")
+	webhookGroup.Use(middleware.JWTAuth())
+	webhookGroup.Use(middleware.RequireWorkspaceAccess())
+	{
+		webhookGroup.POST("", CreateWebhookApi)
+		webhookGroup.GET("", GetWebhooksApi)
+		webhookGroup.PUT("/:webhookID", UpdateWebhookApi)
+		webhookGroup.DELETE("/:webhookID", DeleteWebhookApi)
+		webhookGroup.GET("/:webhookID/deliveries", GetWebhookDeliveriesApi)
+		webhookGroup.POST("/:webhookID/test", SendTestWebhookApi)
+	}
+}
@@ -227,7 +227,7 @@ func DeleteWorkspaceNoteApi(c *gin.Context) {
 		c.JSON(http.StatusOK, response.Response(message.ERROR_WORKSPACE_NOTE_VALIDATE, nil))
 		return
 	}
-	responseCode, data := noteService.DeleteNote(params)
+	responseCode, data := noteService.DeleteNote(c.Request.Context(), params)
 	if responseCode != message.SUCCESS {
 		c.JSON(http.StatusInternalServerError, response.Response(responseCode, nil))
 		return
@@ -235,6 +235,66 @@ func DeleteWorkspaceNoteApi(c *gin.Context) {
 	c.JSON(http.StatusOK, response.Response(responseCode, data))
 }
 
+func GetWorkspaceCategoryTreeApi(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+	params := &dto.NoteCategoryTreeQueryDTO{}
+
+	if err := c.ShouldBindQuery(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	params.UserID = userID
+	responseCode, data := noteService.GetNoteCategoryTree(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func MoveWorkspaceCategoryApi(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+	params := &dto.MoveNoteCategoryDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	params.UserID = userID
+	responseCode, data := noteService.MoveNoteCategory(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
+func DeleteWorkspaceCategoryApi(c *gin.Context) {
+	userID := c.MustGet("userID").(int64)
+	params := &dto.DeleteCategoryTreeDTO{}
+
+	if err := c.ShouldBindJSON(params); err != nil {
+		log.Printf("params %s", err)
+		c.JSON(http.StatusInternalServerError, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	if err := validator.ValidateStruct(params); err != nil {
+		c.JSON(http.StatusOK, response.Response(message.ERROR_INVALID_PARAMS, nil))
+		return
+	}
+
+	params.UserID = userID
+	responseCode, data := noteService.DeleteNoteCategory(c.Request.Context(), params)
+	c.JSON(http.StatusOK, response.Response(responseCode, data))
+}
+
 func GetRecommandNotesCategoryApi(c *gin.Context) {
 	// userID := c.MustGet("userID").(int64)
 	params := &dto.RecommendNoteCategoryQueryDTO{}
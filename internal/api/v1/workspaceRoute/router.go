@@ -27,8 +27,14 @@ func RegisterWorkspaceRoutes(r *gin.RouterGroup) {
 		workspaceGroup.PUT("/notes/category/", UpdateWorkspaceCategoryApi)
 		workspaceGroup.POST("/notes/category/", CreateWorkspaceCategoryApi)
 		workspaceGroup.GET("/notes/category/:id/", GetWorkspaceNotesCategoryApi)
+		workspaceGroup.GET("/notes/category/tree/", GetWorkspaceCategoryTreeApi)
+		workspaceGroup.PUT("/notes/category/move/", MoveWorkspaceCategoryApi)
+		workspaceGroup.POST("/notes/category/delete/", DeleteWorkspaceCategoryApi)
 		workspaceGroup.GET("/recommend/category/", GetRecommandNotesCategoryApi)
 		workspaceGroup.GET("/members", GetWorkspaceMembersApi)
+		workspaceGroup.GET("/trash/", GetTrashApi)
+		workspaceGroup.POST("/trash/restore/", RestoreTrashApi)
+		workspaceGroup.POST("/trash/delete/", PurgeTrashApi)
 		// workspaceGroup.GET("/upload/generate/token", GetUploadTokenApi)
 	}
 }
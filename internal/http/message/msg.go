@@ -47,9 +47,42 @@ const (
 	ERROR_INVALID_NOTE_INDEX      = 2008
 	ERROR_NOTE_UPDATE_CONFLICT    = 2009
 	ERROR_NOTE_SYNC_NOT_FOUND     = 2010
+	ERROR_NOTE_SHARE_DISABLED     = 2011 // 工作区或笔记未开启分享
+	ERROR_NOTE_SHARE_NOT_FOUND    = 2012 // 分享链接不存在或已撤销
+	ERROR_NOTE_SHARE_EXPIRED      = 2013 // 分享链接已过期
+	ERROR_NOTE_SHARE_NEED_PASS    = 2014 // 分享链接需要密码
+	ERROR_NOTE_SHARE_PASS_WRONG   = 2015 // 分享链接密码错误
+	ERROR_NOTE_NO_PERMISSION      = 2016 // 对笔记没有足够的权限
+	ERROR_NOTE_GRANTEE_INVALID    = 2017 // 授权对象无效
+	ERROR_NOTE_COMMENT_NOT_FOUND  = 2018 // 笔记评论不存在
+	ERROR_NOTE_COMMENT_ANCHOR     = 2019 // 评论锚点无效（块不存在或文本区间越界）
+	ERROR_NOTE_COMMENT_NOT_ROOT   = 2020 // 只有根评论可以解决/重新打开
+	ERROR_TRASH_ITEM_NOT_FOUND    = 2021 // 回收站中不存在该条目
+	ERROR_TRASH_PARENT_GONE       = 2022 // 条目所属项目已删除，无法恢复
+	ERROR_TEMPLATE_NOT_FOUND      = 2023 // 模板不存在或不可见
+	ERROR_TEMPLATE_VARIABLE       = 2024 // 模板变量定义无效或缺少必填变量
+	ERROR_BLOCK_PATCH_INVALID     = 2025 // 块操作不合法或相互冲突
+	ERROR_DAILY_NOTE_EXISTS       = 2026 // 当天的每日笔记已由其他请求创建
+	ERROR_CHECKLIST_BLOCK_INVALID = 2027 // 所选块不存在或不是清单项
+	ERROR_CHECKLIST_NOT_LINKED    = 2028 // 清单块未关联任务
+	ERROR_NOTE_TAG_NOT_FOUND      = 2029 // 标签不存在
+	ERROR_TRANSFER_TARGET_DENIED  = 2030 // 目标工作区不存在或用户不是其成员
+	ERROR_NOTE_LOCKED             = 2031 // 笔记被他人签出
+	ERROR_NOTE_LOCK_BUSY          = 2032 // 锁状态正在被并发修改
+	ERROR_SYNC_CONFLICT_NOT_FOUND = 2033 // 同步冲突不存在
+	ERROR_SYNC_CONFLICT_RESOLVED  = 2034 // 同步冲突已处理
+	ERROR_SYNC_OUTBOX_NOT_FOUND   = 2035 // 没有阻塞链接的同步任务
+	ERROR_SYNC_LINK_BUSY          = 2036 // 链接正在推送
+	ERROR_NOTE_SHARE_PASS_LOCKED  = 2037 // 分享密码错误次数过多，暂时锁定
+	ERROR_NOTE_SHARE_NO_COMMENT   = 2038 // 分享链接不允许评论
 	// 分类模块的错误
-	ERROR_CATENAME_USED  = 3001
-	ERROR_CATE_NOT_EXIST = 3002
+	ERROR_CATENAME_USED              = 3001
+	ERROR_CATE_NOT_EXIST             = 3002
+	ERROR_CATE_PARENT_NOT_EXIST      = 3003 // 父级分类不存在
+	ERROR_CATE_MOVE_CYCLE            = 3004 // 不能移动到自身或子分类下
+	ERROR_CATE_DELETE_TARGET_INVALID = 3005 // 删除分类时内容去向无效
+	ERROR_CATE_DELETE_LAST           = 3006 // 不能删除工作区唯一的分类
+	ERROR_CATE_NO_PERMISSION         = 3007 // 只有分类创建者或管理员可以连同内容删除分类
 
 	// 工作区模块的错误
 	ERROR_WORKSPACE_NOT_EXIST    = 4001
@@ -155,6 +188,19 @@ const (
 	ERROR_INTEGRATION_ACCOUNT_EXPIRED                 = 13012 // 集成账号已过期
 	ERROR_FEISHU_GET_FILE_META_FAILED                 = 13013 // 获取飞书文件元信息失败
 	ERROR_FEISHU_CONVERT_MARKDOWN_FAILED              = 13014 // 飞书Markdown转换失败
+	ERROR_NOTION_TOKEN_INVALID                        = 13015 // Notion 令牌无效
+	ERROR_NOTION_OAUTH_FAILED                         = 13016 // Notion 授权失败
+	ERROR_NOTION_PAGE_NOT_FOUND                       = 13017 // Notion 页面不存在或未授权
+	ERROR_FEISHU_EVENT_SIGNATURE_INVALID              = 13018 // 飞书事件签名校验失败
+	ERROR_FEISHU_EVENT_TOKEN_INVALID                  = 13019 // 飞书事件 Verification Token 不匹配
+	ERROR_FEISHU_EVENT_INVALID                        = 13020 // 飞书事件无法解析
+	ERROR_JIRA_TOKEN_INVALID                          = 13021 // Jira 凭据无效
+	ERROR_JIRA_PROJECT_NOT_FOUND                      = 13022 // Jira 项目不存在或无权访问
+	ERROR_JIRA_LINK_NOT_FOUND                         = 13023 // 项目未链接该 Jira 项目
+	ERROR_JIRA_LINK_EXISTS                            = 13024 // 项目已链接该 Jira 项目
+	ERROR_JIRA_MAPPING_INVALID                        = 13025 // 字段或状态映射无效
+	ERROR_JIRA_WEBHOOK_SIGNATURE_INVALID              = 13026 // Jira webhook 签名校验失败
+	ERROR_JIRA_WEBHOOK_INVALID                        = 13027 // Jira webhook 无法解析
 
 	// redis 错误
 	ERROR_STORAGE_VALUE = 14001 //存储错误
@@ -162,6 +208,31 @@ const (
 	// Github 错误
 	ERROR_GITHUB_ERROR            = 15001 // Github 通用错误
 	ERROR_GITHUB_API_RATE_LIMITED = 15002 // Github API 请求超出限制
+	ERROR_GITHUB_REPO_NOT_FOUND   = 15003 // Github 仓库不存在或无权访问
+	ERROR_GITHUB_LINK_NOT_FOUND   = 15004 // 项目未链接该仓库
+	ERROR_GITHUB_LINK_EXISTS      = 15005 // 项目已链接该仓库
+	ERROR_GITHUB_MAPPING_INVALID  = 15006 // 列映射无效
+	ERROR_GITHUB_SIGN_INVALID     = 15007 // Github webhook 签名校验失败
+	ERROR_GITHUB_EVENT_INVALID    = 15008 // Github webhook 无法解析
+
+	// Webhook 错误
+	ERROR_WEBHOOK_NOT_FOUND     = 16001 // Webhook 不存在
+	ERROR_WEBHOOK_URL_INVALID   = 16002 // Webhook 地址无效
+	ERROR_WEBHOOK_EVENT_INVALID = 16003 // 不支持的事件类型
+	ERROR_WEBHOOK_NO_PERMISSION = 16004 // 只有工作区管理员可以管理 webhook
+
+	// 接收 webhook 错误
+	ERROR_INCOMING_NOT_FOUND      = 16101 // 接收地址不存在或已停用
+	ERROR_INCOMING_EXISTS         = 16102 // 项目已有同名接收地址
+	ERROR_INCOMING_TOKEN_INVALID  = 16103 // 接收地址令牌无效
+	ERROR_INCOMING_COLUMN_INVALID = 16104 // 目标列不属于该项目
+	ERROR_INCOMING_BUSY           = 16105 // 相同外部键的推送正在处理
+
+	// 飞书通知错误
+	ERROR_FEISHU_CHAT_NOT_FOUND      = 16201 // 项目未配置该飞书群
+	ERROR_FEISHU_CHAT_EXISTS         = 16202 // 项目已配置该飞书群
+	ERROR_FEISHU_CHAT_UNAVAILABLE    = 16203 // 飞书群不存在或机器人未加入
+	ERROR_FEISHU_NOTIFY_KIND_INVALID = 16204 // 不支持的通知类型
 
 )
 
@@ -187,6 +258,11 @@ var CodeMsg = map[int]string{
 	ERROR_INVALID_PARAMS:                             "请求参数错误",
 	ERROR_CATENAME_USED:                              "该分类已存在",
 	ERROR_CATE_NOT_EXIST:                             "该分类不存在",
+	ERROR_CATE_PARENT_NOT_EXIST:                      "父级分类不存在",
+	ERROR_CATE_MOVE_CYCLE:                            "不能将分类移动到自身或其子分类下",
+	ERROR_CATE_DELETE_TARGET_INVALID:                 "分类内容的目标分类无效",
+	ERROR_CATE_DELETE_LAST:                           "不能删除工作区唯一的分类",
+	ERROR_CATE_NO_PERMISSION:                         "只有分类创建者或管理员可以删除分类及其笔记",
 	ERROR_SEND_CAPTCHA:                               "发送验证码失败",
 	ERROR_DATABASE:                                   "数据库操作失败",
 	ERROR_PASSWORD_INVALID:                           "密码错误",
@@ -253,9 +329,50 @@ var CodeMsg = map[int]string{
 	ERROR_INTEGRATION_ACCOUNT_NOT_FOUND:              "集成账号未找到",
 	ERROR_FEISHU_GET_FILE_META_FAILED:                "获取飞书文件元信息失败",
 	ERROR_FEISHU_CONVERT_MARKDOWN_FAILED:             "飞书Markdown转换失败",
+	ERROR_NOTION_TOKEN_INVALID:                       "Notion 令牌无效",
+	ERROR_NOTION_OAUTH_FAILED:                        "Notion 授权失败",
+	ERROR_NOTION_PAGE_NOT_FOUND:                      "Notion 页面不存在或未共享给集成",
+	ERROR_FEISHU_EVENT_SIGNATURE_INVALID:             "飞书事件签名校验失败",
+	ERROR_FEISHU_EVENT_TOKEN_INVALID:                 "飞书事件校验令牌不匹配",
+	ERROR_FEISHU_EVENT_INVALID:                       "飞书事件无法解析",
+	ERROR_JIRA_TOKEN_INVALID:                         "Jira 凭据无效",
+	ERROR_JIRA_PROJECT_NOT_FOUND:                     "Jira 项目不存在或当前账号无权访问",
+	ERROR_JIRA_LINK_NOT_FOUND:                        "项目未链接该 Jira 项目",
+	ERROR_JIRA_LINK_EXISTS:                           "项目已链接该 Jira 项目",
+	ERROR_JIRA_MAPPING_INVALID:                       "字段或状态映射无效",
+	ERROR_JIRA_WEBHOOK_SIGNATURE_INVALID:             "Jira webhook 签名校验失败",
+	ERROR_JIRA_WEBHOOK_INVALID:                       "Jira webhook 无法解析",
 	ERROR_INVALID_NOTE_INDEX:                         "无效的笔记索引",
 	ERROR_NOTE_UPDATE_CONFLICT:                       "笔记更新冲突，请刷新页面后重试",
 	ERROR_NOTE_SYNC_NOT_FOUND:                        "笔记同步配置未找到",
+	ERROR_NOTE_SHARE_DISABLED:                        "当前工作区或笔记未开启分享",
+	ERROR_NOTE_SHARE_NOT_FOUND:                       "分享链接不存在或已撤销",
+	ERROR_NOTE_SHARE_EXPIRED:                         "分享链接已过期",
+	ERROR_NOTE_SHARE_NEED_PASS:                       "请输入分享密码",
+	ERROR_NOTE_SHARE_PASS_WRONG:                      "分享密码错误",
+	ERROR_NOTE_NO_PERMISSION:                         "没有该笔记的操作权限",
+	ERROR_NOTE_GRANTEE_INVALID:                       "授权对象无效",
+	ERROR_NOTE_COMMENT_NOT_FOUND:                     "评论不存在",
+	ERROR_NOTE_COMMENT_ANCHOR:                        "评论锚点无效",
+	ERROR_NOTE_COMMENT_NOT_ROOT:                      "只能解决或重新打开讨论的首条评论",
+	ERROR_TRASH_ITEM_NOT_FOUND:                       "回收站中不存在该条目",
+	ERROR_TRASH_PARENT_GONE:                          "所属项目已删除，无法恢复",
+	ERROR_TEMPLATE_NOT_FOUND:                         "模板不存在",
+	ERROR_TEMPLATE_VARIABLE:                          "模板变量无效或缺少必填变量",
+	ERROR_BLOCK_PATCH_INVALID:                        "内容操作无效或存在冲突",
+	ERROR_DAILY_NOTE_EXISTS:                          "当天的每日笔记已存在",
+	ERROR_CHECKLIST_BLOCK_INVALID:                    "所选内容不是清单项",
+	ERROR_CHECKLIST_NOT_LINKED:                       "该清单项未关联任务",
+	ERROR_NOTE_TAG_NOT_FOUND:                         "标签不存在",
+	ERROR_TRANSFER_TARGET_DENIED:                     "无权访问目标工作区",
+	ERROR_NOTE_LOCKED:                                "笔记已被他人锁定",
+	ERROR_NOTE_LOCK_BUSY:                             "笔记锁正在被修改，请稍后重试",
+	ERROR_SYNC_CONFLICT_NOT_FOUND:                    "同步冲突不存在",
+	ERROR_SYNC_CONFLICT_RESOLVED:                     "同步冲突已处理",
+	ERROR_SYNC_OUTBOX_NOT_FOUND:                      "没有可跳过的同步任务",
+	ERROR_SYNC_LINK_BUSY:                             "同步任务正在执行，请稍后再试",
+	ERROR_NOTE_SHARE_PASS_LOCKED:                     "分享密码错误次数过多，请稍后再试",
+	ERROR_NOTE_SHARE_NO_COMMENT:                      "该分享链接不允许评论",
 	ERROR_AI_EMBEDDING:                               "AI 消息嵌入失败",
 	ERROR_STORAGE_VALUE:                              "缓存错误",
 	ERROR_AI_ACTION_NOT_FOUND:                        "AI 对话prompt选项未找到",
@@ -263,4 +380,23 @@ var CodeMsg = map[int]string{
 	ERROR_AI_PROMPT_CREATE_FAIL:                      "AI 对话prompt创建失败",
 	ERROR_AI_INTENTS_CACHE_FAIL:                      "AI 意图缓存失败",
 	ERROR_AI_PROMPT_NOT_FOUND:                        "AI 对话prompt未找到",
+	ERROR_GITHUB_REPO_NOT_FOUND:                      "Github 仓库不存在或无权访问",
+	ERROR_GITHUB_LINK_NOT_FOUND:                      "项目未链接该仓库",
+	ERROR_GITHUB_LINK_EXISTS:                         "项目已链接该仓库",
+	ERROR_GITHUB_MAPPING_INVALID:                     "列映射无效",
+	ERROR_GITHUB_SIGN_INVALID:                        "Github webhook 签名校验失败",
+	ERROR_GITHUB_EVENT_INVALID:                       "Github webhook 无法解析",
+	ERROR_WEBHOOK_NOT_FOUND:                          "Webhook 不存在",
+	ERROR_WEBHOOK_URL_INVALID:                        "Webhook 地址无效",
+	ERROR_WEBHOOK_EVENT_INVALID:                      "不支持的事件类型",
+	ERROR_WEBHOOK_NO_PERMISSION:                      "只有工作区管理员可以管理 webhook",
+	ERROR_INCOMING_NOT_FOUND:                         "接收地址不存在或已停用",
+	ERROR_INCOMING_EXISTS:                            "项目已有同名接收地址",
+	ERROR_INCOMING_TOKEN_INVALID:                     "接收地址令牌无效",
+	ERROR_INCOMING_COLUMN_INVALID:                    "目标列不属于该项目",
+	ERROR_INCOMING_BUSY:                              "相同外部键的推送正在处理，请稍后重试",
+	ERROR_FEISHU_CHAT_NOT_FOUND:                      "项目未配置该飞书群",
+	ERROR_FEISHU_CHAT_EXISTS:                         "项目已配置该飞书群",
+	ERROR_FEISHU_CHAT_UNAVAILABLE:                    "飞书群不存在或机器人未加入该群",
+	ERROR_FEISHU_NOTIFY_KIND_INVALID:                 "不支持的通知类型",
 }
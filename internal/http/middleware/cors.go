@@ -14,7 +14,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://172.20.10.5:5173", "http://172.20.10.2:5173", "http://127.0.0.1:5173", "http://127.0.0.1:5173/"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "Idempotency-Key"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "Idempotency-Key", "X-Share-Password"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
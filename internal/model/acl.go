@@ -0,0 +1,50 @@
+package model
+
+type NoteRole string
+
+const (
+	NoteRoleNone      NoteRole = ""
+	NoteRoleViewer    NoteRole = "viewer"
+	NoteRoleCommenter NoteRole = "commenter"
+	NoteRoleEditor    NoteRole = "editor"
+	NoteRoleOwner     NoteRole = "owner"
+)
+
+var noteRoleLevel = map[NoteRole]int{
+	NoteRoleNone:      0,
+	NoteRoleViewer:    1,
+	NoteRoleCommenter: 2,
+	NoteRoleEditor:    3,
+	NoteRoleOwner:     4,
+}
+
+// Allows 判断当前角色是否不低于 required
+func (r NoteRole) Allows(required NoteRole) bool {
+	return noteRoleLevel[r] >= noteRoleLevel[required]
+}
+
+func MaxNoteRole(a, b NoteRole) NoteRole {
+	if noteRoleLevel[b] > noteRoleLevel[a] {
+		return b
+	}
+	return a
+}
+
+type NoteGranteeType string
+
+const (
+	NoteGranteeMember NoteGranteeType = "member" // 授权给单个工作区成员（grantee_id 为成员 ID）
+	NoteGranteeGroup  NoteGranteeType = "group"  // 授权给一组成员（grantee_group 为工作区成员角色，如 admin/user）
+)
+
+// NotePermission 笔记级授权
+type NotePermission struct {
+	BaseModel
+	NoteID       int64           `json:"note_id,string" gorm:"not null; uniqueIndex:uidx_note_grantee,priority:1"`
+	WorkspaceID  int64           `json:"workspace_id,string" gorm:"not null; index"`
+	GranteeType  NoteGranteeType `json:"grantee_type" gorm:"type:varchar(16); not null; uniqueIndex:uidx_note_grantee,priority:2"`
+	GranteeID    int64           `json:"grantee_id,string" gorm:"not null; default:0; uniqueIndex:uidx_note_grantee,priority:3"`
+	GranteeGroup string          `json:"grantee_group" gorm:"type:varchar(64); not null; default:''; uniqueIndex:uidx_note_grantee,priority:4"`
+	Role         NoteRole        `json:"role" gorm:"type:varchar(16); not null"`
+	GrantedBy    int64           `json:"granted_by,string" gorm:"not null"` // 授权人的成员 ID
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"gorm.io/datatypes"
+)
+
+type FeishuNotifyKind string
+
+const (
+	FeishuNotifyAssigned  FeishuNotifyKind = "assigned"  // 成员被分配为负责人
+	FeishuNotifyMentioned FeishuNotifyKind = "mentioned" // 成员在任务评论中被提及
+	FeishuNotifyDeadline  FeishuNotifyKind = "deadline"  // 任务临近截止
+	FeishuNotifyMoved     FeishuNotifyKind = "moved"     // 任务移动到其他列
+)
+
+var FeishuNotifyKinds = []FeishuNotifyKind{
+	FeishuNotifyAssigned, FeishuNotifyMentioned, FeishuNotifyDeadline, FeishuNotifyMoved,
+}
+
+func IsFeishuNotifyKind(kind string) bool {
+	for _, k := range FeishuNotifyKinds {
+		if string(k) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// FeishuProjectChat 项目配置的飞书群，机器人需已加入该群；Events 为空表示推送全部通知
+type FeishuProjectChat struct {
+	ProjectID int64          `gorm:"not null; uniqueIndex:idx_project_chat"`
+	ChatID    string         `gorm:"type:varchar(64); not null; uniqueIndex:idx_project_chat"`
+	Name      string         `gorm:"type:varchar(128); not null"`
+	Events    datatypes.JSON `gorm:"type:jsonb; not null; default:'[]'::jsonb"` // []FeishuNotifyKind
+	CreatorID int64          `gorm:"not null"`                                  // 工作区成员 ID
+
+	IsActive  bool    `gorm:"not null; default:true; index"`
+	LastError *string `gorm:"type:text"`
+	BaseModel
+}
+
+func (c *FeishuProjectChat) EventList() []FeishuNotifyKind {
+	events := make([]FeishuNotifyKind, 0)
+	_ = json.Unmarshal(c.Events, &events)
+	return events
+}
+
+func (c *FeishuProjectChat) Matches(kind FeishuNotifyKind) bool {
+	events := c.EventList()
+	if len(events) == 0 {
+		return true
+	}
+	for _, e := range events {
+		if e == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *FeishuProjectChat) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":         strconv.FormatInt(c.ID, 10),
+		"project_id": strconv.FormatInt(c.ProjectID, 10),
+		"chat_id":    c.ChatID,
+		"name":       c.Name,
+		"events":     c.EventList(),
+		"creator_id": strconv.FormatInt(c.CreatorID, 10),
+		"is_active":  c.IsActive,
+		"last_error": c.LastError,
+		"created_at": c.CreatedAt,
+		"updated_at": c.UpdatedAt,
+	}
+}
@@ -23,6 +23,8 @@ type IntegrationAccount struct {
 	Scopes             *string        `gorm:"type:text"` // 原始 scope 串
 	Extra              datatypes.JSON `gorm:"type:json"` // 任意附加信息（如安装信息、bot_id等）
 
+	KID *string `gorm:"column:kid; type:varchar(64); index"` // 加密令牌所用的数据密钥
+
 	// 状态管理
 	IsActive  bool       `gorm:"not null; default:true; index"`
 	RevokedAt *time.Time `gorm:"index"`
@@ -55,7 +57,7 @@ type IntegrationApp struct {
 	SignSecretEnc        *string `gorm:"type:text"`
 	VerificationTokenEnc *string `gorm:"type:text"`
 
-	KID           *string `gorm:"type:varchar(64)"`
+	KID           *string `gorm:"column:kid; type:varchar(64); index"` // 加密密钥所用的数据密钥
 	LastRotatedAt *time.Time
 	IsActive      bool `gorm:"not null; default:true"`
 
@@ -74,3 +76,30 @@ func (i *IntegrationApp) Data() map[string]interface{} {
 		"updated_at": i.UpdatedAt,
 	}
 }
+
+type EncryptionKeyStatus string
+
+const (
+	KeyPrimary EncryptionKeyStatus = "primary" // 新数据使用的密钥，同一时间只有一个
+	KeyActive  EncryptionKeyStatus = "active"  // 只用于解密旧数据
+	KeyRetired EncryptionKeyStatus = "retired" // 已停用，不再加载
+)
+
+// EncryptionKey 数据密钥，以主密钥加密后保存
+type EncryptionKey struct {
+	KID        string              `gorm:"column:kid; type:varchar(64); not null; uniqueIndex"`
+	WrappedKey string              `gorm:"type:text; not null"`
+	Status     EncryptionKeyStatus `gorm:"type:varchar(16); not null; index"`
+	RetiredAt  *time.Time
+
+	BaseModel
+}
+
+func (k *EncryptionKey) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"kid":        k.KID,
+		"status":     k.Status,
+		"retired_at": k.RetiredAt,
+		"created_at": k.CreatedAt,
+	}
+}
@@ -14,6 +14,9 @@ const (
 	DeleteAction KanbanAction = "delete"
 	MoveAction   KanbanAction = "move"
 	CreateAction KanbanAction = "create"
+	LinkAction   KanbanAction = "link"   // 与笔记清单块建立关联
+	UnlinkAction KanbanAction = "unlink" // 解除与笔记清单块的关联
+	RefAction    KanbanAction = "ref"    // 在外部提交、PR 或 issue 中被引用
 )
 
 // 资源类型枚举：用于统一过滤和扩展
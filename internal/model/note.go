@@ -46,6 +46,8 @@ type NoteCategory struct {
 	CategoryName string `json:"category_name" gorm:"not null; type:varchar(100); index:idx_category_name"`
 	WorkspaceID  int64  `json:"workspace_id" gorm:"not null; index:idx_workspace_id"`
 	OwnerID      int64  `json:"owner_id" gorm:"not null; index:idx_owner_id"`
+	ParentID     *int64 `json:"parent_id,string" gorm:"default:NULL; index:idx_category_parent_id"` // 父级分类，NULL 表示根目录
+	OrderIndex   string `json:"order_index" gorm:"type:varchar(64); not null; default:''"`          // 同级排序，LexoRank 格式
 }
 
 type FavoriteNote struct {
@@ -58,11 +60,15 @@ type FavoriteNote struct {
 
 type TemplateNote struct {
 	BaseModel
-	Content  datatypes.JSON `json:"content" gorm:"type:jsonb;not null;default:'[]'::jsonb;index:idx_content"`
-	Title    string         `json:"title" gorm:"not null; type:varchar(255); index:idx_title"`
-	OwnerID  int64          `json:"owner_id" gorm:"not null; index:idx_owner_id"`
-	IsPublic *bool          `json:"is_public" gorm:"default:false"`
-	Cover    *string        `json:"cover" gorm:"default:NULL; type:text;"`
+	Content     datatypes.JSON `json:"content" gorm:"type:jsonb;not null;default:'[]'::jsonb;index:idx_content"`
+	Title       string         `json:"title" gorm:"not null; type:varchar(255); index:idx_title"`
+	OwnerID     int64          `json:"owner_id" gorm:"not null; index:idx_owner_id"`
+	IsPublic    *bool          `json:"is_public" gorm:"default:false"` // 为 true 时出现在所属工作区的模板库中
+	Cover       *string        `json:"cover" gorm:"default:NULL; type:text;"`
+	WorkspaceID *int64         `json:"workspace_id,string" gorm:"default:NULL; index"` // 模板所属工作区
+	Description string         `json:"description" gorm:"type:varchar(255); not null; default:''"`
+	Variables   datatypes.JSON `json:"variables" gorm:"type:jsonb;not null;default:'[]'::jsonb"` // 自定义变量定义 []TemplateVariableDTO
+	UsageCount  int64          `json:"usage_count" gorm:"not null; default:0"`
 }
 
 type NoteExternalLink struct {
@@ -90,6 +96,11 @@ type NoteExternalLink struct {
 	SyncHash        *string `gorm:"type:varchar(64); index"`
 	ScheduleTaskID  *int64  `gorm:"index"`
 	ContentVersion  int64   `gorm:"type:bigint;not null;default:0"`
+
+	// 健康指标：累计失败次数、连续失败次数与最近一次失败时间，推送成功后连续失败清零
+	ErrorCount  int64 `gorm:"not null;default:0"`
+	FailStreak  int   `gorm:"not null;default:0"`
+	LastErrorAt *time.Time
 	BaseModel
 }
 
@@ -109,6 +120,10 @@ func (n *NoteExternalLink) Data() map[string]interface{} {
 		"last_error":      n.LastError,
 		"is_active":       n.IsActive,
 		"last_synced_at":  n.LastSyncedAt,
+		"init_status":     n.InitStatus,
+		"error_count":     n.ErrorCount,
+		"fail_streak":     n.FailStreak,
+		"last_error_at":   n.LastErrorAt,
 		"created_at":      n.CreatedAt,
 		"updated_at":      n.UpdatedAt,
 	}
@@ -133,5 +148,205 @@ type NoteExternalNodeMapping struct {
 	ExtMeta datatypes.JSON `gorm:"type:json"`
 	// 乐观并发（可选）
 	ETag string `gorm:"type:varchar(64)"` // 外部版本戳/指纹
+	// 最近一次同步时双方一致的本地块（不含子块），作为三方合并的基线
+	BaseBlock datatypes.JSON
+	BaseModel
+}
+
+// NoteSyncConflict 拉取时本地与外部同时修改且无法自动合并的块；
+// 每个链接的每个块最多保留一条未处理记录，再次检测到时刷新两侧内容
+type NoteSyncConflict struct {
+	NoteID          int64               `gorm:"not null; index"`
+	LinkID          int64               `gorm:"not null; index:idx_sync_conflict_link_node,priority:1"`
+	Provider        IntegrationProvider `gorm:"type:varchar(32); not null"`
+	NodeUID         string              `gorm:"type:varchar(36); not null; index:idx_sync_conflict_link_node,priority:2"`
+	ExternalBlockID string              `gorm:"type:varchar(128); not null"`
+	BaseBlock       datatypes.JSON
+	LocalBlock      datatypes.JSON      // 本地已删除时为空
+	RemoteBlock     datatypes.JSON      // 外部已删除时为空
+	RemoteETag      string              `gorm:"type:varchar(64)"`
+	Status          ConflictStatus      `gorm:"type:varchar(16); not null; default:'open'; index"`
+	Resolution      *ConflictResolution `gorm:"type:varchar(16)"`
+	ResolvedBy      *int64
+	ResolvedAt      *time.Time
+	BaseModel
+}
+
+func (c *NoteSyncConflict) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                strconv.FormatInt(c.ID, 10),
+		"note_id":           strconv.FormatInt(c.NoteID, 10),
+		"link_id":           strconv.FormatInt(c.LinkID, 10),
+		"provider":          c.Provider,
+		"node_uid":          c.NodeUID,
+		"external_block_id": c.ExternalBlockID,
+		"base_block":        c.BaseBlock,
+		"local_block":       c.LocalBlock,
+		"remote_block":      c.RemoteBlock,
+		"status":            c.Status,
+		"resolution":        c.Resolution,
+		"resolved_at":       c.ResolvedAt,
+		"created_at":        c.CreatedAt,
+		"updated_at":        c.UpdatedAt,
+	}
+}
+
+type NoteLinkTargetType string
+
+const (
+	NoteLinkTargetNote NoteLinkTargetType = "note"
+	NoteLinkTargetTask NoteLinkTargetType = "task"
+)
+
+// NoteLink 笔记内的双向链接，每次保存笔记时根据内容重建
+type NoteLink struct {
+	ImmutableBaseModel
+	WorkspaceID  int64              `json:"workspace_id,string" gorm:"not null; index"`
+	SourceNoteID int64              `json:"source_note_id,string" gorm:"not null; uniqueIndex:uidx_note_link,priority:1"`
+	TargetType   NoteLinkTargetType `json:"target_type" gorm:"type:varchar(16); not null; uniqueIndex:uidx_note_link,priority:2; index:idx_note_link_target,priority:1"`
+	TargetID     int64              `json:"target_id,string" gorm:"not null; uniqueIndex:uidx_note_link,priority:3; index:idx_note_link_target,priority:2"`
+	TargetTitle  string             `json:"target_title" gorm:"type:varchar(255); not null; default:''"` // 书写时的 [[标题]]，目标改名后仍按此匹配
+	BlockID      string             `json:"block_id" gorm:"type:varchar(64)"`                            // 首次出现的块
+}
+
+type SharePermission string
+
+const (
+	SharePermissionRead    SharePermission = "read"    // 只读
+	SharePermissionComment SharePermission = "comment" // 可查看并以访客身份评论
+)
+
+// NoteShare 笔记公开分享链接
+type NoteShare struct {
+	BaseModel
+	NoteID       int64           `json:"note_id,string" gorm:"not null; index"`
+	WorkspaceID  int64           `json:"workspace_id,string" gorm:"not null; index"`
+	CreatorID    int64           `json:"creator_id,string" gorm:"not null"` // 创建者的工作区成员 ID
+	Token        string          `json:"token" gorm:"type:varchar(64); not null; uniqueIndex"`
+	Permission   SharePermission `json:"permission" gorm:"type:varchar(16); not null; default:'read'"`
+	PasswordHash *string         `json:"-" gorm:"type:varchar(128)"`
+	ExpiresAt    *time.Time      `json:"expires_at"`
+	ViewCount    int64           `json:"view_count" gorm:"not null; default:0"`
+	LastViewedAt *time.Time      `json:"last_viewed_at"`
+	RevokedAt    *time.Time      `json:"revoked_at" gorm:"index"`
+}
+
+func (s *NoteShare) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":             strconv.FormatInt(s.ID, 10),
+		"note_id":        strconv.FormatInt(s.NoteID, 10),
+		"token":          s.Token,
+		"permission":     s.Permission,
+		"has_password":   s.PasswordHash != nil,
+		"expires_at":     s.ExpiresAt,
+		"view_count":     s.ViewCount,
+		"last_viewed_at": s.LastViewedAt,
+		"revoked_at":     s.RevokedAt,
+		"created_at":     s.CreatedAt,
+	}
+}
+
+type NoteCommentStatus string
+
+const (
+	NoteCommentOpen     NoteCommentStatus = "open"
+	NoteCommentResolved NoteCommentStatus = "resolved"
+)
+
+type NoteAnchorState string
+
+const (
+	NoteAnchorActive   NoteAnchorState = "active"   // 锚点块仍在笔记中
+	NoteAnchorDetached NoteAnchorState = "detached" // 锚点块被删除或锚定文本已不存在
+)
+
+// NoteComment 锚定在笔记块上的评论；ParentID 为 0 的是讨论串的根评论，只有根评论携带锚点与解决状态
+type NoteComment struct {
+	BaseModel
+	NoteID         int64             `json:"note_id,string" gorm:"not null; index:idx_note_comment_block,priority:1"`
+	WorkspaceID    int64             `json:"workspace_id,string" gorm:"not null; index"`
+	MemberID       int64             `json:"member_id,string" gorm:"not null; index"` // 评论作者，访客评论为 0
+	ShareID        *int64            `json:"share_id,string" gorm:"index"`            // 访客通过该分享链接发表
+	GuestName      string            `json:"guest_name" gorm:"type:varchar(64); not null; default:''"`
+	ParentID       int64             `json:"parent_id,string" gorm:"not null; default:0; index"`
+	BlockID        string            `json:"block_id" gorm:"type:varchar(64); not null; default:''; index:idx_note_comment_block,priority:2"`
+	AnchorStart    int               `json:"anchor_start" gorm:"not null; default:0"`              // 块内文本起始位置（rune）
+	AnchorEnd      int               `json:"anchor_end" gorm:"not null; default:0"`                // 块内文本结束位置（rune），与起始相同表示整块
+	AnchorText     string            `json:"anchor_text" gorm:"type:text; not null; default:''"`   // 划选的原文，编辑后据此重新定位
+	AnchorParentID string            `json:"anchor_parent_id" gorm:"type:varchar(64); default:''"` // 锚点块当前的父块，随 move 更新
+	AnchorState    NoteAnchorState   `json:"anchor_state" gorm:"type:varchar(16); not null; default:'active'"`
+	Content        string            `json:"content" gorm:"type:text; not null"`
+	ReplyCount     int64             `json:"reply_count" gorm:"not null; default:0"`
+	Status         NoteCommentStatus `json:"status" gorm:"type:varchar(16); not null; default:'open'; index"`
+	ResolvedBy     *int64            `json:"resolved_by,string"`
+	ResolvedAt     *time.Time        `json:"resolved_at"`
+}
+
+type NoteCommentMention struct {
+	BaseModel
+	CommentID int64 `json:"comment_id,string" gorm:"not null; index"`
+	MemberID  int64 `json:"member_id,string" gorm:"not null; index"`
+	StartRune int   `json:"start_rune" gorm:"not null"`
+	EndRune   int   `json:"end_rune" gorm:"not null"`
+}
+
+type NoteCommentAttachment struct {
 	BaseModel
+	CommentID     int64  `json:"comment_id,string" gorm:"not null; index"`
+	FileName      string `json:"name" gorm:"not null; type:varchar(255)"`
+	FileURL       string `json:"url" gorm:"not null; type:varchar(255)"`
+	FileSize      int64  `json:"size" gorm:"not null"`
+	FileType      string `json:"type" gorm:"not null; type:varchar(50)"`
+	ThumbnailPath string `json:"thumbnail_path" gorm:"type:varchar(255)"`
+	UploaderID    int64  `json:"uploader_id,string" gorm:"not null"`
+	SHA256Hash    string `json:"sha256_hash" gorm:"type:varchar(64)"`
+}
+
+// DailyNote 每日笔记索引：同一用户在同一工作区每天（按其时区）只有一篇
+type DailyNote struct {
+	BaseModel
+	WorkspaceID int64  `json:"workspace_id,string" gorm:"not null;uniqueIndex:uidx_daily_note,priority:1"`
+	UserID      int64  `json:"user_id,string" gorm:"not null;uniqueIndex:uidx_daily_note,priority:2"`
+	Date        string `json:"date" gorm:"type:varchar(10);not null;uniqueIndex:uidx_daily_note,priority:3"` // YYYY-MM-DD
+	NoteID      int64  `json:"note_id,string" gorm:"not null;index:idx_daily_note_note_id"`
+}
+
+// DailyNoteSetting 用户在工作区内的每日笔记设置
+type DailyNoteSetting struct {
+	BaseModel
+	WorkspaceID   int64  `json:"workspace_id,string" gorm:"not null;uniqueIndex:uidx_daily_note_setting,priority:1"`
+	UserID        int64  `json:"user_id,string" gorm:"not null;uniqueIndex:uidx_daily_note_setting,priority:2"`
+	TemplateID    *int64 `json:"template_id,string" gorm:"default:NULL"`                            // 为空时使用内置模板
+	CategoryID    *int64 `json:"category_id,string" gorm:"default:NULL"`                            // 每日笔记专用分类，首次创建时生成
+	Timezone      string `json:"timezone" gorm:"type:varchar(64);not null;default:'Asia/Shanghai'"` // IANA 时区名
+	IncludeAgenda *bool  `json:"include_agenda" gorm:"not null;default:true"`                       // 新建时是否生成当天任务与日程
+}
+
+// NoteChecklistTask 笔记清单块与看板任务的双向关联；一个块只对应一个任务
+type NoteChecklistTask struct {
+	BaseModel
+	WorkspaceID int64  `json:"workspace_id,string" gorm:"not null;index:idx_checklist_task_ws"`
+	NoteID      int64  `json:"note_id,string" gorm:"not null;uniqueIndex:uidx_checklist_block,priority:1"`
+	BlockID     string `json:"block_id" gorm:"type:varchar(64);not null;uniqueIndex:uidx_checklist_block,priority:2"`
+	TaskID      int64  `json:"task_id,string" gorm:"not null;uniqueIndex:uidx_checklist_task"`
+}
+
+// NoteViewStat 成员阅读笔记的汇总；浏览按会话去重后由定时任务批量写入
+type NoteViewStat struct {
+	BaseModel
+	WorkspaceID   int64     `json:"workspace_id,string" gorm:"not null;index"`
+	NoteID        int64     `json:"note_id,string" gorm:"not null;uniqueIndex:uidx_note_view_member,priority:1"`
+	MemberID      int64     `json:"member_id,string" gorm:"not null;uniqueIndex:uidx_note_view_member,priority:2"`
+	ViewCount     int64     `json:"view_count" gorm:"not null;default:0"`
+	FirstViewedAt time.Time `json:"first_viewed_at" gorm:"not null"`
+	LastViewedAt  time.Time `json:"last_viewed_at" gorm:"not null;index"`
+}
+
+// NoteViewDaily 笔记每天的浏览次数，用于按时间窗口统计热门笔记
+type NoteViewDaily struct {
+	ImmutableBaseModel
+	WorkspaceID int64  `json:"workspace_id,string" gorm:"not null;index:idx_note_view_daily_ws,priority:1"`
+	NoteID      int64  `json:"note_id,string" gorm:"not null;uniqueIndex:uidx_note_view_daily,priority:1"`
+	Date        string `json:"date" gorm:"type:varchar(10);not null;uniqueIndex:uidx_note_view_daily,priority:2;index:idx_note_view_daily_ws,priority:2"` // YYYY-MM-DD（UTC）
+	ViewCount   int64  `json:"view_count" gorm:"not null;default:0"`
 }
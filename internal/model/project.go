@@ -25,6 +25,20 @@ func (p *Project) AfterCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// 列的流程阶段（ToDoColumn.ProcessID），ProcessDone 的列视为已完成
+const (
+	ProcessTodo  uint8 = 0
+	ProcessDoing uint8 = 1
+	ProcessDone  uint8 = 2
+)
+
+// 任务状态（ToDoTask.Status）
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusCompleted  = "completed"
+)
+
 type ToDoColumn struct {
 	BaseModel
 	ProjectID   int64  `json:"project_id,string" gorm:"not null; index:idx_project_id"`
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ProjectExternalLink 项目与外部项目（如 Jira 项目）的链接，任务按映射配置双向同步
+type ProjectExternalLink struct {
+	ProjectID          int64               `gorm:"not null; uniqueIndex:idx_project_provider_target"`
+	Provider           IntegrationProvider `gorm:"type:varchar(32); not null; uniqueIndex:idx_project_provider_target"`
+	ExternalProjectID  string              `gorm:"type:varchar(64); not null"`
+	ExternalProjectKey string              `gorm:"type:varchar(64); not null; uniqueIndex:idx_project_provider_target"`
+	MemberID           int64               `gorm:"not null; index"` // 同步使用该成员绑定的集成账号
+
+	Direction       SyncDirection  `gorm:"type:varchar(16); not null; default:'both'"`
+	StatusMapping   datatypes.JSON `gorm:"type:jsonb; not null; default:'[]'::jsonb"`  // []TaskStatusMapping
+	PriorityMapping datatypes.JSON `gorm:"type:jsonb; not null; default:'{}'::jsonb"`  // 本地优先级名称 -> 外部优先级名称
+	AssigneeMapping datatypes.JSON `gorm:"type:jsonb; not null; default:'[]'::jsonb"`  // []TaskAssigneeMapping
+	WebhookSecret   string         `gorm:"type:text; not null"`                        // 校验 webhook 签名，加密保存
+	SecretKID       *string        `gorm:"column:secret_kid; type:varchar(64); index"` // 加密 WebhookSecret 所用的数据密钥
+
+	IsActive     bool       `gorm:"not null; default:true; index"`
+	LastStatus   SyncStatus `gorm:"type:varchar(16); not null; default:'idle'"`
+	LastError    *string    `gorm:"type:text"`
+	LastSyncedAt *time.Time
+	BaseModel
+}
+
+// TaskStatusMapping 本地列与外部状态的对应关系
+type TaskStatusMapping struct {
+	ColumnID   int64  `json:"column_id,string"`
+	StatusID   string `json:"status_id"`
+	StatusName string `json:"status_name"`
+}
+
+// TaskAssigneeMapping 工作区成员与外部账号的对应关系
+type TaskAssigneeMapping struct {
+	MemberID  int64  `json:"member_id,string"`
+	AccountID string `json:"account_id"`
+}
+
+func (l *ProjectExternalLink) StatusMappings() []TaskStatusMapping {
+	mappings := make([]TaskStatusMapping, 0)
+	_ = json.Unmarshal(l.StatusMapping, &mappings)
+	return mappings
+}
+
+func (l *ProjectExternalLink) PriorityMappings() map[string]string {
+	mappings := make(map[string]string)
+	_ = json.Unmarshal(l.PriorityMapping, &mappings)
+	return mappings
+}
+
+func (l *ProjectExternalLink) AssigneeMappings() []TaskAssigneeMapping {
+	mappings := make([]TaskAssigneeMapping, 0)
+	_ = json.Unmarshal(l.AssigneeMapping, &mappings)
+	return mappings
+}
+
+// HookColumnID 接收 webhook 创建任务的目标列，保存为唯一的一条列映射
+func (l *ProjectExternalLink) HookColumnID() int64 {
+	mappings := l.StatusMappings()
+	if len(mappings) == 0 {
+		return 0
+	}
+	return mappings[0].ColumnID
+}
+
+func (l *ProjectExternalLink) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                   strconv.FormatInt(l.ID, 10),
+		"project_id":           strconv.FormatInt(l.ProjectID, 10),
+		"provider":             l.Provider,
+		"external_project_id":  l.ExternalProjectID,
+		"external_project_key": l.ExternalProjectKey,
+		"member_id":            strconv.FormatInt(l.MemberID, 10),
+		"direction":            l.Direction,
+		"status_mapping":       l.StatusMappings(),
+		"priority_mapping":     l.PriorityMappings(),
+		"assignee_mapping":     l.AssigneeMappings(),
+		"is_active":            l.IsActive,
+		"last_status":          l.LastStatus,
+		"last_error":           l.LastError,
+		"last_synced_at":       l.LastSyncedAt,
+		"created_at":           l.CreatedAt,
+		"updated_at":           l.UpdatedAt,
+	}
+}
+
+// TaskExternalLink 任务与外部问题的对应关系
+type TaskExternalLink struct {
+	ProjectLinkID int64          `gorm:"not null; uniqueIndex:idx_link_task; uniqueIndex:idx_link_issue"`
+	TaskID        int64          `gorm:"not null; uniqueIndex:idx_link_task"`
+	IssueID       string         `gorm:"type:varchar(64); not null; uniqueIndex:idx_link_issue"`
+	IssueKey      string         `gorm:"type:varchar(64); not null"`
+	BaseSnapshot  datatypes.JSON `gorm:"type:jsonb"` // TaskIssueSnapshot
+	LastSyncedAt  *time.Time
+	BaseModel
+}
+
+// TaskIssueSnapshot 上次同步后两侧一致的字段值（按外部平台的取值记录），用于逐字段三方比对
+type TaskIssueSnapshot struct {
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	Priority    string `json:"priority"`
+	Assignee    string `json:"assignee"`
+	Status      string `json:"status"`
+}
+
+func (l *TaskExternalLink) Snapshot() *TaskIssueSnapshot {
+	if len(l.BaseSnapshot) == 0 {
+		return nil
+	}
+	var snapshot TaskIssueSnapshot
+	if err := json.Unmarshal(l.BaseSnapshot, &snapshot); err != nil {
+		return nil
+	}
+	return &snapshot
+}
+
+// TaskCommentExternalLink 任务评论与外部评论的对应关系，两侧内容的指纹用于判断哪一侧被修改
+type TaskCommentExternalLink struct {
+	TaskLinkID        int64  `gorm:"not null; uniqueIndex:idx_task_link_comment; uniqueIndex:idx_task_link_ext_comment"`
+	CommentID         int64  `gorm:"not null; uniqueIndex:idx_task_link_comment"`
+	ExternalCommentID string `gorm:"type:varchar(64); not null; uniqueIndex:idx_task_link_ext_comment"`
+	LocalHash         string `gorm:"type:varchar(64); not null"`
+	RemoteHash        string `gorm:"type:varchar(64); not null"`
+	BaseModel
+}
+
+// TaskExternalReference 任务在外部提交、PR 或 issue 中被引用的记录，PR 合并时据此找到关联任务
+type TaskExternalReference struct {
+	ProjectLinkID int64                `gorm:"not null; uniqueIndex:idx_link_task_ref"`
+	TaskID        int64                `gorm:"not null; index; uniqueIndex:idx_link_task_ref"`
+	Kind          ExternalResourceType `gorm:"type:varchar(32); not null; uniqueIndex:idx_link_task_ref"`
+	ExternalID    string               `gorm:"type:varchar(64); not null; uniqueIndex:idx_link_task_ref"` // PR/issue 编号或提交 SHA
+	Title         string               `gorm:"type:varchar(255); not null; default:''"`
+	URL           string               `gorm:"type:varchar(512); not null; default:''"`
+	State         string               `gorm:"type:varchar(16); not null; default:''"`
+	BaseModel
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strconv"
 	"time"
 
 	"gorm.io/datatypes"
@@ -27,8 +28,27 @@ type SyncOutbox struct {
 	CreatedAt   time.Time      `gorm:"type:timestamptz;not null;default:now()"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime" time_format:"2006-01-02"`
 	LastError   *string        `gorm:"type:text"`
+	Attempts    int            `gorm:"not null;default:0"` // 已失败次数
+	NextRetryAt *time.Time     `gorm:"index"`              // 退避到期前不会被取出执行
 }
 
 func (SyncOutbox) TableName() string {
 	return "sync_outbox"
 }
+
+func (o *SyncOutbox) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":            strconv.FormatInt(o.ID, 10),
+		"link_id":       strconv.FormatInt(o.LinkID, 10),
+		"note_id":       strconv.FormatInt(o.NoteID, 10),
+		"note_version":  o.NoteVersion,
+		"op_type":       o.OpType,
+		"patch":         o.PatchJSON,
+		"status":        o.Status,
+		"attempts":      o.Attempts,
+		"next_retry_at": o.NextRetryAt,
+		"last_error":    o.LastError,
+		"created_at":    o.CreatedAt,
+		"updated_at":    o.UpdatedAt,
+	}
+}
@@ -13,6 +13,8 @@ const (
 	ProviderNotion IntegrationProvider = "notion"
 	ProviderJira   IntegrationProvider = "jira"
 	ProviderFeishu IntegrationProvider = "feishu"
+	ProviderGitHub IntegrationProvider = "github"
+	ProviderHook   IntegrationProvider = "webhook" // 项目的接收 webhook，外部系统推送创建任务
 	// ...后续扩展
 )
 
@@ -22,6 +24,8 @@ const (
 	ExtTypePage     ExternalResourceType = "page"
 	ExtTypeDatabase ExternalResourceType = "database"
 	ExtTypeIssue    ExternalResourceType = "issue"
+	ExtTypePull     ExternalResourceType = "pull_request"
+	ExtTypeCommit   ExternalResourceType = "commit"
 	// ...
 )
 
@@ -42,6 +46,7 @@ const (
 	SyncSuccess SyncStatus = "success"
 	SyncFailed  SyncStatus = "failed"
 	SyncSkipped SyncStatus = "skipped"
+	SyncDead    SyncStatus = "dead" // 超过重试上限，进入死信，等待人工重试或跳过
 )
 
 type SyncMode string
@@ -54,8 +59,26 @@ const (
 type ConflictPolicy string
 
 const (
-	ConflictLatest ConflictPolicy = "latest" // 以最新修改时间为准
-	// ...
+	ConflictLocalWins  ConflictPolicy = "local_wins"  // 以本地为准
+	ConflictRemoteWins ConflictPolicy = "remote_wins" // 以外部文档为准
+	ConflictLatest     ConflictPolicy = "latest"      // 以最新修改时间为准
+	ConflictManual     ConflictPolicy = "manual"      // 记录冲突，等待人工处理
+)
+
+type ConflictStatus string
+
+const (
+	ConflictOpen     ConflictStatus = "open"
+	ConflictResolved ConflictStatus = "resolved"
+)
+
+// ConflictResolution 人工处理冲突时选择的版本
+type ConflictResolution string
+
+const (
+	ResolveLocal  ConflictResolution = "local"
+	ResolveRemote ConflictResolution = "remote"
+	ResolveMerged ConflictResolution = "merged"
 )
 
 type InitStatus string
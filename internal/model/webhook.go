@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookNoteCreated  WebhookEvent = "note.created"
+	WebhookNoteUpdated  WebhookEvent = "note.updated"
+	WebhookNoteDeleted  WebhookEvent = "note.deleted"
+	WebhookTaskCreated  WebhookEvent = "task.created"
+	WebhookTaskMoved    WebhookEvent = "task.moved"
+	WebhookTaskAssigned WebhookEvent = "task.assigned"
+	WebhookCommentAdded WebhookEvent = "comment.added"
+	WebhookEventCreated WebhookEvent = "event.created"
+	WebhookPing         WebhookEvent = "ping" // 测试事件，只发给指定的 webhook
+)
+
+// WebhookEvents 可订阅的事件
+var WebhookEvents = []WebhookEvent{
+	WebhookNoteCreated, WebhookNoteUpdated, WebhookNoteDeleted,
+	WebhookTaskCreated, WebhookTaskMoved, WebhookTaskAssigned,
+	WebhookCommentAdded, WebhookEventCreated,
+}
+
+func IsWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if string(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription 工作区配置的外发 webhook；Events 为空表示订阅全部事件，
+// ProjectIDs 为空表示不按项目过滤（与项目无关的事件如笔记总是投递）
+type WebhookSubscription struct {
+	WorkspaceID int64          `gorm:"not null; index"`
+	Name        string         `gorm:"type:varchar(128); not null"`
+	URL         string         `gorm:"type:varchar(1024); not null"`
+	Secret      string         `gorm:"type:text; not null"`                        // 签名密钥，加密保存
+	SecretKID   *string        `gorm:"column:secret_kid; type:varchar(64); index"` // 加密 Secret 所用的数据密钥
+	Events      datatypes.JSON `gorm:"type:jsonb; not null; default:'[]'::jsonb"`  // []WebhookEvent
+	ProjectIDs  datatypes.JSON `gorm:"type:jsonb; not null; default:'[]'::jsonb"`  // []int64
+	CreatorID   int64          `gorm:"not null"`                                   // 工作区成员 ID
+
+	IsActive       bool                  `gorm:"not null; default:true; index"`
+	LastStatus     WebhookDeliveryStatus `gorm:"type:varchar(16)"`
+	LastDeliveryAt *time.Time
+	BaseModel
+}
+
+func (w *WebhookSubscription) EventList() []WebhookEvent {
+	events := make([]WebhookEvent, 0)
+	_ = json.Unmarshal(w.Events, &events)
+	return events
+}
+
+func (w *WebhookSubscription) ProjectIDList() []int64 {
+	ids := make([]int64, 0)
+	_ = json.Unmarshal(w.ProjectIDs, &ids)
+	return ids
+}
+
+// Matches 事件类型与项目是否命中订阅；projectID 为 0 表示事件与项目无关
+func (w *WebhookSubscription) Matches(event WebhookEvent, projectID int64) bool {
+	if events := w.EventList(); len(events) > 0 {
+		found := false
+		for _, e := range events {
+			if e == event {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if projectID == 0 {
+		return true
+	}
+	projects := w.ProjectIDList()
+	if len(projects) == 0 {
+		return true
+	}
+	for _, id := range projects {
+		if id == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WebhookSubscription) Data() map[string]interface{} {
+	projectIDs := make([]string, 0)
+	for _, id := range w.ProjectIDList() {
+		projectIDs = append(projectIDs, strconv.FormatInt(id, 10))
+	}
+	return map[string]interface{}{
+		"id":               strconv.FormatInt(w.ID, 10),
+		"workspace_id":     strconv.FormatInt(w.WorkspaceID, 10),
+		"name":             w.Name,
+		"url":              w.URL,
+		"events":           w.EventList(),
+		"project_ids":      projectIDs,
+		"is_active":        w.IsActive,
+		"last_status":      w.LastStatus,
+		"last_delivery_at": w.LastDeliveryAt,
+		"created_at":       w.CreatedAt,
+		"updated_at":       w.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending WebhookDeliveryStatus = "pending"
+	DeliverySuccess WebhookDeliveryStatus = "success"
+	DeliveryFailed  WebhookDeliveryStatus = "failed" // 重试耗尽
+)
+
+// WebhookDelivery 投递日志，每次尝试覆盖最近一次的响应
+type WebhookDelivery struct {
+	SubscriptionID int64                 `gorm:"not null; index"`
+	EventID        string                `gorm:"type:varchar(64); not null; index"`
+	Event          WebhookEvent          `gorm:"type:varchar(32); not null"`
+	Payload        datatypes.JSON        `gorm:"type:jsonb; not null"`
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(16); not null; default:'pending'; index"`
+	Attempts       int                   `gorm:"not null; default:0"`
+	ResponseStatus int
+	ResponseBody   *string `gorm:"type:text"` // 截断保存
+	Error          *string `gorm:"type:text"`
+	DurationMs     int64
+	DeliveredAt    *time.Time
+	BaseModel
+}
+
+func (d *WebhookDelivery) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"id":              strconv.FormatInt(d.ID, 10),
+		"subscription_id": strconv.FormatInt(d.SubscriptionID, 10),
+		"event_id":        d.EventID,
+		"event":           d.Event,
+		"payload":         d.Payload,
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"response_status": d.ResponseStatus,
+		"response_body":   d.ResponseBody,
+		"error":           d.Error,
+		"duration_ms":     d.DurationMs,
+		"delivered_at":    d.DeliveredAt,
+		"created_at":      d.CreatedAt,
+	}
+}
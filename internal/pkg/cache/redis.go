@@ -26,6 +26,12 @@ var (
 	IntentListKey     = "ai:prompt:intents"
 	PromptPrefix      = "ai:prompt:"
 	GithubRepoDataKey = "github:data"
+	NoteViewQueueKey  = "note:view:queue"
+	NoteViewSeenKey   = "note:view:seen:%d:%d:%s" // note_id:member_id:session_id
+	NoteLockKey       = "note:lock:%d"
+	ShareAttemptKey   = "note:share:attempt:%d" // share_id
+
+	FeishuDeadlineSentKey = "feishu:deadline:sent:%d:%s" // task_id:deadline
 )
 
 type RedisClient struct {
@@ -143,6 +149,96 @@ func (r *RedisClient) GetNoteCategoryMap(CategoryID int64) (string, error) {
 	return val, nil
 }
 
+func (r *RedisClient) DelNoteCategoryMap(ctx context.Context, categoryIDs ...int64) error {
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	fields := make([]string, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		fields = append(fields, strconv.FormatInt(id, 10))
+	}
+	if err := r.Client.HDel(ctx, GategoryMapKey, fields...).Err(); err != nil {
+		logger.LogError(err, "failed to delete redis hash field")
+		return err
+	}
+	return nil
+}
+
+// MarkNoteViewed 同一成员同一会话在 ttl 内首次浏览返回 true
+func (r *RedisClient) MarkNoteViewed(ctx context.Context, noteID, memberID int64, sessionID string, ttl time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, fmt.Sprintf(NoteViewSeenKey, noteID, memberID, sessionID), 1, ttl).Result()
+}
+
+// MarkDeadlineNotified 同一任务的同一截止日期只提醒一次，首次标记返回 true
+func (r *RedisClient) MarkDeadlineNotified(ctx context.Context, taskID int64, deadline string, ttl time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, fmt.Sprintf(FeishuDeadlineSentKey, taskID, deadline), 1, ttl).Result()
+}
+
+// IncrShareAttempt 记录一次分享密码错误并返回窗口内的累计次数，首次错误时开始计时
+func (r *RedisClient) IncrShareAttempt(ctx context.Context, shareID int64, window time.Duration) (int64, error) {
+	key := fmt.Sprintf(ShareAttemptKey, shareID)
+	count, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		r.Client.Expire(ctx, key, window)
+	}
+	return count, nil
+}
+
+// GetShareAttempt 窗口内的分享密码错误次数
+func (r *RedisClient) GetShareAttempt(ctx context.Context, shareID int64) (int64, error) {
+	count, err := r.Client.Get(ctx, fmt.Sprintf(ShareAttemptKey, shareID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (r *RedisClient) PushNoteViews(ctx context.Context, events ...string) error {
+	if len(events) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		values = append(values, event)
+	}
+	return r.Client.RPush(ctx, NoteViewQueueKey, values...).Err()
+}
+
+// PopNoteViews 从队首取出最多 count 条浏览事件，队列为空时返回空切片
+func (r *RedisClient) PopNoteViews(ctx context.Context, count int) ([]string, error) {
+	events, err := r.Client.LPopCount(ctx, NoteViewQueueKey, count).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return events, err
+}
+
+// GetNoteLock 读取笔记锁状态，未加锁时返回空字符串
+func (r *RedisClient) GetNoteLock(ctx context.Context, noteID int64) (string, error) {
+	val, err := r.Client.Get(ctx, fmt.Sprintf(NoteLockKey, noteID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return val, err
+}
+
+// SetNoteLock ttl 为 0 时锁不过期
+func (r *RedisClient) SetNoteLock(ctx context.Context, noteID int64, state string, ttl time.Duration) error {
+	return r.Client.Set(ctx, fmt.Sprintf(NoteLockKey, noteID), state, ttl).Err()
+}
+
+func (r *RedisClient) DelNoteLock(ctx context.Context, noteID int64) error {
+	return r.Client.Del(ctx, fmt.Sprintf(NoteLockKey, noteID)).Err()
+}
+
+// NoteLockMutexKey 串行化同一笔记锁状态读写的互斥锁
+func NoteLockMutexKey(noteID int64) string {
+	return fmt.Sprintf(NoteLockKey, noteID) + ":mutex"
+}
+
 func (r *RedisClient) SaveSystemSettings(key map[string]interface{}) error {
 	ctx := context.Background()
 	err := r.Client.HSet(ctx, SystemSettingsKey, key).Err()
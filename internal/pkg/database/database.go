@@ -6,8 +6,10 @@ import (
 	"gin-notebook/configs"
 	"gin-notebook/internal/http/message"
 	"gin-notebook/internal/model"
+	"gin-notebook/pkg/utils/algorithm"
 
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/morikuni/go-lexorank"
 	"gorm.io/gorm"
 )
 
@@ -51,8 +53,12 @@ func Migrate(db *gorm.DB) {
 		&model.NoteExternalLink{},
 		&model.IntegrationAccount{},
 		&model.IntegrationApp{},
+		&model.EncryptionKey{},
+		&model.WebhookSubscription{},
+		&model.WebhookDelivery{},
 		&model.OutboxEvent{},
 		&model.NoteExternalNodeMapping{},
+		&model.NoteSyncConflict{},
 		&model.ScheduledTask{},
 		&model.SyncOutbox{},
 		&model.AiPrompt{},
@@ -61,7 +67,89 @@ func Migrate(db *gorm.DB) {
 		&model.Document{},
 		&model.Chunk{},
 		&model.Outbox{},
+		&model.NoteLink{},
+		&model.NoteShare{},
+		&model.NotePermission{},
+		&model.NoteComment{},
+		&model.NoteCommentMention{},
+		&model.NoteCommentAttachment{},
+		&model.DailyNote{},
+		&model.DailyNoteSetting{},
+		&model.NoteChecklistTask{},
+		&model.NoteViewStat{},
+		&model.NoteViewDaily{},
+		&model.ProjectExternalLink{},
+		&model.TaskExternalLink{},
+		&model.TaskCommentExternalLink{},
+		&model.TaskExternalReference{},
+		&model.FeishuProjectChat{},
 	)
+	if err := backfillCategoryOrderIndex(db); err != nil {
+		fmt.Println("补齐分类排序失败:", err)
+	}
+}
+
+// backfillCategoryOrderIndex 为引入排序字段前创建的分类补齐 LexoRank，
+// 同级内按创建时间依次排在已有排序之后；已有排序的分类不受影响，可重复执行
+func backfillCategoryOrderIndex(db *gorm.DB) error {
+	type row struct {
+		ID          int64
+		WorkspaceID int64
+		ParentID    *int64
+	}
+	var rows []row
+	err := db.Unscoped().Model(&model.NoteCategory{}).
+		Select("id, workspace_id, parent_id").
+		Where("order_index = '' OR order_index IS NULL").
+		Order("workspace_id ASC, parent_id ASC NULLS FIRST, created_at ASC, id ASC").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var prev lexorank.BucketKey
+		for i, r := range rows {
+			if i == 0 || r.WorkspaceID != rows[i-1].WorkspaceID || !sameParent(r.ParentID, rows[i-1].ParentID) {
+				last, err := lastCategoryOrderIndex(tx, r.WorkspaceID, r.ParentID)
+				if err != nil {
+					return err
+				}
+				prev = algorithm.RankMin()
+				if last != "" {
+					prev = lexorank.BucketKey(last)
+				}
+			}
+			prev = algorithm.RankBetweenBucket(prev, algorithm.RankMax())
+			err := tx.Unscoped().Model(&model.NoteCategory{}).Where("id = ?", r.ID).
+				UpdateColumn("order_index", prev.String()).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func lastCategoryOrderIndex(db *gorm.DB, workspaceID int64, parentID *int64) (string, error) {
+	var orderIndex string
+	sql := db.Unscoped().Model(&model.NoteCategory{}).
+		Select("order_index").
+		Where("workspace_id = ? AND order_index <> ''", workspaceID)
+	if parentID == nil {
+		sql = sql.Where("parent_id IS NULL")
+	} else {
+		sql = sql.Where("parent_id = ?", *parentID)
+	}
+	err := sql.Order(`order_index COLLATE "C" DESC, id DESC`).Limit(1).Scan(&orderIndex).Error
+	return orderIndex, err
+}
+
+func sameParent(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
 }
 
 func ConnectDB(c *configs.Config, migrateDB bool) {
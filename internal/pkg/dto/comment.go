@@ -153,3 +153,113 @@ type LikeCommentDTO struct {
 	DislikedByMe bool      `json:"disliked_by_me"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+type NoteCommentMentionDTO struct {
+	MemberID  int64 `json:"member_id,string" validate:"required,gt=0"`
+	StartRune int   `json:"start_rune" validate:"gte=0"`
+	EndRune   int   `json:"end_rune" validate:"gtfield=StartRune"`
+}
+
+type NoteCommentAttachmentDTO struct {
+	FileName      string `json:"name" validate:"required,min=1,max=255"`
+	FileURL       string `json:"url" validate:"required,min=1,max=255"`
+	FileSize      int64  `json:"size" validate:"required,gt=0"`
+	FileType      string `json:"type" validate:"required,min=1,max=50"`
+	ThumbnailPath string `json:"thumbnail_path" validate:"omitempty,max=255"`
+	SHA256Hash    string `json:"sha256_hash" validate:"omitempty,sha256"`
+}
+
+// CreateNoteCommentDTO 新建根评论需要 block_id；回复只需 parent_id，锚点沿用根评论
+type CreateNoteCommentDTO struct {
+	WorkspaceID int64                      `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64                      `json:"note_id,string" validate:"required,gt=0"`
+	ParentID    int64                      `json:"parent_id,string" validate:"omitempty,gt=0"`
+	BlockID     string                     `json:"block_id" validate:"required_without=ParentID,omitempty,max=64"`
+	AnchorStart int                        `json:"anchor_start" validate:"gte=0"`
+	AnchorEnd   int                        `json:"anchor_end" validate:"gtefield=AnchorStart"`
+	Content     string                     `json:"content" validate:"required,min=1,max=1000"`
+	Mentions    []NoteCommentMentionDTO    `json:"mentions" validate:"omitempty,dive"`
+	Attachments []NoteCommentAttachmentDTO `json:"attachments" validate:"omitempty,max=10,dive"`
+	UserID      int64                      `json:"-"`
+	MemberID    int64                      `json:"-"`
+}
+
+type GetNoteCommentsDTO struct {
+	WorkspaceID     int64  `form:"workspace_id,string" validate:"required,gt=0"`
+	NoteID          int64  `form:"note_id,string" validate:"required,gt=0"`
+	BlockID         string `form:"block_id" validate:"omitempty,max=64"`
+	Status          string `form:"status" validate:"omitempty,oneof=open resolved"`
+	IncludeDetached bool   `form:"include_detached"`
+	UserID          int64  `form:"-"`
+	MemberID        int64  `form:"-"`
+}
+
+type UpdateNoteCommentDTO struct {
+	WorkspaceID int64                   `json:"workspace_id,string" validate:"required,gt=0"`
+	CommentID   int64                   `json:"comment_id,string" validate:"required,gt=0"`
+	Content     string                  `json:"content" validate:"required,min=1,max=1000"`
+	Mentions    []NoteCommentMentionDTO `json:"mentions" validate:"omitempty,dive"`
+	UserID      int64                   `json:"-"`
+	MemberID    int64                   `json:"-"`
+}
+
+type DeleteNoteCommentDTO struct {
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	CommentID   int64 `json:"comment_id,string" validate:"required,gt=0"`
+	UserID      int64 `json:"-"`
+	MemberID    int64 `json:"-"`
+}
+
+type ResolveNoteCommentDTO struct {
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	CommentID   int64 `json:"comment_id,string" validate:"required,gt=0"`
+	Resolved    bool  `json:"resolved"` // true 解决，false 重新打开
+	UserID      int64 `json:"-"`
+	MemberID    int64 `json:"-"`
+}
+
+// NoteCommentThread 根评论及其回复
+type NoteCommentThread struct {
+	NoteCommentItem
+	Replies []NoteCommentItem `json:"replies"`
+}
+
+type NoteCommentItem struct {
+	ID             int64                       `json:"id,string"`
+	NoteID         int64                       `json:"note_id,string"`
+	ParentID       int64                       `json:"parent_id,string"`
+	BlockID        string                      `json:"block_id"`
+	AnchorStart    int                         `json:"anchor_start"`
+	AnchorEnd      int                         `json:"anchor_end"`
+	AnchorText     string                      `json:"anchor_text"`
+	AnchorParentID string                      `json:"anchor_parent_id"`
+	AnchorState    string                      `json:"anchor_state"`
+	Content        string                      `json:"content"`
+	ReplyCount     int64                       `json:"reply_count"`
+	Status         string                      `json:"status"`
+	ResolvedBy     *int64                      `json:"resolved_by,string"`
+	ResolvedAt     *time.Time                  `json:"resolved_at"`
+	CreatedAt      time.Time                   `json:"created_at"`
+	UpdatedAt      time.Time                   `json:"updated_at"`
+	IsAuthor       bool                        `json:"is_author"`
+	Author         *WorkspaceMemberDTO         `json:"author,omitempty"`
+	GuestName      string                      `json:"guest_name,omitempty"` // 访客评论的署名
+	Mentions       []NoteCommentMentionResp    `json:"mentions"`
+	Attachments    []NoteCommentAttachmentResp `json:"attachments"`
+}
+
+type NoteCommentMentionResp struct {
+	MemberID  int64               `json:"member_id,string"`
+	StartRune int                 `json:"start_rune"`
+	EndRune   int                 `json:"end_rune"`
+	Member    *WorkspaceMemberDTO `json:"member,omitempty"`
+}
+
+type NoteCommentAttachmentResp struct {
+	ID            int64  `json:"id,string"`
+	FileName      string `json:"name"`
+	FileURL       string `json:"url"`
+	FileSize      int64  `json:"size"`
+	FileType      string `json:"type"`
+	ThumbnailPath string `json:"thumbnail_path"`
+}
@@ -19,11 +19,15 @@ type ListResultDTO[T any] struct {
 type StringArray []string
 
 func (a *StringArray) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		// sqlite 等驱动把 json 列按文本返回
+		return json.Unmarshal([]byte(v), a)
+	default:
 		return fmt.Errorf("StringArray: failed to assert value to []byte")
 	}
-	return json.Unmarshal(bytes, a)
 }
 
 func (a StringArray) Value() (driver.Value, error) {
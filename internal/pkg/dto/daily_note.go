@@ -0,0 +1,133 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DailyNoteDateLayout     = "2006-01-02"
+	DailyNoteMonthLayout    = "2006-01"
+	DefaultDailyNoteTZ      = "Asia/Shanghai"
+	DailyNoteCategoryName   = "每日笔记"
+	DailyNoteAgendaHeadline = "今日任务与日程"
+)
+
+// GetDailyNoteDTO 获取（必要时创建）某一天的每日笔记
+type GetDailyNoteDTO struct {
+	WorkspaceID int64  `form:"-"`
+	UserID      int64  `form:"-"`
+	MemberID    int64  `form:"-"`
+	Date        string `form:"date" validate:"omitempty,datetime=2006-01-02"` // 为空时取用户时区的今天
+}
+
+// DailyNoteCalendarDTO 按月查询每日笔记索引
+type DailyNoteCalendarDTO struct {
+	WorkspaceID int64  `form:"-"`
+	UserID      int64  `form:"-"`
+	Month       string `form:"month" validate:"omitempty,datetime=2006-01"` // 为空时取用户时区的本月
+}
+
+type DailyNoteSettingDTO struct {
+	TemplateID    *int64 `json:"template_id,string"`
+	CategoryID    *int64 `json:"category_id,string"`
+	Timezone      string `json:"timezone"`
+	IncludeAgenda bool   `json:"include_agenda"`
+}
+
+type UpdateDailyNoteSettingDTO struct {
+	WorkspaceID   int64   `json:"-"`
+	UserID        int64   `json:"-"`
+	TemplateID    *int64  `json:"template_id,string" validate:"omitempty,gte=0"` // 传 0 表示恢复内置模板
+	Timezone      *string `json:"timezone" validate:"omitempty,timezone"`
+	IncludeAgenda *bool   `json:"include_agenda"`
+}
+
+// DailyNoteIndexItem 日历索引中的一天
+type DailyNoteIndexItem struct {
+	Date      string    `json:"date"`
+	NoteID    int64     `json:"note_id,string"`
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DailyAgendaTaskDTO 当天到期的任务
+type DailyAgendaTaskDTO struct {
+	ID          int64  `json:"id,string"`
+	Title       string `json:"title"`
+	ProjectID   int64  `json:"project_id,string"`
+	ProjectName string `json:"project_name"`
+	Status      string `json:"status"`
+	Priority    uint8  `json:"priority"`
+}
+
+type DailyAgendaDTO struct {
+	Tasks  []DailyAgendaTaskDTO `json:"tasks"`
+	Events []EventDTO           `json:"events"`
+}
+
+// DefaultDailyNoteBlocks 未配置模板时使用的内置内容
+func DefaultDailyNoteBlocks() Blocks {
+	return Blocks{
+		dailyHeading(2, "{{date}} {{weekday}}"),
+		dailyParagraph(""),
+	}
+}
+
+// DailyAgendaBlocks 把当天任务与日程渲染为一段生成内容；任务以 task:// 链接嵌入，保存后会建立反向链接
+func DailyAgendaBlocks(agenda DailyAgendaDTO, loc *time.Location) Blocks {
+	blocks := Blocks{dailyHeading(3, DailyNoteAgendaHeadline)}
+
+	for _, task := range agenda.Tasks {
+		done := task.Status == "completed"
+		blocks = append(blocks, NoteBlockDTO{
+			ID:    uuid.NewString(),
+			Type:  "checkListItem",
+			Props: BlockPropsDTO{Checked: &done},
+			Content: []InlineDTO{{
+				Type:    "link",
+				Href:    fmt.Sprintf("task://%d", task.ID),
+				Content: []InlineDTO{{Type: "text", Text: task.Title}},
+			}},
+		})
+	}
+
+	for _, event := range agenda.Events {
+		text := event.Title
+		if event.AllDay == nil || !*event.AllDay {
+			text = event.Start.In(loc).Format("15:04") + "-" + event.End.In(loc).Format("15:04") + " " + text
+		}
+		if event.Location != "" {
+			text += " @" + event.Location
+		}
+		blocks = append(blocks, NoteBlockDTO{
+			ID:      uuid.NewString(),
+			Type:    "bulletListItem",
+			Content: []InlineDTO{{Type: "text", Text: text}},
+		})
+	}
+
+	if len(agenda.Tasks) == 0 && len(agenda.Events) == 0 {
+		blocks = append(blocks, dailyParagraph("今天没有到期任务和日程"))
+	}
+	return blocks
+}
+
+func dailyHeading(level int, text string) NoteBlockDTO {
+	return NoteBlockDTO{
+		ID:      uuid.NewString(),
+		Type:    "heading",
+		Props:   BlockPropsDTO{Level: &level},
+		Content: []InlineDTO{{Type: "text", Text: text}},
+	}
+}
+
+func dailyParagraph(text string) NoteBlockDTO {
+	content := []InlineDTO{}
+	if text != "" {
+		content = append(content, InlineDTO{Type: "text", Text: text})
+	}
+	return NoteBlockDTO{ID: uuid.NewString(), Type: "paragraph", Content: content}
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+// CreateFeishuChatDTO 项目的飞书通知群；Events 为空推送全部通知，Name 为空时使用群名称
+type CreateFeishuChatDTO struct {
+	WorkspaceID int64    `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID   int64    `validate:"required,gt=0"`
+	ChatID      string   `json:"chat_id" validate:"required,max=64"`
+	Name        string   `json:"name" validate:"omitempty,max=128"`
+	Events      []string `json:"events" validate:"omitempty,max=10,dive,required"`
+	MemberID    int64    `validate:"required,gt=0"`
+	UserID      int64    `validate:"required,gt=0"`
+}
+
+// UpdateFeishuChatDTO 只修改提供的字段，Events 整体替换
+type UpdateFeishuChatDTO struct {
+	WorkspaceID int64     `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID   int64     `validate:"required,gt=0"`
+	LinkID      int64     `validate:"required,gt=0"`
+	Name        *string   `json:"name" validate:"omitempty,min=1,max=128"`
+	Events      *[]string `json:"events" validate:"omitempty,max=10,dive,required"`
+	IsActive    *bool     `json:"is_active" validate:"omitempty"`
+	UserID      int64     `validate:"required,gt=0"`
+}
+
+// FeishuTaskContextDTO 通知卡片展示的任务信息
+type FeishuTaskContextDTO struct {
+	ID          int64
+	Title       string
+	Priority    uint8
+	Deadline    *time.Time
+	ProjectID   int64
+	ProjectName string
+	WorkspaceID int64
+	ColumnID    int64
+	ColumnName  string
+}
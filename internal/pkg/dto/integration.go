@@ -36,6 +36,36 @@ type FeishuOAuthCallbackDTO struct {
 	UserID int64   `validate:"required"`
 }
 
+// FeishuEventDTO 飞书事件回调；签名相关字段来自请求头，Body 为原始请求体
+type FeishuEventDTO struct {
+	Timestamp string `header:"X-Lark-Request-Timestamp"`
+	Nonce     string `header:"X-Lark-Request-Nonce"`
+	Signature string `header:"X-Lark-Signature"`
+	Body      []byte `validate:"required"`
+}
+
+// NotionTokenLinkDTO 使用内部集成令牌绑定 Notion
+type NotionTokenLinkDTO struct {
+	Token  string `json:"token" validate:"required"`
+	UserID int64  `validate:"required"`
+}
+
+// JiraTokenLinkDTO Jira Cloud 填写邮箱与 API token；Data Center 留空邮箱，使用个人访问令牌
+type JiraTokenLinkDTO struct {
+	SiteURL string `json:"site_url" validate:"required,url"`
+	Email   string `json:"email" validate:"omitempty,email"`
+	Token   string `json:"token" validate:"required"`
+	UserID  int64  `validate:"required"`
+}
+
+type NotionOAuthCallbackDTO struct {
+	Code        string  `form:"code" validate:"required"`
+	State       string  `form:"state" validate:"omitempty"`
+	Origin      *string `form:"origin" validate:"omitempty,url"`
+	RedirectURI string  `form:"redirect_uri" validate:"omitempty,url"`
+	UserID      int64   `validate:"required"`
+}
+
 type FeishuUserAccessTokenResponse struct {
 	AccessToken      string `json:"access_token"`
 	ExpiresIn        int64  `json:"expires_in"`
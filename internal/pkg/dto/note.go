@@ -29,6 +29,7 @@ type BlockPropsDTO struct {
 	Name            *string `json:"name,omitempty"`         // 仅 image 生效
 	ShowPreview     *bool   `json:"showPreview,omitempty"`  // 仅 link_preview 生效
 	Url             *string `json:"url,omitempty"`          // 仅 link_preview 生效
+	Checked         *bool   `json:"checked,omitempty"`      // 仅 checkListItem 生效
 }
 
 func (p *BlockPropsDTO) Update(data *BlockPropsDTO) {
@@ -50,9 +51,12 @@ func (p *BlockPropsDTO) Update(data *BlockPropsDTO) {
 }
 
 type InlineDTO struct {
-	Type   string          `json:"type"`
-	Text   string          `json:"text"`
-	Styles InlineStylesDTO `json:"styles"`
+	Type    string                 `json:"type"`
+	Text    string                 `json:"text"`
+	Styles  InlineStylesDTO        `json:"styles"`
+	Href    string                 `json:"href,omitempty"`    // 仅 link 生效
+	Content []InlineDTO            `json:"content,omitempty"` // 仅 link 生效，链接内的文本 runs
+	Props   map[string]interface{} `json:"props,omitempty"`   // 自定义 inline（如笔记/任务提及）
 }
 
 type InlineStylesDTO struct {
@@ -111,12 +115,14 @@ type WorkspaceNoteDTO struct {
 	CreatedAt    time.Time `json:"created_at" time_format:"2006-01-02"`
 	UpdatedAt    time.Time `json:"updated_at" time_format:"2006-01-02"`
 	CategoryName string    `json:"category_name"`
-	Cover        *string   `json:"cover"` // 笔记封面
+	Cover        *string   `json:"cover"`                   // 笔记封面
+	NotionPageID *string   `json:"notion_page_id" gorm:"-"` // 已绑定的 Notion 页面
 }
 
 type WorkspaceUpdateNoteCategoryDTO struct {
 	ID           int64  `json:"id,string"`
 	CategoryName string `json:"category_name"`
+	ParentID     *int64 `json:"parent_id,string"`
 	Total        int64  `json:"total"`
 }
 
@@ -205,12 +211,14 @@ type CreateNoteCategoryDTO struct {
 	BaseDto
 	CategoryName string `json:"category_name" validate:"required,min=1,max=20"`
 	WorkspaceID  int64  `json:"workspace_id,string" validate:"required"`
+	ParentID     *int64 `json:"parent_id,string" validate:"omitempty,gt=0"`
 }
 
 func (v *CreateNoteCategoryDTO) ToModel() *model.NoteCategory {
 	return &model.NoteCategory{
 		WorkspaceID:  v.WorkspaceID,
 		CategoryName: v.CategoryName,
+		ParentID:     v.ParentID,
 	}
 }
 
@@ -228,6 +236,47 @@ type NoteCategoryQueryDTO struct {
 	CategoryName string `form:"kw" validate:"omitempty,min=1,max=20"`
 }
 
+type NoteCategoryTreeQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	UserID      int64 `json:"-"`
+}
+
+// NoteCategoryTreeNode 分类树节点，Total 为当前分类下（不含子分类）的笔记数
+type NoteCategoryTreeNode struct {
+	ID           int64                   `json:"id,string"`
+	CategoryName string                  `json:"category_name"`
+	ParentID     *int64                  `json:"parent_id,string"`
+	OrderIndex   string                  `json:"order_index"`
+	Total        int64                   `json:"total"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+	Children     []*NoteCategoryTreeNode `json:"children" gorm:"-"`
+}
+
+type MoveNoteCategoryDTO struct {
+	ID          int64  `json:"id,string" validate:"required,gt=0"`
+	WorkspaceID int64  `json:"workspace_id,string" validate:"required,gt=0"`
+	ParentID    *int64 `json:"parent_id,string" validate:"omitempty,gt=0"` // 目标父级，为空则移动到根目录
+	AfterID     *int64 `json:"after_id,string" validate:"omitempty,gt=0"`  // 放在该分类之后
+	BeforeID    *int64 `json:"before_id,string" validate:"omitempty,gt=0"` // 放在该分类之前
+	UserID      int64  `json:"-"`
+}
+
+type CategoryDeleteMode string
+
+const (
+	CategoryDeleteMoveContents CategoryDeleteMode = "move"    // 子分类与笔记移动到目标分类
+	CategoryDeleteCascade      CategoryDeleteMode = "cascade" // 子分类与笔记一并移入回收站
+)
+
+type DeleteCategoryTreeDTO struct {
+	ID          int64              `json:"id,string" validate:"required,gt=0"`
+	WorkspaceID int64              `json:"workspace_id,string" validate:"required,gt=0"`
+	Mode        CategoryDeleteMode `json:"mode" validate:"required,oneof=move cascade"`
+	TargetID    *int64             `json:"target_id,string" validate:"required_if=Mode move,omitempty,gt=0"` // move 模式下内容的去向
+	UserID      int64              `json:"-"`
+}
+
 type FavoriteNoteDTO struct {
 	NoteID     int64 `json:"note_id,string" validate:"required,gt=0"`
 	UserID     int64 `json:"user_id,string" validate:"required,gt=0"`
@@ -262,30 +311,87 @@ type FavoriteNoteListDTO struct {
 }
 
 type CreateTemplateNoteDTO struct {
-	OwnerID  int64   `validate:"required,gt=0"`
-	Content  Blocks  `json:"content" validate:"required"`
-	Title    string  `json:"title" validate:"required,min=1,max=100"`
-	IsPublic *bool   `json:"is_public" validate:"omitempty"`
-	Cover    *string `json:"cover" validate:"omitempty,url"`
+	OwnerID     int64                 `validate:"required,gt=0"`
+	WorkspaceID int64                 `json:"-"`
+	Content     Blocks                `json:"content" validate:"required"`
+	Title       string                `json:"title" validate:"required,min=1,max=100"`
+	IsPublic    *bool                 `json:"is_public" validate:"omitempty"`
+	Cover       *string               `json:"cover" validate:"omitempty,url"`
+	Description string                `json:"description" validate:"omitempty,max=255"`
+	Variables   []TemplateVariableDTO `json:"variables" validate:"omitempty,max=20,dive"`
 }
 
 type TemplateNote struct {
-	ID        int64        `json:"id,string"`
-	User      UserBreifDTO `json:"user"`
-	Content   Blocks       `json:"content"`
-	Title     string       `json:"title"`
-	IsPublic  *bool        `json:"is_public"`
-	Cover     *string      `json:"cover"`
-	CreatedAt time.Time    `json:"created_at" time_format:"2006-01-02"`
-	UpdatedAt time.Time    `json:"updated_at" time_format:"2006-01-02"`
-}
+	ID           int64                 `json:"id,string"`
+	User         UserBreifDTO          `json:"user"`
+	Content      Blocks                `json:"content"`
+	Title        string                `json:"title"`
+	IsPublic     *bool                 `json:"is_public"`
+	Cover        *string               `json:"cover"`
+	WorkspaceID  *int64                `json:"workspace_id,string"`
+	Description  string                `json:"description"`
+	Variables    []TemplateVariableDTO `json:"variables"`
+	Placeholders []string              `json:"placeholders"` // 内容中出现的全部占位符，含内置占位符
+	UsageCount   int64                 `json:"usage_count"`
+	CreatedAt    time.Time             `json:"created_at" time_format:"2006-01-02"`
+	UpdatedAt    time.Time             `json:"updated_at" time_format:"2006-01-02"`
+}
+
+type TemplateScope string
+
+const (
+	TemplateScopeMine      TemplateScope = "mine"      // 我创建的模板
+	TemplateScopeWorkspace TemplateScope = "workspace" // 工作区共享模板库
+)
 
 type GetTemplateNotesDTO struct {
-	UserID   int64   `validate:"required,gt=0"`
-	Keywords *string `form:"keywords" validate:"omitempty,min=1,max=100"`
-	OrderBy  *string `form:"order_by" validate:"omitempty,oneof=created_at updated_at title"`
-	Limit    *int    `form:"limit" validate:"omitempty,gt=0,lt=20"`
-	Offset   int     `form:"offset" validate:"omitempty,gte=0"`
+	UserID      int64         `validate:"required,gt=0"`
+	WorkspaceID int64         `form:"-"`
+	Scope       TemplateScope `form:"scope" validate:"omitempty,oneof=mine workspace"`
+	Keywords    *string       `form:"keywords" validate:"omitempty,min=1,max=100"`
+	OrderBy     *string       `form:"order_by" validate:"omitempty,oneof=created_at updated_at title"`
+	Limit       *int          `form:"limit" validate:"omitempty,gt=0,lt=20"`
+	Offset      int           `form:"offset" validate:"omitempty,gte=0"`
+}
+
+type UpdateTemplateNoteDTO struct {
+	WorkspaceID int64                  `json:"workspace_id,string" validate:"required,gt=0"`
+	TemplateID  int64                  `json:"id,string" validate:"required,gt=0"`
+	Title       *string                `json:"title" validate:"omitempty,min=1,max=100"`
+	Content     *Blocks                `json:"content" validate:"omitempty"`
+	IsPublic    *bool                  `json:"is_public" validate:"omitempty"`
+	Cover       *string                `json:"cover" validate:"omitempty,url"`
+	Description *string                `json:"description" validate:"omitempty,max=255"`
+	Variables   *[]TemplateVariableDTO `json:"variables" validate:"omitempty,max=20,dive"`
+	UserID      int64                  `json:"-"`
+}
+
+type DeleteTemplateNoteDTO struct {
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	TemplateID  int64 `json:"id,string" validate:"required,gt=0"`
+	UserID      int64 `json:"-"`
+}
+
+// SaveNoteAsTemplateDTO 把现有笔记另存为模板，未传标题时沿用笔记标题
+type SaveNoteAsTemplateDTO struct {
+	WorkspaceID int64                 `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64                 `json:"note_id,string" validate:"required,gt=0"`
+	Title       *string               `json:"title" validate:"omitempty,min=1,max=100"`
+	Description string                `json:"description" validate:"omitempty,max=255"`
+	IsPublic    *bool                 `json:"is_public" validate:"omitempty"`
+	Variables   []TemplateVariableDTO `json:"variables" validate:"omitempty,max=20,dive"`
+	UserID      int64                 `json:"-"`
+}
+
+// InstantiateTemplateDTO 用模板在指定分类下创建笔记；Variables 为自定义变量取值
+type InstantiateTemplateDTO struct {
+	WorkspaceID int64             `json:"workspace_id,string" validate:"required,gt=0"`
+	TemplateID  int64             `json:"template_id,string" validate:"required,gt=0"`
+	CategoryID  int64             `json:"category_id,string" validate:"required,gt=0"`
+	Title       *string           `json:"title" validate:"omitempty,min=1,max=100"`
+	ProjectID   *int64            `json:"project_id,string" validate:"omitempty,gt=0"`
+	Variables   map[string]string `json:"variables" validate:"omitempty,max=20,dive,keys,min=1,max=32,endkeys,max=255"`
+	UserID      int64             `json:"-"`
 }
 
 type AddNoteSyncDTO struct {
@@ -294,7 +400,7 @@ type AddNoteSyncDTO struct {
 	Provider       model.IntegrationProvider `json:"provider" validate:"required,oneof=notion feishu"`
 	Mode           model.SyncMode            `json:"mode" validate:"required,oneof=auto manual"`
 	Direction      model.SyncDirection       `json:"direction" validate:"required,oneof=push pull both"`
-	ConflictPolicy model.ConflictPolicy      `json:"conflict_policy" validate:"required,oneof=latest"`
+	ConflictPolicy model.ConflictPolicy      `json:"conflict_policy" validate:"required,oneof=local_wins remote_wins latest manual"`
 	MemberID       int64                     `validate:"required,gt=0"`
 	TargetNoteID   string                    `json:"target_note_id"`
 	UserID         int64                     `validate:"required,gt=0"`
@@ -315,6 +421,24 @@ type DeleteNoteSyncDTO struct {
 	NoteID      int64 `json:"note_id,string" validate:"required,gt=0"`
 }
 
+type GetNoteSyncConflictsDTO struct {
+	NoteID      int64                 `form:"note_id,string" validate:"required,gt=0"`
+	WorkspaceID int64                 `form:"workspace_id,string" validate:"required,gt=0"`
+	LinkID      *int64                `form:"link_id,string" validate:"omitempty,gt=0"`
+	Status      *model.ConflictStatus `form:"status" validate:"omitempty,oneof=open resolved"`
+	UserID      int64                 `validate:"required,gt=0"`
+}
+
+// ResolveNoteSyncConflictDTO 选择本地、外部版本，或提交合并后的块
+type ResolveNoteSyncConflictDTO struct {
+	ConflictID  int64                    `json:"conflict_id,string" validate:"required,gt=0"`
+	NoteID      int64                    `json:"note_id,string" validate:"required,gt=0"`
+	WorkspaceID int64                    `json:"workspace_id,string" validate:"required,gt=0"`
+	Resolution  model.ConflictResolution `json:"resolution" validate:"required,oneof=local remote merged"`
+	Block       *NoteBlockDTO            `json:"block" validate:"required_if=Resolution merged"`
+	UserID      int64                    `validate:"required,gt=0"`
+}
+
 type PatchRequest struct {
 	Ops         []PatchOp `json:"ops" binding:"required"`
 	BaseVersion *int      `json:"base_version,omitempty"` // 可选：文档版本
@@ -355,3 +479,100 @@ type PartialUpdate struct {
 	Props   *BlockPropsDTO `json:"props,omitempty"`
 	Content *[]InlineDTO   `json:"content,omitempty"`
 }
+
+type NoteBacklinkQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64 `form:"note_id,string" validate:"required,gt=0"`
+	UserID      int64 `form:"-"`
+}
+
+type NoteBacklinkDTO struct {
+	NoteID      int64     `json:"note_id,string"`
+	Title       string    `json:"title"`
+	CategoryID  int64     `json:"category_id,string"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	BlockID     string    `json:"block_id"`
+	TargetTitle string    `json:"target_title"`
+}
+
+type NoteGraphQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	UserID      int64 `form:"-"`
+}
+
+type NoteGraphNodeDTO struct {
+	ID         int64  `json:"id,string"`
+	Title      string `json:"title"`
+	Type       string `json:"type"` // note | task
+	CategoryID *int64 `json:"category_id,string,omitempty"`
+	ProjectID  *int64 `json:"project_id,string,omitempty"`
+}
+
+type NoteGraphEdgeDTO struct {
+	Source     int64  `json:"source,string"`
+	Target     int64  `json:"target,string"`
+	TargetType string `json:"target_type"`
+}
+
+type CreateNoteShareDTO struct {
+	WorkspaceID int64                 `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64                 `json:"note_id,string" validate:"required,gt=0"`
+	MemberID    int64                 `json:"-"`
+	UserID      int64                 `json:"-"`
+	Permission  model.SharePermission `json:"permission" validate:"omitempty,oneof=read comment"`
+	Password    *string               `json:"password" validate:"omitempty,min=4,max=32"`
+	ExpiresAt   *time.Time            `json:"expires_at" validate:"omitempty"`
+}
+
+type GetNoteSharesDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64 `form:"note_id,string" validate:"required,gt=0"`
+	UserID      int64 `json:"-"`
+}
+
+type RevokeNoteShareDTO struct {
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	ShareID     int64 `json:"share_id,string" validate:"required,gt=0"`
+	UserID      int64 `json:"-"`
+}
+
+type GetSharedNoteDTO struct {
+	Token    string `uri:"token" validate:"required,max=64"`
+	Password string `json:"-"`
+}
+
+// CreateSharedNoteCommentDTO 访客通过 comment 权限的分享链接发表评论
+type CreateSharedNoteCommentDTO struct {
+	Token       string `uri:"token" json:"-" validate:"required,max=64"`
+	Password    string `json:"-"`
+	ParentID    int64  `json:"parent_id,string" validate:"omitempty,gt=0"`
+	BlockID     string `json:"block_id" validate:"required_without=ParentID,omitempty,max=64"`
+	AnchorStart int    `json:"anchor_start" validate:"gte=0"`
+	AnchorEnd   int    `json:"anchor_end" validate:"gtefield=AnchorStart"`
+	Content     string `json:"content" validate:"required,min=1,max=1000"`
+	GuestName   string `json:"guest_name" validate:"required,min=1,max=64"`
+}
+
+type GetNotePermissionsDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64 `form:"note_id,string" validate:"required,gt=0"`
+	UserID      int64 `form:"-"`
+}
+
+type SetNotePermissionDTO struct {
+	WorkspaceID  int64                 `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID       int64                 `json:"note_id,string" validate:"required,gt=0"`
+	GranteeType  model.NoteGranteeType `json:"grantee_type" validate:"required,oneof=member group"`
+	GranteeID    int64                 `json:"grantee_id,string" validate:"required_if=GranteeType member,omitempty,gt=0"`
+	GranteeGroup string                `json:"grantee_group" validate:"required_if=GranteeType group,omitempty,max=64"`
+	Role         model.NoteRole        `json:"role" validate:"required,oneof=viewer commenter editor owner"`
+	UserID       int64                 `json:"-"`
+	MemberID     int64                 `json:"-"`
+}
+
+type DeleteNotePermissionDTO struct {
+	WorkspaceID  int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID       int64 `json:"note_id,string" validate:"required,gt=0"`
+	PermissionID int64 `json:"permission_id,string" validate:"required,gt=0"`
+	UserID       int64 `json:"-"`
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"math"
+	"time"
+	"unicode"
+)
+
+const (
+	NoteViewDedupTTL   = 30 * time.Minute // 同一会话内重复打开只记一次
+	NoteViewDateLayout = "2006-01-02"
+	cjkCharsPerMinute  = 300
+	wordsPerMinute     = 200
+)
+
+// NoteViewEvent 浏览事件，先写入 Redis 队列，再由定时任务批量落库
+type NoteViewEvent struct {
+	WorkspaceID int64     `json:"workspace_id"`
+	NoteID      int64     `json:"note_id"`
+	MemberID    int64     `json:"member_id"`
+	ViewedAt    time.Time `json:"viewed_at"`
+}
+
+type RecordNoteViewDTO struct {
+	WorkspaceID int64  `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64  `json:"note_id,string" validate:"required,gt=0"`
+	SessionID   string `json:"session_id" validate:"required,min=1,max=64"`
+	UserID      int64  `json:"-"`
+	MemberID    int64  `json:"-"`
+}
+
+type NoteReadersQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id" validate:"required,gt=0"`
+	NoteID      int64 `form:"note_id" validate:"required,gt=0"`
+	Limit       int   `form:"limit" validate:"omitempty,min=1,max=200"`
+	Offset      int   `form:"offset" validate:"omitempty,min=0"`
+	UserID      int64 `form:"-"`
+}
+
+// NoteViewReportDTO most_viewed 统计最近 days 天的浏览次数；stale 返回最近 days 天无人阅读的笔记
+type NoteViewReportDTO struct {
+	WorkspaceID int64  `form:"workspace_id" validate:"required,gt=0"`
+	CategoryID  *int64 `form:"category_id" validate:"omitempty,gt=0"`
+	Report      string `form:"report" validate:"required,oneof=most_viewed stale"`
+	Days        int    `form:"days" validate:"omitempty,min=1,max=365"`
+	Limit       int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	UserID      int64  `form:"-"`
+	IsAdmin     bool   `form:"-"`
+}
+
+// NoteReaderDTO 阅读过笔记的成员
+type NoteReaderDTO struct {
+	MemberID      int64     `json:"member_id,string"`
+	UserID        int64     `json:"user_id,string"`
+	Nickname      string    `json:"nickname"`
+	Avatar        string    `json:"avatar"`
+	ViewCount     int64     `json:"view_count"`
+	FirstViewedAt time.Time `json:"first_viewed_at"`
+	LastViewedAt  time.Time `json:"last_viewed_at"`
+}
+
+// NoteViewReportItem 报表中的一篇笔记；Content 只用于计算阅读时长，不返回
+type NoteViewReportItem struct {
+	NoteID         int64      `json:"note_id,string"`
+	Title          string     `json:"title"`
+	CategoryID     int64      `json:"category_id,string"`
+	OwnerID        int64      `json:"owner_id,string"`
+	Views          int64      `json:"views"`
+	Readers        int64      `json:"readers"`
+	LastViewedAt   *time.Time `json:"last_viewed_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	WordCount      int        `json:"word_count"`
+	ReadingMinutes int        `json:"reading_minutes"`
+	Content        Blocks     `json:"-"`
+}
+
+// NoteReadingStats 统计字数与预计阅读分钟数：中日韩字符按字计，其它按空白分词计
+func NoteReadingStats(blocks Blocks) (words int, minutes int) {
+	var cjk, latin int
+	var walk func(items []NoteBlockDTO)
+	walk = func(items []NoteBlockDTO) {
+		for _, block := range items {
+			inWord := false
+			for _, r := range InlinePlainText(block.Content) {
+				switch {
+				case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+					unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+					cjk++
+					inWord = false
+				case unicode.IsLetter(r) || unicode.IsDigit(r):
+					if !inWord {
+						latin++
+						inWord = true
+					}
+				default:
+					inWord = false
+				}
+			}
+			walk(block.Children)
+		}
+	}
+	walk(blocks)
+
+	words = cjk + latin
+	if words == 0 {
+		return 0, 0
+	}
+	minutes = int(math.Ceil(float64(cjk)/cjkCharsPerMinute + float64(latin)/wordsPerMinute))
+	return words, minutes
+}
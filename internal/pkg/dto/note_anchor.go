@@ -0,0 +1,85 @@
+package dto
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// BlockLocation 块在文档树中的位置与纯文本
+type BlockLocation struct {
+	ParentID string
+	Text     string
+}
+
+// IndexBlocks 递归建立 块ID -> 位置 的索引，根级块的 ParentID 为空串
+func IndexBlocks(blocks Blocks) map[string]BlockLocation {
+	index := make(map[string]BlockLocation)
+	var walk func(items []NoteBlockDTO, parentID string)
+	walk = func(items []NoteBlockDTO, parentID string) {
+		for _, block := range items {
+			if block.ID != "" {
+				index[block.ID] = BlockLocation{ParentID: parentID, Text: InlinePlainText(block.Content)}
+			}
+			if len(block.Children) > 0 {
+				walk(block.Children, block.ID)
+			}
+		}
+	}
+	walk(blocks, "")
+	return index
+}
+
+// InlinePlainText 拼接文本 runs（含链接等嵌套 run）得到块的纯文本
+func InlinePlainText(content []InlineDTO) string {
+	var text strings.Builder
+	var walk func(items []InlineDTO)
+	walk = func(items []InlineDTO) {
+		for _, inline := range items {
+			text.WriteString(inline.Text)
+			if len(inline.Content) > 0 {
+				walk(inline.Content)
+			}
+		}
+	}
+	walk(content)
+	return text.String()
+}
+
+// RelocateAnchor 块内容变化后重新定位评论的文本区间（rune 下标）。
+// quote 为空表示锚定整块，总能定位；原位置文本未变时保持不变；
+// 否则在新文本中寻找离原起点最近的 quote，找不到返回 ok=false。
+func RelocateAnchor(text, quote string, start, end int) (newStart, newEnd int, ok bool) {
+	runes := []rune(text)
+	if quote == "" {
+		return 0, 0, true
+	}
+	if start >= 0 && end <= len(runes) && start < end && string(runes[start:end]) == quote {
+		return start, end, true
+	}
+
+	quoteLen := utf8.RuneCountInString(quote)
+	best, bestDist := -1, -1
+	offset := 0
+	rest := text
+	for {
+		idx := strings.Index(rest, quote)
+		if idx < 0 {
+			break
+		}
+		pos := offset + utf8.RuneCountInString(rest[:idx])
+		dist := pos - start
+		if dist < 0 {
+			dist = -dist
+		}
+		if best < 0 || dist < bestDist {
+			best, bestDist = pos, dist
+		}
+		_, size := utf8.DecodeRuneInString(rest[idx:])
+		offset = pos + 1
+		rest = rest[idx+size:]
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	return best, best + quoteLen, true
+}
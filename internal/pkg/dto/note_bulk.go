@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// BulkNoteAction 批量操作类型
+type BulkNoteAction string
+
+const (
+	BulkNoteMove       BulkNoteAction = "move"
+	BulkNoteStatus     BulkNoteAction = "status"
+	BulkNoteTag        BulkNoteAction = "tag"
+	BulkNoteUntag      BulkNoteAction = "untag"
+	BulkNoteFavorite   BulkNoteAction = "favorite"
+	BulkNoteUnfavorite BulkNoteAction = "unfavorite"
+	BulkNoteDuplicate  BulkNoteAction = "duplicate"
+	BulkNoteDelete     BulkNoteAction = "delete"
+)
+
+const BulkNoteCopySuffix = " (副本)"
+
+type BulkNoteDTO struct {
+	WorkspaceID int64          `json:"workspace_id,string" validate:"required,gt=0"`
+	Action      BulkNoteAction `json:"action" validate:"required,oneof=move status tag untag favorite unfavorite duplicate delete"`
+	NoteIDs     []string       `json:"note_ids" validate:"required,min=1,max=100,dive,numeric"`
+	CategoryID  *int64         `json:"category_id,string" validate:"required_if=Action move,omitempty,gt=0"` // duplicate 时可选，为空则与原笔记同分类
+	Status      string         `json:"status" validate:"required_if=Action status,omitempty,oneof=public private group"`
+	TagID       *int64         `json:"tag_id,string" validate:"required_if=Action tag,omitempty,gt=0"`
+	UserID      int64          `json:"-"`
+}
+
+// ParseNoteIDs 解析并去重，保持请求中的顺序
+func (d *BulkNoteDTO) ParseNoteIDs() ([]int64, error) {
+	ids := make([]int64, 0, len(d.NoteIDs))
+	seen := make(map[int64]struct{}, len(d.NoteIDs))
+	for _, raw := range d.NoteIDs {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// BulkNoteResult 单条笔记的处理结果，code 为 200 表示成功
+type BulkNoteResult struct {
+	NoteID    int64  `json:"note_id,string"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	NewNoteID *int64 `json:"new_note_id,string,omitempty"` // duplicate 生成的笔记
+}
+
+// CloneBlocks 深拷贝块并重新生成块 ID，用于复制笔记
+func CloneBlocks(blocks Blocks) Blocks {
+	var clone func(items []NoteBlockDTO) []NoteBlockDTO
+	clone = func(items []NoteBlockDTO) []NoteBlockDTO {
+		if items == nil {
+			return nil
+		}
+		out := make([]NoteBlockDTO, 0, len(items))
+		for _, block := range items {
+			block.ID = uuid.NewString()
+			block.Children = clone(block.Children)
+			out = append(out, block)
+		}
+		return out
+	}
+	return clone(blocks)
+}
@@ -0,0 +1,77 @@
+package dto
+
+const ChecklistBlockType = "checkListItem"
+
+// ChecklistBlock 笔记中的一个清单块
+type ChecklistBlock struct {
+	ID      string
+	Text    string
+	Checked bool
+}
+
+// IndexChecklistBlocks 递归收集所有清单块，键为块 ID
+func IndexChecklistBlocks(blocks Blocks) map[string]ChecklistBlock {
+	index := make(map[string]ChecklistBlock)
+	var walk func(items []NoteBlockDTO)
+	walk = func(items []NoteBlockDTO) {
+		for _, block := range items {
+			if block.Type == ChecklistBlockType && block.ID != "" {
+				index[block.ID] = ChecklistBlock{
+					ID:      block.ID,
+					Text:    InlinePlainText(block.Content),
+					Checked: block.Props.Checked != nil && *block.Props.Checked,
+				}
+			}
+			if len(block.Children) > 0 {
+				walk(block.Children)
+			}
+		}
+	}
+	walk(blocks)
+	return index
+}
+
+// SetChecklistChecked 修改清单块的勾选状态，返回新内容与实际生效的块操作；状态未变时 ops 为空
+func SetChecklistChecked(blocks Blocks, blockID string, checked bool) (Blocks, []PatchOp, error) {
+	return ApplyPatch(blocks, []PatchOp{{
+		Op:      PatchOpUpdate,
+		NodeUID: blockID,
+		Patch:   &PartialUpdate{Props: &BlockPropsDTO{Checked: &checked}},
+	}})
+}
+
+type ChecklistToTasksDTO struct {
+	WorkspaceID int64    `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64    `json:"note_id,string" validate:"required,gt=0"`
+	BlockIDs    []string `json:"block_ids" validate:"required,min=1,max=50,dive,min=1,max=64"`
+	ProjectID   int64    `json:"project_id,string" validate:"required,gt=0"`
+	ColumnID    *int64   `json:"column_id,string" validate:"omitempty,gt=0"` // 为空时放入项目的待办列
+	UserID      int64    `json:"-"`
+	MemberID    int64    `json:"-"`
+}
+
+type NoteChecklistTasksQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id" validate:"required,gt=0"`
+	NoteID      int64 `form:"note_id" validate:"required,gt=0"`
+	UserID      int64 `form:"-"`
+}
+
+type UnlinkChecklistTaskDTO struct {
+	WorkspaceID int64  `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64  `json:"note_id,string" validate:"required,gt=0"`
+	BlockID     string `json:"block_id" validate:"required,min=1,max=64"`
+	UserID      int64  `json:"-"`
+	MemberID    int64  `json:"-"`
+}
+
+// ChecklistTaskDTO 清单块关联的任务
+type ChecklistTaskDTO struct {
+	BlockID    string `json:"block_id"`
+	TaskID     int64  `json:"task_id,string"`
+	ProjectID  int64  `json:"project_id,string"`
+	ColumnID   int64  `json:"column_id,string"`
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+	ColumnName string `json:"column_name"`
+	Done       bool   `json:"done"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+const (
+	NoteLockDefaultTTL = 10 * time.Minute
+	NoteLockMutexTTL   = 5 * time.Second // 读写锁状态的临界区很短
+)
+
+// NoteLockState 保存在 Redis 中的笔记锁，永久锁没有过期时间
+type NoteLockState struct {
+	NoteID    int64      `json:"note_id,string"`
+	UserID    int64      `json:"user_id,string"`
+	MemberID  int64      `json:"member_id,string"`
+	Permanent bool       `json:"permanent"`
+	LockedAt  time.Time  `json:"locked_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// AcquireNoteLockDTO 加锁；持有者重复调用即为续期，permanent 仅笔记所有者可用
+type AcquireNoteLockDTO struct {
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64 `json:"note_id,string" validate:"required,gt=0"`
+	TTLSeconds  int   `json:"ttl_seconds" validate:"omitempty,min=30,max=7200"`
+	Permanent   bool  `json:"permanent"`
+	UserID      int64 `json:"-"`
+	MemberID    int64 `json:"-"`
+}
+
+// ReleaseNoteLockDTO 持有者解锁；force 为管理员强制解锁
+type ReleaseNoteLockDTO struct {
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID      int64 `json:"note_id,string" validate:"required,gt=0"`
+	Force       bool  `json:"force"`
+	UserID      int64 `json:"-"`
+}
+
+type NoteLockQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id" validate:"required,gt=0"`
+	NoteID      int64 `form:"note_id" validate:"required,gt=0"`
+	UserID      int64 `form:"-"`
+}
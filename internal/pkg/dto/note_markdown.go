@@ -0,0 +1,194 @@
+package dto
+
+import (
+	"gin-notebook/pkg/utils/tools"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const markdownMaxHeading = 3 // 编辑器只支持三级标题
+
+// MarkdownToBlocks 把外部传入的 Markdown（GFM）转换为块；表格按行转为段落，HTML 与分隔线忽略
+func MarkdownToBlocks(md string) Blocks {
+	src := []byte(strings.ReplaceAll(md, "\r\n", "\n"))
+	root := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(src))
+
+	c := &mdConverter{src: src}
+	blocks := c.blocks(root)
+	if len(blocks) == 0 {
+		return DefaultBlocks()
+	}
+	return blocks
+}
+
+type mdConverter struct {
+	src []byte
+}
+
+func (c *mdConverter) blocks(parent ast.Node) Blocks {
+	blocks := make(Blocks, 0)
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.Heading:
+			block := mdBlock("heading", c.inlines(node, InlineStylesDTO{}))
+			block.Props.Level = tools.Ptr(min(node.Level, markdownMaxHeading))
+			blocks = append(blocks, block)
+		case *ast.Paragraph, *ast.TextBlock:
+			blocks = append(blocks, mdBlock("paragraph", c.inlines(node, InlineStylesDTO{})))
+		case *ast.List:
+			blocks = append(blocks, c.listItems(node)...)
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			blocks = append(blocks, mdBlock("codeBlock", mdText(c.lines(node))))
+		case *ast.Blockquote:
+			lines := make([]string, 0)
+			for _, b := range c.blocks(node) {
+				lines = append(lines, inlineText(b.Content))
+			}
+			blocks = append(blocks, mdBlock("quote", mdText(strings.Join(lines, "\n"))))
+		case *extast.Table:
+			for row := node.FirstChild(); row != nil; row = row.NextSibling() {
+				cells := make([]string, 0)
+				for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+					cells = append(cells, inlineText(c.inlines(cell, InlineStylesDTO{})))
+				}
+				blocks = append(blocks, mdBlock("paragraph", mdText(strings.Join(cells, " | "))))
+			}
+		}
+	}
+	return blocks
+}
+
+// listItems 列表项的首段作为内容，嵌套列表与后续段落作为子块
+func (c *mdConverter) listItems(list *ast.List) Blocks {
+	blockType := "bulletListItem"
+	if list.IsOrdered() {
+		blockType = "numberedListItem"
+	}
+	items := make(Blocks, 0)
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		block := mdBlock(blockType, []InlineDTO{})
+		children := c.blocks(item)
+		if first := item.FirstChild(); first != nil && (first.Kind() == ast.KindTextBlock || first.Kind() == ast.KindParagraph) {
+			if box, ok := first.FirstChild().(*extast.TaskCheckBox); ok {
+				block.Type = "checkListItem"
+				block.Props.Checked = tools.Ptr(box.IsChecked)
+			}
+			block.Content, children = children[0].Content, children[1:]
+		}
+		block.Children = append(block.Children, children...)
+		items = append(items, block)
+	}
+	return items
+}
+
+func (c *mdConverter) lines(n ast.Node) string {
+	var sb strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		sb.Write(seg.Value(c.src))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (c *mdConverter) inlines(parent ast.Node, styles InlineStylesDTO) []InlineDTO {
+	out := make([]InlineDTO, 0)
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.Text:
+			value := string(node.Segment.Value(c.src))
+			if node.HardLineBreak() {
+				value += "\n"
+			} else if node.SoftLineBreak() {
+				value += " "
+			}
+			out = appendText(out, value, styles)
+		case *ast.String:
+			out = appendText(out, string(node.Value), styles)
+		case *ast.CodeSpan:
+			s := styles
+			s.Code = tools.Ptr(true)
+			out = append(out, c.inlines(node, s)...)
+		case *ast.Emphasis:
+			s := styles
+			if node.Level >= 2 {
+				s.Bold = tools.Ptr(true)
+			} else {
+				s.Italic = tools.Ptr(true)
+			}
+			out = append(out, c.inlines(node, s)...)
+		case *extast.Strikethrough:
+			s := styles
+			s.Strike = tools.Ptr(true)
+			out = append(out, c.inlines(node, s)...)
+		case *ast.Link:
+			out = append(out, InlineDTO{Type: "link", Href: string(node.Destination), Content: c.inlines(node, styles)})
+		case *ast.Image:
+			out = append(out, InlineDTO{Type: "link", Href: string(node.Destination), Content: c.inlines(node, styles)})
+		case *ast.AutoLink:
+			url := string(node.URL(c.src))
+			out = append(out, InlineDTO{Type: "link", Href: url, Content: []InlineDTO{{Type: "text", Text: string(node.Label(c.src))}}})
+		case *extast.TaskCheckBox, *ast.RawHTML:
+		default:
+			out = append(out, c.inlines(node, styles)...)
+		}
+	}
+	if n := len(out); n > 0 && out[n-1].Type == "text" {
+		out[n-1].Text = strings.TrimRight(out[n-1].Text, " \n")
+	}
+	return out
+}
+
+// appendText 相邻且样式相同的文本合并为一段
+func appendText(out []InlineDTO, value string, styles InlineStylesDTO) []InlineDTO {
+	if value == "" {
+		return out
+	}
+	if n := len(out); n > 0 && out[n-1].Type == "text" && sameStyles(out[n-1].Styles, styles) {
+		out[n-1].Text += value
+		return out
+	}
+	return append(out, InlineDTO{Type: "text", Text: value, Styles: styles})
+}
+
+func sameStyles(a, b InlineStylesDTO) bool {
+	flag := func(p *bool) bool { return p != nil && *p }
+	return flag(a.Bold) == flag(b.Bold) && flag(a.Italic) == flag(b.Italic) &&
+		flag(a.Code) == flag(b.Code) && flag(a.Strike) == flag(b.Strike)
+}
+
+func inlineText(content []InlineDTO) string {
+	var sb strings.Builder
+	for _, in := range content {
+		sb.WriteString(in.Text)
+		sb.WriteString(inlineText(in.Content))
+	}
+	return sb.String()
+}
+
+func mdText(s string) []InlineDTO {
+	if s == "" {
+		return []InlineDTO{}
+	}
+	return []InlineDTO{{Type: "text", Text: s}}
+}
+
+func mdBlock(blockType string, content []InlineDTO) NoteBlockDTO {
+	return NoteBlockDTO{
+		ID:   uuid.NewString(),
+		Type: blockType,
+		Props: BlockPropsDTO{
+			BackgroundColor: tools.Ptr("default"),
+			TextColor:       tools.Ptr("default"),
+			TextAlignment:   tools.Ptr("left"),
+		},
+		Content:  content,
+		Children: []NoteBlockDTO{},
+	}
+}
@@ -0,0 +1,493 @@
+package dto
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PatchOpInsert = "insert"
+	PatchOpUpdate = "update"
+	PatchOpMove   = "move"
+	PatchOpDelete = "delete"
+)
+
+// PatchError 描述第 Index 个 op 为什么被拒绝
+type PatchError struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	NodeUID string `json:"node_uid,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("patch op #%d (%s %s): %s", e.Index, e.Op, e.NodeUID, e.Reason)
+}
+
+// patchNode 可变的块树节点；Block.Children 不使用，子节点保存在 children 中
+type patchNode struct {
+	block    NoteBlockDTO
+	parent   *patchNode
+	children []*patchNode
+}
+
+func (n *patchNode) id() string {
+	if n.parent == nil {
+		return ""
+	}
+	return n.block.ID
+}
+
+func (n *patchNode) indexOf(child *patchNode) int {
+	for i, c := range n.children {
+		if c == child {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *patchNode) toBlock() NoteBlockDTO {
+	block := n.block
+	block.Children = make([]NoteBlockDTO, 0, len(n.children))
+	for _, c := range n.children {
+		block.Children = append(block.Children, c.toBlock())
+	}
+	return block
+}
+
+type patchTree struct {
+	root    *patchNode
+	index   map[string]*patchNode
+	deleted map[string]int // 本批次中被删除的块 -> 删除它的 op 下标，用于给出冲突原因
+}
+
+func newPatchTree(blocks Blocks) *patchTree {
+	t := &patchTree{
+		root:    &patchNode{},
+		index:   make(map[string]*patchNode),
+		deleted: make(map[string]int),
+	}
+	for _, b := range blocks {
+		t.root.children = append(t.root.children, t.load(b, t.root))
+	}
+	return t
+}
+
+// load 载入已保存的内容；历史数据中缺失或重复的块 ID 会被重新分配，避免整篇笔记无法编辑
+func (t *patchTree) load(block NoteBlockDTO, parent *patchNode) *patchNode {
+	if _, ok := t.index[block.ID]; ok || block.ID == "" {
+		block.ID = uuid.NewString()
+	}
+	node := &patchNode{block: block, parent: parent}
+	node.block.Children = nil
+	t.index[block.ID] = node
+	for _, c := range block.Children {
+		node.children = append(node.children, t.load(c, node))
+	}
+	return node
+}
+
+// build 为 op 携带的新块建立子树并登记索引，块 ID 缺失或重复时报错
+func (t *patchTree) build(block NoteBlockDTO, parent *patchNode) (*patchNode, error) {
+	if block.ID == "" {
+		return nil, fmt.Errorf("block without id")
+	}
+	if _, ok := t.index[block.ID]; ok {
+		return nil, fmt.Errorf("duplicate block id %s", block.ID)
+	}
+	node := &patchNode{block: block, parent: parent}
+	node.block.Children = nil
+	t.index[block.ID] = node
+	for _, c := range block.Children {
+		child, err := t.build(c, node)
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, child)
+	}
+	return node, nil
+}
+
+func (t *patchTree) unindex(node *patchNode, opIndex int) {
+	delete(t.index, node.block.ID)
+	t.deleted[node.block.ID] = opIndex
+	for _, c := range node.children {
+		t.unindex(c, opIndex)
+	}
+}
+
+func (t *patchTree) detach(node *patchNode) {
+	parent := node.parent
+	if i := parent.indexOf(node); i >= 0 {
+		parent.children = append(parent.children[:i], parent.children[i+1:]...)
+	}
+	node.parent = nil
+}
+
+func (t *patchTree) attach(node, parent *patchNode, pos int) {
+	node.parent = parent
+	parent.children = append(parent.children, nil)
+	copy(parent.children[pos+1:], parent.children[pos:])
+	parent.children[pos] = node
+}
+
+func (t *patchTree) blocks() Blocks {
+	out := make(Blocks, 0, len(t.root.children))
+	for _, c := range t.root.children {
+		out = append(out, c.toBlock())
+	}
+	return out
+}
+
+// position 返回节点当前的父级与左右兄弟，用于生成生效后的 op
+func (t *patchTree) position(node *patchNode) (parentUID, afterID, beforeID *string) {
+	parent := node.parent
+	if parent != t.root {
+		id := parent.id()
+		parentUID = &id
+	}
+	i := parent.indexOf(node)
+	if i > 0 {
+		id := parent.children[i-1].block.ID
+		afterID = &id
+	}
+	if i >= 0 && i < len(parent.children)-1 {
+		id := parent.children[i+1].block.ID
+		beforeID = &id
+	}
+	return
+}
+
+// ApplyPatch 在块树上依次应用 ops，支持任意层级的 insert/update/move/delete。
+// 任何一个 op 不合法时整体拒绝，返回 *PatchError，原内容不受影响；
+// 成功时返回新内容以及实际生效的 op（无变化的 update/move 会被剔除，
+// 位置统一补全为 new_parent_uid/afterId/beforeId），供同步 outbox 使用。
+func ApplyPatch(blocks Blocks, ops []PatchOp) (Blocks, []PatchOp, error) {
+	tree := newPatchTree(blocks)
+	effective := make([]PatchOp, 0, len(ops))
+	for i, op := range ops {
+		applied, err := tree.apply(i, op)
+		if err != nil {
+			return nil, nil, err
+		}
+		if applied != nil {
+			effective = append(effective, *applied)
+		}
+	}
+	return tree.blocks(), effective, nil
+}
+
+func (t *patchTree) fail(i int, op PatchOp, uid, format string, args ...any) *PatchError {
+	return &PatchError{Index: i, Op: op.Op, NodeUID: uid, Reason: fmt.Sprintf(format, args...)}
+}
+
+// lookup 查找已存在的块；若该块在本批次中已被删除，给出冲突原因
+func (t *patchTree) lookup(i int, op PatchOp, uid string) (*patchNode, *PatchError) {
+	if node, ok := t.index[uid]; ok {
+		return node, nil
+	}
+	if by, ok := t.deleted[uid]; ok {
+		return nil, t.fail(i, op, uid, "conflict: block %s was deleted by op #%d", uid, by)
+	}
+	return nil, t.fail(i, op, uid, "block %s not found", uid)
+}
+
+// resolveTarget 计算插入/移动的目标父级与下标。
+// 未指定 new_parent_uid 时，由 afterId/beforeId 所在层级推断，二者都没有时视为根级；
+// afterId 优先，其次 beforeId，都没有时放到父级最前面。
+func (t *patchTree) resolveTarget(i int, op PatchOp, uid string) (*patchNode, int, *PatchError) {
+	var after, before *patchNode
+	if op.AfterID != nil && *op.AfterID != "" {
+		node, err := t.lookup(i, op, *op.AfterID)
+		if err != nil {
+			err.Reason = "afterId: " + err.Reason
+			return nil, 0, err
+		}
+		after = node
+	}
+	if op.BeforeID != nil && *op.BeforeID != "" {
+		node, err := t.lookup(i, op, *op.BeforeID)
+		if err != nil {
+			err.Reason = "beforeId: " + err.Reason
+			return nil, 0, err
+		}
+		before = node
+	}
+	if (after != nil && after.block.ID == uid) || (before != nil && before.block.ID == uid) {
+		return nil, 0, t.fail(i, op, uid, "block cannot be positioned relative to itself")
+	}
+
+	parent := t.root
+	switch {
+	case op.NewParentUID != nil && *op.NewParentUID != "":
+		node, err := t.lookup(i, op, *op.NewParentUID)
+		if err != nil {
+			err.Reason = "new_parent_uid: " + err.Reason
+			return nil, 0, err
+		}
+		parent = node
+	case after != nil:
+		parent = after.parent
+	case before != nil:
+		parent = before.parent
+	}
+
+	if after != nil && after.parent != parent {
+		return nil, 0, t.fail(i, op, uid, "afterId %s is not a child of the target parent", after.block.ID)
+	}
+	if before != nil && before.parent != parent {
+		return nil, 0, t.fail(i, op, uid, "beforeId %s is not a child of the target parent", before.block.ID)
+	}
+
+	// 移动时先不计算自身，下标以摘除后的兄弟列表为准
+	siblings := make([]*patchNode, 0, len(parent.children))
+	for _, c := range parent.children {
+		if c.block.ID != uid {
+			siblings = append(siblings, c)
+		}
+	}
+	indexIn := func(n *patchNode) int {
+		for k, c := range siblings {
+			if c == n {
+				return k
+			}
+		}
+		return -1
+	}
+
+	pos := 0
+	switch {
+	case after != nil:
+		pos = indexIn(after) + 1
+		if before != nil && indexIn(before) != pos {
+			return nil, 0, t.fail(i, op, uid, "conflict: afterId %s and beforeId %s are not adjacent", after.block.ID, before.block.ID)
+		}
+	case before != nil:
+		pos = indexIn(before)
+	}
+	return parent, pos, nil
+}
+
+func (t *patchTree) apply(i int, op PatchOp) (*PatchOp, error) {
+	switch op.Op {
+	case PatchOpInsert:
+		return t.applyInsert(i, op)
+	case PatchOpUpdate:
+		return t.applyUpdate(i, op)
+	case PatchOpMove:
+		return t.applyMove(i, op)
+	case PatchOpDelete:
+		return t.applyDelete(i, op)
+	}
+	return nil, t.fail(i, op, op.NodeUID, "unknown op %q", op.Op)
+}
+
+func (t *patchTree) applyInsert(i int, op PatchOp) (*PatchOp, error) {
+	if op.Block == nil {
+		return nil, t.fail(i, op, op.NodeUID, "insert requires block")
+	}
+	block := *op.Block
+	if block.ID == "" {
+		block.ID = op.NodeUID
+	}
+	if block.ID == "" {
+		block.ID = uuid.NewString()
+	}
+	if op.NodeUID != "" && op.NodeUID != block.ID {
+		return nil, t.fail(i, op, op.NodeUID, "node_uid does not match block.id %s", block.ID)
+	}
+	if err := t.checkNewIDs(i, op, block); err != nil {
+		return nil, err
+	}
+
+	parent, pos, perr := t.resolveTarget(i, op, block.ID)
+	if perr != nil {
+		return nil, perr
+	}
+	node, err := t.build(block, parent)
+	if err != nil {
+		return nil, t.fail(i, op, block.ID, "%s", err.Error())
+	}
+	t.attach(node, parent, pos)
+	for id := range collectIDs(block) {
+		delete(t.deleted, id)
+	}
+
+	full := node.toBlock()
+	parentUID, afterID, beforeID := t.position(node)
+	return &PatchOp{
+		Op:           PatchOpInsert,
+		NodeUID:      block.ID,
+		Block:        &full,
+		NewParentUID: parentUID,
+		AfterID:      afterID,
+		BeforeID:     beforeID,
+	}, nil
+}
+
+// checkNewIDs 新块及其子块的 ID 不能与现有块重复，子块不能缺少 ID
+func (t *patchTree) checkNewIDs(i int, op PatchOp, block NoteBlockDTO) *PatchError {
+	seen := make(map[string]bool)
+	var walk func(b NoteBlockDTO, root bool) *PatchError
+	walk = func(b NoteBlockDTO, root bool) *PatchError {
+		if b.ID == "" && !root {
+			return t.fail(i, op, block.ID, "child block without id")
+		}
+		if _, ok := t.index[b.ID]; ok || seen[b.ID] {
+			return t.fail(i, op, block.ID, "conflict: block id %s already exists", b.ID)
+		}
+		seen[b.ID] = true
+		for _, c := range b.Children {
+			if err := walk(c, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(block, true)
+}
+
+func (t *patchTree) applyUpdate(i int, op PatchOp) (*PatchOp, error) {
+	uid := op.NodeUID
+	if uid == "" && op.Block != nil {
+		uid = op.Block.ID
+	}
+	if uid == "" {
+		return nil, t.fail(i, op, uid, "update requires node_uid")
+	}
+	if op.Block == nil && op.Patch == nil {
+		return nil, t.fail(i, op, uid, "update requires block or patch")
+	}
+	if op.Block != nil && op.Block.ID != "" && op.Block.ID != uid {
+		return nil, t.fail(i, op, uid, "node_uid does not match block.id %s", op.Block.ID)
+	}
+	node, perr := t.lookup(i, op, uid)
+	if perr != nil {
+		return nil, perr
+	}
+
+	before := node.toBlock()
+	if op.Block != nil {
+		node.block.Type = op.Block.Type
+		node.block.Props = op.Block.Props
+		node.block.Content = op.Block.Content
+		// 仅在显式携带 children 时替换整棵子树
+		if op.Block.Children != nil {
+			if err := t.replaceChildren(i, op, node, op.Block.Children); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if op.Patch != nil {
+		if op.Patch.Type != nil {
+			node.block.Type = *op.Patch.Type
+		}
+		if op.Patch.Props != nil {
+			node.block.Props.Update(op.Patch.Props)
+		}
+		if op.Patch.Content != nil {
+			node.block.Content = *op.Patch.Content
+		}
+	}
+
+	after := node.toBlock()
+	if reflect.DeepEqual(before, after) {
+		return nil, nil
+	}
+	return &PatchOp{
+		Op:      PatchOpUpdate,
+		NodeUID: uid,
+		Block:   &after,
+		Patch:   op.Patch,
+	}, nil
+}
+
+func (t *patchTree) replaceChildren(i int, op PatchOp, node *patchNode, children []NoteBlockDTO) *PatchError {
+	// 先摘掉旧子树再按新内容重建，新子树可以沿用旧子块的 ID
+	for _, c := range node.children {
+		t.unindex(c, i)
+	}
+	node.children = nil
+	for _, c := range children {
+		child, err := t.build(c, node)
+		if err != nil {
+			return t.fail(i, op, node.block.ID, "%s", err.Error())
+		}
+		node.children = append(node.children, child)
+		for id := range collectIDs(c) {
+			delete(t.deleted, id)
+		}
+	}
+	return nil
+}
+
+func (t *patchTree) applyMove(i int, op PatchOp) (*PatchOp, error) {
+	if op.NodeUID == "" {
+		return nil, t.fail(i, op, "", "move requires node_uid")
+	}
+	node, perr := t.lookup(i, op, op.NodeUID)
+	if perr != nil {
+		return nil, perr
+	}
+
+	parent, pos, perr := t.resolveTarget(i, op, node.block.ID)
+	if perr != nil {
+		return nil, perr
+	}
+	// 不能移动到自身或自身的子孙下面
+	for p := parent; p != nil; p = p.parent {
+		if p == node {
+			return nil, t.fail(i, op, op.NodeUID, "conflict: cannot move block into its own subtree")
+		}
+	}
+
+	oldParent, oldIndex := node.parent, node.parent.indexOf(node)
+	t.detach(node)
+	t.attach(node, parent, pos)
+	if oldParent == parent && parent.indexOf(node) == oldIndex {
+		return nil, nil
+	}
+
+	parentUID, afterID, beforeID := t.position(node)
+	return &PatchOp{
+		Op:           PatchOpMove,
+		NodeUID:      node.block.ID,
+		NewParentUID: parentUID,
+		AfterID:      afterID,
+		BeforeID:     beforeID,
+	}, nil
+}
+
+func (t *patchTree) applyDelete(i int, op PatchOp) (*PatchOp, error) {
+	uid := op.NodeUID
+	if uid == "" && op.Block != nil {
+		uid = op.Block.ID
+	}
+	if uid == "" {
+		return nil, t.fail(i, op, "", "delete requires node_uid")
+	}
+	node, perr := t.lookup(i, op, uid)
+	if perr != nil {
+		return nil, perr
+	}
+
+	t.detach(node)
+	t.unindex(node, i)
+	return &PatchOp{Op: PatchOpDelete, NodeUID: uid}, nil
+}
+
+func collectIDs(block NoteBlockDTO) map[string]bool {
+	ids := make(map[string]bool)
+	var walk func(b NoteBlockDTO)
+	walk = func(b NoteBlockDTO) {
+		ids[b.ID] = true
+		for _, c := range b.Children {
+			walk(c)
+		}
+	}
+	walk(block)
+	return ids
+}
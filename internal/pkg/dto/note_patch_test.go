@@ -0,0 +1,473 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func textBlock(id, text string, children ...NoteBlockDTO) NoteBlockDTO {
+	return NoteBlockDTO{
+		ID:       id,
+		Type:     "paragraph",
+		Content:  []InlineDTO{{Type: "text", Text: text}},
+		Children: children,
+	}
+}
+
+// sampleBlocks a{a1{a1x}, a2}, b, c{c1}
+func sampleBlocks() Blocks {
+	return Blocks{
+		textBlock("a", "a", textBlock("a1", "a1", textBlock("a1x", "a1x")), textBlock("a2", "a2")),
+		textBlock("b", "b"),
+		textBlock("c", "c", textBlock("c1", "c1")),
+	}
+}
+
+// blockIndex 块 ID -> 父级 ID（根级为空串），重复 ID 或成环时报错
+func blockIndex(t *testing.T, blocks Blocks) map[string]string {
+	t.Helper()
+	parents := make(map[string]string)
+	var walk func(list []NoteBlockDTO, parent string, ancestors map[string]bool)
+	walk = func(list []NoteBlockDTO, parent string, ancestors map[string]bool) {
+		for _, b := range list {
+			if b.ID == "" {
+				t.Fatalf("block without id under %q", parent)
+			}
+			if ancestors[b.ID] {
+				t.Fatalf("cycle: block %s is its own ancestor", b.ID)
+			}
+			if _, ok := parents[b.ID]; ok {
+				t.Fatalf("duplicate block id %s", b.ID)
+			}
+			parents[b.ID] = parent
+			ancestors[b.ID] = true
+			walk(b.Children, b.ID, ancestors)
+			delete(ancestors, b.ID)
+		}
+	}
+	walk(blocks, "", map[string]bool{})
+	return parents
+}
+
+func childIDs(blocks Blocks, parent string) []string {
+	list := []NoteBlockDTO(blocks)
+	if parent != "" {
+		var find func([]NoteBlockDTO) []NoteBlockDTO
+		find = func(l []NoteBlockDTO) []NoteBlockDTO {
+			for _, b := range l {
+				if b.ID == parent {
+					return b.Children
+				}
+				if c := find(b.Children); c != nil {
+					return c
+				}
+			}
+			return nil
+		}
+		list = find(list)
+	}
+	ids := make([]string, 0, len(list))
+	for _, b := range list {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func subtreeIDs(parents map[string]string, root string) map[string]bool {
+	ids := map[string]bool{root: true}
+	for changed := true; changed; {
+		changed = false
+		for id, p := range parents {
+			if ids[p] && !ids[id] {
+				ids[id] = true
+				changed = true
+			}
+		}
+	}
+	return ids
+}
+
+type patchGen struct {
+	r    *rand.Rand
+	next int
+}
+
+func (g *patchGen) newID() string {
+	g.next++
+	return fmt.Sprintf("n%d", g.next)
+}
+
+// newSubtree 生成 1~3 层的新块子树
+func (g *patchGen) newSubtree(depth int) NoteBlockDTO {
+	b := textBlock(g.newID(), "new")
+	if depth > 1 {
+		for n := g.r.Intn(3); n > 0; n-- {
+			b.Children = append(b.Children, g.newSubtree(depth-1))
+		}
+	}
+	return b
+}
+
+// position 在 parent 的子级中随机选一个插入点，以随机组合的 new_parent_uid/afterId/beforeId 表示
+func (g *patchGen) position(blocks Blocks, parent, exclude string) (newParent, after, before *string) {
+	siblings := make([]string, 0)
+	for _, id := range childIDs(blocks, parent) {
+		if id != exclude {
+			siblings = append(siblings, id)
+		}
+	}
+	pos := g.r.Intn(len(siblings) + 1)
+	if pos > 0 && g.r.Intn(2) == 0 {
+		after = strPtr(siblings[pos-1])
+	}
+	if pos < len(siblings) && (after == nil || g.r.Intn(2) == 0) {
+		before = strPtr(siblings[pos])
+	}
+	if (after == nil && before == nil) || g.r.Intn(3) == 0 {
+		if parent != "" {
+			newParent = strPtr(parent)
+		}
+		if after == nil && before == nil && pos > 0 {
+			after = strPtr(siblings[pos-1])
+		}
+	}
+	return
+}
+
+func (g *patchGen) op(blocks Blocks, parents map[string]string) PatchOp {
+	ids := make([]string, 0, len(parents))
+	for id := range parents {
+		ids = append(ids, id)
+	}
+	// map 遍历无序，排序后用种子决定选择，保证可复现
+	sort.Strings(ids)
+	pick := func() string { return ids[g.r.Intn(len(ids))] }
+	pickParent := func(exclude map[string]bool) string {
+		candidates := []string{""}
+		for _, id := range ids {
+			if !exclude[id] {
+				candidates = append(candidates, id)
+			}
+		}
+		return candidates[g.r.Intn(len(candidates))]
+	}
+
+	kind := g.r.Intn(4)
+	if len(ids) == 0 {
+		kind = 0
+	}
+	switch kind {
+	case 0:
+		block := g.newSubtree(1 + g.r.Intn(3))
+		np, after, before := g.position(blocks, pickParent(nil), "")
+		return PatchOp{Op: PatchOpInsert, NodeUID: block.ID, Block: &block, NewParentUID: np, AfterID: after, BeforeID: before}
+	case 1:
+		uid := pick()
+		if g.r.Intn(2) == 0 {
+			content := []InlineDTO{{Type: "text", Text: fmt.Sprintf("edit %d", g.r.Intn(1000))}}
+			return PatchOp{Op: PatchOpUpdate, NodeUID: uid, Patch: &PartialUpdate{Content: &content}}
+		}
+		typ := []string{"paragraph", "heading", "checkListItem"}[g.r.Intn(3)]
+		return PatchOp{Op: PatchOpUpdate, NodeUID: uid, Block: &NoteBlockDTO{ID: uid, Type: typ, Content: []InlineDTO{{Type: "text", Text: typ}}}}
+	case 2:
+		uid := pick()
+		np, after, before := g.position(blocks, pickParent(subtreeIDs(parents, uid)), uid)
+		return PatchOp{Op: PatchOpMove, NodeUID: uid, NewParentUID: np, AfterID: after, BeforeID: before}
+	default:
+		return PatchOp{Op: PatchOpDelete, NodeUID: pick()}
+	}
+}
+
+func TestApplyPatchRandomSequences(t *testing.T) {
+	for seed := int64(1); seed <= 200; seed++ {
+		t.Run(fmt.Sprintf("seed%d", seed), func(t *testing.T) {
+			g := &patchGen{r: rand.New(rand.NewSource(seed))}
+			original := Blocks{g.newSubtree(3), g.newSubtree(3), g.newSubtree(2)}
+			blockIndex(t, original)
+
+			// 逐个应用生成的 op，同时维护预期的块 ID 集合
+			current := original
+			var ops []PatchOp
+			for step := 0; step < 40; step++ {
+				parents := blockIndex(t, current)
+				op := g.op(current, parents)
+				next, _, err := ApplyPatch(current, []PatchOp{op})
+				if err != nil {
+					t.Fatalf("step %d: generated op %+v rejected: %v", step, op, err)
+				}
+
+				expected := make(map[string]bool, len(parents))
+				for id := range parents {
+					expected[id] = true
+				}
+				switch op.Op {
+				case PatchOpInsert:
+					for id := range collectIDs(*op.Block) {
+						expected[id] = true
+					}
+				case PatchOpDelete:
+					for id := range subtreeIDs(parents, op.NodeUID) {
+						delete(expected, id)
+					}
+				}
+				got := blockIndex(t, next)
+				if len(got) != len(expected) {
+					t.Fatalf("step %d (%s): got %d blocks, want %d", step, op.Op, len(got), len(expected))
+				}
+				for id := range expected {
+					if _, ok := got[id]; !ok {
+						t.Fatalf("step %d (%s): block %s lost", step, op.Op, id)
+					}
+				}
+				if op.Op == PatchOpMove {
+					want := ""
+					if op.NewParentUID != nil {
+						want = *op.NewParentUID
+					} else if op.AfterID != nil {
+						want = parents[*op.AfterID]
+					} else if op.BeforeID != nil {
+						want = parents[*op.BeforeID]
+					}
+					if got[op.NodeUID] != want {
+						t.Fatalf("step %d: moved %s under %q, want %q", step, op.NodeUID, got[op.NodeUID], want)
+					}
+				}
+
+				ops = append(ops, op)
+				current = next
+			}
+
+			// 整批应用与逐个应用结果一致
+			result, effective, err := ApplyPatch(original, ops)
+			if err != nil {
+				t.Fatalf("batch rejected: %v", err)
+			}
+			if !reflect.DeepEqual(result, current) {
+				t.Fatalf("batch result differs from step-by-step result")
+			}
+			blockIndex(t, result)
+
+			// 在原内容上重放生效的 op 得到相同结果，且每个 op 都依然生效
+			replayed, again, err := ApplyPatch(original, effective)
+			if err != nil {
+				t.Fatalf("replaying effective ops rejected: %v", err)
+			}
+			if !reflect.DeepEqual(replayed, result) {
+				t.Fatalf("replaying effective ops does not reproduce the result")
+			}
+			if len(again) != len(effective) {
+				t.Fatalf("replay produced %d effective ops, want %d", len(again), len(effective))
+			}
+		})
+	}
+}
+
+func TestApplyPatchEffectiveOps(t *testing.T) {
+	// 无变化的 update/move 被剔除，位置补全为父级与左右兄弟
+	same := []InlineDTO{{Type: "text", Text: "b"}}
+	ops := []PatchOp{
+		{Op: PatchOpUpdate, NodeUID: "b", Patch: &PartialUpdate{Content: &same}},
+		{Op: PatchOpMove, NodeUID: "b", AfterID: strPtr("a")},
+		{Op: PatchOpMove, NodeUID: "c1", NewParentUID: strPtr("a1"), AfterID: strPtr("a1x")},
+	}
+	result, effective, err := ApplyPatch(sampleBlocks(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(effective) != 1 {
+		t.Fatalf("got %d effective ops, want 1", len(effective))
+	}
+	move := effective[0]
+	if move.NodeUID != "c1" || move.NewParentUID == nil || *move.NewParentUID != "a1" ||
+		move.AfterID == nil || *move.AfterID != "a1x" || move.BeforeID != nil {
+		t.Fatalf("unexpected effective move %+v", move)
+	}
+	if got := childIDs(result, "a1"); !reflect.DeepEqual(got, []string{"a1x", "c1"}) {
+		t.Fatalf("a1 children = %v", got)
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		ops    []PatchOp
+		index  int
+		reason string
+	}{
+		{
+			name:   "unknown op",
+			ops:    []PatchOp{{Op: "copy", NodeUID: "a"}},
+			reason: `unknown op "copy"`,
+		},
+		{
+			name:   "insert without block",
+			ops:    []PatchOp{{Op: PatchOpInsert, NodeUID: "x"}},
+			reason: "insert requires block",
+		},
+		{
+			name:   "insert node_uid mismatch",
+			ops:    []PatchOp{{Op: PatchOpInsert, NodeUID: "x", Block: &NoteBlockDTO{ID: "y"}}},
+			reason: "node_uid does not match block.id y",
+		},
+		{
+			name:   "insert existing id",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "a2"}}},
+			reason: "conflict: block id a2 already exists",
+		},
+		{
+			name:   "insert duplicate id in subtree",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x", Children: []NoteBlockDTO{{ID: "y"}, {ID: "y"}}}}},
+			reason: "conflict: block id y already exists",
+		},
+		{
+			name:   "insert child without id",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x", Children: []NoteBlockDTO{{Type: "paragraph"}}}}},
+			reason: "child block without id",
+		},
+		{
+			name:   "insert after missing block",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x"}, AfterID: strPtr("zz")}},
+			reason: "afterId: block zz not found",
+		},
+		{
+			name:   "insert before missing block",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x"}, BeforeID: strPtr("zz")}},
+			reason: "beforeId: block zz not found",
+		},
+		{
+			name:   "insert under missing parent",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x"}, NewParentUID: strPtr("zz")}},
+			reason: "new_parent_uid: block zz not found",
+		},
+		{
+			name:   "afterId outside parent",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x"}, NewParentUID: strPtr("a"), AfterID: strPtr("b")}},
+			reason: "afterId b is not a child of the target parent",
+		},
+		{
+			name:   "beforeId outside parent",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x"}, NewParentUID: strPtr("a"), BeforeID: strPtr("c1")}},
+			reason: "beforeId c1 is not a child of the target parent",
+		},
+		{
+			name:   "afterId and beforeId not adjacent",
+			ops:    []PatchOp{{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "x"}, AfterID: strPtr("a"), BeforeID: strPtr("c")}},
+			reason: "conflict: afterId a and beforeId c are not adjacent",
+		},
+		{
+			name:   "update without node_uid",
+			ops:    []PatchOp{{Op: PatchOpUpdate, Patch: &PartialUpdate{}}},
+			reason: "update requires node_uid",
+		},
+		{
+			name:   "update without block or patch",
+			ops:    []PatchOp{{Op: PatchOpUpdate, NodeUID: "a"}},
+			reason: "update requires block or patch",
+		},
+		{
+			name:   "update node_uid mismatch",
+			ops:    []PatchOp{{Op: PatchOpUpdate, NodeUID: "a", Block: &NoteBlockDTO{ID: "b"}}},
+			reason: "node_uid does not match block.id b",
+		},
+		{
+			name:   "update missing block",
+			ops:    []PatchOp{{Op: PatchOpUpdate, NodeUID: "zz", Patch: &PartialUpdate{}}},
+			reason: "block zz not found",
+		},
+		{
+			name:   "update children with duplicate id",
+			ops:    []PatchOp{{Op: PatchOpUpdate, NodeUID: "c", Block: &NoteBlockDTO{Children: []NoteBlockDTO{{ID: "b"}}}}},
+			reason: "duplicate block id b",
+		},
+		{
+			name:   "move without node_uid",
+			ops:    []PatchOp{{Op: PatchOpMove, AfterID: strPtr("a")}},
+			reason: "move requires node_uid",
+		},
+		{
+			name:   "move relative to itself",
+			ops:    []PatchOp{{Op: PatchOpMove, NodeUID: "b", AfterID: strPtr("b")}},
+			reason: "block cannot be positioned relative to itself",
+		},
+		{
+			name:   "move into own subtree",
+			ops:    []PatchOp{{Op: PatchOpMove, NodeUID: "a", NewParentUID: strPtr("a1x")}},
+			reason: "conflict: cannot move block into its own subtree",
+		},
+		{
+			name:   "delete without node_uid",
+			ops:    []PatchOp{{Op: PatchOpDelete}},
+			reason: "delete requires node_uid",
+		},
+		{
+			name: "update after delete in same batch",
+			ops: []PatchOp{
+				{Op: PatchOpDelete, NodeUID: "a"},
+				{Op: PatchOpUpdate, NodeUID: "a1x", Patch: &PartialUpdate{}},
+			},
+			index:  1,
+			reason: "conflict: block a1x was deleted by op #0",
+		},
+		{
+			name: "move after parent deleted",
+			ops: []PatchOp{
+				{Op: PatchOpDelete, NodeUID: "c"},
+				{Op: PatchOpMove, NodeUID: "b", NewParentUID: strPtr("c")},
+			},
+			index:  1,
+			reason: "new_parent_uid: conflict: block c was deleted by op #0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := sampleBlocks()
+			result, effective, err := ApplyPatch(original, tt.ops)
+			if err == nil {
+				t.Fatalf("expected error, got result %v", result)
+			}
+			if result != nil || effective != nil {
+				t.Fatalf("rejected patch must not return content")
+			}
+			var perr *PatchError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error %T is not *PatchError", err)
+			}
+			if perr.Index != tt.index || perr.Op != tt.ops[tt.index].Op {
+				t.Fatalf("error at op #%d (%s), want #%d (%s)", perr.Index, perr.Op, tt.index, tt.ops[tt.index].Op)
+			}
+			if !strings.Contains(perr.Reason, tt.reason) {
+				t.Fatalf("reason %q, want %q", perr.Reason, tt.reason)
+			}
+			if !reflect.DeepEqual(original, sampleBlocks()) {
+				t.Fatalf("rejected patch modified the input")
+			}
+		})
+	}
+}
+
+func TestApplyPatchReusesDeletedID(t *testing.T) {
+	// 同一批次中删除后重新插入同 ID 的块是合法的
+	ops := []PatchOp{
+		{Op: PatchOpDelete, NodeUID: "b"},
+		{Op: PatchOpInsert, Block: &NoteBlockDTO{ID: "b", Type: "heading"}, NewParentUID: strPtr("c")},
+		{Op: PatchOpUpdate, NodeUID: "b", Patch: &PartialUpdate{Type: strPtr("paragraph")}},
+	}
+	result, effective, err := ApplyPatch(sampleBlocks(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(effective) != 3 {
+		t.Fatalf("got %d effective ops, want 3", len(effective))
+	}
+	if parents := blockIndex(t, result); parents["b"] != "c" {
+		t.Fatalf("b is under %q, want c", parents["b"])
+	}
+}
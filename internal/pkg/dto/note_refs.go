@@ -0,0 +1,124 @@
+package dto
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type NoteRefType string
+
+const (
+	NoteRefNote NoteRefType = "note"
+	NoteRefTask NoteRefType = "task"
+)
+
+// NoteRef 从笔记内容中解析出的一条引用；TargetID 为 0 时表示仅有标题，需要按标题解析
+type NoteRef struct {
+	Type     NoteRefType
+	TargetID int64
+	Title    string
+	BlockID  string
+}
+
+var (
+	// [[笔记标题]]、[[笔记标题|别名]]、[[note:123]]、[[task:123]]
+	wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]\n]{1,255})\]\]`)
+	// note://123、/notes/123、?task_id=123 等站内链接
+	refHrefPattern = regexp.MustCompile(`(?i)(?:^|[/?&#])(note|task)s?(?:_id)?(?:://|/|=)(\d+)`)
+)
+
+// ExtractNoteRefs 递归遍历 BlockNote 内容，提取对笔记和任务的引用
+func ExtractNoteRefs(blocks Blocks) []NoteRef {
+	refs := make([]NoteRef, 0)
+	var walk func(items []NoteBlockDTO)
+	walk = func(items []NoteBlockDTO) {
+		for _, block := range items {
+			refs = append(refs, extractInlineRefs(block.ID, block.Content)...)
+			if len(block.Children) > 0 {
+				walk(block.Children)
+			}
+		}
+	}
+	walk(blocks)
+	return refs
+}
+
+func extractInlineRefs(blockID string, content []InlineDTO) []NoteRef {
+	refs := make([]NoteRef, 0)
+
+	// 文本可能因样式被拆成多段 run，拼接后再匹配 [[...]]
+	var text strings.Builder
+	var walk func(items []InlineDTO)
+	walk = func(items []InlineDTO) {
+		for _, inline := range items {
+			text.WriteString(inline.Text)
+
+			if inline.Href != "" {
+				if m := refHrefPattern.FindStringSubmatch(inline.Href); m != nil {
+					if id, err := strconv.ParseInt(m[2], 10, 64); err == nil {
+						refs = append(refs, NoteRef{Type: NoteRefType(strings.ToLower(m[1])), TargetID: id, BlockID: blockID})
+					}
+				}
+			}
+
+			if id := inlinePropID(inline.Props, "noteId", "note_id"); id > 0 {
+				refs = append(refs, NoteRef{Type: NoteRefNote, TargetID: id, BlockID: blockID})
+			}
+			if id := inlinePropID(inline.Props, "taskId", "task_id"); id > 0 {
+				refs = append(refs, NoteRef{Type: NoteRefTask, TargetID: id, BlockID: blockID})
+			}
+
+			if len(inline.Content) > 0 {
+				walk(inline.Content)
+			}
+		}
+	}
+	walk(content)
+
+	for _, m := range wikiLinkPattern.FindAllStringSubmatch(text.String(), -1) {
+		inner := strings.TrimSpace(m[1])
+		if idx := strings.Index(inner, "|"); idx >= 0 {
+			inner = strings.TrimSpace(inner[:idx])
+		}
+		if inner == "" {
+			continue
+		}
+
+		if kind, rawID, ok := strings.Cut(inner, ":"); ok {
+			kind = strings.ToLower(strings.TrimSpace(kind))
+			if kind == string(NoteRefNote) || kind == string(NoteRefTask) {
+				if id, err := strconv.ParseInt(strings.TrimSpace(rawID), 10, 64); err == nil && id > 0 {
+					refs = append(refs, NoteRef{Type: NoteRefType(kind), TargetID: id, BlockID: blockID})
+					continue
+				}
+			}
+		}
+
+		refs = append(refs, NoteRef{Type: NoteRefNote, Title: inner, BlockID: blockID})
+	}
+	return refs
+}
+
+func inlinePropID(props map[string]interface{}, keys ...string) int64 {
+	for _, key := range keys {
+		v, ok := props[key]
+		if !ok || v == nil {
+			continue
+		}
+		var raw string
+		switch val := v.(type) {
+		case string:
+			raw = val
+		case float64:
+			raw = strconv.FormatFloat(val, 'f', 0, 64)
+		default:
+			raw = fmt.Sprint(val)
+		}
+		if id, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"gin-notebook/internal/model"
+	"time"
+)
+
+// GetNoteSyncHealthDTO 查看笔记上各同步链接的健康状况
+type GetNoteSyncHealthDTO struct {
+	NoteID      int64 `form:"note_id,string" validate:"required,gt=0"`
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	UserID      int64 `validate:"required,gt=0"`
+}
+
+// GetSyncOutboxDTO 查看链接的 outbox 队列，status 为空时返回全部
+type GetSyncOutboxDTO struct {
+	NoteID      int64             `form:"note_id,string" validate:"required,gt=0"`
+	WorkspaceID int64             `form:"workspace_id,string" validate:"required,gt=0"`
+	LinkID      int64             `form:"link_id,string" validate:"required,gt=0"`
+	Status      *model.SyncStatus `form:"status" validate:"omitempty,oneof=pending running success failed skipped dead"`
+	Limit       int               `form:"limit" validate:"omitempty,gt=0,lte=200"`
+	UserID      int64             `validate:"required,gt=0"`
+}
+
+// SyncLinkOperateDTO 对单个同步链接执行重试、跳过或重建
+type SyncLinkOperateDTO struct {
+	NoteID      int64 `json:"note_id,string" validate:"required,gt=0"`
+	WorkspaceID int64 `json:"workspace_id,string" validate:"required,gt=0"`
+	LinkID      int64 `json:"link_id,string" validate:"required,gt=0"`
+	UserID      int64 `validate:"required,gt=0"`
+}
+
+// GetSyncDashboardDTO 工作区管理员查看全部同步链接，Unhealthy 只返回有失败或死信的链接
+type GetSyncDashboardDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	Unhealthy   bool  `form:"unhealthy"`
+	Page        int   `form:"page" validate:"omitempty,gt=0"`
+	PageSize    int   `form:"page_size" validate:"omitempty,gt=0,lte=100"`
+	UserID      int64 `validate:"required,gt=0"`
+}
+
+// SyncOutboxStat 按链接与状态聚合的 outbox 数量
+type SyncOutboxStat struct {
+	LinkID      int64
+	Status      model.SyncStatus
+	Count       int64
+	NextRetryAt *time.Time
+}
+
+// SyncRetryTarget 需要重新投递推送任务的链接
+type SyncRetryTarget struct {
+	LinkID      int64
+	NoteID      int64
+	WorkspaceID int64
+	MemberID    int64
+	UserID      int64
+}
@@ -0,0 +1,201 @@
+package dto
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TemplateVariableType 模板自定义变量的输入类型
+type TemplateVariableType string
+
+const (
+	TemplateVariableText   TemplateVariableType = "text"
+	TemplateVariableDate   TemplateVariableType = "date"
+	TemplateVariableNumber TemplateVariableType = "number"
+	TemplateVariableSelect TemplateVariableType = "select"
+)
+
+// 内置占位符，实例化时由服务端填充，不能作为自定义变量名
+const (
+	TemplateVarDate      = "date"
+	TemplateVarTime      = "time"
+	TemplateVarDateTime  = "datetime"
+	TemplateVarWeekday   = "weekday"
+	TemplateVarUser      = "user"
+	TemplateVarProject   = "project"
+	TemplateVarWorkspace = "workspace"
+	TemplateVarTitle     = "title"
+)
+
+var builtinTemplateVars = []string{
+	TemplateVarDate, TemplateVarTime, TemplateVarDateTime, TemplateVarWeekday,
+	TemplateVarUser, TemplateVarProject, TemplateVarWorkspace, TemplateVarTitle,
+}
+
+// 占位符形如 {{date}}、{{ customer_name }}；日期类支持 {{date:2006/01/02}} 指定 Go 时间格式
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)(?::([^{}]+))?\s*\}\}`)
+var templateVarKey = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// TemplateVariableDTO 模板自定义变量（实例化时向用户提问）
+type TemplateVariableDTO struct {
+	Key      string               `json:"key" validate:"required,min=1,max=32"`
+	Label    string               `json:"label" validate:"omitempty,max=64"`
+	Type     TemplateVariableType `json:"type" validate:"omitempty,oneof=text date number select"`
+	Default  string               `json:"default" validate:"omitempty,max=255"`
+	Required bool                 `json:"required"`
+	Options  []string             `json:"options,omitempty" validate:"required_if=Type select,omitempty,max=50,dive,max=64"`
+}
+
+// IsValidTemplateVarKey 变量名需能被占位符语法引用
+func IsValidTemplateVarKey(key string) bool {
+	return templateVarKey.MatchString(key)
+}
+
+func IsBuiltinTemplateVar(key string) bool {
+	for _, k := range builtinTemplateVars {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltinTemplateValues 生成内置占位符的取值；日期类的值为 RFC3339，渲染时再按格式输出
+func BuiltinTemplateValues(now time.Time, user, project, workspace, title string) map[string]string {
+	return map[string]string{
+		TemplateVarDate:      now.Format(time.RFC3339),
+		TemplateVarTime:      now.Format(time.RFC3339),
+		TemplateVarDateTime:  now.Format(time.RFC3339),
+		TemplateVarWeekday:   now.Format(time.RFC3339),
+		TemplateVarUser:      user,
+		TemplateVarProject:   project,
+		TemplateVarWorkspace: workspace,
+		TemplateVarTitle:     title,
+	}
+}
+
+// RenderTemplateText 替换文本中的占位符；未提供取值的占位符原样保留
+func RenderTemplateText(text string, values map[string]string, types map[string]TemplateVariableType) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return templatePlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		sub := templatePlaceholder.FindStringSubmatch(match)
+		key, layout := sub[1], strings.TrimSpace(sub[2])
+		value, ok := values[key]
+		if !ok {
+			return match
+		}
+		return formatTemplateValue(key, value, layout, types[key])
+	})
+}
+
+func formatTemplateValue(key, value, layout string, varType TemplateVariableType) string {
+	defaultLayout := ""
+	switch key {
+	case TemplateVarDate:
+		defaultLayout = "2006-01-02"
+	case TemplateVarTime:
+		defaultLayout = "15:04"
+	case TemplateVarDateTime:
+		defaultLayout = "2006-01-02 15:04"
+	case TemplateVarWeekday:
+		defaultLayout = "Monday"
+	default:
+		if varType == TemplateVariableDate {
+			defaultLayout = "2006-01-02"
+		}
+	}
+	if defaultLayout == "" {
+		return value
+	}
+	if layout == "" {
+		layout = defaultLayout
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", value); err != nil {
+			return value
+		}
+	}
+	return t.Format(layout)
+}
+
+// RenderTemplateBlocks 深拷贝模板内容并渲染占位符。
+// 每个块都会分配新的 ID，避免多个实例共享块 ID 导致评论锚点、同步映射串号。
+// 占位符需完整地出现在同一个文本 run 中，跨样式拆分的占位符不会被识别。
+func RenderTemplateBlocks(blocks Blocks, values map[string]string, types map[string]TemplateVariableType) Blocks {
+	var renderInlines func(items []InlineDTO) []InlineDTO
+	renderInlines = func(items []InlineDTO) []InlineDTO {
+		out := make([]InlineDTO, 0, len(items))
+		for _, inline := range items {
+			inline.Text = RenderTemplateText(inline.Text, values, types)
+			if len(inline.Content) > 0 {
+				inline.Content = renderInlines(inline.Content)
+			}
+			out = append(out, inline)
+		}
+		return out
+	}
+
+	var renderBlocks func(items []NoteBlockDTO) []NoteBlockDTO
+	renderBlocks = func(items []NoteBlockDTO) []NoteBlockDTO {
+		out := make([]NoteBlockDTO, 0, len(items))
+		for _, block := range items {
+			block.ID = uuid.NewString()
+			if block.Props.Caption != nil {
+				block.Props.Caption = renderTemplatePtr(block.Props.Caption, values, types)
+			}
+			block.Content = renderInlines(block.Content)
+			block.Children = renderBlocks(block.Children)
+			out = append(out, block)
+		}
+		return out
+	}
+
+	return renderBlocks(blocks)
+}
+
+func renderTemplatePtr(s *string, values map[string]string, types map[string]TemplateVariableType) *string {
+	rendered := RenderTemplateText(*s, values, types)
+	return &rendered
+}
+
+// ExtractTemplatePlaceholders 收集内容中出现的占位符名（去重，保持首次出现顺序）
+func ExtractTemplatePlaceholders(title string, blocks Blocks) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	collect := func(text string) {
+		for _, sub := range templatePlaceholder.FindAllStringSubmatch(text, -1) {
+			if !seen[sub[1]] {
+				seen[sub[1]] = true
+				keys = append(keys, sub[1])
+			}
+		}
+	}
+
+	collect(title)
+	var walkInlines func(items []InlineDTO)
+	walkInlines = func(items []InlineDTO) {
+		for _, inline := range items {
+			collect(inline.Text)
+			walkInlines(inline.Content)
+		}
+	}
+	var walk func(items []NoteBlockDTO)
+	walk = func(items []NoteBlockDTO) {
+		for _, block := range items {
+			if block.Props.Caption != nil {
+				collect(*block.Props.Caption)
+			}
+			walkInlines(block.Content)
+			walk(block.Children)
+		}
+	}
+	walk(blocks)
+	return keys
+}
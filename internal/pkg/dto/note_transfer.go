@@ -0,0 +1,31 @@
+package dto
+
+// NoteTransferMode 跨工作区转移方式
+type NoteTransferMode string
+
+const (
+	NoteTransferCopy NoteTransferMode = "copy"
+	NoteTransferMove NoteTransferMode = "move"
+)
+
+// SyncLinkPolicy 转移时外部同步链接的处理方式：
+// keep 保持原样（移动时随笔记走，复制时留在原笔记）；drop 删除；
+// repoint 改挂到目标工作区中同一用户的成员下，复制时从原笔记转到副本
+type SyncLinkPolicy string
+
+const (
+	SyncLinkKeep    SyncLinkPolicy = "keep"
+	SyncLinkDrop    SyncLinkPolicy = "drop"
+	SyncLinkRepoint SyncLinkPolicy = "repoint"
+)
+
+type TransferNoteDTO struct {
+	WorkspaceID       int64            `json:"workspace_id,string" validate:"required,gt=0"`
+	NoteID            int64            `json:"note_id,string" validate:"required,gt=0"`
+	TargetWorkspaceID int64            `json:"target_workspace_id,string" validate:"required,gt=0,nefield=WorkspaceID"`
+	TargetCategoryID  int64            `json:"target_category_id,string" validate:"required,gt=0"`
+	Mode              NoteTransferMode `json:"mode" validate:"required,oneof=copy move"`
+	IncludeComments   bool             `json:"include_comments"`
+	SyncLinks         SyncLinkPolicy   `json:"sync_links" validate:"required,oneof=keep drop repoint"`
+	UserID            int64            `json:"-"`
+}
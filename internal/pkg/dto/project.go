@@ -60,6 +60,10 @@ type ProjectTaskDTO struct {
 	MemberID    int64           `validate:"required,gt=0"`                            // 用户ID
 	UpdatedAt   time.Time       `json:"updated_at" validate:"omitempty"`              // 用于乐观锁
 	Cover       *string         `json:"cover" validate:"omitempty,max=255"`           // 任务封面图片
+
+	// 由服务端调用方（如接收 webhook）填写
+	Description Blocks                 `json:"-"` // 任务描述，为空时使用默认内容
+	Source      map[string]interface{} `json:"-"` // 任务来源，写入创建动态的摘要参数
 }
 
 type ListProjectsDTO struct {
@@ -0,0 +1,104 @@
+package dto
+
+import "gin-notebook/internal/model"
+
+// CreateJiraProjectLinkDTO 未提供的映射按默认规则生成
+type CreateJiraProjectLinkDTO struct {
+	WorkspaceID int64               `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID   int64               `validate:"required,gt=0"`
+	ProjectKey  string              `json:"project_key" validate:"required,max=64"`
+	Direction   model.SyncDirection `json:"direction" validate:"omitempty,oneof=push pull both"`
+	MemberID    int64               `validate:"required,gt=0"`
+	UserID      int64               `validate:"required,gt=0"`
+}
+
+// ProjectLinkQueryDTO 查询或删除项目的外部链接，列表查询时 LinkID 为空
+type ProjectLinkQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID   int64 `validate:"required,gt=0"`
+	LinkID      int64 `validate:"omitempty,gt=0"`
+	UserID      int64 `validate:"required,gt=0"`
+}
+
+// UpdateJiraProjectLinkDTO 只修改提供的字段，映射整体替换
+type UpdateJiraProjectLinkDTO struct {
+	WorkspaceID     int64                        `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID       int64                        `validate:"required,gt=0"`
+	LinkID          int64                        `validate:"required,gt=0"`
+	Direction       *model.SyncDirection         `json:"direction" validate:"omitempty,oneof=push pull both"`
+	IsActive        *bool                        `json:"is_active" validate:"omitempty"`
+	StatusMapping   *[]model.TaskStatusMapping   `json:"status_mapping" validate:"omitempty,dive"`
+	PriorityMapping *map[string]string           `json:"priority_mapping" validate:"omitempty"`
+	AssigneeMapping *[]model.TaskAssigneeMapping `json:"assignee_mapping" validate:"omitempty,dive"`
+	UserID          int64                        `validate:"required,gt=0"`
+}
+
+// JiraWebhookDTO Jira webhook 回调；Body 为原始请求体，用于校验签名
+type JiraWebhookDTO struct {
+	LinkID    int64  `validate:"required,gt=0"`
+	Signature string `header:"X-Hub-Signature"`
+	Body      []byte `validate:"required"`
+}
+
+// CreateGitHubProjectLinkDTO Repository 为 owner/repo；未指定合并后的目标列时取第一个已完成阶段的列
+type CreateGitHubProjectLinkDTO struct {
+	WorkspaceID   int64  `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID     int64  `validate:"required,gt=0"`
+	Repository    string `json:"repository" validate:"required,max=140"`
+	MergeColumnID int64  `json:"merge_column_id,string" validate:"omitempty,gt=0"`
+	MemberID      int64  `validate:"required,gt=0"`
+	UserID        int64  `validate:"required,gt=0"`
+}
+
+// UpdateGitHubProjectLinkDTO StatusMapping 的 status_id 为 PR 状态（opened、merged、closed）
+type UpdateGitHubProjectLinkDTO struct {
+	WorkspaceID   int64                      `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID     int64                      `validate:"required,gt=0"`
+	LinkID        int64                      `validate:"required,gt=0"`
+	IsActive      *bool                      `json:"is_active" validate:"omitempty"`
+	StatusMapping *[]model.TaskStatusMapping `json:"status_mapping" validate:"omitempty,dive"`
+	UserID        int64                      `validate:"required,gt=0"`
+}
+
+// GitHubWebhookDTO GitHub webhook 回调；Body 为原始请求体，用于校验签名
+type GitHubWebhookDTO struct {
+	LinkID    int64  `validate:"required,gt=0"`
+	Event     string `header:"X-GitHub-Event" validate:"required"`
+	Delivery  string `header:"X-GitHub-Delivery"`
+	Signature string `header:"X-Hub-Signature-256"`
+	Body      []byte `validate:"required"`
+}
+
+// CreateIncomingHookDTO 项目的接收 webhook，推送的任务创建在 ColumnID 列
+type CreateIncomingHookDTO struct {
+	WorkspaceID int64  `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID   int64  `validate:"required,gt=0"`
+	Name        string `json:"name" validate:"required,max=64"`
+	ColumnID    int64  `json:"column_id,string" validate:"required,gt=0"`
+	MemberID    int64  `validate:"required,gt=0"`
+	UserID      int64  `validate:"required,gt=0"`
+}
+
+// UpdateIncomingHookDTO RegenerateToken 为 true 时生成新令牌，旧令牌立即失效
+type UpdateIncomingHookDTO struct {
+	WorkspaceID     int64   `json:"workspace_id,string" validate:"required,gt=0"`
+	ProjectID       int64   `validate:"required,gt=0"`
+	LinkID          int64   `validate:"required,gt=0"`
+	Name            *string `json:"name" validate:"omitempty,min=1,max=64"`
+	ColumnID        *int64  `json:"column_id,string" validate:"omitempty,gt=0"`
+	IsActive        *bool   `json:"is_active" validate:"omitempty"`
+	RegenerateToken bool    `json:"regenerate_token"`
+	UserID          int64   `validate:"required,gt=0"`
+}
+
+// IncomingTaskDTO 外部系统推送的任务；ExternalKey 相同的推送只创建一次任务
+type IncomingTaskDTO struct {
+	LinkID        int64    `json:"-" validate:"required,gt=0"`
+	Token         string   `json:"-"` // 请求头 X-Webhook-Token 或查询参数 token
+	Title         string   `json:"title" validate:"required,max=1000"`
+	Description   string   `json:"description" validate:"omitempty,max=65536"` // Markdown
+	Priority      string   `json:"priority" validate:"omitempty,oneof=low medium high"`
+	Labels        []string `json:"labels" validate:"omitempty,max=20,dive,required,max=64"`
+	AssigneeEmail string   `json:"assignee_email" validate:"omitempty,email"`
+	ExternalKey   string   `json:"external_key" validate:"omitempty,max=64"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+type TrashItemType string
+
+const (
+	TrashItemNote TrashItemType = "note"
+	TrashItemTask TrashItemType = "task"
+)
+
+type TrashQueryDTO struct {
+	WorkspaceID int64         `form:"workspace_id,string" validate:"required,gt=0"`
+	Type        TrashItemType `form:"type" validate:"omitempty,oneof=note task"` // 为空时同时返回笔记与任务
+	Limit       int           `form:"limit" validate:"omitempty,gt=0,lte=100"`
+	Offset      int           `form:"offset" validate:"omitempty,gte=0"`
+	UserID      int64         `form:"-"`
+}
+
+type TrashActionDTO struct {
+	WorkspaceID int64         `json:"workspace_id,string" validate:"required,gt=0"`
+	Type        TrashItemType `json:"type" validate:"required,oneof=note task"`
+	ID          int64         `json:"id,string" validate:"required,gt=0"`
+	UserID      int64         `json:"-"`
+}
+
+// TrashItemDTO 回收站条目；ParentID 为笔记分类或任务所在列
+type TrashItemDTO struct {
+	ID         int64         `json:"id,string"`
+	Type       TrashItemType `json:"type"`
+	Title      string        `json:"title"`
+	OwnerID    int64         `json:"owner_id,string"`
+	ParentID   int64         `json:"parent_id,string"`
+	ParentName string        `json:"parent_name"`
+	ProjectID  *int64        `json:"project_id,string,omitempty"`
+	DeletedAt  time.Time     `json:"deleted_at"`
+	PurgeAt    time.Time     `json:"purge_at" gorm:"-"` // 预计被彻底删除的时间
+}
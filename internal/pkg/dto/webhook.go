@@ -0,0 +1,59 @@
+package dto
+
+import "strconv"
+
+// CreateWebhookDTO Events 为空订阅全部事件，ProjectIDs 为空不按项目过滤
+type CreateWebhookDTO struct {
+	WorkspaceID int64    `json:"workspace_id,string" validate:"required,gt=0"`
+	Name        string   `json:"name" validate:"required,max=128"`
+	URL         string   `json:"url" validate:"required,url,max=1024"`
+	Events      []string `json:"events" validate:"omitempty,max=20,dive,required"`
+	ProjectIDs  []string `json:"project_ids" validate:"omitempty,max=50,dive,numeric"`
+	MemberID    int64    `json:"-"`
+	UserID      int64    `json:"-"`
+}
+
+// UpdateWebhookDTO 只修改提供的字段，Events 与 ProjectIDs 整体替换
+type UpdateWebhookDTO struct {
+	WorkspaceID int64     `json:"workspace_id,string" validate:"required,gt=0"`
+	WebhookID   int64     `json:"-" validate:"required,gt=0"`
+	Name        *string   `json:"name" validate:"omitempty,min=1,max=128"`
+	URL         *string   `json:"url" validate:"omitempty,url,max=1024"`
+	Events      *[]string `json:"events" validate:"omitempty,max=20,dive,required"`
+	ProjectIDs  *[]string `json:"project_ids" validate:"omitempty,max=50,dive,numeric"`
+	IsActive    *bool     `json:"is_active" validate:"omitempty"`
+	UserID      int64     `json:"-"`
+}
+
+// WebhookQueryDTO 查询、删除或测试 webhook，列表查询时 WebhookID 为空
+type WebhookQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	WebhookID   int64 `form:"-" validate:"omitempty,gt=0"`
+	UserID      int64 `form:"-"`
+}
+
+type WebhookDeliveryQueryDTO struct {
+	WorkspaceID int64 `form:"workspace_id,string" validate:"required,gt=0"`
+	WebhookID   int64 `form:"-" validate:"required,gt=0"`
+	Limit       int   `form:"limit" validate:"omitempty,gt=0,lte=100"`
+	Offset      int   `form:"offset" validate:"omitempty,gte=0"`
+	UserID      int64 `form:"-"`
+}
+
+// ParseWebhookProjectIDs 解析并去重项目 ID
+func ParseWebhookProjectIDs(raw []string) ([]int64, error) {
+	ids := make([]int64, 0, len(raw))
+	seen := make(map[int64]struct{}, len(raw))
+	for _, s := range raw {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
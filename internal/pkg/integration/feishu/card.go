@@ -0,0 +1,63 @@
+package feishu
+
+// TaskCard 看板通知卡片：标题栏、正文、字段、可选的引用内容与跳转按钮
+type TaskCard struct {
+	Title    string
+	Template string // 标题栏颜色，如 blue、orange、red
+	Summary  string // lark_md 正文
+	Fields   []CardField
+	Quote    string // 评论等引用内容，按纯文本展示
+	URL      string // 为空时不显示跳转按钮
+	Button   string
+}
+
+type CardField struct {
+	Name  string
+	Value string
+}
+
+func (c *TaskCard) build() map[string]interface{} {
+	elements := []map[string]interface{}{
+		{"tag": "div", "text": map[string]interface{}{"tag": "lark_md", "content": c.Summary}},
+	}
+	if len(c.Fields) > 0 {
+		fields := make([]map[string]interface{}, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			fields = append(fields, map[string]interface{}{
+				"is_short": true,
+				"text":     map[string]interface{}{"tag": "lark_md", "content": "**" + f.Name + "**\n" + f.Value},
+			})
+		}
+		elements = append(elements, map[string]interface{}{"tag": "div", "fields": fields})
+	}
+	if c.Quote != "" {
+		elements = append(elements,
+			map[string]interface{}{"tag": "hr"},
+			map[string]interface{}{"tag": "div", "text": map[string]interface{}{"tag": "plain_text", "content": c.Quote}},
+		)
+	}
+	if c.URL != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "action",
+			"actions": []map[string]interface{}{{
+				"tag":  "button",
+				"type": "primary",
+				"text": map[string]interface{}{"tag": "plain_text", "content": c.Button},
+				"url":  c.URL,
+			}},
+		})
+	}
+
+	template := c.Template
+	if template == "" {
+		template = "blue"
+	}
+	return map[string]interface{}{
+		"config": map[string]interface{}{"wide_screen_mode": true},
+		"header": map[string]interface{}{
+			"template": template,
+			"title":    map[string]interface{}{"tag": "plain_text", "content": c.Title},
+		},
+		"elements": elements,
+	}
+}
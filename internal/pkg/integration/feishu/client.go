@@ -279,11 +279,14 @@ func (c *Client) TransferMarkdownToBlock(ctx context.Context, userToken, content
 }
 
 func (c *Client) GetNoteAllBlocks(ctx context.Context, userToken, documentID string, pageToken *string) (*larkdocx.ListDocumentBlockRespData, error) {
-	req := larkdocx.NewListDocumentBlockReqBuilder().
+	builder := larkdocx.NewListDocumentBlockReqBuilder().
 		DocumentId(documentID).
 		PageSize(500).
-		DocumentRevisionId(-1).
-		Build()
+		DocumentRevisionId(-1)
+	if pageToken != nil {
+		builder = builder.PageToken(*pageToken)
+	}
+	req := builder.Build()
 
 	resp, err := c.Client.Docx.V1.DocumentBlock.List(context.Background(), req, larkcore.WithUserAccessToken(userToken))
 	if err != nil {
@@ -440,3 +443,46 @@ func (c *Client) BatchUpdateBlocks(ctx context.Context, noteID, userToken string
 	fmt.Println(resp.Data.ClientToken)
 	return resp.Data, nil
 }
+
+// GetDocumentRevision 读取文档当前版本号，文档任何修改都会使其递增
+func (c *Client) GetDocumentRevision(ctx context.Context, userToken, documentID string) (int, error) {
+	req := larkdocx.NewGetDocumentReqBuilder().
+		DocumentId(documentID).
+		Build()
+
+	resp, err := c.Client.Docx.V1.Document.Get(ctx, req, larkcore.WithUserAccessToken(userToken))
+	if err != nil {
+		logger.LogError(err, "Feishu GetDocumentRevision error")
+		return 0, err
+	}
+	if !resp.Success() {
+		logger.LogError(resp.CodeError, "Feishu GetDocumentRevision response error")
+		return 0, resp.CodeError
+	}
+	if resp.Data == nil || resp.Data.Document == nil || resp.Data.Document.RevisionId == nil {
+		return 0, fmt.Errorf("feishu: empty revision of %s", documentID)
+	}
+	return *resp.Data.Document.RevisionId, nil
+}
+
+// SubscribeFileEvents 以用户身份订阅文档事件，之后文档被编辑时会推送 drive.file.edit_v1；重复订阅是幂等的
+func (c *Client) SubscribeFileEvents(ctx context.Context, userToken, fileToken, fileType string) error {
+	if fileType == "" {
+		fileType = "docx"
+	}
+	req := larkdrive.NewSubscribeFileReqBuilder().
+		FileToken(fileToken).
+		FileType(fileType).
+		Build()
+
+	resp, err := c.Client.Drive.V1.File.Subscribe(ctx, req, larkcore.WithUserAccessToken(userToken))
+	if err != nil {
+		logger.LogError(err, "Feishu SubscribeFileEvents error")
+		return err
+	}
+	if !resp.Success() {
+		logger.LogError(resp.CodeError, "Feishu SubscribeFileEvents response error")
+		return resp.CodeError
+	}
+	return nil
+}
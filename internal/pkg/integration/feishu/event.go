@@ -0,0 +1,81 @@
+package feishu
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	larkevent "github.com/larksuite/oapi-sdk-go/v3/event"
+	larkdrive "github.com/larksuite/oapi-sdk-go/v3/service/drive/v1"
+)
+
+const (
+	EventURLVerification = "url_verification"
+	EventFileEdit        = "drive.file.edit_v1"
+)
+
+// Event 事件回调解密后的统一结构；url_verification 为 1.0 格式，token 与 challenge 在顶层
+type Event struct {
+	Schema    string                 `json:"schema"`
+	Type      string                 `json:"type"`
+	Token     string                 `json:"token"`
+	Challenge string                 `json:"challenge"`
+	Header    *larkevent.EventHeader `json:"header"`
+	Event     json.RawMessage        `json:"event"`
+}
+
+func (e *Event) EventType() string {
+	if e.Header != nil {
+		return e.Header.EventType
+	}
+	return e.Type
+}
+
+func (e *Event) VerificationToken() string {
+	if e.Header != nil {
+		return e.Header.Token
+	}
+	return e.Token
+}
+
+// VerifySignature 校验 X-Lark-Signature：sha256(timestamp + nonce + encryptKey + body)
+func VerifySignature(timestamp, nonce, encryptKey string, body []byte, signature string) bool {
+	expected := larkevent.Signature(timestamp, nonce, encryptKey, string(body))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
+
+// ParseEvent 解析回调请求体，配置了 Encrypt Key 时先解密 encrypt 字段
+func ParseEvent(body []byte, encryptKey string) (*Event, error) {
+	var encrypted larkevent.EventEncryptMsg
+	if err := json.Unmarshal(body, &encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.Encrypt != "" {
+		if encryptKey == "" {
+			return nil, fmt.Errorf("feishu: encrypted event but encrypt key not configured")
+		}
+		plain, err := larkevent.EventDecrypt(encrypted.Encrypt, encryptKey)
+		if err != nil {
+			return nil, err
+		}
+		body = plain
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// FileEditToken 取出文档编辑事件中的文档 token
+func (e *Event) FileEditToken() (string, error) {
+	var data larkdrive.P2FileEditV1Data
+	if err := json.Unmarshal(e.Event, &data); err != nil {
+		return "", err
+	}
+	if data.FileToken == nil || *data.FileToken == "" {
+		return "", fmt.Errorf("feishu: file edit event without file_token")
+	}
+	return *data.FileToken, nil
+}
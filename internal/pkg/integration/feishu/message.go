@@ -0,0 +1,75 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gin-notebook/pkg/logger"
+
+	larkcore "github.com/larksuite/oapi-sdk-go/v3/core"
+	larkim "github.com/larksuite/oapi-sdk-go/v3/service/im/v1"
+)
+
+// 通知卡片的接收方类型
+const (
+	ReceiveOpenID = larkim.ReceiveIdTypeOpenId
+	ReceiveChatID = larkim.ReceiveIdTypeChatId
+)
+
+// GetUserOpenID 用户访问令牌对应的 open_id，机器人私信按 open_id 发送
+func (c *Client) GetUserOpenID(ctx context.Context, userToken string) (string, error) {
+	resp, err := c.Client.Authen.V1.UserInfo.Get(ctx, larkcore.WithUserAccessToken(userToken))
+	if err != nil {
+		logger.LogError(err, "Feishu GetUserOpenID error")
+		return "", err
+	}
+	if !resp.Success() {
+		logger.LogError(resp.CodeError, "Feishu GetUserOpenID response error")
+		return "", resp.CodeError
+	}
+	if resp.Data == nil || resp.Data.OpenId == nil {
+		return "", fmt.Errorf("open_id not found in response")
+	}
+	return *resp.Data.OpenId, nil
+}
+
+// GetChatName 以应用身份读取群信息，机器人不在群内时返回错误
+func (c *Client) GetChatName(ctx context.Context, chatID string) (string, error) {
+	resp, err := c.Client.Im.V1.Chat.Get(ctx, larkim.NewGetChatReqBuilder().ChatId(chatID).Build())
+	if err != nil {
+		logger.LogError(err, "Feishu GetChatName error")
+		return "", err
+	}
+	if !resp.Success() {
+		return "", resp.CodeError
+	}
+	if resp.Data == nil || resp.Data.Name == nil {
+		return "", nil
+	}
+	return *resp.Data.Name, nil
+}
+
+// SendCard 以机器人身份发送交互卡片；uuid 相同的消息飞书在一小时内只发送一次，重试时不会重复
+func (c *Client) SendCard(ctx context.Context, receiveIDType, receiveID string, card *TaskCard, uuid string) error {
+	content, err := json.Marshal(card.build())
+	if err != nil {
+		return err
+	}
+	req := larkim.NewCreateMessageReqBuilder().
+		ReceiveIdType(receiveIDType).
+		Body(larkim.NewCreateMessageReqBodyBuilder().
+			ReceiveId(receiveID).
+			MsgType(larkim.MsgTypeInteractive).
+			Content(string(content)).
+			Uuid(uuid).
+			Build()).
+		Build()
+	resp, err := c.Client.Im.V1.Message.Create(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !resp.Success() {
+		return resp.CodeError
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package feishu
 
 import (
 	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/pkg/utils/tools"
 
+	"github.com/google/uuid"
 	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
 )
 
@@ -147,3 +149,110 @@ func ParseBlockToLark(blocks dto.Blocks) []*larkdocx.Block {
 
 	return feishuBlocks
 }
+
+func GetLocalTextColor(color int) string {
+	switch color {
+	case 1:
+		return "pink"
+	case 2:
+		return "orange"
+	case 3:
+		return "yellow"
+	case 4:
+		return "green"
+	case 5:
+		return "blue"
+	case 6:
+		return "purple"
+	case 7:
+		return "gray"
+	}
+	return "default"
+}
+
+func GetLocalTextAlignment(alignment int) string {
+	switch alignment {
+	case 2:
+		return "center"
+	case 3:
+		return "right"
+	}
+	return "left"
+}
+
+// ParseLarkToBlock ParseBlockToLark 的逆向转换，仅支持文本类块；不支持的块类型返回 false
+func ParseLarkToBlock(block *larkdocx.Block) (dto.NoteBlockDTO, bool) {
+	if block == nil || block.BlockType == nil {
+		return dto.NoteBlockDTO{}, false
+	}
+	local := dto.NoteBlockDTO{
+		ID:       uuid.NewString(),
+		Content:  []dto.InlineDTO{},
+		Children: []dto.NoteBlockDTO{},
+	}
+
+	var text *larkdocx.Text
+	switch blockType := *block.BlockType; {
+	case blockType == 2:
+		local.Type, text = "paragraph", block.Text
+	case blockType >= 3 && blockType <= 11:
+		level := blockType - 2
+		local.Type = "heading"
+		local.Props.Level = &level
+		text = []*larkdocx.Text{block.Heading1, block.Heading2, block.Heading3, block.Heading4, block.Heading5,
+			block.Heading6, block.Heading7, block.Heading8, block.Heading9}[level-1]
+	case blockType == 12:
+		local.Type, text = "bulletListItem", block.Bullet
+	case blockType == 13:
+		local.Type, text = "numberedListItem", block.Ordered
+	case blockType == 14:
+		local.Type, text = "codeBlock", block.Code
+	case blockType == 15:
+		local.Type, text = "quote", block.Quote
+	case blockType == 17:
+		local.Type, text = "checkListItem", block.Todo
+	default:
+		return dto.NoteBlockDTO{}, false
+	}
+	// ParseBlockToLark 总是写入 Text 字段，兼容本系统创建的块
+	if text == nil {
+		text = block.Text
+	}
+
+	alignment := "left"
+	if text != nil && text.Style != nil {
+		if text.Style.Align != nil {
+			alignment = GetLocalTextAlignment(*text.Style.Align)
+		}
+		if local.Type == "checkListItem" && text.Style.Done != nil {
+			local.Props.Checked = text.Style.Done
+		}
+	}
+	local.Props.TextAlignment = &alignment
+	local.Props.TextColor = tools.Ptr("default")
+	local.Props.BackgroundColor = tools.Ptr("default")
+	if text == nil {
+		return local, true
+	}
+
+	for _, element := range text.Elements {
+		if element == nil || element.TextRun == nil || element.TextRun.Content == nil {
+			continue
+		}
+		inline := dto.InlineDTO{Type: "text", Text: *element.TextRun.Content}
+		if style := element.TextRun.TextElementStyle; style != nil {
+			inline.Styles = dto.InlineStylesDTO{
+				Bold:      style.Bold,
+				Italic:    style.Italic,
+				Underline: style.Underline,
+				Strike:    style.Strikethrough,
+				Code:      style.InlineCode,
+			}
+			if style.TextColor != nil && *style.TextColor != 0 {
+				inline.Styles.TextColor = tools.Ptr(GetLocalTextColor(*style.TextColor))
+			}
+		}
+		local.Content = append(local.Content, inline)
+	}
+	return local, true
+}
@@ -0,0 +1,199 @@
+package feishu
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/syncer"
+	"gin-notebook/pkg/utils/tools"
+	"net/http"
+	"strconv"
+	"time"
+
+	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
+)
+
+const (
+	pageBlockType   = 1
+	createChunkSize = 20 // 单次创建子块的数量上限
+)
+
+// syncProvider 飞书文档的 SyncProvider 实现
+type syncProvider struct {
+	client *Client
+}
+
+// NewSyncProvider 作为 syncer.Factory 注册；应用未配置时返回错误
+func NewSyncProvider() (syncer.SyncProvider, error) {
+	c := GetClient()
+	if c == nil {
+		return nil, fmt.Errorf("Feishu integration not configured")
+	}
+	return &syncProvider{client: c}, nil
+}
+
+func (p *syncProvider) Name() model.IntegrationProvider {
+	return model.ProviderFeishu
+}
+
+// FetchBlocks 按页面块的 children 顺序返回顶层块
+func (p *syncProvider) FetchBlocks(ctx context.Context, token, docID string) (*syncer.Document, error) {
+	data, err := p.client.GetNoteAllBlocks(ctx, token, docID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("feishu: list blocks of %s failed", docID)
+	}
+
+	var page *larkdocx.Block
+	byID := make(map[string]*larkdocx.Block, len(data.Items))
+	for _, block := range data.Items {
+		if block == nil || block.BlockId == nil {
+			continue
+		}
+		byID[*block.BlockId] = block
+		if page == nil && block.BlockType != nil && *block.BlockType == pageBlockType {
+			page = block
+		}
+	}
+	if page == nil {
+		return nil, fmt.Errorf("feishu: page block not found in %s", docID)
+	}
+
+	doc := &syncer.Document{RootID: *page.BlockId, Blocks: make([]syncer.RemoteBlock, 0, len(page.Children))}
+	for _, childID := range page.Children {
+		block, ok := byID[childID]
+		if !ok {
+			continue
+		}
+		remote := syncer.RemoteBlock{ID: childID, ParentID: *page.BlockId, Raw: block}
+		if block.BlockType != nil {
+			remote.Type = strconv.Itoa(*block.BlockType)
+		}
+		doc.Blocks = append(doc.Blocks, remote)
+	}
+	return doc, nil
+}
+
+func (p *syncProvider) CreateBlocks(ctx context.Context, token, docID, parentID string, index int, blocks dto.Blocks, idempotencyKey string) ([]syncer.BlockRef, error) {
+	created := make([]syncer.BlockRef, 0, len(blocks))
+	for i, chunk := range tools.Chunk(ParseBlockToLark(blocks), createChunkSize) {
+		resp, err := p.client.CreateBlocks(ctx, token, docID, parentID, chunk, index, &http.Header{
+			"Idempotency-Key": []string{fmt.Sprintf("%s:%d", idempotencyKey, i)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range resp.Children {
+			ref := syncer.BlockRef{ID: *child.BlockId, ParentID: *child.ParentId, ETag: BlockETag(child)}
+			if n := len(created); n < len(blocks) {
+				ref.LocalID = blocks[n].ID
+			}
+			created = append(created, ref)
+		}
+		if index >= 0 {
+			index += len(chunk)
+		}
+	}
+	return created, nil
+}
+
+func (p *syncProvider) UpdateBlocks(ctx context.Context, token, docID string, updates []syncer.BlockUpdate, idempotencyKey string) ([]syncer.BlockRef, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	feishuBlocks := make([]*UpdateFeishuBlock, 0, len(updates))
+	for _, u := range updates {
+		feishuBlocks = append(feishuBlocks, &UpdateFeishuBlock{
+			LocalBlockID:  u.Block.ID,
+			Block:         ParseBlockToLark(dto.Blocks{u.Block})[0],
+			TargetBlockID: u.ExternalID,
+		})
+	}
+	if _, err := p.client.BatchUpdateBlocks(ctx, docID, token, feishuBlocks, &http.Header{
+		"Idempotency-Key": []string{idempotencyKey},
+	}); err != nil {
+		return nil, err
+	}
+	// 批量更新只改写文字元素，远端最终内容以拉取为准：不记录指纹，下一次拉取时补齐基线
+	refs := make([]syncer.BlockRef, 0, len(updates))
+	for _, u := range updates {
+		refs = append(refs, syncer.BlockRef{LocalID: u.Block.ID, ID: u.ExternalID})
+	}
+	return refs, nil
+}
+
+func (p *syncProvider) DeleteBlocks(ctx context.Context, token, docID, parentID string, start, end int) error {
+	if start >= end {
+		return nil
+	}
+	return p.client.DeleteBlocks(ctx, docID, token, parentID, start, end)
+}
+
+func (p *syncProvider) ToLocal(blocks []syncer.RemoteBlock) (dto.Blocks, error) {
+	local := make(dto.Blocks, 0, len(blocks))
+	for _, remote := range blocks {
+		block, ok := remote.Raw.(*larkdocx.Block)
+		if !ok {
+			return nil, fmt.Errorf("feishu: unexpected block payload %T", remote.Raw)
+		}
+		if converted, ok := ParseLarkToBlock(block); ok {
+			local = append(local, converted)
+		}
+	}
+	return local, nil
+}
+
+// Revision 文档版本号，拉取前用于判断文档是否有变化
+func (p *syncProvider) Revision(ctx context.Context, token, docID string) (string, error) {
+	revision, err := p.client.GetDocumentRevision(ctx, token, docID)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(revision), nil
+}
+
+// Subscribe 订阅文档编辑事件，由事件回调触发即时拉取
+func (p *syncProvider) Subscribe(ctx context.Context, token, docID string) error {
+	return p.client.SubscribeFileEvents(ctx, token, docID, "docx")
+}
+
+// RefreshToken 过期时间提前 5 分钟，与定时刷新任务保持一致
+func (p *syncProvider) RefreshToken(ctx context.Context, refreshToken string) (*syncer.Token, error) {
+	token, err := p.client.RefreshUserAccessToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, fmt.Errorf("feishu: empty refresh token response")
+	}
+	now := time.Now()
+	return &syncer.Token{
+		AccessToken:   token.AccessToken,
+		RefreshToken:  token.RefreshToken,
+		AccessExpiry:  now.Add(time.Duration(token.ExpiresIn-300) * time.Second),
+		RefreshExpiry: now.Add(time.Duration(token.RefreshExpiresIn-300) * time.Second),
+	}, nil
+}
+
+// BlockETag 块内容指纹：取转换为本地块后的类型、属性与内容，
+// 创建接口与列表接口返回的默认字段不完全一致，直接对原始结构取指纹会误判
+func BlockETag(block *larkdocx.Block) string {
+	var raw []byte
+	if local, ok := ParseLarkToBlock(block); ok {
+		raw, _ = json.Marshal(struct {
+			Type    string            `json:"type"`
+			Props   dto.BlockPropsDTO `json:"props"`
+			Content []dto.InlineDTO   `json:"content"`
+		}{local.Type, local.Props, local.Content})
+	} else if block != nil && block.BlockType != nil {
+		raw, _ = json.Marshal(*block.BlockType)
+	}
+	sum := sha1.Sum(raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// FixtureTaskID 录制的 webhook 负载中引用的任务 ID，回放前用 WithTaskID 替换为本地任务
+const FixtureTaskID int64 = 1000000000000000001
+
+// FixtureRepo 录制负载所属的仓库
+const FixtureRepo = "octo-org/notebook-demo"
+
+// fixtures 录制的 webhook 负载，文件名为 <事件>[_<动作>].json
+//
+//go:embed testdata/*.json
+var fixtures embed.FS
+
+// Fixture 读取录制的负载，name 如 "push"、"pull_request_merged"
+func Fixture(name string) ([]byte, error) {
+	return fixtures.ReadFile("testdata/" + name + ".json")
+}
+
+// WithTaskID 把负载中的任务引用替换为指定任务
+func WithTaskID(body []byte, taskID int64) []byte {
+	return bytes.ReplaceAll(body,
+		[]byte("#task-"+strconv.FormatInt(FixtureTaskID, 10)),
+		[]byte("#task-"+strconv.FormatInt(taskID, 10)))
+}
+
+// Replay 以 GitHub 的请求头与签名把负载投递到 webhook 地址
+func Replay(ctx context.Context, url, secret, event string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, fmt.Sprintf("replay-%d", len(body)))
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("github: replay %s got status %d", event, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package github_test
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/configs"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/github"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/tasks/asynq/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const (
+	testWorkspaceID int64 = 10
+	testUserID      int64 = 20
+	testMemberID    int64 = 30
+	testProjectID   int64 = 50
+	todoColumnID    int64 = 51
+	doingColumnID   int64 = 52
+	doneColumnID    int64 = 53
+)
+
+type gitHubEnv struct {
+	link       *model.ProjectExternalLink
+	secret     string
+	hookURL    string
+	dispatcher *testutil.Dispatcher
+}
+
+// setupGitHub 准备项目与三个流程阶段的列，链接录制负载所属的仓库，
+// 并启动经由 HandleGitHubWebhook 处理回放请求的 webhook 地址
+func setupGitHub(t *testing.T) *gitHubEnv {
+	t.Helper()
+	prev := configs.Configs
+	configs.Configs = &configs.Config{} // 未配置令牌时不访问 GitHub API
+	t.Cleanup(func() { configs.Configs = prev })
+
+	db := testutil.UseDB(t, &model.Project{}, &model.ProjectSetting{}, &model.ToDoColumn{},
+		&model.ToDoTask{}, &model.ToDoTaskAssignee{}, &model.ProjectExternalLink{},
+		&model.TaskExternalLink{}, &model.TaskExternalReference{}, &model.WorkspaceMember{}, &model.User{})
+	testutil.UseRedis(t)
+	dispatcher := testutil.UseDispatcher(t)
+
+	member := model.WorkspaceMember{WorkspaceID: testWorkspaceID, UserID: testUserID, Nickname: "alice", Role: []byte(`["owner"]`)}
+	member.ID = testMemberID
+	project := model.Project{Name: "notebook", OwnerID: testUserID, WorkspaceID: testWorkspaceID}
+	project.ID = testProjectID
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []struct {
+		id      int64
+		process uint8
+	}{{todoColumnID, model.ProcessTodo}, {doingColumnID, model.ProcessDoing}, {doneColumnID, model.ProcessDone}} {
+		column := model.ToDoColumn{ProjectID: testProjectID, Name: "column " + strconv.Itoa(i), OrderIndex: strconv.Itoa(i), ProcessID: c.process}
+		column.ID = c.id
+		if err := db.Create(&column).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, data := integrationService.CreateGitHubProjectLink(context.Background(), &dto.CreateGitHubProjectLinkDTO{
+		WorkspaceID: testWorkspaceID, ProjectID: testProjectID, Repository: github.FixtureRepo,
+		MemberID: testMemberID, UserID: testUserID,
+	})
+	if code != message.SUCCESS {
+		t.Fatalf("create project link code = %d", code)
+	}
+	created := data["link"].(map[string]interface{})
+	var link model.ProjectExternalLink
+	if err := db.First(&link, "id = ?", created["id"]).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		code := integrationService.HandleGitHubWebhook(r.Context(), &dto.GitHubWebhookDTO{
+			LinkID:    link.ID,
+			Event:     r.Header.Get(github.EventHeader),
+			Delivery:  r.Header.Get(github.DeliveryHeader),
+			Signature: r.Header.Get(github.SignatureHeader),
+			Body:      body,
+		})
+		if code != message.SUCCESS {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(hook.Close)
+
+	return &gitHubEnv{link: &link, secret: created["webhook_secret"].(string), hookURL: hook.URL, dispatcher: dispatcher}
+}
+
+func seedTask(t *testing.T, title string, columnID int64) *model.ToDoTask {
+	t.Helper()
+	task := model.ToDoTask{
+		ProjectID: testProjectID, Title: title, ColumnID: columnID, Creator: testUserID,
+		OrderIndex: "0|hzzzzz:", Description: []byte(`[]`), Status: model.TaskStatusPending,
+	}
+	if err := database.DB.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &task
+}
+
+// replay 回放录制的负载，其中的任务引用替换为 taskID
+func (env *gitHubEnv) replay(t *testing.T, secret, event, fixture string, taskID int64) error {
+	t.Helper()
+	body, err := github.Fixture(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return github.Replay(context.Background(), env.hookURL, secret, event, github.WithTaskID(body, taskID))
+}
+
+// refActivities 已投递的被引用动态
+func (env *gitHubEnv) refActivities(t *testing.T) []types.KanbanActivityPayload {
+	t.Helper()
+	refs := make([]types.KanbanActivityPayload, 0)
+	for _, job := range env.dispatcher.Jobs(types.KanbanActivityKey) {
+		var payload types.KanbanActivityPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if payload.Action == model.RefAction {
+			refs = append(refs, payload)
+		}
+	}
+	return refs
+}
+
+func loadReferences(t *testing.T, taskID int64) []model.TaskExternalReference {
+	t.Helper()
+	var refs []model.TaskExternalReference
+	if err := database.DB.Where("task_id = ?", taskID).Order("id").Find(&refs).Error; err != nil {
+		t.Fatal(err)
+	}
+	return refs
+}
+
+func TestGitHubWebhookRejectsBadSignature(t *testing.T) {
+	env := setupGitHub(t)
+	task := seedTask(t, "login form", todoColumnID)
+
+	if err := env.replay(t, "not-the-secret", github.EventPush, "push", task.ID); err == nil {
+		t.Fatal("webhook signed with a wrong secret must be rejected")
+	}
+	body, _ := github.Fixture("push")
+	if code := integrationService.HandleGitHubWebhook(context.Background(), &dto.GitHubWebhookDTO{
+		LinkID: env.link.ID, Event: github.EventPush, Body: body,
+	}); code != message.ERROR_GITHUB_SIGN_INVALID {
+		t.Fatalf("unsigned webhook code = %d", code)
+	}
+	if refs := loadReferences(t, task.ID); len(refs) != 0 {
+		t.Fatalf("rejected webhooks recorded %d references", len(refs))
+	}
+	if refs := env.refActivities(t); len(refs) != 0 {
+		t.Fatalf("rejected webhooks emitted %d activities", len(refs))
+	}
+}
+
+func TestGitHubWebhookRecordsReferenceOncePerState(t *testing.T) {
+	env := setupGitHub(t)
+	task := seedTask(t, "login form", todoColumnID)
+
+	// 重复投递同一事件只记录一次
+	for i := 0; i < 2; i++ {
+		if err := env.replay(t, env.secret, github.EventPush, "push", task.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.replay(t, env.secret, github.EventIssues, "issues_opened", task.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refs := loadReferences(t, task.ID)
+	if len(refs) != 2 || refs[0].Kind != model.ExtTypeCommit || refs[1].Kind != model.ExtTypeIssue || refs[1].ExternalID != "43" {
+		t.Fatalf("references = %+v", refs)
+	}
+	if activities := env.refActivities(t); len(activities) != 2 {
+		t.Fatalf("ref activities = %d, want 2", len(activities))
+	}
+
+	// 引用其他项目或不存在的任务时忽略
+	if err := env.replay(t, env.secret, github.EventPush, "push", github.FixtureTaskID); err != nil {
+		t.Fatal(err)
+	}
+	if activities := env.refActivities(t); len(activities) != 2 {
+		t.Fatalf("ref activities = %d after unknown task", len(activities))
+	}
+}
+
+func TestGitHubPullRequestMergeMovesTask(t *testing.T) {
+	env := setupGitHub(t)
+	task := seedTask(t, "login form", todoColumnID)
+
+	// 打开 PR 时没有映射的列，任务保持不动
+	for i := 0; i < 2; i++ {
+		if err := env.replay(t, env.secret, github.EventPullRequest, "pull_request_opened", task.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got model.ToDoTask
+	if err := database.DB.First(&got, "id = ?", task.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.ColumnID != todoColumnID {
+		t.Fatalf("opened PR moved task to column %d", got.ColumnID)
+	}
+
+	if err := env.replay(t, env.secret, github.EventPullRequest, "pull_request_merged", task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DB.First(&got, "id = ?", task.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.ColumnID != doneColumnID || got.Status != model.TaskStatusCompleted {
+		t.Fatalf("merged PR left task in column %d status %s", got.ColumnID, got.Status)
+	}
+
+	refs := loadReferences(t, task.ID)
+	if len(refs) != 1 || refs[0].ExternalID != "42" || refs[0].State != github.StateMerged {
+		t.Fatalf("references = %+v", refs)
+	}
+	// opened 与 merged 各记录一次
+	activities := env.refActivities(t)
+	if len(activities) != 2 || activities[0].Patch["state"] != github.StateOpened || activities[1].Patch["state"] != github.StateMerged {
+		t.Fatalf("ref activities = %+v", activities)
+	}
+	var link model.ProjectExternalLink
+	if err := database.DB.First(&link, "id = ?", env.link.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if link.LastStatus != model.SyncSuccess {
+		t.Fatalf("link status = %s", link.LastStatus)
+	}
+}
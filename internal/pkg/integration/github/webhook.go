@@ -0,0 +1,188 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gin-notebook/internal/model"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gogithub "github.com/google/go-github/v79/github"
+)
+
+const (
+	SignatureHeader = gogithub.SHA256SignatureHeader
+	EventHeader     = gogithub.EventTypeHeader
+	DeliveryHeader  = gogithub.DeliveryIDHeader
+)
+
+const (
+	EventPing        = "ping"
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+	EventIssues      = "issues"
+)
+
+// 引用的状态，PR 状态同时作为列映射的键（TaskStatusMapping.StatusID）
+const (
+	StateOpened = "opened"
+	StateClosed = "closed"
+	StateMerged = "merged"
+	StatePushed = "pushed"
+)
+
+// MappableStates 可以配置目标列的 PR 状态
+var MappableStates = []string{StateOpened, StateMerged, StateClosed}
+
+// taskRefPattern 提交说明、PR 与 issue 的标题、正文中以 #task-<任务ID> 引用任务
+var taskRefPattern = regexp.MustCompile(`(?i)#task-(\d{1,19})\b`)
+
+// ErrUnsupportedEvent 不处理的事件类型，调用方直接忽略
+var ErrUnsupportedEvent = errors.New("github: unsupported event")
+
+// Reference 一次提交、PR 或 issue 及其引用的任务
+type Reference struct {
+	Kind       model.ExternalResourceType
+	ExternalID string // PR/issue 编号或提交 SHA
+	Title      string
+	URL        string
+	Author     string
+	State      string
+	TaskIDs    []int64
+}
+
+// Event 规整后的 webhook 事件，只保留引用了任务的对象
+type Event struct {
+	Type       string
+	Action     string
+	RepoID     int64
+	RepoName   string // owner/repo
+	Sender     string
+	References []Reference
+}
+
+// ExtractTaskIDs 按出现顺序去重
+func ExtractTaskIDs(texts ...string) []int64 {
+	seen := make(map[int64]bool)
+	ids := make([]int64, 0)
+	for _, text := range texts {
+		for _, match := range taskRefPattern.FindAllStringSubmatch(text, -1) {
+			id, err := strconv.ParseInt(match[1], 10, 64)
+			if err != nil || id <= 0 || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ParseEvent 解析 push、pull_request、issues 与 ping 事件，其他类型返回 ErrUnsupportedEvent
+func ParseEvent(eventType string, body []byte) (*Event, error) {
+	switch eventType {
+	case EventPing, EventPush, EventPullRequest, EventIssues:
+	default:
+		return nil, ErrUnsupportedEvent
+	}
+	payload, err := gogithub.ParseWebHook(eventType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &Event{Type: eventType, References: make([]Reference, 0)}
+	switch e := payload.(type) {
+	case *gogithub.PingEvent:
+		if e.Repo != nil {
+			event.RepoID, event.RepoName = e.Repo.GetID(), e.Repo.GetFullName()
+		}
+	case *gogithub.PushEvent:
+		event.RepoID, event.RepoName = e.GetRepo().GetID(), e.GetRepo().GetFullName()
+		event.Sender = e.GetSender().GetLogin()
+		for _, commit := range e.Commits {
+			ids := ExtractTaskIDs(commit.GetMessage())
+			if len(ids) == 0 {
+				continue
+			}
+			title, _, _ := strings.Cut(commit.GetMessage(), "\n")
+			event.References = append(event.References, Reference{
+				Kind:       model.ExtTypeCommit,
+				ExternalID: commit.GetID(),
+				Title:      title,
+				URL:        commit.GetURL(),
+				Author:     commit.GetAuthor().GetName(),
+				State:      StatePushed,
+				TaskIDs:    ids,
+			})
+		}
+	case *gogithub.PullRequestEvent:
+		event.Action = e.GetAction()
+		event.RepoID, event.RepoName = e.GetRepo().GetID(), e.GetRepo().GetFullName()
+		event.Sender = e.GetSender().GetLogin()
+		pr := e.GetPullRequest()
+		ids := ExtractTaskIDs(pr.GetTitle(), pr.GetBody(), pr.GetHead().GetRef())
+		if len(ids) > 0 || event.Action == "closed" {
+			// 合并事件即使标题中没有引用也要返回，关联任务可能由之前的事件记录
+			event.References = append(event.References, Reference{
+				Kind:       model.ExtTypePull,
+				ExternalID: strconv.Itoa(pr.GetNumber()),
+				Title:      pr.GetTitle(),
+				URL:        pr.GetHTMLURL(),
+				Author:     pr.GetUser().GetLogin(),
+				State:      pullState(pr),
+				TaskIDs:    ids,
+			})
+		}
+	case *gogithub.IssuesEvent:
+		event.Action = e.GetAction()
+		event.RepoID, event.RepoName = e.GetRepo().GetID(), e.GetRepo().GetFullName()
+		event.Sender = e.GetSender().GetLogin()
+		issue := e.GetIssue()
+		if ids := ExtractTaskIDs(issue.GetTitle(), issue.GetBody()); len(ids) > 0 {
+			state := StateOpened
+			if issue.GetState() == "closed" {
+				state = StateClosed
+			}
+			event.References = append(event.References, Reference{
+				Kind:       model.ExtTypeIssue,
+				ExternalID: strconv.Itoa(issue.GetNumber()),
+				Title:      issue.GetTitle(),
+				URL:        issue.GetHTMLURL(),
+				Author:     issue.GetUser().GetLogin(),
+				State:      state,
+				TaskIDs:    ids,
+			})
+		}
+	default:
+		return nil, ErrUnsupportedEvent
+	}
+	return event, nil
+}
+
+func pullState(pr *gogithub.PullRequest) string {
+	switch {
+	case pr.GetMerged():
+		return StateMerged
+	case pr.GetState() == "closed":
+		return StateClosed
+	default:
+		return StateOpened
+	}
+}
+
+// Sign 生成 "sha256=<hex>" 形式的签名
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	return gogithub.ValidateSignature(signature, body, []byte(secret)) == nil
+}
@@ -0,0 +1,95 @@
+package github
+
+import (
+	"gin-notebook/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestExtractTaskIDs(t *testing.T) {
+	ids := ExtractTaskIDs(
+		"Fix login #task-12 and #TASK-7",
+		"see #task-12 again, #task-0 and task-9 are ignored",
+		"#task-34x is not a reference, #task-56.",
+	)
+	if want := []int64{12, 7, 56}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+	if ids := ExtractTaskIDs("no references"); len(ids) != 0 {
+		t.Fatalf("ids = %v, want none", ids)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body, err := Fixture("push")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySignature("secret", body, Sign("secret", body)) {
+		t.Fatal("valid signature rejected")
+	}
+	for name, c := range map[string]struct {
+		secret, signature string
+	}{
+		"wrong secret":  {"secret", Sign("other", body)},
+		"empty secret":  {"", Sign("", body)},
+		"no signature":  {"secret", ""},
+		"bad signature": {"secret", "sha256=deadbeef"},
+	} {
+		if VerifySignature(c.secret, body, c.signature) {
+			t.Fatalf("%s: signature accepted", name)
+		}
+	}
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = ' '
+	if VerifySignature("secret", tampered, Sign("secret", body)) {
+		t.Fatal("tampered body accepted")
+	}
+}
+
+func TestParseEventFixtures(t *testing.T) {
+	cases := []struct {
+		fixture, event string
+		kind           model.ExternalResourceType
+		externalID     string
+		state          string
+	}{
+		{"push", EventPush, model.ExtTypeCommit, "a10867b14bb761a232cd80139fbd4c0d33264240", StatePushed},
+		{"pull_request_opened", EventPullRequest, model.ExtTypePull, "42", StateOpened},
+		{"pull_request_merged", EventPullRequest, model.ExtTypePull, "42", StateMerged},
+		{"issues_opened", EventIssues, model.ExtTypeIssue, "43", StateOpened},
+	}
+	for _, c := range cases {
+		body, err := Fixture(c.fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := ParseEvent(c.event, WithTaskID(body, 99))
+		if err != nil {
+			t.Fatalf("%s: %v", c.fixture, err)
+		}
+		if event.RepoName != FixtureRepo {
+			t.Fatalf("%s: repo = %q", c.fixture, event.RepoName)
+		}
+		// push 中未引用任务的提交不返回
+		if len(event.References) != 1 {
+			t.Fatalf("%s: references = %d, want 1", c.fixture, len(event.References))
+		}
+		ref := event.References[0]
+		if ref.Kind != c.kind || ref.ExternalID != c.externalID || ref.State != c.state || !reflect.DeepEqual(ref.TaskIDs, []int64{99}) {
+			t.Fatalf("%s: reference = %+v", c.fixture, ref)
+		}
+	}
+
+	body, err := Fixture("ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := ParseEvent(EventPing, body)
+	if err != nil || len(event.References) != 0 {
+		t.Fatalf("ping = %+v, %v", event, err)
+	}
+	if _, err := ParseEvent("release", body); err != ErrUnsupportedEvent {
+		t.Fatalf("release err = %v", err)
+	}
+}
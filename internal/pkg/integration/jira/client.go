@@ -0,0 +1,235 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/repository"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultIssueType = "Task" // 本地任务新建为该类型的问题
+	apiPrefix        = "/rest/api/2"
+	commentPageSize  = 100
+	issueFields      = "summary,description,priority,assignee,status,updated" // 读取问题时只取同步用到的字段
+)
+
+// AccountExtra 绑定账号时记录在 IntegrationAccount.Extra 中的站点信息
+type AccountExtra struct {
+	SiteURL string `json:"site_url"`
+	Email   string `json:"email"`
+}
+
+type Client struct {
+	baseURL string
+	email   string
+	token   string
+	http    *http.Client
+}
+
+// NewClient Jira Cloud 使用邮箱 + API token 的 Basic 认证；email 为空时按 Data Center 的个人访问令牌使用 Bearer 认证。
+// 测试中 siteURL 指向 httptest 启动的 StandIn
+func NewClient(siteURL, email, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(siteURL, "/"),
+		email:   email,
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ClientForAccount 按绑定的集成账号构造客户端
+func ClientForAccount(account *model.IntegrationAccount) (*Client, error) {
+	var extra AccountExtra
+	if len(account.Extra) > 0 {
+		if err := json.Unmarshal(account.Extra, &extra); err != nil {
+			return nil, err
+		}
+	}
+	if extra.SiteURL == "" {
+		return nil, fmt.Errorf("jira: site url missing for account %d", account.ID)
+	}
+	return NewClient(extra.SiteURL, extra.Email, account.AccessTokenEnc), nil
+}
+
+// GetClientForUser 使用用户绑定的 Jira 账号
+func GetClientForUser(ctx context.Context, userID int64) (*Client, error) {
+	provider := model.ProviderJira
+	account, err := repository.NewIntegrationRepository(database.DB).GetIntegrationAccountByUser(ctx, &provider, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.ID == 0 || !account.IsActive {
+		return nil, fmt.Errorf("jira: account not bound for user %d", userID)
+	}
+	return ClientForAccount(account)
+}
+
+func (c *Client) SiteURL() string {
+	return c.baseURL
+}
+
+// Myself 校验凭据并返回当前用户
+func (c *Client) Myself(ctx context.Context) (*User, error) {
+	user := &User{}
+	if err := c.do(ctx, http.MethodGet, "/myself", nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *Client) GetProject(ctx context.Context, key string) (*Project, error) {
+	project := &Project{}
+	if err := c.do(ctx, http.MethodGet, "/project/"+url.PathEscape(key), nil, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// GetProjectStatuses 项目内各问题类型可用的状态
+func (c *Client) GetProjectStatuses(ctx context.Context, key string) ([]IssueTypeStatuses, error) {
+	statuses := make([]IssueTypeStatuses, 0)
+	if err := c.do(ctx, http.MethodGet, "/project/"+url.PathEscape(key)+"/statuses", nil, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// CreateIssue fields 直接作为请求体的 fields，返回的问题只带 ID 与 Key
+func (c *Client) CreateIssue(ctx context.Context, fields map[string]any) (*Issue, error) {
+	issue := &Issue{}
+	if err := c.do(ctx, http.MethodPost, "/issue", map[string]any{"fields": fields}, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, key string) (*Issue, error) {
+	issue := &Issue{}
+	if err := c.do(ctx, http.MethodGet, "/issue/"+url.PathEscape(key)+"?fields="+issueFields, nil, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// UpdateIssue 只修改 fields 中出现的字段，值为 nil 时清空该字段
+func (c *Client) UpdateIssue(ctx context.Context, key string, fields map[string]any) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return c.do(ctx, http.MethodPut, "/issue/"+url.PathEscape(key), map[string]any{"fields": fields}, nil)
+}
+
+// GetTransitions 问题在当前状态下可执行的流转
+func (c *Client) GetTransitions(ctx context.Context, key string) ([]Transition, error) {
+	list := &transitionList{}
+	if err := c.do(ctx, http.MethodGet, "/issue/"+url.PathEscape(key)+"/transitions", nil, list); err != nil {
+		return nil, err
+	}
+	return list.Transitions, nil
+}
+
+func (c *Client) DoTransition(ctx context.Context, key, transitionID string) error {
+	body := map[string]any{"transition": map[string]string{"id": transitionID}}
+	return c.do(ctx, http.MethodPost, "/issue/"+url.PathEscape(key)+"/transitions", body, nil)
+}
+
+// GetComments 翻页读取问题的全部评论
+func (c *Client) GetComments(ctx context.Context, key string) ([]Comment, error) {
+	comments := make([]Comment, 0)
+	for start := 0; ; {
+		path := "/issue/" + url.PathEscape(key) + "/comment?startAt=" + strconv.Itoa(start) + "&maxResults=" + strconv.Itoa(commentPageSize)
+		page := &commentPage{}
+		if err := c.do(ctx, http.MethodGet, path, nil, page); err != nil {
+			return nil, err
+		}
+		comments = append(comments, page.Comments...)
+		start += len(page.Comments)
+		if len(page.Comments) == 0 || start >= page.Total {
+			return comments, nil
+		}
+	}
+}
+
+func (c *Client) AddComment(ctx context.Context, key, body string) (*Comment, error) {
+	comment := &Comment{}
+	if err := c.do(ctx, http.MethodPost, "/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": body}, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (c *Client) UpdateComment(ctx context.Context, key, commentID, body string) (*Comment, error) {
+	comment := &Comment{}
+	path := "/issue/" + url.PathEscape(key) + "/comment/" + url.PathEscape(commentID)
+	if err := c.do(ctx, http.MethodPut, path, map[string]string{"body": body}, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+apiPrefix+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &apiError{}
+		if json.Unmarshal(raw, apiErr) != nil || (len(apiErr.ErrorMessages) == 0 && len(apiErr.Errors) == 0) {
+			apiErr.ErrorMessages = []string{http.StatusText(resp.StatusCode)}
+		}
+		apiErr.Status = resp.StatusCode
+		return apiErr
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// IsNotFound 问题不存在、已删除或当前账号无权查看
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Status == http.StatusNotFound
+}
+
+// IsUnauthorized 凭据失效或已被吊销
+func IsUnauthorized(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Status == http.StatusUnauthorized
+}
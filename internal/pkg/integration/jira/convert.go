@@ -0,0 +1,160 @@
+package jira
+
+import (
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/pkg/utils/tools"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	codeFence    = "{code}"
+	checkedMark  = "[x] "
+	uncheckMark  = "[ ] "
+	quotePrefix  = "bq. "
+	maxListDepth = 6
+)
+
+// BlocksToWiki 任务描述转换为 Jira wiki 标记，每个块占一行；行内样式只保留文字，空段落不输出
+func BlocksToWiki(blocks dto.Blocks) string {
+	lines := make([]string, 0, len(blocks))
+	var walk func(blocks []dto.NoteBlockDTO, depth int)
+	walk = func(blocks []dto.NoteBlockDTO, depth int) {
+		for _, block := range blocks {
+			text := strings.TrimRight(dto.InlinePlainText(block.Content), "\n")
+			switch block.Type {
+			case "heading":
+				level := 1
+				if block.Props.Level != nil {
+					level = min(max(*block.Props.Level, 1), 6)
+				}
+				lines = append(lines, "h"+string(rune('0'+level))+". "+text)
+			case "bulletListItem":
+				lines = append(lines, strings.Repeat("*", depth)+" "+text)
+			case "numberedListItem":
+				lines = append(lines, strings.Repeat("#", depth)+" "+text)
+			case "checkListItem":
+				mark := uncheckMark
+				if block.Props.Checked != nil && *block.Props.Checked {
+					mark = checkedMark
+				}
+				lines = append(lines, strings.Repeat("*", depth)+" "+mark+text)
+			case "codeBlock":
+				lines = append(lines, codeFence, text, codeFence)
+			case "quote":
+				lines = append(lines, quotePrefix+text)
+			default:
+				if strings.TrimSpace(text) != "" {
+					lines = append(lines, text)
+				}
+			}
+			if len(block.Children) > 0 {
+				walk(block.Children, min(depth+1, maxListDepth))
+			}
+		}
+	}
+	walk(blocks, 1)
+	return strings.Join(lines, "\n")
+}
+
+// WikiToBlocks 解析 BlocksToWiki 能生成的标记；多级列表还原为子块，其余行作为段落
+func WikiToBlocks(text string) dto.Blocks {
+	blocks := make(dto.Blocks, 0)
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == codeFence {
+			code := make([]string, 0)
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != codeFence; i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, newBlock("codeBlock", strings.Join(code, "\n")))
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		switch {
+		case len(line) > 3 && line[0] == 'h' && line[1] >= '1' && line[1] <= '6' && line[2] == '.' && line[3] == ' ':
+			block := newBlock("heading", line[4:])
+			block.Props.Level = tools.Ptr(int(line[1] - '0'))
+			blocks = append(blocks, block)
+		case strings.HasPrefix(line, quotePrefix):
+			blocks = append(blocks, newBlock("quote", strings.TrimPrefix(line, quotePrefix)))
+		case listMarker(line, '*') > 0:
+			depth := listMarker(line, '*')
+			item := line[depth+1:]
+			switch {
+			case strings.HasPrefix(item, checkedMark), strings.HasPrefix(item, uncheckMark):
+				block := newBlock("checkListItem", item[len(checkedMark):])
+				block.Props.Checked = tools.Ptr(strings.HasPrefix(item, checkedMark))
+				appendListItem(&blocks, depth, block)
+			default:
+				appendListItem(&blocks, depth, newBlock("bulletListItem", item))
+			}
+		case listMarker(line, '#') > 0:
+			depth := listMarker(line, '#')
+			appendListItem(&blocks, depth, newBlock("numberedListItem", line[depth+1:]))
+		default:
+			blocks = append(blocks, newBlock("paragraph", line))
+		}
+	}
+	if len(blocks) == 0 {
+		return dto.DefaultBlocks()
+	}
+	return blocks
+}
+
+// NormalizeWiki 按本地能表达的结构规整文本，三方比对时两侧都用规整后的值，避免格式差异被当作修改
+func NormalizeWiki(text string) string {
+	return BlocksToWiki(WikiToBlocks(text))
+}
+
+// listMarker 返回行首列表标记（如 "** "）的长度，不是列表行时返回 0
+func listMarker(line string, mark byte) int {
+	n := 0
+	for n < len(line) && line[n] == mark {
+		n++
+	}
+	if n == 0 || n >= len(line) || line[n] != ' ' {
+		return 0
+	}
+	return n
+}
+
+// appendListItem 第 depth 级的列表项挂到上一个列表项下；层级断开时挂到能到达的最深一级
+func appendListItem(blocks *dto.Blocks, depth int, block dto.NoteBlockDTO) {
+	siblings := (*[]dto.NoteBlockDTO)(blocks)
+	for ; depth > 1 && len(*siblings) > 0; depth-- {
+		last := &(*siblings)[len(*siblings)-1]
+		if !isListBlock(last.Type) {
+			break
+		}
+		siblings = &last.Children
+	}
+	*siblings = append(*siblings, block)
+}
+
+func isListBlock(blockType string) bool {
+	return blockType == "bulletListItem" || blockType == "numberedListItem" || blockType == "checkListItem"
+}
+
+func newBlock(blockType, text string) dto.NoteBlockDTO {
+	content := []dto.InlineDTO{}
+	if text != "" {
+		content = append(content, dto.InlineDTO{Type: "text", Text: text, Styles: dto.InlineStylesDTO{}})
+	}
+	return dto.NoteBlockDTO{
+		ID:   uuid.NewString(),
+		Type: blockType,
+		Props: dto.BlockPropsDTO{
+			BackgroundColor: tools.Ptr("default"),
+			TextColor:       tools.Ptr("default"),
+			TextAlignment:   tools.Ptr("left"),
+		},
+		Content:  content,
+		Children: []dto.NoteBlockDTO{},
+	}
+}
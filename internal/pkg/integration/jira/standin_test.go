@@ -0,0 +1,513 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StandIn 内存版的 Jira REST API v2，实现客户端用到的接口子集。
+// 通过 httptest.NewServer(standIn) 启动后把账号的 site_url 指向它即可联调；
+// 以 Remote 开头的方法模拟在 Jira 中直接修改，并在配置了 WithWebhook 时投递带签名的 webhook
+type StandIn struct {
+	mu         sync.Mutex
+	email      string // 为空时按 Bearer 令牌校验
+	token      string // 为空时不校验
+	myself     User
+	seq        int
+	projects   map[string]*Project
+	statuses   []Status
+	priorities map[string]Priority
+	users      map[string]*User
+	issues     map[string]*standInIssue
+	issueKeys  map[string]string // issue ID -> key
+	projectSeq map[string]int
+	webhookURL string
+	secret     string
+	http       *http.Client
+}
+
+type standInIssue struct {
+	issue    Issue
+	comments []Comment
+	deleted  bool
+}
+
+func NewStandIn(email, token string) *StandIn {
+	me := User{AccountID: "standin-me", DisplayName: "Stand-in", EmailAddress: email, Active: true}
+	return &StandIn{
+		email:  email,
+		token:  token,
+		myself: me,
+		statuses: []Status{
+			{ID: "1", Name: "To Do", StatusCategory: &StatusCategory{ID: 2, Key: CategoryNew, Name: "To Do"}},
+			{ID: "3", Name: "In Progress", StatusCategory: &StatusCategory{ID: 4, Key: CategoryInProgress, Name: "In Progress"}},
+			{ID: "10001", Name: "Done", StatusCategory: &StatusCategory{ID: 3, Key: CategoryDone, Name: "Done"}},
+		},
+		priorities: map[string]Priority{
+			"Highest": {ID: "1", Name: "Highest"},
+			"High":    {ID: "2", Name: "High"},
+			"Medium":  {ID: "3", Name: "Medium"},
+			"Low":     {ID: "4", Name: "Low"},
+			"Lowest":  {ID: "5", Name: "Lowest"},
+		},
+		projects:   map[string]*Project{},
+		users:      map[string]*User{me.AccountID: &me},
+		issues:     map[string]*standInIssue{},
+		issueKeys:  map[string]string{},
+		projectSeq: map[string]int{},
+		http:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithWebhook 模拟的远端修改会以 secret 签名后 POST 到 url
+func (s *StandIn) WithWebhook(url, secret string) *StandIn {
+	s.webhookURL = url
+	s.secret = secret
+	return s
+}
+
+func (s *StandIn) AddProject(key, name string) Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	project := &Project{ID: strconv.Itoa(10000 + s.seq), Key: key, Name: name}
+	s.projects[key] = project
+	return *project
+}
+
+func (s *StandIn) AddUser(accountID, displayName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[accountID] = &User{AccountID: accountID, DisplayName: displayName, Active: true}
+}
+
+// Issue 按 key 或 ID 读取问题的当前内容
+func (s *StandIn) Issue(keyOrID string) (Issue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.find(keyOrID)
+	if item == nil {
+		return Issue{}, false
+	}
+	return item.issue, true
+}
+
+func (s *StandIn) Comments(keyOrID string) []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.find(keyOrID)
+	if item == nil {
+		return nil
+	}
+	return append([]Comment{}, item.comments...)
+}
+
+// RemoteCreateIssue 模拟在 Jira 中新建问题
+func (s *StandIn) RemoteCreateIssue(projectKey, summary string) (Issue, error) {
+	s.mu.Lock()
+	project, ok := s.projects[projectKey]
+	if !ok {
+		s.mu.Unlock()
+		return Issue{}, fmt.Errorf("project %s not found", projectKey)
+	}
+	item := s.createIssue(project, IssueFields{Summary: summary})
+	issue := item.issue
+	s.mu.Unlock()
+	return issue, s.deliver(EventIssueCreated, &issue, nil)
+}
+
+// RemoteEditIssue 模拟在 Jira 中修改问题字段；状态请用 RemoteSetStatus
+func (s *StandIn) RemoteEditIssue(keyOrID string, edit func(fields *IssueFields)) error {
+	s.mu.Lock()
+	item := s.find(keyOrID)
+	if item == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("issue %s not found", keyOrID)
+	}
+	status := item.issue.Fields.Status
+	edit(&item.issue.Fields)
+	item.issue.Fields.Status = status
+	item.issue.Fields.Updated = now()
+	issue := item.issue
+	s.mu.Unlock()
+	return s.deliver(EventIssueUpdated, &issue, nil)
+}
+
+func (s *StandIn) RemoteSetStatus(keyOrID, statusID string) error {
+	s.mu.Lock()
+	item := s.find(keyOrID)
+	status := s.status(statusID)
+	if item == nil || status == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("issue %s or status %s not found", keyOrID, statusID)
+	}
+	item.issue.Fields.Status = status
+	item.issue.Fields.Updated = now()
+	issue := item.issue
+	s.mu.Unlock()
+	return s.deliver(EventIssueUpdated, &issue, nil)
+}
+
+// RemoteAddComment 模拟 Jira 用户发表评论
+func (s *StandIn) RemoteAddComment(keyOrID, accountID, body string) (Comment, error) {
+	s.mu.Lock()
+	item := s.find(keyOrID)
+	author, ok := s.users[accountID]
+	if item == nil || !ok {
+		s.mu.Unlock()
+		return Comment{}, fmt.Errorf("issue %s or user %s not found", keyOrID, accountID)
+	}
+	comment := s.addComment(item, *author, body)
+	issue := item.issue
+	s.mu.Unlock()
+	return comment, s.deliver(EventCommentCreated, &issue, &comment)
+}
+
+func (s *StandIn) RemoteEditComment(keyOrID, commentID, body string) error {
+	s.mu.Lock()
+	item := s.find(keyOrID)
+	if item == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("issue %s not found", keyOrID)
+	}
+	comment := s.updateComment(item, commentID, body)
+	issue := item.issue
+	s.mu.Unlock()
+	if comment == nil {
+		return fmt.Errorf("comment %s not found", commentID)
+	}
+	return s.deliver(EventCommentUpdated, &issue, comment)
+}
+
+func (s *StandIn) RemoteDeleteIssue(keyOrID string) error {
+	s.mu.Lock()
+	item := s.find(keyOrID)
+	if item == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("issue %s not found", keyOrID)
+	}
+	item.deleted = true
+	issue := item.issue
+	s.mu.Unlock()
+	return s.deliver(EventIssueDeleted, &issue, nil)
+}
+
+func (s *StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "Client must be authenticated to access this resource.")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "myself" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.myself)
+	case len(parts) == 2 && parts[0] == "project" && r.Method == http.MethodGet:
+		project, ok := s.projects[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "No project could be found with key '"+parts[1]+"'.")
+			return
+		}
+		writeJSON(w, http.StatusOK, project)
+	case len(parts) == 3 && parts[0] == "project" && parts[2] == "statuses" && r.Method == http.MethodGet:
+		if _, ok := s.projects[parts[1]]; !ok {
+			writeError(w, http.StatusNotFound, "No project could be found with key '"+parts[1]+"'.")
+			return
+		}
+		writeJSON(w, http.StatusOK, []IssueTypeStatuses{{ID: "10002", Name: "Task", Statuses: s.statuses}})
+	case path == "issue" && r.Method == http.MethodPost:
+		s.handleCreateIssue(w, r)
+	case len(parts) >= 2 && parts[0] == "issue":
+		item := s.find(parts[1])
+		if item == nil {
+			writeError(w, http.StatusNotFound, "Issue does not exist or you do not have permission to see it.")
+			return
+		}
+		s.handleIssue(w, r, item, parts[2:])
+	default:
+		writeError(w, http.StatusNotFound, "null for uri: "+r.URL.String())
+	}
+}
+
+func (s *StandIn) handleCreateIssue(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Fields map[string]json.RawMessage `json:"fields"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request payload.")
+		return
+	}
+	var project Project
+	_ = json.Unmarshal(req.Fields["project"], &project)
+	target, ok := s.projects[project.Key]
+	if !ok {
+		writeFieldError(w, "project", "valid project is required")
+		return
+	}
+	item := s.createIssue(target, IssueFields{})
+	if !s.applyFields(w, item, req.Fields) {
+		delete(s.issues, item.issue.Key)
+		delete(s.issueKeys, item.issue.ID)
+		return
+	}
+	if item.issue.Fields.Summary == "" {
+		delete(s.issues, item.issue.Key)
+		delete(s.issueKeys, item.issue.ID)
+		writeFieldError(w, "summary", "You must specify a summary of the issue.")
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": item.issue.ID, "key": item.issue.Key, "self": item.issue.Self})
+}
+
+func (s *StandIn) handleIssue(w http.ResponseWriter, r *http.Request, item *standInIssue, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, item.issue)
+	case len(rest) == 0 && r.Method == http.MethodPut:
+		var req struct {
+			Fields map[string]json.RawMessage `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request payload.")
+			return
+		}
+		if s.applyFields(w, item, req.Fields) {
+			item.issue.Fields.Updated = now()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case len(rest) == 1 && rest[0] == "transitions" && r.Method == http.MethodGet:
+		transitions := make([]Transition, 0, len(s.statuses))
+		for _, status := range s.statuses {
+			if status.ID != item.issue.StatusID() {
+				transitions = append(transitions, Transition{ID: "t" + status.ID, Name: status.Name, To: status})
+			}
+		}
+		writeJSON(w, http.StatusOK, transitionList{Transitions: transitions})
+	case len(rest) == 1 && rest[0] == "transitions" && r.Method == http.MethodPost:
+		var req struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		status := s.status(strings.TrimPrefix(req.Transition.ID, "t"))
+		if status == nil || !strings.HasPrefix(req.Transition.ID, "t") || status.ID == item.issue.StatusID() {
+			writeError(w, http.StatusBadRequest, "Transition id '"+req.Transition.ID+"' is not valid for this issue.")
+			return
+		}
+		item.issue.Fields.Status = status
+		item.issue.Fields.Updated = now()
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 1 && rest[0] == "comment" && r.Method == http.MethodGet:
+		start, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+		if size <= 0 {
+			size = 50
+		}
+		start = min(max(start, 0), len(item.comments))
+		end := min(start+size, len(item.comments))
+		writeJSON(w, http.StatusOK, commentPage{StartAt: start, MaxResults: size, Total: len(item.comments), Comments: item.comments[start:end]})
+	case len(rest) == 1 && rest[0] == "comment" && r.Method == http.MethodPost:
+		var req struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Body) == "" {
+			writeFieldError(w, "comment", "Comment body can not be empty!")
+			return
+		}
+		writeJSON(w, http.StatusCreated, s.addComment(item, s.myself, req.Body))
+	case len(rest) == 2 && rest[0] == "comment" && r.Method == http.MethodPut:
+		var req struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Body) == "" {
+			writeFieldError(w, "comment", "Comment body can not be empty!")
+			return
+		}
+		comment := s.updateComment(item, rest[1], req.Body)
+		if comment == nil {
+			writeError(w, http.StatusNotFound, "Can not find a comment for the id: "+rest[1]+".")
+			return
+		}
+		writeJSON(w, http.StatusOK, comment)
+	default:
+		writeError(w, http.StatusNotFound, "null for uri: "+r.URL.String())
+	}
+}
+
+// applyFields 按 Jira 的校验规则写入字段，失败时已写出错误响应
+func (s *StandIn) applyFields(w http.ResponseWriter, item *standInIssue, fields map[string]json.RawMessage) bool {
+	next := item.issue.Fields
+	for name, raw := range fields {
+		switch name {
+		case "project", "issuetype":
+		case "summary":
+			if err := json.Unmarshal(raw, &next.Summary); err != nil || strings.TrimSpace(next.Summary) == "" {
+				writeFieldError(w, "summary", "You must specify a summary of the issue.")
+				return false
+			}
+		case "description":
+			next.Description = nil
+			_ = json.Unmarshal(raw, &next.Description)
+		case "priority":
+			var priority Priority
+			if err := json.Unmarshal(raw, &priority); err != nil {
+				writeFieldError(w, "priority", "Could not parse priority.")
+				return false
+			}
+			known, ok := s.priorities[priority.Name]
+			if !ok {
+				writeFieldError(w, "priority", "Specify the Priority (name) in the string format")
+				return false
+			}
+			next.Priority = &known
+		case "assignee":
+			if string(raw) == "null" {
+				next.Assignee = nil
+				continue
+			}
+			var assignee User
+			_ = json.Unmarshal(raw, &assignee)
+			user, ok := s.users[assignee.AccountID]
+			if !ok {
+				writeFieldError(w, "assignee", "User '"+assignee.AccountID+"' cannot be assigned issues.")
+				return false
+			}
+			copied := *user
+			next.Assignee = &copied
+		default:
+			writeFieldError(w, name, "Field '"+name+"' cannot be set. It is not on the appropriate screen, or unknown.")
+			return false
+		}
+	}
+	item.issue.Fields = next
+	return true
+}
+
+func (s *StandIn) createIssue(project *Project, fields IssueFields) *standInIssue {
+	s.seq++
+	s.projectSeq[project.Key]++
+	id := strconv.Itoa(20000 + s.seq)
+	key := fmt.Sprintf("%s-%d", project.Key, s.projectSeq[project.Key])
+	fields.Project = project
+	fields.IssueType = &IssueType{ID: "10002", Name: "Task"}
+	fields.Status = s.status(s.statuses[0].ID)
+	fields.Updated = now()
+	item := &standInIssue{issue: Issue{ID: id, Key: key, Self: "/rest/api/2/issue/" + id, Fields: fields}, comments: []Comment{}}
+	s.issues[key] = item
+	s.issueKeys[id] = key
+	return item
+}
+
+func (s *StandIn) addComment(item *standInIssue, author User, body string) Comment {
+	s.seq++
+	comment := Comment{ID: strconv.Itoa(30000 + s.seq), Body: body, Author: &author, Created: now(), Updated: now()}
+	item.comments = append(item.comments, comment)
+	return comment
+}
+
+func (s *StandIn) updateComment(item *standInIssue, commentID, body string) *Comment {
+	for i := range item.comments {
+		if item.comments[i].ID == commentID {
+			item.comments[i].Body = body
+			item.comments[i].Updated = now()
+			comment := item.comments[i]
+			return &comment
+		}
+	}
+	return nil
+}
+
+func (s *StandIn) find(keyOrID string) *standInIssue {
+	if key, ok := s.issueKeys[keyOrID]; ok {
+		keyOrID = key
+	}
+	item, ok := s.issues[keyOrID]
+	if !ok || item.deleted {
+		return nil
+	}
+	return item
+}
+
+func (s *StandIn) status(id string) *Status {
+	for _, status := range s.statuses {
+		if status.ID == id {
+			copied := status
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (s *StandIn) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	if s.email == "" {
+		return r.Header.Get("Authorization") == "Bearer "+s.token
+	}
+	email, token, ok := r.BasicAuth()
+	return ok && email == s.email && token == s.token
+}
+
+// deliver 在释放锁之后同步投递，接收方可以立即回调替身服务
+func (s *StandIn) deliver(event string, issue *Issue, comment *Comment) error {
+	if s.webhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(WebhookEvent{
+		Timestamp:    time.Now().UnixMilli(),
+		WebhookEvent: event,
+		Issue:        issue,
+		Comment:      comment,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(s.secret, body))
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook delivery failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func now() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000-0700")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiError{ErrorMessages: []string{msg}, Errors: map[string]string{}})
+}
+
+func writeFieldError(w http.ResponseWriter, field, msg string) {
+	writeJSON(w, http.StatusBadRequest, apiError{ErrorMessages: []string{}, Errors: map[string]string{field: msg}})
+}
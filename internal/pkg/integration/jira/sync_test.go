@@ -0,0 +1,324 @@
+package jira_test
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/jira"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/tasks/asynq/handlers"
+	"gin-notebook/internal/tasks/asynq/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	testEmail             = "me@example.com"
+	testToken             = "jira-token"
+	testProjectKey        = "NB"
+	testWorkspaceID int64 = 10
+	testUserID      int64 = 20
+	testMemberID    int64 = 30
+	testProjectID   int64 = 50
+	todoColumnID    int64 = 51
+	doingColumnID   int64 = 52
+	doneColumnID    int64 = 53
+)
+
+type jiraEnv struct {
+	standIn    *jira.StandIn
+	siteURL    string
+	dispatcher *testutil.Dispatcher
+}
+
+// setupJira 启动 Jira 替身并准备项目、三个流程阶段的列与同步用到的表
+func setupJira(t *testing.T) *jiraEnv {
+	t.Helper()
+	db := testutil.UseDB(t, &model.IntegrationAccount{}, &model.Project{}, &model.ProjectSetting{},
+		&model.ToDoColumn{}, &model.ToDoTask{}, &model.ToDoTaskAssignee{}, &model.ToDoTaskComment{},
+		&model.ProjectExternalLink{}, &model.TaskExternalLink{}, &model.TaskCommentExternalLink{},
+		&model.WorkspaceMember{}, &model.User{})
+	testutil.UseRedis(t)
+	dispatcher := testutil.UseDispatcher(t)
+
+	standIn := jira.NewStandIn(testEmail, testToken)
+	standIn.AddProject(testProjectKey, "Notebook")
+	srv := httptest.NewServer(standIn)
+	t.Cleanup(srv.Close)
+
+	member := model.WorkspaceMember{WorkspaceID: testWorkspaceID, UserID: testUserID, Nickname: "alice", Role: []byte(`["owner"]`)}
+	member.ID = testMemberID
+	project := model.Project{Name: "notebook", OwnerID: testUserID, WorkspaceID: testWorkspaceID}
+	project.ID = testProjectID
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&project).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range []struct {
+		id      int64
+		process uint8
+	}{{todoColumnID, model.ProcessTodo}, {doingColumnID, model.ProcessDoing}, {doneColumnID, model.ProcessDone}} {
+		column := model.ToDoColumn{ProjectID: testProjectID, Name: "column " + strconv.Itoa(i), OrderIndex: strconv.Itoa(i), ProcessID: c.process}
+		column.ID = c.id
+		if err := db.Create(&column).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code, _ := integrationService.LinkJiraAccount(context.Background(), &dto.JiraTokenLinkDTO{
+		SiteURL: srv.URL, Email: testEmail, Token: testToken, UserID: testUserID,
+	}); code != message.SUCCESS {
+		t.Fatalf("link account code = %d", code)
+	}
+	return &jiraEnv{standIn: standIn, siteURL: srv.URL, dispatcher: dispatcher}
+}
+
+// linkProject 链接 Jira 项目，返回链接与只在创建时下发的 webhook secret
+func linkProject(t *testing.T) (*model.ProjectExternalLink, string) {
+	t.Helper()
+	code, data := integrationService.CreateJiraProjectLink(context.Background(), &dto.CreateJiraProjectLinkDTO{
+		WorkspaceID: testWorkspaceID, ProjectID: testProjectID, ProjectKey: testProjectKey,
+		MemberID: testMemberID, UserID: testUserID,
+	})
+	if code != message.SUCCESS {
+		t.Fatalf("create project link code = %d", code)
+	}
+	created := data["link"].(map[string]interface{})
+	var link model.ProjectExternalLink
+	if err := database.DB.First(&link, "id = ?", created["id"]).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &link, created["webhook_secret"].(string)
+}
+
+func seedTask(t *testing.T, title string, columnID int64, priority uint8) *model.ToDoTask {
+	t.Helper()
+	task := model.ToDoTask{
+		ProjectID: testProjectID, Title: title, ColumnID: columnID, Creator: testUserID,
+		Priority: priority, OrderIndex: "0|hzzzzz:", Description: []byte(`[]`),
+	}
+	if err := database.DB.Create(&task).Error; err != nil {
+		t.Fatal(err)
+	}
+	assignee := model.ToDoTaskAssignee{AssigneeID: testMemberID, ToDoTaskID: task.ID}
+	if err := database.DB.Create(&assignee).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &task
+}
+
+func runTaskSync(t *testing.T, payload types.JiraTaskSyncPayload) {
+	t.Helper()
+	raw, _ := json.Marshal(payload)
+	if err := handlers.HandleJiraTaskSync(context.Background(), asynq.NewTask(types.JiraTaskSyncKey, raw)); err != nil {
+		t.Fatalf("jira sync: %v", err)
+	}
+}
+
+func loadTask(t *testing.T, id int64) model.ToDoTask {
+	t.Helper()
+	var task model.ToDoTask
+	if err := database.DB.First(&task, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func loadTaskLink(t *testing.T, query string, args ...any) model.TaskExternalLink {
+	t.Helper()
+	var taskLink model.TaskExternalLink
+	if err := database.DB.Where(query, args...).First(&taskLink).Error; err != nil {
+		t.Fatal(err)
+	}
+	return taskLink
+}
+
+func TestLinkJiraAccountRejectsBadToken(t *testing.T) {
+	env := setupJira(t)
+	code, _ := integrationService.LinkJiraAccount(context.Background(), &dto.JiraTokenLinkDTO{
+		SiteURL: env.siteURL, Email: testEmail, Token: "wrong", UserID: testUserID,
+	})
+	if code != message.ERROR_JIRA_TOKEN_INVALID {
+		t.Fatalf("code = %d, want %d", code, message.ERROR_JIRA_TOKEN_INVALID)
+	}
+}
+
+func TestJiraProjectLinkDefaultMappings(t *testing.T) {
+	env := setupJira(t)
+	existing := seedTask(t, "existing", todoColumnID, 2)
+	link, secret := linkProject(t)
+
+	// 每列取与其流程阶段同分类的第一个状态
+	want := map[int64]string{todoColumnID: "1", doingColumnID: "3", doneColumnID: "10001"}
+	mappings := link.StatusMappings()
+	if len(mappings) != len(want) {
+		t.Fatalf("status mappings = %+v", mappings)
+	}
+	for _, m := range mappings {
+		if want[m.ColumnID] != m.StatusID {
+			t.Fatalf("column %d mapped to status %s, want %s", m.ColumnID, m.StatusID, want[m.ColumnID])
+		}
+	}
+	if p := link.PriorityMappings(); p["low"] != "Low" || p["medium"] != "Medium" || p["high"] != "High" {
+		t.Fatalf("priority mappings = %v", p)
+	}
+	if a := link.AssigneeMappings(); len(a) != 1 || a[0].MemberID != testMemberID || a[0].AccountID != "standin-me" {
+		t.Fatalf("assignee mappings = %+v", a)
+	}
+	if secret == "" || link.Direction != model.SyncTwoWay {
+		t.Fatalf("secret = %q direction = %s", secret, link.Direction)
+	}
+
+	// 项目现有任务逐个投递首次同步
+	jobs := env.dispatcher.Jobs(types.JiraTaskSyncKey)
+	if len(jobs) != 1 {
+		t.Fatalf("sync jobs = %d, want 1", len(jobs))
+	}
+	var payload types.JiraTaskSyncPayload
+	_ = json.Unmarshal(jobs[0].Payload, &payload)
+	if payload.ProjectLinkID != link.ID || payload.TaskID != existing.ID || payload.Remote {
+		t.Fatalf("sync payload = %+v", payload)
+	}
+}
+
+func TestJiraPushTask(t *testing.T) {
+	env := setupJira(t)
+	link, _ := linkProject(t)
+	task := seedTask(t, "Write docs", doingColumnID, 3)
+
+	// 首次同步新建问题，列按映射流转到对应状态
+	runTaskSync(t, types.JiraTaskSyncPayload{ProjectLinkID: link.ID, TaskID: task.ID})
+	taskLink := loadTaskLink(t, "task_id = ?", task.ID)
+	issue, ok := env.standIn.Issue(taskLink.IssueKey)
+	if !ok {
+		t.Fatalf("issue %s not created", taskLink.IssueKey)
+	}
+	if issue.Fields.Summary != "Write docs" || issue.PriorityName() != "High" || issue.StatusID() != "3" || issue.AssigneeAccountID() != "standin-me" {
+		t.Fatalf("created issue = summary %q priority %q status %q assignee %q",
+			issue.Fields.Summary, issue.PriorityName(), issue.StatusID(), issue.AssigneeAccountID())
+	}
+
+	// 本地修改标题、优先级并移到完成列
+	database.DB.Model(&model.ToDoTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"title": "Write more docs", "priority": 1, "column_id": doneColumnID,
+	})
+	runTaskSync(t, types.JiraTaskSyncPayload{ProjectLinkID: link.ID, TaskID: task.ID})
+	issue, _ = env.standIn.Issue(taskLink.IssueKey)
+	if issue.Fields.Summary != "Write more docs" || issue.PriorityName() != "Low" || issue.StatusID() != "10001" {
+		t.Fatalf("pushed issue = summary %q priority %q status %q", issue.Fields.Summary, issue.PriorityName(), issue.StatusID())
+	}
+	synced := loadTaskLink(t, "task_id = ?", task.ID)
+	if snapshot := synced.Snapshot(); snapshot == nil || snapshot.Status != "10001" || snapshot.Priority != "Low" {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+}
+
+func TestJiraPullIssue(t *testing.T) {
+	env := setupJira(t)
+	link, _ := linkProject(t)
+
+	// 外部新建的问题在状态对应的列建任务
+	issue, err := env.standIn.RemoteCreateIssue(testProjectKey, "From Jira")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runTaskSync(t, types.JiraTaskSyncPayload{ProjectLinkID: link.ID, IssueID: issue.ID, Remote: true})
+	taskLink := loadTaskLink(t, "issue_id = ?", issue.ID)
+	task := loadTask(t, taskLink.TaskID)
+	if task.Title != "From Jira" || task.ColumnID != todoColumnID || task.Status != model.TaskStatusPending {
+		t.Fatalf("created task = title %q column %d status %s", task.Title, task.ColumnID, task.Status)
+	}
+
+	// 外部修改字段与状态后写回任务，状态按映射移动到对应列
+	if err := env.standIn.RemoteEditIssue(issue.Key, func(fields *jira.IssueFields) {
+		fields.Summary = "From Jira, edited"
+		fields.Priority = &jira.Priority{ID: "2", Name: "High"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.standIn.RemoteSetStatus(issue.Key, "3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.standIn.RemoteAddComment(issue.Key, "standin-me", "looks good"); err != nil {
+		t.Fatal(err)
+	}
+	runTaskSync(t, types.JiraTaskSyncPayload{ProjectLinkID: link.ID, IssueID: issue.ID, Remote: true})
+	task = loadTask(t, taskLink.TaskID)
+	if task.Title != "From Jira, edited" || task.Priority != 3 || task.ColumnID != doingColumnID || task.Status != model.TaskStatusInProgress {
+		t.Fatalf("pulled task = title %q priority %d column %d status %s", task.Title, task.Priority, task.ColumnID, task.Status)
+	}
+	var comments []model.ToDoTaskComment
+	database.DB.Where("to_do_task_id = ?", task.ID).Find(&comments)
+	if len(comments) != 1 || comments[0].Content != "[Jira] Stand-in: looks good" {
+		t.Fatalf("pulled comments = %+v", comments)
+	}
+}
+
+func TestJiraWebhookSignature(t *testing.T) {
+	env := setupJira(t)
+	link, secret := linkProject(t)
+	env.standIn.AddProject("OT", "Other")
+
+	// 替身投递的 webhook 经由 HandleJiraWebhook 校验签名
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		code := integrationService.HandleJiraWebhook(r.Context(), &dto.JiraWebhookDTO{
+			LinkID: link.ID, Signature: r.Header.Get(jira.SignatureHeader), Body: body,
+		})
+		if code != message.SUCCESS {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(hook.Close)
+
+	env.standIn.WithWebhook(hook.URL, "not-the-secret")
+	if _, err := env.standIn.RemoteCreateIssue(testProjectKey, "forged"); err == nil {
+		t.Fatal("webhook signed with a wrong secret must be rejected")
+	}
+	unsigned, _ := json.Marshal(jira.WebhookEvent{WebhookEvent: jira.EventIssueUpdated, Issue: &jira.Issue{ID: "1", Key: "NB-1"}})
+	if code := integrationService.HandleJiraWebhook(context.Background(), &dto.JiraWebhookDTO{LinkID: link.ID, Body: unsigned}); code != message.ERROR_JIRA_WEBHOOK_SIGNATURE_INVALID {
+		t.Fatalf("unsigned webhook code = %d", code)
+	}
+	if jobs := env.dispatcher.Jobs(types.JiraTaskSyncKey); len(jobs) != 0 {
+		t.Fatalf("rejected webhooks enqueued %d jobs", len(jobs))
+	}
+
+	env.standIn.WithWebhook(hook.URL, secret)
+	issue, err := env.standIn.RemoteCreateIssue(testProjectKey, "signed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 站点级 webhook 会带来其他项目的问题，不投递
+	if _, err := env.standIn.RemoteCreateIssue("OT", "elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+	jobs := env.dispatcher.Jobs(types.JiraTaskSyncKey)
+	if len(jobs) != 1 {
+		t.Fatalf("sync jobs = %d, want 1", len(jobs))
+	}
+	var payload types.JiraTaskSyncPayload
+	_ = json.Unmarshal(jobs[0].Payload, &payload)
+	if payload.ProjectLinkID != link.ID || payload.IssueID != issue.ID || !payload.Remote || payload.Deleted {
+		t.Fatalf("sync payload = %+v", payload)
+	}
+
+	if err := env.standIn.RemoteDeleteIssue(issue.Key); err != nil {
+		t.Fatal(err)
+	}
+	jobs = env.dispatcher.Jobs(types.JiraTaskSyncKey)
+	_ = json.Unmarshal(jobs[len(jobs)-1].Payload, &payload)
+	if len(jobs) != 2 || !payload.Deleted {
+		t.Fatalf("delete event payload = %+v", payload)
+	}
+}
@@ -0,0 +1,165 @@
+package jira
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 使用 REST API v2：描述与评论均为 wiki 标记的纯文本，便于与本地块互转
+
+type User struct {
+	AccountID    string `json:"accountId"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	Active       bool   `json:"active"`
+}
+
+type Project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// 状态分类，用于状态未配置映射时按流程阶段落到本地列
+const (
+	CategoryNew        = "new"
+	CategoryInProgress = "indeterminate"
+	CategoryDone       = "done"
+)
+
+type StatusCategory struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type Status struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	StatusCategory *StatusCategory `json:"statusCategory,omitempty"`
+}
+
+func (s *Status) CategoryKey() string {
+	if s == nil || s.StatusCategory == nil {
+		return ""
+	}
+	return s.StatusCategory.Key
+}
+
+// IssueTypeStatuses 项目内某个问题类型的工作流状态
+type IssueTypeStatuses struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Statuses []Status `json:"statuses"`
+}
+
+type Priority struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type IssueType struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type IssueFields struct {
+	Summary     string     `json:"summary"`
+	Description *string    `json:"description"`
+	Priority    *Priority  `json:"priority"`
+	Assignee    *User      `json:"assignee"`
+	Status      *Status    `json:"status,omitempty"`
+	IssueType   *IssueType `json:"issuetype,omitempty"`
+	Project     *Project   `json:"project,omitempty"`
+	Updated     string     `json:"updated,omitempty"`
+}
+
+type Issue struct {
+	ID     string      `json:"id"`
+	Key    string      `json:"key"`
+	Self   string      `json:"self,omitempty"`
+	Fields IssueFields `json:"fields"`
+}
+
+func (i *Issue) DescriptionText() string {
+	if i.Fields.Description == nil {
+		return ""
+	}
+	return *i.Fields.Description
+}
+
+func (i *Issue) PriorityName() string {
+	if i.Fields.Priority == nil {
+		return ""
+	}
+	return i.Fields.Priority.Name
+}
+
+func (i *Issue) AssigneeAccountID() string {
+	if i.Fields.Assignee == nil {
+		return ""
+	}
+	return i.Fields.Assignee.AccountID
+}
+
+func (i *Issue) StatusID() string {
+	if i.Fields.Status == nil {
+		return ""
+	}
+	return i.Fields.Status.ID
+}
+
+type Comment struct {
+	ID      string `json:"id"`
+	Body    string `json:"body"`
+	Author  *User  `json:"author,omitempty"`
+	Created string `json:"created,omitempty"`
+	Updated string `json:"updated,omitempty"`
+}
+
+func (c *Comment) AuthorName() string {
+	if c.Author == nil || c.Author.DisplayName == "" {
+		return "Jira"
+	}
+	return c.Author.DisplayName
+}
+
+type commentPage struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	Comments   []Comment `json:"comments"`
+}
+
+type Transition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   Status `json:"to"`
+}
+
+type transitionList struct {
+	Transitions []Transition `json:"transitions"`
+}
+
+type apiError struct {
+	Status        int               `json:"-"`
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+func (e *apiError) Error() string {
+	msgs := append([]string{}, e.ErrorMessages...)
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		msgs = append(msgs, field+": "+e.Errors[field])
+	}
+	if len(msgs) == 0 {
+		return fmt.Sprintf("jira: status %d", e.Status)
+	}
+	return "jira: " + strings.Join(msgs, "; ")
+}
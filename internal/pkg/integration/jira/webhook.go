@@ -0,0 +1,55 @@
+package jira
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SignatureHeader 注册 webhook 时设置 secret 后，Jira 以 HMAC-SHA256 对请求体签名
+const SignatureHeader = "X-Hub-Signature"
+
+const (
+	EventIssueCreated   = "jira:issue_created"
+	EventIssueUpdated   = "jira:issue_updated"
+	EventIssueDeleted   = "jira:issue_deleted"
+	EventCommentCreated = "comment_created"
+	EventCommentUpdated = "comment_updated"
+	EventCommentDeleted = "comment_deleted"
+)
+
+type WebhookEvent struct {
+	Timestamp    int64    `json:"timestamp"`
+	WebhookEvent string   `json:"webhookEvent"`
+	User         *User    `json:"user,omitempty"`
+	Issue        *Issue   `json:"issue,omitempty"`
+	Comment      *Comment `json:"comment,omitempty"`
+}
+
+func ParseWebhook(body []byte) (*WebhookEvent, error) {
+	event := &WebhookEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, err
+	}
+	if event.WebhookEvent == "" {
+		return nil, fmt.Errorf("jira: webhook event missing")
+	}
+	return event, nil
+}
+
+// Sign 生成 "sha256=<hex>" 形式的签名
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
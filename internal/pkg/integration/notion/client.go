@@ -0,0 +1,215 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gin-notebook/configs"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/repository"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.notion.com"
+	APIVersion     = "2022-06-28"
+	maxChildren    = 100 // 单次追加子块的上限
+)
+
+type Client struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	http         *http.Client
+}
+
+// NewClient baseURL 为空时使用官方地址；测试中指向 httptest 启动的 StandIn
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetClient 读取配置中的接口地址以及后台配置的 OAuth 应用；未配置应用时仍可使用内部集成令牌
+func GetClient() *Client {
+	baseURL := ""
+	if configs.Configs != nil {
+		baseURL = configs.Configs.Notion.BaseURL
+	}
+	c := NewClient(baseURL)
+
+	apps, err := repository.NewIntegrationRepository(database.DB).GetIntegrationAppList(context.Background(), "notion")
+	if err == nil && len(apps) > 0 {
+		c.clientID = apps[0].AppID
+		c.clientSecret = apps[0].AppSecretEnc
+	}
+	return c
+}
+
+func (c *Client) OAuthConfigured() bool {
+	return c.clientID != "" && c.clientSecret != ""
+}
+
+// ExchangeCode 用 OAuth 授权码换取访问令牌
+func (c *Client) ExchangeCode(ctx context.Context, code, redirectURI string) (*OAuthTokenResponse, error) {
+	if !c.OAuthConfigured() {
+		return nil, fmt.Errorf("notion: oauth app not configured")
+	}
+	body := map[string]string{"grant_type": "authorization_code", "code": code}
+	if redirectURI != "" {
+		body["redirect_uri"] = redirectURI
+	}
+	token := &OAuthTokenResponse{}
+	err := c.do(ctx, http.MethodPost, "/v1/oauth/token", "", body, token, func(req *http.Request) {
+		req.SetBasicAuth(c.clientID, c.clientSecret)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("notion: empty access token")
+	}
+	return token, nil
+}
+
+// Me 校验令牌并返回对应的机器人用户
+func (c *Client) Me(ctx context.Context, token string) (*BotUser, error) {
+	user := &BotUser{}
+	if err := c.do(ctx, http.MethodGet, "/v1/users/me", token, nil, user, nil); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *Client) RetrievePage(ctx context.Context, token, pageID string) (*Page, error) {
+	page := &Page{}
+	if err := c.do(ctx, http.MethodGet, "/v1/pages/"+url.PathEscape(pageID), token, nil, page, nil); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (c *Client) RetrieveBlock(ctx context.Context, token, blockID string) (*Block, error) {
+	block := &Block{}
+	if err := c.do(ctx, http.MethodGet, "/v1/blocks/"+url.PathEscape(blockID), token, nil, block, nil); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// ListChildren 翻页读取全部直接子块
+func (c *Client) ListChildren(ctx context.Context, token, blockID string) ([]Block, error) {
+	blocks := make([]Block, 0)
+	cursor := ""
+	for {
+		path := "/v1/blocks/" + url.PathEscape(blockID) + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		page := &blockList{}
+		if err := c.do(ctx, http.MethodGet, path, token, nil, page, nil); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, page.Results...)
+		if !page.HasMore || page.NextCursor == nil {
+			return blocks, nil
+		}
+		cursor = *page.NextCursor
+	}
+}
+
+// AppendChildren 在 after 之后追加子块，after 为空时追加到末尾；超过 100 块时分批并保持顺序
+func (c *Client) AppendChildren(ctx context.Context, token, blockID, after string, children []Block) ([]Block, error) {
+	created := make([]Block, 0, len(children))
+	for start := 0; start < len(children); start += maxChildren {
+		end := min(start+maxChildren, len(children))
+		body := map[string]any{"children": children[start:end]}
+		if after != "" {
+			body["after"] = after
+		}
+		resp := &blockList{}
+		if err := c.do(ctx, http.MethodPatch, "/v1/blocks/"+url.PathEscape(blockID)+"/children", token, body, resp, nil); err != nil {
+			return nil, err
+		}
+		created = append(created, resp.Results...)
+		if len(resp.Results) > 0 {
+			after = resp.Results[len(resp.Results)-1].ID
+		}
+	}
+	return created, nil
+}
+
+// UpdateBlock 只能修改同类型块的内容，类型变化需要删除后重建
+func (c *Client) UpdateBlock(ctx context.Context, token, blockID string, block Block) (*Block, error) {
+	updated := &Block{}
+	body := map[string]any{block.Type: block.Body}
+	if err := c.do(ctx, http.MethodPatch, "/v1/blocks/"+url.PathEscape(blockID), token, body, updated, nil); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (c *Client) DeleteBlock(ctx context.Context, token, blockID string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/blocks/"+url.PathEscape(blockID), token, nil, nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path, token string, body any, out any, decorate func(*http.Request)) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Notion-Version", APIVersion)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if decorate != nil {
+		decorate(req)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &apiError{Status: resp.StatusCode}
+		if json.Unmarshal(raw, apiErr) != nil || apiErr.Code == "" {
+			apiErr.Code = http.StatusText(resp.StatusCode)
+			apiErr.Message = string(raw)
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// IsNotFound 页面或块不存在、已删除或未共享给集成
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && (apiErr.Status == http.StatusNotFound || apiErr.Code == "object_not_found")
+}
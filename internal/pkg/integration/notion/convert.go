@@ -0,0 +1,214 @@
+package notion
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/pkg/utils/tools"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const maxRichTextLength = 2000 // Notion 单段 rich_text 的长度上限
+
+var notionColors = map[string]bool{
+	"default": true, "gray": true, "brown": true, "orange": true, "yellow": true,
+	"green": true, "blue": true, "purple": true, "pink": true, "red": true,
+}
+
+func isTextBlock(blockType string) bool {
+	switch blockType {
+	case "paragraph", "heading_1", "heading_2", "heading_3", "bulleted_list_item",
+		"numbered_list_item", "to_do", "code", "quote":
+		return true
+	}
+	return false
+}
+
+// ToNotionBlock 本地块转换为 Notion 块；不支持的类型降级为段落纯文本，子块不下发
+func ToNotionBlock(block dto.NoteBlockDTO) Block {
+	body := &BlockBody{RichText: toRichText(block.Content)}
+	out := Block{Type: "paragraph", Body: body}
+
+	switch block.Type {
+	case "paragraph":
+	case "heading":
+		level := 1
+		if block.Props.Level != nil {
+			level = min(max(*block.Props.Level, 1), 3)
+		}
+		out.Type = []string{"heading_1", "heading_2", "heading_3"}[level-1]
+		body.IsToggleable = block.Props.IsToggleable
+	case "bulletListItem":
+		out.Type = "bulleted_list_item"
+	case "numberedListItem":
+		out.Type = "numbered_list_item"
+	case "checkListItem":
+		out.Type = "to_do"
+		body.Checked = tools.Ptr(block.Props.Checked != nil && *block.Props.Checked)
+	case "codeBlock":
+		out.Type = "code"
+		body.Language = "plain text"
+	case "quote":
+		out.Type = "quote"
+	default:
+		body.RichText = toRichText([]dto.InlineDTO{{Type: "text", Text: dto.InlinePlainText(block.Content)}})
+	}
+
+	if out.Type != "code" {
+		body.Color = "default"
+		if block.Props.BackgroundColor != nil && notionColors[*block.Props.BackgroundColor] && *block.Props.BackgroundColor != "default" {
+			body.Color = *block.Props.BackgroundColor + "_background"
+		} else if block.Props.TextColor != nil && notionColors[*block.Props.TextColor] {
+			body.Color = *block.Props.TextColor
+		}
+	}
+	return out
+}
+
+func toRichText(content []dto.InlineDTO) []RichText {
+	texts := make([]RichText, 0, len(content))
+	appendText := func(text string, styles dto.InlineStylesDTO, href string) {
+		annotations := &Annotations{
+			Bold:          styles.Bold != nil && *styles.Bold,
+			Italic:        styles.Italic != nil && *styles.Italic,
+			Strikethrough: styles.Strike != nil && *styles.Strike,
+			Underline:     styles.Underline != nil && *styles.Underline,
+			Code:          styles.Code != nil && *styles.Code,
+			Color:         "default",
+		}
+		if styles.TextColor != nil && notionColors[*styles.TextColor] {
+			annotations.Color = *styles.TextColor
+		}
+		runes := []rune(text)
+		for start := 0; start < len(runes) || start == 0; start += maxRichTextLength {
+			end := min(start+maxRichTextLength, len(runes))
+			item := RichText{Type: "text", Text: &TextContent{Content: string(runes[start:end])}, Annotations: annotations}
+			if href != "" {
+				item.Text.Link = &struct {
+					URL string `json:"url"`
+				}{URL: href}
+			}
+			texts = append(texts, item)
+			if end == len(runes) {
+				break
+			}
+		}
+	}
+
+	for _, inline := range content {
+		switch inline.Type {
+		case "text":
+			appendText(inline.Text, inline.Styles, "")
+		case "link":
+			for _, child := range inline.Content {
+				appendText(child.Text, child.Styles, inline.Href)
+			}
+		default:
+			appendText(dto.InlinePlainText([]dto.InlineDTO{inline}), inline.Styles, "")
+		}
+	}
+	return texts
+}
+
+// ToLocalBlock Notion 块转换为本地块，ID 由调用方按映射覆盖；不支持的类型返回 false
+func ToLocalBlock(block Block) (dto.NoteBlockDTO, bool) {
+	if block.Body == nil {
+		return dto.NoteBlockDTO{}, false
+	}
+	local := dto.NoteBlockDTO{
+		ID:       uuid.NewString(),
+		Content:  fromRichText(block.Body.RichText),
+		Children: []dto.NoteBlockDTO{},
+		Props: dto.BlockPropsDTO{
+			TextAlignment:   tools.Ptr("left"),
+			TextColor:       tools.Ptr("default"),
+			BackgroundColor: tools.Ptr("default"),
+		},
+	}
+
+	switch block.Type {
+	case "paragraph":
+		local.Type = "paragraph"
+	case "heading_1", "heading_2", "heading_3":
+		local.Type = "heading"
+		local.Props.Level = tools.Ptr(int(block.Type[len(block.Type)-1] - '0'))
+		local.Props.IsToggleable = block.Body.IsToggleable
+	case "bulleted_list_item":
+		local.Type = "bulletListItem"
+	case "numbered_list_item":
+		local.Type = "numberedListItem"
+	case "to_do":
+		local.Type = "checkListItem"
+		local.Props.Checked = tools.Ptr(block.Body.Checked != nil && *block.Body.Checked)
+	case "code":
+		local.Type = "codeBlock"
+	case "quote":
+		local.Type = "quote"
+	default:
+		return dto.NoteBlockDTO{}, false
+	}
+
+	if color := block.Body.Color; color != "" && color != "default" {
+		if bg, ok := strings.CutSuffix(color, "_background"); ok {
+			local.Props.BackgroundColor = &bg
+		} else {
+			local.Props.TextColor = &color
+		}
+	}
+	return local, true
+}
+
+func fromRichText(texts []RichText) []dto.InlineDTO {
+	content := make([]dto.InlineDTO, 0, len(texts))
+	for _, text := range texts {
+		value := text.PlainText
+		if value == "" && text.Text != nil {
+			value = text.Text.Content
+		}
+		styles := dto.InlineStylesDTO{}
+		if a := text.Annotations; a != nil {
+			styles = dto.InlineStylesDTO{
+				Bold:      optionalTrue(a.Bold),
+				Italic:    optionalTrue(a.Italic),
+				Underline: optionalTrue(a.Underline),
+				Strike:    optionalTrue(a.Strikethrough),
+				Code:      optionalTrue(a.Code),
+			}
+			if a.Color != "" && a.Color != "default" && !strings.HasSuffix(a.Color, "_background") {
+				styles.TextColor = tools.Ptr(a.Color)
+			}
+		}
+
+		inline := dto.InlineDTO{Type: "text", Text: value, Styles: styles}
+		if text.Text != nil && text.Text.Link != nil && text.Text.Link.URL != "" {
+			inline = dto.InlineDTO{Type: "link", Href: text.Text.Link.URL, Content: []dto.InlineDTO{inline}}
+		}
+		content = append(content, inline)
+	}
+	return content
+}
+
+func optionalTrue(v bool) *bool {
+	if !v {
+		return nil
+	}
+	return &v
+}
+
+// BlockETag 块内容指纹，只取本系统关心的字段，避免 last_edited_time 等元数据造成误判
+func BlockETag(block Block) string {
+	var raw []byte
+	if block.Body != nil {
+		raw, _ = json.Marshal(struct {
+			Type string     `json:"type"`
+			Body *BlockBody `json:"body"`
+		}{block.Type, block.Body})
+	} else {
+		raw, _ = json.Marshal(block.Type)
+	}
+	sum := sha1.Sum(raw)
+	return hex.EncodeToString(sum[:])
+}
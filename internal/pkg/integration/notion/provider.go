@@ -0,0 +1,188 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/syncer"
+	"time"
+)
+
+// syncProvider Notion 页面的 SyncProvider 实现，只同步页面的顶层块
+type syncProvider struct {
+	client *Client
+}
+
+// NewSyncProvider 作为 syncer.Factory 注册；内部集成令牌不依赖 OAuth 应用，因此总能构造成功
+func NewSyncProvider() (syncer.SyncProvider, error) {
+	return &syncProvider{client: GetClient()}, nil
+}
+
+func (p *syncProvider) Name() model.IntegrationProvider {
+	return model.ProviderNotion
+}
+
+// FetchBlocks 页面本身就是块的父节点，RootID 即页面 ID
+func (p *syncProvider) FetchBlocks(ctx context.Context, token, docID string) (*syncer.Document, error) {
+	children, err := p.children(ctx, token, docID)
+	if err != nil {
+		return nil, err
+	}
+	doc := &syncer.Document{RootID: docID, Blocks: make([]syncer.RemoteBlock, 0, len(children))}
+	for _, block := range children {
+		editedAt, _ := time.Parse(time.RFC3339, block.LastEditedTime)
+		doc.Blocks = append(doc.Blocks, syncer.RemoteBlock{
+			ID:       block.ID,
+			ParentID: docID,
+			Type:     block.Type,
+			ETag:     BlockETag(block),
+			EditedAt: editedAt,
+			Raw:      block,
+		})
+	}
+	return doc, nil
+}
+
+// CreateBlocks Notion 只支持追加到某个块之后，无法插到首位；
+// index 为 0 且页面非空时退化为插在第一个块之后
+func (p *syncProvider) CreateBlocks(ctx context.Context, token, docID, parentID string, index int, blocks dto.Blocks, idempotencyKey string) ([]syncer.BlockRef, error) {
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	if parentID == "" {
+		parentID = docID
+	}
+
+	after := ""
+	if index >= 0 {
+		children, err := p.children(ctx, token, parentID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case index == 0 && len(children) > 0:
+			after = children[0].ID
+		case index > 0 && index <= len(children):
+			after = children[index-1].ID
+		}
+	}
+
+	payload := make([]Block, 0, len(blocks))
+	for _, block := range blocks {
+		payload = append(payload, ToNotionBlock(block))
+	}
+	created, err := p.client.AppendChildren(ctx, token, parentID, after, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]syncer.BlockRef, 0, len(created))
+	for i, block := range created {
+		ref := syncer.BlockRef{ID: block.ID, ParentID: parentID, ETag: BlockETag(block)}
+		if i < len(blocks) {
+			ref.LocalID = blocks[i].ID
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// UpdateBlocks 类型未变时原地修改；类型变化时在原块之后重建并删除原块，返回新块 ID
+func (p *syncProvider) UpdateBlocks(ctx context.Context, token, docID string, updates []syncer.BlockUpdate, idempotencyKey string) ([]syncer.BlockRef, error) {
+	refs := make([]syncer.BlockRef, 0, len(updates))
+	for _, u := range updates {
+		target := ToNotionBlock(u.Block)
+		current, err := p.client.RetrieveBlock(ctx, token, u.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		parentID := docID
+		if current.Parent != nil {
+			if current.Parent.BlockID != "" {
+				parentID = current.Parent.BlockID
+			} else if current.Parent.PageID != "" {
+				parentID = current.Parent.PageID
+			}
+		}
+
+		if current.Type == target.Type {
+			updated, err := p.client.UpdateBlock(ctx, token, u.ExternalID, target)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, syncer.BlockRef{LocalID: u.Block.ID, ID: updated.ID, ParentID: parentID, ETag: BlockETag(*updated)})
+			continue
+		}
+
+		created, err := p.client.AppendChildren(ctx, token, parentID, u.ExternalID, []Block{target})
+		if err != nil {
+			return nil, err
+		}
+		if len(created) == 0 {
+			return nil, fmt.Errorf("notion: recreate block %s returned nothing", u.ExternalID)
+		}
+		if err := p.client.DeleteBlock(ctx, token, u.ExternalID); err != nil && !IsNotFound(err) {
+			return nil, err
+		}
+		refs = append(refs, syncer.BlockRef{LocalID: u.Block.ID, ID: created[0].ID, ParentID: parentID, ETag: BlockETag(created[0])})
+	}
+	return refs, nil
+}
+
+// DeleteBlocks Notion 没有批量删除接口，按下标逐个归档
+func (p *syncProvider) DeleteBlocks(ctx context.Context, token, docID, parentID string, start, end int) error {
+	if start >= end {
+		return nil
+	}
+	if parentID == "" {
+		parentID = docID
+	}
+	children, err := p.children(ctx, token, parentID)
+	if err != nil {
+		return err
+	}
+	if start < 0 || end > len(children) {
+		return fmt.Errorf("notion: delete range [%d, %d) out of bounds", start, end)
+	}
+	for _, block := range children[start:end] {
+		if err := p.client.DeleteBlock(ctx, token, block.ID); err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *syncProvider) ToLocal(blocks []syncer.RemoteBlock) (dto.Blocks, error) {
+	local := make(dto.Blocks, 0, len(blocks))
+	for _, remote := range blocks {
+		block, ok := remote.Raw.(Block)
+		if !ok {
+			return nil, fmt.Errorf("notion: unexpected block payload %T", remote.Raw)
+		}
+		if converted, ok := ToLocalBlock(block); ok {
+			local = append(local, converted)
+		}
+	}
+	return local, nil
+}
+
+// RefreshToken Notion 的令牌不会过期，账户不记录过期时间，不会走到这里
+func (p *syncProvider) RefreshToken(ctx context.Context, refreshToken string) (*syncer.Token, error) {
+	return nil, fmt.Errorf("notion: access tokens do not expire")
+}
+
+// children 已归档的块不参与下标计算
+func (p *syncProvider) children(ctx context.Context, token, blockID string) ([]Block, error) {
+	all, err := p.client.ListChildren(ctx, token, blockID)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]Block, 0, len(all))
+	for _, block := range all {
+		if !block.Archived {
+			children = append(children, block)
+		}
+	}
+	return children, nil
+}
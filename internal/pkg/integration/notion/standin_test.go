@@ -0,0 +1,342 @@
+package notion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StandIn 内存版的 Notion API，实现客户端用到的接口子集。
+// 通过 httptest.NewServer(standIn) 启动后把 notion.base_url 指向它，即可在无外网时联调同步流程
+type StandIn struct {
+	mu           sync.Mutex
+	token        string // 接受的访问令牌，为空时不校验
+	clientID     string
+	clientSecret string
+	seq          int
+	pages        map[string]*Page
+	blocks       map[string]*standInBlock
+	children     map[string][]string
+}
+
+type standInBlock struct {
+	block    Block
+	parent   string
+	archived bool
+}
+
+func NewStandIn(token string) *StandIn {
+	return &StandIn{
+		token:    token,
+		pages:    map[string]*Page{},
+		blocks:   map[string]*standInBlock{},
+		children: map[string][]string{},
+	}
+}
+
+// WithOAuthApp 设置 OAuth 换取令牌时校验的应用凭据
+func (s *StandIn) WithOAuthApp(clientID, clientSecret string) *StandIn {
+	s.clientID = clientID
+	s.clientSecret = clientSecret
+	return s
+}
+
+// AddPage 新建一个空页面并返回页面 ID
+func (s *StandIn) AddPage() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID()
+	s.pages[id] = &Page{Object: "page", ID: id, URL: "https://www.notion.so/" + strings.ReplaceAll(id, "-", ""), LastEditedTime: now()}
+	return id
+}
+
+// SetText 模拟在 Notion 中直接修改块的文字，用于驱动拉取流程
+func (s *StandIn) SetText(blockID, text string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blocks[blockID]
+	if !ok || b.block.Body == nil {
+		return false
+	}
+	b.block.Body.RichText = []RichText{{Type: "text", Text: &TextContent{Content: text}, Annotations: &Annotations{Color: "default"}, PlainText: text}}
+	b.block.LastEditedTime = now()
+	return true
+}
+
+// Children 返回页面或块当前未归档的直接子块
+func (s *StandIn) Children(parentID string) []Block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	blocks := make([]Block, 0)
+	for _, id := range s.children[parentID] {
+		if b := s.blocks[id]; !b.archived {
+			blocks = append(blocks, b.block)
+		}
+	}
+	return blocks
+}
+
+func (s *StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1"), "/")
+	parts := strings.Split(path, "/")
+
+	if path == "oauth/token" && r.Method == http.MethodPost {
+		s.exchangeToken(w, r)
+		return
+	}
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "API token is invalid.")
+		return
+	}
+
+	switch {
+	case path == "users/me" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, BotUser{Object: "user", ID: "bot-standin", Type: "bot", Name: "Stand-in", Bot: &struct {
+			WorkspaceName string `json:"workspace_name"`
+		}{WorkspaceName: "Stand-in Workspace"}})
+	case len(parts) == 2 && parts[0] == "pages" && r.Method == http.MethodGet:
+		page, ok := s.pages[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "object_not_found", "Could not find page with ID: "+parts[1])
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+	case len(parts) == 3 && parts[0] == "blocks" && parts[2] == "children":
+		s.handleChildren(w, r, parts[1])
+	case len(parts) == 2 && parts[0] == "blocks":
+		s.handleBlock(w, r, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, "invalid_request_url", "Invalid request URL.")
+	}
+}
+
+func (s *StandIn) exchangeToken(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != s.clientID || secret != s.clientSecret {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "Invalid client.")
+		return
+	}
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Code == "" {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "Invalid code.")
+		return
+	}
+	writeJSON(w, http.StatusOK, OAuthTokenResponse{
+		AccessToken:   s.token,
+		TokenType:     "bearer",
+		BotID:         "bot-standin",
+		WorkspaceID:   "workspace-standin",
+		WorkspaceName: "Stand-in Workspace",
+	})
+}
+
+func (s *StandIn) handleChildren(w http.ResponseWriter, r *http.Request, parentID string) {
+	if !s.exists(parentID) {
+		writeError(w, http.StatusNotFound, "object_not_found", "Could not find block with ID: "+parentID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ids := make([]string, 0, len(s.children[parentID]))
+		for _, id := range s.children[parentID] {
+			if !s.blocks[id].archived {
+				ids = append(ids, id)
+			}
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("start_cursor"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		if size <= 0 || size > maxChildren {
+			size = maxChildren
+		}
+		start = min(max(start, 0), len(ids))
+		end := min(start+size, len(ids))
+		results := make([]map[string]any, 0, end-start)
+		for _, id := range ids[start:end] {
+			results = append(results, s.render(id))
+		}
+		resp := map[string]any{"object": "list", "results": results, "has_more": end < len(ids), "next_cursor": nil}
+		if end < len(ids) {
+			resp["next_cursor"] = strconv.Itoa(end)
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+	case http.MethodPatch:
+		var body struct {
+			Children []Block `json:"children"`
+			After    string  `json:"after"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Children) == 0 || len(body.Children) > maxChildren {
+			writeError(w, http.StatusBadRequest, "validation_error", "body.children should be a non-empty array of at most 100 blocks.")
+			return
+		}
+		ids := s.children[parentID]
+		pos := len(ids)
+		if body.After != "" {
+			pos = -1
+			for i, id := range ids {
+				if id == body.After {
+					pos = i + 1
+				}
+			}
+			if pos < 0 {
+				writeError(w, http.StatusBadRequest, "validation_error", "body.after is not a child of the parent block.")
+				return
+			}
+		}
+		created := make([]string, 0, len(body.Children))
+		for _, child := range body.Children {
+			if child.Body == nil {
+				writeError(w, http.StatusBadRequest, "validation_error", "Unsupported block type: "+child.Type)
+				return
+			}
+			id := s.nextID()
+			fillPlainText(child.Body)
+			s.blocks[id] = &standInBlock{block: Block{Object: "block", ID: id, Type: child.Type, Body: child.Body, LastEditedTime: now()}, parent: parentID}
+			created = append(created, id)
+		}
+		s.children[parentID] = append(ids[:pos], append(created, ids[pos:]...)...)
+		s.touch(parentID)
+
+		results := make([]map[string]any, 0, len(created))
+		for _, id := range created {
+			results = append(results, s.render(id))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"object": "list", "results": results, "has_more": false, "next_cursor": nil})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request", "Method not allowed.")
+	}
+}
+
+func (s *StandIn) handleBlock(w http.ResponseWriter, r *http.Request, blockID string) {
+	b, ok := s.blocks[blockID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "object_not_found", "Could not find block with ID: "+blockID)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.render(blockID))
+
+	case http.MethodPatch:
+		if b.archived {
+			writeError(w, http.StatusBadRequest, "validation_error", "Can't edit block that is archived.")
+			return
+		}
+		var fields map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", err.Error())
+			return
+		}
+		for key := range fields {
+			if key != b.block.Type && key != "archived" {
+				writeError(w, http.StatusBadRequest, "validation_error", fmt.Sprintf("Block type %s cannot be changed to %s.", b.block.Type, key))
+				return
+			}
+		}
+		if raw, ok := fields[b.block.Type]; ok {
+			body := &BlockBody{}
+			if err := json.Unmarshal(raw, body); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_json", err.Error())
+				return
+			}
+			fillPlainText(body)
+			b.block.Body = body
+			b.block.LastEditedTime = now()
+		}
+		if raw, ok := fields["archived"]; ok {
+			_ = json.Unmarshal(raw, &b.archived)
+		}
+		s.touch(b.parent)
+		writeJSON(w, http.StatusOK, s.render(blockID))
+
+	case http.MethodDelete:
+		b.archived = true
+		s.touch(b.parent)
+		writeJSON(w, http.StatusOK, s.render(blockID))
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request", "Method not allowed.")
+	}
+}
+
+// render 生成与官方接口一致的块结构，内容放在以类型命名的字段下
+func (s *StandIn) render(id string) map[string]any {
+	b := s.blocks[id]
+	parent := map[string]any{"type": "block_id", "block_id": b.parent}
+	if _, ok := s.pages[b.parent]; ok {
+		parent = map[string]any{"type": "page_id", "page_id": b.parent}
+	}
+	out := map[string]any{
+		"object":           "block",
+		"id":               id,
+		"type":             b.block.Type,
+		"parent":           parent,
+		"has_children":     len(s.children[id]) > 0,
+		"archived":         b.archived,
+		"last_edited_time": b.block.LastEditedTime,
+	}
+	if b.block.Body != nil {
+		out[b.block.Type] = b.block.Body
+	}
+	return out
+}
+
+func (s *StandIn) exists(id string) bool {
+	if _, ok := s.pages[id]; ok {
+		return true
+	}
+	b, ok := s.blocks[id]
+	return ok && !b.archived
+}
+
+func (s *StandIn) touch(parentID string) {
+	if page, ok := s.pages[parentID]; ok {
+		page.LastEditedTime = now()
+	}
+}
+
+func (s *StandIn) nextID() string {
+	s.seq++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.seq)
+}
+
+func fillPlainText(body *BlockBody) {
+	for i := range body.RichText {
+		text := &body.RichText[i]
+		if text.Text != nil {
+			text.PlainText = text.Text.Content
+			if text.Text.Link != nil {
+				text.Href = &text.Text.Link.URL
+			}
+		}
+		if text.Annotations == nil {
+			text.Annotations = &Annotations{Color: "default"}
+		}
+	}
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	writeJSON(w, status, apiError{Status: status, Code: code, Message: msg})
+}
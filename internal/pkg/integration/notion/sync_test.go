@@ -0,0 +1,306 @@
+package notion_test
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/configs"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/notion"
+	"gin-notebook/internal/pkg/integration/syncer"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/tasks/asynq/handlers"
+	"gin-notebook/internal/tasks/asynq/types"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	testToken             = "secret_standin"
+	testWorkspaceID int64 = 10
+	testUserID      int64 = 20
+	testMemberID    int64 = 30
+	testNoteID      int64 = 40
+)
+
+// setupNotion 启动 Notion 替身并把客户端指向它，同时准备同步流程用到的表
+func setupNotion(t *testing.T) *notion.StandIn {
+	t.Helper()
+	db := testutil.UseDB(t, &model.Note{}, &model.NoteExternalLink{}, &model.SyncOutbox{},
+		&model.NoteExternalNodeMapping{}, &model.NoteSyncConflict{}, &model.IntegrationAccount{},
+		&model.IntegrationApp{}, &model.WorkspaceMember{}, &model.User{},
+		&model.NoteLink{}, &model.NoteComment{}, &model.NoteChecklistTask{})
+	testutil.UseRedis(t)
+	testutil.UseDispatcher(t)
+
+	standIn := notion.NewStandIn(testToken)
+	srv := httptest.NewServer(standIn)
+	t.Cleanup(srv.Close)
+
+	prev := configs.Configs
+	cfg := configs.Config{}
+	cfg.Notion.BaseURL = srv.URL
+	configs.Configs = &cfg
+	t.Cleanup(func() { configs.Configs = prev })
+	syncer.Register(model.ProviderNotion, notion.NewSyncProvider)
+
+	member := model.WorkspaceMember{WorkspaceID: testWorkspaceID, UserID: testUserID, Role: []byte(`["owner"]`)}
+	member.ID = testMemberID
+	if err := db.Create(&member).Error; err != nil {
+		t.Fatal(err)
+	}
+	return standIn
+}
+
+// linkNotionPage 绑定令牌、建好笔记与链接并完成初始化
+func linkNotionPage(t *testing.T, standIn *notion.StandIn, blocks dto.Blocks) (string, *model.NoteExternalLink) {
+	t.Helper()
+	if code, _ := integrationService.LinkNotionAccount(context.Background(), &dto.NotionTokenLinkDTO{Token: testToken, UserID: testUserID}); code != message.SUCCESS {
+		t.Fatalf("link account code = %d", code)
+	}
+
+	content, _ := json.Marshal(blocks)
+	note := model.Note{Title: "notion", Content: content, WorkspaceID: testWorkspaceID, OwnerID: testUserID, Version: 1}
+	note.ID = testNoteID
+	if err := database.DB.Create(&note).Error; err != nil {
+		t.Fatal(err)
+	}
+	pageID := standIn.AddPage()
+	link := model.NoteExternalLink{
+		NoteID:       testNoteID,
+		Provider:     model.ProviderNotion,
+		TargetNoteID: pageID,
+		MemberID:     testMemberID,
+		Direction:    model.SyncTwoWay,
+		InitStatus:   model.InitPending,
+		IsActive:     true,
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	payload, _ := json.Marshal(types.SyncNotePayload{
+		NoteID: testNoteID, MemberID: testMemberID, UserID: testUserID, WorkspaceID: testWorkspaceID,
+		TargetNoteID: pageID, LinkID: link.ID,
+	})
+	if err := handlers.HandleInitSyncNote(context.Background(), asynq.NewTask(types.InitSyncNoteKey, payload)); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	return pageID, &link
+}
+
+func paragraph(id, text string) dto.NoteBlockDTO {
+	return dto.NoteBlockDTO{ID: id, Type: "paragraph", Content: []dto.InlineDTO{{Type: "text", Text: text}}}
+}
+
+func pageTexts(standIn *notion.StandIn, pageID string) []string {
+	texts := make([]string, 0)
+	for _, b := range standIn.Children(pageID) {
+		texts = append(texts, b.Body.RichText[0].PlainText)
+	}
+	return texts
+}
+
+// mappingsByNode 本地块 ID -> 映射
+func mappingsByNode(t *testing.T) map[string]model.NoteExternalNodeMapping {
+	t.Helper()
+	var mappings []model.NoteExternalNodeMapping
+	if err := database.DB.Where("note_id = ? AND provider = ?", testNoteID, model.ProviderNotion).Find(&mappings).Error; err != nil {
+		t.Fatal(err)
+	}
+	byNode := make(map[string]model.NoteExternalNodeMapping, len(mappings))
+	for _, m := range mappings {
+		byNode[m.NodeUID] = m
+	}
+	return byNode
+}
+
+func equal(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLinkNotionAccount(t *testing.T) {
+	setupNotion(t)
+	ctx := context.Background()
+
+	if code, _ := integrationService.LinkNotionAccount(ctx, &dto.NotionTokenLinkDTO{Token: "secret_wrong", UserID: testUserID}); code != message.ERROR_NOTION_TOKEN_INVALID {
+		t.Fatalf("invalid token code = %d", code)
+	}
+	code, data := integrationService.LinkNotionAccount(ctx, &dto.NotionTokenLinkDTO{Token: testToken, UserID: testUserID})
+	if code != message.SUCCESS || data["account"] == nil {
+		t.Fatalf("link code = %d", code)
+	}
+
+	var account model.IntegrationAccount
+	if err := database.DB.Where("user_id = ? AND provider = ?", testUserID, model.ProviderNotion).First(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	if account.AuthType != model.AuthAPIKey || account.AccessTokenEnc != testToken || !account.IsActive {
+		t.Fatalf("account = %+v", account)
+	}
+	if account.AccountID == nil || *account.AccountID != "bot-standin" || account.AccountName == nil || *account.AccountName != "Stand-in Workspace" {
+		t.Fatalf("account identity = %v / %v", account.AccountID, account.AccountName)
+	}
+}
+
+func TestNotionOAuthCallback(t *testing.T) {
+	standIn := setupNotion(t)
+	standIn.WithOAuthApp("client-id", "client-secret")
+	ctx := context.Background()
+
+	params := &dto.NotionOAuthCallbackDTO{Code: "code", UserID: testUserID}
+	if code, _ := integrationService.HandleNotionOAuthCallback(ctx, params); code != message.ERROR_INTEGRATION_APP_NOT_FOUND {
+		t.Fatalf("callback without app code = %d", code)
+	}
+
+	if err := database.DB.Create(&model.IntegrationApp{Provider: "notion", AppID: "client-id", AppSecretEnc: "wrong-secret", IsActive: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := integrationService.HandleNotionOAuthCallback(ctx, params); code != message.ERROR_NOTION_OAUTH_FAILED {
+		t.Fatalf("callback with bad client code = %d", code)
+	}
+
+	database.DB.Model(&model.IntegrationApp{}).Where("provider = ?", "notion").Update("app_secret_enc", "client-secret")
+	if code, _ := integrationService.HandleNotionOAuthCallback(ctx, params); code != message.SUCCESS {
+		t.Fatalf("callback code = %d", code)
+	}
+	var account model.IntegrationAccount
+	if err := database.DB.Where("user_id = ? AND provider = ?", testUserID, model.ProviderNotion).First(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	if account.AuthType != model.AuthOAuth2 || account.AccessTokenEnc != testToken || account.AccountID == nil || *account.AccountID != "workspace-standin" {
+		t.Fatalf("account = %+v", account)
+	}
+}
+
+func TestNotionInitWritesBlockMappings(t *testing.T) {
+	standIn := setupNotion(t)
+	pageID, link := linkNotionPage(t, standIn, dto.Blocks{paragraph("a", "alpha"), paragraph("b", "beta")})
+
+	if got := pageTexts(standIn, pageID); !equal(got, "alpha", "beta") {
+		t.Fatalf("page blocks = %v", got)
+	}
+	children := standIn.Children(pageID)
+	mappings := mappingsByNode(t)
+	for i, node := range []string{"a", "b"} {
+		m, ok := mappings[node]
+		if !ok {
+			t.Fatalf("block %s is not mapped", node)
+		}
+		if m.ExternalBlockID != children[i].ID || m.ExternalDocID != pageID || m.ETag != notion.BlockETag(children[i]) {
+			t.Fatalf("mapping %s = %+v", node, m)
+		}
+	}
+
+	var ready model.NoteExternalLink
+	database.DB.First(&ready, link.ID)
+	if ready.InitStatus != model.InitReady || ready.ContentVersion != 1 {
+		t.Fatalf("link status=%s version=%d", ready.InitStatus, ready.ContentVersion)
+	}
+}
+
+func TestNotionPushOutboxPatch(t *testing.T) {
+	standIn := setupNotion(t)
+	pageID, link := linkNotionPage(t, standIn, dto.Blocks{paragraph("a", "alpha"), paragraph("b", "beta")})
+	before := mappingsByNode(t)
+
+	edited, inserted := paragraph("a", "alpha v2"), paragraph("c", "gamma")
+	content, _ := json.Marshal(dto.Blocks{edited, inserted, paragraph("b", "beta")})
+	database.DB.Model(&model.Note{}).Where("id = ?", testNoteID).Updates(map[string]interface{}{"content": content, "version": 2})
+	after := "a"
+	patch, _ := json.Marshal([]dto.PatchOp{
+		{Op: "update", NodeUID: "a", Block: &edited},
+		{Op: "insert", NodeUID: "c", Block: &inserted, AfterID: &after},
+	})
+	ob := model.SyncOutbox{LinkID: link.ID, NoteID: testNoteID, NoteVersion: 2, OpType: "patch", PatchJSON: patch, Status: model.SyncPending}
+	if err := database.DB.Create(&ob).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	payload, _ := json.Marshal(types.SyncDeltaPayload{LinkID: link.ID, NoteID: testNoteID, WorkspaceID: testWorkspaceID, UserID: testUserID, MemberID: testMemberID})
+	if err := handlers.HandleSyncDelta(context.Background(), asynq.NewTask(types.SyncDeltaKey, payload)); err != nil {
+		t.Fatalf("delta: %v", err)
+	}
+
+	if got := pageTexts(standIn, pageID); !equal(got, "alpha v2", "gamma", "beta") {
+		t.Fatalf("page blocks = %v", got)
+	}
+	var done model.SyncOutbox
+	database.DB.First(&done, ob.ID)
+	if done.Status != model.SyncSuccess {
+		t.Fatalf("outbox status = %s", done.Status)
+	}
+
+	// 更新的块沿用原外部块并刷新指纹，插入的块新增映射
+	children := standIn.Children(pageID)
+	mappings := mappingsByNode(t)
+	if len(mappings) != 3 {
+		t.Fatalf("mappings = %d, want 3", len(mappings))
+	}
+	if m := mappings["a"]; m.ExternalBlockID != before["a"].ExternalBlockID || m.ETag != notion.BlockETag(children[0]) || m.ETag == before["a"].ETag {
+		t.Fatalf("updated mapping = %+v", m)
+	}
+	if m := mappings["c"]; m.ExternalBlockID != children[1].ID || m.ETag != notion.BlockETag(children[1]) {
+		t.Fatalf("inserted mapping = %+v", m)
+	}
+}
+
+func TestNotionPullRemoteEdit(t *testing.T) {
+	standIn := setupNotion(t)
+	dispatcher := testutil.UseDispatcher(t)
+	pageID, link := linkNotionPage(t, standIn, dto.Blocks{paragraph("a", "alpha"), paragraph("b", "beta")})
+
+	target := model.Note{Title: "Roadmap", Content: []byte(`[]`), WorkspaceID: testWorkspaceID, OwnerID: testUserID}
+	if err := database.DB.Create(&target).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	remoteB := mappingsByNode(t)["b"].ExternalBlockID
+	if !standIn.SetText(remoteB, "beta from notion [[Roadmap]]") {
+		t.Fatal("stand-in block not found")
+	}
+
+	payload, _ := json.Marshal(types.SyncPullPayload{LinkID: link.ID})
+	if err := handlers.HandleSyncPull(context.Background(), asynq.NewTask(types.SyncPullKey, payload)); err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+
+	var note model.Note
+	database.DB.First(&note, testNoteID)
+	var blocks dto.Blocks
+	_ = json.Unmarshal(note.Content, &blocks)
+	if note.Version != 2 || len(blocks) != 2 || blocks[0].Content[0].Text != "alpha" || blocks[1].Content[0].Text != "beta from notion [[Roadmap]]" {
+		t.Fatalf("note version=%d blocks=%+v", note.Version, blocks)
+	}
+
+	var pulled model.NoteExternalLink
+	database.DB.First(&pulled, link.ID)
+	if pulled.ContentVersion != 2 || pulled.LastStatus != model.SyncSuccess {
+		t.Fatalf("link version=%d status=%s", pulled.ContentVersion, pulled.LastStatus)
+	}
+	if m := mappingsByNode(t)["b"]; m.ETag != notion.BlockETag(standIn.Children(pageID)[1]) {
+		t.Fatalf("pulled mapping etag not refreshed: %+v", m)
+	}
+	// 拉取写回与编辑共用派生数据更新，出链随内容重建
+	var links []model.NoteLink
+	database.DB.Where("source_note_id = ?", testNoteID).Find(&links)
+	if len(links) != 1 || links[0].TargetID != target.ID || links[0].BlockID != "b" {
+		t.Fatalf("note links = %+v", links)
+	}
+	if jobs := dispatcher.Jobs(types.IngestNoteKey); len(jobs) != 1 {
+		t.Fatalf("ingest jobs = %d, want 1", len(jobs))
+	}
+}
@@ -0,0 +1,128 @@
+package notion
+
+import "encoding/json"
+
+// OAuthTokenResponse POST /v1/oauth/token 的返回，Notion 的令牌不会过期，也没有 refresh_token
+type OAuthTokenResponse struct {
+	AccessToken   string `json:"access_token"`
+	TokenType     string `json:"token_type"`
+	BotID         string `json:"bot_id"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+}
+
+type BotUser struct {
+	Object string `json:"object"`
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Bot    *struct {
+		WorkspaceName string `json:"workspace_name"`
+	} `json:"bot,omitempty"`
+}
+
+type Page struct {
+	Object         string `json:"object"`
+	ID             string `json:"id"`
+	URL            string `json:"url"`
+	Archived       bool   `json:"archived"`
+	LastEditedTime string `json:"last_edited_time"`
+}
+
+type Annotations struct {
+	Bold          bool   `json:"bold"`
+	Italic        bool   `json:"italic"`
+	Strikethrough bool   `json:"strikethrough"`
+	Underline     bool   `json:"underline"`
+	Code          bool   `json:"code"`
+	Color         string `json:"color"`
+}
+
+type TextContent struct {
+	Content string `json:"content"`
+	Link    *struct {
+		URL string `json:"url"`
+	} `json:"link"`
+}
+
+type RichText struct {
+	Type        string       `json:"type"`
+	Text        *TextContent `json:"text,omitempty"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+	PlainText   string       `json:"plain_text,omitempty"`
+	Href        *string      `json:"href,omitempty"`
+}
+
+// BlockBody 文本类块共用的内容结构
+type BlockBody struct {
+	RichText     []RichText `json:"rich_text"`
+	Color        string     `json:"color,omitempty"`
+	Checked      *bool      `json:"checked,omitempty"`       // 仅 to_do
+	Language     string     `json:"language,omitempty"`      // 仅 code
+	IsToggleable *bool      `json:"is_toggleable,omitempty"` // 仅 heading
+}
+
+// Block 只展开本系统支持的文本类块，其它类型的内容保留在 Raw 中
+type Block struct {
+	Object      string `json:"object,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children,omitempty"`
+	Archived    bool   `json:"archived,omitempty"`
+	// 精确到分钟，RFC 3339 格式
+	LastEditedTime string `json:"last_edited_time,omitempty"`
+	Parent         *struct {
+		Type    string `json:"type"`
+		PageID  string `json:"page_id,omitempty"`
+		BlockID string `json:"block_id,omitempty"`
+	} `json:"parent,omitempty"`
+	Body *BlockBody      `json:"-"`
+	Raw  json.RawMessage `json:"-"`
+}
+
+// MarshalJSON 按 Notion 的格式把内容放到以类型命名的字段下
+func (b Block) MarshalJSON() ([]byte, error) {
+	out := map[string]any{"object": "block", "type": b.Type}
+	if b.Body != nil {
+		out[b.Type] = b.Body
+	}
+	return json.Marshal(out)
+}
+
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type plain Block
+	var head plain
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	*b = Block(head)
+	b.Raw = append(json.RawMessage(nil), data...)
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if body, ok := fields[b.Type]; ok && isTextBlock(b.Type) {
+		b.Body = &BlockBody{}
+		if err := json.Unmarshal(body, b.Body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type blockList struct {
+	Results    []Block `json:"results"`
+	NextCursor *string `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+}
+
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return "notion: " + e.Code + ": " + e.Message
+}
@@ -0,0 +1,69 @@
+package syncer
+
+import (
+	"gin-notebook/internal/pkg/dto"
+	"reflect"
+)
+
+// MergeBlock 以 base 为基线对本地与外部的同一个块做三方合并，只比较类型、属性与内容，子块不参与。
+// 属性按字段合并：只有一侧修改的字段取修改方，两侧改成相同值也视为一致；
+// 两侧对同一字段改成不同值，或没有基线且两侧不一致时返回 conflict
+func MergeBlock(base *dto.NoteBlockDTO, local, remote dto.NoteBlockDTO) (merged dto.NoteBlockDTO, conflict bool) {
+	merged = local
+	merged.Children = nil
+	if SameBlock(local, remote) {
+		return merged, false
+	}
+	if base == nil {
+		return merged, true
+	}
+
+	pick := func(b, l, r any) (any, bool) {
+		switch {
+		case reflect.DeepEqual(l, r), reflect.DeepEqual(b, r):
+			return l, true
+		case reflect.DeepEqual(b, l):
+			return r, true
+		}
+		return nil, false
+	}
+
+	if v, ok := pick(base.Type, local.Type, remote.Type); ok {
+		merged.Type = v.(string)
+	} else {
+		return merged, true
+	}
+	if v, ok := pick(normalizeContent(base.Content), normalizeContent(local.Content), normalizeContent(remote.Content)); ok {
+		merged.Content = v.([]dto.InlineDTO)
+	} else {
+		return merged, true
+	}
+
+	bp := reflect.ValueOf(base.Props)
+	lp := reflect.ValueOf(local.Props)
+	rp := reflect.ValueOf(remote.Props)
+	mp := reflect.ValueOf(&merged.Props).Elem()
+	for i := 0; i < mp.NumField(); i++ {
+		v, ok := pick(bp.Field(i).Interface(), lp.Field(i).Interface(), rp.Field(i).Interface())
+		if !ok {
+			return merged, true
+		}
+		mp.Field(i).Set(reflect.ValueOf(v))
+	}
+	return merged, false
+}
+
+// SameBlock 类型、属性与内容一致即视为相同，忽略 ID 与子块
+func SameBlock(a, b dto.NoteBlockDTO) bool {
+	return a.Type == b.Type &&
+		reflect.DeepEqual(a.Props, b.Props) &&
+		reflect.DeepEqual(normalizeContent(a.Content), normalizeContent(b.Content))
+}
+
+// normalizeContent nil 与空切片等价
+func normalizeContent(content []dto.InlineDTO) []dto.InlineDTO {
+	if len(content) == 0 {
+		return nil
+	}
+	return content
+}
@@ -0,0 +1,58 @@
+package syncer
+
+import (
+	"gin-notebook/internal/pkg/dto"
+	"testing"
+)
+
+func block(text string, color *string) dto.NoteBlockDTO {
+	b := dto.NoteBlockDTO{ID: "a", Type: "paragraph", Props: dto.BlockPropsDTO{TextColor: color}}
+	if text != "" {
+		b.Content = []dto.InlineDTO{{Type: "text", Text: text}}
+	}
+	return b
+}
+
+func TestMergeBlock(t *testing.T) {
+	red, blue := "red", "blue"
+	base := block("base", nil)
+	empty := block("", nil)
+	emptyContent := block("", nil)
+	emptyContent.Content = []dto.InlineDTO{}
+
+	cases := []struct {
+		name          string
+		base          *dto.NoteBlockDTO
+		local, remote dto.NoteBlockDTO
+		want          dto.NoteBlockDTO
+		conflict      bool
+	}{
+		{"local only", &base, block("local", nil), block("base", nil), block("local", nil), false},
+		{"remote only", &base, block("base", nil), block("remote", nil), block("remote", nil), false},
+		{"same change", &base, block("both", &red), block("both", &red), block("both", &red), false},
+		{"same prop change", &base, block("local", &red), block("base", &red), block("local", &red), false},
+		{"fields merged", &base, block("local", nil), block("base", &red), block("local", &red), false},
+		{"content conflict", &base, block("local", nil), block("remote", nil), dto.NoteBlockDTO{}, true},
+		{"prop conflict", &base, block("base", &red), block("base", &blue), dto.NoteBlockDTO{}, true},
+		{"no base differs", nil, block("local", nil), block("remote", nil), dto.NoteBlockDTO{}, true},
+		{"no base same", nil, block("same", nil), block("same", nil), block("same", nil), false},
+		{"nil vs empty content", nil, empty, emptyContent, empty, false},
+		{"empty content unchanged", &empty, emptyContent, block("remote", nil), block("remote", nil), false},
+	}
+	for _, c := range cases {
+		// 子块不参与合并
+		local := c.local
+		local.Children = []dto.NoteBlockDTO{block("child", nil)}
+
+		merged, conflict := MergeBlock(c.base, local, c.remote)
+		if conflict != c.conflict {
+			t.Fatalf("%s: conflict = %v, want %v", c.name, conflict, c.conflict)
+		}
+		if conflict {
+			continue
+		}
+		if merged.Children != nil || !SameBlock(merged, c.want) {
+			t.Fatalf("%s: merged = %+v, want %+v", c.name, merged, c.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"sync"
+	"time"
+)
+
+var ErrProviderNotRegistered = errors.New("sync provider not registered")
+
+// RemoteBlock 外部文档中的一个块；Raw 保存平台原始结构，供 ToLocal 转换。
+// ETag 为块内容指纹，平台无法提供时为空；EditedAt 为块最后修改时间，平台无法提供时为零值
+type RemoteBlock struct {
+	ID       string
+	ParentID string
+	Type     string
+	ETag     string
+	EditedAt time.Time
+	Raw      any
+}
+
+// Document 外部文档：RootID 为插入顶层块时使用的父节点，Blocks 为按文档顺序排列的顶层块
+type Document struct {
+	RootID string
+	Blocks []RemoteBlock
+}
+
+// BlockRef 写入后的外部块。CreateBlocks 按传入顺序一一对应；
+// UpdateBlocks 在平台需要重建块时 ID 会与原 ExternalID 不同
+type BlockRef struct {
+	LocalID  string
+	ID       string
+	ParentID string
+	ETag     string
+}
+
+// BlockUpdate 用本地块内容覆盖 ExternalID 对应的外部块
+type BlockUpdate struct {
+	ExternalID string
+	Block      dto.NoteBlockDTO
+}
+
+type Token struct {
+	AccessToken   string
+	RefreshToken  string
+	AccessExpiry  time.Time
+	RefreshExpiry time.Time
+}
+
+// SyncProvider 笔记同步的外部平台实现，outbox 循环只依赖这组接口。
+// index 均为顶层块下标，-1 表示追加到末尾；DeleteBlocks 删除 [start, end) 范围内的顶层块
+type SyncProvider interface {
+	Name() model.IntegrationProvider
+	FetchBlocks(ctx context.Context, token, docID string) (*Document, error)
+	CreateBlocks(ctx context.Context, token, docID, parentID string, index int, blocks dto.Blocks, idempotencyKey string) ([]BlockRef, error)
+	UpdateBlocks(ctx context.Context, token, docID string, updates []BlockUpdate, idempotencyKey string) ([]BlockRef, error)
+	DeleteBlocks(ctx context.Context, token, docID, parentID string, start, end int) error
+	// ToLocal 把外部块转换为本地块，无法识别的块类型跳过
+	ToLocal(blocks []RemoteBlock) (dto.Blocks, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*Token, error)
+}
+
+// RevisionProvider 能低成本读取文档版本号的平台；拉取前先比对版本，未变化时跳过整篇读取
+type RevisionProvider interface {
+	Revision(ctx context.Context, token, docID string) (string, error)
+}
+
+// EventSubscriber 需要按文档订阅变更事件的平台，初始化完成后调用
+type EventSubscriber interface {
+	Subscribe(ctx context.Context, token, docID string) error
+}
+
+// Factory 每次取用时构造 provider，平台未配置时返回错误
+type Factory func() (SyncProvider, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[model.IntegrationProvider]Factory{}
+)
+
+func Register(name model.IntegrationProvider, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+}
+
+func Get(name model.IntegrationProvider) (SyncProvider, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotRegistered, name)
+	}
+	return factory()
+}
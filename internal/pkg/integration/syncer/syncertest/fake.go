@@ -0,0 +1,218 @@
+// Package syncertest 提供测试用的内存同步平台，只应被 _test.go 引用
+package syncertest
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/syncer"
+	"sync"
+	"time"
+)
+
+const ProviderFake model.IntegrationProvider = "fake"
+
+type fakeDocument struct {
+	blocks []fakeBlock
+}
+
+type fakeBlock struct {
+	id    string
+	block dto.NoteBlockDTO
+}
+
+// FakeProvider 内存实现的 SyncProvider，用于在没有外部平台时驱动完整的
+// init / delta / skip / retry 流程；FailNext 可为指定操作注入一次失败
+type FakeProvider struct {
+	mu       sync.Mutex
+	docs     map[string]*fakeDocument
+	seq      int
+	failures map[string][]error
+	calls    map[string]int
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		docs:     map[string]*fakeDocument{},
+		failures: map[string][]error{},
+		calls:    map[string]int{},
+	}
+}
+
+// RegisterFake 注册给定实例，返回的 provider 与 Get 拿到的是同一个
+func RegisterFake(p *FakeProvider) *FakeProvider {
+	syncer.Register(ProviderFake, func() (syncer.SyncProvider, error) { return p, nil })
+	return p
+}
+
+// FailNext op 为 fetch / create / update / delete / refresh
+func (p *FakeProvider) FailNext(op string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures[op] = append(p.failures[op], err)
+}
+
+func (p *FakeProvider) Calls(op string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[op]
+}
+
+// Snapshot 返回文档当前的本地块视图
+func (p *FakeProvider) Snapshot(docID string) dto.Blocks {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	doc := p.docs[docID]
+	if doc == nil {
+		return dto.Blocks{}
+	}
+	blocks := make(dto.Blocks, 0, len(doc.blocks))
+	for _, b := range doc.blocks {
+		blocks = append(blocks, b.block)
+	}
+	return blocks
+}
+
+func (p *FakeProvider) Name() model.IntegrationProvider {
+	return ProviderFake
+}
+
+func (p *FakeProvider) FetchBlocks(ctx context.Context, token, docID string) (*syncer.Document, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.hit("fetch"); err != nil {
+		return nil, err
+	}
+	doc := p.doc(docID)
+	remote := &syncer.Document{RootID: docID, Blocks: make([]syncer.RemoteBlock, 0, len(doc.blocks))}
+	for _, b := range doc.blocks {
+		remote.Blocks = append(remote.Blocks, syncer.RemoteBlock{ID: b.id, ParentID: docID, Type: b.block.Type, ETag: fakeETag(b.block), Raw: b.block})
+	}
+	return remote, nil
+}
+
+func (p *FakeProvider) CreateBlocks(ctx context.Context, token, docID, parentID string, index int, blocks dto.Blocks, idempotencyKey string) ([]syncer.BlockRef, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.hit("create"); err != nil {
+		return nil, err
+	}
+	doc := p.doc(docID)
+	if index < 0 || index > len(doc.blocks) {
+		index = len(doc.blocks)
+	}
+	created := make([]syncer.BlockRef, 0, len(blocks))
+	inserted := make([]fakeBlock, 0, len(blocks))
+	for _, block := range blocks {
+		p.seq++
+		id := fmt.Sprintf("fake-%d", p.seq)
+		inserted = append(inserted, fakeBlock{id: id, block: block})
+		created = append(created, syncer.BlockRef{LocalID: block.ID, ID: id, ParentID: docID, ETag: fakeETag(block)})
+	}
+	doc.blocks = append(doc.blocks[:index], append(inserted, doc.blocks[index:]...)...)
+	return created, nil
+}
+
+func (p *FakeProvider) UpdateBlocks(ctx context.Context, token, docID string, updates []syncer.BlockUpdate, idempotencyKey string) ([]syncer.BlockRef, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.hit("update"); err != nil {
+		return nil, err
+	}
+	doc := p.doc(docID)
+	refs := make([]syncer.BlockRef, 0, len(updates))
+	for _, u := range updates {
+		for i := range doc.blocks {
+			if doc.blocks[i].id == u.ExternalID {
+				doc.blocks[i].block = u.Block
+				refs = append(refs, syncer.BlockRef{LocalID: u.Block.ID, ID: u.ExternalID, ParentID: docID, ETag: fakeETag(u.Block)})
+			}
+		}
+	}
+	return refs, nil
+}
+
+// Edit 模拟在外部平台直接修改块内容，用于驱动拉取流程
+func (p *FakeProvider) Edit(docID, externalID string, block dto.NoteBlockDTO) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	doc := p.doc(docID)
+	for i := range doc.blocks {
+		if doc.blocks[i].id == externalID {
+			doc.blocks[i].block = block
+		}
+	}
+}
+
+func (p *FakeProvider) DeleteBlocks(ctx context.Context, token, docID, parentID string, start, end int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.hit("delete"); err != nil {
+		return err
+	}
+	doc := p.doc(docID)
+	if start < 0 || end > len(doc.blocks) || start > end {
+		return fmt.Errorf("fake: delete range [%d, %d) out of bounds", start, end)
+	}
+	doc.blocks = append(doc.blocks[:start], doc.blocks[end:]...)
+	return nil
+}
+
+func (p *FakeProvider) ToLocal(blocks []syncer.RemoteBlock) (dto.Blocks, error) {
+	local := make(dto.Blocks, 0, len(blocks))
+	for _, b := range blocks {
+		if block, ok := b.Raw.(dto.NoteBlockDTO); ok {
+			local = append(local, block)
+		}
+	}
+	return local, nil
+}
+
+func (p *FakeProvider) RefreshToken(ctx context.Context, refreshToken string) (*syncer.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.hit("refresh"); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &syncer.Token{
+		AccessToken:   "fake-access-" + refreshToken,
+		RefreshToken:  refreshToken,
+		AccessExpiry:  now.Add(2 * time.Hour),
+		RefreshExpiry: now.Add(30 * 24 * time.Hour),
+	}, nil
+}
+
+func fakeETag(block dto.NoteBlockDTO) string {
+	raw, _ := json.Marshal(struct {
+		Type    string
+		Props   dto.BlockPropsDTO
+		Content []dto.InlineDTO
+	}{block.Type, block.Props, block.Content})
+	sum := sha1.Sum(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *FakeProvider) doc(docID string) *fakeDocument {
+	doc, ok := p.docs[docID]
+	if !ok {
+		doc = &fakeDocument{}
+		p.docs[docID] = doc
+	}
+	return doc
+}
+
+// hit 记录调用次数并弹出一个注入的失败，调用方需持有锁
+func (p *FakeProvider) hit(op string) error {
+	p.calls[op]++
+	queue := p.failures[op]
+	if len(queue) == 0 {
+		return nil
+	}
+	p.failures[op] = queue[1:]
+	return queue[0]
+}
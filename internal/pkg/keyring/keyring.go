@@ -0,0 +1,280 @@
+package keyring
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 密文格式 enc:v1:<kid>:<base64(nonce|ciphertext)>，kid 同时作为 AEAD 附加数据，
+// 不带前缀的值视为旧的明文数据，由轮换任务补加密
+const (
+	prefix  = "enc:v1:"
+	keySize = 32
+
+	// reloadInterval 其他进程（管理命令、其他实例）生成新主用密钥后，最迟在这个间隔内生效
+	reloadInterval = time.Minute
+)
+
+var (
+	ErrDisabled     = errors.New("keyring: master key not configured")
+	ErrNoPrimaryKey = errors.New("keyring: no primary key")
+	ErrUnknownKey   = errors.New("keyring: unknown key id")
+	ErrMalformed    = errors.New("keyring: malformed ciphertext")
+)
+
+// KeyStore 数据密钥的持久化，由 repository 实现
+type KeyStore interface {
+	ListUsableKeys(ctx context.Context) ([]model.EncryptionKey, error)
+	CreatePrimaryKey(ctx context.Context, key *model.EncryptionKey) error
+}
+
+type Keyring struct {
+	master cipher.AEAD
+	store  KeyStore
+
+	mu       sync.RWMutex
+	keys     map[string]cipher.AEAD
+	primary  string
+	loadedAt time.Time
+}
+
+var defaultRing *Keyring
+
+// Init 初始化全局密钥环；masterKey 为空时不加密，读写保持明文。
+// 配置了主密钥但库中没有可用密钥时自动生成第一个主用密钥
+func Init(ctx context.Context, masterKey string, store KeyStore) error {
+	if masterKey == "" {
+		defaultRing = nil
+		return nil
+	}
+	ring, err := New(masterKey, store)
+	if err != nil {
+		return err
+	}
+	if err := ring.Reload(ctx); err != nil {
+		return err
+	}
+	if ring.PrimaryKID() == "" {
+		if _, err := ring.Generate(ctx); err != nil {
+			return err
+		}
+	}
+	defaultRing = ring
+	return nil
+}
+
+// Default 未配置主密钥时返回 nil
+func Default() *Keyring {
+	return defaultRing
+}
+
+func New(masterKey string, store KeyStore) (*Keyring, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(masterKey))
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decode master key: %w", err)
+	}
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("keyring: master key must be %d bytes, got %d", keySize, len(raw))
+	}
+	master, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{master: master, store: store, keys: map[string]cipher.AEAD{}}, nil
+}
+
+// Reload 重新加载所有未停用的数据密钥
+func (k *Keyring) Reload(ctx context.Context) error {
+	rows, err := k.store.ListUsableKeys(ctx)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]cipher.AEAD, len(rows))
+	primary := ""
+	for _, row := range rows {
+		raw, err := k.unwrap(row.KID, row.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("keyring: unwrap %s: %w", row.KID, err)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return err
+		}
+		keys[row.KID] = aead
+		if row.Status == model.KeyPrimary {
+			primary = row.KID
+		}
+	}
+
+	k.mu.Lock()
+	k.keys, k.primary, k.loadedAt = keys, primary, time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+func (k *Keyring) PrimaryKID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// Generate 生成新的数据密钥并设为主用，原主用密钥降为只解密
+func (k *Keyring) Generate(ctx context.Context) (*model.EncryptionKey, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	kid := time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(suffix)
+
+	wrapped, err := k.wrap(kid, raw)
+	if err != nil {
+		return nil, err
+	}
+	key := &model.EncryptionKey{KID: kid, WrappedKey: wrapped, Status: model.KeyPrimary}
+	if err := k.store.CreatePrimaryKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, k.Reload(ctx)
+}
+
+// Encrypt 用主用密钥加密，返回密文与密钥 ID
+func (k *Keyring) Encrypt(ctx context.Context, plain string) (string, string, error) {
+	if k.stale() {
+		if err := k.Reload(ctx); err != nil {
+			return "", "", err
+		}
+	}
+	k.mu.RLock()
+	kid, aead := k.primary, k.keys[k.primary]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", "", ErrNoPrimaryKey
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plain), []byte(kid))
+	return prefix + kid + ":" + base64.StdEncoding.EncodeToString(sealed), kid, nil
+}
+
+// Decrypt 支持所有未停用的密钥；遇到未知密钥 ID 时重新加载一次
+func (k *Keyring) Decrypt(ctx context.Context, value string) (string, error) {
+	kid, payload, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	k.mu.RLock()
+	aead := k.keys[kid]
+	k.mu.RUnlock()
+	if aead == nil {
+		if err := k.Reload(ctx); err != nil {
+			return "", err
+		}
+		k.mu.RLock()
+		aead = k.keys[kid]
+		k.mu.RUnlock()
+		if aead == nil {
+			return "", fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+		}
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(kid))
+	if err != nil {
+		return "", fmt.Errorf("keyring: decrypt with %s: %w", kid, err)
+	}
+	return string(plain), nil
+}
+
+func (k *Keyring) stale() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return time.Since(k.loadedAt) > reloadInterval
+}
+
+func (k *Keyring) wrap(kid string, raw []byte) (string, error) {
+	nonce := make([]byte, k.master.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(k.master.Seal(nonce, nonce, raw, []byte(kid))), nil
+}
+
+func (k *Keyring) unwrap(kid, wrapped string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(sealed) < k.master.NonceSize() {
+		return nil, ErrMalformed
+	}
+	n := k.master.NonceSize()
+	return k.master.Open(nil, sealed[:n], sealed[n:], []byte(kid))
+}
+
+// Encrypt 使用全局密钥环加密；未配置主密钥时原样返回，kid 为空
+func Encrypt(ctx context.Context, plain string) (string, string, error) {
+	if defaultRing == nil {
+		return plain, "", nil
+	}
+	return defaultRing.Encrypt(ctx, plain)
+}
+
+// Decrypt 使用全局密钥环解密，明文值原样返回
+func Decrypt(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if defaultRing == nil {
+		return "", ErrDisabled
+	}
+	return defaultRing.Decrypt(ctx, value)
+}
+
+// IsEncrypted 是否为密钥环生成的密文
+func IsEncrypted(value string) bool {
+	_, _, ok := parse(value)
+	return ok
+}
+
+// KeyID 密文使用的密钥 ID，明文返回空串
+func KeyID(value string) string {
+	kid, _, _ := parse(value)
+	return kid
+}
+
+func parse(value string) (kid, payload string, ok bool) {
+	rest, found := strings.CutPrefix(value, prefix)
+	if !found {
+		return "", "", false
+	}
+	kid, payload, ok = strings.Cut(rest, ":")
+	if !ok || kid == "" || payload == "" {
+		return "", "", false
+	}
+	return kid, payload, true
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
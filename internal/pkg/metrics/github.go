@@ -36,8 +36,6 @@ func GetClient() *github.Client {
 }
 
 func GetRepoMetrics(ctx context.Context) (*github.Repository, error) {
-	client := GetClient()
-
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -46,7 +44,16 @@ func GetRepoMetrics(ctx context.Context) (*github.Repository, error) {
 		return nil, nil
 	}
 
-	r, _, err := client.Repositories.Get(ctx, configs.Configs.Github.Owner, configs.Configs.Github.Repo)
+	return GetRepository(ctx, configs.Configs.Github.Owner, configs.Configs.Github.Repo)
+}
+
+// GetRepository 使用配置的令牌读取仓库，私有仓库需要令牌有访问权限
+func GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r, _, err := GetClient().Repositories.Get(ctx, owner, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -12,6 +12,7 @@ import (
 const (
 	wsChProject = "project_events:" // project_events:{projectID}
 	wsChTask    = "task_events:"    // task_events:{taskID}
+	wsChNote    = "note_events:"    // note_events:{noteID}
 )
 
 // —— 发布实现 —— //
@@ -31,6 +32,12 @@ func (b *RedisWsPublisher) PublishTask(ctx context.Context, taskID string, evt W
 	return b.rdb.Publish(ctx, wsChTask+taskID, raw).Err()
 }
 
+func (b *RedisWsPublisher) PublishNote(ctx context.Context, noteID string, evt WsEvent) error {
+	evt.NoteID = noteID
+	raw, _ := json.Marshal(evt)
+	return b.rdb.Publish(ctx, wsChNote+noteID, raw).Err()
+}
+
 // —— 订阅辅助：给 realtimeService 使用 —— //
 func PSubscribeWsProjects(rdb *redis.Client) *redis.PubSub {
 	return rdb.PSubscribe(context.Background(), wsChProject+"*")
@@ -38,6 +45,9 @@ func PSubscribeWsProjects(rdb *redis.Client) *redis.PubSub {
 func PSubscribeWsTasks(rdb *redis.Client) *redis.PubSub {
 	return rdb.PSubscribe(context.Background(), wsChTask+"*")
 }
+func PSubscribeWsNotes(rdb *redis.Client) *redis.PubSub {
+	return rdb.PSubscribe(context.Background(), wsChNote+"*")
+}
 
 // 从 redis 消息反解 WsEvent，并补全 {project,task}ID（从 channel 提取，防止事件体缺失）
 func DecodeWsEventFromRedis(channel string, payload string) (WsEvent, bool) {
@@ -51,6 +61,9 @@ func DecodeWsEventFromRedis(channel string, payload string) (WsEvent, bool) {
 	if strings.HasPrefix(channel, wsChTask) && e.TaskID == "" {
 		e.TaskID = strings.TrimPrefix(channel, wsChTask)
 	}
+	if strings.HasPrefix(channel, wsChNote) && e.NoteID == "" {
+		e.NoteID = strings.TrimPrefix(channel, wsChNote)
+	}
 	return e, true
 }
 
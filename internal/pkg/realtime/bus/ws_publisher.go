@@ -15,6 +15,7 @@ var (
 type WsPublisher interface {
 	PublishProject(ctx context.Context, projectID string, evt WsEvent) error
 	PublishTask(ctx context.Context, taskID string, evt WsEvent) error
+	PublishNote(ctx context.Context, noteID string, evt WsEvent) error
 }
 
 // 在 main/startup 注入一次
@@ -67,3 +68,55 @@ func PublishCommentAdded(ctx context.Context, taskID int64, comment map[string]a
 		},
 	})
 }
+
+func PublishWsNote(ctx context.Context, noteID string, evt WsEvent) error {
+	wsMu.RLock()
+	p := defaultWsPublisher
+	wsMu.RUnlock()
+	if p == nil {
+		return nil
+	}
+	evt.NoteID = noteID // 兜底
+	return p.PublishNote(ctx, noteID, evt)
+}
+
+// 笔记评论事件，推送到 note_events:{noteID} 房间
+func PublishNoteComment(ctx context.Context, evtType EventType, noteID int64, comment any) error {
+	noteIDStr := strconv.FormatInt(noteID, 10)
+
+	return PublishWsNote(ctx, noteIDStr, WsEvent{
+		Type:   evtType,
+		NoteID: noteIDStr,
+		Payload: map[string]any{
+			"comment": comment,
+		},
+	})
+}
+
+// 笔记被修改，打开该笔记的客户端据此拉取新版本
+func PublishNoteUpdated(ctx context.Context, noteID, version int64, fields []string, actorID int64) error {
+	noteIDStr := strconv.FormatInt(noteID, 10)
+
+	return PublishWsNote(ctx, noteIDStr, WsEvent{
+		Type:   WsNoteUpdated,
+		NoteID: noteIDStr,
+		Payload: map[string]any{
+			"version":  version,
+			"fields":   fields,
+			"actor_id": strconv.FormatInt(actorID, 10),
+		},
+	})
+}
+
+// 笔记锁状态变化，解锁时 lock 为 nil
+func PublishNoteLock(ctx context.Context, evtType EventType, noteID int64, lock any) error {
+	noteIDStr := strconv.FormatInt(noteID, 10)
+
+	return PublishWsNote(ctx, noteIDStr, WsEvent{
+		Type:   evtType,
+		NoteID: noteIDStr,
+		Payload: map[string]any{
+			"lock": lock,
+		},
+	})
+}
@@ -27,3 +27,21 @@ func SubscribeTaskEventsLoop(ctx context.Context, rdb *redis.Client, handle Task
 		}
 	}
 }
+
+func SubscribeNoteEventsLoop(ctx context.Context, rdb *redis.Client, handle TaskEventHandler) {
+	ps := PSubscribeWsNotes(rdb) // note_events:*
+	defer ps.Close()
+	for {
+		msg, err := ps.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+		if e, ok := DecodeWsEventFromRedis(msg.Channel, msg.Payload); ok {
+			handle(e)
+		}
+	}
+}
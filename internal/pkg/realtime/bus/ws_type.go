@@ -8,12 +8,18 @@ const (
 	WsProjectDirty   EventType = "project_dirty"
 	WsCommentAdded   EventType = "comment_added"
 	WsCommentRemoved EventType = "comment_removed"
+	WsCommentUpdated EventType = "comment_updated" // 评论编辑、解决/重新打开、锚点变化
+	WsNoteLocked     EventType = "note_locked"     // 加锁、续期或转为永久锁
+	WsNoteUnlocked   EventType = "note_unlocked"
+	WsNoteSynced     EventType = "note_synced"  // 外部平台的修改已拉取到笔记
+	WsNoteUpdated    EventType = "note_updated" // 笔记内容或元数据被修改
 )
 
 type WsEvent struct {
 	Type      EventType      `json:"type"`
 	ProjectID string         `json:"project_id,omitempty"`
 	TaskID    string         `json:"task_id,omitempty"`
+	NoteID    string         `json:"note_id,omitempty"`
 	Payload   map[string]any `json:"payload,omitempty"`
 }
 
@@ -5,8 +5,11 @@ import "strings"
 const (
 	RoomPrefixProject = "project_presence:"
 	RoomPrefixTask    = "task_events:"
+	RoomPrefixNote    = "note_events:"
 )
 
 func IsProjectRoom(r string) bool { return strings.HasPrefix(r, RoomPrefixProject) }
 func IsTaskRoom(r string) bool    { return strings.HasPrefix(r, RoomPrefixTask) }
+func IsNoteRoom(r string) bool    { return strings.HasPrefix(r, RoomPrefixNote) }
 func TaskRoom(id string) string   { return RoomPrefixTask + id }
+func NoteRoom(id string) string   { return RoomPrefixNote + id }
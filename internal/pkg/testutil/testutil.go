@@ -0,0 +1,145 @@
+// Package testutil 测试用的全局依赖替身：sqlite 数据库、miniredis 与记录入队任务的 Dispatcher，只应被 _test.go 引用
+package testutil
+
+import (
+	"context"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	asynqSingleton "gin-notebook/internal/tasks/asynq/singleton"
+	"gin-notebook/internal/tasks/contracts"
+	"gin-notebook/pkg/utils/algorithm"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redsync/redsync/v4"
+	redsyncredis "github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// sqliteSchema 默认值是 postgres 函数等无法改写的表，sqlite 下手写建表
+var sqliteSchema = map[string]string{
+	"sync_outbox": "CREATE TABLE `sync_outbox` (`id` integer PRIMARY KEY AUTOINCREMENT,`link_id` bigint NOT NULL,`note_id` bigint NOT NULL,`note_version` bigint NOT NULL,`op_type` varchar(32) NOT NULL,`patch_json` JSON NOT NULL,`status` varchar(16) NOT NULL DEFAULT 'pending',`created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,`updated_at` datetime,`last_error` text,`attempts` integer NOT NULL DEFAULT 0,`next_retry_at` datetime)",
+}
+
+// UseDB 用临时 sqlite 文件替换 database.DB 并建好 models 对应的表，测试结束后恢复
+func UseDB(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+	if algorithm.Snow == nil {
+		if err := algorithm.NewSnowflake(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000"),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		if ddl, ok := sqliteSchema[stmt.Schema.Table]; ok {
+			err = db.Exec(ddl).Error
+		} else {
+			adaptSchema(stmt.Schema)
+			err = db.AutoMigrate(m)
+		}
+		if err != nil {
+			t.Fatalf("create table %s: %v", stmt.Schema.Table, err)
+		}
+	}
+
+	prev := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = prev })
+	return db
+}
+
+// adaptSchema 让 postgres 的模型能在 sqlite 建表：去掉 '[]'::jsonb 这类默认值里的类型转换，
+// 索引名加上表名前缀（sqlite 的索引名在整个库内唯一）。AutoMigrate 复用同一份缓存的 schema
+func adaptSchema(s *schema.Schema) {
+	for _, field := range s.Fields {
+		if i := strings.Index(field.DefaultValue, "::"); i >= 0 {
+			field.DefaultValue = field.DefaultValue[:i]
+			field.DefaultValueInterface = strings.Trim(field.DefaultValue, "'")
+		}
+
+		tag := field.Tag.Get("gorm")
+		parts := strings.Split(tag, ";")
+		for i, part := range parts {
+			key, name, ok := strings.Cut(part, ":")
+			switch strings.ToUpper(strings.TrimSpace(key)) {
+			case "INDEX", "UNIQUEINDEX":
+				if ok && name != "" && !strings.HasPrefix(name, ",") {
+					parts[i] = key + ":" + s.Table + "_" + name
+				}
+			}
+		}
+		field.Tag = reflect.StructTag(strings.Replace(string(field.Tag), `gorm:"`+tag+`"`, `gorm:"`+strings.Join(parts, ";")+`"`, 1))
+	}
+}
+
+// UseRedis 用 miniredis 替换 cache.RedisInstance，测试结束后恢复
+func UseRedis(t testing.TB) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	prev := cache.RedisInstance
+	cache.RedisInstance = &cache.RedisClient{Client: rdb, Locker: redsync.New(redsyncredis.NewPool(rdb))}
+	t.Cleanup(func() {
+		cache.RedisInstance = prev
+		_ = rdb.Close()
+	})
+	return mr
+}
+
+// Job 一次入队记录
+type Job struct {
+	Key     contracts.JobKey
+	Payload []byte
+}
+
+// Dispatcher 只记录入队的任务，不真正执行
+type Dispatcher struct {
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// UseDispatcher 替换全局 Dispatcher；测试结束后不恢复，后续测试会再次替换
+func UseDispatcher(t testing.TB) *Dispatcher {
+	t.Helper()
+	d := &Dispatcher{}
+	asynqSingleton.UseDispatcher(d)
+	return d
+}
+
+func (d *Dispatcher) Enqueue(ctx context.Context, key contracts.JobKey, payload []byte, opts ...contracts.Option) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.jobs = append(d.jobs, Job{Key: key, Payload: payload})
+	return strconv.Itoa(len(d.jobs)), nil
+}
+
+// Jobs 返回指定类型的入队记录
+func (d *Dispatcher) Jobs(key contracts.JobKey) []Job {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	jobs := make([]Job, 0)
+	for _, job := range d.jobs {
+		if job.Key == key {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	sendTimeout  = 10 * time.Second
+	maxRespBytes = 256 // 投递日志只保存响应的前 256 字节，避免把响应内容大段回显
+)
+
+// ErrBlockedAddress 订阅地址指向回环、内网等不允许投递的地址
+var ErrBlockedAddress = errors.New("webhook destination address is not allowed")
+
+// blockedNets IsPrivate 等之外同样不能访问的保留网段
+var blockedNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),     // 本网络
+	mustCIDR("100.64.0.0/10"), // 运营商级 NAT
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// BlockedIP 回环、私有、链路本地（含云厂商元数据地址）、组播与未指定地址不允许投递
+func BlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialControl 在连接建立前检查实际拨号的 IP，DNS 重绑定拿到的地址同样会被拒绝
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || BlockedIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// newClient 不走环境代理（代理会绕过拨号检查），也不跟随重定向，3xx 原样作为失败记录
+func newClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: sendTimeout, Control: control}
+	return &http.Client{
+		Timeout: sendTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: sendTimeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var client = newClient(dialControl)
+
+// Result 单次投递的响应，请求未发出时 Status 为 0
+type Result struct {
+	Status   int
+	Body     string
+	Duration time.Duration
+}
+
+// Send 签名后 POST 到订阅地址，非 2xx 响应视为失败
+func Send(ctx context.Context, url, secret, event, deliveryID string, body []byte) (*Result, error) {
+	ts := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mamoes-Webhook/1.0")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, ts, body))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result := &Result{Duration: time.Since(start)}
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxRespBytes))
+	result.Status, result.Body = resp.StatusCode, string(b)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return result, nil
+}
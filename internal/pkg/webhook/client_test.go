@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBlockedIP(t *testing.T) {
+	for ip, blocked := range map[string]bool{
+		"127.0.0.1":        true,
+		"::1":              true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"fd00::1":          true,
+		"fe80::1":          true,
+		"::ffff:127.0.0.1": true,
+		"8.8.8.8":          false,
+		"2606:4700::1111":  false,
+	} {
+		if got := BlockedIP(net.ParseIP(ip)); got != blocked {
+			t.Fatalf("BlockedIP(%s) = %v, want %v", ip, got, blocked)
+		}
+	}
+}
+
+func TestSendRejectsBlockedAddress(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, "http://169.254.169.254/latest/meta-data"} {
+		result, err := Send(context.Background(), url, "secret", "note.created", "1", []byte(`{}`))
+		if !errors.Is(err, ErrBlockedAddress) || result.Status != 0 {
+			t.Fatalf("%s: status = %d err = %v", url, result.Status, err)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Fatal("request reached a loopback address")
+	}
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	// 本测试只验证重定向与响应截断，放开回环地址
+	prev := client
+	client = newClient(nil)
+	t.Cleanup(func() { client = prev })
+
+	var hits atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", target.URL)
+		w.WriteHeader(http.StatusFound)
+		_, _ = w.Write([]byte(strings.Repeat("x", 4096)))
+	}))
+	defer redirect.Close()
+
+	result, err := Send(context.Background(), redirect.URL, "secret", "note.created", "1", []byte(`{}`))
+	if err == nil || result.Status != http.StatusFound {
+		t.Fatalf("status = %d err = %v", result.Status, err)
+	}
+	if hits.Load() != 0 {
+		t.Fatal("redirect was followed")
+	}
+	if len(result.Body) != maxRespBytes {
+		t.Fatalf("stored %d response bytes", len(result.Body))
+	}
+}
@@ -0,0 +1,45 @@
+// Package webhook 外发 webhook 的签名与投递
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Mamoes-Event"
+	DeliveryHeader  = "X-Mamoes-Delivery"
+	TimestampHeader = "X-Mamoes-Timestamp"
+	SignatureHeader = "X-Mamoes-Signature"
+)
+
+// Envelope 投递给订阅方的请求体
+type Envelope struct {
+	ID          string          `json:"id"` // 事件 ID，同一事件投递给多个订阅时相同
+	Event       string          `json:"event"`
+	WorkspaceID string          `json:"workspace_id"`
+	ProjectID   string          `json:"project_id,omitempty"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Sign 对 "<timestamp>.<body>" 做 HMAC-SHA256，生成 "sha256=<hex>" 形式的签名；
+// 时间戳参与签名，接收方可据此拒绝重放
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type aclRepository struct {
+	db *gorm.DB
+}
+
+func NewACLRepository(db *gorm.DB) *aclRepository {
+	return &aclRepository{db: db}
+}
+
+func (r *aclRepository) GetNotePermissions(ctx context.Context, noteID int64) ([]model.NotePermission, error) {
+	var permissions []model.NotePermission
+	err := r.db.WithContext(ctx).
+		Where("note_id = ?", noteID).
+		Order("created_at ASC").
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// GetGranteePermissions 取出命中某个成员（直接授权或所在分组）的全部授权
+func (r *aclRepository) GetGranteePermissions(ctx context.Context, noteID, memberID int64, groups []string) ([]model.NotePermission, error) {
+	var permissions []model.NotePermission
+	sql := r.db.WithContext(ctx).Where("note_id = ?", noteID)
+	if len(groups) > 0 {
+		sql = sql.Where(
+			r.db.Where("grantee_type = ? AND grantee_id = ?", model.NoteGranteeMember, memberID).
+				Or("grantee_type = ? AND grantee_group IN ?", model.NoteGranteeGroup, groups),
+		)
+	} else {
+		sql = sql.Where("grantee_type = ? AND grantee_id = ?", model.NoteGranteeMember, memberID)
+	}
+	if err := sql.Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// GetGranteePermissionsByNotes 批量版本的 GetGranteePermissions
+func (r *aclRepository) GetGranteePermissionsByNotes(ctx context.Context, noteIDs []int64, memberID int64, groups []string) ([]model.NotePermission, error) {
+	var permissions []model.NotePermission
+	sql := r.db.WithContext(ctx).Where("note_id IN ?", noteIDs)
+	if len(groups) > 0 {
+		sql = sql.Where(
+			r.db.Where("grantee_type = ? AND grantee_id = ?", model.NoteGranteeMember, memberID).
+				Or("grantee_type = ? AND grantee_group IN ?", model.NoteGranteeGroup, groups),
+		)
+	} else {
+		sql = sql.Where("grantee_type = ? AND grantee_id = ?", model.NoteGranteeMember, memberID)
+	}
+	if err := sql.Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// UpsertNotePermission 同一笔记同一授权对象只保留一条记录，重复授权时覆盖角色
+func (r *aclRepository) UpsertNotePermission(ctx context.Context, permission *model.NotePermission) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "note_id"}, {Name: "grantee_type"}, {Name: "grantee_id"}, {Name: "grantee_group"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"role":       permission.Role,
+			"granted_by": permission.GrantedBy,
+			"updated_at": time.Now(),
+			"deleted_at": nil,
+		}),
+	}).Create(permission).Error
+}
+
+// DeleteNotePermission 授权记录直接物理删除，避免软删除记录占住唯一索引
+func (r *aclRepository) DeleteNotePermission(ctx context.Context, noteID, permissionID int64) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND note_id = ?", permissionID, noteID).
+		Delete(&model.NotePermission{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *aclRepository) DeleteNotePermissionsByNote(ctx context.Context, noteID int64) error {
+	return r.db.WithContext(ctx).Unscoped().Where("note_id = ?", noteID).Delete(&model.NotePermission{}).Error
+}
+
+func (r *aclRepository) IsWorkspaceMember(ctx context.Context, workspaceID, memberID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.WorkspaceMember{}).
+		Where("id = ? AND workspace_id = ?", memberID, workspaceID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetNoteCategoryByID(db *gorm.DB, workspaceID, categoryID int64, opts ...DatabaseExtraOpt) (*model.NoteCategory, error) {
+	option := &DatabaseExtraOptions{}
+	for _, o := range opts {
+		o(option)
+	}
+
+	var category model.NoteCategory
+	sql := db.Where("id = ? AND workspace_id = ?", categoryID, workspaceID)
+
+	if option.WithLock {
+		sql = sql.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	if err := sql.First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func GetNoteCategoriesByIDs(db *gorm.DB, workspaceID int64, categoryIDs []int64) ([]model.NoteCategory, error) {
+	var categories []model.NoteCategory
+	err := db.Model(&model.NoteCategory{}).
+		Where("id IN ? AND workspace_id = ?", categoryIDs, workspaceID).
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// GetNoteCategoryTreeRows 一次性取出工作区全部分类（平铺，按同级顺序排好），由调用方组装成树
+func GetNoteCategoryTreeRows(db *gorm.DB, workspaceID int64) ([]*dto.NoteCategoryTreeNode, error) {
+	var rows []*dto.NoteCategoryTreeNode
+	err := db.Table("note_categories").
+		Select(`note_categories.id, note_categories.category_name, note_categories.parent_id,
+			note_categories.order_index, note_categories.created_at, note_categories.updated_at,
+			COUNT(notes.id) as total`).
+		Joins("LEFT JOIN notes ON notes.category_id = note_categories.id AND notes.deleted_at IS NULL").
+		Where("note_categories.workspace_id = ? AND note_categories.deleted_at IS NULL", workspaceID).
+		Group("note_categories.id").
+		Order(clause.Expr{SQL: `note_categories.order_index COLLATE "C" ASC, note_categories.id ASC`}).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetCategoryAncestorIDs 返回从父级到根的全部祖先 ID（不含自身）
+func GetCategoryAncestorIDs(db *gorm.DB, categoryID int64) ([]int64, error) {
+	var ids []int64
+	err := db.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 1 AS depth FROM note_categories WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1
+			FROM note_categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < 128
+		)
+		SELECT id FROM ancestors WHERE id <> ? ORDER BY depth`, categoryID, categoryID).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetCategoryDescendantIDs 返回自身及全部子孙分类 ID
+func GetCategoryDescendantIDs(db *gorm.DB, workspaceID, categoryID int64) ([]int64, error) {
+	var ids []int64
+	err := db.Raw(`
+		WITH RECURSIVE descendants AS (
+			SELECT id, 1 AS depth FROM note_categories
+			WHERE id = ? AND workspace_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, d.depth + 1
+			FROM note_categories c
+			JOIN descendants d ON c.parent_id = d.id
+			WHERE c.deleted_at IS NULL AND d.depth < 128
+		)
+		SELECT id FROM descendants`, categoryID, workspaceID).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetSiblingEdgeOrderIndex 获取同级分类中最靠前（first=true）或最靠后的 order_index，没有同级时返回空串
+func GetSiblingEdgeOrderIndex(db *gorm.DB, workspaceID int64, parentID *int64, first bool) (string, error) {
+	var orderIndex string
+	sql := db.Model(&model.NoteCategory{}).
+		Select("order_index").
+		Where("workspace_id = ? AND order_index <> ''", workspaceID)
+
+	if parentID == nil {
+		sql = sql.Where("parent_id IS NULL")
+	} else {
+		sql = sql.Where("parent_id = ?", *parentID)
+	}
+
+	err := sql.Order(GetLexoRankOrderExpr(!first)).Limit(1).Scan(&orderIndex).Error
+	if err != nil {
+		return "", err
+	}
+	return orderIndex, nil
+}
+
+func CountNoteCategories(db *gorm.DB, workspaceID int64) (int64, error) {
+	var count int64
+	err := db.Model(&model.NoteCategory{}).Where("workspace_id = ?", workspaceID).Count(&count).Error
+	return count, err
+}
+
+func UpdateNoteCategoryByID(db *gorm.DB, categoryID int64, data map[string]interface{}) error {
+	return db.Model(&model.NoteCategory{}).Where("id = ?", categoryID).Updates(data).Error
+}
+
+// ReparentNoteCategories 把 parentID 下的直接子分类挂到 newParentID 下
+func ReparentNoteCategories(db *gorm.DB, parentID int64, newParentID *int64) error {
+	return db.Model(&model.NoteCategory{}).
+		Where("parent_id = ?", parentID).
+		Update("parent_id", newParentID).Error
+}
+
+func MoveNotesToCategory(db *gorm.DB, categoryIDs []int64, targetID int64) error {
+	return db.Model(&model.Note{}).
+		Where("category_id IN ?", categoryIDs).
+		Update("category_id", targetID).Error
+}
+
+// TrashNotesByCategoryIDs 软删除分类下的笔记（移入回收站）
+func TrashNotesByCategoryIDs(db *gorm.DB, categoryIDs []int64) (int64, error) {
+	result := db.Where("category_id IN ?", categoryIDs).Delete(&model.Note{})
+	return result.RowsAffected, result.Error
+}
+
+func DeleteNoteCategoriesByIDs(db *gorm.DB, workspaceID int64, categoryIDs []int64) error {
+	return db.Where("id IN ? AND workspace_id = ?", categoryIDs, workspaceID).Delete(&model.NoteCategory{}).Error
+}
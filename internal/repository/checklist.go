@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type checklistRepository struct {
+	db *gorm.DB
+}
+
+func NewChecklistRepository(db *gorm.DB) *checklistRepository {
+	return &checklistRepository{db: db}
+}
+
+func (r *checklistRepository) ListByNote(ctx context.Context, noteID int64) ([]model.NoteChecklistTask, error) {
+	links := make([]model.NoteChecklistTask, 0)
+	if err := r.db.WithContext(ctx).Where("note_id = ?", noteID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ListTasksByNote 笔记中清单块关联的任务；任务已删除的关联不返回
+func (r *checklistRepository) ListTasksByNote(ctx context.Context, noteID int64) ([]dto.ChecklistTaskDTO, error) {
+	items := make([]dto.ChecklistTaskDTO, 0)
+	err := r.db.WithContext(ctx).Table("note_checklist_tasks l").
+		Select(`l.block_id, l.task_id, t.project_id, t.column_id, t.title, t.status,
+			c.name AS column_name, (c.process_id = ? OR t.status = ?) AS done`, model.ProcessDone, model.TaskStatusCompleted).
+		Joins("JOIN to_do_tasks t ON t.id = l.task_id AND t.deleted_at IS NULL").
+		Joins("LEFT JOIN to_do_columns c ON c.id = t.column_id").
+		Where("l.note_id = ? AND l.deleted_at IS NULL", noteID).
+		Order("l.created_at ASC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *checklistRepository) GetByTask(ctx context.Context, taskID int64) (*model.NoteChecklistTask, error) {
+	var link model.NoteChecklistTask
+	if err := r.db.WithContext(ctx).Where("task_id = ?", taskID).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *checklistRepository) GetByBlock(ctx context.Context, noteID int64, blockID string) (*model.NoteChecklistTask, error) {
+	var link model.NoteChecklistTask
+	if err := r.db.WithContext(ctx).Where("note_id = ? AND block_id = ?", noteID, blockID).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *checklistRepository) Create(ctx context.Context, links []model.NoteChecklistTask) error {
+	if len(links) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&links).Error
+}
+
+// DeleteByIDs 关联表有唯一索引，采用硬删除以便同一块重新关联
+func (r *checklistRepository) DeleteByIDs(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Unscoped().Where("id IN ?", ids).Delete(&model.NoteChecklistTask{}).Error
+}
+
+func (r *checklistRepository) GetColumn(ctx context.Context, projectID, columnID int64) (*model.ToDoColumn, error) {
+	var column model.ToDoColumn
+	err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", columnID, projectID).First(&column).Error
+	if err != nil {
+		return nil, err
+	}
+	return &column, nil
+}
+
+// GetProcessColumn 项目中处于指定流程阶段的第一列，不存在时返回 gorm.ErrRecordNotFound
+func (r *checklistRepository) GetProcessColumn(ctx context.Context, projectID int64, processID uint8) (*model.ToDoColumn, error) {
+	var column model.ToDoColumn
+	err := r.db.WithContext(ctx).
+		Where("project_id = ? AND process_id = ?", projectID, processID).
+		Order(GetLexoRankOrderExpr(false)).
+		First(&column).Error
+	if err != nil {
+		return nil, err
+	}
+	return &column, nil
+}
+
+func (r *checklistRepository) GetTask(ctx context.Context, taskID int64) (*model.ToDoTask, error) {
+	var task model.ToDoTask
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, taskID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *checklistRepository) CreateTask(ctx context.Context, task *model.ToDoTask) error {
+	return r.db.WithContext(ctx).Create(task).Error
+}
+
+func (r *checklistRepository) MoveTask(ctx context.Context, taskID, columnID int64, orderIndex, status string) error {
+	return r.db.WithContext(ctx).Model(&model.ToDoTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+		"column_id":   columnID,
+		"order_index": orderIndex,
+		"status":      status,
+		"updated_at":  gorm.Expr("NOW()"),
+	}).Error
+}
+
+// MarkBlockChecked 修改笔记中清单块的勾选状态并递增版本；
+// 块不存在或状态未变时返回的 ops 为空，笔记不做修改
+func (r *checklistRepository) MarkBlockChecked(ctx context.Context, noteID int64, blockID string, checked bool) (*model.Note, []dto.PatchOp, error) {
+	var note model.Note
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", noteID).First(&note).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		return nil, nil, err
+	}
+	if _, ok := dto.IndexChecklistBlocks(content)[blockID]; !ok {
+		return &note, nil, nil
+	}
+	newContent, ops, err := dto.SetChecklistChecked(content, blockID, checked)
+	if err != nil || len(ops) == 0 {
+		return &note, nil, err
+	}
+
+	raw, err := json.Marshal(newContent)
+	if err != nil {
+		return nil, nil, err
+	}
+	note.Content = raw
+	note.Version++
+	note.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	err = r.db.WithContext(ctx).Model(&model.Note{}).Where("id = ?", noteID).Updates(map[string]interface{}{
+		"content":    note.Content,
+		"version":    note.Version,
+		"updated_at": note.UpdatedAt,
+	}).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return &note, ops, nil
+}
+
+// CreatePatchOutboxes 为笔记的每个可推送的外部同步写入一条 patch 记录，返回 linkID -> memberID
+func (r *checklistRepository) CreatePatchOutboxes(ctx context.Context, noteID, version int64, ops []dto.PatchOp) (map[int64]int64, error) {
+	links, _, err := GetNoteSyncList(r.db, ctx, nil, &noteID, nil)
+	if err != nil || links == nil || len(*links) == 0 {
+		return nil, err
+	}
+	patchJSON, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := make(map[int64]int64, len(*links))
+	outboxs := make([]model.SyncOutbox, 0, len(*links))
+	for _, link := range *links {
+		// 与笔记编辑一致：停用或只拉取的链接不向外推送
+		if !link.IsActive || link.Direction == model.SyncPullOnly {
+			continue
+		}
+		mapping[link.ID] = link.MemberID
+		outboxs = append(outboxs, model.SyncOutbox{
+			NoteID:      noteID,
+			LinkID:      link.ID,
+			NoteVersion: version,
+			OpType:      "patch",
+			Status:      model.SyncPending,
+			PatchJSON:   patchJSON,
+		})
+	}
+	if len(outboxs) == 0 {
+		return nil, nil
+	}
+	if err := NewSyncRepository(r.db).CreateSyncOutboxs(ctx, &outboxs); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
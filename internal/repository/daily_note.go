@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type dailyNoteRepository struct {
+	db *gorm.DB
+}
+
+func NewDailyNoteRepository(db *gorm.DB) *dailyNoteRepository {
+	return &dailyNoteRepository{db: db}
+}
+
+// GetSetting 未配置时返回 gorm.ErrRecordNotFound
+func (r *dailyNoteRepository) GetSetting(ctx context.Context, workspaceID, userID int64) (*model.DailyNoteSetting, error) {
+	var setting model.DailyNoteSetting
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&setting).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// SaveSetting 已有记录直接更新；新记录按 (workspace_id, user_id) 写入，并发时以后写入的为准
+func (r *dailyNoteRepository) SaveSetting(ctx context.Context, setting *model.DailyNoteSetting) error {
+	if setting.ID != 0 {
+		return r.db.WithContext(ctx).Model(setting).
+			Select("template_id", "category_id", "timezone", "include_agenda").
+			Updates(setting).Error
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"template_id", "category_id", "timezone", "include_agenda", "updated_at"}),
+	}).Create(setting).Error
+}
+
+func (r *dailyNoteRepository) GetDailyNote(ctx context.Context, workspaceID, userID int64, date string) (*model.DailyNote, error) {
+	var daily model.DailyNote
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ? AND date = ?", workspaceID, userID, date).
+		First(&daily).Error
+	if err != nil {
+		return nil, err
+	}
+	return &daily, nil
+}
+
+// CreateDailyNote 并发创建同一天时只有一条能写入，返回是否写入成功
+func (r *dailyNoteRepository) CreateDailyNote(ctx context.Context, daily *model.DailyNote) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(daily)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RelinkDailyNote 原笔记已被删除时指向新笔记；以旧 note_id 作为条件避免并发覆盖
+func (r *dailyNoteRepository) RelinkDailyNote(ctx context.Context, dailyID, oldNoteID, newNoteID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.DailyNote{}).
+		Where("id = ? AND note_id = ?", dailyID, oldNoteID).
+		Update("note_id", newNoteID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDailyNotes 返回 [from, to] 日期范围内仍存在的每日笔记
+func (r *dailyNoteRepository) ListDailyNotes(ctx context.Context, workspaceID, userID int64, from, to string) ([]dto.DailyNoteIndexItem, error) {
+	items := make([]dto.DailyNoteIndexItem, 0)
+	err := r.db.WithContext(ctx).Table("daily_notes dn").
+		Select("dn.date, dn.note_id, n.title, n.updated_at").
+		Joins("JOIN notes n ON n.id = dn.note_id AND n.deleted_at IS NULL").
+		Where("dn.workspace_id = ? AND dn.user_id = ? AND dn.deleted_at IS NULL", workspaceID, userID).
+		Where("dn.date BETWEEN ? AND ?", from, to).
+		Order("dn.date ASC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListDueTasks 当天到期、由该用户创建或分配给该成员的任务
+func (r *dailyNoteRepository) ListDueTasks(ctx context.Context, workspaceID, userID, memberID int64, date time.Time) ([]dto.DailyAgendaTaskDTO, error) {
+	tasks := make([]dto.DailyAgendaTaskDTO, 0)
+	err := r.db.WithContext(ctx).Table("to_do_tasks t").
+		Select("t.id, t.title, t.project_id, p.name AS project_name, t.status, t.priority").
+		Joins("JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL").
+		Where("p.workspace_id = ? AND t.deleted_at IS NULL AND t.deadline = ?", workspaceID, date.Format(dto.DailyNoteDateLayout)).
+		Where(`t.creator = ? OR EXISTS (
+				SELECT 1 FROM to_do_task_assignees a
+				WHERE a.to_do_task_id = t.id AND a.assignee_id = ? AND a.deleted_at IS NULL)`, userID, memberID).
+		Order("t.priority DESC, t.id ASC").
+		Scan(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *dailyNoteRepository) CreateNote(ctx context.Context, note *model.Note) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+func (r *dailyNoteRepository) CreateCategory(ctx context.Context, category *model.NoteCategory) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
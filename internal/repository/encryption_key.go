@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type encryptionKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewEncryptionKeyRepository(db *gorm.DB) *encryptionKeyRepository {
+	return &encryptionKeyRepository{db: db}
+}
+
+// ListUsableKeys 主用与只解密的密钥
+func (r *encryptionKeyRepository) ListUsableKeys(ctx context.Context) ([]model.EncryptionKey, error) {
+	keys := make([]model.EncryptionKey, 0)
+	err := r.db.WithContext(ctx).Where("status <> ?", model.KeyRetired).Order("created_at ASC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *encryptionKeyRepository) ListKeys(ctx context.Context) ([]model.EncryptionKey, error) {
+	keys := make([]model.EncryptionKey, 0)
+	err := r.db.WithContext(ctx).Order("created_at ASC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *encryptionKeyRepository) GetKey(ctx context.Context, kid string) (*model.EncryptionKey, error) {
+	var key model.EncryptionKey
+	if err := r.db.WithContext(ctx).Where("kid = ?", kid).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreatePrimaryKey 原主用密钥降为只解密后写入新密钥，保证同一时间只有一个主用密钥
+func (r *encryptionKeyRepository) CreatePrimaryKey(ctx context.Context, key *model.EncryptionKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.EncryptionKey{}).
+			Where("status = ?", model.KeyPrimary).
+			Update("status", model.KeyActive).Error; err != nil {
+			return err
+		}
+		key.Status = model.KeyPrimary
+		return tx.Create(key).Error
+	})
+}
+
+// RetireKey 只能停用只解密的密钥
+func (r *encryptionKeyRepository) RetireKey(ctx context.Context, kid string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.EncryptionKey{}).
+		Where("kid = ? AND status = ?", kid, model.KeyActive).
+		Updates(map[string]interface{}{"status": model.KeyRetired, "retired_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+// CountKeyUsage 仍由该密钥加密的记录数
+func (r *encryptionKeyRepository) CountKeyUsage(ctx context.Context, kid string) (int64, error) {
+	var total int64
+	for _, m := range []interface{}{&model.IntegrationApp{}, &model.IntegrationAccount{}} {
+		var n int64
+		if err := r.db.WithContext(ctx).Model(m).Unscoped().Where("kid = ?", kid).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	for _, m := range []interface{}{&model.ProjectExternalLink{}, &model.WebhookSubscription{}} {
+		var n int64
+		if err := r.db.WithContext(ctx).Model(m).Unscoped().Where("secret_kid = ?", kid).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// 以下查询返回库中原始（加密）数据，供轮换任务使用；kid 为空表示尚未加密
+
+func (r *encryptionKeyRepository) GetAppsToRotate(ctx context.Context, primaryKID string, afterID int64, limit int) ([]model.IntegrationApp, error) {
+	apps := make([]model.IntegrationApp, 0)
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("(kid IS NULL OR kid <> ?) AND id > ?", primaryKID, afterID).
+		Order("id ASC").Limit(limit).Find(&apps).Error
+	return apps, err
+}
+
+func (r *encryptionKeyRepository) GetAccountsToRotate(ctx context.Context, primaryKID string, afterID int64, limit int) ([]model.IntegrationAccount, error) {
+	accounts := make([]model.IntegrationAccount, 0)
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("(kid IS NULL OR kid <> ?) AND id > ?", primaryKID, afterID).
+		Order("id ASC").Limit(limit).Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *encryptionKeyRepository) GetLinksToRotate(ctx context.Context, primaryKID string, afterID int64, limit int) ([]model.ProjectExternalLink, error) {
+	links := make([]model.ProjectExternalLink, 0)
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("(secret_kid IS NULL OR secret_kid <> ?) AND id > ?", primaryKID, afterID).
+		Order("id ASC").Limit(limit).Find(&links).Error
+	return links, err
+}
+
+func (r *encryptionKeyRepository) GetWebhooksToRotate(ctx context.Context, primaryKID string, afterID int64, limit int) ([]model.WebhookSubscription, error) {
+	subs := make([]model.WebhookSubscription, 0)
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("(secret_kid IS NULL OR secret_kid <> ?) AND id > ?", primaryKID, afterID).
+		Order("id ASC").Limit(limit).Find(&subs).Error
+	return subs, err
+}
+
+// RotateRecord 按读取时的密钥 ID 条件更新，期间被其他请求改写过的记录不覆盖，返回是否更新
+func (r *encryptionKeyRepository) RotateRecord(ctx context.Context, m interface{}, id int64, kidColumn string, oldKID *string, data map[string]interface{}) (bool, error) {
+	query := r.db.WithContext(ctx).Model(m).Unscoped().Where("id = ?", id)
+	if oldKID == nil {
+		query = query.Where(kidColumn + " IS NULL")
+	} else {
+		query = query.Where(kidColumn+" = ?", *oldKID)
+	}
+	res := query.UpdateColumns(data)
+	return res.RowsAffected > 0, res.Error
+}
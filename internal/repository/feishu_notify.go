@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type feishuNotifyRepository struct {
+	db *gorm.DB
+}
+
+func NewFeishuNotifyRepository(db *gorm.DB) *feishuNotifyRepository {
+	return &feishuNotifyRepository{db: db}
+}
+
+func (r *feishuNotifyRepository) CreateChat(ctx context.Context, chat *model.FeishuProjectChat) error {
+	return r.db.WithContext(ctx).Create(chat).Error
+}
+
+func (r *feishuNotifyRepository) GetChat(ctx context.Context, id int64) (*model.FeishuProjectChat, error) {
+	var chat model.FeishuProjectChat
+	if err := r.db.WithContext(ctx).First(&chat, id).Error; err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (r *feishuNotifyRepository) ListChats(ctx context.Context, projectID int64) ([]model.FeishuProjectChat, error) {
+	chats := make([]model.FeishuProjectChat, 0)
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at ASC").Find(&chats).Error
+	return chats, err
+}
+
+// GetActiveChats 项目启用的飞书群，按通知类型过滤由调用方完成
+func (r *feishuNotifyRepository) GetActiveChats(ctx context.Context, projectID int64) ([]model.FeishuProjectChat, error) {
+	chats := make([]model.FeishuProjectChat, 0)
+	err := r.db.WithContext(ctx).Where("project_id = ? AND is_active = TRUE", projectID).Find(&chats).Error
+	return chats, err
+}
+
+func (r *feishuNotifyRepository) ChatExists(ctx context.Context, projectID int64, chatID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.FeishuProjectChat{}).
+		Where("project_id = ? AND chat_id = ?", projectID, chatID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *feishuNotifyRepository) UpdateChat(ctx context.Context, id int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.FeishuProjectChat{}).Where("id = ?", id).Updates(data).Error
+}
+
+func (r *feishuNotifyRepository) DeleteChat(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&model.FeishuProjectChat{}, id).Error
+}
+
+// GetTaskContext 通知卡片需要的任务、所在项目与列
+func (r *feishuNotifyRepository) GetTaskContext(ctx context.Context, taskID int64) (*dto.FeishuTaskContextDTO, error) {
+	var task dto.FeishuTaskContextDTO
+	err := r.db.WithContext(ctx).Table("to_do_tasks t").
+		Select("t.id, t.title, t.priority, t.deadline, t.project_id, p.name AS project_name, p.workspace_id, t.column_id, c.name AS column_name").
+		Joins("JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL").
+		Joins("LEFT JOIN to_do_columns c ON c.id = t.column_id").
+		Where("t.id = ? AND t.deleted_at IS NULL", taskID).
+		Take(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *feishuNotifyRepository) GetColumnName(ctx context.Context, columnID int64) (string, error) {
+	var name string
+	err := r.db.WithContext(ctx).Model(&model.ToDoColumn{}).Where("id = ?", columnID).Pluck("name", &name).Error
+	return name, err
+}
+
+// ListDueTasks 截止日期在 [from, to] 之间、且不在已完成列中的任务，只取 ID 与截止日期
+func (r *feishuNotifyRepository) ListDueTasks(ctx context.Context, from, to time.Time) ([]model.ToDoTask, error) {
+	tasks := make([]model.ToDoTask, 0)
+	err := r.db.WithContext(ctx).Table("to_do_tasks t").
+		Select("t.id, t.deadline").
+		Joins("JOIN to_do_columns c ON c.id = t.column_id AND c.deleted_at IS NULL").
+		Where("t.deleted_at IS NULL AND t.deadline BETWEEN ? AND ?", from.Format(time.DateOnly), to.Format(time.DateOnly)).
+		Where("c.process_id <> ?", model.ProcessDone).
+		Order("t.id ASC").
+		Scan(&tasks).Error
+	return tasks, err
+}
+
+func (r *feishuNotifyRepository) GetCommentContent(ctx context.Context, commentID int64) (string, error) {
+	var content string
+	err := r.db.WithContext(ctx).Model(&model.ToDoTaskComment{}).Where("id = ?", commentID).Pluck("content", &content).Error
+	return content, err
+}
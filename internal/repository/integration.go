@@ -22,20 +22,33 @@ func NewIntegrationRepository(db *gorm.DB) *integrationRepository {
 	return &integrationRepository{db: db}
 }
 
+// CreateIntegrationApp 密钥加密后写入，调用方持有的 app 仍为明文
 func (r *integrationRepository) CreateIntegrationApp(ctx context.Context, app *model.IntegrationApp) error {
-	if err := r.db.WithContext(ctx).Create(app).Error; err != nil {
+	sealed := *app
+	if err := SealIntegrationApp(ctx, &sealed); err != nil {
 		return err
 	}
+	if err := r.db.WithContext(ctx).Create(&sealed).Error; err != nil {
+		return err
+	}
+	app.ID, app.CreatedAt, app.UpdatedAt = sealed.ID, sealed.CreatedAt, sealed.UpdatedAt
+	app.KID, app.LastRotatedAt = sealed.KID, sealed.LastRotatedAt
 	return nil
 }
 
+// BindIntegrationAccount 令牌加密后写入，调用方持有的 app 仍为明文
 func (r *integrationRepository) BindIntegrationAccount(ctx context.Context, app *model.IntegrationAccount) error {
+	sealed := *app
+	if err := SealIntegrationAccount(ctx, &sealed); err != nil {
+		return err
+	}
 	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "user_id"}, {Name: "provider"}},
-		DoUpdates: clause.AssignmentColumns([]string{"access_token_enc", "refresh_token_enc", "access_token_expiry", "refresh_token_expiry", "scopes", "extra", "is_active"}),
-	}).Create(app).Error; err != nil {
+		DoUpdates: clause.AssignmentColumns([]string{"account_id", "account_name", "auth_type", "access_token_enc", "refresh_token_enc", "access_token_expiry", "refresh_token_expiry", "scopes", "extra", "kid", "is_active"}),
+	}).Create(&sealed).Error; err != nil {
 		return err
 	}
+	app.ID, app.KID = sealed.ID, sealed.KID
 	return nil
 }
 
@@ -49,6 +62,11 @@ func (r *integrationRepository) GetIntegrationAppList(ctx context.Context, provi
 	if err := query.Debug().Scan(&apps).Error; err != nil {
 		return nil, err
 	}
+	for i := range apps {
+		if err := OpenIntegrationApp(ctx, &apps[i]); err != nil {
+			return nil, err
+		}
+	}
 	return apps, nil
 }
 
@@ -65,6 +83,11 @@ func (r *integrationRepository) GetIntegrationAccountList(ctx context.Context, p
 	if err := query.Debug().Scan(&accounts).Error; err != nil {
 		return nil, err
 	}
+	for i := range accounts {
+		if err := OpenIntegrationAccount(ctx, &accounts[i]); err != nil {
+			return nil, err
+		}
+	}
 	return accounts, nil
 }
 
@@ -81,9 +104,36 @@ func (r *integrationRepository) GetIntegrationAccountByUser(ctx context.Context,
 	if err := query.Find(&account).Error; err != nil {
 		return nil, err
 	}
+	if err := OpenIntegrationAccount(ctx, &account); err != nil {
+		return nil, err
+	}
 	return &account, nil
 }
 
+// GetActiveAccountsByUsers 一批用户在指定平台上启用的账号
+func (r *integrationRepository) GetActiveAccountsByUsers(ctx context.Context, provider model.IntegrationProvider, userIDs []int64) ([]model.IntegrationAccount, error) {
+	accounts := make([]model.IntegrationAccount, 0)
+	if len(userIDs) == 0 {
+		return accounts, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND user_id IN ? AND is_active = TRUE", provider, userIDs).
+		Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		if err := OpenIntegrationAccount(ctx, &accounts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+func (r *integrationRepository) UpdateAccountID(ctx context.Context, id int64, accountID string) error {
+	return r.db.WithContext(ctx).Model(&model.IntegrationAccount{}).Where("id = ?", id).Update("account_id", accountID).Error
+}
+
 func (r *integrationRepository) UnlinkIntegrationAccount(ctx context.Context, userID int64, provider model.IntegrationProvider) error {
 	if err := r.db.WithContext(ctx).Unscoped().Where("user_id = ? AND provider = ?", userID, provider).Delete(&model.IntegrationAccount{}).Error; err != nil {
 		return err
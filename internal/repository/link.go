@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type linkRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkRepository(db *gorm.DB) *linkRepository {
+	return &linkRepository{db: db}
+}
+
+func (r *linkRepository) GetLinksBySource(ctx context.Context, noteID int64) ([]model.NoteLink, error) {
+	var links []model.NoteLink
+	err := r.db.WithContext(ctx).Where("source_note_id = ?", noteID).Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// ReplaceSourceLinks 用新的链接集合整体替换某篇笔记的出链
+func (r *linkRepository) ReplaceSourceLinks(ctx context.Context, noteID int64, links []model.NoteLink) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("source_note_id = ?", noteID).Delete(&model.NoteLink{}).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(&links, 200).Error
+}
+
+// ResolveNoteTitles 按标题（忽略大小写）查找工作区内的笔记，同名时取最早创建的一篇
+func (r *linkRepository) ResolveNoteTitles(ctx context.Context, workspaceID int64, titles []string) (map[string]int64, error) {
+	result := make(map[string]int64, len(titles))
+	if len(titles) == 0 {
+		return result, nil
+	}
+
+	lowered := make([]string, 0, len(titles))
+	for _, t := range titles {
+		lowered = append(lowered, strings.ToLower(t))
+	}
+
+	var rows []struct {
+		ID    int64
+		Title string
+	}
+	err := r.db.WithContext(ctx).Model(&model.Note{}).
+		Select("id, title").
+		Where("workspace_id = ? AND LOWER(title) IN ?", workspaceID, lowered).
+		Order("created_at ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		key := strings.ToLower(row.Title)
+		if _, ok := result[key]; !ok {
+			result[key] = row.ID
+		}
+	}
+	return result, nil
+}
+
+func (r *linkRepository) FilterExistingNoteIDs(ctx context.Context, workspaceID int64, noteIDs []int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	if len(noteIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.Note{}).
+		Where("workspace_id = ? AND id IN ?", workspaceID, noteIDs).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *linkRepository) FilterExistingTaskIDs(ctx context.Context, workspaceID int64, taskIDs []int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	if len(taskIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.WithContext(ctx).Table("to_do_tasks AS t").
+		Joins("JOIN projects AS p ON p.id = t.project_id").
+		Where("p.workspace_id = ? AND t.id IN ? AND t.deleted_at IS NULL", workspaceID, taskIDs).
+		Pluck("t.id", &ids).Error
+	return ids, err
+}
+
+// GetBacklinks 返回引用了目标的笔记（已删除的笔记不计入），调用方需按成员权限过滤来源
+func (r *linkRepository) GetBacklinks(ctx context.Context, workspaceID int64, targetType model.NoteLinkTargetType, targetID int64) ([]dto.NoteBacklinkDTO, error) {
+	var backlinks []dto.NoteBacklinkDTO
+	err := r.db.WithContext(ctx).Table("note_links AS l").
+		Select(`n.id AS note_id, n.title, n.category_id, n.updated_at, l.block_id, l.target_title`).
+		Joins("JOIN notes AS n ON n.id = l.source_note_id AND n.deleted_at IS NULL").
+		Where("l.workspace_id = ? AND l.target_type = ? AND l.target_id = ?", workspaceID, targetType, targetID).
+		Order("n.updated_at DESC").
+		Scan(&backlinks).Error
+	if err != nil {
+		return nil, err
+	}
+	return backlinks, nil
+}
+
+func (r *linkRepository) GetWorkspaceLinks(ctx context.Context, workspaceID int64) ([]model.NoteLink, error) {
+	var links []model.NoteLink
+	err := r.db.WithContext(ctx).Table("note_links AS l").
+		Select("l.*").
+		Joins("JOIN notes AS n ON n.id = l.source_note_id AND n.deleted_at IS NULL").
+		Where("l.workspace_id = ?", workspaceID).
+		Scan(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// noteACLColumns 计算笔记权限与展示节点所需的列
+const noteACLColumns = "id, workspace_id, title, category_id, owner_id, status, allow_comment, allow_edit"
+
+// GetGraphNotes 工作区内未删除的笔记，由调用方按成员权限过滤后作为关系图节点
+func (r *linkRepository) GetGraphNotes(ctx context.Context, workspaceID int64) ([]model.Note, error) {
+	var notes []model.Note
+	err := r.db.WithContext(ctx).Select(noteACLColumns).
+		Where("workspace_id = ?", workspaceID).
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// GetLinkNotes 读取链接两端的笔记，用于按成员权限过滤反向链接
+func (r *linkRepository) GetLinkNotes(ctx context.Context, workspaceID int64, noteIDs []int64) ([]model.Note, error) {
+	notes := make([]model.Note, 0, len(noteIDs))
+	if len(noteIDs) == 0 {
+		return notes, nil
+	}
+	err := r.db.WithContext(ctx).Select(noteACLColumns).
+		Where("workspace_id = ? AND id IN ?", workspaceID, noteIDs).
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *linkRepository) GetGraphTaskNodes(ctx context.Context, taskIDs []int64) ([]dto.NoteGraphNodeDTO, error) {
+	nodes := make([]dto.NoteGraphNodeDTO, 0)
+	if len(taskIDs) == 0 {
+		return nodes, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.ToDoTask{}).
+		Select("id, title, 'task' AS type, project_id").
+		Where("id IN ?", taskIDs).
+		Scan(&nodes).Error
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
@@ -70,7 +70,7 @@ func GetNoteCategory(params *dto.NoteCategoryQueryDTO) (*[]dto.WorkspaceUpdateNo
 	})
 	query := database.DB.
 		Table("note_categories").
-		Select("note_categories.id, note_categories.category_name , COUNT(notes.id) as total")
+		Select("note_categories.id, note_categories.category_name, note_categories.parent_id, COUNT(notes.id) as total")
 
 	if params.CategoryName != "" {
 		name := strings.ReplaceAll(params.CategoryName, "%", "")
@@ -81,7 +81,7 @@ func GetNoteCategory(params *dto.NoteCategoryQueryDTO) (*[]dto.WorkspaceUpdateNo
 	err := query.Joins("LEFT JOIN notes ON notes.category_id = note_categories.id AND notes.workspace_id = ?", params.WorkspaceID).
 		Where("note_categories.workspace_id = ?", params.WorkspaceID).
 		Limit(-1).
-		Group("note_categories.id, note_categories.category_name").
+		Group("note_categories.id, note_categories.category_name, note_categories.parent_id").
 		Scan(&notesCategory).Error
 	if err != nil {
 		return nil, err
@@ -284,6 +284,56 @@ func GetTemplateNotes(db *gorm.DB, userID int64, limit *int, offset int) (*[]mod
 	return &templateNotes, count, nil
 }
 
+// GetWorkspaceTemplateNotes 工作区模板库：工作区内公开的模板，按使用次数排序
+func GetWorkspaceTemplateNotes(db *gorm.DB, workspaceID int64, keywords *string, limit *int, offset int) (*[]model.TemplateNote, int64, error) {
+	var templateNotes []model.TemplateNote
+	var count int64
+	sql := db.Model(&model.TemplateNote{}).
+		Where("workspace_id = ? AND is_public = ?", workspaceID, true)
+
+	if keywords != nil && *keywords != "" {
+		like := "%" + *keywords + "%"
+		sql = sql.Where("title ILIKE ? OR description ILIKE ?", like, like)
+	}
+
+	if err := sql.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sql = sql.Offset(offset)
+	if limit != nil {
+		sql = sql.Limit(*limit)
+	}
+	err := sql.Order("usage_count DESC, created_at DESC").Find(&templateNotes).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return &templateNotes, count, nil
+}
+
+func GetTemplateNoteByID(db *gorm.DB, templateID int64) (*model.TemplateNote, error) {
+	var templateNote model.TemplateNote
+	err := db.Where("id = ?", templateID).First(&templateNote).Error
+	if err != nil {
+		return nil, err
+	}
+	return &templateNote, nil
+}
+
+func UpdateTemplateNote(db *gorm.DB, templateID int64, data map[string]interface{}) error {
+	return db.Model(&model.TemplateNote{}).Where("id = ?", templateID).Updates(data).Error
+}
+
+func DeleteTemplateNote(db *gorm.DB, templateID int64) error {
+	return db.Where("id = ?", templateID).Delete(&model.TemplateNote{}).Error
+}
+
+func IncreaseTemplateUsage(db *gorm.DB, templateID int64) error {
+	return db.Model(&model.TemplateNote{}).
+		Where("id = ?", templateID).
+		UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error
+}
+
 func GetNoteSyncList(db *gorm.DB, ctx context.Context, memberID *int64, noteID *int64, provider *model.IntegrationProvider) (*[]model.NoteExternalLink, int64, error) {
 	var syncPolicies []model.NoteExternalLink
 	var count int64
@@ -355,6 +405,29 @@ func GetNoteMappingByNoteAndProvider(db *gorm.DB, ctx context.Context, noteID in
 	return &mappings, nil
 }
 
+// GetNotionPageIDs 笔记 ID -> 已启用的 Notion 页面 ID
+func GetNotionPageIDs(db *gorm.DB, ctx context.Context, noteIDs []int64) (map[int64]string, error) {
+	pageIDs := make(map[int64]string, len(noteIDs))
+	if len(noteIDs) == 0 {
+		return pageIDs, nil
+	}
+	var links []model.NoteExternalLink
+	err := db.WithContext(ctx).
+		Select("note_id", "target_note_id").
+		Where("note_id IN ? AND provider = ? AND is_active = TRUE", noteIDs, model.ProviderNotion).
+		Order("id ASC").
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if _, ok := pageIDs[link.NoteID]; !ok {
+			pageIDs[link.NoteID] = link.TargetNoteID
+		}
+	}
+	return pageIDs, nil
+}
+
 func GetNoteExternalLinkContentVersion(ctx context.Context, db *gorm.DB, linkID int64) (int64, error) {
 	var baseVesion int64
 	sql := db.Model(&model.NoteExternalLink{}).
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type noteBulkRepository struct {
+	db *gorm.DB
+}
+
+func NewNoteBulkRepository(db *gorm.DB) *noteBulkRepository {
+	return &noteBulkRepository{db: db}
+}
+
+// GetNotes 加锁读取工作区内的笔记，不存在或已删除的 ID 不返回
+func (r *noteBulkRepository) GetNotes(ctx context.Context, workspaceID int64, noteIDs []int64) ([]model.Note, error) {
+	notes := make([]model.Note, 0, len(noteIDs))
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("workspace_id = ? AND id IN ?", workspaceID, noteIDs).
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// GetTag 不存在时返回 gorm.ErrRecordNotFound
+func (r *noteBulkRepository) GetTag(ctx context.Context, workspaceID, tagID int64) (*model.NoteTag, error) {
+	var tag model.NoteTag
+	err := r.db.WithContext(ctx).Where("id = ? AND workspace_id = ?", tagID, workspaceID).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *noteBulkRepository) UpdateNotes(ctx context.Context, noteIDs []int64, data map[string]interface{}) error {
+	if len(noteIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.Note{}).Where("id IN ?", noteIDs).Updates(data).Error
+}
+
+// SetFavorites 与 SetFavoriteNote 相同的 upsert 规则，sep 较旧的请求不会覆盖新的状态
+func (r *noteBulkRepository) SetFavorites(ctx context.Context, userID int64, noteIDs []int64, isFavorite bool, sep int64) error {
+	if len(noteIDs) == 0 {
+		return nil
+	}
+	favorites := make([]model.FavoriteNote, 0, len(noteIDs))
+	for _, noteID := range noteIDs {
+		favorites = append(favorites, model.FavoriteNote{
+			NoteID:     noteID,
+			UserID:     userID,
+			IsFavorite: &isFavorite,
+			Sep:        sep,
+		})
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "note_id"}},
+			DoUpdates: []clause.Assignment{
+				{Column: clause.Column{Name: "is_favorite"}, Value: gorm.Expr("EXCLUDED.is_favorite")},
+				{Column: clause.Column{Name: "sep"}, Value: gorm.Expr("EXCLUDED.sep")},
+			},
+			Where: clause.Where{Exprs: []clause.Expression{
+				gorm.Expr("favorite_notes.sep < EXCLUDED.sep"),
+			}},
+		}).
+		Create(&favorites).Error
+}
+
+func (r *noteBulkRepository) CreateNote(ctx context.Context, note *model.Note) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+// TrashNotes 软删除笔记（移入回收站）
+func (r *noteBulkRepository) TrashNotes(ctx context.Context, noteIDs []int64) error {
+	if len(noteIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Where("id IN ?", noteIDs).Delete(&model.Note{}).Error
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type noteCommentRepository struct {
+	db *gorm.DB
+}
+
+func NewNoteCommentRepository(db *gorm.DB) *noteCommentRepository {
+	return &noteCommentRepository{db: db}
+}
+
+func (r *noteCommentRepository) CreateNoteComment(ctx context.Context, comment *model.NoteComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *noteCommentRepository) GetNoteCommentByID(ctx context.Context, workspaceID, commentID int64, opts ...DatabaseExtraOpt) (*model.NoteComment, error) {
+	option := &DatabaseExtraOptions{}
+	for _, o := range opts {
+		o(option)
+	}
+
+	var comment model.NoteComment
+	sql := r.db.WithContext(ctx).Where("id = ? AND workspace_id = ?", commentID, workspaceID)
+	if option.WithLock {
+		sql = sql.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := sql.First(&comment).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetNoteComments 取出笔记下的评论（根评论与回复平铺），按创建时间升序
+func (r *noteCommentRepository) GetNoteComments(ctx context.Context, noteID int64) ([]model.NoteComment, error) {
+	var comments []model.NoteComment
+	err := r.db.WithContext(ctx).
+		Where("note_id = ?", noteID).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// GetAnchoredRootComments 取出笔记下带锚点的根评论，用于内容变化后重新定位
+func (r *noteCommentRepository) GetAnchoredRootComments(ctx context.Context, noteID int64) ([]model.NoteComment, error) {
+	var comments []model.NoteComment
+	err := r.db.WithContext(ctx).
+		Where("note_id = ? AND parent_id = 0 AND block_id <> ''", noteID).
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *noteCommentRepository) UpdateNoteComment(ctx context.Context, commentID int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.NoteComment{}).Where("id = ?", commentID).Updates(data).Error
+}
+
+func (r *noteCommentRepository) IncreaseReplyCount(ctx context.Context, commentID int64, delta int) error {
+	return r.db.WithContext(ctx).Model(&model.NoteComment{}).
+		Where("id = ?", commentID).
+		UpdateColumn("reply_count", gorm.Expr("GREATEST(reply_count + ?, 0)", delta)).Error
+}
+
+// DeleteNoteCommentThread 删除评论；删除根评论时连同回复一起删除
+func (r *noteCommentRepository) DeleteNoteCommentThread(ctx context.Context, commentID int64) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? OR parent_id = ?", commentID, commentID).
+		Delete(&model.NoteComment{}).Error
+}
+
+func (r *noteCommentRepository) CreateMentions(ctx context.Context, mentions []model.NoteCommentMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&mentions).Error
+}
+
+func (r *noteCommentRepository) ReplaceMentions(ctx context.Context, commentID int64, mentions []model.NoteCommentMention) error {
+	if err := r.db.WithContext(ctx).Unscoped().Where("comment_id = ?", commentID).Delete(&model.NoteCommentMention{}).Error; err != nil {
+		return err
+	}
+	return r.CreateMentions(ctx, mentions)
+}
+
+func (r *noteCommentRepository) CreateAttachments(ctx context.Context, attachments []model.NoteCommentAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&attachments).Error
+}
+
+func (r *noteCommentRepository) GetMentionsByCommentIDs(ctx context.Context, commentIDs []int64) ([]model.NoteCommentMention, error) {
+	var mentions []model.NoteCommentMention
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+	err := r.db.WithContext(ctx).Where("comment_id IN ?", commentIDs).Order("start_rune ASC").Find(&mentions).Error
+	return mentions, err
+}
+
+func (r *noteCommentRepository) GetAttachmentsByCommentIDs(ctx context.Context, commentIDs []int64) ([]model.NoteCommentAttachment, error) {
+	var attachments []model.NoteCommentAttachment
+	if len(commentIDs) == 0 {
+		return attachments, nil
+	}
+	err := r.db.WithContext(ctx).Where("comment_id IN ?", commentIDs).Order("created_at ASC").Find(&attachments).Error
+	return attachments, err
+}
+
+// FilterWorkspaceMemberIDs 过滤出属于工作区的成员 ID，用于校验 @提及
+func (r *noteCommentRepository) FilterWorkspaceMemberIDs(ctx context.Context, workspaceID int64, memberIDs []int64) ([]int64, error) {
+	var ids []int64
+	if len(memberIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.WorkspaceMember{}).
+		Where("workspace_id = ? AND id IN ?", workspaceID, memberIDs).
+		Pluck("id", &ids).Error
+	return ids, err
+}
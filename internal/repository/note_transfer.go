@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type noteTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewNoteTransferRepository(db *gorm.DB) *noteTransferRepository {
+	return &noteTransferRepository{db: db}
+}
+
+// MapMembersByUser 把源工作区的成员 ID 映射为同一用户在目标工作区的成员 ID，不在目标工作区的成员不返回
+func (r *noteTransferRepository) MapMembersByUser(ctx context.Context, memberIDs []int64, targetWorkspaceID int64) (map[int64]int64, error) {
+	mapping := make(map[int64]int64, len(memberIDs))
+	if len(memberIDs) == 0 {
+		return mapping, nil
+	}
+	rows := make([]struct {
+		SourceID int64
+		TargetID int64
+	}, 0, len(memberIDs))
+	err := r.db.WithContext(ctx).Table("workspace_members s").
+		Select("s.id AS source_id, t.id AS target_id").
+		Joins("JOIN workspace_members t ON t.user_id = s.user_id AND t.workspace_id = ? AND t.deleted_at IS NULL", targetWorkspaceID).
+		Where("s.id IN ?", memberIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		mapping[row.SourceID] = row.TargetID
+	}
+	return mapping, nil
+}
+
+// ListComments 按创建时间返回笔记的全部评论，根评论在前
+func (r *noteTransferRepository) ListComments(ctx context.Context, noteID int64) ([]model.NoteComment, error) {
+	comments := make([]model.NoteComment, 0)
+	err := r.db.WithContext(ctx).Where("note_id = ?", noteID).
+		Order("parent_id = 0 DESC, created_at ASC").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *noteTransferRepository) ListMentions(ctx context.Context, commentIDs []int64) ([]model.NoteCommentMention, error) {
+	mentions := make([]model.NoteCommentMention, 0)
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+	if err := r.db.WithContext(ctx).Where("comment_id IN ?", commentIDs).Find(&mentions).Error; err != nil {
+		return nil, err
+	}
+	return mentions, nil
+}
+
+func (r *noteTransferRepository) ListAttachments(ctx context.Context, commentIDs []int64) ([]model.NoteCommentAttachment, error) {
+	attachments := make([]model.NoteCommentAttachment, 0)
+	if len(commentIDs) == 0 {
+		return attachments, nil
+	}
+	if err := r.db.WithContext(ctx).Where("comment_id IN ?", commentIDs).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *noteTransferRepository) ListExternalLinks(ctx context.Context, noteID int64) ([]model.NoteExternalLink, error) {
+	links := make([]model.NoteExternalLink, 0)
+	if err := r.db.WithContext(ctx).Where("note_id = ?", noteID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *noteTransferRepository) CreateNote(ctx context.Context, note *model.Note) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+func (r *noteTransferRepository) CreateComments(ctx context.Context, comments []model.NoteComment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&comments).Error
+}
+
+func (r *noteTransferRepository) CreateMentions(ctx context.Context, mentions []model.NoteCommentMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&mentions).Error
+}
+
+func (r *noteTransferRepository) CreateAttachments(ctx context.Context, attachments []model.NoteCommentAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&attachments).Error
+}
+
+func (r *noteTransferRepository) UpdateNote(ctx context.Context, noteID int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Note{}).Where("id = ?", noteID).Updates(data).Error
+}
+
+// MoveComments 评论随笔记迁移；作者、解决人与提及按用户映射到目标工作区成员，
+// 作者或解决人不在目标工作区时改为操作人 actorMemberID，提及则直接移除；访客评论没有成员作者，保持不变
+func (r *noteTransferRepository) MoveComments(ctx context.Context, noteID, targetWorkspaceID int64, memberMap map[int64]int64, actorMemberID int64) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&model.NoteComment{}).Where("note_id = ?", noteID).
+		Update("workspace_id", targetWorkspaceID).Error; err != nil {
+		return err
+	}
+
+	commentIDs := db.Model(&model.NoteComment{}).Select("id").Where("note_id = ?", noteID)
+	for from, to := range memberMap {
+		if err := db.Model(&model.NoteComment{}).Where("note_id = ? AND member_id = ?", noteID, from).
+			Update("member_id", to).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&model.NoteComment{}).Where("note_id = ? AND resolved_by = ?", noteID, from).
+			Update("resolved_by", to).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&model.NoteCommentMention{}).Where("comment_id IN (?) AND member_id = ?", commentIDs, from).
+			Update("member_id", to).Error; err != nil {
+			return err
+		}
+	}
+
+	targetMembers := db.Model(&model.WorkspaceMember{}).Select("id").Where("workspace_id = ?", targetWorkspaceID)
+	if err := db.Model(&model.NoteComment{}).Where("note_id = ? AND member_id <> 0 AND member_id NOT IN (?)", noteID, targetMembers).
+		Update("member_id", actorMemberID).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.NoteComment{}).Where("note_id = ? AND resolved_by NOT IN (?)", noteID, targetMembers).
+		Update("resolved_by", actorMemberID).Error; err != nil {
+		return err
+	}
+	return db.Where("comment_id IN (?) AND member_id NOT IN (?)", commentIDs, targetMembers).
+		Delete(&model.NoteCommentMention{}).Error
+}
+
+// DeleteComments 不迁移评论时软删除，附件随笔记在回收站清理时一并删除
+func (r *noteTransferRepository) DeleteComments(ctx context.Context, noteID int64) error {
+	db := r.db.WithContext(ctx)
+	commentIDs := db.Model(&model.NoteComment{}).Select("id").Where("note_id = ?", noteID)
+	steps := []*gorm.DB{
+		db.Where("comment_id IN (?)", commentIDs).Delete(&model.NoteCommentMention{}),
+		db.Where("comment_id IN (?)", commentIDs).Delete(&model.NoteCommentAttachment{}),
+		db.Where("note_id = ?", noteID).Delete(&model.NoteComment{}),
+	}
+	for _, step := range steps {
+		if step.Error != nil {
+			return step.Error
+		}
+	}
+	return nil
+}
+
+// DetachFromWorkspace 清理只在源工作区有意义的数据：授权、分享、每日笔记索引、清单任务关联、
+// 阅读统计以及来自源工作区的反向链接
+func (r *noteTransferRepository) DetachFromWorkspace(ctx context.Context, noteID int64) error {
+	db := r.db.WithContext(ctx)
+	steps := []*gorm.DB{
+		db.Where("note_id = ?", noteID).Delete(&model.NotePermission{}),
+		db.Model(&model.NoteShare{}).Where("note_id = ? AND revoked_at IS NULL", noteID).Update("revoked_at", time.Now()),
+		db.Where("note_id = ?", noteID).Delete(&model.DailyNote{}),
+		db.Unscoped().Where("note_id = ?", noteID).Delete(&model.NoteChecklistTask{}),
+		db.Where("target_type = ? AND target_id = ?", model.NoteLinkTargetNote, noteID).Delete(&model.NoteLink{}),
+		db.Unscoped().Where("note_id = ?", noteID).Delete(&model.NoteViewStat{}),
+		db.Where("note_id = ?", noteID).Delete(&model.NoteViewDaily{}),
+	}
+	for _, step := range steps {
+		if step.Error != nil {
+			return step.Error
+		}
+	}
+	return nil
+}
+
+// DropExternalLinks 删除外部同步链接及其块映射、待发送记录
+func (r *noteTransferRepository) DropExternalLinks(ctx context.Context, linkIDs []int64) error {
+	if len(linkIDs) == 0 {
+		return nil
+	}
+	db := r.db.WithContext(ctx).Unscoped().Session(&gorm.Session{})
+	noteIDs := db.Model(&model.NoteExternalLink{}).Select("note_id").Where("id IN ?", linkIDs)
+	providers := db.Model(&model.NoteExternalLink{}).Select("provider").Where("id IN ?", linkIDs)
+	return runPurgeSteps(db, []purgeStep{
+		{&model.SyncOutbox{}, "link_id IN ?", []interface{}{linkIDs}},
+		{&model.NoteExternalNodeMapping{}, "note_id IN (?) AND provider IN (?)", []interface{}{noteIDs, providers}},
+		{&model.NoteSyncConflict{}, "link_id IN ?", []interface{}{linkIDs}},
+		{&model.NoteExternalLink{}, "id IN ?", []interface{}{linkIDs}},
+	})
+}
+
+// RepointExternalLink 把同步链接改挂到 noteID 与目标工作区成员下，块映射与待发送记录一并迁移
+func (r *noteTransferRepository) RepointExternalLink(ctx context.Context, link *model.NoteExternalLink, noteID, memberID int64) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&model.NoteExternalLink{}).Where("id = ?", link.ID).Updates(map[string]interface{}{
+		"note_id":   noteID,
+		"member_id": memberID,
+	}).Error; err != nil {
+		return err
+	}
+	if link.NoteID == noteID {
+		return nil
+	}
+	if err := db.Model(&model.NoteExternalNodeMapping{}).
+		Where("note_id = ? AND provider = ?", link.NoteID, link.Provider).
+		Update("note_id", noteID).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.NoteSyncConflict{}).Where("link_id = ?", link.ID).Update("note_id", noteID).Error; err != nil {
+		return err
+	}
+	return db.Model(&model.SyncOutbox{}).Where("link_id = ?", link.ID).Update("note_id", noteID).Error
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type noteViewRepository struct {
+	db *gorm.DB
+}
+
+func NewNoteViewRepository(db *gorm.DB) *noteViewRepository {
+	return &noteViewRepository{db: db}
+}
+
+// UpsertStats 累加成员浏览次数并维护首次/最近浏览时间
+func (r *noteViewRepository) UpsertStats(ctx context.Context, stats []model.NoteViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "note_id"}, {Name: "member_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"view_count":      gorm.Expr("note_view_stats.view_count + EXCLUDED.view_count"),
+			"first_viewed_at": gorm.Expr("LEAST(note_view_stats.first_viewed_at, EXCLUDED.first_viewed_at)"),
+			"last_viewed_at":  gorm.Expr("GREATEST(note_view_stats.last_viewed_at, EXCLUDED.last_viewed_at)"),
+			"updated_at":      gorm.Expr("NOW()"),
+		}),
+	}).Create(&stats).Error
+}
+
+func (r *noteViewRepository) UpsertDaily(ctx context.Context, daily []model.NoteViewDaily) error {
+	if len(daily) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "note_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"view_count": gorm.Expr("note_view_dailies.view_count + EXCLUDED.view_count"),
+		}),
+	}).Create(&daily).Error
+}
+
+// ListReaders 按最近浏览时间倒序返回阅读过笔记的成员
+func (r *noteViewRepository) ListReaders(ctx context.Context, noteID int64, limit, offset int) ([]dto.NoteReaderDTO, int64, error) {
+	readers := make([]dto.NoteReaderDTO, 0)
+	var total int64
+
+	sql := r.db.WithContext(ctx).Table("note_view_stats s").
+		Joins("JOIN workspace_members m ON m.id = s.member_id").
+		Joins("LEFT JOIN users u ON u.id = m.user_id").
+		Where("s.note_id = ? AND s.deleted_at IS NULL", noteID)
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := sql.Select(`s.member_id, m.user_id, COALESCE(NULLIF(m.nickname, ''), u.nickname) AS nickname, u.avatar,
+			s.view_count, s.first_viewed_at, s.last_viewed_at`).
+		Order("s.last_viewed_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&readers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return readers, total, nil
+}
+
+// NoteTotals 笔记的总浏览次数与阅读人数
+func (r *noteViewRepository) NoteTotals(ctx context.Context, noteID int64) (views int64, readers int64, err error) {
+	var row struct {
+		Views   int64
+		Readers int64
+	}
+	err = r.db.WithContext(ctx).Model(&model.NoteViewStat{}).
+		Select("COALESCE(SUM(view_count), 0) AS views, COUNT(*) AS readers").
+		Where("note_id = ?", noteID).
+		Scan(&row).Error
+	return row.Views, row.Readers, err
+}
+
+// MostViewed since（含）之后浏览次数最多的笔记
+func (r *noteViewRepository) MostViewed(ctx context.Context, params *dto.NoteViewReportDTO, since time.Time, limit int) ([]dto.NoteViewReportItem, error) {
+	db := r.db.WithContext(ctx)
+	daily := db.Model(&model.NoteViewDaily{}).
+		Select("note_id, SUM(view_count) AS views").
+		Where("workspace_id = ? AND date >= ?", params.WorkspaceID, since.Format(dto.NoteViewDateLayout)).
+		Group("note_id")
+
+	items := make([]dto.NoteViewReportItem, 0)
+	sql := db.Table("(?) d", daily).
+		Select(`n.id AS note_id, n.title, n.category_id, n.owner_id, n.updated_at, n.content,
+			d.views, COALESCE(s.readers, 0) AS readers, s.last_viewed_at`).
+		Joins("JOIN notes n ON n.id = d.note_id AND n.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) s ON s.note_id = n.id", r.noteStatsByNote(params.WorkspaceID)).
+		Where("n.workspace_id = ?", params.WorkspaceID)
+	err := r.reportScope(sql, params).
+		Order("d.views DESC, n.id ASC").
+		Limit(limit).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Stale since 之后无人阅读的笔记，从未被阅读过的排在最前
+func (r *noteViewRepository) Stale(ctx context.Context, params *dto.NoteViewReportDTO, since time.Time, limit int) ([]dto.NoteViewReportItem, error) {
+	items := make([]dto.NoteViewReportItem, 0)
+	sql := r.db.WithContext(ctx).Table("notes n").
+		Select(`n.id AS note_id, n.title, n.category_id, n.owner_id, n.updated_at, n.content,
+			COALESCE(s.views, 0) AS views, COALESCE(s.readers, 0) AS readers, s.last_viewed_at`).
+		Joins("LEFT JOIN (?) s ON s.note_id = n.id", r.noteStatsByNote(params.WorkspaceID)).
+		Where("n.workspace_id = ? AND n.deleted_at IS NULL", params.WorkspaceID).
+		Where("s.last_viewed_at IS NULL OR s.last_viewed_at < ?", since)
+	err := r.reportScope(sql, params).
+		Order("s.last_viewed_at ASC NULLS FIRST, n.updated_at ASC").
+		Limit(limit).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *noteViewRepository) noteStatsByNote(workspaceID int64) *gorm.DB {
+	return r.db.Model(&model.NoteViewStat{}).
+		Select("note_id, SUM(view_count) AS views, COUNT(*) AS readers, MAX(last_viewed_at) AS last_viewed_at").
+		Where("workspace_id = ?", workspaceID).
+		Group("note_id")
+}
+
+// reportScope 按分类过滤；非管理员只能看到公开笔记与自己的笔记
+func (r *noteViewRepository) reportScope(sql *gorm.DB, params *dto.NoteViewReportDTO) *gorm.DB {
+	if params.CategoryID != nil {
+		sql = sql.Where("n.category_id = ?", *params.CategoryID)
+	}
+	if !params.IsAdmin {
+		sql = sql.Where("n.status = ? OR n.owner_id = ?", model.Public, params.UserID)
+	}
+	return sql
+}
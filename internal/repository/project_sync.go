@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type projectSyncRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectSyncRepository(db *gorm.DB) *projectSyncRepository {
+	return &projectSyncRepository{db: db}
+}
+
+// CreateProjectLink webhook 密钥加密后写入，调用方持有的 link 仍为明文
+func (r *projectSyncRepository) CreateProjectLink(ctx context.Context, link *model.ProjectExternalLink) error {
+	sealed := *link
+	if err := SealProjectLink(ctx, &sealed); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&sealed).Error; err != nil {
+		return err
+	}
+	sealed.WebhookSecret = link.WebhookSecret
+	*link = sealed
+	return nil
+}
+
+func (r *projectSyncRepository) GetProjectLink(ctx context.Context, linkID int64) (*model.ProjectExternalLink, error) {
+	var link model.ProjectExternalLink
+	if err := r.db.WithContext(ctx).First(&link, linkID).Error; err != nil {
+		return nil, err
+	}
+	if err := OpenProjectLink(ctx, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *projectSyncRepository) GetProjectLinks(ctx context.Context, projectID int64) ([]model.ProjectExternalLink, error) {
+	links := make([]model.ProjectExternalLink, 0)
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("created_at ASC").Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, openProjectLinks(ctx, links)
+}
+
+func openProjectLinks(ctx context.Context, links []model.ProjectExternalLink) error {
+	for i := range links {
+		if err := OpenProjectLink(ctx, &links[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetActiveProjectLinksByTask 任务所在项目上启用的链接
+func (r *projectSyncRepository) GetActiveProjectLinksByTask(ctx context.Context, taskID int64) ([]model.ProjectExternalLink, error) {
+	links := make([]model.ProjectExternalLink, 0)
+	err := r.db.WithContext(ctx).Model(&model.ProjectExternalLink{}).
+		Joins("JOIN to_do_tasks t ON t.project_id = project_external_links.project_id AND t.deleted_at IS NULL").
+		Where("t.id = ? AND project_external_links.is_active = TRUE", taskID).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, openProjectLinks(ctx, links)
+}
+
+func (r *projectSyncRepository) UpdateProjectLink(ctx context.Context, linkID int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.ProjectExternalLink{}).Where("id = ?", linkID).Updates(data).Error
+}
+
+// UpdateProjectLinkSecret 更换 webhook 密钥，加密后写入
+func (r *projectSyncRepository) UpdateProjectLinkSecret(ctx context.Context, linkID int64, secret string) error {
+	sealed := model.ProjectExternalLink{WebhookSecret: secret}
+	if err := SealProjectLink(ctx, &sealed); err != nil {
+		return err
+	}
+	return r.UpdateProjectLink(ctx, linkID, map[string]interface{}{
+		"webhook_secret": sealed.WebhookSecret,
+		"secret_kid":     sealed.SecretKID,
+	})
+}
+
+// DeleteProjectLink 链接及其任务、评论映射与引用记录一并硬删除，之后可以重新链接同一外部项目
+func (r *projectSyncRepository) DeleteProjectLink(ctx context.Context, linkID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().
+			Where("task_link_id IN (?)", tx.Model(&model.TaskExternalLink{}).Select("id").Where("project_link_id = ?", linkID)).
+			Delete(&model.TaskCommentExternalLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_link_id = ?", linkID).Delete(&model.TaskExternalLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_link_id = ?", linkID).Delete(&model.TaskExternalReference{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.ProjectExternalLink{}, linkID).Error
+	})
+}
+
+// GetProjectTaskIDs 链接建立后首次推送的任务
+func (r *projectSyncRepository) GetProjectTaskIDs(ctx context.Context, projectID int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := r.db.WithContext(ctx).Model(&model.ToDoTask{}).
+		Where("project_id = ?", projectID).
+		Order("created_at ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *projectSyncRepository) GetTaskLink(ctx context.Context, projectLinkID, taskID int64) (*model.TaskExternalLink, error) {
+	var link model.TaskExternalLink
+	err := r.db.WithContext(ctx).Where("project_link_id = ? AND task_id = ?", projectLinkID, taskID).Take(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *projectSyncRepository) GetTaskLinkByIssue(ctx context.Context, projectLinkID int64, issueID string) (*model.TaskExternalLink, error) {
+	var link model.TaskExternalLink
+	err := r.db.WithContext(ctx).Where("project_link_id = ? AND issue_id = ?", projectLinkID, issueID).Take(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *projectSyncRepository) SaveTaskLink(ctx context.Context, link *model.TaskExternalLink) error {
+	return r.db.WithContext(ctx).Save(link).Error
+}
+
+// DeleteTaskLink 外部问题被删除时只解除映射，本地任务保留
+func (r *projectSyncRepository) DeleteTaskLink(ctx context.Context, taskLinkID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("task_link_id = ?", taskLinkID).Delete(&model.TaskCommentExternalLink{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.TaskExternalLink{}, taskLinkID).Error
+	})
+}
+
+func (r *projectSyncRepository) GetCommentLinks(ctx context.Context, taskLinkID int64) ([]model.TaskCommentExternalLink, error) {
+	links := make([]model.TaskCommentExternalLink, 0)
+	err := r.db.WithContext(ctx).Where("task_link_id = ?", taskLinkID).Find(&links).Error
+	return links, err
+}
+
+func (r *projectSyncRepository) SaveCommentLink(ctx context.Context, link *model.TaskCommentExternalLink) error {
+	return r.db.WithContext(ctx).Save(link).Error
+}
+
+// GetTaskComments 任务下未删除的评论，按发表顺序
+func (r *projectSyncRepository) GetTaskComments(ctx context.Context, taskID int64) ([]model.ToDoTaskComment, error) {
+	comments := make([]model.ToDoTaskComment, 0)
+	err := r.db.WithContext(ctx).Where("to_do_task_id = ?", taskID).Order("created_at ASC").Find(&comments).Error
+	return comments, err
+}
+
+// GetTaskForUpdate 锁定任务，拉取外部修改时与本地编辑串行
+func (r *projectSyncRepository) GetTaskForUpdate(ctx context.Context, taskID int64) (*model.ToDoTask, error) {
+	var task model.ToDoTask
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, taskID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *projectSyncRepository) UpdateTask(ctx context.Context, taskID int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.ToDoTask{}).Where("id = ?", taskID).Updates(data).Error
+}
+
+// GetTaskAssigneeIDs 任务负责人（成员 ID），按分配先后排序
+func (r *projectSyncRepository) GetTaskAssigneeIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := r.db.WithContext(ctx).Model(&model.ToDoTaskAssignee{}).
+		Where("to_do_task_id = ?", taskID).
+		Order("created_at ASC").
+		Pluck("assignee_id", &ids).Error
+	return ids, err
+}
+
+// AddTaskAssignee 负责人已存在时忽略
+func (r *projectSyncRepository) AddTaskAssignee(ctx context.Context, taskID, memberID int64) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ToDoTaskAssignee{ToDoTaskID: taskID, AssigneeID: memberID}).Error
+}
+
+func (r *projectSyncRepository) GetProjectWorkspaceID(ctx context.Context, projectID int64) (int64, error) {
+	var workspaceID int64
+	err := r.db.WithContext(ctx).Model(&model.Project{}).Where("id = ?", projectID).Pluck("workspace_id", &workspaceID).Error
+	return workspaceID, err
+}
+
+func (r *projectSyncRepository) GetReference(ctx context.Context, projectLinkID, taskID int64, kind model.ExternalResourceType, externalID string) (*model.TaskExternalReference, error) {
+	var ref model.TaskExternalReference
+	err := r.db.WithContext(ctx).
+		Where("project_link_id = ? AND task_id = ? AND kind = ? AND external_id = ?", projectLinkID, taskID, kind, externalID).
+		Take(&ref).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// GetReferencedTaskIDs 之前的事件中引用过该 PR 或 issue 的任务
+func (r *projectSyncRepository) GetReferencedTaskIDs(ctx context.Context, projectLinkID int64, kind model.ExternalResourceType, externalID string) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := r.db.WithContext(ctx).Model(&model.TaskExternalReference{}).
+		Where("project_link_id = ? AND kind = ? AND external_id = ?", projectLinkID, kind, externalID).
+		Order("created_at ASC").
+		Pluck("task_id", &ids).Error
+	return ids, err
+}
+
+func (r *projectSyncRepository) SaveReference(ctx context.Context, ref *model.TaskExternalReference) error {
+	return r.db.WithContext(ctx).Save(ref).Error
+}
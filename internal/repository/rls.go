@@ -69,3 +69,23 @@ func BeginWithRLS(ctx context.Context, db *gorm.DB, auth AuthCtx, opts ...TxOpti
 
 	return tx, finish, nil
 }
+
+// BypassRLS 在当前事务内声明后台任务身份，rag 表的 RLS 不再按用户收窄。
+// 只用于不代表具体用户的任务（清理、向量化），必须在事务中调用，提交或回滚后自动失效
+func BypassRLS(ctx context.Context, tx *gorm.DB) error {
+	// sqlite 等没有 RLS
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return tx.WithContext(ctx).Exec(`SELECT set_config('app.bypass_rls', 'on', true)`).Error
+}
+
+// WithRLSBypass 在声明了 BypassRLS 的事务中执行 fn
+func WithRLSBypass(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := BypassRLS(ctx, tx); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/keyring"
+	"time"
+)
+
+// 集成密钥与令牌在仓储层加解密：写入前加密，读出后解密，业务代码只接触明文
+
+// clonePtr 加密前复制指针字段，避免改写调用方共享的字符串
+func clonePtr(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func sealValues(ctx context.Context, values ...*string) (*string, error) {
+	var kid string
+	for _, v := range values {
+		if v == nil || *v == "" {
+			continue
+		}
+		enc, k, err := keyring.Encrypt(ctx, *v)
+		if err != nil {
+			return nil, err
+		}
+		*v, kid = enc, k
+	}
+	if kid == "" && keyring.Default() != nil {
+		// 没有需要加密的值时也记录主用密钥，避免轮换任务反复选中
+		kid = keyring.Default().PrimaryKID()
+	}
+	if kid == "" {
+		return nil, nil
+	}
+	return &kid, nil
+}
+
+func openValues(ctx context.Context, values ...*string) error {
+	for _, v := range values {
+		if v == nil || *v == "" {
+			continue
+		}
+		plain, err := keyring.Decrypt(ctx, *v)
+		if err != nil {
+			return err
+		}
+		*v = plain
+	}
+	return nil
+}
+
+// SealIntegrationApp 加密应用密钥并记录密钥 ID
+func SealIntegrationApp(ctx context.Context, app *model.IntegrationApp) error {
+	app.SignSecretEnc, app.VerificationTokenEnc = clonePtr(app.SignSecretEnc), clonePtr(app.VerificationTokenEnc)
+	kid, err := sealValues(ctx, &app.AppSecretEnc, app.SignSecretEnc, app.VerificationTokenEnc)
+	if err != nil {
+		return err
+	}
+	if kid != nil {
+		now := time.Now()
+		app.KID, app.LastRotatedAt = kid, &now
+	}
+	return nil
+}
+
+func OpenIntegrationApp(ctx context.Context, app *model.IntegrationApp) error {
+	return openValues(ctx, &app.AppSecretEnc, app.SignSecretEnc, app.VerificationTokenEnc)
+}
+
+func SealIntegrationAccount(ctx context.Context, account *model.IntegrationAccount) error {
+	account.RefreshTokenEnc = clonePtr(account.RefreshTokenEnc)
+	kid, err := sealValues(ctx, &account.AccessTokenEnc, account.RefreshTokenEnc)
+	if err != nil {
+		return err
+	}
+	account.KID = kid
+	return nil
+}
+
+func OpenIntegrationAccount(ctx context.Context, account *model.IntegrationAccount) error {
+	return openValues(ctx, &account.AccessTokenEnc, account.RefreshTokenEnc)
+}
+
+func SealProjectLink(ctx context.Context, link *model.ProjectExternalLink) error {
+	kid, err := sealValues(ctx, &link.WebhookSecret)
+	if err != nil {
+		return err
+	}
+	link.SecretKID = kid
+	return nil
+}
+
+func OpenProjectLink(ctx context.Context, link *model.ProjectExternalLink) error {
+	return openValues(ctx, &link.WebhookSecret)
+}
+
+func SealWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	kid, err := sealValues(ctx, &sub.Secret)
+	if err != nil {
+		return err
+	}
+	sub.SecretKID = kid
+	return nil
+}
+
+func OpenWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	return openValues(ctx, &sub.Secret)
+}
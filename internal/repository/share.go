@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type shareRepository struct {
+	db *gorm.DB
+}
+
+func NewShareRepository(db *gorm.DB) *shareRepository {
+	return &shareRepository{db: db}
+}
+
+func (r *shareRepository) CreateNoteShare(ctx context.Context, share *model.NoteShare) error {
+	return r.db.WithContext(ctx).Create(share).Error
+}
+
+func (r *shareRepository) GetNoteSharesByNote(ctx context.Context, workspaceID, noteID int64) ([]model.NoteShare, error) {
+	var shares []model.NoteShare
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND note_id = ?", workspaceID, noteID).
+		Order("created_at DESC").
+		Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (r *shareRepository) GetNoteShareByID(ctx context.Context, workspaceID, shareID int64) (*model.NoteShare, error) {
+	var share model.NoteShare
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND workspace_id = ?", shareID, workspaceID).
+		First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) GetNoteShareByToken(ctx context.Context, token string) (*model.NoteShare, error) {
+	var share model.NoteShare
+	err := r.db.WithContext(ctx).
+		Where("token = ? AND revoked_at IS NULL", token).
+		First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) RevokeNoteShare(ctx context.Context, shareID int64) error {
+	return r.db.WithContext(ctx).Model(&model.NoteShare{}).
+		Where("id = ? AND revoked_at IS NULL", shareID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *shareRepository) IncreaseShareViewCount(ctx context.Context, shareID int64) error {
+	return r.db.WithContext(ctx).Model(&model.NoteShare{}).
+		Where("id = ?", shareID).
+		UpdateColumns(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": time.Now(),
+		}).Error
+}
+
+func (r *shareRepository) GetWorkspaceAllowShare(ctx context.Context, workspaceID int64) (bool, error) {
+	var allow bool
+	err := r.db.WithContext(ctx).Model(&model.Workspace{}).
+		Select("allow_share").
+		Where("id = ?", workspaceID).
+		Scan(&allow).Error
+	return allow, err
+}
@@ -59,12 +59,75 @@ func (r *syncRepository) CreateOutboxEvent(ctx context.Context, event *model.Out
 }
 
 func (r *syncRepository) UpsertNoteExternalNodeMappings(ctx context.Context, nodeMappings *[]model.NoteExternalNodeMapping) error {
+	if nodeMappings == nil || len(*nodeMappings) == 0 {
+		return nil
+	}
 	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "note_id"}, {Name: "provider"}, {Name: "external_block_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"updated_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"node_uid", "e_tag", "base_block", "sync_status", "last_synced_at", "updated_at"}),
 	}).Create(nodeMappings).Error
 }
 
+func (r *syncRepository) DeleteNoteExternalNodeMappings(ctx context.Context, noteID int64, provider model.IntegrationProvider, externalBlockIDs []string) error {
+	if len(externalBlockIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Where("note_id = ? AND provider = ? AND external_block_id IN ?", noteID, provider, externalBlockIDs).
+		Unscoped().
+		Delete(&model.NoteExternalNodeMapping{}).Error
+}
+
+// GetPullableNoteSyncLinks 已初始化、启用且允许拉取的同步链接
+func (r *syncRepository) GetPullableNoteSyncLinks(ctx context.Context, providers []model.IntegrationProvider) ([]model.NoteExternalLink, error) {
+	var links []model.NoteExternalLink
+	err := r.db.WithContext(ctx).
+		Where("is_active = TRUE AND init_status = ? AND direction IN ? AND provider IN ?",
+			model.InitReady, []model.SyncDirection{model.SyncPullOnly, model.SyncTwoWay}, providers).
+		Order("id ASC").
+		Find(&links).Error
+	return links, err
+}
+
+// GetPullableNoteSyncLinksByTarget 外部文档变更事件按文档 ID 找到需要拉取的链接
+func (r *syncRepository) GetPullableNoteSyncLinksByTarget(ctx context.Context, provider model.IntegrationProvider, targetID string) ([]model.NoteExternalLink, error) {
+	var links []model.NoteExternalLink
+	err := r.db.WithContext(ctx).
+		Where("is_active = TRUE AND init_status = ? AND direction IN ? AND provider = ? AND target_note_id = ?",
+			model.InitReady, []model.SyncDirection{model.SyncPullOnly, model.SyncTwoWay}, provider, targetID).
+		Find(&links).Error
+	return links, err
+}
+
+// GetNoteForUpdate 拉取写回前锁定笔记行，已删除的笔记返回 ErrRecordNotFound
+func (r *syncRepository) GetNoteForUpdate(ctx context.Context, noteID int64) (*model.Note, error) {
+	var note model.Note
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NULL", noteID).
+		First(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// UpdateNoteByVersion 以 version 做 CAS 更新笔记，版本已变化时返回 false
+func (r *syncRepository) UpdateNoteByVersion(ctx context.Context, noteID, version int64, data map[string]interface{}) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Note{}).
+		Where("id = ? AND version = ?", noteID, version).
+		Updates(data)
+	return res.RowsAffected > 0, res.Error
+}
+
+// CountPendingSyncOutbox 链接上尚未推送完成的 outbox 数量
+func (r *syncRepository) CountPendingSyncOutbox(ctx context.Context, linkID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.SyncOutbox{}).
+		Where("link_id = ? AND status IN ?", linkID, []model.SyncStatus{model.SyncPending, model.SyncRunning}).
+		Count(&count).Error
+	return count, err
+}
+
 func (r *syncRepository) GetNoteSyncByID(ctx context.Context, syncID int64) (model.NoteExternalLink, error) {
 	var syncModel model.NoteExternalLink
 	err := r.db.WithContext(ctx).First(&syncModel, syncID).Error
@@ -96,6 +159,9 @@ func (r *syncRepository) DeleteSyncOutbox(ctx context.Context, linkID int64) err
 }
 
 func (r *syncRepository) CreateSyncOutboxs(ctx context.Context, outbox *[]model.SyncOutbox) error {
+	if outbox == nil || len(*outbox) == 0 {
+		return nil
+	}
 	sql := r.db.WithContext(ctx).Create(outbox)
 
 	if err := sql.Error; err != nil {
@@ -121,7 +187,8 @@ func (r *syncRepository) GetBlockMappingByBlockIDs(ctx context.Context, nodeID i
 	return &mappings, nil
 }
 
-func (r *syncRepository) GetSequenceSynOutbox(ctx context.Context, linkID int64, status *model.SyncStatus, version *int64) (model.SyncOutbox, error) {
+// GetSequenceSynOutbox dueAt 不为空时跳过退避尚未到期的 outbox
+func (r *syncRepository) GetSequenceSynOutbox(ctx context.Context, linkID int64, status *model.SyncStatus, version *int64, dueAt *time.Time) (model.SyncOutbox, error) {
 	var outboxs model.SyncOutbox
 	sql := r.db.WithContext(ctx).
 		Where("link_id = ?", linkID).
@@ -135,6 +202,10 @@ func (r *syncRepository) GetSequenceSynOutbox(ctx context.Context, linkID int64,
 		sql = sql.Where("note_version = ?", *version)
 	}
 
+	if dueAt != nil {
+		sql = sql.Where("next_retry_at IS NULL OR next_retry_at <= ?", *dueAt)
+	}
+
 	sql = sql.Take(&outboxs)
 	if err := sql.Error; err != nil {
 		return outboxs, err
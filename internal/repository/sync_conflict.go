@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gin-notebook/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveOpenNoteSyncConflict 同一链接同一块已有未处理冲突时刷新两侧内容，否则新建
+func (r *syncRepository) SaveOpenNoteSyncConflict(ctx context.Context, conflict *model.NoteSyncConflict) error {
+	var existing model.NoteSyncConflict
+	err := r.db.WithContext(ctx).
+		Where("link_id = ? AND node_uid = ? AND status = ?", conflict.LinkID, conflict.NodeUID, model.ConflictOpen).
+		Take(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		conflict.Status = model.ConflictOpen
+		return r.db.WithContext(ctx).Create(conflict).Error
+	}
+	if err != nil {
+		return err
+	}
+	conflict.ID = existing.ID
+	return r.db.WithContext(ctx).Model(&model.NoteSyncConflict{}).
+		Where("id = ?", existing.ID).
+		Updates(map[string]interface{}{
+			"external_block_id": conflict.ExternalBlockID,
+			"base_block":        conflict.BaseBlock,
+			"local_block":       conflict.LocalBlock,
+			"remote_block":      conflict.RemoteBlock,
+			"remote_e_tag":      conflict.RemoteETag,
+			"updated_at":        time.Now(),
+		}).Error
+}
+
+// GetNoteSyncConflicts status 为空时返回全部，按发现时间倒序
+func (r *syncRepository) GetNoteSyncConflicts(ctx context.Context, noteID int64, linkID *int64, status *model.ConflictStatus) ([]model.NoteSyncConflict, error) {
+	var conflicts []model.NoteSyncConflict
+	query := r.db.WithContext(ctx).Where("note_id = ?", noteID)
+	if linkID != nil {
+		query = query.Where("link_id = ?", *linkID)
+	}
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	err := query.Order("created_at DESC").Find(&conflicts).Error
+	return conflicts, err
+}
+
+// GetNoteSyncConflictForUpdate 处理冲突前锁定记录，避免重复处理
+func (r *syncRepository) GetNoteSyncConflictForUpdate(ctx context.Context, conflictID int64) (*model.NoteSyncConflict, error) {
+	var conflict model.NoteSyncConflict
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", conflictID).
+		Take(&conflict).Error
+	if err != nil {
+		return nil, err
+	}
+	return &conflict, nil
+}
+
+func (r *syncRepository) ResolveNoteSyncConflict(ctx context.Context, conflictID int64, resolution model.ConflictResolution, userID int64) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.NoteSyncConflict{}).
+		Where("id = ? AND status = ?", conflictID, model.ConflictOpen).
+		Updates(map[string]interface{}{
+			"status":      model.ConflictResolved,
+			"resolution":  resolution,
+			"resolved_by": userID,
+			"resolved_at": now,
+			"updated_at":  now,
+		}).Error
+}
+
+func (r *syncRepository) DeleteNoteSyncConflicts(ctx context.Context, linkID int64) error {
+	return r.db.WithContext(ctx).Where("link_id = ?", linkID).Unscoped().Delete(&model.NoteSyncConflict{}).Error
+}
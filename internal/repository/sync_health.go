@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// GetSyncOutboxStats 按链接、状态聚合 outbox，NextRetryAt 为该状态下最早的退避到期时间
+func (r *syncRepository) GetSyncOutboxStats(ctx context.Context, linkIDs []int64) ([]dto.SyncOutboxStat, error) {
+	var stats []dto.SyncOutboxStat
+	if len(linkIDs) == 0 {
+		return stats, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.SyncOutbox{}).
+		Select("link_id, status, COUNT(*) AS count, MIN(next_retry_at) AS next_retry_at").
+		Where("link_id IN ?", linkIDs).
+		Group("link_id, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *syncRepository) GetSyncOutboxList(ctx context.Context, linkID int64, status *model.SyncStatus, limit int) ([]model.SyncOutbox, error) {
+	var outboxs []model.SyncOutbox
+	query := r.db.WithContext(ctx).Where("link_id = ?", linkID)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	err := query.Order("note_version DESC, id DESC").Limit(limit).Find(&outboxs).Error
+	return outboxs, err
+}
+
+// GetBlockingSyncOutboxForUpdate 锁定阻塞链接推进的 outbox，即版本紧接基线的那一条
+func (r *syncRepository) GetBlockingSyncOutboxForUpdate(ctx context.Context, linkID, version int64) (*model.SyncOutbox, error) {
+	var ob model.SyncOutbox
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("link_id = ? AND note_version = ? AND status IN ?", linkID, version,
+			[]model.SyncStatus{model.SyncPending, model.SyncDead}).
+		Order("id ASC").
+		Take(&ob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ob, nil
+}
+
+// ResetSyncOutboxForRetry 死信与退避中的 outbox 重新置为可立即执行
+func (r *syncRepository) ResetSyncOutboxForRetry(ctx context.Context, linkID int64) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.SyncOutbox{}).
+		Where("link_id = ? AND status IN ?", linkID, []model.SyncStatus{model.SyncPending, model.SyncDead}).
+		Updates(map[string]interface{}{
+			"status":        model.SyncPending,
+			"attempts":      0,
+			"next_retry_at": nil,
+			"updated_at":    time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// SkipPendingSyncOutbox 链接不再推送时，把尚未执行的 outbox 标记为跳过
+func (r *syncRepository) SkipPendingSyncOutbox(ctx context.Context, linkID int64) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.SyncOutbox{}).
+		Where("link_id = ? AND status = ?", linkID, model.SyncPending).
+		Updates(map[string]interface{}{
+			"status":        model.SyncSkipped,
+			"next_retry_at": nil,
+			"updated_at":    time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// SkipStaleSyncOutbox 版本不超过基线的 pending outbox 已被初始化或更早的推送覆盖，标记为跳过
+func (r *syncRepository) SkipStaleSyncOutbox(ctx context.Context, linkID, version int64) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.SyncOutbox{}).
+		Where("link_id = ? AND status = ? AND note_version <= ?", linkID, model.SyncPending, version).
+		Updates(map[string]interface{}{
+			"status":        model.SyncSkipped,
+			"next_retry_at": nil,
+			"updated_at":    time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// ReclaimStaleSyncOutbox worker 中途退出时 running 的 outbox 会一直卡住，超时后退回 pending
+func (r *syncRepository) ReclaimStaleSyncOutbox(ctx context.Context, staleBefore time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.SyncOutbox{}).
+		Where("status = ? AND updated_at < ?", model.SyncRunning, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     model.SyncPending,
+			"last_error": "worker timeout",
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// GetRetryableSyncTargets 退避已到期，或长时间无人处理的 pending outbox 所在链接
+func (r *syncRepository) GetRetryableSyncTargets(ctx context.Context, now, staleBefore time.Time) ([]dto.SyncRetryTarget, error) {
+	var targets []dto.SyncRetryTarget
+	err := r.db.WithContext(ctx).Table("note_external_links AS l").
+		Select("DISTINCT l.id AS link_id, l.note_id, n.workspace_id, l.member_id, wm.user_id").
+		Joins("JOIN sync_outbox AS o ON o.link_id = l.id").
+		Joins("JOIN notes AS n ON n.id = l.note_id AND n.deleted_at IS NULL").
+		Joins("JOIN workspace_members AS wm ON wm.id = l.member_id").
+		Where("l.is_active = TRUE AND l.init_status = ? AND l.deleted_at IS NULL", model.InitReady).
+		Where("o.status = ? AND (o.next_retry_at <= ? OR (o.next_retry_at IS NULL AND o.updated_at < ?))",
+			model.SyncPending, now, staleBefore).
+		Scan(&targets).Error
+	return targets, err
+}
+
+// GetWorkspaceNoteSyncLinks 工作区内全部同步链接；unhealthy 时只返回连续失败、初始化失败或有死信的链接
+func (r *syncRepository) GetWorkspaceNoteSyncLinks(ctx context.Context, workspaceID int64, unhealthy bool, page, pageSize int) ([]model.NoteExternalLink, int64, error) {
+	var (
+		links []model.NoteExternalLink
+		total int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.NoteExternalLink{}).
+		Joins("JOIN notes ON notes.id = note_external_links.note_id AND notes.deleted_at IS NULL").
+		Where("notes.workspace_id = ? AND note_external_links.deleted_at IS NULL", workspaceID)
+	if unhealthy {
+		query = query.Where(
+			"note_external_links.fail_streak > 0 OR note_external_links.init_status = ? OR EXISTS (SELECT 1 FROM sync_outbox o WHERE o.link_id = note_external_links.id AND o.status = ?)",
+			model.InitFailed, model.SyncDead)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Select("note_external_links.*").
+		Order("note_external_links.fail_streak DESC, note_external_links.updated_at DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&links).Error
+	return links, total, err
+}
+
+// GetNoteVersions 批量读取笔记当前版本，用于计算链接落后的版本数
+func (r *syncRepository) GetNoteVersions(ctx context.Context, noteIDs []int64) (map[int64]int64, error) {
+	var rows []struct {
+		ID      int64
+		Version int64
+	}
+	versions := make(map[int64]int64, len(noteIDs))
+	if len(noteIDs) == 0 {
+		return versions, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&model.Note{}).
+		Select("id, version").
+		Where("id IN ?", noteIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		versions[row.ID] = row.Version
+	}
+	return versions, nil
+}
+
+// DeleteNoteProviderMappings 重建链接前清空笔记在该平台上的全部块映射
+func (r *syncRepository) DeleteNoteProviderMappings(ctx context.Context, noteID int64, provider model.IntegrationProvider) error {
+	return r.db.WithContext(ctx).
+		Where("note_id = ? AND provider = ?", noteID, provider).
+		Unscoped().
+		Delete(&model.NoteExternalNodeMapping{}).Error
+}
@@ -30,6 +30,9 @@ func SetTransactionIsolationLevel(ctx context.Context, tx *gorm.DB, level Isolat
 		stmt = fmt.Sprintf("SET TRANSACTION ISOLATION LEVEL %s;", level)
 	case "mysql":
 		stmt = fmt.Sprintf("SET SESSION TRANSACTION ISOLATION LEVEL %s;", level)
+	case "sqlite":
+		// sqlite 的事务本身是串行化的，没有可设置的隔离级别
+		return nil
 	default:
 		stmt = fmt.Sprintf("SET TRANSACTION ISOLATION LEVEL %s;", level)
 	}
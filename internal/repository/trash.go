@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *trashRepository {
+	return &trashRepository{db: db}
+}
+
+// ListTrashedNotes ownerID 为 nil 时返回工作区内全部已删除笔记（管理员视角）
+func (r *trashRepository) ListTrashedNotes(ctx context.Context, workspaceID int64, ownerID *int64, limit, offset int) ([]dto.TrashItemDTO, int64, error) {
+	var items []dto.TrashItemDTO
+	var total int64
+
+	sql := r.db.WithContext(ctx).Unscoped().Table("notes").
+		Joins("LEFT JOIN note_categories nc ON nc.id = notes.category_id AND nc.deleted_at IS NULL").
+		Where("notes.workspace_id = ? AND notes.deleted_at IS NOT NULL", workspaceID)
+	if ownerID != nil {
+		sql = sql.Where("notes.owner_id = ?", *ownerID)
+	}
+
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := sql.Select(`notes.id, 'note' AS type, notes.title, notes.owner_id,
+			notes.category_id AS parent_id, COALESCE(nc.category_name, '') AS parent_name, notes.deleted_at`).
+		Order("notes.deleted_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// ListTrashedTasks creatorID 为 nil 时返回工作区内全部已删除任务；所属项目已删除的任务无法恢复，不再列出
+func (r *trashRepository) ListTrashedTasks(ctx context.Context, workspaceID int64, creatorID *int64, limit, offset int) ([]dto.TrashItemDTO, int64, error) {
+	var items []dto.TrashItemDTO
+	var total int64
+
+	sql := r.db.WithContext(ctx).Unscoped().Table("to_do_tasks").
+		Joins("JOIN projects p ON p.id = to_do_tasks.project_id AND p.deleted_at IS NULL").
+		Joins("LEFT JOIN to_do_columns c ON c.id = to_do_tasks.column_id AND c.deleted_at IS NULL").
+		Where("p.workspace_id = ? AND to_do_tasks.deleted_at IS NOT NULL", workspaceID)
+	if creatorID != nil {
+		sql = sql.Where("to_do_tasks.creator = ?", *creatorID)
+	}
+
+	if err := sql.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := sql.Select(`to_do_tasks.id, 'task' AS type, to_do_tasks.title, to_do_tasks.creator AS owner_id,
+			to_do_tasks.column_id AS parent_id, COALESCE(c.name, '') AS parent_name,
+			to_do_tasks.project_id, to_do_tasks.deleted_at`).
+		Order("to_do_tasks.deleted_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *trashRepository) GetTrashedNote(ctx context.Context, workspaceID, noteID int64) (*model.Note, error) {
+	var note model.Note
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND workspace_id = ? AND deleted_at IS NOT NULL", noteID, workspaceID).
+		First(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *trashRepository) GetTrashedTask(ctx context.Context, workspaceID, taskID int64) (*model.ToDoTask, error) {
+	var task model.ToDoTask
+	err := r.db.WithContext(ctx).Unscoped().
+		Joins("JOIN projects p ON p.id = to_do_tasks.project_id").
+		Where("to_do_tasks.id = ? AND p.workspace_id = ? AND to_do_tasks.deleted_at IS NOT NULL", taskID, workspaceID).
+		First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetFallbackCategory 原分类不存在时的恢复目标：根目录下排在最前的分类
+func (r *trashRepository) GetFallbackCategory(ctx context.Context, workspaceID int64) (*model.NoteCategory, error) {
+	var category model.NoteCategory
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ?", workspaceID).
+		Order("parent_id IS NOT NULL").
+		Order(GetLexoRankOrderExpr(false)).
+		First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetFallbackColumn 原列不存在时的恢复目标：项目中排在最前的列
+func (r *trashRepository) GetFallbackColumn(ctx context.Context, projectID int64) (*model.ToDoColumn, error) {
+	var column model.ToDoColumn
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order(GetLexoRankOrderExpr(false)).
+		First(&column).Error
+	if err != nil {
+		return nil, err
+	}
+	return &column, nil
+}
+
+func (r *trashRepository) ExistsCategory(ctx context.Context, workspaceID, categoryID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.NoteCategory{}).
+		Where("id = ? AND workspace_id = ?", categoryID, workspaceID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *trashRepository) ExistsColumn(ctx context.Context, projectID, columnID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.ToDoColumn{}).
+		Where("id = ? AND project_id = ?", columnID, projectID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *trashRepository) ExistsProject(ctx context.Context, workspaceID, projectID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Project{}).
+		Where("id = ? AND workspace_id = ?", projectID, workspaceID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *trashRepository) RestoreNote(ctx context.Context, noteID, categoryID int64) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&model.Note{}).
+		Where("id = ?", noteID).
+		Updates(map[string]interface{}{
+			"deleted_at":  nil,
+			"category_id": categoryID,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+func (r *trashRepository) RestoreTask(ctx context.Context, taskID, columnID int64, orderIndex string) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&model.ToDoTask{}).
+		Where("id = ?", taskID).
+		Updates(map[string]interface{}{
+			"deleted_at":  nil,
+			"column_id":   columnID,
+			"order_index": orderIndex,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+// GetExpiredNoteIDs 删除时间早于 before 的笔记
+func (r *trashRepository) GetExpiredNoteIDs(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.Note{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *trashRepository) GetExpiredTaskIDs(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.ToDoTask{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// PurgeNotes 彻底删除笔记及其关联数据（rag 文档、同步链接、评论、链接、分享、授权、收藏），
+// 返回需要从对象存储删除的文件地址。调用方负责放在事务中执行。
+func (r *trashRepository) PurgeNotes(ctx context.Context, noteIDs []int64) ([]string, error) {
+	if len(noteIDs) == 0 {
+		return nil, nil
+	}
+	// Session 让每条语句从同一起点派生，避免条件在多次调用之间累积
+	db := r.db.WithContext(ctx).Unscoped().Session(&gorm.Session{})
+
+	files := make([]string, 0)
+	var covers []string
+	if err := db.Model(&model.Note{}).Where("id IN ? AND cover IS NOT NULL AND cover <> ''", noteIDs).Pluck("cover", &covers).Error; err != nil {
+		return nil, err
+	}
+	files = append(files, covers...)
+
+	commentIDs := db.Model(&model.NoteComment{}).Select("id").Where("note_id IN ?", noteIDs)
+	var attachmentURLs []string
+	if err := db.Model(&model.NoteCommentAttachment{}).Where("comment_id IN (?)", commentIDs).Pluck("file_url", &attachmentURLs).Error; err != nil {
+		return nil, err
+	}
+	files = append(files, attachmentURLs...)
+
+	externalIDs := make([]string, 0, len(noteIDs))
+	for _, id := range noteIDs {
+		externalIDs = append(externalIDs, fmt.Sprintf("note:%d", id))
+	}
+	// 笔记的 rag 文档可能属于其他成员，清理时绕过 rag 表的 RLS
+	if err := BypassRLS(ctx, r.db); err != nil {
+		return nil, err
+	}
+	documentIDs := db.Model(&model.Document{}).Select("id").Where("external_id IN ?", externalIDs)
+	linkIDs := db.Model(&model.NoteExternalLink{}).Select("id").Where("note_id IN ?", noteIDs)
+
+	steps := []purgeStep{
+		{&model.Chunk{}, "document_id IN (?)", []interface{}{documentIDs}},
+		{&model.Document{}, "external_id IN ?", []interface{}{externalIDs}},
+		{&model.SyncOutbox{}, "note_id IN ? OR link_id IN (?)", []interface{}{noteIDs, linkIDs}},
+		{&model.NoteExternalNodeMapping{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteSyncConflict{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteExternalLink{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteCommentMention{}, "comment_id IN (?)", []interface{}{commentIDs}},
+		{&model.NoteCommentAttachment{}, "comment_id IN (?)", []interface{}{commentIDs}},
+		{&model.NoteComment{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteLink{}, "source_note_id IN ? OR (target_type = ? AND target_id IN ?)", []interface{}{noteIDs, model.NoteLinkTargetNote, noteIDs}},
+		{&model.NoteShare{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NotePermission{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.FavoriteNote{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.DailyNote{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteChecklistTask{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteViewStat{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.NoteViewDaily{}, "note_id IN ?", []interface{}{noteIDs}},
+		{&model.Note{}, "id IN ?", []interface{}{noteIDs}},
+	}
+	if err := runPurgeSteps(db, steps); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// PurgeTasks 彻底删除任务及其评论、附件、负责人等关联数据，返回需要从对象存储删除的文件地址
+func (r *trashRepository) PurgeTasks(ctx context.Context, taskIDs []int64) ([]string, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx).Unscoped().Session(&gorm.Session{})
+
+	files := make([]string, 0)
+	var covers []string
+	if err := db.Model(&model.ToDoTask{}).Where("id IN ? AND cover IS NOT NULL AND cover <> ''", taskIDs).Pluck("cover", &covers).Error; err != nil {
+		return nil, err
+	}
+	files = append(files, covers...)
+
+	commentIDs := db.Model(&model.ToDoTaskComment{}).Select("id").Where("to_do_task_id IN ?", taskIDs)
+	var attachmentURLs []string
+	if err := db.Model(&model.ToDoCommentAttachment{}).Where("comment_id IN (?)", commentIDs).Pluck("file_url", &attachmentURLs).Error; err != nil {
+		return nil, err
+	}
+	files = append(files, attachmentURLs...)
+
+	steps := []purgeStep{
+		{&model.ToDoCommentMention{}, "comment_id IN (?)", []interface{}{commentIDs}},
+		{&model.ToDoCommentAttachment{}, "comment_id IN (?)", []interface{}{commentIDs}},
+		{&model.ToDoCommentLike{}, "comment_id IN (?)", []interface{}{commentIDs}},
+		{&model.ToDoTaskComment{}, "to_do_task_id IN ?", []interface{}{taskIDs}},
+		{&model.ToDoTaskAssignee{}, "to_do_task_id IN ?", []interface{}{taskIDs}},
+		{&model.NoteLink{}, "target_type = ? AND target_id IN ?", []interface{}{model.NoteLinkTargetTask, taskIDs}},
+		{&model.NoteChecklistTask{}, "task_id IN ?", []interface{}{taskIDs}},
+		{&model.ToDoTask{}, "id IN ?", []interface{}{taskIDs}},
+	}
+	if err := runPurgeSteps(db, steps); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// purgeStep 一条按条件删除的语句
+type purgeStep struct {
+	model interface{}
+	query string
+	args  []interface{}
+}
+
+// runPurgeSteps 按顺序执行，遇到错误立即返回
+func runPurgeSteps(db *gorm.DB, steps []purgeStep) error {
+	for _, step := range steps {
+		if err := db.Where(step.query, step.args...).Delete(step.model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateFallbackCategory 工作区已没有任何分类时，为恢复的笔记新建一个根分类
+func (r *trashRepository) CreateFallbackCategory(ctx context.Context, category *model.NoteCategory) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
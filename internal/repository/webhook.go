@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *webhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription 签名密钥加密后写入，调用方持有的 sub 仍为明文
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	sealed := *sub
+	if err := SealWebhookSubscription(ctx, &sealed); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(&sealed).Error; err != nil {
+		return err
+	}
+	sealed.Secret = sub.Secret
+	*sub = sealed
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id int64) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	if err := r.db.WithContext(ctx).First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	if err := OpenWebhookSubscription(ctx, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions 工作区全部 webhook，不解密签名密钥
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, workspaceID int64) ([]model.WebhookSubscription, error) {
+	subs := make([]model.WebhookSubscription, 0)
+	err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Order("created_at ASC").Find(&subs).Error
+	return subs, err
+}
+
+// GetActiveSubscriptions 工作区启用的 webhook，按事件与项目过滤由调用方完成
+func (r *webhookRepository) GetActiveSubscriptions(ctx context.Context, workspaceID int64) ([]model.WebhookSubscription, error) {
+	subs := make([]model.WebhookSubscription, 0)
+	err := r.db.WithContext(ctx).Where("workspace_id = ? AND is_active = TRUE", workspaceID).Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, id int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookSubscription{}).Where("id = ?", id).Updates(data).Error
+}
+
+// DeleteSubscription webhook 及其投递日志一并硬删除
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("subscription_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.WebhookSubscription{}, id).Error
+	})
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.db.WithContext(ctx).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, id int64, data map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", id).Updates(data).Error
+}
+
+// ListDeliveries 投递日志，最近的在前
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]model.WebhookDelivery, int64, error) {
+	deliveries := make([]model.WebhookDelivery, 0)
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
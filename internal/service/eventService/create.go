@@ -1,11 +1,14 @@
 package eventService
 
 import (
+	"context"
 	"gin-notebook/internal/http/message"
 	"gin-notebook/internal/model"
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
+	"strconv"
 
 	"gorm.io/gorm"
 )
@@ -45,5 +48,14 @@ func CreateEvent(params *dto.CreateEventParamsDTO) (responseCode int, data map[s
 	data = map[string]interface{}{
 		"id": event.ID,
 	}
+	webhookService.Dispatch(context.Background(), event.WorkspaceID, 0, model.WebhookEventCreated, map[string]interface{}{
+		"event_id": strconv.FormatInt(event.ID, 10),
+		"title":    event.Title,
+		"start":    event.Start,
+		"end":      event.End,
+		"all_day":  event.Allday,
+		"location": event.Location,
+		"user_id":  strconv.FormatInt(event.UserID, 10),
+	})
 	return
 }
@@ -2,12 +2,17 @@ package integrationService
 
 import (
 	"context"
+	"crypto/subtle"
+	"fmt"
 	"gin-notebook/internal/http/message"
 	"gin-notebook/internal/model"
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/pkg/integration/feishu"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
 	"time"
 )
 
@@ -24,6 +29,12 @@ func HandleFeishuOAuthCallback(ctx context.Context, params *dto.FeishuOAuthCallb
 		return message.ERROR_FEISHU_GET_USER_ACCESS_TOKEN_FAILED, nil
 	}
 
+	// 机器人私信按 open_id 发送；获取失败时由通知任务稍后补齐
+	var openID *string
+	if id, err := c.GetUserOpenID(ctx, user_access_token.AccessToken); err == nil {
+		openID = &id
+	}
+
 	repo := repository.NewIntegrationRepository(database.DB)
 
 	accessTokenExpiry := time.Now().Add(time.Duration(user_access_token.ExpiresIn-300) * time.Second)
@@ -32,6 +43,7 @@ func HandleFeishuOAuthCallback(ctx context.Context, params *dto.FeishuOAuthCallb
 	repo.BindIntegrationAccount(ctx, &model.IntegrationAccount{
 		UserID:             params.UserID,
 		Provider:           "feishu",
+		AccountID:          openID,
 		AccessTokenEnc:     user_access_token.AccessToken,
 		RefreshTokenEnc:    &user_access_token.RefreshToken,
 		AccessTokenExpiry:  &accessTokenExpiry,
@@ -42,3 +54,72 @@ func HandleFeishuOAuthCallback(ctx context.Context, params *dto.FeishuOAuthCallb
 	})
 	return message.SUCCESS, nil
 }
+
+// HandleFeishuEvent 处理飞书事件回调：配置了 Encrypt Key 时校验签名并解密，
+// 配置了 Verification Token 时校验令牌，两者都未配置时拒绝；文档编辑事件投递对应链接的拉取任务。
+// url_verification 返回需要原样回显的 challenge
+func HandleFeishuEvent(ctx context.Context, params *dto.FeishuEventDTO) (responseCode int, data map[string]interface{}) {
+	apps, err := repository.NewIntegrationRepository(database.DB).GetIntegrationAppList(ctx, string(model.ProviderFeishu))
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if len(apps) == 0 {
+		return message.ERROR_FEISHU_INTEGRATION_NOT_CONFIGURED, nil
+	}
+	app := apps[0]
+	encryptKey, verificationToken := "", ""
+	if app.SignSecretEnc != nil {
+		encryptKey = *app.SignSecretEnc
+	}
+	if app.VerificationTokenEnc != nil {
+		verificationToken = *app.VerificationTokenEnc
+	}
+	// 两者都未配置时无法确认事件来自飞书，不接收
+	if encryptKey == "" && verificationToken == "" {
+		logger.LogInfo("飞书应用未配置 Encrypt Key 或 Verification Token，拒绝事件回调")
+		return message.ERROR_FEISHU_INTEGRATION_NOT_CONFIGURED, nil
+	}
+
+	if encryptKey != "" && params.Signature != "" &&
+		!feishu.VerifySignature(params.Timestamp, params.Nonce, encryptKey, params.Body, params.Signature) {
+		return message.ERROR_FEISHU_EVENT_SIGNATURE_INVALID, nil
+	}
+
+	event, err := feishu.ParseEvent(params.Body, encryptKey)
+	if err != nil {
+		logger.LogError(err, "解析飞书事件失败")
+		return message.ERROR_FEISHU_EVENT_INVALID, nil
+	}
+	if verificationToken != "" &&
+		subtle.ConstantTimeCompare([]byte(verificationToken), []byte(event.VerificationToken())) != 1 {
+		return message.ERROR_FEISHU_EVENT_TOKEN_INVALID, nil
+	}
+
+	switch event.EventType() {
+	case feishu.EventURLVerification:
+		return message.SUCCESS, map[string]interface{}{"challenge": event.Challenge}
+	case feishu.EventFileEdit:
+		// 事件推送不带签名头时只有 url_verification 可以放行
+		if encryptKey != "" && params.Signature == "" {
+			return message.ERROR_FEISHU_EVENT_SIGNATURE_INVALID, nil
+		}
+		fileToken, err := event.FileEditToken()
+		if err != nil {
+			logger.LogError(err, "解析飞书文档编辑事件失败")
+			return message.ERROR_FEISHU_EVENT_INVALID, nil
+		}
+		links, err := repository.NewSyncRepository(database.DB).GetPullableNoteSyncLinksByTarget(ctx, model.ProviderFeishu, fileToken)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		for _, link := range links {
+			// 同一链接短时间内的重复事件由任务唯一性合并
+			if _, err := enqueue.SyncPull(ctx, types.SyncPullPayload{LinkID: link.ID}); err != nil {
+				logger.LogInfo(fmt.Sprintf("飞书事件投递拉取任务跳过 link=%d: %v", link.ID, err))
+			}
+		}
+	default:
+		logger.LogInfo("忽略飞书事件 " + event.EventType())
+	}
+	return message.SUCCESS, nil
+}
@@ -0,0 +1,175 @@
+package integrationService
+
+import (
+	"context"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/feishu"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/utils/tools"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// parseFeishuNotifyKinds 去重并校验通知类型，空列表表示推送全部通知
+func parseFeishuNotifyKinds(events []string) ([]model.FeishuNotifyKind, bool) {
+	kinds := make([]model.FeishuNotifyKind, 0, len(events))
+	seen := make(map[string]bool, len(events))
+	for _, e := range events {
+		if !model.IsFeishuNotifyKind(e) {
+			return nil, false
+		}
+		if !seen[e] {
+			seen[e] = true
+			kinds = append(kinds, model.FeishuNotifyKind(e))
+		}
+	}
+	return kinds, true
+}
+
+func getFeishuChat(ctx context.Context, workspaceID, projectID, chatID int64) (*model.FeishuProjectChat, int) {
+	exists, err := repository.ProjectExistsByID(database.DB, projectID, workspaceID)
+	if err != nil {
+		return nil, database.IsError(err)
+	}
+	if !exists {
+		return nil, message.ERROR_PROJECT_NOT_EXIST
+	}
+	chat, err := repository.NewFeishuNotifyRepository(database.DB).GetChat(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, message.ERROR_FEISHU_CHAT_NOT_FOUND
+		}
+		return nil, database.IsError(err)
+	}
+	if chat.ProjectID != projectID {
+		return nil, message.ERROR_FEISHU_CHAT_NOT_FOUND
+	}
+	return chat, message.SUCCESS
+}
+
+// CreateFeishuChat 绑定项目的飞书通知群；创建前以机器人身份读取群信息，确认机器人已在群内
+func CreateFeishuChat(ctx context.Context, params *dto.CreateFeishuChatDTO) (responseCode int, data map[string]interface{}) {
+	exists, err := repository.ProjectExistsByID(database.DB, params.ProjectID, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if !exists {
+		return message.ERROR_PROJECT_NOT_EXIST, nil
+	}
+	kinds, ok := parseFeishuNotifyKinds(params.Events)
+	if !ok {
+		return message.ERROR_FEISHU_NOTIFY_KIND_INVALID, nil
+	}
+
+	chatID := strings.TrimSpace(params.ChatID)
+	repo := repository.NewFeishuNotifyRepository(database.DB)
+	taken, err := repo.ChatExists(ctx, params.ProjectID, chatID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if taken {
+		return message.ERROR_FEISHU_CHAT_EXISTS, nil
+	}
+
+	c := feishu.GetClient()
+	if c == nil || c.Client == nil {
+		return message.ERROR_FEISHU_INTEGRATION_NOT_CONFIGURED, nil
+	}
+	chatName, err := c.GetChatName(ctx, chatID)
+	if err != nil {
+		return message.ERROR_FEISHU_CHAT_UNAVAILABLE, nil
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		name = chatName
+	}
+
+	chat := &model.FeishuProjectChat{
+		ProjectID: params.ProjectID,
+		ChatID:    chatID,
+		Name:      name,
+		Events:    tools.MustJSONBytes(kinds),
+		CreatorID: params.MemberID,
+		IsActive:  true,
+	}
+	if err := repo.CreateChat(ctx, chat); err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"chat": chat.Data()}
+}
+
+func GetFeishuChats(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int, data map[string]interface{}) {
+	exists, err := repository.ProjectExistsByID(database.DB, params.ProjectID, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if !exists {
+		return message.ERROR_PROJECT_NOT_EXIST, nil
+	}
+	chats, err := repository.NewFeishuNotifyRepository(database.DB).ListChats(ctx, params.ProjectID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	list := make([]map[string]interface{}, 0, len(chats))
+	for i := range chats {
+		list = append(list, chats[i].Data())
+	}
+	return message.SUCCESS, map[string]interface{}{"chats": list}
+}
+
+func UpdateFeishuChat(ctx context.Context, params *dto.UpdateFeishuChatDTO) (responseCode int, data map[string]interface{}) {
+	chat, responseCode := getFeishuChat(ctx, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+
+	update := make(map[string]interface{})
+	if params.Name != nil {
+		name := strings.TrimSpace(*params.Name)
+		if name == "" {
+			return message.ERROR_INVALID_PARAMS, nil
+		}
+		update["name"] = name
+	}
+	if params.Events != nil {
+		kinds, ok := parseFeishuNotifyKinds(*params.Events)
+		if !ok {
+			return message.ERROR_FEISHU_NOTIFY_KIND_INVALID, nil
+		}
+		update["events"] = tools.MustJSONBytes(kinds)
+	}
+	if params.IsActive != nil {
+		update["is_active"] = *params.IsActive
+		if *params.IsActive {
+			update["last_error"] = nil
+		}
+	}
+
+	repo := repository.NewFeishuNotifyRepository(database.DB)
+	if len(update) > 0 {
+		if err := repo.UpdateChat(ctx, chat.ID, update); err != nil {
+			return database.IsError(err), nil
+		}
+	}
+	chat, err := repo.GetChat(ctx, chat.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"chat": chat.Data()}
+}
+
+func DeleteFeishuChat(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int) {
+	chat, responseCode := getFeishuChat(ctx, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode
+	}
+	if err := repository.NewFeishuNotifyRepository(database.DB).DeleteChat(ctx, chat.ID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
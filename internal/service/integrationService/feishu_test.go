@@ -0,0 +1,95 @@
+package integrationService_test
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/utils/tools"
+	"testing"
+)
+
+func seedFeishuApp(t *testing.T, verificationToken *string) {
+	t.Helper()
+	db := testutil.UseDB(t, &model.IntegrationApp{}, &model.NoteExternalLink{})
+	testutil.UseRedis(t)
+	app := model.IntegrationApp{
+		ID:                   1,
+		Provider:             string(model.ProviderFeishu),
+		AppID:                "cli_test",
+		AppSecretEnc:         "app-secret",
+		VerificationTokenEnc: verificationToken,
+		IsActive:             true,
+	}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func feishuEvent(t *testing.T, body map[string]any) *dto.FeishuEventDTO {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dto.FeishuEventDTO{Body: raw}
+}
+
+func TestHandleFeishuEventRejectsUnverifiableApp(t *testing.T) {
+	seedFeishuApp(t, nil)
+	params := feishuEvent(t, map[string]any{"type": "url_verification", "challenge": "c-1", "token": ""})
+	if code, _ := integrationService.HandleFeishuEvent(context.Background(), params); code != message.ERROR_FEISHU_INTEGRATION_NOT_CONFIGURED {
+		t.Fatalf("code = %d, want %d", code, message.ERROR_FEISHU_INTEGRATION_NOT_CONFIGURED)
+	}
+}
+
+func TestHandleFeishuEventVerificationToken(t *testing.T) {
+	seedFeishuApp(t, tools.Ptr("verify-token"))
+	dispatcher := testutil.UseDispatcher(t)
+
+	bad := feishuEvent(t, map[string]any{"type": "url_verification", "challenge": "c-1", "token": "guess"})
+	if code, _ := integrationService.HandleFeishuEvent(context.Background(), bad); code != message.ERROR_FEISHU_EVENT_TOKEN_INVALID {
+		t.Fatalf("bad token code = %d", code)
+	}
+
+	good := feishuEvent(t, map[string]any{"type": "url_verification", "challenge": "c-1", "token": "verify-token"})
+	code, data := integrationService.HandleFeishuEvent(context.Background(), good)
+	if code != message.SUCCESS || data["challenge"] != "c-1" {
+		t.Fatalf("url_verification code = %d data = %v", code, data)
+	}
+
+	// 文档编辑事件投递对应链接的拉取任务
+	link := model.NoteExternalLink{
+		NoteID:       40,
+		Provider:     model.ProviderFeishu,
+		TargetNoteID: "doxcn-1",
+		MemberID:     30,
+		Direction:    model.SyncTwoWay,
+		InitStatus:   model.InitReady,
+		IsActive:     true,
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	edit := feishuEvent(t, map[string]any{
+		"schema": "2.0",
+		"header": map[string]any{"event_type": "drive.file.edit_v1", "token": "verify-token"},
+		"event":  map[string]any{"file_token": "doxcn-1", "file_type": "docx"},
+	})
+	if code, _ := integrationService.HandleFeishuEvent(context.Background(), edit); code != message.SUCCESS {
+		t.Fatalf("file edit code = %d", code)
+	}
+	jobs := dispatcher.Jobs(types.SyncPullKey)
+	if len(jobs) != 1 {
+		t.Fatalf("pull jobs = %d, want 1", len(jobs))
+	}
+	var payload types.SyncPullPayload
+	if err := json.Unmarshal(jobs[0].Payload, &payload); err != nil || payload.LinkID != link.ID {
+		t.Fatalf("pull payload = %s", jobs[0].Payload)
+	}
+}
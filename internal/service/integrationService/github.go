@@ -0,0 +1,438 @@
+package integrationService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-notebook/configs"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/github"
+	"gin-notebook/internal/pkg/metrics"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+	"gin-notebook/pkg/utils/tools"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/morikuni/go-lexorank"
+	"gorm.io/gorm"
+)
+
+// CreateGitHubProjectLink 链接仓库并生成 webhook 密钥；配置了 GitHub 令牌时先校验仓库可访问
+func CreateGitHubProjectLink(ctx context.Context, params *dto.CreateGitHubProjectLinkDTO) (responseCode int, data map[string]interface{}) {
+	exists, err := repository.ProjectExistsByID(database.DB, params.ProjectID, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if !exists {
+		return message.ERROR_PROJECT_NOT_EXIST, nil
+	}
+
+	owner, name, ok := strings.Cut(strings.Trim(params.Repository, "/ "), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return message.ERROR_INVALID_PARAMS, nil
+	}
+	fullName, repoID := owner+"/"+name, ""
+	if configs.Configs.Github.Token != "" {
+		repo, err := metrics.GetRepository(ctx, owner, name)
+		if err != nil {
+			logger.LogError(err, "获取 Github 仓库失败 repo=", fullName)
+			return message.ERROR_GITHUB_REPO_NOT_FOUND, nil
+		}
+		fullName, repoID = repo.GetFullName(), strconv.FormatInt(repo.GetID(), 10)
+	}
+
+	repo := repository.NewProjectSyncRepository(database.DB)
+	links, err := repo.GetProjectLinks(ctx, params.ProjectID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	for _, l := range links {
+		if l.Provider == model.ProviderGitHub && strings.EqualFold(l.ExternalProjectKey, fullName) {
+			return message.ERROR_GITHUB_LINK_EXISTS, nil
+		}
+	}
+
+	mappings := make([]model.TaskStatusMapping, 0, 1)
+	checklistRepo := repository.NewChecklistRepository(database.DB)
+	if params.MergeColumnID != 0 {
+		column, err := checklistRepo.GetColumn(ctx, params.ProjectID, params.MergeColumnID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return message.ERROR_GITHUB_MAPPING_INVALID, nil
+			}
+			return database.IsError(err), nil
+		}
+		mappings = append(mappings, model.TaskStatusMapping{ColumnID: column.ID, StatusID: github.StateMerged, StatusName: column.Name})
+	} else if column, err := checklistRepo.GetProcessColumn(ctx, params.ProjectID, model.ProcessDone); err == nil {
+		mappings = append(mappings, model.TaskStatusMapping{ColumnID: column.ID, StatusID: github.StateMerged, StatusName: column.Name})
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return message.ERROR_INTERNAL_SERVER, nil
+	}
+	link := &model.ProjectExternalLink{
+		ProjectID:          params.ProjectID,
+		Provider:           model.ProviderGitHub,
+		ExternalProjectID:  repoID,
+		ExternalProjectKey: fullName,
+		MemberID:           params.MemberID,
+		Direction:          model.SyncPullOnly, // 只接收仓库事件
+		StatusMapping:      tools.MustJSONBytes(mappings),
+		WebhookSecret:      secret,
+		IsActive:           true,
+		LastStatus:         model.SyncIdle,
+	}
+	if err := repo.CreateProjectLink(ctx, link); err != nil {
+		return database.IsError(err), nil
+	}
+
+	data = link.Data()
+	// secret 只在创建时返回一次，用于在仓库设置中添加 webhook（content type 选 application/json）
+	data["webhook_secret"] = link.WebhookSecret
+	data["webhook_path"] = fmt.Sprintf("/api/v1/integration/events/github/%d", link.ID)
+	return message.SUCCESS, map[string]interface{}{"link": data}
+}
+
+func GetGitHubProjectLinks(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int, data map[string]interface{}) {
+	list, responseCode := getProjectLinks(ctx, model.ProviderGitHub, params.WorkspaceID, params.ProjectID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+	return message.SUCCESS, map[string]interface{}{"links": list}
+}
+
+// UpdateGitHubProjectLink 列映射的键只能是 PR 状态，每个状态最多对应一列
+func UpdateGitHubProjectLink(ctx context.Context, params *dto.UpdateGitHubProjectLinkDTO) (responseCode int, data map[string]interface{}) {
+	link, responseCode := getProjectLink(ctx, model.ProviderGitHub, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+
+	update := make(map[string]interface{})
+	if params.IsActive != nil {
+		update["is_active"] = *params.IsActive
+	}
+	if params.StatusMapping != nil {
+		seen := make(map[string]bool, len(*params.StatusMapping))
+		for _, m := range *params.StatusMapping {
+			if !tools.Contains(github.MappableStates, m.StatusID) || seen[m.StatusID] {
+				return message.ERROR_GITHUB_MAPPING_INVALID, nil
+			}
+			seen[m.StatusID] = true
+		}
+		valid, err := validateColumnMapping(link.ProjectID, *params.StatusMapping)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		if !valid {
+			return message.ERROR_GITHUB_MAPPING_INVALID, nil
+		}
+		update["status_mapping"] = tools.MustJSONBytes(*params.StatusMapping)
+	}
+	if len(update) == 0 {
+		return message.SUCCESS, map[string]interface{}{"link": link.Data()}
+	}
+
+	repo := repository.NewProjectSyncRepository(database.DB)
+	if err := repo.UpdateProjectLink(ctx, link.ID, update); err != nil {
+		return database.IsError(err), nil
+	}
+	link, err := repo.GetProjectLink(ctx, link.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"link": link.Data()}
+}
+
+// DeleteGitHubProjectLink 解除链接，已记录的引用一并删除，任务动态保留
+func DeleteGitHubProjectLink(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int) {
+	link, responseCode := getProjectLink(ctx, model.ProviderGitHub, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode
+	}
+	if err := repository.NewProjectSyncRepository(database.DB).DeleteProjectLink(ctx, link.ID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
+
+// HandleGitHubWebhook 记录提交、PR 与 issue 对任务的引用并写入任务动态；
+// PR 状态变化时把关联任务移动到映射的列
+func HandleGitHubWebhook(ctx context.Context, params *dto.GitHubWebhookDTO) (responseCode int) {
+	repo := repository.NewProjectSyncRepository(database.DB)
+	link, err := repo.GetProjectLink(ctx, params.LinkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_GITHUB_LINK_NOT_FOUND
+		}
+		return database.IsError(err)
+	}
+	if link.Provider != model.ProviderGitHub {
+		return message.ERROR_GITHUB_LINK_NOT_FOUND
+	}
+	if !github.VerifySignature(link.WebhookSecret, params.Body, params.Signature) {
+		return message.ERROR_GITHUB_SIGN_INVALID
+	}
+
+	event, err := github.ParseEvent(params.Event, params.Body)
+	if errors.Is(err, github.ErrUnsupportedEvent) {
+		return message.SUCCESS
+	}
+	if err != nil {
+		logger.LogError(err, "解析 Github webhook 失败 delivery=", params.Delivery)
+		return message.ERROR_GITHUB_EVENT_INVALID
+	}
+	if !link.IsActive || !sameRepository(event, link) {
+		return message.SUCCESS
+	}
+
+	h, err := newGitHubEventHandler(ctx, link, event)
+	if err != nil {
+		logger.LogError(err, "准备 Github 事件处理失败 link=", link.ID)
+		return database.IsError(err)
+	}
+	syncErr := h.handle(ctx)
+	status := map[string]interface{}{
+		"last_status":    model.SyncSuccess,
+		"last_error":     nil,
+		"last_synced_at": time.Now(),
+	}
+	if syncErr != nil {
+		logger.LogError(syncErr, "处理 Github 事件失败 delivery=", params.Delivery)
+		status["last_status"] = model.SyncFailed
+		status["last_error"] = syncErr.Error()
+	}
+	if err := repo.UpdateProjectLink(ctx, link.ID, status); err != nil {
+		logger.LogError(err, "更新链接状态失败 link=", link.ID)
+	}
+	if syncErr != nil {
+		return database.IsError(syncErr)
+	}
+	return message.SUCCESS
+}
+
+// sameRepository 仓库改名后 full_name 会变化，记录了仓库 ID 时以 ID 为准
+func sameRepository(event *github.Event, link *model.ProjectExternalLink) bool {
+	if link.ExternalProjectID != "" && event.RepoID != 0 {
+		return link.ExternalProjectID == strconv.FormatInt(event.RepoID, 10)
+	}
+	return strings.EqualFold(link.ExternalProjectKey, event.RepoName)
+}
+
+type gitHubEventHandler struct {
+	link        *model.ProjectExternalLink
+	event       *github.Event
+	userID      int64
+	workspaceID int64
+}
+
+func newGitHubEventHandler(ctx context.Context, link *model.ProjectExternalLink, event *github.Event) (*gitHubEventHandler, error) {
+	member, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	workspaceID, err := repository.NewProjectSyncRepository(database.DB).GetProjectWorkspaceID(ctx, link.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return &gitHubEventHandler{link: link, event: event, userID: member.UserID, workspaceID: workspaceID}, nil
+}
+
+func (h *gitHubEventHandler) handle(ctx context.Context) error {
+	repo := repository.NewProjectSyncRepository(database.DB)
+	for _, ref := range h.event.References {
+		taskIDs := ref.TaskIDs
+		if ref.Kind != model.ExtTypeCommit {
+			// PR、issue 的后续事件（如合并）不一定再次带有引用
+			known, err := repo.GetReferencedTaskIDs(ctx, h.link.ID, ref.Kind, ref.ExternalID)
+			if err != nil {
+				return err
+			}
+			taskIDs = mergeTaskIDs(taskIDs, known)
+		}
+
+		for _, taskID := range taskIDs {
+			task, err := repository.GetProjectTaskByID(database.DB, taskID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// 只处理链接项目内的任务，引用其他项目的任务 ID 直接忽略
+			if task.ProjectID != h.link.ProjectID {
+				continue
+			}
+			if err := h.recordReference(ctx, task, ref); err != nil {
+				return err
+			}
+			if ref.Kind == model.ExtTypePull {
+				if err := h.moveTask(ctx, task, ref); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// recordReference 同一对象重复投递时只在首次引用或状态变化时写入动态
+func (h *gitHubEventHandler) recordReference(ctx context.Context, task *model.ToDoTask, ref github.Reference) error {
+	repo := repository.NewProjectSyncRepository(database.DB)
+	existing, err := repo.GetReference(ctx, h.link.ID, task.ID, ref.Kind, ref.ExternalID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.State == ref.State && existing.Title == ref.Title {
+		return nil
+	}
+	changed := existing == nil || existing.State != ref.State
+	if existing == nil {
+		existing = &model.TaskExternalReference{
+			ProjectLinkID: h.link.ID,
+			TaskID:        task.ID,
+			Kind:          ref.Kind,
+			ExternalID:    ref.ExternalID,
+		}
+	}
+	existing.Title = truncateText(ref.Title, 255)
+	existing.URL = truncateText(ref.URL, 512)
+	existing.State = ref.State
+	if err := repo.SaveReference(ctx, existing); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	h.emitActivity(ctx, model.RefAction, task, nil, map[string]interface{}{
+		"provider":    model.ProviderGitHub,
+		"repository":  h.event.RepoName,
+		"kind":        ref.Kind,
+		"external_id": ref.ExternalID,
+		"title":       ref.Title,
+		"url":         ref.URL,
+		"author":      ref.Author,
+		"state":       ref.State,
+	})
+	return nil
+}
+
+// moveTask PR 状态有映射的列时把任务移到该列顶部
+func (h *gitHubEventHandler) moveTask(ctx context.Context, task *model.ToDoTask, ref github.Reference) error {
+	var columnID int64
+	for _, m := range h.link.StatusMappings() {
+		if m.StatusID == ref.State {
+			columnID = m.ColumnID
+			break
+		}
+	}
+	if columnID == 0 || columnID == task.ColumnID {
+		return nil
+	}
+	column, err := repository.NewChecklistRepository(database.DB).GetColumn(ctx, h.link.ProjectID, columnID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.LogInfo(fmt.Sprintf("[github] link=%d 映射的列 %d 已不存在", h.link.ID, columnID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	status := model.TaskStatusPending
+	switch column.ProcessID {
+	case model.ProcessDone:
+		status = model.TaskStatusCompleted
+	case model.ProcessDoing:
+		status = model.TaskStatusInProgress
+	}
+	origin := *task
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewProjectSyncRepository(tx)
+		if _, err := txRepo.GetTaskForUpdate(ctx, task.ID); err != nil {
+			return err
+		}
+		upper := algorithm.RankMax()
+		if first, err := repository.GetFirstTask(tx, column.ID, repository.WithLock()); err == nil {
+			upper = lexorank.BucketKey(first.OrderIndex)
+		}
+		return txRepo.UpdateTask(ctx, task.ID, map[string]interface{}{
+			"column_id":   column.ID,
+			"order_index": algorithm.RankBetweenBucket(algorithm.RankMin(), upper).String(),
+			"status":      status,
+			"updated_at":  time.Now(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	task.ColumnID, task.Status = column.ID, status
+
+	h.emitActivity(ctx, model.UpdateAction, &origin, &origin, map[string]interface{}{
+		"column_id": column.ID,
+		"status":    status,
+	})
+	DispatchTaskIssueSync(ctx, task.ID)
+	webhookService.Dispatch(ctx, h.workspaceID, task.ProjectID, model.WebhookTaskMoved, map[string]interface{}{
+		"task_id":        strconv.FormatInt(task.ID, 10),
+		"project_id":     strconv.FormatInt(task.ProjectID, 10),
+		"from_column_id": strconv.FormatInt(origin.ColumnID, 10),
+		"to_column_id":   strconv.FormatInt(column.ID, 10),
+		"actor_id":       strconv.FormatInt(h.userID, 10),
+		"source":         model.ProviderGitHub,
+	})
+	return nil
+}
+
+func (h *gitHubEventHandler) emitActivity(ctx context.Context, action model.KanbanAction, task *model.ToDoTask, origin *model.ToDoTask, patch map[string]interface{}) {
+	success := true
+	code := message.SUCCESS
+	payload := types.KanbanActivityPayload{
+		MemberID:    h.link.MemberID,
+		ActorID:     h.userID,
+		Success:     &success,
+		SuccessCode: &code,
+		ProjectID:   &task.ProjectID,
+		TaskID:      &task.ID,
+		ColumnID:    &task.ColumnID,
+		WorkspaceID: h.workspaceID,
+		Patch:       patch,
+		Action:      action,
+		TargetType:  model.TargetTask,
+		TargetID:    task.ID,
+	}
+	if origin != nil {
+		payload.OriginData = *origin
+	}
+	enqueue.KanbanActivityJob(ctx, payload)
+}
+
+func mergeTaskIDs(a, b []int64) []int64 {
+	seen := make(map[int64]bool, len(a)+len(b))
+	out := make([]int64, 0, len(a)+len(b))
+	for _, id := range append(append([]int64{}, a...), b...) {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func truncateText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
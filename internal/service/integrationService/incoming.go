@@ -0,0 +1,186 @@
+package integrationService
+
+import (
+	"context"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/utils/tools"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// incomingStatusID 目标列映射的状态键，接收 webhook 只有这一条映射
+const incomingStatusID = "incoming"
+
+func incomingHookPath(linkID string) string {
+	return "/api/v1/integration/events/incoming/" + linkID
+}
+
+// incomingColumnMapping 目标列必须属于该项目
+func incomingColumnMapping(ctx context.Context, projectID, columnID int64) ([]model.TaskStatusMapping, int) {
+	column, err := repository.NewChecklistRepository(database.DB).GetColumn(ctx, projectID, columnID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, message.ERROR_INCOMING_COLUMN_INVALID
+		}
+		return nil, database.IsError(err)
+	}
+	return []model.TaskStatusMapping{{ColumnID: column.ID, StatusID: incomingStatusID, StatusName: column.Name}}, message.SUCCESS
+}
+
+// incomingNameTaken 同一项目内接收地址名称不区分大小写唯一
+func incomingNameTaken(ctx context.Context, projectID, exceptID int64, name string) (bool, error) {
+	links, err := repository.NewProjectSyncRepository(database.DB).GetProjectLinks(ctx, projectID)
+	if err != nil {
+		return false, err
+	}
+	for _, l := range links {
+		if l.Provider == model.ProviderHook && l.ID != exceptID && strings.EqualFold(l.ExternalProjectKey, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateIncomingHook 生成接收地址与令牌；令牌只在创建或重新生成时返回
+func CreateIncomingHook(ctx context.Context, params *dto.CreateIncomingHookDTO) (responseCode int, data map[string]interface{}) {
+	exists, err := repository.ProjectExistsByID(database.DB, params.ProjectID, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if !exists {
+		return message.ERROR_PROJECT_NOT_EXIST, nil
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return message.ERROR_INVALID_PARAMS, nil
+	}
+	taken, err := incomingNameTaken(ctx, params.ProjectID, 0, name)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if taken {
+		return message.ERROR_INCOMING_EXISTS, nil
+	}
+	mappings, responseCode := incomingColumnMapping(ctx, params.ProjectID, params.ColumnID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+
+	token, err := newWebhookSecret()
+	if err != nil {
+		return message.ERROR_INTERNAL_SERVER, nil
+	}
+	link := &model.ProjectExternalLink{
+		ProjectID:          params.ProjectID,
+		Provider:           model.ProviderHook,
+		ExternalProjectKey: name,
+		MemberID:           params.MemberID, // 推送创建的任务以该成员为创建者
+		Direction:          model.SyncPullOnly,
+		StatusMapping:      tools.MustJSONBytes(mappings),
+		WebhookSecret:      token,
+		IsActive:           true,
+		LastStatus:         model.SyncIdle,
+	}
+	if err := repository.NewProjectSyncRepository(database.DB).CreateProjectLink(ctx, link); err != nil {
+		return database.IsError(err), nil
+	}
+
+	data = link.Data()
+	data["token"] = link.WebhookSecret
+	data["webhook_path"] = incomingHookPath(strconv.FormatInt(link.ID, 10))
+	return message.SUCCESS, map[string]interface{}{"link": data}
+}
+
+func GetIncomingHooks(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int, data map[string]interface{}) {
+	list, responseCode := getProjectLinks(ctx, model.ProviderHook, params.WorkspaceID, params.ProjectID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+	for _, item := range list {
+		if id, ok := item["id"].(string); ok {
+			item["webhook_path"] = incomingHookPath(id)
+		}
+	}
+	return message.SUCCESS, map[string]interface{}{"links": list}
+}
+
+func UpdateIncomingHook(ctx context.Context, params *dto.UpdateIncomingHookDTO) (responseCode int, data map[string]interface{}) {
+	link, responseCode := getProjectLink(ctx, model.ProviderHook, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+
+	update := make(map[string]interface{})
+	if params.Name != nil {
+		name := strings.TrimSpace(*params.Name)
+		if name == "" {
+			return message.ERROR_INVALID_PARAMS, nil
+		}
+		taken, err := incomingNameTaken(ctx, link.ProjectID, link.ID, name)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		if taken {
+			return message.ERROR_INCOMING_EXISTS, nil
+		}
+		update["external_project_key"] = name
+	}
+	if params.ColumnID != nil {
+		mappings, responseCode := incomingColumnMapping(ctx, link.ProjectID, *params.ColumnID)
+		if responseCode != message.SUCCESS {
+			return responseCode, nil
+		}
+		update["status_mapping"] = tools.MustJSONBytes(mappings)
+	}
+	if params.IsActive != nil {
+		update["is_active"] = *params.IsActive
+	}
+
+	repo := repository.NewProjectSyncRepository(database.DB)
+	if len(update) > 0 {
+		if err := repo.UpdateProjectLink(ctx, link.ID, update); err != nil {
+			return database.IsError(err), nil
+		}
+	}
+	var token string
+	if params.RegenerateToken {
+		var err error
+		if token, err = newWebhookSecret(); err != nil {
+			return message.ERROR_INTERNAL_SERVER, nil
+		}
+		if err := repo.UpdateProjectLinkSecret(ctx, link.ID, token); err != nil {
+			return database.IsError(err), nil
+		}
+	}
+
+	link, err := repo.GetProjectLink(ctx, link.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	data = link.Data()
+	data["webhook_path"] = incomingHookPath(strconv.FormatInt(link.ID, 10))
+	if token != "" {
+		data["token"] = token
+	}
+	return message.SUCCESS, map[string]interface{}{"link": data}
+}
+
+// DeleteIncomingHook 删除接收地址与外部键记录，已创建的任务保留
+func DeleteIncomingHook(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int) {
+	link, responseCode := getProjectLink(ctx, model.ProviderHook, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode
+	}
+	if err := repository.NewProjectSyncRepository(database.DB).DeleteProjectLink(ctx, link.ID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
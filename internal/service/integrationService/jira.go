@@ -0,0 +1,358 @@
+package integrationService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/jira"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/tasks/contracts"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/tools"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// defaultJiraPriorityMapping Jira 默认优先级方案中的同名优先级
+var defaultJiraPriorityMapping = map[string]string{
+	"low":    "Low",
+	"medium": "Medium",
+	"high":   "High",
+}
+
+// LinkJiraAccount 绑定 Jira 账号，先调用 /myself 校验凭据有效
+func LinkJiraAccount(ctx context.Context, params *dto.JiraTokenLinkDTO) (responseCode int, data map[string]interface{}) {
+	client := jira.NewClient(params.SiteURL, params.Email, params.Token)
+	user, err := client.Myself(ctx)
+	if err != nil {
+		logger.LogError(err, "校验 Jira 凭据失败")
+		return message.ERROR_JIRA_TOKEN_INVALID, nil
+	}
+
+	account := &model.IntegrationAccount{
+		UserID:         params.UserID,
+		Provider:       model.ProviderJira,
+		AccountID:      &user.AccountID,
+		AccountName:    &user.DisplayName,
+		AuthType:       model.AuthAPIKey,
+		AccessTokenEnc: params.Token,
+		Extra:          tools.MustJSONBytes(jira.AccountExtra{SiteURL: client.SiteURL(), Email: params.Email}),
+		IsActive:       true,
+	}
+	if err := repository.NewIntegrationRepository(database.DB).BindIntegrationAccount(ctx, account); err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"account": account.Data()}
+}
+
+// CreateJiraProjectLink 使用当前用户绑定的 Jira 账号链接项目，按列的流程阶段生成默认状态映射，
+// 创建后把项目现有任务逐个投递首次同步
+func CreateJiraProjectLink(ctx context.Context, params *dto.CreateJiraProjectLinkDTO) (responseCode int, data map[string]interface{}) {
+	exists, err := repository.ProjectExistsByID(database.DB, params.ProjectID, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if !exists {
+		return message.ERROR_PROJECT_NOT_EXIST, nil
+	}
+
+	client, err := jira.GetClientForUser(ctx, params.UserID)
+	if err != nil {
+		logger.LogError(err, "获取 Jira 客户端失败")
+		return message.ERROR_INTEGRATION_ACCOUNT_NOT_EXIST, nil
+	}
+	project, err := client.GetProject(ctx, params.ProjectKey)
+	if err != nil {
+		if jira.IsUnauthorized(err) {
+			return message.ERROR_JIRA_TOKEN_INVALID, nil
+		}
+		logger.LogError(err, "获取 Jira 项目失败")
+		return message.ERROR_JIRA_PROJECT_NOT_FOUND, nil
+	}
+	myself, err := client.Myself(ctx)
+	if err != nil {
+		return message.ERROR_JIRA_TOKEN_INVALID, nil
+	}
+
+	repo := repository.NewProjectSyncRepository(database.DB)
+	links, err := repo.GetProjectLinks(ctx, params.ProjectID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	for _, l := range links {
+		if l.Provider == model.ProviderJira && l.ExternalProjectKey == project.Key {
+			return message.ERROR_JIRA_LINK_EXISTS, nil
+		}
+	}
+
+	statusMapping, err := defaultJiraStatusMapping(ctx, client, params.ProjectID, project.Key)
+	if err != nil {
+		logger.LogError(err, "生成 Jira 状态映射失败")
+		return message.ERROR_JIRA_PROJECT_NOT_FOUND, nil
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return message.ERROR_INTERNAL_SERVER, nil
+	}
+	direction := params.Direction
+	if direction == "" {
+		direction = model.SyncTwoWay
+	}
+
+	link := &model.ProjectExternalLink{
+		ProjectID:          params.ProjectID,
+		Provider:           model.ProviderJira,
+		ExternalProjectID:  project.ID,
+		ExternalProjectKey: project.Key,
+		MemberID:           params.MemberID,
+		Direction:          direction,
+		StatusMapping:      tools.MustJSONBytes(statusMapping),
+		PriorityMapping:    tools.MustJSONBytes(defaultJiraPriorityMapping),
+		AssigneeMapping:    tools.MustJSONBytes([]model.TaskAssigneeMapping{{MemberID: params.MemberID, AccountID: myself.AccountID}}),
+		WebhookSecret:      secret,
+		IsActive:           true,
+		LastStatus:         model.SyncIdle,
+	}
+	if err := repo.CreateProjectLink(ctx, link); err != nil {
+		return database.IsError(err), nil
+	}
+
+	if direction != model.SyncPullOnly {
+		taskIDs, err := repo.GetProjectTaskIDs(ctx, params.ProjectID)
+		if err != nil {
+			logger.LogError(err, "获取项目任务失败 project=", params.ProjectID)
+		}
+		for _, taskID := range taskIDs {
+			if _, err := enqueue.JiraTaskSync(ctx, types.JiraTaskSyncPayload{ProjectLinkID: link.ID, TaskID: taskID}); err != nil {
+				logger.LogError(err, "投递 Jira 首次同步失败 task=", taskID)
+			}
+		}
+	}
+
+	data = link.Data()
+	// secret 只在创建时返回一次，用于在 Jira 中注册 webhook
+	data["webhook_secret"] = link.WebhookSecret
+	data["webhook_path"] = fmt.Sprintf("/api/v1/integration/events/jira/%d", link.ID)
+	return message.SUCCESS, map[string]interface{}{"link": data}
+}
+
+func GetJiraProjectLinks(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int, data map[string]interface{}) {
+	list, responseCode := getProjectLinks(ctx, model.ProviderJira, params.WorkspaceID, params.ProjectID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+	return message.SUCCESS, map[string]interface{}{"links": list}
+}
+
+// UpdateJiraProjectLink 映射中的列必须属于该项目，优先级键必须是本地优先级名称
+func UpdateJiraProjectLink(ctx context.Context, params *dto.UpdateJiraProjectLinkDTO) (responseCode int, data map[string]interface{}) {
+	link, responseCode := getProjectLink(ctx, model.ProviderJira, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+
+	update := make(map[string]interface{})
+	if params.Direction != nil {
+		update["direction"] = *params.Direction
+	}
+	if params.IsActive != nil {
+		update["is_active"] = *params.IsActive
+	}
+	if params.StatusMapping != nil {
+		valid, err := validateColumnMapping(link.ProjectID, *params.StatusMapping)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		if !valid {
+			return message.ERROR_JIRA_MAPPING_INVALID, nil
+		}
+		update["status_mapping"] = tools.MustJSONBytes(*params.StatusMapping)
+	}
+	if params.PriorityMapping != nil {
+		for local, remote := range *params.PriorityMapping {
+			if _, ok := model.StringToPriority[local]; !ok || local == "" || remote == "" {
+				return message.ERROR_JIRA_MAPPING_INVALID, nil
+			}
+		}
+		update["priority_mapping"] = tools.MustJSONBytes(*params.PriorityMapping)
+	}
+	if params.AssigneeMapping != nil {
+		for _, m := range *params.AssigneeMapping {
+			if m.MemberID == 0 || m.AccountID == "" {
+				return message.ERROR_JIRA_MAPPING_INVALID, nil
+			}
+		}
+		update["assignee_mapping"] = tools.MustJSONBytes(*params.AssigneeMapping)
+	}
+	if len(update) == 0 {
+		return message.SUCCESS, map[string]interface{}{"link": link.Data()}
+	}
+
+	repo := repository.NewProjectSyncRepository(database.DB)
+	if err := repo.UpdateProjectLink(ctx, link.ID, update); err != nil {
+		return database.IsError(err), nil
+	}
+	link, err := repo.GetProjectLink(ctx, link.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"link": link.Data()}
+}
+
+// DeleteJiraProjectLink 解除链接，两侧的任务与问题都保留
+func DeleteJiraProjectLink(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int) {
+	link, responseCode := getProjectLink(ctx, model.ProviderJira, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode
+	}
+	if err := repository.NewProjectSyncRepository(database.DB).DeleteProjectLink(ctx, link.ID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
+
+// GetJiraProjectStatuses 外部项目的工作流状态，供配置状态映射
+func GetJiraProjectStatuses(ctx context.Context, params *dto.ProjectLinkQueryDTO) (responseCode int, data map[string]interface{}) {
+	link, responseCode := getProjectLink(ctx, model.ProviderJira, params.WorkspaceID, params.ProjectID, params.LinkID)
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+	member, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	client, err := jira.GetClientForUser(ctx, member.UserID)
+	if err != nil {
+		return message.ERROR_INTEGRATION_ACCOUNT_NOT_EXIST, nil
+	}
+	statuses, err := client.GetProjectStatuses(ctx, link.ExternalProjectKey)
+	if err != nil {
+		if jira.IsUnauthorized(err) {
+			return message.ERROR_JIRA_TOKEN_INVALID, nil
+		}
+		return message.ERROR_JIRA_PROJECT_NOT_FOUND, nil
+	}
+	return message.SUCCESS, map[string]interface{}{"issue_types": statuses}
+}
+
+// HandleJiraWebhook 校验签名后按问题投递同步，评论事件同样触发整条问题的同步
+func HandleJiraWebhook(ctx context.Context, params *dto.JiraWebhookDTO) (responseCode int) {
+	link, err := repository.NewProjectSyncRepository(database.DB).GetProjectLink(ctx, params.LinkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_JIRA_LINK_NOT_FOUND
+		}
+		return database.IsError(err)
+	}
+	if link.Provider != model.ProviderJira {
+		return message.ERROR_JIRA_LINK_NOT_FOUND
+	}
+	if !jira.VerifySignature(link.WebhookSecret, params.Body, params.Signature) {
+		return message.ERROR_JIRA_WEBHOOK_SIGNATURE_INVALID
+	}
+
+	event, err := jira.ParseWebhook(params.Body)
+	if err != nil {
+		logger.LogError(err, "解析 Jira webhook 失败")
+		return message.ERROR_JIRA_WEBHOOK_INVALID
+	}
+	if !link.IsActive || link.Direction == model.SyncPushOnly || event.Issue == nil || event.Issue.ID == "" {
+		return message.SUCCESS
+	}
+	if !jiraIssueInProject(event.Issue, link) {
+		return message.SUCCESS
+	}
+
+	payload := types.JiraTaskSyncPayload{
+		ProjectLinkID: link.ID,
+		IssueID:       event.Issue.ID,
+		Remote:        true,
+		Deleted:       event.WebhookEvent == jira.EventIssueDeleted,
+	}
+	opts := make([]contracts.Option, 0, 1)
+	if event.WebhookEvent == jira.EventIssueCreated {
+		// 本地推送新建的问题也会回调 issue_created，延后处理，等待推送方写入映射
+		opts = append(opts, contracts.WithDelay(10))
+	}
+	if _, err := enqueue.JiraTaskSync(ctx, payload, opts...); err != nil {
+		logger.LogError(err, "投递 Jira 同步任务失败 link=", link.ID)
+		return message.ERROR_INTERNAL_SERVER
+	}
+	return message.SUCCESS
+}
+
+// DispatchTaskIssueSync 本地任务或评论修改后，投递到任务所在项目上允许推送的链接
+func DispatchTaskIssueSync(ctx context.Context, taskID int64) {
+	links, err := repository.NewProjectSyncRepository(database.DB).GetActiveProjectLinksByTask(ctx, taskID)
+	if err != nil {
+		logger.LogError(err, "获取任务的外部链接失败 task=", taskID)
+		return
+	}
+	for _, link := range links {
+		if link.Provider != model.ProviderJira || link.Direction == model.SyncPullOnly {
+			continue
+		}
+		if _, err := enqueue.JiraTaskSync(ctx, types.JiraTaskSyncPayload{ProjectLinkID: link.ID, TaskID: taskID}); err != nil {
+			logger.LogError(err, "投递 Jira 同步任务失败 task=", taskID)
+		}
+	}
+}
+
+// defaultJiraStatusMapping 每一列取与其流程阶段同分类的第一个状态
+func defaultJiraStatusMapping(ctx context.Context, client *jira.Client, projectID int64, projectKey string) ([]model.TaskStatusMapping, error) {
+	issueTypes, err := client.GetProjectStatuses(ctx, projectKey)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]jira.Status, 0)
+	for _, t := range issueTypes {
+		if t.Name == jira.DefaultIssueType {
+			statuses = t.Statuses
+			break
+		}
+	}
+	if len(statuses) == 0 {
+		for _, t := range issueTypes {
+			statuses = append(statuses, t.Statuses...)
+		}
+	}
+
+	columns, err := repository.GetProjectColumnsByProjectID(database.DB, projectID, nil)
+	if err != nil {
+		return nil, err
+	}
+	categories := map[uint8]string{
+		model.ProcessTodo:  jira.CategoryNew,
+		model.ProcessDoing: jira.CategoryInProgress,
+		model.ProcessDone:  jira.CategoryDone,
+	}
+	mappings := make([]model.TaskStatusMapping, 0, len(columns))
+	for _, column := range columns {
+		for i := range statuses {
+			if statuses[i].CategoryKey() == categories[column.ProcessID] {
+				mappings = append(mappings, model.TaskStatusMapping{
+					ColumnID:   column.ID,
+					StatusID:   statuses[i].ID,
+					StatusName: statuses[i].Name,
+				})
+				break
+			}
+		}
+	}
+	return mappings, nil
+}
+
+// jiraIssueInProject webhook 按站点注册时会收到其他项目的问题
+func jiraIssueInProject(issue *jira.Issue, link *model.ProjectExternalLink) bool {
+	if issue.Fields.Project != nil {
+		return issue.Fields.Project.ID == link.ExternalProjectID || issue.Fields.Project.Key == link.ExternalProjectKey
+	}
+	return strings.HasPrefix(issue.Key, link.ExternalProjectKey+"-")
+}
@@ -2,33 +2,71 @@ package integrationService
 
 import (
 	"context"
+	"encoding/json"
+	"gin-notebook/internal/http/message"
 	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/notion"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
 )
 
-type NotionService interface {
-	// 绑定：将 note 与某个 notion page 关联起来
-	LinkNoteToPage(ctx context.Context, noteID int64, notionPageID string, linkedByUserID int64, policy model.ConflictPolicy) error
-	// 取消绑定
-	UnlinkNote(ctx context.Context, noteID int64) error
-	// 同步
-	SyncNote(ctx context.Context, noteID int64, mode SyncMode) error
-	// 批量补充派生字段（便于列表展示）
-	AttachNotionPageIDs(ctx context.Context, notes []*model.Note) error
-	UnlinkAccount(ctx context.Context, userID int64, provider model.IntegrationProvider, accountID string) error
-	LinkAccount(ctx context.Context, userID int64, provider model.IntegrationProvider, accountID string) (*model.IntegrationAccount, error)
-}
+// LinkNotionAccount 绑定内部集成令牌，先调用 /users/me 校验令牌有效
+func LinkNotionAccount(ctx context.Context, params *dto.NotionTokenLinkDTO) (responseCode int, data map[string]interface{}) {
+	bot, err := notion.GetClient().Me(ctx, params.Token)
+	if err != nil {
+		logger.LogError(err, "校验 Notion 令牌失败")
+		return message.ERROR_NOTION_TOKEN_INVALID, nil
+	}
 
-type notionService struct{}
+	account := &model.IntegrationAccount{
+		UserID:         params.UserID,
+		Provider:       model.ProviderNotion,
+		AccountID:      &bot.ID,
+		AuthType:       model.AuthAPIKey,
+		AccessTokenEnc: params.Token,
+		IsActive:       true,
+	}
+	if bot.Bot != nil && bot.Bot.WorkspaceName != "" {
+		account.AccountName = &bot.Bot.WorkspaceName
+	}
 
-func NewNotionService() *notionService {
-	return &notionService{}
+	if err := repository.NewIntegrationRepository(database.DB).BindIntegrationAccount(ctx, account); err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"account": account.Data()}
 }
 
-func (s *notionService) LinkNoteToPage(ctx context.Context) error {
-	return nil
-}
+// HandleNotionOAuthCallback 用授权码换取令牌并绑定；Notion 的令牌不会过期，不记录过期时间
+func HandleNotionOAuthCallback(ctx context.Context, params *dto.NotionOAuthCallbackDTO) (responseCode int, data interface{}) {
+	c := notion.GetClient()
+	if !c.OAuthConfigured() {
+		return message.ERROR_INTEGRATION_APP_NOT_FOUND, nil
+	}
+
+	token, err := c.ExchangeCode(ctx, params.Code, params.RedirectURI)
+	if err != nil {
+		logger.LogError(err, "Notion 授权码换取令牌失败")
+		return message.ERROR_NOTION_OAUTH_FAILED, nil
+	}
 
-func (s *notionService) LinkAccount(ctx context.Context, params dto.IntegrationAccountCreateDTO) error {
-	return nil
+	extra, _ := json.Marshal(map[string]string{
+		"bot_id":       token.BotID,
+		"workspace_id": token.WorkspaceID,
+	})
+	account := &model.IntegrationAccount{
+		UserID:         params.UserID,
+		Provider:       model.ProviderNotion,
+		AccountID:      &token.WorkspaceID,
+		AccountName:    &token.WorkspaceName,
+		AuthType:       model.AuthOAuth2,
+		AccessTokenEnc: token.AccessToken,
+		Extra:          extra,
+		IsActive:       true,
+	}
+	if err := repository.NewIntegrationRepository(database.DB).BindIntegrationAccount(ctx, account); err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, nil
 }
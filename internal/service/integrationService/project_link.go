@@ -0,0 +1,103 @@
+package integrationService
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// linkNotFoundCode 各平台链接不存在时的错误码
+var linkNotFoundCode = map[model.IntegrationProvider]int{
+	model.ProviderJira:   message.ERROR_JIRA_LINK_NOT_FOUND,
+	model.ProviderGitHub: message.ERROR_GITHUB_LINK_NOT_FOUND,
+	model.ProviderHook:   message.ERROR_INCOMING_NOT_FOUND,
+}
+
+// getProjectLink 校验项目属于工作区、链接属于项目且平台一致
+func getProjectLink(ctx context.Context, provider model.IntegrationProvider, workspaceID, projectID, linkID int64) (*model.ProjectExternalLink, int) {
+	exists, err := repository.ProjectExistsByID(database.DB, projectID, workspaceID)
+	if err != nil {
+		return nil, database.IsError(err)
+	}
+	if !exists {
+		return nil, message.ERROR_PROJECT_NOT_EXIST
+	}
+	link, err := repository.NewProjectSyncRepository(database.DB).GetProjectLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, linkNotFoundCode[provider]
+		}
+		return nil, database.IsError(err)
+	}
+	if link.ProjectID != projectID || link.Provider != provider {
+		return nil, linkNotFoundCode[provider]
+	}
+	return link, message.SUCCESS
+}
+
+// getProjectLinks 项目上指定平台的链接
+func getProjectLinks(ctx context.Context, provider model.IntegrationProvider, workspaceID, projectID int64) ([]map[string]interface{}, int) {
+	exists, err := repository.ProjectExistsByID(database.DB, projectID, workspaceID)
+	if err != nil {
+		return nil, database.IsError(err)
+	}
+	if !exists {
+		return nil, message.ERROR_PROJECT_NOT_EXIST
+	}
+
+	links, err := repository.NewProjectSyncRepository(database.DB).GetProjectLinks(ctx, projectID)
+	if err != nil {
+		return nil, database.IsError(err)
+	}
+	list := make([]map[string]interface{}, 0, len(links))
+	for i := range links {
+		if links[i].Provider == provider {
+			list = append(list, links[i].Data())
+		}
+	}
+	return list, message.SUCCESS
+}
+
+// validateColumnMapping 映射中的列必须属于该项目
+func validateColumnMapping(projectID int64, mappings []model.TaskStatusMapping) (bool, error) {
+	columnIDs := make([]int64, 0, len(mappings))
+	for _, m := range mappings {
+		if m.ColumnID == 0 || m.StatusID == "" {
+			return false, nil
+		}
+		columnIDs = append(columnIDs, m.ColumnID)
+	}
+	if len(columnIDs) == 0 {
+		return true, nil
+	}
+	columns, err := repository.GetProjectColumnsByProjectID(database.DB, projectID, &columnIDs)
+	if err != nil {
+		return false, err
+	}
+	found := make(map[int64]bool, len(columns))
+	for _, column := range columns {
+		found[column.ID] = true
+	}
+	for _, id := range columnIDs {
+		if !found[id] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// newWebhookSecret 链接的 webhook 签名密钥
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,232 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/tools"
+
+	"gorm.io/gorm"
+)
+
+// resolveNoteRole 计算成员对笔记的有效角色，规则与 10_post_gorm_init.sql 中的 app_note_role 保持一致：
+//  1. 笔记创建者、工作区管理员为 owner
+//  2. 否则取成员授权与分组授权中的最高角色
+//  3. 没有授权时，公开笔记对成员默认可读，并按 allow_comment/allow_edit 提升
+//  4. allow_edit/allow_comment 关闭时，非 owner 的角色会被压低
+func resolveNoteRole(ctx context.Context, db *gorm.DB, note *model.Note, member *model.WorkspaceMember) (model.NoteRole, error) {
+	if member == nil || note == nil || member.WorkspaceID != note.WorkspaceID {
+		return model.NoteRoleNone, nil
+	}
+
+	roles := make([]string, 0)
+	if err := json.Unmarshal(member.Role, &roles); err != nil {
+		return model.NoteRoleNone, err
+	}
+	if note.OwnerID == member.UserID || tools.Contains(roles, model.MemberRole.Admin) {
+		return model.NoteRoleOwner, nil
+	}
+
+	permissions, err := repository.NewACLRepository(db).GetGranteePermissions(ctx, note.ID, member.ID, roles)
+	if err != nil {
+		return model.NoteRoleNone, err
+	}
+	return grantedNoteRole(note, permissions), nil
+}
+
+// grantedNoteRole 非 owner 成员按命中的授权与笔记开关得出的角色
+func grantedNoteRole(note *model.Note, permissions []model.NotePermission) model.NoteRole {
+	role := model.NoteRoleNone
+	for _, permission := range permissions {
+		role = model.MaxNoteRole(role, permission.Role)
+	}
+
+	if role == model.NoteRoleNone && note.Status == model.Public {
+		role = model.NoteRoleViewer
+		if note.AllowComment == nil || *note.AllowComment {
+			role = model.NoteRoleCommenter
+		}
+		if note.AllowEdit == nil || *note.AllowEdit {
+			role = model.NoteRoleEditor
+		}
+	}
+
+	// 授权上限：owner 之外的角色受笔记开关约束
+	if note.AllowEdit != nil && !*note.AllowEdit && role == model.NoteRoleEditor {
+		role = model.NoteRoleCommenter
+	}
+	if note.AllowComment != nil && !*note.AllowComment && role == model.NoteRoleCommenter {
+		role = model.NoteRoleViewer
+	}
+	return role
+}
+
+// readableNotes 批量按 resolveNoteRole 的规则筛出成员至少可读的笔记，授权一次查出
+func readableNotes(ctx context.Context, db *gorm.DB, notes []model.Note, member *model.WorkspaceMember) (map[int64]bool, error) {
+	readable := make(map[int64]bool, len(notes))
+	if len(notes) == 0 {
+		return readable, nil
+	}
+	roles := make([]string, 0)
+	if err := json.Unmarshal(member.Role, &roles); err != nil {
+		return nil, err
+	}
+	isAdmin := tools.Contains(roles, model.MemberRole.Admin)
+
+	noteIDs := make([]int64, 0, len(notes))
+	for _, note := range notes {
+		noteIDs = append(noteIDs, note.ID)
+	}
+	permissions, err := repository.NewACLRepository(db).GetGranteePermissionsByNotes(ctx, noteIDs, member.ID, roles)
+	if err != nil {
+		return nil, err
+	}
+	permissionMap := make(map[int64][]model.NotePermission, len(permissions))
+	for _, permission := range permissions {
+		permissionMap[permission.NoteID] = append(permissionMap[permission.NoteID], permission)
+	}
+
+	for i := range notes {
+		note := &notes[i]
+		if note.WorkspaceID != member.WorkspaceID {
+			continue
+		}
+		if isAdmin || note.OwnerID == member.UserID || grantedNoteRole(note, permissionMap[note.ID]).Allows(model.NoteRoleViewer) {
+			readable[note.ID] = true
+		}
+	}
+	return readable, nil
+}
+
+// workspaceMember 返回工作区成员及其是否为管理员；非成员返回错误码
+func workspaceMember(userID, workspaceID int64) (*model.WorkspaceMember, bool, int) {
+	member, err := repository.GetWorkspaceMember(userID, workspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, message.ERROR_WORKSPACE_MEMBER_NOT_EXIST
+		}
+		return nil, false, database.IsError(err)
+	}
+	if member == nil || member.ID == 0 {
+		return nil, false, message.ERROR_WORKSPACE_MEMBER_NOT_EXIST
+	}
+	roles := make([]string, 0)
+	_ = json.Unmarshal(member.Role, &roles)
+	return member, tools.Contains(roles, model.MemberRole.Admin), 0
+}
+
+// checkNoteRole 校验用户对笔记是否具备 required 角色，返回值为 0 表示通过
+func checkNoteRole(ctx context.Context, db *gorm.DB, note *model.Note, userID int64, required model.NoteRole) (model.NoteRole, int) {
+	member, err := repository.GetWorkspaceMember(userID, note.WorkspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.NoteRoleNone, message.ERROR_NOTE_NO_PERMISSION
+		}
+		return model.NoteRoleNone, database.IsError(err)
+	}
+
+	role, err := resolveNoteRole(ctx, db, note, member)
+	if err != nil {
+		logger.LogError(err, "计算笔记权限失败")
+		return model.NoteRoleNone, message.ERROR
+	}
+	if !role.Allows(required) {
+		return role, message.ERROR_NOTE_NO_PERMISSION
+	}
+	return role, 0
+}
+
+// authorizeNote 读取笔记并校验权限，常用于只拿到 note_id 的入口
+func authorizeNote(ctx context.Context, db *gorm.DB, workspaceID, noteID, userID int64, required model.NoteRole) (*model.Note, model.NoteRole, int) {
+	note, err := repository.GetNoteByID(db, ctx, workspaceID, noteID)
+	if err != nil {
+		return nil, model.NoteRoleNone, database.IsError(err)
+	}
+	if note.ID == 0 {
+		return nil, model.NoteRoleNone, message.ERROR_NOTE_NOT_FOUND
+	}
+	role, code := checkNoteRole(ctx, db, note, userID, required)
+	return note, role, code
+}
+
+func GetNotePermissions(ctx context.Context, params *dto.GetNotePermissionsDTO) (responseCode int, data map[string]interface{}) {
+	_, role, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer)
+	if code != 0 {
+		return code, nil
+	}
+
+	permissions, err := repository.NewACLRepository(database.DB).GetNotePermissions(ctx, params.NoteID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"permissions": permissions,
+		"my_role":     role,
+		"total":       len(permissions),
+	}
+}
+
+func SetNotePermission(ctx context.Context, params *dto.SetNotePermissionDTO) (responseCode int, data map[string]interface{}) {
+	note, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleOwner)
+	if code != 0 {
+		return code, nil
+	}
+
+	aclRepo := repository.NewACLRepository(database.DB)
+	permission := &model.NotePermission{
+		NoteID:      note.ID,
+		WorkspaceID: note.WorkspaceID,
+		GranteeType: params.GranteeType,
+		Role:        params.Role,
+		GrantedBy:   params.MemberID,
+	}
+
+	switch params.GranteeType {
+	case model.NoteGranteeMember:
+		ok, err := aclRepo.IsWorkspaceMember(ctx, note.WorkspaceID, params.GranteeID)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		if !ok {
+			return message.ERROR_NOTE_GRANTEE_INVALID, nil
+		}
+		permission.GranteeID = params.GranteeID
+	case model.NoteGranteeGroup:
+		if params.GranteeGroup != model.MemberRole.Admin && params.GranteeGroup != model.MemberRole.User {
+			return message.ERROR_NOTE_GRANTEE_INVALID, nil
+		}
+		permission.GranteeGroup = params.GranteeGroup
+	}
+
+	if err := aclRepo.UpsertNotePermission(ctx, permission); err != nil {
+		logger.LogError(err, "保存笔记授权失败")
+		return database.IsError(err), nil
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"permission": permission,
+	}
+}
+
+func DeleteNotePermission(ctx context.Context, params *dto.DeleteNotePermissionDTO) (responseCode int) {
+	_, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleOwner)
+	if code != 0 {
+		return code
+	}
+
+	affected, err := repository.NewACLRepository(database.DB).DeleteNotePermission(ctx, params.NoteID, params.PermissionID)
+	if err != nil {
+		return database.IsError(err)
+	}
+	if affected == 0 {
+		return message.ERROR_NOTE_GRANTEE_INVALID
+	}
+	return message.SUCCESS
+}
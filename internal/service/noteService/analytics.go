@@ -0,0 +1,120 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+	"time"
+)
+
+const (
+	defaultNoteViewReportDays  = 30
+	defaultNoteViewReportLimit = 20
+	defaultNoteReadersLimit    = 50
+)
+
+// RecordNoteView 记录一次浏览：同一会话内只记一次，事件写入 Redis 队列后由定时任务批量落库
+func RecordNoteView(ctx context.Context, params *dto.RecordNoteViewDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+	if cache.RedisInstance == nil {
+		return message.ERROR_REDIS, nil
+	}
+
+	first, err := cache.RedisInstance.MarkNoteViewed(ctx, params.NoteID, params.MemberID, params.SessionID, dto.NoteViewDedupTTL)
+	if err != nil {
+		logger.LogError(err, "记录笔记浏览失败")
+		return message.ERROR_REDIS, nil
+	}
+	if first {
+		event, _ := json.Marshal(dto.NoteViewEvent{
+			WorkspaceID: params.WorkspaceID,
+			NoteID:      params.NoteID,
+			MemberID:    params.MemberID,
+			ViewedAt:    time.Now().UTC(),
+		})
+		if err := cache.RedisInstance.PushNoteViews(ctx, string(event)); err != nil {
+			logger.LogError(err, "写入浏览队列失败")
+			return message.ERROR_REDIS, nil
+		}
+	}
+	return message.SUCCESS, map[string]interface{}{"recorded": first}
+}
+
+// GetNoteReaders 笔记的阅读者列表以及字数、阅读时长、浏览汇总
+func GetNoteReaders(ctx context.Context, params *dto.NoteReadersQueryDTO) (responseCode int, data map[string]interface{}) {
+	note, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer)
+	if code != 0 {
+		return code, nil
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultNoteReadersLimit
+	}
+
+	viewRepo := repository.NewNoteViewRepository(database.DB)
+	readers, total, err := viewRepo.ListReaders(ctx, note.ID, params.Limit, params.Offset)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	views, readerCount, err := viewRepo.NoteTotals(ctx, note.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		logger.LogError(err, "Unmarshal note content error")
+		return message.ERROR, nil
+	}
+	words, minutes := dto.NoteReadingStats(content)
+
+	return message.SUCCESS, map[string]interface{}{
+		"readers":         readers,
+		"total":           total,
+		"views":           views,
+		"reader_count":    readerCount,
+		"word_count":      words,
+		"reading_minutes": minutes,
+	}
+}
+
+// GetNoteViewReport 工作区或分类下的热门笔记 / 长期无人阅读的笔记
+func GetNoteViewReport(ctx context.Context, params *dto.NoteViewReportDTO) (responseCode int, data map[string]interface{}) {
+	if params.Days == 0 {
+		params.Days = defaultNoteViewReportDays
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultNoteViewReportLimit
+	}
+	_, params.IsAdmin = repository.IsUserAllowedToModifyWorkspace(params.UserID, params.WorkspaceID)
+
+	since := time.Now().UTC().AddDate(0, 0, -params.Days)
+	viewRepo := repository.NewNoteViewRepository(database.DB)
+
+	var items []dto.NoteViewReportItem
+	var err error
+	if params.Report == "stale" {
+		items, err = viewRepo.Stale(ctx, params, since, params.Limit)
+	} else {
+		items, err = viewRepo.MostViewed(ctx, params, since, params.Limit)
+	}
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	for i := range items {
+		items[i].WordCount, items[i].ReadingMinutes = dto.NoteReadingStats(items[i].Content)
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"report": params.Report,
+		"days":   params.Days,
+		"notes":  items,
+	}
+}
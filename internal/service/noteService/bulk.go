@@ -0,0 +1,262 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/thirdparty/qiniu"
+	"gin-notebook/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// bulkNoteRequiredRole 各批量操作需要的笔记角色
+func bulkNoteRequiredRole(action dto.BulkNoteAction) model.NoteRole {
+	switch action {
+	case dto.BulkNoteMove, dto.BulkNoteTag, dto.BulkNoteUntag:
+		return model.NoteRoleEditor
+	case dto.BulkNoteStatus, dto.BulkNoteDelete:
+		return model.NoteRoleOwner
+	default:
+		return model.NoteRoleViewer
+	}
+}
+
+// bulkNoteChecksLock 改写笔记本身的批量操作需要遵守签出锁
+func bulkNoteChecksLock(action dto.BulkNoteAction) bool {
+	switch action {
+	case dto.BulkNoteMove, dto.BulkNoteStatus, dto.BulkNoteDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// BulkNoteOperation 在一个事务内批量处理笔记并逐条返回结果。
+// 笔记不存在、权限不足只记为该条失败；目标分类/标签无效或数据库出错时整批回滚。
+func BulkNoteOperation(ctx context.Context, params *dto.BulkNoteDTO) (responseCode int, data map[string]interface{}) {
+	noteIDs, err := params.ParseNoteIDs()
+	if err != nil {
+		return message.ERROR_INVALID_PARAMS, nil
+	}
+
+	required := bulkNoteRequiredRole(params.Action)
+	results := make([]dto.BulkNoteResult, 0, len(noteIDs))
+	affected := make([]model.Note, 0, len(noteIDs))
+	copiedFiles := make([]string, 0)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.WithContext(ctx)
+		bulkRepo := repository.NewNoteBulkRepository(tx)
+
+		if params.CategoryID != nil && (params.Action == dto.BulkNoteMove || params.Action == dto.BulkNoteDuplicate) {
+			if _, err := repository.GetNoteCategoryByID(tx, params.WorkspaceID, *params.CategoryID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					responseCode = message.ERROR_CATE_NOT_EXIST
+				} else {
+					responseCode = database.IsError(err)
+				}
+				return err
+			}
+		}
+		if params.Action == dto.BulkNoteTag {
+			if _, err := bulkRepo.GetTag(ctx, params.WorkspaceID, *params.TagID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					responseCode = message.ERROR_NOTE_TAG_NOT_FOUND
+				} else {
+					responseCode = database.IsError(err)
+				}
+				return err
+			}
+		}
+
+		notes, err := bulkRepo.GetNotes(ctx, params.WorkspaceID, noteIDs)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		noteMap := make(map[int64]*model.Note, len(notes))
+		for i := range notes {
+			noteMap[notes[i].ID] = &notes[i]
+		}
+
+		// 逐条校验权限，通过的笔记统一处理
+		allowed := make([]*model.Note, 0, len(notes))
+		for _, id := range noteIDs {
+			note, ok := noteMap[id]
+			if !ok {
+				results = append(results, bulkNoteResult(id, message.ERROR_NOTE_NOT_FOUND))
+				continue
+			}
+			if _, code := checkNoteRole(ctx, tx, note, params.UserID, required); code != 0 {
+				if code != message.ERROR_NOTE_NO_PERMISSION {
+					responseCode = code
+					return gorm.ErrInvalidData
+				}
+				results = append(results, bulkNoteResult(id, code))
+				continue
+			}
+			if bulkNoteChecksLock(params.Action) {
+				if _, code := checkNoteLock(ctx, note.ID, params.UserID); code != 0 {
+					if code != message.ERROR_NOTE_LOCKED {
+						responseCode = code
+						return gorm.ErrInvalidData
+					}
+					results = append(results, bulkNoteResult(id, code))
+					continue
+				}
+			}
+			allowed = append(allowed, note)
+		}
+		if len(allowed) == 0 {
+			return nil
+		}
+
+		allowedIDs := make([]int64, 0, len(allowed))
+		for _, note := range allowed {
+			allowedIDs = append(allowedIDs, note.ID)
+		}
+
+		switch params.Action {
+		case dto.BulkNoteMove:
+			err = bulkRepo.UpdateNotes(ctx, allowedIDs, map[string]interface{}{"category_id": *params.CategoryID})
+		case dto.BulkNoteStatus:
+			err = bulkRepo.UpdateNotes(ctx, allowedIDs, map[string]interface{}{"status": model.NoteStatus(params.Status)})
+		case dto.BulkNoteTag:
+			err = bulkRepo.UpdateNotes(ctx, allowedIDs, map[string]interface{}{"tags_id": *params.TagID})
+		case dto.BulkNoteUntag:
+			err = bulkRepo.UpdateNotes(ctx, allowedIDs, map[string]interface{}{"tags_id": nil})
+		case dto.BulkNoteFavorite, dto.BulkNoteUnfavorite:
+			// 批量收藏没有客户端序号，以毫秒时间戳作为 sep
+			err = bulkRepo.SetFavorites(ctx, params.UserID, allowedIDs, params.Action == dto.BulkNoteFavorite, time.Now().UnixMilli())
+		case dto.BulkNoteDelete:
+			err = bulkRepo.TrashNotes(ctx, allowedIDs)
+		case dto.BulkNoteDuplicate:
+			for _, note := range allowed {
+				copied, err := duplicateNote(ctx, tx, note, params, &copiedFiles)
+				if err != nil {
+					responseCode = database.IsError(err)
+					return err
+				}
+				result := bulkNoteResult(note.ID, message.SUCCESS)
+				result.NewNoteID = &copied.ID
+				results = append(results, result)
+				affected = append(affected, *copied)
+			}
+			return nil
+		}
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		for _, note := range allowed {
+			results = append(results, bulkNoteResult(note.ID, message.SUCCESS))
+			// 收藏只影响当前用户，不需要重新索引
+			if params.Action != dto.BulkNoteFavorite && params.Action != dto.BulkNoteUnfavorite {
+				affected = append(affected, *note)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		qiniu.DeleteByURLs(ctx, copiedFiles)
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return
+	}
+
+	triggerNoteIngest(ctx, affected)
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Code == message.SUCCESS {
+			succeeded++
+		}
+	}
+	data = map[string]interface{}{
+		"action":    params.Action,
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	}
+	responseCode = message.SUCCESS
+	return
+}
+
+func bulkNoteResult(noteID int64, code int) dto.BulkNoteResult {
+	return dto.BulkNoteResult{NoteID: noteID, Code: code, Message: message.CodeMsg[code]}
+}
+
+// duplicateNote 复制笔记内容并重新生成块 ID；副本归操作人所有，权限设置使用默认值。
+// 封面在对象存储中另存一份，新外链记入 copiedFiles 以便事务失败时清理
+func duplicateNote(ctx context.Context, tx *gorm.DB, note *model.Note, params *dto.BulkNoteDTO, copiedFiles *[]string) (*model.Note, error) {
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		return nil, err
+	}
+	content = dto.CloneBlocks(content)
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID := note.CategoryID
+	if params.CategoryID != nil {
+		categoryID = *params.CategoryID
+	}
+	copied := &model.Note{
+		Title:       truncateRunes(note.Title+dto.BulkNoteCopySuffix, 100),
+		Content:     raw,
+		WorkspaceID: note.WorkspaceID,
+		TagsID:      note.TagsID,
+		CategoryID:  categoryID,
+		OwnerID:     params.UserID,
+	}
+	if note.Cover != nil && *note.Cover != "" {
+		cover, copiedCover, err := qiniu.CopyByURL(ctx, *note.Cover)
+		if err != nil {
+			return nil, err
+		}
+		if copiedCover {
+			*copiedFiles = append(*copiedFiles, cover)
+		}
+		copied.Cover = &cover
+	}
+	if err := repository.NewNoteBulkRepository(tx).CreateNote(ctx, copied); err != nil {
+		return nil, err
+	}
+	if err := syncNoteLinks(ctx, tx, copied.WorkspaceID, copied.ID, content); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// triggerNoteIngest 事务提交后为受影响的笔记各投递一次索引任务；已删除的笔记由任务下线对应文档
+func triggerNoteIngest(ctx context.Context, notes []model.Note) {
+	seen := make(map[int64]struct{}, len(notes))
+	for _, note := range notes {
+		if _, ok := seen[note.ID]; ok {
+			continue
+		}
+		seen[note.ID] = struct{}{}
+		_, err := enqueue.IngestNote(ctx, types.IngestNotePayload{
+			NoteID:      note.ID,
+			WorkspaceID: note.WorkspaceID,
+			OwnerUserID: note.OwnerID,
+		})
+		if err != nil {
+			logger.LogError(err, "投递笔记索引任务失败")
+		}
+	}
+}
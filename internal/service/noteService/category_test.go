@@ -0,0 +1,71 @@
+package noteService_test
+
+import (
+	"context"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/noteService"
+	"testing"
+)
+
+func seedCategory(t *testing.T, name string, ownerID int64) *model.NoteCategory {
+	t.Helper()
+	category := model.NoteCategory{CategoryName: name, WorkspaceID: testWorkspaceID, OwnerID: ownerID, OrderIndex: "0|i00000:"}
+	seed(t, &category)
+	return &category
+}
+
+func TestNoteCategoryRequiresMembership(t *testing.T) {
+	setupNotes(t)
+	category := seedCategory(t, "plans", aliceUserID)
+	ctx := context.Background()
+
+	if code, _ := noteService.GetNoteCategoryTree(ctx, &dto.NoteCategoryTreeQueryDTO{WorkspaceID: testWorkspaceID, UserID: carolUserID}); code != message.ERROR_WORKSPACE_MEMBER_NOT_EXIST {
+		t.Fatalf("tree code = %d", code)
+	}
+	parentID := rootCategoryID
+	if code, _ := noteService.MoveNoteCategory(ctx, &dto.MoveNoteCategoryDTO{ID: category.ID, WorkspaceID: testWorkspaceID, ParentID: &parentID, UserID: carolUserID}); code != message.ERROR_WORKSPACE_MEMBER_NOT_EXIST {
+		t.Fatalf("move code = %d", code)
+	}
+	if code, _ := noteService.DeleteNoteCategory(ctx, &dto.DeleteCategoryTreeDTO{ID: category.ID, WorkspaceID: testWorkspaceID, Mode: dto.CategoryDeleteCascade, UserID: carolUserID}); code != message.ERROR_WORKSPACE_MEMBER_NOT_EXIST {
+		t.Fatalf("delete code = %d", code)
+	}
+	if n := count(t, &model.NoteCategory{}, "id = ? AND deleted_at IS NULL", category.ID); n != 1 {
+		t.Fatal("non-member deleted the category")
+	}
+
+	code, data := noteService.GetNoteCategoryTree(ctx, &dto.NoteCategoryTreeQueryDTO{WorkspaceID: testWorkspaceID, UserID: bobUserID})
+	if code != message.SUCCESS || data["total"] != 2 {
+		t.Fatalf("member tree code = %d data = %v", code, data)
+	}
+}
+
+func TestNoteCategoryCascadeDeleteRequiresOwnerOrAdmin(t *testing.T) {
+	setupNotes(t)
+	category := seedCategory(t, "plans", aliceUserID)
+	note := seedNote(t, "inside", aliceUserID, category.ID)
+	ctx := context.Background()
+
+	cascade := &dto.DeleteCategoryTreeDTO{ID: category.ID, WorkspaceID: testWorkspaceID, Mode: dto.CategoryDeleteCascade, UserID: bobUserID}
+	if code, _ := noteService.DeleteNoteCategory(ctx, cascade); code != message.ERROR_CATE_NO_PERMISSION {
+		t.Fatalf("member cascade code = %d", code)
+	}
+	if n := count(t, &model.Note{}, "id = ? AND deleted_at IS NULL", note.ID); n != 1 {
+		t.Fatal("rejected cascade trashed the note")
+	}
+
+	// 成员自己创建的分类可以连同内容删除
+	own := seedCategory(t, "bob", bobUserID)
+	if code, _ := noteService.DeleteNoteCategory(ctx, &dto.DeleteCategoryTreeDTO{ID: own.ID, WorkspaceID: testWorkspaceID, Mode: dto.CategoryDeleteCascade, UserID: bobUserID}); code != message.SUCCESS {
+		t.Fatalf("owner cascade code = %d", code)
+	}
+
+	cascade.UserID = aliceUserID
+	if code, _ := noteService.DeleteNoteCategory(ctx, cascade); code != message.SUCCESS {
+		t.Fatalf("admin cascade code = %d", code)
+	}
+	if n := count(t, &model.Note{}, "id = ? AND deleted_at IS NOT NULL", note.ID); n != 1 {
+		t.Fatal("cascade did not trash the note")
+	}
+}
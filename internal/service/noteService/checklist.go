@@ -0,0 +1,389 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+	"strings"
+
+	"github.com/morikuni/go-lexorank"
+	"gorm.io/gorm"
+)
+
+// CreateTasksFromChecklist 把笔记中选中的清单块转为看板任务并建立双向关联；已关联的块直接返回原任务
+func CreateTasksFromChecklist(ctx context.Context, params *dto.ChecklistToTasksDTO) (responseCode int, data map[string]interface{}) {
+	db := database.DB.WithContext(ctx)
+	note, _, code := authorizeNote(ctx, db, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleEditor)
+	if code != 0 {
+		return code, nil
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		logger.LogError(err, "Unmarshal note content error")
+		return message.ERROR, nil
+	}
+	checklist := dto.IndexChecklistBlocks(content)
+	for _, blockID := range params.BlockIDs {
+		if _, ok := checklist[blockID]; !ok {
+			return message.ERROR_CHECKLIST_BLOCK_INVALID, nil
+		}
+	}
+
+	project, err := repository.GetProjectByID(db, params.ProjectID, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if project.ID == 0 {
+		return message.ERROR_PROJECT_NOT_EXIST, nil
+	}
+
+	created := make([]model.ToDoTask, 0, len(params.BlockIDs))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		checklistRepo := repository.NewChecklistRepository(tx)
+
+		var column *model.ToDoColumn
+		var err error
+		if params.ColumnID != nil {
+			column, err = checklistRepo.GetColumn(ctx, project.ID, *params.ColumnID)
+		} else {
+			column, err = checklistRepo.GetProcessColumn(ctx, project.ID, model.ProcessTodo)
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseCode = message.ERROR_COLUMN_NOT_EXIST
+			} else {
+				responseCode = database.IsError(err)
+			}
+			return err
+		}
+		doneColumn, err := checklistRepo.GetProcessColumn(ctx, project.ID, model.ProcessDone)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		existing, err := checklistRepo.ListByNote(ctx, note.ID)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		linked := make(map[string]bool, len(existing))
+		for _, link := range existing {
+			linked[link.BlockID] = true
+		}
+
+		// 按块的选择顺序依次排在目标列顶部
+		ranks := make(map[int64]*checklistRank)
+		links := make([]model.NoteChecklistTask, 0, len(params.BlockIDs))
+		for _, blockID := range params.BlockIDs {
+			if linked[blockID] {
+				continue
+			}
+			linked[blockID] = true
+			block := checklist[blockID]
+
+			target, status := column, model.TaskStatusPending
+			if block.Checked {
+				status = model.TaskStatusCompleted
+				if doneColumn != nil {
+					target = doneColumn
+				}
+			}
+			orderIndex, err := nextChecklistRank(tx, target.ID, ranks)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+
+			title := truncateRunes(strings.TrimSpace(block.Text), 200)
+			if title == "" {
+				title = note.Title
+			}
+			task := model.ToDoTask{
+				ProjectID:   project.ID,
+				Title:       title,
+				OrderIndex:  orderIndex,
+				ColumnID:    target.ID,
+				Creator:     params.UserID,
+				Status:      status,
+				Description: []byte("[]"),
+			}
+			if err := checklistRepo.CreateTask(ctx, &task); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			created = append(created, task)
+			links = append(links, model.NoteChecklistTask{
+				WorkspaceID: params.WorkspaceID,
+				NoteID:      note.ID,
+				BlockID:     blockID,
+				TaskID:      task.ID,
+			})
+		}
+		if err := checklistRepo.Create(ctx, links); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return responseCode, nil
+	}
+
+	success := true
+	for _, task := range created {
+		enqueue.KanbanActivityJob(ctx, types.KanbanActivityPayload{
+			MemberID:    params.MemberID,
+			ActorID:     params.UserID,
+			Success:     &success,
+			ProjectID:   &task.ProjectID,
+			TaskID:      &task.ID,
+			ColumnID:    &task.ColumnID,
+			WorkspaceID: params.WorkspaceID,
+			Action:      model.LinkAction,
+			TargetType:  model.TargetTask,
+			TargetID:    task.ID,
+			Patch:       checklistActivityParams(note, ""),
+		})
+	}
+
+	items, err := repository.NewChecklistRepository(db).ListTasksByNote(ctx, note.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{
+		"created": len(created),
+		"items":   items,
+	}
+}
+
+func GetNoteChecklistTasks(ctx context.Context, params *dto.NoteChecklistTasksQueryDTO) (responseCode int, data map[string]interface{}) {
+	db := database.DB.WithContext(ctx)
+	if _, _, code := authorizeNote(ctx, db, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+	items, err := repository.NewChecklistRepository(db).ListTasksByNote(ctx, params.NoteID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"items": items}
+}
+
+// UnlinkChecklistTask 解除清单块与任务的关联，任务本身保留
+func UnlinkChecklistTask(ctx context.Context, params *dto.UnlinkChecklistTaskDTO) (responseCode int) {
+	db := database.DB.WithContext(ctx)
+	note, _, code := authorizeNote(ctx, db, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleEditor)
+	if code != 0 {
+		return code
+	}
+
+	checklistRepo := repository.NewChecklistRepository(db)
+	link, err := checklistRepo.GetByBlock(ctx, note.ID, params.BlockID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_CHECKLIST_NOT_LINKED
+		}
+		return database.IsError(err)
+	}
+	if err := checklistRepo.DeleteByIDs(ctx, []int64{link.ID}); err != nil {
+		return database.IsError(err)
+	}
+
+	success := true
+	enqueue.KanbanActivityJob(ctx, types.KanbanActivityPayload{
+		MemberID:    params.MemberID,
+		ActorID:     params.UserID,
+		Success:     &success,
+		TaskID:      &link.TaskID,
+		WorkspaceID: params.WorkspaceID,
+		Action:      model.UnlinkAction,
+		TargetType:  model.TargetTask,
+		TargetID:    link.TaskID,
+		Patch:       checklistActivityParams(note, link.BlockID),
+	})
+	return message.SUCCESS
+}
+
+// syncChecklistTasks 笔记内容变更后同步关联任务：勾选状态变化时移动任务，块被删除时解除关联。
+// 在笔记更新事务内调用，返回需要在提交后写入的任务动态。
+func syncChecklistTasks(ctx context.Context, tx *gorm.DB, note *model.Note, before, after dto.Blocks) ([]types.KanbanActivityPayload, error) {
+	checklistRepo := repository.NewChecklistRepository(tx)
+	links, err := checklistRepo.ListByNote(ctx, note.ID)
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+
+	oldState := dto.IndexChecklistBlocks(before)
+	newState := dto.IndexChecklistBlocks(after)
+	removed := make([]int64, 0)
+	activities := make([]types.KanbanActivityPayload, 0)
+
+	for _, link := range links {
+		block, ok := newState[link.BlockID]
+		if !ok {
+			removed = append(removed, link.ID)
+			activities = append(activities, types.KanbanActivityPayload{
+				WorkspaceID: note.WorkspaceID,
+				TaskID:      &link.TaskID,
+				Action:      model.UnlinkAction,
+				TargetType:  model.TargetTask,
+				TargetID:    link.TaskID,
+				Patch:       checklistActivityParams(note, link.BlockID),
+			})
+			continue
+		}
+		if old, ok := oldState[link.BlockID]; ok && old.Checked == block.Checked {
+			continue
+		}
+
+		task, err := checklistRepo.GetTask(ctx, link.TaskID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		fromColumn := task.ColumnID
+		moved, err := moveChecklistTask(ctx, tx, task, block.Checked)
+		if err != nil {
+			return nil, err
+		}
+		if !moved {
+			continue
+		}
+		activities = append(activities, types.KanbanActivityPayload{
+			WorkspaceID: note.WorkspaceID,
+			ProjectID:   &task.ProjectID,
+			TaskID:      &task.ID,
+			ColumnID:    &task.ColumnID,
+			Action:      model.MoveAction,
+			TargetType:  model.TargetTask,
+			TargetID:    task.ID,
+			Patch: map[string]interface{}{
+				"from_column_id": fmt.Sprint(fromColumn),
+				"to_column_id":   fmt.Sprint(task.ColumnID),
+				"status":         task.Status,
+				"note_id":        fmt.Sprint(note.ID),
+				"note_title":     note.Title,
+			},
+		})
+	}
+
+	if err := checklistRepo.DeleteByIDs(ctx, removed); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// moveChecklistTask 勾选时移到项目的完成列，取消勾选时移回待办列；没有对应列时只改状态
+func moveChecklistTask(ctx context.Context, tx *gorm.DB, task *model.ToDoTask, done bool) (bool, error) {
+	checklistRepo := repository.NewChecklistRepository(tx)
+
+	current, err := checklistRepo.GetColumn(ctx, task.ProjectID, task.ColumnID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	inDone := current != nil && current.ProcessID == model.ProcessDone
+
+	status, process := model.TaskStatusPending, model.ProcessTodo
+	if done {
+		status, process = model.TaskStatusCompleted, model.ProcessDone
+	}
+	if inDone == done && task.Status == status {
+		return false, nil
+	}
+
+	columnID, orderIndex := task.ColumnID, task.OrderIndex
+	if inDone != done {
+		target, err := checklistRepo.GetProcessColumn(ctx, task.ProjectID, process)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+		if target != nil {
+			if orderIndex, err = nextChecklistRank(tx, target.ID, nil); err != nil {
+				return false, err
+			}
+			columnID = target.ID
+		}
+	}
+
+	if err := checklistRepo.MoveTask(ctx, task.ID, columnID, orderIndex, status); err != nil {
+		return false, err
+	}
+	task.ColumnID, task.OrderIndex, task.Status = columnID, orderIndex, status
+	return true, nil
+}
+
+// checklistRank 一次请求内向同一列顶部连续插入时的可用区间
+type checklistRank struct {
+	lower, upper lexorank.BucketKey
+}
+
+// nextChecklistRank 返回列顶部的位置；ranks 记录本次已分配的位置，使连续插入的任务保持先后顺序
+func nextChecklistRank(tx *gorm.DB, columnID int64, ranks map[int64]*checklistRank) (string, error) {
+	r, ok := ranks[columnID]
+	if !ok {
+		r = &checklistRank{lower: algorithm.RankMin(), upper: algorithm.RankMax()}
+		first, err := repository.GetFirstTask(tx, columnID, repository.WithLock())
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+		if first != nil {
+			r.upper = lexorank.BucketKey(first.OrderIndex)
+		}
+		if ranks != nil {
+			ranks[columnID] = r
+		}
+	}
+	key := algorithm.RankBetweenBucket(r.lower, r.upper)
+	r.lower = key
+	return key.String(), nil
+}
+
+func checklistActivityParams(note *model.Note, blockID string) map[string]interface{} {
+	params := map[string]interface{}{
+		"note_id":    fmt.Sprint(note.ID),
+		"note_title": note.Title,
+	}
+	if blockID != "" {
+		params["block_id"] = blockID
+	}
+	return params
+}
+
+// emitChecklistActivities 笔记更新提交后写入任务动态
+func emitChecklistActivities(ctx context.Context, userID int64, activities []types.KanbanActivityPayload) {
+	if len(activities) == 0 {
+		return
+	}
+	var memberID int64
+	if member, err := repository.GetWorkspaceMember(userID, activities[0].WorkspaceID); err == nil && member != nil {
+		memberID = member.ID
+	}
+	success := true
+	for _, activity := range activities {
+		activity.ActorID = userID
+		activity.MemberID = memberID
+		activity.Success = &success
+		enqueue.KanbanActivityJob(ctx, activity)
+		if activity.TaskID != nil {
+			integrationService.DispatchTaskIssueSync(ctx, *activity.TaskID)
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
+	"gin-notebook/pkg/logger"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+func CreateNoteComment(ctx context.Context, params *dto.CreateNoteCommentDTO) (responseCode int, data map[string]interface{}) {
+	note, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleCommenter)
+	if code != 0 {
+		return code, nil
+	}
+
+	comment := &model.NoteComment{
+		NoteID:      note.ID,
+		WorkspaceID: note.WorkspaceID,
+		MemberID:    params.MemberID,
+		Content:     params.Content,
+		Status:      model.NoteCommentOpen,
+		AnchorState: model.NoteAnchorActive,
+	}
+	if code := anchorNoteComment(ctx, note, comment, params.ParentID, params.BlockID, params.AnchorStart, params.AnchorEnd); code != 0 {
+		return code, nil
+	}
+
+	mentions, code := buildNoteCommentMentions(ctx, note.WorkspaceID, params.Content, params.Mentions)
+	if code != 0 {
+		return code, nil
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewNoteCommentRepository(tx)
+		if err := txRepo.CreateNoteComment(ctx, comment); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		for i := range mentions {
+			mentions[i].CommentID = comment.ID
+		}
+		if err := txRepo.CreateMentions(ctx, mentions); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		attachments := make([]model.NoteCommentAttachment, 0, len(params.Attachments))
+		for _, attachment := range params.Attachments {
+			attachments = append(attachments, model.NoteCommentAttachment{
+				CommentID:     comment.ID,
+				FileName:      attachment.FileName,
+				FileURL:       attachment.FileURL,
+				FileSize:      attachment.FileSize,
+				FileType:      attachment.FileType,
+				ThumbnailPath: attachment.ThumbnailPath,
+				UploaderID:    params.MemberID,
+				SHA256Hash:    attachment.SHA256Hash,
+			})
+		}
+		if err := txRepo.CreateAttachments(ctx, attachments); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		if comment.ParentID > 0 {
+			if err := txRepo.IncreaseReplyCount(ctx, comment.ParentID, 1); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return responseCode, nil
+	}
+
+	items, err := buildNoteCommentItems(ctx, []model.NoteComment{*comment}, params.MemberID)
+	if err != nil {
+		logger.LogError(err, "组装笔记评论失败")
+		return database.IsError(err), nil
+	}
+
+	_ = bus.PublishNoteComment(context.Background(), bus.WsCommentAdded, note.ID, items[0])
+	webhookService.Dispatch(ctx, note.WorkspaceID, 0, model.WebhookCommentAdded, map[string]interface{}{
+		"target_type": "note",
+		"note_id":     strconv.FormatInt(note.ID, 10),
+		"comment_id":  strconv.FormatInt(comment.ID, 10),
+		"parent_id":   strconv.FormatInt(comment.ParentID, 10),
+		"member_id":   strconv.FormatInt(params.MemberID, 10),
+		"content":     comment.Content,
+	})
+
+	return message.SUCCESS, map[string]interface{}{
+		"comment": items[0],
+	}
+}
+
+func GetNoteComments(ctx context.Context, params *dto.GetNoteCommentsDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+
+	comments, err := repository.NewNoteCommentRepository(database.DB).GetNoteComments(ctx, params.NoteID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	items, err := buildNoteCommentItems(ctx, comments, params.MemberID)
+	if err != nil {
+		logger.LogError(err, "组装笔记评论失败")
+		return database.IsError(err), nil
+	}
+
+	threads := buildNoteCommentThreads(items, func(item dto.NoteCommentItem) bool {
+		if params.BlockID != "" && item.BlockID != params.BlockID {
+			return false
+		}
+		if params.Status != "" && item.Status != params.Status {
+			return false
+		}
+		return params.IncludeDetached || item.AnchorState != string(model.NoteAnchorDetached)
+	})
+
+	return message.SUCCESS, map[string]interface{}{
+		"threads": threads,
+		"total":   len(threads),
+	}
+}
+
+// buildNoteCommentThreads 把回复挂到根评论下，keep 过滤根评论
+func buildNoteCommentThreads(items []dto.NoteCommentItem, keep func(dto.NoteCommentItem) bool) []*dto.NoteCommentThread {
+	threads := make([]*dto.NoteCommentThread, 0)
+	threadMap := make(map[int64]*dto.NoteCommentThread)
+	for _, item := range items {
+		if item.ParentID != 0 || !keep(item) {
+			continue
+		}
+		thread := &dto.NoteCommentThread{NoteCommentItem: item, Replies: make([]dto.NoteCommentItem, 0)}
+		threadMap[item.ID] = thread
+		threads = append(threads, thread)
+	}
+	for _, item := range items {
+		if thread, ok := threadMap[item.ParentID]; ok {
+			thread.Replies = append(thread.Replies, item)
+		}
+	}
+	return threads
+}
+
+func UpdateNoteComment(ctx context.Context, params *dto.UpdateNoteCommentDTO) (responseCode int, data map[string]interface{}) {
+	commentRepo := repository.NewNoteCommentRepository(database.DB)
+	comment, err := commentRepo.GetNoteCommentByID(ctx, params.WorkspaceID, params.CommentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_NOTE_COMMENT_NOT_FOUND, nil
+		}
+		return database.IsError(err), nil
+	}
+	if comment.MemberID != params.MemberID {
+		return message.ERROR_NOTE_NO_PERMISSION, nil
+	}
+	if _, _, code := authorizeNote(ctx, database.DB, comment.WorkspaceID, comment.NoteID, params.UserID, model.NoteRoleCommenter); code != 0 {
+		return code, nil
+	}
+
+	mentions, code := buildNoteCommentMentions(ctx, comment.WorkspaceID, params.Content, params.Mentions)
+	if code != 0 {
+		return code, nil
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewNoteCommentRepository(tx)
+		if err := txRepo.UpdateNoteComment(ctx, comment.ID, map[string]interface{}{"content": params.Content}); err != nil {
+			return err
+		}
+		for i := range mentions {
+			mentions[i].CommentID = comment.ID
+		}
+		return txRepo.ReplaceMentions(ctx, comment.ID, mentions)
+	})
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	comment.Content = params.Content
+	comment.UpdatedAt = time.Now()
+	items, err := buildNoteCommentItems(ctx, []model.NoteComment{*comment}, params.MemberID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	_ = bus.PublishNoteComment(context.Background(), bus.WsCommentUpdated, comment.NoteID, items[0])
+
+	return message.SUCCESS, map[string]interface{}{
+		"comment": items[0],
+	}
+}
+
+// DeleteNoteComment 作者本人或笔记 owner 可删除；删除根评论会连同整个讨论串
+func DeleteNoteComment(ctx context.Context, params *dto.DeleteNoteCommentDTO) (responseCode int) {
+	commentRepo := repository.NewNoteCommentRepository(database.DB)
+	comment, err := commentRepo.GetNoteCommentByID(ctx, params.WorkspaceID, params.CommentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_NOTE_COMMENT_NOT_FOUND
+		}
+		return database.IsError(err)
+	}
+
+	required := model.NoteRoleCommenter
+	if comment.MemberID != params.MemberID {
+		required = model.NoteRoleOwner
+	}
+	if _, _, code := authorizeNote(ctx, database.DB, comment.WorkspaceID, comment.NoteID, params.UserID, required); code != 0 {
+		return code
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewNoteCommentRepository(tx)
+		if err := txRepo.DeleteNoteCommentThread(ctx, comment.ID); err != nil {
+			return err
+		}
+		if comment.ParentID > 0 {
+			return txRepo.IncreaseReplyCount(ctx, comment.ParentID, -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return database.IsError(err)
+	}
+
+	_ = bus.PublishNoteComment(context.Background(), bus.WsCommentRemoved, comment.NoteID, map[string]any{
+		"id":        comment.ID,
+		"parent_id": comment.ParentID,
+	})
+	return message.SUCCESS
+}
+
+func ResolveNoteComment(ctx context.Context, params *dto.ResolveNoteCommentDTO) (responseCode int, data map[string]interface{}) {
+	commentRepo := repository.NewNoteCommentRepository(database.DB)
+	comment, err := commentRepo.GetNoteCommentByID(ctx, params.WorkspaceID, params.CommentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_NOTE_COMMENT_NOT_FOUND, nil
+		}
+		return database.IsError(err), nil
+	}
+	if comment.ParentID != 0 {
+		return message.ERROR_NOTE_COMMENT_NOT_ROOT, nil
+	}
+	if _, _, code := authorizeNote(ctx, database.DB, comment.WorkspaceID, comment.NoteID, params.UserID, model.NoteRoleCommenter); code != 0 {
+		return code, nil
+	}
+
+	updates := map[string]interface{}{
+		"status":      model.NoteCommentOpen,
+		"resolved_by": nil,
+		"resolved_at": nil,
+	}
+	comment.Status, comment.ResolvedBy, comment.ResolvedAt = model.NoteCommentOpen, nil, nil
+	if params.Resolved {
+		now := time.Now()
+		updates["status"] = model.NoteCommentResolved
+		updates["resolved_by"] = params.MemberID
+		updates["resolved_at"] = now
+		comment.Status, comment.ResolvedBy, comment.ResolvedAt = model.NoteCommentResolved, &params.MemberID, &now
+	}
+
+	if err := commentRepo.UpdateNoteComment(ctx, comment.ID, updates); err != nil {
+		return database.IsError(err), nil
+	}
+
+	items, err := buildNoteCommentItems(ctx, []model.NoteComment{*comment}, params.MemberID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	_ = bus.PublishNoteComment(context.Background(), bus.WsCommentUpdated, comment.NoteID, items[0])
+
+	return message.SUCCESS, map[string]interface{}{
+		"comment": items[0],
+	}
+}
+
+// relocateNoteCommentAnchors 笔记内容变化后跟踪锚点：
+// 块 ID 在 move 时保持不变，只需同步新的父块；文本被编辑时按原文重新定位区间；
+// 块被删除或原文找不到时标记为 detached，块恢复（如撤销删除）后重新挂回。
+// 返回锚点发生变化的评论，由调用方在事务提交后推送。
+func relocateNoteCommentAnchors(ctx context.Context, db *gorm.DB, noteID int64, blocks dto.Blocks) ([]model.NoteComment, error) {
+	commentRepo := repository.NewNoteCommentRepository(db)
+	comments, err := commentRepo.GetAnchoredRootComments(ctx, noteID)
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+
+	index := dto.IndexBlocks(blocks)
+	changed := make([]model.NoteComment, 0)
+	for _, comment := range comments {
+		next := comment
+		location, ok := index[comment.BlockID]
+		if !ok {
+			next.AnchorState = model.NoteAnchorDetached
+		} else {
+			start, end, found := dto.RelocateAnchor(location.Text, comment.AnchorText, comment.AnchorStart, comment.AnchorEnd)
+			next.AnchorParentID = location.ParentID
+			if found {
+				next.AnchorStart, next.AnchorEnd = start, end
+				next.AnchorState = model.NoteAnchorActive
+			} else {
+				next.AnchorState = model.NoteAnchorDetached
+			}
+		}
+
+		if next.AnchorState == comment.AnchorState && next.AnchorParentID == comment.AnchorParentID &&
+			next.AnchorStart == comment.AnchorStart && next.AnchorEnd == comment.AnchorEnd {
+			continue
+		}
+
+		err := commentRepo.UpdateNoteComment(ctx, comment.ID, map[string]interface{}{
+			"anchor_state":     next.AnchorState,
+			"anchor_parent_id": next.AnchorParentID,
+			"anchor_start":     next.AnchorStart,
+			"anchor_end":       next.AnchorEnd,
+		})
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, next)
+	}
+	return changed, nil
+}
+
+// publishNoteAnchorChanges 推送锚点变化，前端据此移动或隐藏高亮
+func publishNoteAnchorChanges(noteID int64, comments []model.NoteComment) {
+	for _, comment := range comments {
+		_ = bus.PublishNoteComment(context.Background(), bus.WsCommentUpdated, noteID, map[string]any{
+			"id":               comment.ID,
+			"block_id":         comment.BlockID,
+			"anchor_start":     comment.AnchorStart,
+			"anchor_end":       comment.AnchorEnd,
+			"anchor_parent_id": comment.AnchorParentID,
+			"anchor_state":     comment.AnchorState,
+		})
+	}
+}
+
+// anchorNoteComment 回复挂在根评论下，锚点由根评论维护；根评论按块内区间截取原文
+func anchorNoteComment(ctx context.Context, note *model.Note, comment *model.NoteComment, parentID int64, blockID string, start, end int) int {
+	if parentID > 0 {
+		parent, err := repository.NewNoteCommentRepository(database.DB).GetNoteCommentByID(ctx, note.WorkspaceID, parentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return message.ERROR_NOTE_COMMENT_NOT_FOUND
+			}
+			return database.IsError(err)
+		}
+		if parent.NoteID != note.ID || parent.ParentID != 0 {
+			return message.ERROR_NOTE_COMMENT_NOT_FOUND
+		}
+		comment.ParentID = parent.ID
+		comment.BlockID = parent.BlockID
+		return 0
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		logger.LogError(err, "Unmarshal note content error")
+		return message.ERROR
+	}
+	location, ok := dto.IndexBlocks(content)[blockID]
+	if !ok {
+		return message.ERROR_NOTE_COMMENT_ANCHOR
+	}
+	runes := []rune(location.Text)
+	if end > len(runes) {
+		return message.ERROR_NOTE_COMMENT_ANCHOR
+	}
+	comment.BlockID = blockID
+	comment.AnchorStart = start
+	comment.AnchorEnd = end
+	comment.AnchorText = string(runes[start:end])
+	comment.AnchorParentID = location.ParentID
+	return 0
+}
+
+// buildNoteCommentMentions 校验 @提及：成员必须属于工作区，区间不能超出评论内容
+func buildNoteCommentMentions(ctx context.Context, workspaceID int64, content string, params []dto.NoteCommentMentionDTO) ([]model.NoteCommentMention, int) {
+	mentions := make([]model.NoteCommentMention, 0, len(params))
+	if len(params) == 0 {
+		return mentions, 0
+	}
+
+	memberIDs := make([]int64, 0, len(params))
+	for _, mention := range params {
+		memberIDs = append(memberIDs, mention.MemberID)
+	}
+	validIDs, err := repository.NewNoteCommentRepository(database.DB).FilterWorkspaceMemberIDs(ctx, workspaceID, memberIDs)
+	if err != nil {
+		return nil, database.IsError(err)
+	}
+	valid := make(map[int64]bool, len(validIDs))
+	for _, id := range validIDs {
+		valid[id] = true
+	}
+
+	contentLen := utf8.RuneCountInString(content)
+	for _, mention := range params {
+		if !valid[mention.MemberID] || mention.EndRune > contentLen {
+			return nil, message.ERROR_INVALID_PARAMS
+		}
+		mentions = append(mentions, model.NoteCommentMention{
+			MemberID:  mention.MemberID,
+			StartRune: mention.StartRune,
+			EndRune:   mention.EndRune,
+		})
+	}
+	return mentions, 0
+}
+
+// buildNoteCommentItems 批量补齐作者、@提及与附件
+func buildNoteCommentItems(ctx context.Context, comments []model.NoteComment, memberID int64) ([]dto.NoteCommentItem, error) {
+	commentRepo := repository.NewNoteCommentRepository(database.DB)
+
+	commentIDs := make([]int64, 0, len(comments))
+	memberIDs := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+		memberIDs = append(memberIDs, comment.MemberID)
+	}
+
+	mentions, err := commentRepo.GetMentionsByCommentIDs(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := commentRepo.GetAttachmentsByCommentIDs(ctx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, mention := range mentions {
+		memberIDs = append(memberIDs, mention.MemberID)
+	}
+
+	members, err := repository.GetWorkspaceMemberByIDs(database.DB, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	memberMap := make(map[int64]*dto.WorkspaceMemberDTO)
+	if members != nil {
+		for i := range *members {
+			memberMap[(*members)[i].ID] = &(*members)[i]
+		}
+	}
+
+	mentionMap := make(map[int64][]dto.NoteCommentMentionResp)
+	for _, mention := range mentions {
+		mentionMap[mention.CommentID] = append(mentionMap[mention.CommentID], dto.NoteCommentMentionResp{
+			MemberID:  mention.MemberID,
+			StartRune: mention.StartRune,
+			EndRune:   mention.EndRune,
+			Member:    memberMap[mention.MemberID],
+		})
+	}
+	attachmentMap := make(map[int64][]dto.NoteCommentAttachmentResp)
+	for _, attachment := range attachments {
+		attachmentMap[attachment.CommentID] = append(attachmentMap[attachment.CommentID], dto.NoteCommentAttachmentResp{
+			ID:            attachment.ID,
+			FileName:      attachment.FileName,
+			FileURL:       attachment.FileURL,
+			FileSize:      attachment.FileSize,
+			FileType:      attachment.FileType,
+			ThumbnailPath: attachment.ThumbnailPath,
+		})
+	}
+
+	items := make([]dto.NoteCommentItem, 0, len(comments))
+	for _, comment := range comments {
+		item := dto.NoteCommentItem{
+			ID:             comment.ID,
+			NoteID:         comment.NoteID,
+			ParentID:       comment.ParentID,
+			BlockID:        comment.BlockID,
+			AnchorStart:    comment.AnchorStart,
+			AnchorEnd:      comment.AnchorEnd,
+			AnchorText:     comment.AnchorText,
+			AnchorParentID: comment.AnchorParentID,
+			AnchorState:    string(comment.AnchorState),
+			Content:        comment.Content,
+			ReplyCount:     comment.ReplyCount,
+			Status:         string(comment.Status),
+			ResolvedBy:     comment.ResolvedBy,
+			ResolvedAt:     comment.ResolvedAt,
+			CreatedAt:      comment.CreatedAt,
+			UpdatedAt:      comment.UpdatedAt,
+			IsAuthor:       memberID != 0 && comment.MemberID == memberID,
+			Author:         memberMap[comment.MemberID],
+			GuestName:      comment.GuestName,
+			Mentions:       mentionMap[comment.ID],
+			Attachments:    attachmentMap[comment.ID],
+		}
+		if item.Mentions == nil {
+			item.Mentions = make([]dto.NoteCommentMentionResp, 0)
+		}
+		if item.Attachments == nil {
+			item.Attachments = make([]dto.NoteCommentAttachmentResp, 0)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,294 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/syncer"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/utils/tools"
+	"strconv"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func ListNoteSyncConflicts(ctx context.Context, params *dto.GetNoteSyncConflictsDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+
+	conflicts, err := repository.NewSyncRepository(database.DB).GetNoteSyncConflicts(ctx, params.NoteID, params.LinkID, params.Status)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	items := make([]map[string]interface{}, 0, len(conflicts))
+	for i := range conflicts {
+		items = append(items, conflicts[i].Data())
+	}
+	return message.SUCCESS, map[string]interface{}{"conflicts": items}
+}
+
+// ResolveNoteSyncConflict 把选定的块写回本地，并生成新版本的 outbox 推送到所有可推送链接；
+// 冲突所在链接以外部当前内容为基线，其它链接沿用本地实际生效的操作
+func ResolveNoteSyncConflict(ctx context.Context, params *dto.ResolveNoteSyncConflictDTO) (responseCode int, data map[string]interface{}) {
+	var (
+		conflict   *model.NoteSyncConflict
+		newVersion int64
+		links      []model.NoteExternalLink
+		effects    *ContentEffects
+	)
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		note, _, code := authorizeNote(ctx, tx, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleEditor)
+		if code != 0 {
+			responseCode = code
+			return fmt.Errorf("no permission")
+		}
+		if lock, code := checkNoteLock(ctx, note.ID, params.UserID); code != 0 {
+			if lock != nil {
+				data = map[string]interface{}{"lock": lock}
+			}
+			responseCode = code
+			return fmt.Errorf("note locked")
+		}
+
+		syncRepo := repository.NewSyncRepository(tx)
+		var err error
+		conflict, err = syncRepo.GetNoteSyncConflictForUpdate(ctx, params.ConflictID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && conflict.NoteID != note.ID) {
+			responseCode = message.ERROR_SYNC_CONFLICT_NOT_FOUND
+			return fmt.Errorf("conflict not found")
+		}
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		if conflict.Status != model.ConflictOpen {
+			responseCode = message.ERROR_SYNC_CONFLICT_RESOLVED
+			return fmt.Errorf("conflict resolved")
+		}
+
+		note, err = syncRepo.GetNoteForUpdate(ctx, note.ID)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		var content dto.Blocks
+		if err := json.Unmarshal(note.Content, &content); err != nil {
+			responseCode = message.ERROR
+			return err
+		}
+		var current *dto.NoteBlockDTO
+		for i := range content {
+			if content[i].ID == conflict.NodeUID {
+				current = &content[i]
+				break
+			}
+		}
+		remote := decodeConflictBlock(conflict.RemoteBlock)
+
+		var final *dto.NoteBlockDTO
+		switch params.Resolution {
+		case model.ResolveLocal:
+			final = current
+		case model.ResolveRemote:
+			final = remote
+		case model.ResolveMerged:
+			final = params.Block
+		}
+		if final != nil {
+			block := *final
+			block.ID = conflict.NodeUID
+			if current != nil {
+				block.Children = current.Children
+			} else {
+				block.Children = nil
+			}
+			final = &block
+		}
+
+		// 本地操作：保留的块覆盖或补回，选择删除时移除
+		ops := make([]dto.PatchOp, 0, 1)
+		switch {
+		case final != nil && current != nil:
+			ops = append(ops, dto.PatchOp{Op: dto.PatchOpUpdate, NodeUID: conflict.NodeUID, Block: final})
+		case final != nil:
+			var after *string
+			if len(content) > 0 {
+				last := content[len(content)-1].ID
+				after = &last
+			}
+			ops = append(ops, dto.PatchOp{Op: dto.PatchOpInsert, NodeUID: conflict.NodeUID, Block: final, AfterID: after})
+		case current != nil:
+			ops = append(ops, dto.PatchOp{Op: dto.PatchOpDelete, NodeUID: conflict.NodeUID})
+		}
+		newContent, effective, err := dto.ApplyPatch(content, ops)
+		if err != nil {
+			var patchErr *dto.PatchError
+			if errors.As(err, &patchErr) {
+				data = map[string]interface{}{"error": patchErr}
+			}
+			responseCode = message.ERROR_BLOCK_PATCH_INVALID
+			return err
+		}
+		raw, err := json.Marshal(newContent)
+		if err != nil {
+			responseCode = message.ERROR
+			return err
+		}
+
+		// 冲突所在链接的回推以外部当前内容为准，即使本地内容未变也推进版本来承载
+		newVersion = note.Version + 1
+		ok, err := syncRepo.UpdateNoteByVersion(ctx, note.ID, note.Version, map[string]interface{}{
+			"content":    raw,
+			"version":    newVersion,
+			"updated_at": time.Now().UTC().Truncate(time.Microsecond),
+		})
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		if !ok {
+			responseCode = message.ERROR_NOTE_UPDATE_CONFLICT
+			return fmt.Errorf("note version changed")
+		}
+		if len(effective) > 0 {
+			if effects, err = ApplyContentEffects(ctx, tx, note, content, newContent); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+		}
+
+		// 映射基线推进到外部当前内容，推送时按 ETag 判断外部是否又有改动
+		linkPatch := make([]dto.PatchOp, 0, 1)
+		if remote != nil {
+			mappings, err := syncRepo.GetBlockMappingByBlockIDs(ctx, note.ID, &conflict.Provider, &[]string{conflict.NodeUID})
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if len(*mappings) > 0 {
+				mapping := (*mappings)[0]
+				mapping.ETag = conflict.RemoteETag
+				mapping.BaseBlock = conflict.RemoteBlock
+				if err := syncRepo.UpsertNoteExternalNodeMappings(ctx, &[]model.NoteExternalNodeMapping{mapping}); err != nil {
+					responseCode = database.IsError(err)
+					return err
+				}
+			}
+			switch {
+			case final == nil:
+				linkPatch = append(linkPatch, dto.PatchOp{Op: dto.PatchOpDelete, NodeUID: conflict.NodeUID})
+			case !syncer.SameBlock(*final, *remote):
+				linkPatch = append(linkPatch, dto.PatchOp{Op: dto.PatchOpUpdate, NodeUID: conflict.NodeUID, Block: final})
+			}
+		} else {
+			// 外部块已删除：去掉映射，保留的块作为新块插回
+			if err := syncRepo.DeleteNoteExternalNodeMappings(ctx, note.ID, conflict.Provider, []string{conflict.ExternalBlockID}); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if final != nil {
+				var after *string
+				for i := range newContent {
+					if newContent[i].ID == conflict.NodeUID {
+						if i > 0 {
+							prev := newContent[i-1].ID
+							after = &prev
+						}
+						break
+					}
+				}
+				linkPatch = append(linkPatch, dto.PatchOp{Op: dto.PatchOpInsert, NodeUID: conflict.NodeUID, Block: final, AfterID: after})
+			}
+		}
+
+		noteLinks, _, err := repository.GetNoteSyncList(tx, ctx, nil, &note.ID, nil)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		effectiveJSON, err := json.Marshal(append(make([]dto.PatchOp, 0, len(effective)), effective...))
+		if err != nil {
+			responseCode = message.ERROR
+			return err
+		}
+		outboxs := make([]model.SyncOutbox, 0)
+		for _, link := range *noteLinks {
+			if link.Direction == model.SyncPullOnly {
+				continue
+			}
+			patchJSON := effectiveJSON
+			if link.ID == conflict.LinkID {
+				if patchJSON, err = json.Marshal(linkPatch); err != nil {
+					responseCode = message.ERROR
+					return err
+				}
+			}
+			links = append(links, link)
+			outboxs = append(outboxs, model.SyncOutbox{
+				NoteID:      note.ID,
+				LinkID:      link.ID,
+				NoteVersion: newVersion,
+				OpType:      "patch",
+				Status:      model.SyncPending,
+				PatchJSON:   patchJSON,
+			})
+		}
+		if len(outboxs) > 0 {
+			if err := syncRepo.CreateSyncOutboxs(ctx, &outboxs); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+		}
+
+		if err := syncRepo.ResolveNoteSyncConflict(ctx, conflict.ID, params.Resolution, params.UserID); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		conflict.Status = model.ConflictResolved
+		conflict.Resolution = &params.Resolution
+		conflict.ResolvedBy = &params.UserID
+		conflict.ResolvedAt = tools.Ptr(time.Now())
+		return nil
+	})
+	if responseCode != 0 {
+		return responseCode, data
+	}
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	effects.Publish(ctx, params.UserID)
+	for i := range links {
+		enqueueLinkDelta(ctx, &links[i], params.WorkspaceID)
+	}
+	_ = bus.PublishWsNote(ctx, strconv.FormatInt(params.NoteID, 10), bus.WsEvent{
+		Type:    bus.WsNoteSynced,
+		NoteID:  strconv.FormatInt(params.NoteID, 10),
+		Payload: map[string]any{"provider": conflict.Provider, "version": newVersion, "conflict_id": strconv.FormatInt(conflict.ID, 10)},
+	})
+
+	return message.SUCCESS, map[string]interface{}{
+		"conflict": conflict.Data(),
+		"version":  newVersion,
+	}
+}
+
+func decodeConflictBlock(raw datatypes.JSON) *dto.NoteBlockDTO {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var block dto.NoteBlockDTO
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil
+	}
+	return &block
+}
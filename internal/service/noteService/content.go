@@ -0,0 +1,51 @@
+package noteService
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// ContentEffects 笔记内容变更派生出的、需要在事务提交后推送的变化
+type ContentEffects struct {
+	noteID     int64
+	anchors    []model.NoteComment
+	activities []types.KanbanActivityPayload
+}
+
+// ApplyContentEffects 笔记内容写入后在同一事务里重建出链、重定位评论锚点并同步清单任务。
+// 编辑、同步拉取与冲突处理等所有写入内容的路径都要调用，提交后再调用 Publish
+func ApplyContentEffects(ctx context.Context, tx *gorm.DB, note *model.Note, before, after dto.Blocks) (*ContentEffects, error) {
+	effects := &ContentEffects{noteID: note.ID}
+	if err := syncNoteLinks(ctx, tx, note.WorkspaceID, note.ID, after); err != nil {
+		logger.LogError(err, "重建笔记链接失败")
+		return nil, err
+	}
+
+	var err error
+	effects.anchors, err = relocateNoteCommentAnchors(ctx, tx, note.ID, after)
+	if err != nil {
+		logger.LogError(err, "更新评论锚点失败")
+		return nil, err
+	}
+
+	effects.activities, err = syncChecklistTasks(ctx, tx, note, before, after)
+	if err != nil {
+		logger.LogError(err, "同步清单关联任务失败")
+		return nil, err
+	}
+	return effects, nil
+}
+
+// Publish 推送锚点变化并写入任务动态，userID 为本次修改的操作者
+func (e *ContentEffects) Publish(ctx context.Context, userID int64) {
+	if e == nil {
+		return
+	}
+	publishNoteAnchorChanges(e.noteID, e.anchors)
+	emitChecklistActivities(ctx, userID, e.activities)
+}
@@ -3,12 +3,15 @@ package noteService
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"gin-notebook/internal/http/message"
 	"gin-notebook/internal/model"
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/pkg/integration/feishu"
+	"gin-notebook/internal/pkg/integration/notion"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
 	"gin-notebook/internal/tasks/asynq/enqueue"
 	"gin-notebook/internal/tasks/asynq/types"
 	"gin-notebook/pkg/logger"
@@ -24,7 +27,24 @@ import (
 
 func CreateNoteCategory(params *dto.CreateNoteCategoryDTO) (responseCode int, data any) {
 	categroyModel := params.ToModel()
-	_, err := repository.CreateNoteCategory(categroyModel)
+
+	if params.ParentID != nil {
+		if _, err := repository.GetNoteCategoryByID(database.DB, params.WorkspaceID, *params.ParentID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return message.ERROR_CATE_PARENT_NOT_EXIST, nil
+			}
+			return database.IsError(err), nil
+		}
+	}
+
+	// 新分类默认追加到同级末尾
+	last, err := repository.GetSiblingEdgeOrderIndex(database.DB, params.WorkspaceID, params.ParentID, false)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	categroyModel.OrderIndex = categoryRankBetween(last, "")
+
+	_, err = repository.CreateNoteCategory(categroyModel)
 	if err != nil {
 		return message.ERROR_DATABASE, nil
 	}
@@ -48,7 +68,17 @@ func CreateNote(param *dto.CreateWorkspaceNoteDTO) (responseCode int, data *dto.
 			return message.ERROR, nil
 		}
 		param.Content = &content
+
+		if err = syncNoteLinks(context.Background(), database.DB, noteModel.WorkspaceID, noteModel.ID, content); err != nil {
+			logger.LogError(err, "创建笔记链接失败")
+		}
 	}
+	webhookService.Dispatch(context.Background(), noteModel.WorkspaceID, 0, model.WebhookNoteCreated, map[string]interface{}{
+		"note_id":     strconv.FormatInt(noteModel.ID, 10),
+		"title":       noteModel.Title,
+		"category_id": strconv.FormatInt(noteModel.CategoryID, 10),
+		"owner_id":    strconv.FormatInt(noteModel.OwnerID, 10),
+	})
 	responseCode = message.SUCCESS
 	data = param
 	return
@@ -114,25 +144,46 @@ func CreateTemplateNote(params *dto.CreateTemplateNoteDTO) (responseCode int, da
 
 	templateNote.Content = content
 
+	if !validateTemplateVariables(params.Variables) {
+		return message.ERROR_TEMPLATE_VARIABLE, nil
+	}
+	variables := params.Variables
+	if variables == nil {
+		variables = []dto.TemplateVariableDTO{}
+	}
+	templateNote.Variables = datatypes.JSON(tools.MustJSONBytes(variables))
+	templateNote.WorkspaceID = &params.WorkspaceID
+
 	err := repository.CreateTemplateNote(database.DB, &templateNote)
 	if err != nil {
 		return message.ERROR_DATABASE, nil
 	}
 
 	data = &dto.TemplateNote{
-		ID:        templateNote.ID,
-		Title:     templateNote.Title,
-		Content:   params.Content,
-		IsPublic:  templateNote.IsPublic,
-		Cover:     templateNote.Cover,
-		CreatedAt: templateNote.CreatedAt,
-		UpdatedAt: templateNote.UpdatedAt,
+		ID:           templateNote.ID,
+		Title:        templateNote.Title,
+		Content:      params.Content,
+		IsPublic:     templateNote.IsPublic,
+		Cover:        templateNote.Cover,
+		WorkspaceID:  templateNote.WorkspaceID,
+		Description:  templateNote.Description,
+		Variables:    variables,
+		Placeholders: dto.ExtractTemplatePlaceholders(templateNote.Title, params.Content),
+		CreatedAt:    templateNote.CreatedAt,
+		UpdatedAt:    templateNote.UpdatedAt,
 	}
 	return
 }
 
 func GetTemplateNotes(params *dto.GetTemplateNotesDTO) (responseCode int, data *dto.ListResultDTO[dto.TemplateNote]) {
-	templateNotes, total, err := repository.GetTemplateNotes(database.DB, params.UserID, params.Limit, params.Offset)
+	var templateNotes *[]model.TemplateNote
+	var total int64
+	var err error
+	if params.Scope == dto.TemplateScopeWorkspace {
+		templateNotes, total, err = repository.GetWorkspaceTemplateNotes(database.DB, params.WorkspaceID, params.Keywords, params.Limit, params.Offset)
+	} else {
+		templateNotes, total, err = repository.GetTemplateNotes(database.DB, params.UserID, params.Limit, params.Offset)
+	}
 
 	if err != nil {
 		responseCode = database.IsError(err)
@@ -168,22 +219,12 @@ func GetTemplateNotes(params *dto.GetTemplateNotesDTO) (responseCode int, data *
 
 	notes := make([]dto.TemplateNote, 0, len(*templateNotes))
 	for note := range *templateNotes {
-		var content dto.Blocks
-		if err = json.Unmarshal((*templateNotes)[note].Content, &content); err != nil {
+		item, err := toTemplateNoteDTO(&(*templateNotes)[note], userMap[(*templateNotes)[note].OwnerID])
+		if err != nil {
 			logger.LogError(err, "Unmarshal note content error")
 			continue
 		}
-
-		notes = append(notes, dto.TemplateNote{
-			ID:        (*templateNotes)[note].ID,
-			Title:     (*templateNotes)[note].Title,
-			Content:   content,
-			IsPublic:  (*templateNotes)[note].IsPublic,
-			Cover:     (*templateNotes)[note].Cover,
-			CreatedAt: (*templateNotes)[note].CreatedAt,
-			UpdatedAt: (*templateNotes)[note].UpdatedAt,
-			User:      userMap[(*templateNotes)[note].OwnerID],
-		})
+		notes = append(notes, item)
 	}
 
 	data = &dto.ListResultDTO[dto.TemplateNote]{
@@ -195,6 +236,11 @@ func GetTemplateNotes(params *dto.GetTemplateNotesDTO) (responseCode int, data *
 }
 
 func AddNoteSync(ctx context.Context, params *dto.AddNoteSyncDTO) (responseCode int, data map[string]interface{}) {
+	// 同步会把远端内容写回笔记，需要 editor 权限
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleEditor); code != 0 {
+		return code, nil
+	}
+
 	// 0) 事务外校验（可选但推荐，避免长事务）
 	//    - 校验集成账号有效
 	//    - 如果前置要求必须是已存在的 Feishu 文档，校验 meta（否则可以把 meta 校验挪到 init 任务里做）
@@ -203,14 +249,37 @@ func AddNoteSync(ctx context.Context, params *dto.AddNoteSyncDTO) (responseCode
 	if err != nil {
 		return database.IsError(err), nil
 	}
-	if account.IsActive == false || account.AccessTokenExpiry == nil || account.AccessTokenExpiry.Before(time.Now()) {
+	if account.ID == 0 {
+		return message.ERROR_INTEGRATION_ACCOUNT_NOT_FOUND, nil
+	}
+	// 没有过期时间的令牌（如 Notion）视为长期有效
+	if !account.IsActive || (account.AccessTokenExpiry != nil && account.AccessTokenExpiry.Before(time.Now())) {
 		return message.ERROR_INTEGRATION_ACCOUNT_EXPIRED, nil
 	}
 
-	// （可选）若需要在创建策略前就确认远端 doc 存在：
-	meta, err := feishu.GetClient().GetFileMeta(ctx, params.TargetNoteID, "docx", account.AccessTokenEnc)
-	if err != nil || len(meta.Metas) == 0 {
-		return message.ERROR_FEISHU_GET_FILE_META_FAILED, nil
+	// 创建策略前确认远端文档存在
+	resType := model.ExternalResourceType("docx")
+	var targetURL *string
+	switch params.Provider {
+	case model.ProviderFeishu:
+		meta, err := feishu.GetClient().GetFileMeta(ctx, params.TargetNoteID, "docx", account.AccessTokenEnc)
+		if err != nil || len(meta.Metas) == 0 {
+			return message.ERROR_FEISHU_GET_FILE_META_FAILED, nil
+		}
+	case model.ProviderNotion:
+		page, err := notion.GetClient().RetrievePage(ctx, account.AccessTokenEnc, params.TargetNoteID)
+		if err != nil {
+			if notion.IsNotFound(err) {
+				return message.ERROR_NOTION_PAGE_NOT_FOUND, nil
+			}
+			logger.LogError(err, "获取 Notion 页面失败")
+			return message.ERROR_NOTION_TOKEN_INVALID, nil
+		}
+		if page.Archived {
+			return message.ERROR_NOTION_PAGE_NOT_FOUND, nil
+		}
+		resType = model.ExtTypePage
+		targetURL = &page.URL
 	}
 
 	var syncID int64
@@ -234,9 +303,10 @@ func AddNoteSync(ctx context.Context, params *dto.AddNoteSyncDTO) (responseCode
 			Mode:           params.Mode,
 			Direction:      params.Direction,
 			TargetNoteID:   params.TargetNoteID, // 若允许「绑定已存在的 Feishu 文档」
+			TargetNoteURL:  targetURL,
 			ConflictPolicy: params.ConflictPolicy,
 			ContentVersion: note.Version, // baseVersion
-			ResType:        "docx",
+			ResType:        resType,
 			LastStatus:     model.SyncPending, // ★ 新增：初始化状态
 			IsActive:       true,              // 建议默认为启用
 		}
@@ -0,0 +1,391 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// loadDailyNoteSetting 读取设置；未配置时返回默认值（不落库）
+func loadDailyNoteSetting(ctx context.Context, db *gorm.DB, workspaceID, userID int64) (*model.DailyNoteSetting, error) {
+	setting, err := repository.NewDailyNoteRepository(db).GetSetting(ctx, workspaceID, userID)
+	if err == nil {
+		return setting, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	includeAgenda := true
+	return &model.DailyNoteSetting{
+		WorkspaceID:   workspaceID,
+		UserID:        userID,
+		Timezone:      dto.DefaultDailyNoteTZ,
+		IncludeAgenda: &includeAgenda,
+	}, nil
+}
+
+func dailyNoteLocation(setting *model.DailyNoteSetting) *time.Location {
+	if loc, err := time.LoadLocation(setting.Timezone); err == nil {
+		return loc
+	}
+	loc, _ := time.LoadLocation(dto.DefaultDailyNoteTZ)
+	if loc == nil {
+		return time.Local
+	}
+	return loc
+}
+
+func toDailyNoteSettingDTO(setting *model.DailyNoteSetting) dto.DailyNoteSettingDTO {
+	return dto.DailyNoteSettingDTO{
+		TemplateID:    setting.TemplateID,
+		CategoryID:    setting.CategoryID,
+		Timezone:      setting.Timezone,
+		IncludeAgenda: setting.IncludeAgenda == nil || *setting.IncludeAgenda,
+	}
+}
+
+// GetDailyNote 返回指定日期的每日笔记，首次访问时按设置的模板创建
+func GetDailyNote(ctx context.Context, params *dto.GetDailyNoteDTO) (responseCode int, data map[string]interface{}) {
+	db := database.DB.WithContext(ctx)
+	setting, err := loadDailyNoteSetting(ctx, db, params.WorkspaceID, params.UserID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	loc := dailyNoteLocation(setting)
+
+	now := time.Now().In(loc)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if params.Date != "" {
+		if day, err = time.ParseInLocation(dto.DailyNoteDateLayout, params.Date, loc); err != nil {
+			return message.ERROR_INVALID_PARAMS, nil
+		}
+	}
+	date := day.Format(dto.DailyNoteDateLayout)
+
+	agenda, code := dailyAgenda(ctx, db, params, day, loc)
+	if code != 0 {
+		return code, nil
+	}
+
+	dailyRepo := repository.NewDailyNoteRepository(db)
+	daily, err := dailyRepo.GetDailyNote(ctx, params.WorkspaceID, params.UserID, date)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return database.IsError(err), nil
+	}
+	if daily != nil {
+		note, err := repository.GetNoteByID(db, ctx, params.WorkspaceID, daily.NoteID)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		// 笔记被删除（进入回收站）后再次访问会重新生成
+		if note.ID != 0 {
+			return message.SUCCESS, map[string]interface{}{
+				"date":    date,
+				"note":    note,
+				"created": false,
+				"agenda":  agenda,
+			}
+		}
+	}
+
+	note, templateID, code := createDailyNote(ctx, params, setting, daily, day, agenda, loc)
+	if code == message.ERROR_DAILY_NOTE_EXISTS {
+		// 并发请求已先创建，直接返回已有的笔记
+		if daily, err = dailyRepo.GetDailyNote(ctx, params.WorkspaceID, params.UserID, date); err != nil {
+			return database.IsError(err), nil
+		}
+		if note, err = repository.GetNoteByID(db, ctx, params.WorkspaceID, daily.NoteID); err != nil {
+			return database.IsError(err), nil
+		}
+		return message.SUCCESS, map[string]interface{}{
+			"date":    date,
+			"note":    note,
+			"created": false,
+			"agenda":  agenda,
+		}
+	}
+	if code != 0 {
+		return code, nil
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err == nil {
+		if err := syncNoteLinks(ctx, database.DB, params.WorkspaceID, note.ID, content); err != nil {
+			logger.LogError(err, "创建笔记链接失败")
+		}
+	}
+	if templateID != 0 {
+		if err := repository.IncreaseTemplateUsage(db, templateID); err != nil {
+			logger.LogError(err, "更新模板使用次数失败")
+		}
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"date":    date,
+		"note":    note,
+		"created": true,
+		"agenda":  agenda,
+	}
+}
+
+// dailyAgenda 当天到期的任务和用户自己的日程
+func dailyAgenda(ctx context.Context, db *gorm.DB, params *dto.GetDailyNoteDTO, day time.Time, loc *time.Location) (dto.DailyAgendaDTO, int) {
+	agenda := dto.DailyAgendaDTO{Tasks: []dto.DailyAgendaTaskDTO{}, Events: []dto.EventDTO{}}
+
+	tasks, err := repository.NewDailyNoteRepository(db).ListDueTasks(ctx, params.WorkspaceID, params.UserID, params.MemberID, day)
+	if err != nil {
+		return agenda, database.IsError(err)
+	}
+	agenda.Tasks = tasks
+
+	events, err := repository.GetEvents(db, params.WorkspaceID, day, day.AddDate(0, 0, 1), &params.UserID)
+	if err != nil {
+		return agenda, database.IsError(err)
+	}
+	if events != nil {
+		agenda.Events = events
+	}
+	return agenda, 0
+}
+
+// dailyNoteContent 渲染模板得到标题和内容；模板已删除或不可见时退回内置模板
+func dailyNoteContent(params *dto.GetDailyNoteDTO, setting *model.DailyNoteSetting, day time.Time, agenda dto.DailyAgendaDTO, loc *time.Location) (string, dto.Blocks, int64) {
+	content := dto.DefaultDailyNoteBlocks()
+	title := ""
+	definitions := make([]dto.TemplateVariableDTO, 0)
+	var templateID int64
+
+	if setting.TemplateID != nil {
+		template, err := repository.GetTemplateNoteByID(database.DB, *setting.TemplateID)
+		switch {
+		case err != nil:
+			logger.LogError(err, "获取每日笔记模板失败，使用内置模板")
+		case !templateVisible(template, params.UserID, params.WorkspaceID):
+			logger.LogInfo("每日笔记模板不可见，使用内置模板", map[string]interface{}{"template_id": template.ID})
+		default:
+			var blocks dto.Blocks
+			if err := json.Unmarshal(template.Content, &blocks); err != nil {
+				logger.LogError(err, "Unmarshal template content error")
+				break
+			}
+			if len(template.Variables) > 0 {
+				if err := json.Unmarshal(template.Variables, &definitions); err != nil {
+					logger.LogError(err, "Unmarshal template variables error")
+				}
+			}
+			content, title, templateID = blocks, template.Title, template.ID
+		}
+	}
+
+	// 日期类占位符取笔记对应的日期，时刻取当前时间
+	now := time.Now().In(loc)
+	moment := time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, loc)
+	workspaceName := ""
+	if workspace, err := repository.GetWorkspaceByID(params.WorkspaceID, params.UserID); err == nil {
+		workspaceName = workspace.Name
+	}
+	values := dto.BuiltinTemplateValues(moment, templateUserName(params.UserID, params.WorkspaceID), "", workspaceName, "")
+
+	// 每日笔记无法向用户提问，自定义变量只取默认值
+	varTypes := make(map[string]dto.TemplateVariableType, len(definitions))
+	for _, def := range definitions {
+		if def.Default != "" && validTemplateValue(def, def.Default) {
+			values[def.Key] = def.Default
+			varTypes[def.Key] = def.Type
+		}
+	}
+
+	title = truncateRunes(dto.RenderTemplateText(title, values, varTypes), 100)
+	if title == "" {
+		title = day.Format(dto.DailyNoteDateLayout)
+	}
+	values[dto.TemplateVarTitle] = title
+	content = dto.RenderTemplateBlocks(content, values, varTypes)
+
+	if setting.IncludeAgenda == nil || *setting.IncludeAgenda {
+		content = append(content, dto.DailyAgendaBlocks(agenda, loc)...)
+	}
+	return title, content, templateID
+}
+
+// createDailyNote 在专用分类下创建笔记并登记到每日笔记索引。
+// daily 不为空表示原笔记已被删除，需要改为指向新笔记。
+func createDailyNote(ctx context.Context, params *dto.GetDailyNoteDTO, setting *model.DailyNoteSetting, daily *model.DailyNote, day time.Time, agenda dto.DailyAgendaDTO, loc *time.Location) (note *model.Note, templateID int64, responseCode int) {
+	title, content, templateID := dailyNoteContent(params, setting, day, agenda, loc)
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dailyRepo := repository.NewDailyNoteRepository(tx)
+
+		categoryID, err := ensureDailyNoteCategory(ctx, tx, setting, params.MemberID)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		noteParams := &dto.CreateWorkspaceNoteDTO{
+			WorkspaceID: params.WorkspaceID,
+			OwnerID:     params.UserID,
+			Title:       title,
+			Content:     &content,
+			CategoryID:  categoryID,
+		}
+		note = noteParams.ToModel([]string{})
+		if err := dailyRepo.CreateNote(ctx, note); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		var linked bool
+		if daily != nil {
+			linked, err = dailyRepo.RelinkDailyNote(ctx, daily.ID, daily.NoteID, note.ID)
+		} else {
+			linked, err = dailyRepo.CreateDailyNote(ctx, &model.DailyNote{
+				WorkspaceID: params.WorkspaceID,
+				UserID:      params.UserID,
+				Date:        day.Format(dto.DailyNoteDateLayout),
+				NoteID:      note.ID,
+			})
+		}
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		if !linked {
+			// 回滚刚创建的笔记，交由调用方返回已有的那篇
+			responseCode = message.ERROR_DAILY_NOTE_EXISTS
+			return errors.New("daily note already exists")
+		}
+		return nil
+	})
+
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return nil, 0, responseCode
+	}
+	return note, templateID, 0
+}
+
+// ensureDailyNoteCategory 返回每日笔记专用分类，不存在时在根目录末尾新建并记入设置
+func ensureDailyNoteCategory(ctx context.Context, tx *gorm.DB, setting *model.DailyNoteSetting, memberID int64) (int64, error) {
+	if setting.CategoryID != nil {
+		_, err := repository.GetNoteCategoryByID(tx, setting.WorkspaceID, *setting.CategoryID)
+		if err == nil {
+			return *setting.CategoryID, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+	}
+
+	last, err := repository.GetSiblingEdgeOrderIndex(tx, setting.WorkspaceID, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	category := &model.NoteCategory{
+		WorkspaceID:  setting.WorkspaceID,
+		CategoryName: dto.DailyNoteCategoryName,
+		OwnerID:      memberID,
+		OrderIndex:   categoryRankBetween(last, ""),
+	}
+	dailyRepo := repository.NewDailyNoteRepository(tx)
+	if err := dailyRepo.CreateCategory(ctx, category); err != nil {
+		return 0, err
+	}
+
+	setting.CategoryID = &category.ID
+	if err := dailyRepo.SaveSetting(ctx, setting); err != nil {
+		return 0, err
+	}
+	return category.ID, nil
+}
+
+// GetDailyNoteCalendar 按月返回已有每日笔记的日期索引
+func GetDailyNoteCalendar(ctx context.Context, params *dto.DailyNoteCalendarDTO) (responseCode int, data map[string]interface{}) {
+	db := database.DB.WithContext(ctx)
+	setting, err := loadDailyNoteSetting(ctx, db, params.WorkspaceID, params.UserID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	loc := dailyNoteLocation(setting)
+
+	now := time.Now().In(loc)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	if params.Month != "" {
+		if month, err = time.ParseInLocation(dto.DailyNoteMonthLayout, params.Month, loc); err != nil {
+			return message.ERROR_INVALID_PARAMS, nil
+		}
+	}
+	from := month.Format(dto.DailyNoteDateLayout)
+	to := month.AddDate(0, 1, -1).Format(dto.DailyNoteDateLayout)
+
+	items, err := repository.NewDailyNoteRepository(db).ListDailyNotes(ctx, params.WorkspaceID, params.UserID, from, to)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{
+		"month": month.Format(dto.DailyNoteMonthLayout),
+		"today": now.Format(dto.DailyNoteDateLayout),
+		"items": items,
+	}
+}
+
+func GetDailyNoteSetting(ctx context.Context, workspaceID, userID int64) (responseCode int, data *dto.DailyNoteSettingDTO) {
+	setting, err := loadDailyNoteSetting(ctx, database.DB.WithContext(ctx), workspaceID, userID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	result := toDailyNoteSettingDTO(setting)
+	return message.SUCCESS, &result
+}
+
+func UpdateDailyNoteSetting(ctx context.Context, params *dto.UpdateDailyNoteSettingDTO) (responseCode int, data *dto.DailyNoteSettingDTO) {
+	db := database.DB.WithContext(ctx)
+	setting, err := loadDailyNoteSetting(ctx, db, params.WorkspaceID, params.UserID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	if params.TemplateID != nil {
+		if *params.TemplateID == 0 {
+			setting.TemplateID = nil
+		} else {
+			template, err := repository.GetTemplateNoteByID(db, *params.TemplateID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return message.ERROR_TEMPLATE_NOT_FOUND, nil
+				}
+				return database.IsError(err), nil
+			}
+			if !templateVisible(template, params.UserID, params.WorkspaceID) {
+				return message.ERROR_TEMPLATE_NOT_FOUND, nil
+			}
+			setting.TemplateID = &template.ID
+		}
+	}
+	if params.Timezone != nil {
+		if _, err := time.LoadLocation(*params.Timezone); err != nil {
+			return message.ERROR_INVALID_PARAMS, nil
+		}
+		setting.Timezone = *params.Timezone
+	}
+	if params.IncludeAgenda != nil {
+		setting.IncludeAgenda = params.IncludeAgenda
+	}
+
+	if err := repository.NewDailyNoteRepository(db).SaveSetting(ctx, setting); err != nil {
+		return database.IsError(err), nil
+	}
+	result := toDailyNoteSettingDTO(setting)
+	return message.SUCCESS, &result
+}
@@ -2,24 +2,46 @@ package noteService
 
 import (
 	"context"
+	"errors"
 	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/tools"
+	"strconv"
 
 	"gorm.io/gorm"
 )
 
-func DeleteNote(params *dto.DeleteNoteCategoryDTO) (responseCode int, data any) {
+func DeleteNote(ctx context.Context, params *dto.DeleteNoteCategoryDTO) (responseCode int, data any) {
+	if params.OwnerID == nil {
+		return message.ERROR_NOTE_NO_PERMISSION, nil
+	}
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.ID, *params.OwnerID, model.NoteRoleOwner); code != 0 {
+		return code, nil
+	}
+
 	err := repository.DeleteNote(params.ID)
 	if err != nil {
 		return message.ERROR_NOTE_DELETE, nil
 	}
+	webhookService.Dispatch(ctx, params.WorkspaceID, 0, model.WebhookNoteDeleted, map[string]interface{}{
+		"note_id":  strconv.FormatInt(params.ID, 10),
+		"actor_id": strconv.FormatInt(*params.OwnerID, 10),
+	})
 	responseCode = message.SUCCESS
 	return
 }
 
 func DeleteSync(ctx context.Context, params *dto.DeleteNoteSyncDTO) (respondeCode int) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleEditor); code != 0 {
+		return code
+	}
+
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		syncRepo := repository.NewSyncRepository(tx)
 
@@ -44,6 +66,11 @@ func DeleteSync(ctx context.Context, params *dto.DeleteNoteSyncDTO) (respondeCod
 			return err
 		}
 
+		if err := syncRepo.DeleteNoteSyncConflicts(ctx, params.SyncID); err != nil {
+			respondeCode = database.IsError(err)
+			return err
+		}
+
 		return nil
 	})
 
@@ -57,3 +84,117 @@ func DeleteSync(ctx context.Context, params *dto.DeleteNoteSyncDTO) (respondeCod
 	respondeCode = message.SUCCESS
 	return
 }
+
+// DeleteNoteCategory 删除分类：move 模式把子分类与笔记移动到目标分类；cascade 模式连同子孙分类一起删除，笔记移入回收站。
+// 只有工作区成员可以删除，cascade 模式还要求是分类创建者或管理员
+func DeleteNoteCategory(ctx context.Context, params *dto.DeleteCategoryTreeDTO) (responseCode int, data map[string]interface{}) {
+	_, isAdmin, code := workspaceMember(params.UserID, params.WorkspaceID)
+	if code != 0 {
+		return code, nil
+	}
+
+	var deletedIDs []int64
+	var trashedNotes int64
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.WithContext(ctx)
+
+		category, err := repository.GetNoteCategoryByID(tx, params.WorkspaceID, params.ID, repository.WithLock())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseCode = message.ERROR_CATE_NOT_EXIST
+			} else {
+				responseCode = database.IsError(err)
+			}
+			return err
+		}
+
+		descendants, err := repository.GetCategoryDescendantIDs(tx, params.WorkspaceID, category.ID)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		switch params.Mode {
+		case dto.CategoryDeleteMoveContents:
+			// 目标分类不能是被删除分类本身或其子孙，否则会成环
+			if tools.Contains(descendants, *params.TargetID) {
+				responseCode = message.ERROR_CATE_DELETE_TARGET_INVALID
+				return gorm.ErrInvalidData
+			}
+			if _, err := repository.GetNoteCategoryByID(tx, params.WorkspaceID, *params.TargetID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					responseCode = message.ERROR_CATE_DELETE_TARGET_INVALID
+				} else {
+					responseCode = database.IsError(err)
+				}
+				return err
+			}
+
+			if err := repository.ReparentNoteCategories(tx, category.ID, params.TargetID); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if err := repository.MoveNotesToCategory(tx, []int64{category.ID}, *params.TargetID); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			deletedIDs = []int64{category.ID}
+		case dto.CategoryDeleteCascade:
+			if !isAdmin && category.OwnerID != params.UserID {
+				responseCode = message.ERROR_CATE_NO_PERMISSION
+				return gorm.ErrInvalidData
+			}
+			total, err := repository.CountNoteCategories(tx, params.WorkspaceID)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if total <= int64(len(descendants)) {
+				responseCode = message.ERROR_CATE_DELETE_LAST
+				return gorm.ErrInvalidData
+			}
+
+			trashedNotes, err = repository.TrashNotesByCategoryIDs(tx, descendants)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			deletedIDs = descendants
+		default:
+			responseCode = message.ERROR_INVALID_PARAMS
+			return gorm.ErrInvalidData
+		}
+
+		if err := repository.DeleteNoteCategoriesByIDs(tx, params.WorkspaceID, deletedIDs); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return
+	}
+
+	if cache.RedisInstance != nil {
+		if err := cache.RedisInstance.DelNoteCategoryMap(ctx, deletedIDs...); err != nil {
+			logger.LogError(err, "清理分类缓存失败")
+		}
+	}
+
+	ids := make([]string, 0, len(deletedIDs))
+	for _, id := range deletedIDs {
+		ids = append(ids, strconv.FormatInt(id, 10))
+	}
+
+	data = map[string]interface{}{
+		"deleted_categories": ids,
+		"trashed_notes":      trashedNotes,
+	}
+	responseCode = message.SUCCESS
+	return
+}
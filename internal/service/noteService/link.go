@@ -0,0 +1,253 @@
+package noteService
+
+import (
+	"context"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type noteLinkKey struct {
+	Type model.NoteLinkTargetType
+	ID   int64
+}
+
+// syncNoteLinks 根据笔记最新内容重建出链。
+// [[标题]] 优先沿用上一次解析到的目标，这样目标笔记改名后旧的引用依旧有效。
+func syncNoteLinks(ctx context.Context, db *gorm.DB, workspaceID, noteID int64, blocks dto.Blocks) error {
+	linkRepo := repository.NewLinkRepository(db)
+	refs := dto.ExtractNoteRefs(blocks)
+
+	existing, err := linkRepo.GetLinksBySource(ctx, noteID)
+	if err != nil {
+		return err
+	}
+	previousByTitle := make(map[string]int64, len(existing))
+	for _, link := range existing {
+		if link.TargetType == model.NoteLinkTargetNote && link.TargetTitle != "" {
+			previousByTitle[strings.ToLower(link.TargetTitle)] = link.TargetID
+		}
+	}
+
+	unresolved := make([]string, 0)
+	noteIDs := make([]int64, 0)
+	taskIDs := make([]int64, 0)
+	for _, ref := range refs {
+		switch {
+		case ref.TargetID > 0 && ref.Type == dto.NoteRefTask:
+			taskIDs = append(taskIDs, ref.TargetID)
+		case ref.TargetID > 0:
+			noteIDs = append(noteIDs, ref.TargetID)
+		default:
+			if id, ok := previousByTitle[strings.ToLower(ref.Title)]; ok {
+				noteIDs = append(noteIDs, id)
+			} else {
+				unresolved = append(unresolved, ref.Title)
+			}
+		}
+	}
+
+	resolved, err := linkRepo.ResolveNoteTitles(ctx, workspaceID, unresolved)
+	if err != nil {
+		return err
+	}
+	for _, id := range resolved {
+		noteIDs = append(noteIDs, id)
+	}
+
+	// 过滤掉不存在或不属于当前工作区的目标
+	validNotes, err := linkRepo.FilterExistingNoteIDs(ctx, workspaceID, noteIDs)
+	if err != nil {
+		return err
+	}
+	validTasks, err := linkRepo.FilterExistingTaskIDs(ctx, workspaceID, taskIDs)
+	if err != nil {
+		return err
+	}
+	valid := make(map[noteLinkKey]bool, len(validNotes)+len(validTasks))
+	for _, id := range validNotes {
+		valid[noteLinkKey{model.NoteLinkTargetNote, id}] = true
+	}
+	for _, id := range validTasks {
+		valid[noteLinkKey{model.NoteLinkTargetTask, id}] = true
+	}
+
+	links := make([]model.NoteLink, 0, len(refs))
+	seen := make(map[noteLinkKey]bool, len(refs))
+	for _, ref := range refs {
+		key := noteLinkKey{Type: model.NoteLinkTargetType(ref.Type), ID: ref.TargetID}
+		if ref.TargetID == 0 {
+			title := strings.ToLower(ref.Title)
+			if id, ok := previousByTitle[title]; ok {
+				key.ID = id
+			} else {
+				key.ID = resolved[title]
+			}
+		}
+
+		if key.ID == 0 || !valid[key] || seen[key] {
+			continue
+		}
+		// 自引用没有意义
+		if key.Type == model.NoteLinkTargetNote && key.ID == noteID {
+			continue
+		}
+		seen[key] = true
+
+		links = append(links, model.NoteLink{
+			WorkspaceID:  workspaceID,
+			SourceNoteID: noteID,
+			TargetType:   key.Type,
+			TargetID:     key.ID,
+			TargetTitle:  ref.Title,
+			BlockID:      ref.BlockID,
+		})
+	}
+
+	return linkRepo.ReplaceSourceLinks(ctx, noteID, links)
+}
+
+// GetNoteBacklinks 需要目标笔记可读，且只返回成员可读的来源笔记
+func GetNoteBacklinks(ctx context.Context, params *dto.NoteBacklinkQueryDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+	member, _, code := workspaceMember(params.UserID, params.WorkspaceID)
+	if code != 0 {
+		return code, nil
+	}
+
+	linkRepo := repository.NewLinkRepository(database.DB)
+	links, err := linkRepo.GetBacklinks(ctx, params.WorkspaceID, model.NoteLinkTargetNote, params.NoteID)
+	if err != nil {
+		logger.LogError(err, "获取笔记反向链接失败")
+		return database.IsError(err), nil
+	}
+
+	sourceIDs := make([]int64, 0, len(links))
+	for _, link := range links {
+		sourceIDs = append(sourceIDs, link.NoteID)
+	}
+	sources, err := linkRepo.GetLinkNotes(ctx, params.WorkspaceID, sourceIDs)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	readable, err := readableNotes(ctx, database.DB, sources, member)
+	if err != nil {
+		logger.LogError(err, "计算笔记权限失败")
+		return message.ERROR, nil
+	}
+	backlinks := make([]dto.NoteBacklinkDTO, 0, len(links))
+	for _, link := range links {
+		if readable[link.NoteID] {
+			backlinks = append(backlinks, link)
+		}
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"backlinks": backlinks,
+		"total":     len(backlinks),
+	}
+}
+
+// GetNoteLinkGraph 节点与边只包含成员可读的笔记
+func GetNoteLinkGraph(ctx context.Context, params *dto.NoteGraphQueryDTO) (responseCode int, data map[string]interface{}) {
+	member, _, code := workspaceMember(params.UserID, params.WorkspaceID)
+	if code != 0 {
+		return code, nil
+	}
+
+	linkRepo := repository.NewLinkRepository(database.DB)
+	notes, err := linkRepo.GetGraphNotes(ctx, params.WorkspaceID)
+	if err != nil {
+		logger.LogError(err, "获取笔记关系图节点失败")
+		return database.IsError(err), nil
+	}
+	readable, err := readableNotes(ctx, database.DB, notes, member)
+	if err != nil {
+		logger.LogError(err, "计算笔记权限失败")
+		return message.ERROR, nil
+	}
+	nodes := make([]dto.NoteGraphNodeDTO, 0, len(readable))
+	for _, note := range notes {
+		if !readable[note.ID] {
+			continue
+		}
+		node := dto.NoteGraphNodeDTO{ID: note.ID, Title: note.Title, Type: "note"}
+		if note.CategoryID != 0 {
+			categoryID := note.CategoryID
+			node.CategoryID = &categoryID
+		}
+		nodes = append(nodes, node)
+	}
+
+	links, err := linkRepo.GetWorkspaceLinks(ctx, params.WorkspaceID)
+	if err != nil {
+		logger.LogError(err, "获取笔记关系图边失败")
+		return database.IsError(err), nil
+	}
+
+	noteSet := make(map[int64]bool, len(nodes))
+	for _, node := range nodes {
+		noteSet[node.ID] = true
+	}
+
+	edges := make([]dto.NoteGraphEdgeDTO, 0, len(links))
+	taskIDs := make([]int64, 0)
+	taskSet := make(map[int64]bool)
+	for _, link := range links {
+		// 来源笔记不可读时不展示这条边
+		if !noteSet[link.SourceNoteID] {
+			continue
+		}
+		switch link.TargetType {
+		case model.NoteLinkTargetNote:
+			// 目标笔记已删除或不可读时不展示这条边
+			if !noteSet[link.TargetID] {
+				continue
+			}
+		case model.NoteLinkTargetTask:
+			if !taskSet[link.TargetID] {
+				taskSet[link.TargetID] = true
+				taskIDs = append(taskIDs, link.TargetID)
+			}
+		}
+		edges = append(edges, dto.NoteGraphEdgeDTO{
+			Source:     link.SourceNoteID,
+			Target:     link.TargetID,
+			TargetType: string(link.TargetType),
+		})
+	}
+
+	taskNodes, err := linkRepo.GetGraphTaskNodes(ctx, taskIDs)
+	if err != nil {
+		logger.LogError(err, "获取笔记关系图任务节点失败")
+		return database.IsError(err), nil
+	}
+	nodes = append(nodes, taskNodes...)
+
+	// 已删除的任务查不到节点，对应的边一并去掉
+	liveTasks := make(map[int64]bool, len(taskNodes))
+	for _, node := range taskNodes {
+		liveTasks[node.ID] = true
+	}
+	filtered := edges[:0]
+	for _, edge := range edges {
+		if edge.TargetType == string(model.NoteLinkTargetTask) && !liveTasks[edge.Target] {
+			continue
+		}
+		filtered = append(filtered, edge)
+	}
+	edges = filtered
+
+	return message.SUCCESS, map[string]interface{}{
+		"nodes": nodes,
+		"edges": edges,
+	}
+}
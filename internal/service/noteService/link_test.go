@@ -0,0 +1,62 @@
+package noteService_test
+
+import (
+	"context"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/noteService"
+	"testing"
+)
+
+func TestNoteLinksHideUnreadableNotes(t *testing.T) {
+	setupNotes(t)
+	public := seedNote(t, "public", aliceUserID, rootCategoryID)
+	if err := database.DB.Model(public).Update("status", model.Public).Error; err != nil {
+		t.Fatal(err)
+	}
+	secret := seedNote(t, "secret", aliceUserID, rootCategoryID)
+	granted := seedNote(t, "granted", aliceUserID, rootCategoryID)
+	grantNote(t, granted.ID, bobMemberID, model.NoteRoleViewer)
+	for _, source := range []*model.Note{secret, granted} {
+		seed(t, &model.NoteLink{WorkspaceID: testWorkspaceID, SourceNoteID: source.ID, TargetType: model.NoteLinkTargetNote, TargetID: public.ID})
+	}
+	seed(t, &model.NoteLink{WorkspaceID: testWorkspaceID, SourceNoteID: public.ID, TargetType: model.NoteLinkTargetNote, TargetID: secret.ID})
+	ctx := context.Background()
+
+	backlinks := func(userID, noteID int64) (int, []dto.NoteBacklinkDTO) {
+		code, data := noteService.GetNoteBacklinks(ctx, &dto.NoteBacklinkQueryDTO{WorkspaceID: testWorkspaceID, NoteID: noteID, UserID: userID})
+		if code != message.SUCCESS {
+			return code, nil
+		}
+		return code, data["backlinks"].([]dto.NoteBacklinkDTO)
+	}
+	if _, links := backlinks(aliceUserID, public.ID); len(links) != 2 {
+		t.Fatalf("owner backlinks = %+v", links)
+	}
+	if _, links := backlinks(bobUserID, public.ID); len(links) != 1 || links[0].NoteID != granted.ID {
+		t.Fatalf("member backlinks = %+v", links)
+	}
+	if code, _ := backlinks(bobUserID, secret.ID); code != message.ERROR_NOTE_NO_PERMISSION {
+		t.Fatalf("unreadable target backlinks code = %d", code)
+	}
+
+	code, data := noteService.GetNoteLinkGraph(ctx, &dto.NoteGraphQueryDTO{WorkspaceID: testWorkspaceID, UserID: bobUserID})
+	if code != message.SUCCESS {
+		t.Fatalf("graph code = %d", code)
+	}
+	for _, node := range data["nodes"].([]dto.NoteGraphNodeDTO) {
+		if node.ID == secret.ID {
+			t.Fatal("graph exposes unreadable note")
+		}
+	}
+	edges := data["edges"].([]dto.NoteGraphEdgeDTO)
+	if len(edges) != 1 || edges[0].Source != granted.ID || edges[0].Target != public.ID {
+		t.Fatalf("graph edges = %+v", edges)
+	}
+
+	if code, _ := noteService.GetNoteLinkGraph(ctx, &dto.NoteGraphQueryDTO{WorkspaceID: testWorkspaceID, UserID: carolUserID}); code != message.ERROR_WORKSPACE_MEMBER_NOT_EXIST {
+		t.Fatalf("non-member graph code = %d", code)
+	}
+}
@@ -10,17 +10,21 @@ import (
 )
 
 func GetWorkspaceNotesList(workspaceID string, UserID int64, limit int, offset int) (responseCode int, data any) {
-	data, err := repository.GetNotesList(workspaceID, UserID, limit, offset)
+	notes, err := repository.GetNotesList(workspaceID, UserID, limit, offset)
 	logger.LogDebug("获取工作区笔记列表", map[string]interface{}{
 		"workspace_id": workspaceID,
 		"user_id":      UserID,
-		"data":         data,
+		"data":         notes,
 	})
 	if err != nil {
 		logger.LogError(err, "获取工作区笔记列表失败")
 		return message.ERROR_DATABASE, err
 	}
-	return message.SUCCESS, data
+	if err := attachNotionPageIDs(context.Background(), *notes); err != nil {
+		logger.LogError(err, "获取笔记绑定的 Notion 页面失败")
+		return message.ERROR_DATABASE, nil
+	}
+	return message.SUCCESS, notes
 }
 
 func GetWorkspaceNotesCategory(params *dto.NoteCategoryQueryDTO) (responseCode int, data any) {
@@ -36,6 +40,41 @@ func GetWorkspaceNotesCategory(params *dto.NoteCategoryQueryDTO) (responseCode i
 	return message.SUCCESS, data
 }
 
+// GetNoteCategoryTree 一次查询取出全部分类并在内存中组装成树
+func GetNoteCategoryTree(ctx context.Context, params *dto.NoteCategoryTreeQueryDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := workspaceMember(params.UserID, params.WorkspaceID); code != 0 {
+		return code, nil
+	}
+	rows, err := repository.GetNoteCategoryTreeRows(database.DB.WithContext(ctx), params.WorkspaceID)
+	if err != nil {
+		logger.LogError(err, "获取工作区笔记分类树失败")
+		return database.IsError(err), nil
+	}
+
+	nodes := make(map[int64]*dto.NoteCategoryTreeNode, len(rows))
+	for _, row := range rows {
+		row.Children = []*dto.NoteCategoryTreeNode{}
+		nodes[row.ID] = row
+	}
+
+	// rows 已按 order_index 排序，按顺序挂载即可保持同级顺序；父级缺失的节点提升到根目录
+	roots := make([]*dto.NoteCategoryTreeNode, 0)
+	for _, row := range rows {
+		if row.ParentID != nil {
+			if parent, ok := nodes[*row.ParentID]; ok {
+				parent.Children = append(parent.Children, row)
+				continue
+			}
+		}
+		roots = append(roots, row)
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"categories": roots,
+		"total":      len(rows),
+	}
+}
+
 func GetRecommandNotesCategory(params *dto.RecommendNoteCategoryQueryDTO) (responseCode int, data dto.RecommendNoteCategoryDTO) {
 	var err error
 	data = dto.RecommendNoteCategoryDTO{
@@ -74,6 +113,13 @@ func GetFavoriteNoteList(params *dto.FavoriteNoteQueryDTO) (int, map[string]inte
 		}
 	}
 
+	if err := attachNotionPageIDs(context.Background(), *notes); err != nil {
+		return database.IsError(err), map[string]interface{}{
+			"notes": nil,
+			"total": 0,
+		}
+	}
+
 	count, err := repository.GetFavoriteNoteCount(params.UserID, params.WorkspaceID)
 	if err != nil {
 		return database.IsError(err), map[string]interface{}{
@@ -109,3 +155,21 @@ func GetNoteSyncList(ctx context.Context, params *dto.GetNoteSyncListDTO) (int,
 		"total":    total,
 	}
 }
+
+// attachNotionPageIDs 补充笔记已绑定的 Notion 页面，便于前端展示
+func attachNotionPageIDs(ctx context.Context, notes []dto.WorkspaceNoteDTO) error {
+	noteIDs := make([]int64, 0, len(notes))
+	for _, note := range notes {
+		noteIDs = append(noteIDs, note.ID)
+	}
+	pageIDs, err := repository.GetNotionPageIDs(database.DB, ctx, noteIDs)
+	if err != nil {
+		return err
+	}
+	for i := range notes {
+		if pageID, ok := pageIDs[notes[i].ID]; ok {
+			notes[i].NotionPageID = &pageID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+	"time"
+)
+
+// AcquireNoteLock 签出笔记供独占编辑。锁状态存放在 Redis 中并带 TTL，
+// 读改写由 RedisClient.Lock 串行化；他人持有时返回当前锁信息
+func AcquireNoteLock(ctx context.Context, params *dto.AcquireNoteLockDTO) (responseCode int, data map[string]interface{}) {
+	required := model.NoteRoleEditor
+	if params.Permanent {
+		required = model.NoteRoleOwner
+	}
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, required); code != 0 {
+		return code, nil
+	}
+	if cache.RedisInstance == nil {
+		return message.ERROR_REDIS, nil
+	}
+
+	unlock, err := cache.RedisInstance.Lock(ctx, cache.NoteLockMutexKey(params.NoteID), dto.NoteLockMutexTTL)
+	if err != nil {
+		return message.ERROR_NOTE_LOCK_BUSY, nil
+	}
+	defer unlock()
+
+	current, err := getNoteLock(ctx, params.NoteID)
+	if err != nil {
+		return message.ERROR_REDIS, nil
+	}
+	if current != nil && current.UserID != params.UserID {
+		return message.ERROR_NOTE_LOCKED, map[string]interface{}{"lock": current}
+	}
+
+	now := time.Now().UTC()
+	lock := &dto.NoteLockState{
+		NoteID:    params.NoteID,
+		UserID:    params.UserID,
+		MemberID:  params.MemberID,
+		Permanent: params.Permanent,
+		LockedAt:  now,
+	}
+	if current != nil {
+		lock.LockedAt = current.LockedAt
+	}
+	ttl := time.Duration(0)
+	if !params.Permanent {
+		ttl = dto.NoteLockDefaultTTL
+		if params.TTLSeconds > 0 {
+			ttl = time.Duration(params.TTLSeconds) * time.Second
+		}
+		expiresAt := now.Add(ttl)
+		lock.ExpiresAt = &expiresAt
+	}
+
+	raw, _ := json.Marshal(lock)
+	if err := cache.RedisInstance.SetNoteLock(ctx, params.NoteID, string(raw), ttl); err != nil {
+		logger.LogError(err, "写入笔记锁失败")
+		return message.ERROR_REDIS, nil
+	}
+
+	if err := bus.PublishNoteLock(ctx, bus.WsNoteLocked, params.NoteID, lock); err != nil {
+		logger.LogError(err, "推送笔记锁事件失败")
+	}
+	return message.SUCCESS, map[string]interface{}{"lock": lock}
+}
+
+// ReleaseNoteLock 持有者解锁；force 时只有工作区管理员可以解除他人的锁
+func ReleaseNoteLock(ctx context.Context, params *dto.ReleaseNoteLockDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+	if params.Force {
+		if _, isAdmin := repository.IsUserAllowedToModifyWorkspace(params.UserID, params.WorkspaceID); !isAdmin {
+			return message.ERROR_NOTE_NO_PERMISSION, nil
+		}
+	}
+	if cache.RedisInstance == nil {
+		return message.ERROR_REDIS, nil
+	}
+
+	unlock, err := cache.RedisInstance.Lock(ctx, cache.NoteLockMutexKey(params.NoteID), dto.NoteLockMutexTTL)
+	if err != nil {
+		return message.ERROR_NOTE_LOCK_BUSY, nil
+	}
+	defer unlock()
+
+	current, err := getNoteLock(ctx, params.NoteID)
+	if err != nil {
+		return message.ERROR_REDIS, nil
+	}
+	if current == nil {
+		return message.SUCCESS, map[string]interface{}{"released": false}
+	}
+	if current.UserID != params.UserID && !params.Force {
+		return message.ERROR_NOTE_LOCKED, map[string]interface{}{"lock": current}
+	}
+
+	if err := cache.RedisInstance.DelNoteLock(ctx, params.NoteID); err != nil {
+		logger.LogError(err, "删除笔记锁失败")
+		return message.ERROR_REDIS, nil
+	}
+
+	if err := bus.PublishNoteLock(ctx, bus.WsNoteUnlocked, params.NoteID, nil); err != nil {
+		logger.LogError(err, "推送笔记锁事件失败")
+	}
+	return message.SUCCESS, map[string]interface{}{"released": true, "forced": current.UserID != params.UserID}
+}
+
+func GetNoteLock(ctx context.Context, params *dto.NoteLockQueryDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+	if cache.RedisInstance == nil {
+		return message.SUCCESS, map[string]interface{}{"lock": nil}
+	}
+	lock, err := getNoteLock(ctx, params.NoteID)
+	if err != nil {
+		return message.ERROR_REDIS, nil
+	}
+	return message.SUCCESS, map[string]interface{}{"lock": lock}
+}
+
+// getNoteLock 未加锁或锁已过期时返回 nil
+func getNoteLock(ctx context.Context, noteID int64) (*dto.NoteLockState, error) {
+	raw, err := cache.RedisInstance.GetNoteLock(ctx, noteID)
+	if err != nil {
+		logger.LogError(err, "读取笔记锁失败")
+		return nil, err
+	}
+	if raw == "" {
+		return nil, nil
+	}
+	lock := &dto.NoteLockState{}
+	if err := json.Unmarshal([]byte(raw), lock); err != nil {
+		// 损坏的锁状态视为未加锁，避免笔记永久无法编辑
+		logger.LogError(err, "解析笔记锁失败")
+		return nil, nil
+	}
+	return lock, nil
+}
+
+// checkNoteLock 笔记被他人锁定时返回 ERROR_NOTE_LOCKED；Redis 不可用时拒绝写入，避免绕过锁
+func checkNoteLock(ctx context.Context, noteID, userID int64) (*dto.NoteLockState, int) {
+	if cache.RedisInstance == nil {
+		return nil, message.ERROR_REDIS
+	}
+	lock, err := getNoteLock(ctx, noteID)
+	if err != nil {
+		return nil, message.ERROR_REDIS
+	}
+	if lock != nil && lock.UserID != userID {
+		return lock, message.ERROR_NOTE_LOCKED
+	}
+	return lock, 0
+}
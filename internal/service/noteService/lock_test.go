@@ -0,0 +1,92 @@
+package noteService_test
+
+import (
+	"context"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/noteService"
+	"strconv"
+	"testing"
+)
+
+// lockNoteAsBob bob 以编辑者身份签出 alice 的笔记
+func lockNoteAsBob(t *testing.T, note *model.Note) {
+	t.Helper()
+	grantNote(t, note.ID, bobMemberID, model.NoteRoleEditor)
+	code, _ := noteService.AcquireNoteLock(context.Background(), &dto.AcquireNoteLockDTO{
+		WorkspaceID: testWorkspaceID, NoteID: note.ID, TTLSeconds: 60, UserID: bobUserID, MemberID: bobMemberID,
+	})
+	if code != message.SUCCESS {
+		t.Fatalf("acquire lock code = %d", code)
+	}
+}
+
+func TestBulkNoteSkipsLockedNotes(t *testing.T) {
+	setupNotes(t)
+	locked := seedNote(t, "locked", aliceUserID, rootCategoryID)
+	free := seedNote(t, "free", aliceUserID, rootCategoryID)
+	lockNoteAsBob(t, locked)
+
+	code, data := noteService.BulkNoteOperation(context.Background(), &dto.BulkNoteDTO{
+		WorkspaceID: testWorkspaceID, Action: dto.BulkNoteDelete, UserID: aliceUserID,
+		NoteIDs: []string{strconv.FormatInt(locked.ID, 10), strconv.FormatInt(free.ID, 10)},
+	})
+	if code != message.SUCCESS {
+		t.Fatalf("bulk code = %d", code)
+	}
+	results := data["results"].([]dto.BulkNoteResult)
+	if len(results) != 2 || results[0].NoteID != locked.ID || results[0].Code != message.ERROR_NOTE_LOCKED {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[1].Code != message.SUCCESS {
+		t.Fatalf("unlocked note result = %+v", results[1])
+	}
+	if n := count(t, &model.Note{}, "id = ? AND deleted_at IS NULL", locked.ID); n != 1 {
+		t.Fatal("locked note was trashed")
+	}
+}
+
+func TestTransferNoteMoveRejectsLockedNote(t *testing.T) {
+	setupNotes(t)
+	note := seedNote(t, "locked", aliceUserID, rootCategoryID)
+	lockNoteAsBob(t, note)
+
+	code, data := noteService.TransferNote(context.Background(), &dto.TransferNoteDTO{
+		WorkspaceID: testWorkspaceID, NoteID: note.ID, TargetWorkspaceID: otherWorkspaceID,
+		TargetCategoryID: otherCategoryID, Mode: dto.NoteTransferMove, SyncLinks: dto.SyncLinkDrop, UserID: aliceUserID,
+	})
+	if code != message.ERROR_NOTE_LOCKED || data["lock"] == nil {
+		t.Fatalf("transfer code = %d data = %v", code, data)
+	}
+	if n := count(t, &model.Note{}, "id = ? AND workspace_id = ?", note.ID, testWorkspaceID); n != 1 {
+		t.Fatal("locked note was moved")
+	}
+}
+
+func TestNoteWritesFailClosedWithoutRedis(t *testing.T) {
+	setupNotes(t)
+	note := seedNote(t, "roadmap", aliceUserID, rootCategoryID)
+	prev := cache.RedisInstance
+	cache.RedisInstance = nil
+	t.Cleanup(func() { cache.RedisInstance = prev })
+
+	code, _ := noteService.BulkNoteOperation(context.Background(), &dto.BulkNoteDTO{
+		WorkspaceID: testWorkspaceID, Action: dto.BulkNoteDelete, UserID: aliceUserID,
+		NoteIDs: []string{strconv.FormatInt(note.ID, 10)},
+	})
+	if code != message.ERROR_REDIS {
+		t.Fatalf("bulk code = %d", code)
+	}
+	code, _ = noteService.TransferNote(context.Background(), &dto.TransferNoteDTO{
+		WorkspaceID: testWorkspaceID, NoteID: note.ID, TargetWorkspaceID: otherWorkspaceID,
+		TargetCategoryID: otherCategoryID, Mode: dto.NoteTransferMove, SyncLinks: dto.SyncLinkDrop, UserID: aliceUserID,
+	})
+	if code != message.ERROR_REDIS {
+		t.Fatalf("transfer code = %d", code)
+	}
+	if n := count(t, &model.Note{}, "id = ? AND deleted_at IS NULL AND workspace_id = ?", note.ID, testWorkspaceID); n != 1 {
+		t.Fatal("note changed without redis")
+	}
+}
@@ -0,0 +1,106 @@
+package noteService_test
+
+import (
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/testutil"
+	"testing"
+)
+
+const (
+	testWorkspaceID  int64 = 10
+	otherWorkspaceID int64 = 11
+	aliceUserID      int64 = 20 // 工作区管理员，也是测试笔记的作者
+	bobUserID        int64 = 21 // 普通成员
+	carolUserID      int64 = 22 // 不属于工作区
+	aliceMemberID    int64 = 30
+	bobMemberID      int64 = 31
+	aliceOtherMember int64 = 32 // alice 在 otherWorkspaceID 的成员 ID
+	rootCategoryID   int64 = 60
+	otherCategoryID  int64 = 61
+)
+
+// noteModels 笔记服务测试用到的表
+var noteModels = []interface{}{
+	&model.Workspace{}, &model.User{}, &model.WorkspaceMember{}, &model.NoteCategory{}, &model.Note{},
+	&model.NotePermission{}, &model.NoteShare{}, &model.DailyNote{}, &model.FavoriteNote{},
+	&model.NoteChecklistTask{}, &model.NoteLink{}, &model.NoteViewStat{}, &model.NoteViewDaily{},
+	&model.NoteComment{}, &model.NoteCommentMention{}, &model.NoteCommentAttachment{},
+	&model.NoteExternalLink{}, &model.NoteExternalNodeMapping{}, &model.NoteSyncConflict{}, &model.SyncOutbox{},
+}
+
+// setupNotes 用 sqlite、miniredis 与记录任务的 Dispatcher 替换全局依赖，
+// 准备两个工作区的成员与根分类
+func setupNotes(t *testing.T) *testutil.Dispatcher {
+	t.Helper()
+	testutil.UseDB(t, noteModels...)
+	testutil.UseRedis(t)
+	dispatcher := testutil.UseDispatcher(t)
+
+	for _, id := range []int64{testWorkspaceID, otherWorkspaceID} {
+		workspace := model.Workspace{Name: "ws", Owner: aliceUserID, AllowShare: true, AllowComment: true}
+		workspace.ID = id
+		seed(t, &workspace)
+	}
+	for _, u := range []struct {
+		id    int64
+		email string
+	}{{aliceUserID, "alice@example.com"}, {bobUserID, "bob@example.com"}, {carolUserID, "carol@example.com"}} {
+		user := model.User{Email: u.email, Password: "x", Phone: u.email}
+		user.ID = u.id
+		seed(t, &user)
+	}
+	for _, m := range []struct {
+		id, workspaceID, userID int64
+		role                    string
+	}{
+		{aliceMemberID, testWorkspaceID, aliceUserID, `["admin"]`},
+		{bobMemberID, testWorkspaceID, bobUserID, `["user"]`},
+		{aliceOtherMember, otherWorkspaceID, aliceUserID, `["admin"]`},
+	} {
+		member := model.WorkspaceMember{WorkspaceID: m.workspaceID, UserID: m.userID, Role: []byte(m.role), Nickname: "m"}
+		member.ID = m.id
+		seed(t, &member)
+	}
+	for _, c := range []struct{ id, workspaceID int64 }{{rootCategoryID, testWorkspaceID}, {otherCategoryID, otherWorkspaceID}} {
+		category := model.NoteCategory{CategoryName: "root", WorkspaceID: c.workspaceID, OwnerID: aliceUserID, OrderIndex: "0|hzzzzz:"}
+		category.ID = c.id
+		seed(t, &category)
+	}
+	return dispatcher
+}
+
+func seed(t *testing.T, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := database.DB.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// seedNote 在 categoryID 下创建 ownerID 的私有笔记
+func seedNote(t *testing.T, title string, ownerID, categoryID int64) *model.Note {
+	t.Helper()
+	note := model.Note{Title: title, Content: []byte(`[]`), WorkspaceID: testWorkspaceID, OwnerID: ownerID, CategoryID: categoryID, Status: model.Private}
+	seed(t, &note)
+	return &note
+}
+
+// grantNote 给成员授予笔记角色
+func grantNote(t *testing.T, noteID, memberID int64, role model.NoteRole) {
+	t.Helper()
+	seed(t, &model.NotePermission{
+		NoteID: noteID, WorkspaceID: testWorkspaceID, GranteeType: model.NoteGranteeMember,
+		GranteeID: memberID, Role: role, GrantedBy: aliceMemberID,
+	})
+}
+
+func count(t *testing.T, m interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := database.DB.Unscoped().Model(m).Where(query, args...).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,316 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 同一分享链接在窗口内最多允许输错的密码次数，超过后锁定到窗口结束
+const (
+	sharePasswordMaxAttempts = 5
+	sharePasswordLockWindow  = 15 * time.Minute
+)
+
+// noteShareAllowed 分享需要工作区与笔记两级开关同时开启
+func noteShareAllowed(ctx context.Context, note *model.Note) (bool, error) {
+	if note.AllowShare != nil && !*note.AllowShare {
+		return false, nil
+	}
+	return repository.NewShareRepository(database.DB).GetWorkspaceAllowShare(ctx, note.WorkspaceID)
+}
+
+// CreateNoteShare 需要笔记的 editor 及以上权限，避免成员把自己无权查看的笔记公开
+func CreateNoteShare(ctx context.Context, params *dto.CreateNoteShareDTO) (responseCode int, data map[string]interface{}) {
+	note, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleEditor)
+	if code != 0 {
+		return code, nil
+	}
+
+	allowed, err := noteShareAllowed(ctx, note)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if !allowed {
+		return message.ERROR_NOTE_SHARE_DISABLED, nil
+	}
+
+	if params.ExpiresAt != nil && params.ExpiresAt.Before(time.Now()) {
+		return message.ERROR_INVALID_PARAMS, nil
+	}
+
+	share := &model.NoteShare{
+		NoteID:      note.ID,
+		WorkspaceID: note.WorkspaceID,
+		CreatorID:   params.MemberID,
+		Token:       strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Permission:  model.SharePermissionRead,
+		ExpiresAt:   params.ExpiresAt,
+	}
+	if params.Permission != "" {
+		share.Permission = params.Permission
+	}
+	if params.Password != nil && *params.Password != "" {
+		hashed := algorithm.HashPassword(*params.Password)
+		share.PasswordHash = &hashed
+	}
+
+	if err := repository.NewShareRepository(database.DB).CreateNoteShare(ctx, share); err != nil {
+		logger.LogError(err, "创建笔记分享失败")
+		return database.IsError(err), nil
+	}
+
+	return message.SUCCESS, share.Data()
+}
+
+func GetNoteShares(ctx context.Context, params *dto.GetNoteSharesDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer); code != 0 {
+		return code, nil
+	}
+
+	shares, err := repository.NewShareRepository(database.DB).GetNoteSharesByNote(ctx, params.WorkspaceID, params.NoteID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	list := make([]map[string]interface{}, 0, len(shares))
+	for _, share := range shares {
+		list = append(list, share.Data())
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"shares": list,
+		"total":  len(list),
+	}
+}
+
+// RevokeNoteShare 与创建一样需要笔记的 editor 及以上权限
+func RevokeNoteShare(ctx context.Context, params *dto.RevokeNoteShareDTO) (responseCode int) {
+	shareRepo := repository.NewShareRepository(database.DB)
+	share, err := shareRepo.GetNoteShareByID(ctx, params.WorkspaceID, params.ShareID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_NOTE_SHARE_NOT_FOUND
+		}
+		return database.IsError(err)
+	}
+	if _, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, share.NoteID, params.UserID, model.NoteRoleEditor); code != 0 {
+		return code
+	}
+
+	if err := shareRepo.RevokeNoteShare(ctx, params.ShareID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
+
+// openSharedNote 校验分享链接的有效期、密码与分享开关，返回分享及其笔记
+func openSharedNote(ctx context.Context, token, password string) (*model.NoteShare, *model.Note, int) {
+	share, err := repository.NewShareRepository(database.DB).GetNoteShareByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, message.ERROR_NOTE_SHARE_NOT_FOUND
+		}
+		return nil, nil, database.IsError(err)
+	}
+
+	if share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now()) {
+		return nil, nil, message.ERROR_NOTE_SHARE_EXPIRED
+	}
+
+	if share.PasswordHash != nil {
+		if password == "" {
+			return nil, nil, message.ERROR_NOTE_SHARE_NEED_PASS
+		}
+		attempts, err := cache.RedisInstance.GetShareAttempt(ctx, share.ID)
+		if err != nil {
+			logger.LogError(err, "读取分享密码错误次数失败")
+			return nil, nil, message.ERROR
+		}
+		if attempts >= sharePasswordMaxAttempts {
+			return nil, nil, message.ERROR_NOTE_SHARE_PASS_LOCKED
+		}
+		if !algorithm.VerifyPassword(password, *share.PasswordHash) {
+			if _, err := cache.RedisInstance.IncrShareAttempt(ctx, share.ID, sharePasswordLockWindow); err != nil {
+				logger.LogError(err, "记录分享密码错误次数失败")
+			}
+			return nil, nil, message.ERROR_NOTE_SHARE_PASS_WRONG
+		}
+	}
+
+	note, err := repository.GetNoteByID(database.DB, ctx, share.WorkspaceID, share.NoteID)
+	if err != nil {
+		return nil, nil, database.IsError(err)
+	}
+	if note.ID == 0 {
+		return nil, nil, message.ERROR_NOTE_SHARE_NOT_FOUND
+	}
+
+	// 分享创建后工作区或笔记关闭了分享，已有链接同样失效
+	allowed, err := noteShareAllowed(ctx, note)
+	if err != nil {
+		return nil, nil, database.IsError(err)
+	}
+	if !allowed {
+		return nil, nil, message.ERROR_NOTE_SHARE_DISABLED
+	}
+	return share, note, 0
+}
+
+// GetSharedNote 无需登录，通过分享 token 读取笔记内容
+func GetSharedNote(ctx context.Context, params *dto.GetSharedNoteDTO) (responseCode int, data map[string]interface{}) {
+	share, note, code := openSharedNote(ctx, params.Token, params.Password)
+	if code != 0 {
+		return code, nil
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		logger.LogError(err, "Unmarshal note content error")
+		return message.ERROR, nil
+	}
+
+	if err := repository.NewShareRepository(database.DB).IncreaseShareViewCount(ctx, share.ID); err != nil {
+		logger.LogError(err, "更新分享访问次数失败")
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"note": map[string]interface{}{
+			"id":         strconv.FormatInt(note.ID, 10),
+			"title":      note.Title,
+			"content":    content,
+			"cover":      note.Cover,
+			"updated_at": note.UpdatedAt,
+		},
+		"permission": share.Permission,
+	}
+}
+
+// openCommentShare 只有 comment 权限的分享链接、且笔记未关闭评论时开放评论
+func openCommentShare(ctx context.Context, token, password string) (*model.NoteShare, *model.Note, int) {
+	share, note, code := openSharedNote(ctx, token, password)
+	if code != 0 {
+		return nil, nil, code
+	}
+	if share.Permission != model.SharePermissionComment || (note.AllowComment != nil && !*note.AllowComment) {
+		return nil, nil, message.ERROR_NOTE_SHARE_NO_COMMENT
+	}
+	return share, note, 0
+}
+
+// publicCommentAuthor 公开页面只展示作者昵称与头像，不暴露邮箱与成员角色
+func publicCommentAuthor(member *dto.WorkspaceMemberDTO) *dto.WorkspaceMemberDTO {
+	if member == nil {
+		return nil
+	}
+	return &dto.WorkspaceMemberDTO{
+		ID:                member.ID,
+		WorkspaceNickname: member.WorkspaceNickname,
+		UserNickname:      member.UserNickname,
+		Avatar:            member.Avatar,
+	}
+}
+
+// GetSharedNoteComments 通过 comment 权限的分享链接读取评论，不含已脱离锚点的评论
+func GetSharedNoteComments(ctx context.Context, params *dto.GetSharedNoteDTO) (responseCode int, data map[string]interface{}) {
+	_, note, code := openCommentShare(ctx, params.Token, params.Password)
+	if code != 0 {
+		return code, nil
+	}
+
+	comments, err := repository.NewNoteCommentRepository(database.DB).GetNoteComments(ctx, note.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	items, err := buildNoteCommentItems(ctx, comments, 0)
+	if err != nil {
+		logger.LogError(err, "组装笔记评论失败")
+		return database.IsError(err), nil
+	}
+	for i := range items {
+		items[i].Author = publicCommentAuthor(items[i].Author)
+		for j := range items[i].Mentions {
+			items[i].Mentions[j].Member = publicCommentAuthor(items[i].Mentions[j].Member)
+		}
+	}
+
+	threads := buildNoteCommentThreads(items, func(item dto.NoteCommentItem) bool {
+		return item.AnchorState != string(model.NoteAnchorDetached)
+	})
+	return message.SUCCESS, map[string]interface{}{
+		"threads": threads,
+		"total":   len(threads),
+	}
+}
+
+// CreateSharedNoteComment 访客通过 comment 权限的分享链接评论，作者记为分享链接与署名
+func CreateSharedNoteComment(ctx context.Context, params *dto.CreateSharedNoteCommentDTO) (responseCode int, data map[string]interface{}) {
+	share, note, code := openCommentShare(ctx, params.Token, params.Password)
+	if code != 0 {
+		return code, nil
+	}
+
+	comment := &model.NoteComment{
+		NoteID:      note.ID,
+		WorkspaceID: note.WorkspaceID,
+		ShareID:     &share.ID,
+		GuestName:   params.GuestName,
+		Content:     params.Content,
+		Status:      model.NoteCommentOpen,
+		AnchorState: model.NoteAnchorActive,
+	}
+	if code := anchorNoteComment(ctx, note, comment, params.ParentID, params.BlockID, params.AnchorStart, params.AnchorEnd); code != 0 {
+		return code, nil
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewNoteCommentRepository(tx)
+		if err := txRepo.CreateNoteComment(ctx, comment); err != nil {
+			return err
+		}
+		if comment.ParentID > 0 {
+			return txRepo.IncreaseReplyCount(ctx, comment.ParentID, 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	items, err := buildNoteCommentItems(ctx, []model.NoteComment{*comment}, 0)
+	if err != nil {
+		logger.LogError(err, "组装笔记评论失败")
+		return database.IsError(err), nil
+	}
+
+	_ = bus.PublishNoteComment(context.Background(), bus.WsCommentAdded, note.ID, items[0])
+	webhookService.Dispatch(ctx, note.WorkspaceID, 0, model.WebhookCommentAdded, map[string]interface{}{
+		"target_type": "note",
+		"note_id":     strconv.FormatInt(note.ID, 10),
+		"comment_id":  strconv.FormatInt(comment.ID, 10),
+		"parent_id":   strconv.FormatInt(comment.ParentID, 10),
+		"share_id":    strconv.FormatInt(share.ID, 10),
+		"guest_name":  comment.GuestName,
+		"content":     comment.Content,
+	})
+
+	return message.SUCCESS, map[string]interface{}{
+		"comment": items[0],
+	}
+}
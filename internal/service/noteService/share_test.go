@@ -0,0 +1,132 @@
+package noteService_test
+
+import (
+	"context"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/noteService"
+	"strconv"
+	"testing"
+)
+
+func TestNoteShareRequiresNoteRole(t *testing.T) {
+	setupNotes(t)
+	note := seedNote(t, "private plan", aliceUserID, rootCategoryID)
+	ctx := context.Background()
+
+	create := &dto.CreateNoteShareDTO{WorkspaceID: testWorkspaceID, NoteID: note.ID, MemberID: bobMemberID, UserID: bobUserID}
+	if code, _ := noteService.CreateNoteShare(ctx, create); code != message.ERROR_NOTE_NO_PERMISSION {
+		t.Fatalf("member without grant create code = %d", code)
+	}
+	list := &dto.GetNoteSharesDTO{WorkspaceID: testWorkspaceID, NoteID: note.ID, UserID: bobUserID}
+	if code, _ := noteService.GetNoteShares(ctx, list); code != message.ERROR_NOTE_NO_PERMISSION {
+		t.Fatalf("member without grant list code = %d", code)
+	}
+
+	code, data := noteService.CreateNoteShare(ctx, &dto.CreateNoteShareDTO{WorkspaceID: testWorkspaceID, NoteID: note.ID, MemberID: aliceMemberID, UserID: aliceUserID})
+	if code != message.SUCCESS {
+		t.Fatalf("owner create code = %d", code)
+	}
+	shareID, _ := strconv.ParseInt(data["id"].(string), 10, 64)
+	revoke := &dto.RevokeNoteShareDTO{WorkspaceID: testWorkspaceID, ShareID: shareID, UserID: bobUserID}
+	if code := noteService.RevokeNoteShare(ctx, revoke); code != message.ERROR_NOTE_NO_PERMISSION {
+		t.Fatalf("member without grant revoke code = %d", code)
+	}
+
+	// viewer 可以查看分享列表，但不能创建或撤销
+	grantNote(t, note.ID, bobMemberID, model.NoteRoleViewer)
+	if code, data := noteService.GetNoteShares(ctx, list); code != message.SUCCESS || data["total"] != 1 {
+		t.Fatalf("viewer list code = %d data = %v", code, data)
+	}
+	if code, _ := noteService.CreateNoteShare(ctx, create); code != message.ERROR_NOTE_NO_PERMISSION {
+		t.Fatalf("viewer create code = %d", code)
+	}
+	if code := noteService.RevokeNoteShare(ctx, revoke); code != message.ERROR_NOTE_NO_PERMISSION {
+		t.Fatalf("viewer revoke code = %d", code)
+	}
+}
+
+func TestNoteShareEditorCanRevoke(t *testing.T) {
+	setupNotes(t)
+	note := seedNote(t, "shared plan", aliceUserID, rootCategoryID)
+	grantNote(t, note.ID, bobMemberID, model.NoteRoleEditor)
+	ctx := context.Background()
+
+	code, data := noteService.CreateNoteShare(ctx, &dto.CreateNoteShareDTO{WorkspaceID: testWorkspaceID, NoteID: note.ID, MemberID: bobMemberID, UserID: bobUserID})
+	if code != message.SUCCESS {
+		t.Fatalf("editor create code = %d", code)
+	}
+	shareID, _ := strconv.ParseInt(data["id"].(string), 10, 64)
+	if code := noteService.RevokeNoteShare(ctx, &dto.RevokeNoteShareDTO{WorkspaceID: testWorkspaceID, ShareID: shareID, UserID: bobUserID}); code != message.SUCCESS {
+		t.Fatalf("editor revoke code = %d", code)
+	}
+	if n := count(t, &model.NoteShare{}, "id = ? AND revoked_at IS NOT NULL", shareID); n != 1 {
+		t.Fatal("share not revoked")
+	}
+}
+
+func TestSharedNoteGuestComments(t *testing.T) {
+	setupNotes(t)
+	note := seedNote(t, "public plan", aliceUserID, rootCategoryID)
+	if err := database.DB.Model(note).Update("content", []byte(`[{"id":"b1","type":"paragraph","content":[{"type":"text","text":"hello world"}]}]`)).Error; err != nil {
+		t.Fatal(err)
+	}
+	seed(t, &model.NoteComment{NoteID: note.ID, WorkspaceID: testWorkspaceID, MemberID: bobMemberID, BlockID: "b1", AnchorEnd: 5, AnchorText: "hello", Content: "member"})
+	ctx := context.Background()
+
+	shareToken := func(permission model.SharePermission) string {
+		t.Helper()
+		code, data := noteService.CreateNoteShare(ctx, &dto.CreateNoteShareDTO{
+			WorkspaceID: testWorkspaceID, NoteID: note.ID, MemberID: aliceMemberID, UserID: aliceUserID, Permission: permission,
+		})
+		if code != message.SUCCESS || data["permission"] != permission {
+			t.Fatalf("create %s share code = %d data = %v", permission, code, data)
+		}
+		return data["token"].(string)
+	}
+	guest := func(token string) *dto.CreateSharedNoteCommentDTO {
+		return &dto.CreateSharedNoteCommentDTO{Token: token, BlockID: "b1", AnchorEnd: 5, Content: "guest", GuestName: "visitor"}
+	}
+
+	// 只读链接既不能查看也不能发表评论
+	read := shareToken(model.SharePermissionRead)
+	if code, _ := noteService.GetSharedNoteComments(ctx, &dto.GetSharedNoteDTO{Token: read}); code != message.ERROR_NOTE_SHARE_NO_COMMENT {
+		t.Fatalf("read link list code = %d", code)
+	}
+	if code, _ := noteService.CreateSharedNoteComment(ctx, guest(read)); code != message.ERROR_NOTE_SHARE_NO_COMMENT {
+		t.Fatalf("read link comment code = %d", code)
+	}
+
+	token := shareToken(model.SharePermissionComment)
+	code, data := noteService.CreateSharedNoteComment(ctx, guest(token))
+	if code != message.SUCCESS {
+		t.Fatalf("guest comment code = %d", code)
+	}
+	root := data["comment"].(dto.NoteCommentItem)
+	if root.AnchorText != "hello" || root.GuestName != "visitor" || root.Author != nil || root.IsAuthor {
+		t.Fatalf("guest comment = %+v", root)
+	}
+
+	code, data = noteService.GetSharedNoteComments(ctx, &dto.GetSharedNoteDTO{Token: token})
+	if code != message.SUCCESS || data["total"] != 2 {
+		t.Fatalf("guest list code = %d data = %v", code, data)
+	}
+	for _, thread := range data["threads"].([]*dto.NoteCommentThread) {
+		if thread.Author != nil && thread.Author.Email != "" {
+			t.Fatal("shared comments expose member email")
+		}
+		if thread.ID == root.ID && thread.GuestName != "visitor" {
+			t.Fatalf("guest thread = %+v", thread)
+		}
+	}
+
+	// 笔记关闭评论后链接同样不能评论
+	if err := database.DB.Model(note).Update("allow_comment", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := noteService.CreateSharedNoteComment(ctx, guest(token)); code != message.ERROR_NOTE_SHARE_NO_COMMENT {
+		t.Fatalf("comments disabled code = %d", code)
+	}
+}
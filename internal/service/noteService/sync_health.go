@@ -0,0 +1,340 @@
+package noteService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSyncOutboxLimit   = 50
+	defaultSyncDashboardSize = 20
+)
+
+// authorizeSyncLink 笔记编辑者或工作区管理员才能操作同步链接；viewer 为 true 时只读权限即可
+func authorizeSyncLink(ctx context.Context, workspaceID, noteID, linkID, userID int64, viewer bool) (*model.NoteExternalLink, int) {
+	if _, isAdmin := repository.IsUserAllowedToModifyWorkspace(userID, workspaceID); isAdmin {
+		note, err := repository.GetNoteByID(database.DB, ctx, workspaceID, noteID)
+		if err != nil {
+			return nil, database.IsError(err)
+		}
+		if note.ID == 0 {
+			return nil, message.ERROR_NOTE_NOT_FOUND
+		}
+	} else {
+		required := model.NoteRoleEditor
+		if viewer {
+			required = model.NoteRoleViewer
+		}
+		if _, _, code := authorizeNote(ctx, database.DB, workspaceID, noteID, userID, required); code != 0 {
+			return nil, code
+		}
+	}
+	if linkID == 0 {
+		return nil, 0
+	}
+
+	link, err := repository.NewSyncRepository(database.DB).GetNoteSyncByID(ctx, linkID)
+	if err != nil || link.NoteID != noteID {
+		return nil, message.ERROR_NOTE_SYNC_NOT_FOUND
+	}
+	return &link, 0
+}
+
+// syncLinkHealth 在链接信息上补充落后版本数、排队与死信数量
+func syncLinkHealth(link *model.NoteExternalLink, noteVersion int64, stats []dto.SyncOutboxStat) map[string]interface{} {
+	counts := make(map[model.SyncStatus]int64)
+	var nextRetryAt *time.Time
+	for _, stat := range stats {
+		if stat.LinkID != link.ID {
+			continue
+		}
+		counts[stat.Status] = stat.Count
+		if stat.Status == model.SyncPending {
+			nextRetryAt = stat.NextRetryAt
+		}
+	}
+	lag := noteVersion - link.ContentVersion
+	if lag < 0 {
+		lag = 0
+	}
+
+	item := link.Data()
+	item["content_version"] = link.ContentVersion
+	item["note_version"] = noteVersion
+	item["lag"] = lag
+	item["pending"] = counts[model.SyncPending] + counts[model.SyncRunning]
+	item["dead"] = counts[model.SyncDead]
+	item["next_retry_at"] = nextRetryAt
+	item["healthy"] = link.FailStreak == 0 && counts[model.SyncDead] == 0 && link.InitStatus != model.InitFailed
+	return item
+}
+
+func syncLinksHealth(ctx context.Context, links []model.NoteExternalLink) ([]map[string]interface{}, error) {
+	syncRepo := repository.NewSyncRepository(database.DB)
+	linkIDs := make([]int64, 0, len(links))
+	noteIDs := make([]int64, 0, len(links))
+	for _, link := range links {
+		linkIDs = append(linkIDs, link.ID)
+		noteIDs = append(noteIDs, link.NoteID)
+	}
+	stats, err := syncRepo.GetSyncOutboxStats(ctx, linkIDs)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := syncRepo.GetNoteVersions(ctx, noteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]interface{}, 0, len(links))
+	for i := range links {
+		items = append(items, syncLinkHealth(&links[i], versions[links[i].NoteID], stats))
+	}
+	return items, nil
+}
+
+func GetNoteSyncHealth(ctx context.Context, params *dto.GetNoteSyncHealthDTO) (responseCode int, data map[string]interface{}) {
+	if _, code := authorizeSyncLink(ctx, params.WorkspaceID, params.NoteID, 0, params.UserID, true); code != 0 {
+		return code, nil
+	}
+
+	links, _, err := repository.GetNoteSyncList(database.DB, ctx, nil, &params.NoteID, nil)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	items, err := syncLinksHealth(ctx, *links)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"links": items}
+}
+
+func GetSyncOutbox(ctx context.Context, params *dto.GetSyncOutboxDTO) (responseCode int, data map[string]interface{}) {
+	link, code := authorizeSyncLink(ctx, params.WorkspaceID, params.NoteID, params.LinkID, params.UserID, true)
+	if code != 0 {
+		return code, nil
+	}
+	if params.Limit == 0 {
+		params.Limit = defaultSyncOutboxLimit
+	}
+
+	outboxs, err := repository.NewSyncRepository(database.DB).GetSyncOutboxList(ctx, link.ID, params.Status, params.Limit)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	items := make([]map[string]interface{}, 0, len(outboxs))
+	for i := range outboxs {
+		items = append(items, outboxs[i].Data())
+	}
+	return message.SUCCESS, map[string]interface{}{
+		"content_version": link.ContentVersion,
+		"outbox":          items,
+	}
+}
+
+// RetrySyncLink 初始化失败的链接重新初始化；否则把死信与退避中的 outbox 置为立即执行并投递推送
+func RetrySyncLink(ctx context.Context, params *dto.SyncLinkOperateDTO) (responseCode int, data map[string]interface{}) {
+	link, code := authorizeSyncLink(ctx, params.WorkspaceID, params.NoteID, params.LinkID, params.UserID, false)
+	if code != 0 {
+		return code, nil
+	}
+
+	if link.InitStatus == model.InitFailed {
+		if err := repository.UpdateNoteSync(ctx, database.DB, "id = ? AND init_status = ?", []interface{}{link.ID, model.InitFailed}, map[string]interface{}{
+			"init_status": model.InitPending,
+			"last_status": model.SyncPending,
+			"fail_streak": 0,
+			"updated_at":  time.Now(),
+		}); err != nil {
+			return database.IsError(err), nil
+		}
+		enqueueSyncInit(ctx, link, params.WorkspaceID)
+		return message.SUCCESS, map[string]interface{}{"init": true, "reset": 0}
+	}
+
+	reset, err := repository.NewSyncRepository(database.DB).ResetSyncOutboxForRetry(ctx, link.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	if err := repository.UpdateNoteSync(ctx, database.DB, "id = ?", []interface{}{link.ID}, map[string]interface{}{
+		"fail_streak": 0,
+		"updated_at":  time.Now(),
+	}); err != nil {
+		return database.IsError(err), nil
+	}
+	enqueueLinkDelta(ctx, link, params.WorkspaceID)
+	return message.SUCCESS, map[string]interface{}{"init": false, "reset": reset}
+}
+
+// SkipSyncOutbox 放弃阻塞链接的那条 outbox 并把基线推进过去，外部文档会缺少这次修改，需要时再重建
+func SkipSyncOutbox(ctx context.Context, params *dto.SyncLinkOperateDTO) (responseCode int, data map[string]interface{}) {
+	link, code := authorizeSyncLink(ctx, params.WorkspaceID, params.NoteID, params.LinkID, params.UserID, false)
+	if code != 0 {
+		return code, nil
+	}
+
+	unlock, err := cache.RedisInstance.Lock(ctx, fmt.Sprintf("sync:delta:%d", link.ID), 30*time.Second)
+	if err != nil {
+		return message.ERROR_SYNC_LINK_BUSY, nil
+	}
+	defer unlock()
+
+	var skipped *model.SyncOutbox
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		base, err := repository.GetNoteExternalLinkContentVersion(ctx, tx, link.ID)
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		syncRepo := repository.NewSyncRepository(tx)
+		skipped, err = syncRepo.GetBlockingSyncOutboxForUpdate(ctx, link.ID, base+1)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			responseCode = message.ERROR_SYNC_OUTBOX_NOT_FOUND
+			return err
+		}
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		now := time.Now()
+		if err := syncRepo.UpdateSyncOutboxByID(ctx, tx, skipped.ID, nil, map[string]interface{}{
+			"status":        model.SyncSkipped,
+			"next_retry_at": nil,
+			"updated_at":    now,
+		}); err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		skipped.Status = model.SyncSkipped
+		return repository.UpdateNoteSync(ctx, tx, "id = ? AND content_version = ?", []interface{}{link.ID, base}, map[string]interface{}{
+			"content_version": skipped.NoteVersion,
+			"fail_streak":     0,
+			"updated_at":      now,
+		})
+	})
+	if responseCode != 0 {
+		return responseCode, nil
+	}
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	logger.LogInfo(fmt.Sprintf("[sync] 用户 %d 跳过 link=%d outbox=%d version=%d", params.UserID, link.ID, skipped.ID, skipped.NoteVersion))
+	enqueueLinkDelta(ctx, link, params.WorkspaceID)
+	return message.SUCCESS, map[string]interface{}{"outbox": skipped.Data()}
+}
+
+// RebuildSyncLink 丢弃链接的 outbox、块映射与冲突记录，重新初始化；推送链接会整篇覆盖外部文档
+func RebuildSyncLink(ctx context.Context, params *dto.SyncLinkOperateDTO) (responseCode int, data map[string]interface{}) {
+	link, code := authorizeSyncLink(ctx, params.WorkspaceID, params.NoteID, params.LinkID, params.UserID, false)
+	if code != 0 {
+		return code, nil
+	}
+
+	unlock, err := cache.RedisInstance.Lock(ctx, fmt.Sprintf("sync:delta:%d", link.ID), 30*time.Second)
+	if err != nil {
+		return message.ERROR_SYNC_LINK_BUSY, nil
+	}
+	defer unlock()
+
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		syncRepo := repository.NewSyncRepository(tx)
+		if err := syncRepo.DeleteSyncOutbox(ctx, link.ID); err != nil {
+			return err
+		}
+		if err := syncRepo.DeleteNoteProviderMappings(ctx, link.NoteID, link.Provider); err != nil {
+			return err
+		}
+		if err := syncRepo.DeleteNoteSyncConflicts(ctx, link.ID); err != nil {
+			return err
+		}
+		return repository.UpdateNoteSync(ctx, tx, "id = ?", []interface{}{link.ID}, map[string]interface{}{
+			"init_status":      model.InitPending,
+			"last_status":      model.SyncPending,
+			"last_error":       nil,
+			"fail_streak":      0,
+			"external_version": nil,
+			"updated_at":       time.Now(),
+		})
+	})
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	_, _ = cache.RedisInstance.Del(ctx, fmt.Sprintf("sync:ready:%d", link.ID))
+	enqueueSyncInit(ctx, link, params.WorkspaceID)
+	return message.SUCCESS, nil
+}
+
+// GetSyncDashboard 工作区管理员查看全部同步链接的健康状况
+func GetSyncDashboard(ctx context.Context, params *dto.GetSyncDashboardDTO) (responseCode int, data map[string]interface{}) {
+	if _, isAdmin := repository.IsUserAllowedToModifyWorkspace(params.UserID, params.WorkspaceID); !isAdmin {
+		return message.ERROR_FORBIDDEN, nil
+	}
+	if params.Page == 0 {
+		params.Page = 1
+	}
+	if params.PageSize == 0 {
+		params.PageSize = defaultSyncDashboardSize
+	}
+
+	links, total, err := repository.NewSyncRepository(database.DB).
+		GetWorkspaceNoteSyncLinks(ctx, params.WorkspaceID, params.Unhealthy, params.Page, params.PageSize)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	items, err := syncLinksHealth(ctx, links)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{
+		"links": items,
+		"total": total,
+	}
+}
+
+// enqueueLinkDelta 推送任务使用链接创建者的集成账号
+func enqueueLinkDelta(ctx context.Context, link *model.NoteExternalLink, workspaceID int64) {
+	member, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		logger.LogError(err, "获取链接成员失败 link=", link.ID)
+		return
+	}
+	_, _ = enqueue.SyncDelta(ctx, types.SyncDeltaPayload{
+		LinkID:      link.ID,
+		NoteID:      link.NoteID,
+		WorkspaceID: workspaceID,
+		UserID:      member.UserID,
+		MemberID:    link.MemberID,
+	})
+}
+
+func enqueueSyncInit(ctx context.Context, link *model.NoteExternalLink, workspaceID int64) {
+	member, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		logger.LogError(err, "获取链接成员失败 link=", link.ID)
+		return
+	}
+	_, _ = enqueue.SyncInitNote(ctx, types.SyncNotePayload{
+		NoteID:       link.NoteID,
+		MemberID:     link.MemberID,
+		UserID:       member.UserID,
+		WorkspaceID:  workspaceID,
+		TargetNoteID: link.TargetNoteID,
+		LinkID:       link.ID,
+	})
+}
@@ -0,0 +1,372 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/thirdparty/qiniu"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/tools"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// validateTemplateVariables 校验变量定义：变量名合法、不与内置占位符重名、不重复，select 类型的默认值需在选项内
+func validateTemplateVariables(variables []dto.TemplateVariableDTO) bool {
+	seen := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		if !dto.IsValidTemplateVarKey(v.Key) || dto.IsBuiltinTemplateVar(v.Key) || seen[v.Key] {
+			return false
+		}
+		seen[v.Key] = true
+		if v.Type == dto.TemplateVariableSelect && v.Default != "" && !slices.Contains(v.Options, v.Default) {
+			return false
+		}
+	}
+	return true
+}
+
+// templateVisible 模板对当前用户可见：自己创建的，或所在工作区模板库中公开的
+func templateVisible(template *model.TemplateNote, userID, workspaceID int64) bool {
+	if template.OwnerID == userID {
+		return true
+	}
+	return template.IsPublic != nil && *template.IsPublic &&
+		template.WorkspaceID != nil && *template.WorkspaceID == workspaceID
+}
+
+func toTemplateNoteDTO(template *model.TemplateNote, user dto.UserBreifDTO) (dto.TemplateNote, error) {
+	var content dto.Blocks
+	if err := json.Unmarshal(template.Content, &content); err != nil {
+		return dto.TemplateNote{}, err
+	}
+	variables := make([]dto.TemplateVariableDTO, 0)
+	if len(template.Variables) > 0 {
+		if err := json.Unmarshal(template.Variables, &variables); err != nil {
+			return dto.TemplateNote{}, err
+		}
+	}
+	return dto.TemplateNote{
+		ID:           template.ID,
+		Title:        template.Title,
+		Content:      content,
+		IsPublic:     template.IsPublic,
+		Cover:        template.Cover,
+		WorkspaceID:  template.WorkspaceID,
+		Description:  template.Description,
+		Variables:    variables,
+		Placeholders: dto.ExtractTemplatePlaceholders(template.Title, content),
+		UsageCount:   template.UsageCount,
+		CreatedAt:    template.CreatedAt,
+		UpdatedAt:    template.UpdatedAt,
+		User:         user,
+	}, nil
+}
+
+func UpdateTemplateNote(ctx context.Context, params *dto.UpdateTemplateNoteDTO) (responseCode int, data *dto.TemplateNote) {
+	db := database.DB.WithContext(ctx)
+	template, err := repository.GetTemplateNoteByID(db, params.TemplateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_TEMPLATE_NOT_FOUND, nil
+		}
+		return database.IsError(err), nil
+	}
+	// 只有创建者可以修改模板
+	if template.OwnerID != params.UserID {
+		return message.ERROR_TEMPLATE_NOT_FOUND, nil
+	}
+
+	updates := make(map[string]interface{})
+	if params.Title != nil {
+		updates["title"] = *params.Title
+	}
+	if params.Description != nil {
+		updates["description"] = *params.Description
+	}
+	if params.Cover != nil {
+		updates["cover"] = *params.Cover
+	}
+	if params.IsPublic != nil {
+		updates["is_public"] = *params.IsPublic
+		// 公开到模板库时归属当前工作区
+		updates["workspace_id"] = params.WorkspaceID
+	}
+	if params.Content != nil {
+		updates["content"] = datatypes.JSON(tools.MustJSONBytes(*params.Content))
+	}
+	if params.Variables != nil {
+		if !validateTemplateVariables(*params.Variables) {
+			return message.ERROR_TEMPLATE_VARIABLE, nil
+		}
+		updates["variables"] = datatypes.JSON(tools.MustJSONBytes(*params.Variables))
+	}
+
+	if len(updates) > 0 {
+		if err := repository.UpdateTemplateNote(db, template.ID, updates); err != nil {
+			return database.IsError(err), nil
+		}
+	}
+
+	template, err = repository.GetTemplateNoteByID(db, template.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	result, err := toTemplateNoteDTO(template, dto.UserBreifDTO{ID: template.OwnerID})
+	if err != nil {
+		logger.LogError(err, "Unmarshal template content error")
+		return message.ERROR, nil
+	}
+	return message.SUCCESS, &result
+}
+
+func DeleteTemplateNote(ctx context.Context, params *dto.DeleteTemplateNoteDTO) (responseCode int) {
+	db := database.DB.WithContext(ctx)
+	template, err := repository.GetTemplateNoteByID(db, params.TemplateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_TEMPLATE_NOT_FOUND
+		}
+		return database.IsError(err)
+	}
+	if template.OwnerID != params.UserID {
+		return message.ERROR_TEMPLATE_NOT_FOUND
+	}
+
+	if err := repository.DeleteTemplateNote(db, template.ID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
+
+// SaveNoteAsTemplate 把笔记内容另存为模板；需要对笔记有查看权限
+func SaveNoteAsTemplate(ctx context.Context, params *dto.SaveNoteAsTemplateDTO) (responseCode int, data *dto.TemplateNote) {
+	db := database.DB.WithContext(ctx)
+	note, _, code := authorizeNote(ctx, db, params.WorkspaceID, params.NoteID, params.UserID, model.NoteRoleViewer)
+	if code != 0 {
+		return code, nil
+	}
+
+	if !validateTemplateVariables(params.Variables) {
+		return message.ERROR_TEMPLATE_VARIABLE, nil
+	}
+
+	title := note.Title
+	if params.Title != nil {
+		title = *params.Title
+	}
+	variables := params.Variables
+	if variables == nil {
+		variables = []dto.TemplateVariableDTO{}
+	}
+
+	cover, copiedFiles, err := copyCover(ctx, note.Cover)
+	if err != nil {
+		logger.LogError(err, "复制笔记封面失败")
+		return message.ERROR, nil
+	}
+	template := model.TemplateNote{
+		Content:     note.Content,
+		Title:       title,
+		OwnerID:     params.UserID,
+		IsPublic:    params.IsPublic,
+		Cover:       cover,
+		WorkspaceID: &params.WorkspaceID,
+		Description: params.Description,
+		Variables:   datatypes.JSON(tools.MustJSONBytes(variables)),
+	}
+	if err := repository.CreateTemplateNote(db, &template); err != nil {
+		qiniu.DeleteByURLs(ctx, copiedFiles)
+		return database.IsError(err), nil
+	}
+
+	result, err := toTemplateNoteDTO(&template, dto.UserBreifDTO{ID: params.UserID})
+	if err != nil {
+		logger.LogError(err, "Unmarshal template content error")
+		return message.ERROR, nil
+	}
+	return message.SUCCESS, &result
+}
+
+// InstantiateTemplate 渲染模板占位符并在指定分类下创建笔记
+func InstantiateTemplate(ctx context.Context, params *dto.InstantiateTemplateDTO) (responseCode int, data map[string]interface{}) {
+	db := database.DB.WithContext(ctx)
+
+	template, err := repository.GetTemplateNoteByID(db, params.TemplateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_TEMPLATE_NOT_FOUND, nil
+		}
+		return database.IsError(err), nil
+	}
+	if !templateVisible(template, params.UserID, params.WorkspaceID) {
+		return message.ERROR_TEMPLATE_NOT_FOUND, nil
+	}
+
+	if _, err := repository.GetNoteCategoryByID(db, params.WorkspaceID, params.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_CATE_NOT_EXIST, nil
+		}
+		return database.IsError(err), nil
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(template.Content, &content); err != nil {
+		logger.LogError(err, "Unmarshal template content error")
+		return message.ERROR, nil
+	}
+	definitions := make([]dto.TemplateVariableDTO, 0)
+	if len(template.Variables) > 0 {
+		if err := json.Unmarshal(template.Variables, &definitions); err != nil {
+			logger.LogError(err, "Unmarshal template variables error")
+			return message.ERROR, nil
+		}
+	}
+
+	// 内置占位符
+	workspace, err := repository.GetWorkspaceByID(params.WorkspaceID, params.UserID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	userName := templateUserName(params.UserID, params.WorkspaceID)
+	projectName := ""
+	if params.ProjectID != nil {
+		project, err := repository.GetProjectByID(db, *params.ProjectID, params.WorkspaceID)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		if project.ID == 0 {
+			return message.ERROR_PROJECT_NOT_EXIST, nil
+		}
+		projectName = project.Name
+	}
+	values := dto.BuiltinTemplateValues(time.Now(), userName, projectName, workspace.Name, "")
+
+	// 自定义变量：调用方取值 > 默认值；必填变量缺失时报错
+	varTypes := make(map[string]dto.TemplateVariableType, len(definitions))
+	for _, def := range definitions {
+		value := params.Variables[def.Key]
+		if value == "" {
+			value = def.Default
+		}
+		if value == "" {
+			if def.Required {
+				return message.ERROR_TEMPLATE_VARIABLE, nil
+			}
+			continue
+		}
+		if !validTemplateValue(def, value) {
+			return message.ERROR_TEMPLATE_VARIABLE, nil
+		}
+		values[def.Key] = value
+		varTypes[def.Key] = def.Type
+	}
+	// 未在模板中定义的变量也允许直接传值，但不能覆盖内置占位符
+	for key, value := range params.Variables {
+		if _, ok := varTypes[key]; ok || dto.IsBuiltinTemplateVar(key) || !dto.IsValidTemplateVarKey(key) {
+			continue
+		}
+		values[key] = value
+	}
+
+	title := template.Title
+	if params.Title != nil {
+		title = *params.Title
+	}
+	title = truncateRunes(dto.RenderTemplateText(title, values, varTypes), 100)
+	values[dto.TemplateVarTitle] = title
+	rendered := dto.RenderTemplateBlocks(content, values, varTypes)
+
+	noteParams := &dto.CreateWorkspaceNoteDTO{
+		WorkspaceID: params.WorkspaceID,
+		OwnerID:     params.UserID,
+		Title:       title,
+		Content:     &rendered,
+		CategoryID:  params.CategoryID,
+	}
+	noteModel := noteParams.ToModel([]string{})
+	cover, copiedFiles, err := copyCover(ctx, template.Cover)
+	if err != nil {
+		logger.LogError(err, "复制模板封面失败")
+		return message.ERROR, nil
+	}
+	noteModel.Cover = cover
+	if _, err := repository.CreateNote(noteModel); err != nil {
+		qiniu.DeleteByURLs(ctx, copiedFiles)
+		return database.IsError(err), nil
+	}
+	noteParams.ID = &noteModel.ID
+
+	if err := syncNoteLinks(ctx, database.DB, params.WorkspaceID, noteModel.ID, rendered); err != nil {
+		logger.LogError(err, "创建笔记链接失败")
+	}
+	if err := repository.IncreaseTemplateUsage(db, template.ID); err != nil {
+		logger.LogError(err, "更新模板使用次数失败")
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"note":        noteParams,
+		"template_id": strconv.FormatInt(template.ID, 10),
+	}
+}
+
+// copyCover 封面在对象存储中另存一份，模板与笔记互不共用对象，清理一方时不影响另一方；
+// copiedFiles 为新复制的外链，写库失败时由调用方删除
+func copyCover(ctx context.Context, cover *string) (copied *string, copiedFiles []string, err error) {
+	if cover == nil || *cover == "" {
+		return cover, nil, nil
+	}
+	url, ok, err := qiniu.CopyByURL(ctx, *cover)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ok {
+		copiedFiles = append(copiedFiles, url)
+	}
+	return &url, copiedFiles, nil
+}
+
+// templateUserName {{user}} 的取值：优先工作区昵称，其次用户昵称
+func templateUserName(userID, workspaceID int64) string {
+	if member, err := repository.GetWorkspaceMember(userID, workspaceID); err == nil && member != nil && member.Nickname != "" {
+		return member.Nickname
+	}
+	if user, code := repository.GetUserByID(userID); code == 0 && user.Nickname != nil {
+		return *user.Nickname
+	}
+	return ""
+}
+
+func validTemplateValue(def dto.TemplateVariableDTO, value string) bool {
+	switch def.Type {
+	case dto.TemplateVariableSelect:
+		return slices.Contains(def.Options, value)
+	case dto.TemplateVariableNumber:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case dto.TemplateVariableDate:
+		if _, err := time.Parse("2006-01-02", value); err == nil {
+			return true
+		}
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	}
+	return true
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
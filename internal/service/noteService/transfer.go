@@ -0,0 +1,412 @@
+package noteService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/thirdparty/qiniu"
+	"gin-notebook/pkg/logger"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// noteTransfer 一次跨工作区转移所需的数据，事务外读取，事务内写入
+type noteTransfer struct {
+	params       *dto.TransferNoteDTO
+	note         *model.Note
+	comments     []model.NoteComment
+	mentions     []model.NoteCommentMention
+	attachments  []model.NoteCommentAttachment
+	links        []model.NoteExternalLink
+	memberMap    map[int64]int64   // 源成员 ID -> 目标成员 ID
+	actorMember  int64             // 操作人在目标工作区的成员 ID
+	fileMap      map[string]string // 复制模式下原外链 -> 新外链
+	copiedFiles  []string
+	syncedLinks  int
+	droppedLinks int
+}
+
+// TransferNote 把笔记复制或移动到用户所在的另一个工作区。
+// 源笔记需要 viewer（复制）或 owner（移动）权限，目标工作区需要是其成员且目标分类存在。
+func TransferNote(ctx context.Context, params *dto.TransferNoteDTO) (responseCode int, data map[string]interface{}) {
+	required := model.NoteRoleViewer
+	if params.Mode == dto.NoteTransferMove {
+		required = model.NoteRoleOwner
+	}
+	note, _, code := authorizeNote(ctx, database.DB, params.WorkspaceID, params.NoteID, params.UserID, required)
+	if code != 0 {
+		return code, nil
+	}
+	// 移动会改写源笔记，被他人签出时拒绝
+	if params.Mode == dto.NoteTransferMove {
+		if lock, code := checkNoteLock(ctx, note.ID, params.UserID); code != 0 {
+			if lock != nil {
+				return code, map[string]interface{}{"lock": lock}
+			}
+			return code, nil
+		}
+	}
+
+	actor, err := repository.GetWorkspaceMember(params.UserID, params.TargetWorkspaceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_TRANSFER_TARGET_DENIED, nil
+		}
+		return database.IsError(err), nil
+	}
+
+	t := &noteTransfer{params: params, note: note, actorMember: actor.ID, fileMap: make(map[string]string)}
+	if code := t.load(ctx); code != 0 {
+		return code, nil
+	}
+	if params.Mode == dto.NoteTransferCopy {
+		if err := t.copyFiles(ctx); err != nil {
+			logger.LogError(err, "复制笔记附件失败")
+			qiniu.DeleteByURLs(ctx, t.copiedFiles)
+			return message.ERROR, nil
+		}
+	}
+
+	var target *model.Note
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.WithContext(ctx)
+		if _, err := repository.GetNoteCategoryByID(tx, params.TargetWorkspaceID, params.TargetCategoryID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseCode = message.ERROR_CATE_NOT_EXIST
+			} else {
+				responseCode = database.IsError(err)
+			}
+			return err
+		}
+
+		var err error
+		if params.Mode == dto.NoteTransferMove {
+			target, err = t.move(ctx, tx)
+		} else {
+			target, err = t.copy(ctx, tx)
+		}
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		return nil
+	})
+
+	if err != nil {
+		qiniu.DeleteByURLs(ctx, t.copiedFiles)
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return
+	}
+
+	// 移动后由索引任务下线源工作区的文档并在目标工作区重建
+	triggerNoteIngest(ctx, []model.Note{*target})
+
+	data = map[string]interface{}{
+		"note_id":       strconv.FormatInt(target.ID, 10),
+		"workspace_id":  strconv.FormatInt(target.WorkspaceID, 10),
+		"mode":          params.Mode,
+		"synced_links":  t.syncedLinks,
+		"dropped_links": t.droppedLinks,
+	}
+	responseCode = message.SUCCESS
+	return
+}
+
+// load 读取评论、同步链接，并建立成员映射
+func (t *noteTransfer) load(ctx context.Context) int {
+	repo := repository.NewNoteTransferRepository(database.DB)
+
+	memberIDs := make([]int64, 0)
+	if t.params.IncludeComments {
+		comments, err := repo.ListComments(ctx, t.note.ID)
+		if err != nil {
+			return database.IsError(err)
+		}
+		commentIDs := make([]int64, 0, len(comments))
+		for _, comment := range comments {
+			commentIDs = append(commentIDs, comment.ID)
+			memberIDs = append(memberIDs, comment.MemberID)
+		}
+		if t.mentions, err = repo.ListMentions(ctx, commentIDs); err != nil {
+			return database.IsError(err)
+		}
+		if t.attachments, err = repo.ListAttachments(ctx, commentIDs); err != nil {
+			return database.IsError(err)
+		}
+		for _, mention := range t.mentions {
+			memberIDs = append(memberIDs, mention.MemberID)
+		}
+		t.comments = comments
+	}
+
+	links, err := repo.ListExternalLinks(ctx, t.note.ID)
+	if err != nil {
+		return database.IsError(err)
+	}
+	t.links = links
+	for _, link := range links {
+		memberIDs = append(memberIDs, link.MemberID)
+	}
+
+	if t.memberMap, err = repo.MapMembersByUser(ctx, memberIDs, t.params.TargetWorkspaceID); err != nil {
+		return database.IsError(err)
+	}
+	return 0
+}
+
+// copyFiles 复制封面与评论附件，避免任一笔记清理回收站时删掉另一份引用的文件
+func (t *noteTransfer) copyFiles(ctx context.Context) error {
+	urls := make([]string, 0, len(t.attachments)+1)
+	if t.note.Cover != nil && *t.note.Cover != "" {
+		urls = append(urls, *t.note.Cover)
+	}
+	for _, attachment := range t.attachments {
+		urls = append(urls, attachment.FileURL)
+	}
+	for _, url := range urls {
+		if _, ok := t.fileMap[url]; ok {
+			continue
+		}
+		newURL, copied, err := qiniu.CopyByURL(ctx, url)
+		if err != nil {
+			return err
+		}
+		if copied {
+			t.copiedFiles = append(t.copiedFiles, newURL)
+		}
+		t.fileMap[url] = newURL
+	}
+	return nil
+}
+
+func (t *noteTransfer) fileURL(url string) string {
+	if newURL, ok := t.fileMap[url]; ok {
+		return newURL
+	}
+	return url
+}
+
+func (t *noteTransfer) targetMember(memberID int64) (int64, bool) {
+	id, ok := t.memberMap[memberID]
+	return id, ok
+}
+
+// targetAuthor 评论作者或解决人不在目标工作区时由操作人接管，访客评论保持没有成员作者
+func (t *noteTransfer) targetAuthor(memberID int64) int64 {
+	if memberID == 0 {
+		return 0
+	}
+	if id, ok := t.memberMap[memberID]; ok {
+		return id
+	}
+	return t.actorMember
+}
+
+func (t *noteTransfer) move(ctx context.Context, tx *gorm.DB) (*model.Note, error) {
+	repo := repository.NewNoteTransferRepository(tx)
+	note := *t.note
+
+	// 原作者不在目标工作区时由操作人接管
+	ownerID := note.OwnerID
+	if _, err := repository.GetWorkspaceMember(ownerID, t.params.TargetWorkspaceID); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		ownerID = t.params.UserID
+	}
+
+	updates := map[string]interface{}{
+		"workspace_id": t.params.TargetWorkspaceID,
+		"category_id":  t.params.TargetCategoryID,
+		"owner_id":     ownerID,
+		"tags_id":      nil, // 标签按工作区划分
+	}
+	if err := repo.UpdateNote(ctx, note.ID, updates); err != nil {
+		return nil, err
+	}
+	note.WorkspaceID = t.params.TargetWorkspaceID
+	note.CategoryID = t.params.TargetCategoryID
+	note.OwnerID = ownerID
+	note.TagsID = 0
+
+	if err := repo.DetachFromWorkspace(ctx, note.ID); err != nil {
+		return nil, err
+	}
+	if t.params.IncludeComments {
+		if err := repo.MoveComments(ctx, note.ID, note.WorkspaceID, t.memberMap, t.actorMember); err != nil {
+			return nil, err
+		}
+	} else if err := repo.DeleteComments(ctx, note.ID); err != nil {
+		return nil, err
+	}
+
+	if err := t.applySyncLinks(ctx, tx, note.ID); err != nil {
+		return nil, err
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(note.Content, &content); err != nil {
+		return nil, err
+	}
+	if err := syncNoteLinks(ctx, tx, note.WorkspaceID, note.ID, content); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// copy 保留块 ID，评论锚点与同步块映射可以直接沿用
+func (t *noteTransfer) copy(ctx context.Context, tx *gorm.DB) (*model.Note, error) {
+	repo := repository.NewNoteTransferRepository(tx)
+
+	copied := &model.Note{
+		Title:       t.note.Title,
+		Content:     t.note.Content,
+		WorkspaceID: t.params.TargetWorkspaceID,
+		CategoryID:  t.params.TargetCategoryID,
+		OwnerID:     t.params.UserID,
+	}
+	if t.note.Cover != nil {
+		cover := t.fileURL(*t.note.Cover)
+		copied.Cover = &cover
+	}
+	if err := repo.CreateNote(ctx, copied); err != nil {
+		return nil, err
+	}
+
+	if t.params.IncludeComments {
+		if err := t.copyComments(ctx, tx, copied); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.applySyncLinks(ctx, tx, copied.ID); err != nil {
+		return nil, err
+	}
+
+	var content dto.Blocks
+	if err := json.Unmarshal(copied.Content, &content); err != nil {
+		return nil, err
+	}
+	if err := syncNoteLinks(ctx, tx, copied.WorkspaceID, copied.ID, content); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+func (t *noteTransfer) copyComments(ctx context.Context, tx *gorm.DB, copied *model.Note) error {
+	if len(t.comments) == 0 {
+		return nil
+	}
+	repo := repository.NewNoteTransferRepository(tx)
+
+	// 先写根评论拿到新 ID，再写回复
+	idMap := make(map[int64]int64, len(t.comments))
+	roots, replies := make([]model.NoteComment, 0), make([]model.NoteComment, 0)
+	rootSources, replySources := make([]int64, 0), make([]int64, 0)
+	for _, comment := range t.comments {
+		item := comment
+		item.ID = 0
+		item.NoteID = copied.ID
+		item.WorkspaceID = copied.WorkspaceID
+		item.DeletedAt = nil
+		item.MemberID = t.targetAuthor(comment.MemberID)
+		if item.ResolvedBy != nil {
+			resolvedBy := t.targetAuthor(*item.ResolvedBy)
+			item.ResolvedBy = &resolvedBy
+		}
+		if comment.ParentID == 0 {
+			roots = append(roots, item)
+			rootSources = append(rootSources, comment.ID)
+		} else {
+			replies = append(replies, item)
+			replySources = append(replySources, comment.ID)
+		}
+	}
+	if err := repo.CreateComments(ctx, roots); err != nil {
+		return err
+	}
+	for i := range roots {
+		idMap[rootSources[i]] = roots[i].ID
+	}
+	for i := range replies {
+		replies[i].ParentID = idMap[replies[i].ParentID]
+	}
+	if err := repo.CreateComments(ctx, replies); err != nil {
+		return err
+	}
+	for i := range replies {
+		idMap[replySources[i]] = replies[i].ID
+	}
+
+	mentions := make([]model.NoteCommentMention, 0, len(t.mentions))
+	for _, mention := range t.mentions {
+		commentID, ok := idMap[mention.CommentID]
+		memberID, mapped := t.targetMember(mention.MemberID)
+		if !ok || !mapped {
+			continue
+		}
+		mentions = append(mentions, model.NoteCommentMention{
+			CommentID: commentID,
+			MemberID:  memberID,
+			StartRune: mention.StartRune,
+			EndRune:   mention.EndRune,
+		})
+	}
+	if err := repo.CreateMentions(ctx, mentions); err != nil {
+		return err
+	}
+
+	attachments := make([]model.NoteCommentAttachment, 0, len(t.attachments))
+	for _, attachment := range t.attachments {
+		commentID, ok := idMap[attachment.CommentID]
+		if !ok {
+			continue
+		}
+		item := attachment
+		item.ID = 0
+		item.DeletedAt = nil
+		item.CommentID = commentID
+		item.FileURL = t.fileURL(attachment.FileURL)
+		attachments = append(attachments, item)
+	}
+	return repo.CreateAttachments(ctx, attachments)
+}
+
+// applySyncLinks 按 sync_links 处理外部同步链接；repoint 时链接创建者不在目标工作区的链接会被删除
+func (t *noteTransfer) applySyncLinks(ctx context.Context, tx *gorm.DB, noteID int64) error {
+	repo := repository.NewNoteTransferRepository(tx)
+	drop := make([]int64, 0)
+	switch t.params.SyncLinks {
+	case dto.SyncLinkKeep:
+		if t.params.Mode == dto.NoteTransferMove {
+			t.syncedLinks = len(t.links)
+		}
+	case dto.SyncLinkDrop:
+		if t.params.Mode == dto.NoteTransferMove {
+			for _, link := range t.links {
+				drop = append(drop, link.ID)
+			}
+		}
+	case dto.SyncLinkRepoint:
+		for i := range t.links {
+			memberID, ok := t.targetMember(t.links[i].MemberID)
+			if !ok {
+				drop = append(drop, t.links[i].ID)
+				continue
+			}
+			if err := repo.RepointExternalLink(ctx, &t.links[i], noteID, memberID); err != nil {
+				return err
+			}
+			t.syncedLinks++
+		}
+	}
+	t.droppedLinks = len(drop)
+	return repo.DropExternalLinks(ctx, drop)
+}
@@ -0,0 +1,47 @@
+package noteService_test
+
+import (
+	"context"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/service/noteService"
+	"testing"
+)
+
+func TestTransferNoteMoveDropsSyncLinks(t *testing.T) {
+	setupNotes(t)
+	note := seedNote(t, "roadmap", aliceUserID, rootCategoryID)
+	link := model.NoteExternalLink{NoteID: note.ID, Provider: model.ProviderNotion, TargetNoteID: "page-1", MemberID: aliceMemberID, IsActive: true}
+	seed(t, &link)
+	seed(t,
+		&model.NoteExternalNodeMapping{NoteID: note.ID, Provider: model.ProviderNotion, NodeUID: "a", ExternalDocID: "page-1", ExternalBlockID: "r-a"},
+		&model.SyncOutbox{NoteID: note.ID, LinkID: link.ID, OpType: "delta", PatchJSON: []byte(`[]`)},
+		&model.NoteSyncConflict{NoteID: note.ID, LinkID: link.ID, Provider: model.ProviderNotion, NodeUID: "a", ExternalBlockID: "r-a"},
+	)
+
+	code, data := noteService.TransferNote(context.Background(), &dto.TransferNoteDTO{
+		WorkspaceID: testWorkspaceID, NoteID: note.ID, TargetWorkspaceID: otherWorkspaceID,
+		TargetCategoryID: otherCategoryID, Mode: dto.NoteTransferMove, SyncLinks: dto.SyncLinkDrop, UserID: aliceUserID,
+	})
+	if code != message.SUCCESS {
+		t.Fatalf("transfer code = %d", code)
+	}
+	if data["dropped_links"] != 1 || data["synced_links"] != 0 {
+		t.Fatalf("transfer data = %v", data)
+	}
+
+	var moved model.Note
+	if err := database.DB.First(&moved, "id = ?", note.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if moved.WorkspaceID != otherWorkspaceID || moved.CategoryID != otherCategoryID {
+		t.Fatalf("note moved to workspace %d category %d", moved.WorkspaceID, moved.CategoryID)
+	}
+	for _, m := range []interface{}{&model.NoteExternalLink{}, &model.NoteExternalNodeMapping{}, &model.SyncOutbox{}, &model.NoteSyncConflict{}} {
+		if n := count(t, m, "note_id = ?", note.ID); n != 0 {
+			t.Fatalf("%T: %d rows left after drop", m, n)
+		}
+	}
+}
@@ -3,18 +3,26 @@ package noteService
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gin-notebook/internal/http/message"
 	"gin-notebook/internal/model"
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/realtime/bus"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/webhookService"
 	"gin-notebook/internal/tasks/asynq/enqueue"
 	"gin-notebook/internal/tasks/asynq/types"
 	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+	"gin-notebook/pkg/utils/tools"
 	"reflect"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/morikuni/go-lexorank"
 	"gorm.io/gorm"
 )
 
@@ -51,6 +59,11 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 		MemberID int64
 	}
 	linksIDMapping := make(map[int64]int64)
+	var effects *ContentEffects
+	// 实际生效的块操作，同步 outbox 只下发这些
+	var appliedActions []dto.PatchOp
+	var changedFields []string
+	var updatedVersion int64
 	// —— 事务 —— //
 	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		note, err := repository.GetNoteByID(tx, ctx, params.WorkspaceID, params.NoteID)
@@ -60,11 +73,30 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 			return err
 		}
 
-		if note == nil {
+		if note == nil || note.ID == 0 {
 			responseCode = message.ERROR_NOTE_NOT_FOUND
 			return fmt.Errorf("note not found")
 		}
 
+		// 修改可见性与权限开关属于 owner 操作，其余修改需要 editor
+		required := model.NoteRoleEditor
+		if params.Status != nil || params.AllowEdit != nil || params.AllowComment != nil ||
+			params.AllowShare != nil || params.AllowJoin != nil || params.AllowInvite != nil {
+			required = model.NoteRoleOwner
+		}
+		if _, code := checkNoteRole(ctx, tx, note, params.OwnerID, required); code != 0 {
+			responseCode = code
+			return fmt.Errorf("no permission")
+		}
+		// 被他人签出的笔记只允许持有者修改
+		if lock, code := checkNoteLock(ctx, note.ID, params.OwnerID); code != 0 {
+			if lock != nil {
+				data = map[string]interface{}{"lock": lock}
+			}
+			responseCode = code
+			return fmt.Errorf("note locked")
+		}
+
 		newVersion := note.Version + 1
 		updateData["version"] = newVersion
 
@@ -73,9 +105,21 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 			responseCode = message.ERROR
 			return err
 		}
+		var newContent dto.Blocks
 		switch {
 		case params.Actions != nil:
-			updateData["content"] = dto.UpdateBlock(content, *params.Actions)
+			newContent, appliedActions, err = dto.ApplyPatch(content, *params.Actions)
+			if err != nil {
+				var patchErr *dto.PatchError
+				if errors.As(err, &patchErr) {
+					data = map[string]interface{}{"error": patchErr}
+				}
+				responseCode = message.ERROR_BLOCK_PATCH_INVALID
+				return err
+			}
+			updateData["content"] = newContent
+		case params.Content != nil:
+			newContent = *params.Content
 		default:
 			// 只改元数据，什么也不做
 		}
@@ -104,14 +148,22 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 			return err
 		}
 
-		if params.Actions != nil && len(*params.Actions) > 0 {
+		if newContent != nil {
+			effects, err = ApplyContentEffects(ctx, tx, note, content, newContent)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+		}
+
+		if len(appliedActions) > 0 {
 			links, _, err := repository.GetNoteSyncList(tx, ctx, nil, &params.NoteID, nil)
 
 			if err != nil {
 				responseCode = database.IsError(err)
 				return err
 			}
-			logger.LogInfo("Actions length", len(*params.Actions))
+			logger.LogInfo("Actions length", len(appliedActions))
 
 			if links != nil && len(*links) > 0 {
 				logger.LogInfo("links length", len(*links))
@@ -121,7 +173,11 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 				outboxs := make([]model.SyncOutbox, 0)
 
 				for _, link := range *links {
-					patchJson, err := json.Marshal(params.Actions)
+					// 只拉取的链接不向外推送本地修改
+					if link.Direction == model.SyncPullOnly {
+						continue
+					}
+					patchJson, err := json.Marshal(appliedActions)
 					if err != nil {
 						logger.LogError(err, "Marshal Action error")
 						continue
@@ -154,6 +210,12 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 		data = map[string]interface{}{
 			"note": updateData,
 		}
+		updatedVersion = newVersion
+		for k := range updateData {
+			if k != "version" && k != "updated_at" {
+				changedFields = append(changedFields, k)
+			}
+		}
 		return nil
 	})
 
@@ -166,6 +228,18 @@ func UpdateNote(ctx context.Context, params *dto.UpdateWorkspaceNoteValidator) (
 		return
 	}
 
+	effects.Publish(ctx, params.OwnerID)
+	sort.Strings(changedFields)
+	if err := bus.PublishNoteUpdated(ctx, params.NoteID, updatedVersion, changedFields, params.OwnerID); err != nil {
+		logger.LogError(err, "推送笔记更新事件失败")
+	}
+	webhookService.Dispatch(ctx, params.WorkspaceID, 0, model.WebhookNoteUpdated, map[string]interface{}{
+		"note_id":  strconv.FormatInt(params.NoteID, 10),
+		"version":  updatedVersion,
+		"fields":   changedFields,
+		"actor_id": strconv.FormatInt(params.OwnerID, 10),
+	})
+
 	for k, v := range linksIDMapping {
 		payload := types.SyncDeltaPayload{
 			LinkID:      k,
@@ -191,3 +265,152 @@ func UpdateNoteCategory(params *dto.UpdateNoteCategoryDTO) (responseCode int, da
 	responseCode = message.SUCCESS
 	return
 }
+
+// categoryRankBetween 计算位于 a、b 之间的 LexoRank，空串分别视为最小/最大边界
+func categoryRankBetween(a, b string) string {
+	left, right := algorithm.RankMin(), algorithm.RankMax()
+	if a != "" {
+		left = lexorank.BucketKey(a)
+	}
+	if b != "" {
+		right = lexorank.BucketKey(b)
+	}
+	return algorithm.RankBetweenBucket(left, right).String()
+}
+
+func MoveNoteCategory(ctx context.Context, params *dto.MoveNoteCategoryDTO) (responseCode int, data map[string]interface{}) {
+	if _, _, code := workspaceMember(params.UserID, params.WorkspaceID); code != 0 {
+		return code, nil
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		tx = tx.WithContext(ctx)
+
+		category, err := repository.GetNoteCategoryByID(tx, params.WorkspaceID, params.ID, repository.WithLock())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				responseCode = message.ERROR_CATE_NOT_EXIST
+			} else {
+				responseCode = database.IsError(err)
+			}
+			return err
+		}
+
+		// 防止成环：目标父级不能是自身，也不能是自身的子孙
+		if params.ParentID != nil {
+			if *params.ParentID == category.ID {
+				responseCode = message.ERROR_CATE_MOVE_CYCLE
+				return gorm.ErrInvalidData
+			}
+
+			if _, err := repository.GetNoteCategoryByID(tx, params.WorkspaceID, *params.ParentID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					responseCode = message.ERROR_CATE_PARENT_NOT_EXIST
+				} else {
+					responseCode = database.IsError(err)
+				}
+				return err
+			}
+
+			ancestors, err := repository.GetCategoryAncestorIDs(tx, *params.ParentID)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if tools.Contains(ancestors, category.ID) {
+				responseCode = message.ERROR_CATE_MOVE_CYCLE
+				return gorm.ErrInvalidData
+			}
+		}
+
+		// 不能以自身作为相邻分类
+		if (params.AfterID != nil && *params.AfterID == category.ID) || (params.BeforeID != nil && *params.BeforeID == category.ID) {
+			responseCode = message.ERROR_INVALID_PARAMS
+			return gorm.ErrInvalidData
+		}
+
+		var neighborIDs []int64
+		if params.AfterID != nil {
+			neighborIDs = append(neighborIDs, *params.AfterID)
+		}
+		if params.BeforeID != nil {
+			neighborIDs = append(neighborIDs, *params.BeforeID)
+		}
+
+		var orderIndex string
+		if len(neighborIDs) == 0 {
+			last, err := repository.GetSiblingEdgeOrderIndex(tx, params.WorkspaceID, params.ParentID, false)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			orderIndex = categoryRankBetween(last, "")
+		} else {
+			neighbors, err := repository.GetNoteCategoriesByIDs(tx, params.WorkspaceID, neighborIDs)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+
+			neighborMapping := make(map[int64]model.NoteCategory, len(neighbors))
+			for _, n := range neighbors {
+				// 相邻分类必须与目标位置同级
+				if !sameCategoryParent(n.ParentID, params.ParentID) {
+					responseCode = message.ERROR_INVALID_PARAMS
+					return gorm.ErrInvalidData
+				}
+				neighborMapping[n.ID] = n
+			}
+
+			if len(neighborMapping) != len(neighborIDs) {
+				responseCode = message.ERROR_CATE_NOT_EXIST
+				return gorm.ErrRecordNotFound
+			}
+
+			var after, before string
+			if params.AfterID != nil {
+				after = neighborMapping[*params.AfterID].OrderIndex
+			}
+			if params.BeforeID != nil {
+				before = neighborMapping[*params.BeforeID].OrderIndex
+			}
+			orderIndex = categoryRankBetween(after, before)
+		}
+
+		err = repository.UpdateNoteCategoryByID(tx, category.ID, map[string]interface{}{
+			"parent_id":   params.ParentID,
+			"order_index": orderIndex,
+		})
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+
+		data = map[string]interface{}{
+			"id":          strconv.FormatInt(category.ID, 10),
+			"parent_id":   nil,
+			"order_index": orderIndex,
+		}
+		if params.ParentID != nil {
+			data["parent_id"] = strconv.FormatInt(*params.ParentID, 10)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return
+	}
+
+	responseCode = message.SUCCESS
+	return
+}
+
+func sameCategoryParent(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,106 @@
+package projectService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// checklistNoteSync 任务状态回写到笔记后，需要在事务提交后触发的外部同步
+type checklistNoteSync struct {
+	NoteID      int64
+	WorkspaceID int64
+	Version     int64
+	Links       map[int64]int64 // linkID -> memberID
+}
+
+// syncTaskChecklist 任务完成/重新打开时勾选或取消勾选关联的笔记清单块；
+// 笔记被他人签出时不回写，避免覆盖对方的独占编辑
+func syncTaskChecklist(ctx context.Context, tx *gorm.DB, task *model.ToDoTask, userID int64) (*checklistNoteSync, error) {
+	checklistRepo := repository.NewChecklistRepository(tx)
+	link, err := checklistRepo.GetByTask(ctx, task.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	done := task.Status == model.TaskStatusCompleted
+	if column, err := checklistRepo.GetColumn(ctx, task.ProjectID, task.ColumnID); err == nil {
+		done = done || column.ProcessID == model.ProcessDone
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if lockedByOther(ctx, link.NoteID, userID) {
+		logger.LogInfo(fmt.Sprintf("笔记 %d 已被他人签出，跳过清单回写 task=%d", link.NoteID, task.ID))
+		return nil, nil
+	}
+
+	note, ops, err := checklistRepo.MarkBlockChecked(ctx, link.NoteID, link.BlockID, done)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	links, err := checklistRepo.CreatePatchOutboxes(ctx, note.ID, note.Version, ops)
+	if err != nil {
+		return nil, err
+	}
+	return &checklistNoteSync{NoteID: note.ID, WorkspaceID: note.WorkspaceID, Version: note.Version, Links: links}, nil
+}
+
+// lockedByOther 笔记是否被 userID 以外的用户签出；锁状态读取失败时按已锁定处理
+func lockedByOther(ctx context.Context, noteID, userID int64) bool {
+	if cache.RedisInstance == nil {
+		return false
+	}
+	raw, err := cache.RedisInstance.GetNoteLock(ctx, noteID)
+	if err != nil {
+		logger.LogError(err, "读取笔记锁失败")
+		return true
+	}
+	if raw == "" {
+		return false
+	}
+	var lock dto.NoteLockState
+	if err := json.Unmarshal([]byte(raw), &lock); err != nil {
+		return false // 与笔记编辑一致，损坏的锁状态视为未加锁
+	}
+	return lock.UserID != userID
+}
+
+func (s *checklistNoteSync) dispatch(ctx context.Context, userID int64) {
+	if s == nil {
+		return
+	}
+	if err := bus.PublishNoteUpdated(ctx, s.NoteID, s.Version, []string{"content"}, userID); err != nil {
+		logger.LogError(err, "推送笔记更新事件失败")
+	}
+	for linkID, memberID := range s.Links {
+		enqueue.SyncDelta(ctx, types.SyncDeltaPayload{
+			LinkID:      linkID,
+			NoteID:      s.NoteID,
+			WorkspaceID: s.WorkspaceID,
+			UserID:      userID,
+			MemberID:    memberID,
+		})
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/pkg/realtime/bus"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/service/webhookService"
 	"gin-notebook/internal/tasks/asynq/enqueue"
 	"gin-notebook/internal/tasks/asynq/types"
 	"gin-notebook/pkg/utils/algorithm"
@@ -28,10 +30,18 @@ func CreateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 	task.Creator = params.Creator
 	task.ProjectID = params.ProjectID
 	task.ColumnID = params.ColumnID
-	task.Description = tools.MustJSONBytes(dto.DefaultBlocks())
+	if params.Payload.Priority != nil {
+		task.Priority = model.StringToPriority[*params.Payload.Priority]
+	}
+	description := params.Description
+	if len(description) == 0 {
+		description = dto.DefaultBlocks()
+	}
+	task.Description = tools.MustJSONBytes(description)
 
 	const maxRetry = 3
 	var latestError error
+	var assigneeIDs []string
 	for attempt := 0; attempt < maxRetry; attempt++ {
 		err := database.DB.Transaction(func(tx *gorm.DB) error {
 			firstTask, err := repository.GetFirstTask(tx, params.ColumnID, repository.WithLock())
@@ -66,6 +76,10 @@ func CreateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 				if len(assignees) > 0 {
 					repository.CreateModel(tx, &assignees)
 				}
+				assigneeIDs = assigneeIDs[:0]
+				for _, a := range assignees {
+					assigneeIDs = append(assigneeIDs, strconv.FormatInt(a.AssigneeID, 10))
+				}
 			}
 
 			return nil
@@ -82,6 +96,24 @@ func CreateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 		responseCode = message.SUCCESS
 		data = tools.StructToUpdateMap(&task, nil, []string{"DeletedAt", "CreatedAt", "Creator"})
 		data["priority"] = model.PriorityMap[task.Priority]
+		integrationService.DispatchTaskIssueSync(ctx, task.ID)
+		webhookService.Dispatch(ctx, params.WorkspaceID, task.ProjectID, model.WebhookTaskCreated, map[string]interface{}{
+			"task_id":      strconv.FormatInt(task.ID, 10),
+			"project_id":   strconv.FormatInt(task.ProjectID, 10),
+			"column_id":    strconv.FormatInt(task.ColumnID, 10),
+			"title":        task.Title,
+			"status":       task.Status,
+			"priority":     model.PriorityMap[task.Priority],
+			"assignee_ids": assigneeIDs,
+			"actor_id":     strconv.FormatInt(params.Creator, 10),
+		})
+		if len(assigneeIDs) > 0 {
+			notifyFeishu(ctx, model.FeishuNotifyAssigned, types.FeishuNotifyPayload{
+				TaskID:    task.ID,
+				ActorID:   params.MemberID,
+				MemberIDs: parseMemberIDs(assigneeIDs),
+			})
+		}
 	}
 
 	isSuccess := latestError == nil
@@ -95,7 +127,7 @@ func CreateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 		ColumnID:    &task.ColumnID,
 		WorkspaceID: params.WorkspaceID,
 		OriginData:  task,
-		Patch:       nil,
+		Patch:       params.Source,
 		Action:      model.CreateAction,
 		TargetType:  model.TargetTask,
 		TargetID:    task.ID,
@@ -211,6 +243,28 @@ func CreateTaskComment(ctx context.Context, params *dto.CreateToDoTaskCommentDTO
 	}
 
 	responseCode = message.SUCCESS
+	integrationService.DispatchTaskIssueSync(ctx, params.TaskID)
+	// 评论参数不带项目，按任务所在项目过滤订阅
+	var projectID int64
+	if task, err := repository.GetProjectTaskByID(database.DB, params.TaskID); err == nil {
+		projectID = task.ProjectID
+	}
+	webhookService.Dispatch(ctx, params.WorkspaceID, projectID, model.WebhookCommentAdded, map[string]interface{}{
+		"target_type": "task",
+		"task_id":     strconv.FormatInt(params.TaskID, 10),
+		"comment_id":  strconv.FormatInt(comment.ID, 10),
+		"member_id":   strconv.FormatInt(params.MemberID, 10),
+		"content":     comment.Content,
+	})
+
+	if mentioned := newMentionedMembers(nil, params.Mentions); len(mentioned) > 0 {
+		notifyFeishu(ctx, model.FeishuNotifyMentioned, types.FeishuNotifyPayload{
+			TaskID:    params.TaskID,
+			ActorID:   params.MemberID,
+			MemberIDs: mentioned,
+			CommentID: comment.ID,
+		})
+	}
 
 	return responseCode, data
 }
@@ -0,0 +1,48 @@
+package projectService
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// notifyFeishu 看板通知交给异步任务发送，未配置飞书时由任务直接跳过；入队失败只记录日志
+func notifyFeishu(ctx context.Context, kind model.FeishuNotifyKind, p types.FeishuNotifyPayload) {
+	p.EventID, p.Kind = uuid.NewString(), string(kind)
+	if _, err := enqueue.FeishuNotify(ctx, p); err != nil {
+		logger.LogError(err, "飞书通知入队失败 kind=", kind, " task=", p.TaskID)
+	}
+}
+
+// parseMemberIDs 忽略无法解析的成员 ID
+func parseMemberIDs(ids []string) []int64 {
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if v, err := strconv.ParseInt(id, 10, 64); err == nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// newMentionedMembers 评论中去重后的被提及成员；编辑评论时排除编辑前已提及的成员，只通知新增的提及
+func newMentionedMembers(before []int64, mentions []dto.EditableCommentMentionDTO) []int64 {
+	seen := make(map[int64]bool, len(before))
+	for _, id := range before {
+		seen[id] = true
+	}
+	out := make([]int64, 0, len(mentions))
+	for _, m := range mentions {
+		if m.MemberID > 0 && !seen[m.MemberID] {
+			seen[m.MemberID] = true
+			out = append(out, m.MemberID)
+		}
+	}
+	return out
+}
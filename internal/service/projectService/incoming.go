@@ -0,0 +1,175 @@
+package projectService
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const incomingTitleMaxRunes = 200 // ToDoTask.Title 的列宽
+
+// HandleIncomingTask 接收外部系统（如 CI、工单系统）推送的任务，创建在接收地址配置的列；
+// 带 ExternalKey 时同一键只创建一次，重复推送返回已创建的任务
+func HandleIncomingTask(ctx context.Context, params *dto.IncomingTaskDTO) (responseCode int, data map[string]interface{}) {
+	repo := repository.NewProjectSyncRepository(database.DB)
+	link, err := repo.GetProjectLink(ctx, params.LinkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_INCOMING_NOT_FOUND, nil
+		}
+		return database.IsError(err), nil
+	}
+	if link.Provider != model.ProviderHook || !link.IsActive {
+		return message.ERROR_INCOMING_NOT_FOUND, nil
+	}
+	if params.Token == "" || subtle.ConstantTimeCompare([]byte(params.Token), []byte(link.WebhookSecret)) != 1 {
+		return message.ERROR_INCOMING_TOKEN_INVALID, nil
+	}
+
+	if params.ExternalKey != "" {
+		if cache.RedisInstance == nil {
+			return message.ERROR_REDIS, nil
+		}
+		unlock, err := cache.RedisInstance.Lock(ctx, fmt.Sprintf("incoming:%d:%s", link.ID, params.ExternalKey), 30*time.Second)
+		if err != nil {
+			return message.ERROR_INCOMING_BUSY, nil
+		}
+		defer unlock()
+
+		existing, err := repo.GetTaskLinkByIssue(ctx, link.ID, params.ExternalKey)
+		if err == nil {
+			return message.SUCCESS, map[string]interface{}{
+				"task_id":   strconv.FormatInt(existing.TaskID, 10),
+				"duplicate": true,
+			}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return database.IsError(err), nil
+		}
+	}
+
+	columnID := link.HookColumnID()
+	if _, err := repository.NewChecklistRepository(database.DB).GetColumn(ctx, link.ProjectID, columnID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return message.ERROR_INCOMING_COLUMN_INVALID, nil
+		}
+		return database.IsError(err), nil
+	}
+	creator, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		return message.ERROR_WORKSPACE_MEMBER_NOT_EXIST, nil
+	}
+	workspaceID, err := repo.GetProjectWorkspaceID(ctx, link.ProjectID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+
+	source := map[string]interface{}{
+		"source":    string(model.ProviderHook),
+		"link_id":   strconv.FormatInt(link.ID, 10),
+		"link_name": link.ExternalProjectKey,
+	}
+	if params.ExternalKey != "" {
+		source["external_key"] = params.ExternalKey
+	}
+	if len(params.Labels) > 0 {
+		source["labels"] = params.Labels
+	}
+
+	title := truncateTaskTitle(strings.TrimSpace(params.Title))
+	payload := dto.TaskEditableDTO{Title: &title}
+	if params.Priority != "" {
+		payload.Priority = &params.Priority
+	}
+	if params.AssigneeEmail != "" {
+		if memberID, ok := incomingAssignee(workspaceID, params.AssigneeEmail); ok {
+			payload.AssigneeActions = &dto.UpdateAssigneeDTO{ActionAdd: []string{strconv.FormatInt(memberID, 10)}}
+		} else {
+			// 找不到成员时照常创建任务，只在动态中记录
+			source["unmatched_assignee"] = params.AssigneeEmail
+		}
+	}
+
+	description := dto.Blocks{}
+	if strings.TrimSpace(params.Description) != "" {
+		description = dto.MarkdownToBlocks(params.Description)
+	}
+	if len(params.Labels) > 0 {
+		description = append(description, dto.MarkdownToBlocks("**Labels:** "+strings.Join(params.Labels, ", "))...)
+	}
+
+	responseCode, data = CreateProjectTask(ctx, &dto.ProjectTaskDTO{
+		ProjectID:   link.ProjectID,
+		ColumnID:    columnID,
+		Payload:     payload,
+		WorkspaceID: workspaceID,
+		Creator:     creator.UserID,
+		MemberID:    creator.ID,
+		Description: description,
+		Source:      source,
+	})
+	if responseCode != message.SUCCESS {
+		return responseCode, nil
+	}
+	idStr, _ := data["id"].(string) // StructToUpdateMap 按 json string 选项把 ID 格式化为字符串
+	taskID, _ := strconv.ParseInt(idStr, 10, 64)
+
+	if params.ExternalKey != "" {
+		now := time.Now()
+		if err := repo.SaveTaskLink(ctx, &model.TaskExternalLink{
+			ProjectLinkID: link.ID,
+			TaskID:        taskID,
+			IssueID:       params.ExternalKey,
+			IssueKey:      params.ExternalKey,
+			LastSyncedAt:  &now,
+		}); err != nil {
+			logger.LogError(err, "记录接收任务的外部键失败 link=", link.ID, " key=", params.ExternalKey)
+		}
+	}
+	if err := repo.UpdateProjectLink(ctx, link.ID, map[string]interface{}{
+		"last_status":    model.SyncSuccess,
+		"last_error":     nil,
+		"last_synced_at": time.Now(),
+	}); err != nil {
+		logger.LogError(err, "更新接收地址状态失败 link=", link.ID)
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"task_id":   strconv.FormatInt(taskID, 10),
+		"duplicate": false,
+	}
+}
+
+// incomingAssignee 按邮箱找到工作区成员
+func incomingAssignee(workspaceID int64, email string) (int64, bool) {
+	user, err := repository.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return 0, false
+	}
+	member, err := repository.GetWorkspaceMember(user.ID, workspaceID)
+	if err != nil || member == nil || member.ID == 0 {
+		return 0, false
+	}
+	return member.ID, true
+}
+
+func truncateTaskTitle(s string) string {
+	runes := []rune(s)
+	if len(runes) <= incomingTitleMaxRunes {
+		return s
+	}
+	return string(runes[:incomingTitleMaxRunes])
+}
@@ -10,6 +10,8 @@ import (
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
 	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/integrationService"
+	"gin-notebook/internal/service/webhookService"
 	"gin-notebook/internal/tasks/asynq/enqueue"
 	"gin-notebook/internal/tasks/asynq/types"
 	"gin-notebook/pkg/logger"
@@ -25,6 +27,9 @@ import (
 func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (responseCode int, data map[string]interface{}) {
 	var task map[string]interface{}
 	var originModel *model.ToDoTask
+	var checklistSync *checklistNoteSync
+	var movedFrom, movedTo int64
+	var addedAssignees, removedAssignees []string
 
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		if params.Payload.HasTaskFieldUpdates() {
@@ -65,7 +70,16 @@ func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 					responseCode = message.ERROR
 					return err
 				}
-				task["description"] = dto.UpdateBlock(description, *params.Payload.Actions)
+				newDescription, _, err := dto.ApplyPatch(description, *params.Payload.Actions)
+				if err != nil {
+					var patchErr *dto.PatchError
+					if errors.As(err, &patchErr) {
+						data = map[string]interface{}{"error": patchErr}
+					}
+					responseCode = message.ERROR_BLOCK_PATCH_INVALID
+					return err
+				}
+				task["description"] = newDescription
 			}
 
 			tasks, err := repository.GetProjectTaskByIDs(tx, taskIDs, repository.WithLock())
@@ -137,6 +151,20 @@ func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 					data["conflicted"] = isConflicted
 					return err
 				}
+
+				// 完成或重新打开任务时同步关联的笔记清单块
+				_, columnChanged := task["column_id"]
+				_, statusChanged := task["status"]
+				if columnChanged {
+					movedFrom, movedTo = originModel.ColumnID, taskModel.ColumnID
+				}
+				if columnChanged || statusChanged {
+					if checklistSync, err = syncTaskChecklist(ctx, tx, taskModel, params.Creator); err != nil {
+						logger.LogError(err, "同步笔记清单块失败")
+						responseCode = database.IsError(err)
+						return err
+					}
+				}
 			} else {
 				taskMap := tools.StructToUpdateMap(*originModel, nil, []string{"DeletedAt", "CreatedAt", "Creator"})
 				taskMap["priority"] = model.PriorityMap[originModel.Priority]
@@ -168,6 +196,9 @@ func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 					responseCode = database.IsError(err)
 					return err
 				}
+				for _, a := range addAssignees {
+					addedAssignees = append(addedAssignees, strconv.FormatInt(a.AssigneeID, 10))
+				}
 			}
 
 			if len(params.Payload.AssigneeActions.ActionRemove) > 0 {
@@ -184,6 +215,9 @@ func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 					responseCode = database.IsError(err)
 					return err
 				}
+				for _, id := range removeAssignees {
+					removedAssignees = append(removedAssignees, strconv.FormatInt(id, 10))
+				}
 			}
 		}
 		return nil
@@ -199,6 +233,40 @@ func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 	}
 
 	responseCode = message.SUCCESS
+	checklistSync.dispatch(ctx, params.Creator)
+	integrationService.DispatchTaskIssueSync(ctx, params.TaskID)
+	if movedTo != 0 {
+		webhookService.Dispatch(ctx, params.WorkspaceID, params.ProjectID, model.WebhookTaskMoved, map[string]interface{}{
+			"task_id":        strconv.FormatInt(params.TaskID, 10),
+			"project_id":     strconv.FormatInt(params.ProjectID, 10),
+			"from_column_id": strconv.FormatInt(movedFrom, 10),
+			"to_column_id":   strconv.FormatInt(movedTo, 10),
+			"actor_id":       strconv.FormatInt(params.Creator, 10),
+		})
+	}
+	if len(addedAssignees) > 0 || len(removedAssignees) > 0 {
+		webhookService.Dispatch(ctx, params.WorkspaceID, params.ProjectID, model.WebhookTaskAssigned, map[string]interface{}{
+			"task_id":    strconv.FormatInt(params.TaskID, 10),
+			"project_id": strconv.FormatInt(params.ProjectID, 10),
+			"added":      addedAssignees,
+			"removed":    removedAssignees,
+			"actor_id":   strconv.FormatInt(params.Creator, 10),
+		})
+	}
+	if movedTo != 0 {
+		notifyFeishu(ctx, model.FeishuNotifyMoved, types.FeishuNotifyPayload{
+			TaskID:       params.TaskID,
+			ActorID:      params.MemberID,
+			FromColumnID: movedFrom,
+		})
+	}
+	if len(addedAssignees) > 0 {
+		notifyFeishu(ctx, model.FeishuNotifyAssigned, types.FeishuNotifyPayload{
+			TaskID:    params.TaskID,
+			ActorID:   params.MemberID,
+			MemberIDs: parseMemberIDs(addedAssignees),
+		})
+	}
 
 	enqueue.KanbanActivityJob(ctx, types.KanbanActivityPayload{
 		MemberID:    params.MemberID,
@@ -219,6 +287,7 @@ func UpdateProjectTask(ctx context.Context, params *dto.ProjectTaskDTO) (respons
 }
 
 func UpdateTaskComment(params *dto.UpdateTaskCommentDTO) (responseCode int, data map[string]interface{}) {
+	var previousMentions []int64
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		err := repository.UpdateCommentByID(tx, params.CommentID, map[string]interface{}{
 			"Content": params.Content,
@@ -236,6 +305,15 @@ func UpdateTaskComment(params *dto.UpdateTaskCommentDTO) (responseCode int, data
 
 		data = tools.StructToUpdateMap(comment, nil, []string{"DeletedAt", "MemberID"})
 
+		previous, err := repository.GetCommentMentionByIDs(tx, []int64{params.CommentID})
+		if err != nil {
+			responseCode = database.IsError(err)
+			return err
+		}
+		for _, m := range previous {
+			previousMentions = append(previousMentions, m.MemberID)
+		}
+
 		if err := repository.DeleteCommentMentionByCommentID(tx, params.CommentID); err != nil {
 			responseCode = database.IsError(err)
 			return err
@@ -303,6 +381,16 @@ func UpdateTaskComment(params *dto.UpdateTaskCommentDTO) (responseCode int, data
 		return responseCode, nil
 	}
 	responseCode = message.SUCCESS
+	integrationService.DispatchTaskIssueSync(context.Background(), params.TaskID)
+
+	if mentioned := newMentionedMembers(previousMentions, params.Mentions); len(mentioned) > 0 {
+		notifyFeishu(context.Background(), model.FeishuNotifyMentioned, types.FeishuNotifyPayload{
+			TaskID:    params.TaskID,
+			ActorID:   params.MemberID,
+			MemberIDs: mentioned,
+			CommentID: params.CommentID,
+		})
+	}
 	return
 
 }
@@ -68,6 +68,7 @@ func New(rdb *redis.Client, ttl time.Duration, pub bus.WsPublisher) *Service {
 	s.once.Do(func() {
 		go s.listenProjectDirty()
 		go s.listenTaskEvents()
+		go s.listenNoteEvents()
 	})
 	return s
 }
@@ -128,8 +129,8 @@ func (s *Service) Handle(c *client, in protocol.Incoming) {
 			if !exists && protocol.IsProjectRoom(r) {
 				c.subRooms[r] = struct{}{}
 			}
-			// 任务房间（允许直接订阅）
-			if protocol.IsTaskRoom(r) {
+			// 任务房间、笔记房间（允许直接订阅）
+			if protocol.IsTaskRoom(r) || protocol.IsNoteRoom(r) {
 				c.subRooms[r] = struct{}{}
 			}
 			c.mu.Unlock()
@@ -281,6 +282,20 @@ func (s *Service) listenTaskEvents() {
 	})
 }
 
+// —— 订阅“笔记事件”（笔记评论）并转发 —— //
+func (s *Service) listenNoteEvents() {
+	ctx := context.Background()
+	go bus.SubscribeNoteEventsLoop(ctx, s.rdb, func(e bus.WsEvent) {
+		switch e.Type {
+		case bus.WsCommentAdded, bus.WsCommentUpdated, bus.WsCommentRemoved:
+			s.broadcastToRoom(protocol.NoteRoom(e.NoteID), protocol.Outgoing{
+				Type:    string(e.Type),
+				Payload: e.Payload,
+			})
+		}
+	})
+}
+
 // —— 项目脏事件订阅 & 广播（沿用原有 presence 机制；如需可迁到 bus 层统一） —— //
 func (s *Service) listenProjectDirty() {
 	ps := presence.PSubscribeProjects(s.rdb)
@@ -0,0 +1,330 @@
+package trashService
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/configs"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/thirdparty/qiniu"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+	"gin-notebook/pkg/utils/tools"
+	"sort"
+
+	"github.com/morikuni/go-lexorank"
+	"gorm.io/gorm"
+)
+
+// trashMember 返回工作区成员及其是否为管理员；非成员返回错误码
+func trashMember(userID, workspaceID int64) (*model.WorkspaceMember, bool, int) {
+	member, err := repository.GetWorkspaceMember(userID, workspaceID)
+	if err != nil || member == nil || member.ID == 0 {
+		return nil, false, message.ERROR_WORKSPACE_MEMBER_NOT_EXIST
+	}
+	roles := make([]string, 0)
+	_ = json.Unmarshal(member.Role, &roles)
+	return member, tools.Contains(roles, model.MemberRole.Admin), 0
+}
+
+func GetTrash(ctx context.Context, params *dto.TrashQueryDTO) (responseCode int, data map[string]interface{}) {
+	_, isAdmin, code := trashMember(params.UserID, params.WorkspaceID)
+	if code != 0 {
+		return code, nil
+	}
+
+	// 普通成员只能看到自己删除的内容
+	var ownerID *int64
+	if !isAdmin {
+		ownerID = &params.UserID
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	trashRepo := repository.NewTrashRepository(database.DB)
+	items := make([]dto.TrashItemDTO, 0)
+	var total int64
+
+	// 两类条目合并分页：各取前 offset+limit 条，合并排序后再截取
+	window := params.Offset + limit
+	if params.Type == "" || params.Type == dto.TrashItemNote {
+		notes, count, err := trashRepo.ListTrashedNotes(ctx, params.WorkspaceID, ownerID, window, 0)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		items = append(items, notes...)
+		total += count
+	}
+	if params.Type == "" || params.Type == dto.TrashItemTask {
+		tasks, count, err := trashRepo.ListTrashedTasks(ctx, params.WorkspaceID, ownerID, window, 0)
+		if err != nil {
+			return database.IsError(err), nil
+		}
+		items = append(items, tasks...)
+		total += count
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	if params.Offset >= len(items) {
+		items = items[:0]
+	} else {
+		items = items[params.Offset:min(len(items), window)]
+	}
+
+	retention := configs.Configs.TrashRetention()
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(retention)
+	}
+
+	return message.SUCCESS, map[string]interface{}{
+		"items":          items,
+		"total":          total,
+		"retention_days": int(retention.Hours() / 24),
+	}
+}
+
+func RestoreTrashItem(ctx context.Context, params *dto.TrashActionDTO) (responseCode int, data map[string]interface{}) {
+	_, isAdmin, code := trashMember(params.UserID, params.WorkspaceID)
+	if code != 0 {
+		return code, nil
+	}
+
+	var restored *model.Note
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		trashRepo := repository.NewTrashRepository(tx)
+
+		switch params.Type {
+		case dto.TrashItemNote:
+			note, err := trashRepo.GetTrashedNote(ctx, params.WorkspaceID, params.ID)
+			if err != nil {
+				responseCode = trashLookupError(err)
+				return err
+			}
+			restored = note
+			if !isAdmin && note.OwnerID != params.UserID {
+				responseCode = message.ERROR_NOTE_NO_PERMISSION
+				return fmt.Errorf("no permission")
+			}
+
+			categoryID, err := restoreNoteCategory(ctx, tx, params.WorkspaceID, note.CategoryID, params.UserID)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if err := trashRepo.RestoreNote(ctx, note.ID, categoryID); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			data = map[string]interface{}{
+				"id":        fmt.Sprint(note.ID),
+				"type":      params.Type,
+				"parent_id": fmt.Sprint(categoryID),
+			}
+
+		case dto.TrashItemTask:
+			task, err := trashRepo.GetTrashedTask(ctx, params.WorkspaceID, params.ID)
+			if err != nil {
+				responseCode = trashLookupError(err)
+				return err
+			}
+			if !isAdmin && task.Creator != params.UserID {
+				responseCode = message.ERROR_NOTE_NO_PERMISSION
+				return fmt.Errorf("no permission")
+			}
+
+			ok, err := trashRepo.ExistsProject(ctx, params.WorkspaceID, task.ProjectID)
+			if err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			if !ok {
+				responseCode = message.ERROR_TRASH_PARENT_GONE
+				return fmt.Errorf("project gone")
+			}
+
+			columnID, orderIndex, err := restoreTaskColumn(ctx, tx, task)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					responseCode = message.ERROR_TRASH_PARENT_GONE
+				} else {
+					responseCode = database.IsError(err)
+				}
+				return err
+			}
+			if err := trashRepo.RestoreTask(ctx, task.ID, columnID, orderIndex); err != nil {
+				responseCode = database.IsError(err)
+				return err
+			}
+			data = map[string]interface{}{
+				"id":          fmt.Sprint(task.ID),
+				"type":        params.Type,
+				"parent_id":   fmt.Sprint(columnID),
+				"order_index": orderIndex,
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return
+	}
+
+	// 删除时索引任务已下线笔记的文档，恢复后重新入库使其重新可检索
+	if restored != nil {
+		_, err := enqueue.IngestNote(ctx, types.IngestNotePayload{
+			NoteID:      restored.ID,
+			WorkspaceID: restored.WorkspaceID,
+			OwnerUserID: restored.OwnerID,
+		})
+		if err != nil {
+			logger.LogError(err, "投递笔记索引任务失败")
+		}
+	}
+
+	responseCode = message.SUCCESS
+	return
+}
+
+func PurgeTrashItem(ctx context.Context, params *dto.TrashActionDTO) (responseCode int, data map[string]interface{}) {
+	_, isAdmin, code := trashMember(params.UserID, params.WorkspaceID)
+	if code != 0 {
+		return code, nil
+	}
+
+	var files []string
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		trashRepo := repository.NewTrashRepository(tx)
+
+		var err error
+		switch params.Type {
+		case dto.TrashItemNote:
+			note, lookupErr := trashRepo.GetTrashedNote(ctx, params.WorkspaceID, params.ID)
+			if lookupErr != nil {
+				responseCode = trashLookupError(lookupErr)
+				return lookupErr
+			}
+			if !isAdmin && note.OwnerID != params.UserID {
+				responseCode = message.ERROR_NOTE_NO_PERMISSION
+				return fmt.Errorf("no permission")
+			}
+			files, err = trashRepo.PurgeNotes(ctx, []int64{note.ID})
+
+		case dto.TrashItemTask:
+			task, lookupErr := trashRepo.GetTrashedTask(ctx, params.WorkspaceID, params.ID)
+			if lookupErr != nil {
+				responseCode = trashLookupError(lookupErr)
+				return lookupErr
+			}
+			if !isAdmin && task.Creator != params.UserID {
+				responseCode = message.ERROR_NOTE_NO_PERMISSION
+				return fmt.Errorf("no permission")
+			}
+			files, err = trashRepo.PurgeTasks(ctx, []int64{task.ID})
+		}
+		if err != nil {
+			responseCode = database.IsError(err)
+		}
+		return err
+	})
+
+	if err != nil {
+		if responseCode == 0 {
+			responseCode = message.ERROR
+		}
+		return
+	}
+
+	// 对象存储的删除不可回滚，放在事务提交之后
+	if failed := qiniu.DeleteByURLs(ctx, files); failed > 0 {
+		logger.LogError(fmt.Errorf("%d files not deleted", failed), "回收站附件删除失败")
+	}
+
+	responseCode = message.SUCCESS
+	return
+}
+
+func trashLookupError(err error) int {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return message.ERROR_TRASH_ITEM_NOT_FOUND
+	}
+	return database.IsError(err)
+}
+
+// restoreNoteCategory 原分类仍在则放回原处，否则放到首个根分类；工作区没有分类时新建一个，
+// 与其他分类一样以用户 ID 作为 OwnerID
+func restoreNoteCategory(ctx context.Context, tx *gorm.DB, workspaceID, categoryID, userID int64) (int64, error) {
+	trashRepo := repository.NewTrashRepository(tx)
+	if categoryID != 0 {
+		ok, err := trashRepo.ExistsCategory(ctx, workspaceID, categoryID)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return categoryID, nil
+		}
+	}
+
+	category, err := trashRepo.GetFallbackCategory(ctx, workspaceID)
+	if err == nil {
+		return category.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	category = &model.NoteCategory{
+		WorkspaceID:  workspaceID,
+		CategoryName: "default",
+		OwnerID:      userID,
+		OrderIndex:   algorithm.RankBetweenBucket(algorithm.RankMin(), algorithm.RankMax()).String(),
+	}
+	if err := trashRepo.CreateFallbackCategory(ctx, category); err != nil {
+		return 0, err
+	}
+	return category.ID, nil
+}
+
+// restoreTaskColumn 原列仍在则保留原位置，否则放到项目首列的最上方
+func restoreTaskColumn(ctx context.Context, tx *gorm.DB, task *model.ToDoTask) (int64, string, error) {
+	trashRepo := repository.NewTrashRepository(tx)
+	ok, err := trashRepo.ExistsColumn(ctx, task.ProjectID, task.ColumnID)
+	if err != nil {
+		return 0, "", err
+	}
+	if ok {
+		return task.ColumnID, task.OrderIndex, nil
+	}
+
+	column, err := trashRepo.GetFallbackColumn(ctx, task.ProjectID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	first, err := repository.GetFirstTask(tx, column.ID, repository.WithLock())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, "", err
+	}
+
+	var orderIndex string
+	if first == nil {
+		orderIndex = algorithm.RankBetweenBucket(algorithm.RankMin(), algorithm.RankMax()).String()
+	} else {
+		orderIndex = algorithm.RankBetweenBucket(algorithm.RankMin(), lexorank.BucketKey(first.OrderIndex)).String()
+	}
+	return column.ID, orderIndex, nil
+}
@@ -0,0 +1,39 @@
+package webhookService
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dispatch 把工作区内可订阅的业务事件转成 webhook 分发任务，projectID 为 0 表示不属于项目。
+// 事件只投递给 webhook 订阅方，不经过 SSE，避免把内容推给工作区内无权查看的成员
+func Dispatch(ctx context.Context, workspaceID, projectID int64, event model.WebhookEvent, payload any) {
+	if !model.IsWebhookEvent(string(event)) {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.LogError(err, "webhook 事件序列化失败 event=", event)
+		return
+	}
+
+	// 与业务请求解耦：入队失败只记录日志
+	_, err = enqueue.WebhookDispatch(ctx, types.WebhookDispatchPayload{
+		EventID:     uuid.NewString(),
+		WorkspaceID: workspaceID,
+		ProjectID:   projectID,
+		Event:       string(event),
+		Data:        data,
+		OccurredAt:  time.Now(),
+	})
+	if err != nil {
+		logger.LogError(err, "webhook 分发任务入队失败 event=", event, " workspace=", workspaceID)
+	}
+}
@@ -0,0 +1,299 @@
+package webhookService
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/webhook"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/tasks/contracts"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/tools"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// checkAdmin 只有工作区管理员可以管理 webhook
+func checkAdmin(userID, workspaceID int64) (*model.WorkspaceMember, int) {
+	member, isAdmin := repository.IsUserAllowedToModifyWorkspace(userID, workspaceID)
+	if member == nil || !isAdmin {
+		return nil, message.ERROR_WEBHOOK_NO_PERMISSION
+	}
+	return member, message.SUCCESS
+}
+
+// getWebhook 校验 webhook 属于工作区
+func getWebhook(ctx context.Context, workspaceID, webhookID int64) (*model.WebhookSubscription, int) {
+	sub, err := repository.NewWebhookRepository(database.DB).GetSubscription(ctx, webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, message.ERROR_WEBHOOK_NOT_FOUND
+		}
+		return nil, database.IsError(err)
+	}
+	if sub.WorkspaceID != workspaceID {
+		return nil, message.ERROR_WEBHOOK_NOT_FOUND
+	}
+	return sub, message.SUCCESS
+}
+
+// validURL 创建时先拦下明显的内网地址，域名解析后的地址由投递时的拨号检查兜底
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && webhook.BlockedIP(ip) {
+		return false
+	}
+	return true
+}
+
+// parseEvents 去重并校验事件类型
+func parseEvents(raw []string) ([]model.WebhookEvent, bool) {
+	events := make([]model.WebhookEvent, 0, len(raw))
+	for _, e := range raw {
+		if !model.IsWebhookEvent(e) {
+			return nil, false
+		}
+		if !tools.Contains(events, model.WebhookEvent(e)) {
+			events = append(events, model.WebhookEvent(e))
+		}
+	}
+	return events, true
+}
+
+// parseProjects 项目必须属于该工作区
+func parseProjects(workspaceID int64, raw []string) ([]int64, int) {
+	ids, err := dto.ParseWebhookProjectIDs(raw)
+	if err != nil {
+		return nil, message.ERROR_INVALID_PARAMS
+	}
+	for _, id := range ids {
+		exists, err := repository.ProjectExistsByID(database.DB, id, workspaceID)
+		if err != nil {
+			return nil, database.IsError(err)
+		}
+		if !exists {
+			return nil, message.ERROR_PROJECT_NOT_EXIST
+		}
+	}
+	return ids, message.SUCCESS
+}
+
+// newSecret webhook 的签名密钥
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// CreateWebhook 生成签名密钥，密钥只在创建时返回一次
+func CreateWebhook(ctx context.Context, params *dto.CreateWebhookDTO) (responseCode int, data map[string]interface{}) {
+	if _, code := checkAdmin(params.UserID, params.WorkspaceID); code != message.SUCCESS {
+		return code, nil
+	}
+	if !validURL(params.URL) {
+		return message.ERROR_WEBHOOK_URL_INVALID, nil
+	}
+	events, ok := parseEvents(params.Events)
+	if !ok {
+		return message.ERROR_WEBHOOK_EVENT_INVALID, nil
+	}
+	projectIDs, code := parseProjects(params.WorkspaceID, params.ProjectIDs)
+	if code != message.SUCCESS {
+		return code, nil
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return message.ERROR_INTERNAL_SERVER, nil
+	}
+	sub := &model.WebhookSubscription{
+		WorkspaceID: params.WorkspaceID,
+		Name:        params.Name,
+		URL:         params.URL,
+		Secret:      secret,
+		Events:      tools.MustJSONBytes(events),
+		ProjectIDs:  tools.MustJSONBytes(projectIDs),
+		CreatorID:   params.MemberID,
+		IsActive:    true,
+	}
+	if err := repository.NewWebhookRepository(database.DB).CreateSubscription(ctx, sub); err != nil {
+		return database.IsError(err), nil
+	}
+
+	data = sub.Data()
+	data["secret"] = sub.Secret
+	return message.SUCCESS, map[string]interface{}{"webhook": data}
+}
+
+func GetWebhooks(ctx context.Context, params *dto.WebhookQueryDTO) (responseCode int, data map[string]interface{}) {
+	if _, code := checkAdmin(params.UserID, params.WorkspaceID); code != message.SUCCESS {
+		return code, nil
+	}
+	subs, err := repository.NewWebhookRepository(database.DB).ListSubscriptions(ctx, params.WorkspaceID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	list := make([]map[string]interface{}, 0, len(subs))
+	for i := range subs {
+		list = append(list, subs[i].Data())
+	}
+	return message.SUCCESS, map[string]interface{}{"webhooks": list, "events": model.WebhookEvents}
+}
+
+func UpdateWebhook(ctx context.Context, params *dto.UpdateWebhookDTO) (responseCode int, data map[string]interface{}) {
+	if _, code := checkAdmin(params.UserID, params.WorkspaceID); code != message.SUCCESS {
+		return code, nil
+	}
+	sub, code := getWebhook(ctx, params.WorkspaceID, params.WebhookID)
+	if code != message.SUCCESS {
+		return code, nil
+	}
+
+	update := make(map[string]interface{})
+	if params.Name != nil {
+		update["name"] = *params.Name
+	}
+	if params.URL != nil {
+		if !validURL(*params.URL) {
+			return message.ERROR_WEBHOOK_URL_INVALID, nil
+		}
+		update["url"] = *params.URL
+	}
+	if params.Events != nil {
+		events, ok := parseEvents(*params.Events)
+		if !ok {
+			return message.ERROR_WEBHOOK_EVENT_INVALID, nil
+		}
+		update["events"] = tools.MustJSONBytes(events)
+	}
+	if params.ProjectIDs != nil {
+		projectIDs, code := parseProjects(params.WorkspaceID, *params.ProjectIDs)
+		if code != message.SUCCESS {
+			return code, nil
+		}
+		update["project_ids"] = tools.MustJSONBytes(projectIDs)
+	}
+	if params.IsActive != nil {
+		update["is_active"] = *params.IsActive
+	}
+	if len(update) == 0 {
+		return message.SUCCESS, map[string]interface{}{"webhook": sub.Data()}
+	}
+
+	repo := repository.NewWebhookRepository(database.DB)
+	if err := repo.UpdateSubscription(ctx, sub.ID, update); err != nil {
+		return database.IsError(err), nil
+	}
+	sub, err := repo.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	return message.SUCCESS, map[string]interface{}{"webhook": sub.Data()}
+}
+
+func DeleteWebhook(ctx context.Context, params *dto.WebhookQueryDTO) (responseCode int) {
+	if _, code := checkAdmin(params.UserID, params.WorkspaceID); code != message.SUCCESS {
+		return code
+	}
+	sub, code := getWebhook(ctx, params.WorkspaceID, params.WebhookID)
+	if code != message.SUCCESS {
+		return code
+	}
+	if err := repository.NewWebhookRepository(database.DB).DeleteSubscription(ctx, sub.ID); err != nil {
+		return database.IsError(err)
+	}
+	return message.SUCCESS
+}
+
+// GetWebhookDeliveries 投递日志，最近的在前
+func GetWebhookDeliveries(ctx context.Context, params *dto.WebhookDeliveryQueryDTO) (responseCode int, data map[string]interface{}) {
+	if _, code := checkAdmin(params.UserID, params.WorkspaceID); code != message.SUCCESS {
+		return code, nil
+	}
+	sub, code := getWebhook(ctx, params.WorkspaceID, params.WebhookID)
+	if code != message.SUCCESS {
+		return code, nil
+	}
+	if params.Limit == 0 {
+		params.Limit = 20
+	}
+
+	deliveries, total, err := repository.NewWebhookRepository(database.DB).ListDeliveries(ctx, sub.ID, params.Limit, params.Offset)
+	if err != nil {
+		return database.IsError(err), nil
+	}
+	list := make([]map[string]interface{}, 0, len(deliveries))
+	for i := range deliveries {
+		list = append(list, deliveries[i].Data())
+	}
+	return message.SUCCESS, map[string]interface{}{"deliveries": list, "total": total}
+}
+
+// SendTestWebhook 向该 webhook 投递一次 ping 事件，不重试，停用的 webhook 也可以测试
+func SendTestWebhook(ctx context.Context, params *dto.WebhookQueryDTO) (responseCode int, data map[string]interface{}) {
+	member, code := checkAdmin(params.UserID, params.WorkspaceID)
+	if code != message.SUCCESS {
+		return code, nil
+	}
+	sub, code := getWebhook(ctx, params.WorkspaceID, params.WebhookID)
+	if code != message.SUCCESS {
+		return code, nil
+	}
+
+	eventID := uuid.NewString()
+	body, err := json.Marshal(webhook.Envelope{
+		ID:          eventID,
+		Event:       string(model.WebhookPing),
+		WorkspaceID: strconv.FormatInt(sub.WorkspaceID, 10),
+		OccurredAt:  time.Now(),
+		Data: tools.MustJSONBytes(map[string]interface{}{
+			"webhook_id": strconv.FormatInt(sub.ID, 10),
+			"actor_id":   strconv.FormatInt(member.ID, 10),
+		}),
+	})
+	if err != nil {
+		return message.ERROR_INTERNAL_SERVER, nil
+	}
+
+	deliveries := []model.WebhookDelivery{{
+		SubscriptionID: sub.ID,
+		EventID:        eventID,
+		Event:          model.WebhookPing,
+		Payload:        body,
+		Status:         model.DeliveryPending,
+	}}
+	if err := repository.NewWebhookRepository(database.DB).CreateDeliveries(ctx, deliveries); err != nil {
+		return database.IsError(err), nil
+	}
+	if _, err := enqueue.WebhookDeliver(ctx, types.WebhookDeliverPayload{DeliveryID: deliveries[0].ID}, contracts.WithMaxRetry(0)); err != nil {
+		logger.LogError(err, "投递 webhook 测试事件失败 webhook=", sub.ID)
+		return message.ERROR_INTERNAL_SERVER, nil
+	}
+	return message.SUCCESS, map[string]interface{}{"delivery": deliveries[0].Data()}
+}
@@ -0,0 +1,22 @@
+package webhookService
+
+import "testing"
+
+func TestValidURL(t *testing.T) {
+	for raw, valid := range map[string]bool{
+		"https://hooks.example.com/notebook": true,
+		"http://203.0.113.10:8080/hook":      true,
+		"ftp://hooks.example.com/":           false,
+		"https:///path":                      false,
+		"http://localhost:8080/hook":         false,
+		"http://api.localhost/hook":          false,
+		"http://127.0.0.1/hook":              false,
+		"http://[::1]/hook":                  false,
+		"http://10.0.0.5/hook":               false,
+		"http://169.254.169.254/latest":      false,
+	} {
+		if got := validURL(raw); got != valid {
+			t.Fatalf("validURL(%q) = %v, want %v", raw, got, valid)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package enqueue
+
+import (
+	"context"
+	"encoding/json"
+	asynqSingleton "gin-notebook/internal/tasks/asynq/singleton"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/tasks/contracts"
+)
+
+// FeishuNotify 发送失败时整条通知重试，已送达的消息由飞书按 uuid 去重
+func FeishuNotify(ctx context.Context, p types.FeishuNotifyPayload, opts ...contracts.Option) (string, error) {
+	defaults := []contracts.Option{
+		contracts.WithQueue("low"),
+		contracts.WithTimeout(60),
+		contracts.WithMaxRetry(3),
+	}
+	all := append(defaults, opts...)
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return asynqSingleton.Dispatcher().Enqueue(ctx, types.FeishuNotifyKey, b, all...)
+}
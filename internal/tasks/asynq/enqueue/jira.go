@@ -0,0 +1,29 @@
+package enqueue
+
+import (
+	"context"
+	"encoding/json"
+	asynqSingleton "gin-notebook/internal/tasks/asynq/singleton"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/tasks/contracts"
+)
+
+// JiraTaskSync 同一任务的同步由处理端加锁串行，锁被占用时依赖重试
+func JiraTaskSync(ctx context.Context, p types.JiraTaskSyncPayload, opts ...contracts.Option) (string, error) {
+	defaults := []contracts.Option{
+		contracts.WithQueue("default"),
+		contracts.WithTimeout(120),
+		contracts.WithMaxRetry(5),
+	}
+	all := append(defaults, opts...)
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return asynqSingleton.Dispatcher().Enqueue(ctx, types.JiraTaskSyncKey, b, all...)
+}
@@ -0,0 +1,21 @@
+package enqueue
+
+import (
+	"context"
+	asynqSingleton "gin-notebook/internal/tasks/asynq/singleton"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/tasks/contracts"
+)
+
+// SecretRotate 生成新密钥后立即触发一次重新加密，定时任务兜底
+func SecretRotate(ctx context.Context, opts ...contracts.Option) (string, error) {
+	defaults := []contracts.Option{
+		contracts.WithQueue("low"),
+		contracts.WithTimeout(1800),
+		contracts.WithMaxRetry(3),
+		contracts.WithUnique(600),
+	}
+	all := append(defaults, opts...)
+
+	return asynqSingleton.Dispatcher().Enqueue(ctx, types.SecretRotateKey, nil, all...)
+}
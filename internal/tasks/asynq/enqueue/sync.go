@@ -54,3 +54,24 @@ func SyncDelta(ctx context.Context, p types.SyncDeltaPayload, opts ...contracts.
 	return asynqSingleton.Dispatcher().Enqueue(ctx, types.SyncDeltaKey, b, all...)
 
 }
+
+// SyncPull 同一 link 在去重窗口内只保留一条拉取任务
+func SyncPull(ctx context.Context, p types.SyncPullPayload, opts ...contracts.Option) (string, error) {
+	defaults := []contracts.Option{
+		contracts.WithQueue("default"),
+		contracts.WithTimeout(300),
+		contracts.WithMaxRetry(1),
+		contracts.WithUnique(120),
+	}
+	all := append(defaults, opts...)
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return asynqSingleton.Dispatcher().Enqueue(ctx, types.SyncPullKey, b, all...)
+}
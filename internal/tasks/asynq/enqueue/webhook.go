@@ -0,0 +1,48 @@
+package enqueue
+
+import (
+	"context"
+	"encoding/json"
+	asynqSingleton "gin-notebook/internal/tasks/asynq/singleton"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/internal/tasks/contracts"
+)
+
+func WebhookDispatch(ctx context.Context, p types.WebhookDispatchPayload, opts ...contracts.Option) (string, error) {
+	defaults := []contracts.Option{
+		contracts.WithQueue("default"),
+		contracts.WithTimeout(30),
+		contracts.WithMaxRetry(3),
+	}
+	all := append(defaults, opts...)
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return asynqSingleton.Dispatcher().Enqueue(ctx, types.WebhookDispatchKey, b, all...)
+}
+
+// WebhookDeliver 对方服务不可用时按 asynq 默认退避重试，重试耗尽后投递记录标记为失败
+func WebhookDeliver(ctx context.Context, p types.WebhookDeliverPayload, opts ...contracts.Option) (string, error) {
+	defaults := []contracts.Option{
+		contracts.WithQueue("low"),
+		contracts.WithTimeout(30),
+		contracts.WithMaxRetry(8),
+	}
+	all := append(defaults, opts...)
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return asynqSingleton.Dispatcher().Enqueue(ctx, types.WebhookDeliverKey, b, all...)
+}
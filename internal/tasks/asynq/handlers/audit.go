@@ -200,6 +200,7 @@ func KanbanActivity(ctx context.Context, t *asynq.Task) error {
 			TargetID:   p.TargetID,
 
 			SummaryKey:      SummaryKey,
+			SummaryParams:   p.Patch, // 非更新类动作的 Patch 作为摘要参数，如关联的笔记
 			SummaryFallback: fmt.Sprintf("%s 了 %s", p.Action, p.TargetType),
 
 			Success:    p.Success,
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"gin-notebook/internal/model"
 	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/repository"
 	"gin-notebook/internal/tasks/asynq/types"
 	"gin-notebook/internal/thirdparty/aiServer"
 
 	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
 )
 
 func HandleEmbedChunk(ctx context.Context, t *asynq.Task) error {
@@ -22,10 +24,14 @@ func HandleEmbedChunk(ctx context.Context, t *asynq.Task) error {
 		ID   int64
 		Text string
 	}
-	if err := database.DB.Table("rag_chunks").
-		Select("id, text").
-		Where("document_id = ? AND embedding IS NULL", p.DocumentID).
-		Find(&chunks).Error; err != nil {
+	// 向量化不代表具体用户，读写 rag_chunks 需要显式绕过 RLS
+	err := repository.WithRLSBypass(ctx, database.DB, func(tx *gorm.DB) error {
+		return tx.Table("rag_chunks").
+			Select("id, text").
+			Where("document_id = ? AND embedding IS NULL", p.DocumentID).
+			Find(&chunks).Error
+	})
+	if err != nil {
 		return err
 	}
 
@@ -35,9 +41,10 @@ func HandleEmbedChunk(ctx context.Context, t *asynq.Task) error {
 		if err != nil {
 			return fmt.Errorf("embed failed for chunk %d: %w", c.ID, err)
 		}
-		if err := database.DB.Model(&model.Chunk{}).
-			Where("id = ?", c.ID).
-			Update("embedding", vec).Error; err != nil {
+		err = repository.WithRLSBypass(ctx, database.DB, func(tx *gorm.DB) error {
+			return tx.Model(&model.Chunk{}).Where("id = ?", c.ID).Update("embedding", vec).Error
+		})
+		if err != nil {
 			return err
 		}
 	}
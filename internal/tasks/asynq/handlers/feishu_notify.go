@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/configs"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/feishu"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const feishuQuoteMaxRunes = 300
+
+var feishuPriorityText = map[uint8]string{1: "低", 2: "中", 3: "高"}
+
+// 绑定到 mux.HandleFunc(types.FeishuNotifyKey, HandleFeishuNotify)
+// 按通知类型生成卡片，私信给已绑定飞书的成员，并推送到项目配置的飞书群；
+// 任一接收方发送失败时返回错误重试，已送达的消息由飞书按 uuid 去重
+func HandleFeishuNotify(ctx context.Context, t *asynq.Task) error {
+	var p types.FeishuNotifyPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+	c := feishu.GetClient()
+	if c == nil || c.Client == nil {
+		return nil
+	}
+
+	repo := repository.NewFeishuNotifyRepository(database.DB)
+	task, err := repo.GetTaskContext(ctx, p.TaskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	kind := model.FeishuNotifyKind(p.Kind)
+	if kind == model.FeishuNotifyDeadline && task.Deadline == nil {
+		return nil // 入队后截止日期已被清除
+	}
+	if len(p.MemberIDs) == 0 && (kind == model.FeishuNotifyMoved || kind == model.FeishuNotifyDeadline) {
+		// 移动与截止提醒私信给任务当前的负责人
+		if p.MemberIDs, err = repository.NewProjectSyncRepository(database.DB).GetTaskAssigneeIDs(ctx, p.TaskID); err != nil {
+			return err
+		}
+	}
+	memberIDs := make([]int64, 0, len(p.MemberIDs))
+	for _, id := range p.MemberIDs {
+		if id != p.ActorID {
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	list, err := repository.GetWorkspaceMemberByIDs(database.DB, append([]int64{p.ActorID}, p.MemberIDs...))
+	if err != nil {
+		return err
+	}
+	members := make(map[int64]dto.WorkspaceMemberDTO, len(*list))
+	for _, m := range *list {
+		members[m.ID] = m
+	}
+
+	var extra string // 移动前的列名或评论内容
+	switch kind {
+	case model.FeishuNotifyMoved:
+		extra, err = repo.GetColumnName(ctx, p.FromColumnID)
+	case model.FeishuNotifyMentioned:
+		extra, err = repo.GetCommentContent(ctx, p.CommentID)
+	}
+	if err != nil {
+		return err
+	}
+	card, err := feishuTaskCard(&p, task, members, extra)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, openID := range feishuOpenIDs(ctx, c, memberIDs, members) {
+		if err := c.SendCard(ctx, feishu.ReceiveOpenID, openID, card, feishuMessageUUID(p.EventID, openID)); err != nil {
+			logger.LogError(err, "飞书通知私信发送失败 task=", p.TaskID, " open_id=", openID)
+			failed = append(failed, openID)
+		}
+	}
+
+	chats, err := repo.GetActiveChats(ctx, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	for i := range chats {
+		chat := &chats[i]
+		if !chat.Matches(kind) {
+			continue
+		}
+		update := map[string]interface{}{"last_error": nil}
+		if err := c.SendCard(ctx, feishu.ReceiveChatID, chat.ChatID, card, feishuMessageUUID(p.EventID, chat.ChatID)); err != nil {
+			logger.LogError(err, "飞书通知群消息发送失败 task=", p.TaskID, " chat=", chat.ChatID)
+			errMsg := err.Error()
+			update["last_error"] = &errMsg
+			failed = append(failed, chat.ChatID)
+		}
+		if err := repo.UpdateChat(ctx, chat.ID, update); err != nil {
+			logger.LogError(err, "更新飞书群状态失败 chat=", chat.ID)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("feishu notify failed for %s", strings.Join(failed, ","))
+	}
+	return nil
+}
+
+// 绑定到 mux.HandleFunc(types.FeishuDeadlineScanKey, HandleFeishuDeadlineScan)
+// 找出提前量内到期且未完成的任务，提醒负责人；同一截止日期只提醒一次
+func HandleFeishuDeadlineScan(ctx context.Context, t *asynq.Task) error {
+	if c := feishu.GetClient(); c == nil || c.Client == nil {
+		return nil
+	}
+	ahead := configs.Configs.FeishuDeadlineAhead()
+	now := time.Now()
+	tasks, err := repository.NewFeishuNotifyRepository(database.DB).ListDueTasks(ctx, now, now.Add(ahead))
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.Deadline == nil {
+			continue
+		}
+		deadline := task.Deadline.Format(time.DateOnly)
+		first, err := cache.RedisInstance.MarkDeadlineNotified(ctx, task.ID, deadline, ahead+48*time.Hour)
+		if err != nil {
+			return err
+		}
+		if !first {
+			continue
+		}
+		if _, err := enqueue.FeishuNotify(ctx, types.FeishuNotifyPayload{
+			EventID: fmt.Sprintf("deadline:%d:%s", task.ID, deadline),
+			Kind:    string(model.FeishuNotifyDeadline),
+			TaskID:  task.ID,
+		}); err != nil {
+			logger.LogError(err, "截止提醒入队失败 task=", task.ID)
+		}
+	}
+	return nil
+}
+
+func feishuTaskCard(p *types.FeishuNotifyPayload, task *dto.FeishuTaskContextDTO, members map[int64]dto.WorkspaceMemberDTO, extra string) (*feishu.TaskCard, error) {
+	actor := feishuMemberName(members, p.ActorID)
+	names := make([]string, 0, len(p.MemberIDs))
+	for _, id := range p.MemberIDs {
+		names = append(names, feishuMemberName(members, id))
+	}
+	card := &feishu.TaskCard{
+		Fields: []feishu.CardField{{Name: "项目", Value: task.ProjectName}, {Name: "当前列", Value: task.ColumnName}},
+		Button: "查看任务",
+	}
+	if text, ok := feishuPriorityText[task.Priority]; ok {
+		card.Fields = append(card.Fields, feishu.CardField{Name: "优先级", Value: text})
+	}
+	if task.Deadline != nil {
+		card.Fields = append(card.Fields, feishu.CardField{Name: "截止日期", Value: task.Deadline.Format(time.DateOnly)})
+	}
+	if base := strings.TrimRight(configs.Configs.Feishu.AppURL, "/"); base != "" {
+		card.URL = fmt.Sprintf("%s/project/%d?task=%d", base, task.ProjectID, task.ID)
+	}
+
+	switch model.FeishuNotifyKind(p.Kind) {
+	case model.FeishuNotifyAssigned:
+		card.Title, card.Template = "任务分配", "blue"
+		card.Summary = fmt.Sprintf("**%s** 将任务 **%s** 分配给了 %s", actor, task.Title, strings.Join(names, "、"))
+	case model.FeishuNotifyMentioned:
+		card.Title, card.Template = "评论提及", "wathet"
+		card.Summary = fmt.Sprintf("**%s** 在任务 **%s** 的评论中提到了 %s", actor, task.Title, strings.Join(names, "、"))
+		card.Quote = truncateRunes(extra, feishuQuoteMaxRunes)
+	case model.FeishuNotifyDeadline:
+		card.Title, card.Template = "任务即将截止", "orange"
+		card.Summary = fmt.Sprintf("任务 **%s** 将于 %s 截止，请及时处理", task.Title, task.Deadline.Format(time.DateOnly))
+	case model.FeishuNotifyMoved:
+		card.Title, card.Template = "任务状态变更", "green"
+		card.Summary = fmt.Sprintf("**%s** 将任务 **%s** 从「%s」移动到「%s」", actor, task.Title, extra, task.ColumnName)
+	default:
+		return nil, fmt.Errorf("unknown feishu notify kind %q", p.Kind)
+	}
+	return card, nil
+}
+
+// feishuOpenIDs 成员绑定的飞书 open_id；早期绑定的账号未记录 open_id，用用户令牌补齐
+func feishuOpenIDs(ctx context.Context, c *feishu.Client, memberIDs []int64, members map[int64]dto.WorkspaceMemberDTO) []string {
+	userIDs := make([]int64, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		if m, ok := members[id]; ok {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+	integrationRepo := repository.NewIntegrationRepository(database.DB)
+	accounts, err := integrationRepo.GetActiveAccountsByUsers(ctx, model.ProviderFeishu, userIDs)
+	if err != nil {
+		logger.LogError(err, "读取飞书账号失败")
+		return nil
+	}
+
+	openIDs := make([]string, 0, len(accounts))
+	for i := range accounts {
+		account := &accounts[i]
+		if account.AccountID != nil && *account.AccountID != "" {
+			openIDs = append(openIDs, *account.AccountID)
+			continue
+		}
+		openID, err := c.GetUserOpenID(ctx, account.AccessTokenEnc)
+		if err != nil {
+			continue
+		}
+		if err := integrationRepo.UpdateAccountID(ctx, account.ID, openID); err != nil {
+			logger.LogError(err, "保存飞书 open_id 失败 user=", account.UserID)
+		}
+		openIDs = append(openIDs, openID)
+	}
+	return openIDs
+}
+
+func feishuMemberName(members map[int64]dto.WorkspaceMemberDTO, id int64) string {
+	m, ok := members[id]
+	if !ok {
+		return "系统"
+	}
+	if m.WorkspaceNickname != "" {
+		return m.WorkspaceNickname
+	}
+	return m.UserNickname
+}
+
+// feishuMessageUUID 飞书要求 uuid 不超过 50 个字符
+func feishuMessageUUID(eventID, receiver string) string {
+	sum := sha256.Sum256([]byte(eventID + ":" + receiver))
+	return hex(sum[:16])
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -63,11 +63,25 @@ func HandleIngestNote(ctx context.Context, t *asynq.Task) error {
 		Content     datatypes.JSON
 		Version     int64
 	}
-	if err := tx.Table("notes").Where("id = ?", p.NoteID).First(&note).Error; err != nil {
-		tx.Rollback()
+	if err := tx.Table("notes").Where("id = ? AND deleted_at IS NULL", p.NoteID).First(&note).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 已删：下线该笔记对应的文档，恢复后再次投递会重新激活
+			if err := deactivateNoteDocuments(tx, p.NoteID, 0); err != nil {
+				tx.Rollback()
+				return err
+			}
+			if err := tx.Commit().Error; err != nil {
+				return err
+			}
 			return asynq.SkipRetry
-		} // 已删
+		}
+		tx.Rollback()
+		return err
+	}
+
+	// 笔记移动到其他工作区后，下线旧工作区中的文档
+	if err := deactivateNoteDocuments(tx, note.ID, note.WorkspaceID); err != nil {
+		tx.Rollback()
 		return err
 	}
 
@@ -177,6 +191,22 @@ func HandleIngestNote(ctx context.Context, t *asynq.Task) error {
 	return err
 }
 
+// deactivateNoteDocuments 下线笔记对应的文档；exceptWorkspaceID 非 0 时保留该工作区内的文档
+func deactivateNoteDocuments(tx *gorm.DB, noteID, exceptWorkspaceID int64) error {
+	docs := func() *gorm.DB {
+		q := tx.Table("rag_documents").Where("external_id = ? AND source = ? AND is_active", fmt.Sprintf("note:%d", noteID), "local")
+		if exceptWorkspaceID != 0 {
+			q = q.Where("workspace_id <> ?", exceptWorkspaceID)
+		}
+		return q
+	}
+	if err := tx.Table("rag_chunks").Where("document_id IN (?)", docs().Select("id")).
+		Update("doc_is_active", false).Error; err != nil {
+		return err
+	}
+	return docs().Updates(map[string]any{"is_active": false, "updated_at": gorm.Expr("now()")}).Error
+}
+
 func extractInlineText(raw []dto.InlineDTO) string {
 	if len(raw) == 0 {
 		return ""
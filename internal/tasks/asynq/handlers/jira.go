@@ -0,0 +1,968 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/http/message"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/jira"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/algorithm"
+	"gin-notebook/pkg/utils/tools"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/morikuni/go-lexorank"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	jiraTitleMaxRunes   = 200  // ToDoTask.Title 的列宽
+	jiraCommentMaxRunes = 1000 // 拉取的评论超出部分截断
+)
+
+// jiraCategoryProcess 外部状态分类与本地列流程阶段的对应
+var jiraCategoryProcess = map[string]uint8{
+	jira.CategoryNew:        model.ProcessTodo,
+	jira.CategoryInProgress: model.ProcessDoing,
+	jira.CategoryDone:       model.ProcessDone,
+}
+
+// HandleJiraTaskSync 对单个任务与外部问题做逐字段三方合并：以上次同步的快照为基线，
+// 只有一侧修改的字段同步到另一侧，两侧都修改时以触发方为准；评论按内容指纹增量同步
+func HandleJiraTaskSync(ctx context.Context, t *asynq.Task) error {
+	var p types.JiraTaskSyncPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+
+	repo := repository.NewProjectSyncRepository(database.DB)
+	link, err := repo.GetProjectLink(ctx, p.ProjectLinkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !link.IsActive || (p.Remote && link.Direction == model.SyncPushOnly) || (!p.Remote && link.Direction == model.SyncPullOnly) {
+		return nil
+	}
+
+	taskLink, err := findJiraTaskLink(ctx, link.ID, p)
+	if err != nil {
+		return err
+	}
+	lockKey := fmt.Sprintf("jira:sync:%d:issue:%s", link.ID, p.IssueID)
+	if taskLink != nil {
+		lockKey = fmt.Sprintf("jira:sync:%d:task:%d", link.ID, taskLink.TaskID)
+	} else if p.TaskID != 0 {
+		lockKey = fmt.Sprintf("jira:sync:%d:task:%d", link.ID, p.TaskID)
+	}
+	unlock, err := cache.RedisInstance.Lock(ctx, lockKey, 2*time.Minute)
+	if err != nil {
+		// 同一任务正在同步，交给重试
+		return fmt.Errorf("jira sync busy: %s", lockKey)
+	}
+	defer unlock()
+
+	// 加锁后重新读取映射，避免并发的首次同步重复建任务或问题
+	if taskLink, err = findJiraTaskLink(ctx, link.ID, p); err != nil {
+		return err
+	}
+
+	s, err := newJiraTaskSync(ctx, link, p.Remote)
+	if err != nil {
+		logger.LogError(err, "[jira.sync] 集成不可用 link=", link.ID)
+		recordJiraLinkResult(ctx, link.ID, err)
+		return nil
+	}
+	err = s.run(ctx, p, taskLink)
+	recordJiraLinkResult(ctx, link.ID, err)
+	if err != nil {
+		logger.LogError(err, "[jira.sync] 同步失败 link=", link.ID)
+		if jira.IsUnauthorized(err) {
+			return nil
+		}
+	}
+	return err
+}
+
+// findJiraTaskLink 尚未建立映射时返回 nil
+func findJiraTaskLink(ctx context.Context, linkID int64, p types.JiraTaskSyncPayload) (*model.TaskExternalLink, error) {
+	var (
+		repo     = repository.NewProjectSyncRepository(database.DB)
+		taskLink *model.TaskExternalLink
+		err      error
+	)
+	if p.IssueID != "" {
+		taskLink, err = repo.GetTaskLinkByIssue(ctx, linkID, p.IssueID)
+	} else {
+		taskLink, err = repo.GetTaskLink(ctx, linkID, p.TaskID)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return taskLink, err
+}
+
+func recordJiraLinkResult(ctx context.Context, linkID int64, syncErr error) {
+	data := map[string]interface{}{
+		"last_status":    model.SyncSuccess,
+		"last_error":     nil,
+		"last_synced_at": time.Now(),
+	}
+	if syncErr != nil {
+		data["last_status"] = model.SyncFailed
+		data["last_error"] = syncErr.Error()
+	}
+	if err := repository.NewProjectSyncRepository(database.DB).UpdateProjectLink(ctx, linkID, data); err != nil {
+		logger.LogError(err, "[jira.sync] 更新链接状态失败 link=", linkID)
+	}
+}
+
+type jiraTaskSync struct {
+	link        *model.ProjectExternalLink
+	client      *jira.Client
+	userID      int64
+	workspaceID int64
+	remote      bool // webhook 触发，两侧都修改的字段以外部为准
+
+	statuses    []jira.Status // 懒加载的项目状态
+	statusIndex map[string]jira.Status
+}
+
+func newJiraTaskSync(ctx context.Context, link *model.ProjectExternalLink, remote bool) (*jiraTaskSync, error) {
+	member, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := jira.GetClientForUser(ctx, member.UserID)
+	if err != nil {
+		return nil, err
+	}
+	workspaceID, err := repository.NewProjectSyncRepository(database.DB).GetProjectWorkspaceID(ctx, link.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return &jiraTaskSync{
+		link:        link,
+		client:      client,
+		userID:      member.UserID,
+		workspaceID: workspaceID,
+		remote:      remote,
+		statusIndex: make(map[string]jira.Status),
+	}, nil
+}
+
+func (s *jiraTaskSync) canPush() bool { return s.link.Direction != model.SyncPullOnly }
+func (s *jiraTaskSync) canPull() bool { return s.link.Direction != model.SyncPushOnly }
+
+func (s *jiraTaskSync) run(ctx context.Context, p types.JiraTaskSyncPayload, taskLink *model.TaskExternalLink) error {
+	repo := repository.NewProjectSyncRepository(database.DB)
+	if p.Deleted {
+		if taskLink == nil {
+			return nil
+		}
+		return repo.DeleteTaskLink(ctx, taskLink.ID)
+	}
+
+	var (
+		task  *model.ToDoTask
+		issue *jira.Issue
+		err   error
+	)
+	switch {
+	case taskLink != nil:
+		task, err = repository.GetProjectTaskByID(database.DB, taskLink.TaskID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 本地任务已删除，外部问题保留
+			return repo.DeleteTaskLink(ctx, taskLink.ID)
+		}
+		if err != nil {
+			return err
+		}
+		issue, err = s.client.GetIssue(ctx, taskLink.IssueID)
+		if jira.IsNotFound(err) {
+			return repo.DeleteTaskLink(ctx, taskLink.ID)
+		}
+		if err != nil {
+			return err
+		}
+	case p.IssueID != "":
+		if !s.canPull() {
+			return nil
+		}
+		issue, err = s.client.GetIssue(ctx, p.IssueID)
+		if jira.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if task, taskLink, err = s.createLocalTask(ctx, issue); err != nil || task == nil {
+			return err
+		}
+	default:
+		if !s.canPush() {
+			return nil
+		}
+		task, err = repository.GetProjectTaskByID(database.DB, p.TaskID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if task.ProjectID != s.link.ProjectID {
+			return nil
+		}
+		if issue, taskLink, err = s.createIssue(ctx, task); err != nil {
+			return err
+		}
+	}
+
+	if err := s.reconcile(ctx, task, issue, taskLink); err != nil {
+		return err
+	}
+	return s.syncComments(ctx, task, issue, taskLink)
+}
+
+// createLocalTask 外部新建的问题在对应列顶部建任务，无法定位列时跳过
+func (s *jiraTaskSync) createLocalTask(ctx context.Context, issue *jira.Issue) (*model.ToDoTask, *model.TaskExternalLink, error) {
+	s.rememberStatus(issue.Fields.Status)
+	remote := jiraRemoteValues(issue)
+	column, err := s.columnForStatus(ctx, remote.Status)
+	if err != nil {
+		return nil, nil, err
+	}
+	if column == nil {
+		column, err = repository.NewChecklistRepository(database.DB).GetProcessColumn(ctx, s.link.ProjectID, model.ProcessTodo)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.LogInfo(fmt.Sprintf("[jira.sync] project=%d 没有可用的列，跳过问题 %s", s.link.ProjectID, issue.Key))
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	priority, _ := s.localPriority(remote.Priority)
+	task := &model.ToDoTask{
+		ProjectID:   s.link.ProjectID,
+		Title:       truncateJiraText(issue.Fields.Summary, jiraTitleMaxRunes),
+		ColumnID:    column.ID,
+		Creator:     s.userID,
+		Priority:    priority,
+		Status:      jiraTaskStatus(column.ProcessID),
+		Description: tools.MustJSONBytes(jira.WikiToBlocks(issue.DescriptionText())),
+	}
+	taskLink := &model.TaskExternalLink{
+		ProjectLinkID: s.link.ID,
+		IssueID:       issue.ID,
+		IssueKey:      issue.Key,
+		BaseSnapshot:  tools.MustJSONBytes(remote),
+		LastSyncedAt:  tools.Ptr(time.Now()),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		upper := algorithm.RankMax()
+		if first, err := repository.GetFirstTask(tx, column.ID, repository.WithLock()); err == nil {
+			upper = lexorank.BucketKey(first.OrderIndex)
+		}
+		task.OrderIndex = algorithm.RankBetweenBucket(algorithm.RankMin(), upper).String()
+		if err := repository.CreateProjectTask(tx, task); err != nil {
+			return err
+		}
+		txRepo := repository.NewProjectSyncRepository(tx)
+		if memberID := s.memberForAccount(remote.Assignee); memberID != 0 {
+			if err := txRepo.AddTaskAssignee(ctx, task.ID, memberID); err != nil {
+				return err
+			}
+		}
+		taskLink.TaskID = task.ID
+		return txRepo.SaveTaskLink(ctx, taskLink)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.emitActivity(ctx, model.CreateAction, task, nil)
+	return task, taskLink, nil
+}
+
+// createIssue 本地任务首次同步时新建问题，基线取外部实际保存的值
+func (s *jiraTaskSync) createIssue(ctx context.Context, task *model.ToDoTask) (*jira.Issue, *model.TaskExternalLink, error) {
+	local, err := s.localValues(ctx, task, model.TaskIssueSnapshot{})
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := map[string]any{
+		"project":   map[string]string{"key": s.link.ExternalProjectKey},
+		"issuetype": map[string]string{"name": jira.DefaultIssueType},
+		"summary":   local.Summary,
+	}
+	if local.Description != "" {
+		fields["description"] = local.Description
+	}
+	if local.Priority != "" {
+		fields["priority"] = map[string]string{"name": local.Priority}
+	}
+	if local.Assignee != "" {
+		fields["assignee"] = map[string]string{"accountId": local.Assignee}
+	}
+	created, err := s.client.CreateIssue(ctx, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	issue, err := s.client.GetIssue(ctx, created.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	taskLink := &model.TaskExternalLink{
+		ProjectLinkID: s.link.ID,
+		TaskID:        task.ID,
+		IssueID:       issue.ID,
+		IssueKey:      issue.Key,
+		BaseSnapshot:  tools.MustJSONBytes(jiraRemoteValues(issue)),
+		LastSyncedAt:  tools.Ptr(time.Now()),
+	}
+	if err := repository.NewProjectSyncRepository(database.DB).SaveTaskLink(ctx, taskLink); err != nil {
+		return nil, nil, err
+	}
+	return issue, taskLink, nil
+}
+
+// reconcile 逐字段三方合并，合并结果写回快照作为下一次的基线
+func (s *jiraTaskSync) reconcile(ctx context.Context, task *model.ToDoTask, issue *jira.Issue, taskLink *model.TaskExternalLink) error {
+	s.rememberStatus(issue.Fields.Status)
+	remote := jiraRemoteValues(issue)
+	base := remote
+	if snapshot := taskLink.Snapshot(); snapshot != nil {
+		base = *snapshot
+	}
+	local, err := s.localValues(ctx, task, base)
+	if err != nil {
+		return err
+	}
+
+	next := base
+	fields := []struct {
+		name                string
+		local, remote, base string
+		next                *string
+	}{
+		{"summary", local.Summary, remote.Summary, base.Summary, &next.Summary},
+		{"description", local.Description, remote.Description, base.Description, &next.Description},
+		{"priority", local.Priority, remote.Priority, base.Priority, &next.Priority},
+		{"assignee", local.Assignee, remote.Assignee, base.Assignee, &next.Assignee},
+		{"status", local.Status, remote.Status, base.Status, &next.Status},
+	}
+	push := make(map[string]string)
+	pull := make(map[string]string)
+	for _, f := range fields {
+		switch {
+		case f.local == f.remote:
+			*f.next = f.local
+		case f.remote == f.base || (f.local != f.base && (!s.remote || !s.canPull())):
+			if s.canPush() {
+				push[f.name] = f.local
+			}
+		default:
+			if s.canPull() {
+				pull[f.name] = f.remote
+			}
+		}
+	}
+
+	if len(push) > 0 {
+		pushed, err := s.pushFields(ctx, issue, push)
+		if err != nil {
+			return err
+		}
+		for _, name := range pushed {
+			setSnapshotField(&next, name, push[name])
+		}
+	}
+	if len(pull) > 0 {
+		pulled, err := s.pullFields(ctx, task, base, pull)
+		if err != nil {
+			return err
+		}
+		for _, name := range pulled {
+			setSnapshotField(&next, name, pull[name])
+		}
+	}
+
+	taskLink.BaseSnapshot = tools.MustJSONBytes(next)
+	taskLink.LastSyncedAt = tools.Ptr(time.Now())
+	return repository.NewProjectSyncRepository(database.DB).SaveTaskLink(ctx, taskLink)
+}
+
+// pushFields 返回已写入外部的字段；状态只能通过流转修改，找不到可用流转时保持基线
+func (s *jiraTaskSync) pushFields(ctx context.Context, issue *jira.Issue, values map[string]string) ([]string, error) {
+	pushed := make([]string, 0, len(values))
+	update := make(map[string]any)
+	for name, value := range values {
+		switch name {
+		case "summary", "description":
+			update[name] = value
+		case "priority":
+			update[name] = map[string]string{"name": value}
+		case "assignee":
+			if value == "" {
+				update[name] = nil
+			} else {
+				update[name] = map[string]string{"accountId": value}
+			}
+		default:
+			continue
+		}
+		pushed = append(pushed, name)
+	}
+	if err := s.client.UpdateIssue(ctx, issue.Key, update); err != nil {
+		return nil, err
+	}
+
+	statusID, ok := values["status"]
+	if !ok {
+		return pushed, nil
+	}
+	transitions, err := s.client.GetTransitions(ctx, issue.Key)
+	if err != nil {
+		return nil, err
+	}
+	for _, transition := range transitions {
+		if transition.To.ID == statusID {
+			if err := s.client.DoTransition(ctx, issue.Key, transition.ID); err != nil {
+				return nil, err
+			}
+			return append(pushed, "status"), nil
+		}
+	}
+	logger.LogInfo(fmt.Sprintf("[jira.sync] 问题 %s 没有流转到状态 %s 的路径，跳过", issue.Key, statusID))
+	return pushed, nil
+}
+
+// pullFields 把外部修改写回任务，返回已应用的字段；没有映射的取值不应用，基线保持不变
+func (s *jiraTaskSync) pullFields(ctx context.Context, task *model.ToDoTask, base model.TaskIssueSnapshot, values map[string]string) ([]string, error) {
+	pulled := make([]string, 0, len(values))
+	update := make(map[string]interface{})
+	var addMember, removeMember int64
+	for name, value := range values {
+		switch name {
+		case "summary":
+			update["title"] = truncateJiraText(value, jiraTitleMaxRunes)
+		case "description":
+			update["description"] = datatypes.JSON(tools.MustJSONBytes(jira.WikiToBlocks(value)))
+		case "priority":
+			priority, ok := s.localPriority(value)
+			if !ok {
+				continue
+			}
+			update["priority"] = priority
+		case "status":
+			column, err := s.columnForStatus(ctx, value)
+			if err != nil {
+				return nil, err
+			}
+			if column == nil {
+				continue
+			}
+			if column.ID != task.ColumnID {
+				update["column_id"] = column.ID
+				update["status"] = jiraTaskStatus(column.ProcessID)
+			}
+		case "assignee":
+			memberID := s.memberForAccount(value)
+			if value != "" && memberID == 0 {
+				continue
+			}
+			addMember, removeMember = memberID, s.memberForAccount(base.Assignee)
+		}
+		pulled = append(pulled, name)
+	}
+	if len(update) == 0 && addMember == 0 && removeMember == 0 {
+		return pulled, nil
+	}
+
+	origin := *task
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewProjectSyncRepository(tx)
+		if _, err := txRepo.GetTaskForUpdate(ctx, task.ID); err != nil {
+			return err
+		}
+		if len(update) > 0 {
+			if columnID, ok := update["column_id"].(int64); ok {
+				upper := algorithm.RankMax()
+				if first, err := repository.GetFirstTask(tx, columnID, repository.WithLock()); err == nil {
+					upper = lexorank.BucketKey(first.OrderIndex)
+				}
+				update["order_index"] = algorithm.RankBetweenBucket(algorithm.RankMin(), upper).String()
+			}
+			update["updated_at"] = time.Now()
+			if err := txRepo.UpdateTask(ctx, task.ID, update); err != nil {
+				return err
+			}
+		}
+		if removeMember != 0 && removeMember != addMember {
+			if err := repository.RemoveTaskAssigneesByTaskIDAndMemberIDs(tx, task.ID, []int64{removeMember}); err != nil {
+				return err
+			}
+		}
+		if addMember != 0 {
+			if err := txRepo.AddTaskAssignee(ctx, task.ID, addMember); err != nil {
+				return err
+			}
+		}
+		updated, err := repository.GetProjectTaskByID(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		*task = *updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{}, len(update))
+	for key, value := range update {
+		if key != "order_index" && key != "updated_at" {
+			patch[key] = value
+		}
+	}
+	if len(patch) > 0 {
+		s.emitActivity(ctx, model.UpdateAction, &origin, patch)
+	}
+	return pulled, nil
+}
+
+// syncComments 未映射的评论在另一侧新建，已映射的按指纹判断哪一侧修改过；删除不同步
+func (s *jiraTaskSync) syncComments(ctx context.Context, task *model.ToDoTask, issue *jira.Issue, taskLink *model.TaskExternalLink) error {
+	repo := repository.NewProjectSyncRepository(database.DB)
+	locals, err := repo.GetTaskComments(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	remotes, err := s.client.GetComments(ctx, issue.Key)
+	if err != nil {
+		return err
+	}
+	links, err := repo.GetCommentLinks(ctx, taskLink.ID)
+	if err != nil {
+		return err
+	}
+
+	byLocal := make(map[int64]*model.TaskCommentExternalLink, len(links))
+	byRemote := make(map[string]*model.TaskCommentExternalLink, len(links))
+	for i := range links {
+		byLocal[links[i].CommentID] = &links[i]
+		byRemote[links[i].ExternalCommentID] = &links[i]
+	}
+	remoteByID := make(map[string]*jira.Comment, len(remotes))
+	for i := range remotes {
+		remoteByID[remotes[i].ID] = &remotes[i]
+	}
+
+	names, err := s.memberNames(locals)
+	if err != nil {
+		return err
+	}
+
+	if s.canPush() {
+		for i := range locals {
+			local := &locals[i]
+			localHash := contentHash(local.Content)
+			body := fmt.Sprintf("%s: %s", names[local.MemberID], local.Content)
+			link, ok := byLocal[local.ID]
+			if !ok {
+				created, err := s.client.AddComment(ctx, issue.Key, body)
+				if err != nil {
+					return err
+				}
+				link = &model.TaskCommentExternalLink{
+					TaskLinkID:        taskLink.ID,
+					CommentID:         local.ID,
+					ExternalCommentID: created.ID,
+					LocalHash:         localHash,
+					RemoteHash:        contentHash(created.Body),
+				}
+				if err := repo.SaveCommentLink(ctx, link); err != nil {
+					return err
+				}
+				byRemote[created.ID] = link
+				continue
+			}
+			remote, exists := remoteByID[link.ExternalCommentID]
+			if !exists || link.LocalHash == localHash {
+				continue
+			}
+			if s.remote && s.canPull() && contentHash(remote.Body) != link.RemoteHash {
+				continue
+			}
+			updated, err := s.client.UpdateComment(ctx, issue.Key, remote.ID, body)
+			if err != nil {
+				return err
+			}
+			remote.Body = updated.Body
+			link.LocalHash, link.RemoteHash = localHash, contentHash(updated.Body)
+			if err := repo.SaveCommentLink(ctx, link); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !s.canPull() {
+		return nil
+	}
+	localByID := make(map[int64]*model.ToDoTaskComment, len(locals))
+	for i := range locals {
+		localByID[locals[i].ID] = &locals[i]
+	}
+	for i := range remotes {
+		remote := &remotes[i]
+		remoteHash := contentHash(remote.Body)
+		content := truncateJiraText(fmt.Sprintf("[Jira] %s: %s", remote.AuthorName(), remote.Body), jiraCommentMaxRunes)
+		link, ok := byRemote[remote.ID]
+		if !ok {
+			comment := model.ToDoTaskComment{ToDoTaskID: task.ID, MemberID: s.link.MemberID, Content: content}
+			if err := repository.CreateModel(database.DB, &comment); err != nil {
+				return err
+			}
+			link = &model.TaskCommentExternalLink{
+				TaskLinkID:        taskLink.ID,
+				CommentID:         comment.ID,
+				ExternalCommentID: remote.ID,
+				LocalHash:         contentHash(comment.Content),
+				RemoteHash:        remoteHash,
+			}
+			if err := repo.SaveCommentLink(ctx, link); err != nil {
+				return err
+			}
+			bus.PublishCommentAdded(ctx, task.ID, tools.StructToUpdateMap(&comment, nil, []string{"DeletedAt", "MemberID"}))
+			continue
+		}
+		local, exists := localByID[link.CommentID]
+		if !exists || link.RemoteHash == remoteHash {
+			continue
+		}
+		if !s.remote && s.canPush() && contentHash(local.Content) != link.LocalHash {
+			continue
+		}
+		if err := repository.UpdateCommentByID(database.DB, local.ID, map[string]interface{}{"Content": content}); err != nil {
+			return err
+		}
+		link.LocalHash, link.RemoteHash = contentHash(content), remoteHash
+		if err := repo.SaveCommentLink(ctx, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localValues 把任务换算成外部取值；无法在外部表达的字段取基线值，视为未修改
+func (s *jiraTaskSync) localValues(ctx context.Context, task *model.ToDoTask, base model.TaskIssueSnapshot) (model.TaskIssueSnapshot, error) {
+	var blocks dto.Blocks
+	if len(task.Description) > 0 {
+		if err := json.Unmarshal(task.Description, &blocks); err != nil {
+			return base, fmt.Errorf("unmarshal task description: %w", err)
+		}
+	}
+	values := model.TaskIssueSnapshot{
+		Summary:     task.Title,
+		Description: jira.NormalizeWiki(jira.BlocksToWiki(blocks)),
+		Priority:    base.Priority,
+		Assignee:    base.Assignee,
+		Status:      base.Status,
+	}
+
+	if priority, ok := s.localPriority(base.Priority); !ok || priority != task.Priority {
+		if name := s.link.PriorityMappings()[model.PriorityMap[task.Priority]]; name != "" {
+			values.Priority = name
+		}
+	}
+
+	assignees, err := repository.NewProjectSyncRepository(database.DB).GetTaskAssigneeIDs(ctx, task.ID)
+	if err != nil {
+		return base, err
+	}
+	baseMember := s.memberForAccount(base.Assignee)
+	switch {
+	case len(assignees) == 0:
+		// 外部负责人没有对应成员时本地本就无法表达，不视为清空
+		if baseMember != 0 {
+			values.Assignee = ""
+		}
+	case baseMember != 0 && containsInt64(assignees, baseMember):
+	default:
+		for _, memberID := range assignees {
+			if account := s.accountForMember(memberID); account != "" {
+				values.Assignee = account
+				break
+			}
+		}
+	}
+
+	column, err := s.columnForStatus(ctx, base.Status)
+	if err != nil {
+		return base, err
+	}
+	if column == nil || column.ID != task.ColumnID {
+		status, err := s.statusForColumn(ctx, task.ColumnID)
+		if err != nil {
+			return base, err
+		}
+		if status != "" {
+			values.Status = status
+		}
+	}
+	return values, nil
+}
+
+func jiraRemoteValues(issue *jira.Issue) model.TaskIssueSnapshot {
+	return model.TaskIssueSnapshot{
+		Summary:     issue.Fields.Summary,
+		Description: jira.NormalizeWiki(issue.DescriptionText()),
+		Priority:    issue.PriorityName(),
+		Assignee:    issue.AssigneeAccountID(),
+		Status:      issue.StatusID(),
+	}
+}
+
+func setSnapshotField(snapshot *model.TaskIssueSnapshot, name, value string) {
+	switch name {
+	case "summary":
+		snapshot.Summary = value
+	case "description":
+		snapshot.Description = value
+	case "priority":
+		snapshot.Priority = value
+	case "assignee":
+		snapshot.Assignee = value
+	case "status":
+		snapshot.Status = value
+	}
+}
+
+// localPriority 外部优先级名称对应的本地优先级
+func (s *jiraTaskSync) localPriority(name string) (uint8, bool) {
+	if name == "" {
+		return 0, false
+	}
+	for local, remote := range s.link.PriorityMappings() {
+		if strings.EqualFold(remote, name) {
+			priority, ok := model.StringToPriority[local]
+			return priority, ok
+		}
+	}
+	return 0, false
+}
+
+func (s *jiraTaskSync) memberForAccount(accountID string) int64 {
+	if accountID == "" {
+		return 0
+	}
+	for _, m := range s.link.AssigneeMappings() {
+		if m.AccountID == accountID {
+			return m.MemberID
+		}
+	}
+	return 0
+}
+
+func (s *jiraTaskSync) accountForMember(memberID int64) string {
+	for _, m := range s.link.AssigneeMappings() {
+		if m.MemberID == memberID {
+			return m.AccountID
+		}
+	}
+	return ""
+}
+
+// columnForStatus 优先按映射定位列，未映射时按状态分类取对应流程阶段的第一列；无法定位时返回 nil
+func (s *jiraTaskSync) columnForStatus(ctx context.Context, statusID string) (*model.ToDoColumn, error) {
+	if statusID == "" {
+		return nil, nil
+	}
+	repo := repository.NewChecklistRepository(database.DB)
+	for _, m := range s.link.StatusMappings() {
+		if m.StatusID == statusID {
+			column, err := repo.GetColumn(ctx, s.link.ProjectID, m.ColumnID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return column, err
+		}
+	}
+	if err := s.loadStatuses(ctx); err != nil {
+		return nil, err
+	}
+	status, ok := s.statusIndex[statusID]
+	if !ok {
+		return nil, nil
+	}
+	process, ok := jiraCategoryProcess[status.CategoryKey()]
+	if !ok {
+		return nil, nil
+	}
+	column, err := repo.GetProcessColumn(ctx, s.link.ProjectID, process)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return column, err
+}
+
+// statusForColumn 列对应的外部状态，未映射时取与列流程阶段同分类的第一个状态
+func (s *jiraTaskSync) statusForColumn(ctx context.Context, columnID int64) (string, error) {
+	for _, m := range s.link.StatusMappings() {
+		if m.ColumnID == columnID {
+			return m.StatusID, nil
+		}
+	}
+	column, err := repository.NewChecklistRepository(database.DB).GetColumn(ctx, s.link.ProjectID, columnID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := s.loadStatuses(ctx); err != nil {
+		return "", err
+	}
+	for _, status := range s.statuses {
+		if process, ok := jiraCategoryProcess[status.CategoryKey()]; ok && process == column.ProcessID {
+			return status.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// loadStatuses 取默认问题类型的工作流状态，没有该类型时合并所有类型
+func (s *jiraTaskSync) loadStatuses(ctx context.Context) error {
+	if s.statuses != nil {
+		return nil
+	}
+	issueTypes, err := s.client.GetProjectStatuses(ctx, s.link.ExternalProjectKey)
+	if err != nil {
+		return err
+	}
+	statuses := make([]jira.Status, 0)
+	for _, t := range issueTypes {
+		if t.Name == jira.DefaultIssueType {
+			statuses = t.Statuses
+			break
+		}
+	}
+	if len(statuses) == 0 {
+		for _, t := range issueTypes {
+			statuses = append(statuses, t.Statuses...)
+		}
+	}
+	s.statuses = statuses
+	for i := range statuses {
+		s.rememberStatus(&statuses[i])
+	}
+	return nil
+}
+
+func (s *jiraTaskSync) rememberStatus(status *jira.Status) {
+	if status == nil || status.ID == "" {
+		return
+	}
+	if _, ok := s.statusIndex[status.ID]; !ok || status.StatusCategory != nil {
+		s.statusIndex[status.ID] = *status
+	}
+}
+
+// memberNames 推送评论时标注本地作者
+func (s *jiraTaskSync) memberNames(comments []model.ToDoTaskComment) (map[int64]string, error) {
+	ids := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.MemberID)
+	}
+	names := make(map[int64]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	members, err := repository.GetWorkspaceMemberByIDs(database.DB, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range *members {
+		switch {
+		case member.WorkspaceNickname != "":
+			names[member.ID] = member.WorkspaceNickname
+		case member.UserNickname != "":
+			names[member.ID] = member.UserNickname
+		default:
+			names[member.ID] = member.Email
+		}
+	}
+	return names, nil
+}
+
+func (s *jiraTaskSync) emitActivity(ctx context.Context, action model.KanbanAction, task *model.ToDoTask, patch map[string]interface{}) {
+	success := true
+	code := message.SUCCESS
+	enqueue.KanbanActivityJob(ctx, types.KanbanActivityPayload{
+		MemberID:    s.link.MemberID,
+		ActorID:     s.userID,
+		Success:     &success,
+		SuccessCode: &code,
+		ProjectID:   &task.ProjectID,
+		TaskID:      &task.ID,
+		ColumnID:    &task.ColumnID,
+		WorkspaceID: s.workspaceID,
+		OriginData:  *task,
+		Patch:       patch,
+		Action:      action,
+		TargetType:  model.TargetTask,
+		TargetID:    task.ID,
+	})
+}
+
+// jiraTaskStatus 列的流程阶段对应的任务状态
+func jiraTaskStatus(process uint8) string {
+	switch process {
+	case model.ProcessDone:
+		return model.TaskStatusCompleted
+	case model.ProcessDoing:
+		return model.TaskStatusInProgress
+	default:
+		return model.TaskStatusPending
+	}
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex(sum[:])
+}
+
+func truncateJiraText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+func containsInt64(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
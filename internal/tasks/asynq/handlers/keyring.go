@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"gin-notebook/configs"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/keyring"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+
+	"github.com/hibiken/asynq"
+)
+
+// 绑定到 mux.HandleFunc(types.SecretRotateKey, HandleSecretRotate)
+// 分批把旧密钥加密或尚未加密的集成密钥、令牌及 webhook 签名密钥改用主用密钥加密。
+// 每条记录按读取时的密钥 ID 条件更新，与业务写入并发时以业务写入为准
+func HandleSecretRotate(ctx context.Context, t *asynq.Task) error {
+	ring := keyring.Default()
+	if ring == nil {
+		return nil
+	}
+
+	unlock, err := cache.RedisInstance.Lock(ctx, "secret:rotate", 30*time.Minute)
+	if err != nil {
+		// 已有轮换在执行
+		return nil
+	}
+	defer unlock()
+
+	if err := ring.Reload(ctx); err != nil {
+		return err
+	}
+	primary := ring.PrimaryKID()
+	if primary == "" {
+		return keyring.ErrNoPrimaryKey
+	}
+
+	r := &secretRotation{primary: primary, batch: configs.Configs.SecretRotateBatch()}
+	for _, step := range []func(context.Context) error{r.rotateApps, r.rotateAccounts, r.rotateLinks, r.rotateWebhooks} {
+		if err := step(ctx); err != nil {
+			return err
+		}
+	}
+
+	if r.rotated > 0 || r.skipped > 0 {
+		logger.LogInfo("integration secrets rotated", map[string]interface{}{
+			"kid":     primary,
+			"rotated": r.rotated,
+			"skipped": r.skipped,
+		})
+	}
+	return nil
+}
+
+type secretRotation struct {
+	primary string
+	batch   int
+	rotated int
+	skipped int // 解密失败或期间被改写的记录
+}
+
+// done 统计单条记录的结果；解密失败只记录日志，不中断整轮轮换
+func (r *secretRotation) done(updated bool, err error, table string, id int64) {
+	switch {
+	case err != nil:
+		r.skipped++
+		logger.LogError(err, "重新加密失败 table=", table, " id=", id)
+	case updated:
+		r.rotated++
+	default:
+		r.skipped++
+	}
+}
+
+func (r *secretRotation) rotateApps(ctx context.Context) error {
+	repo := repository.NewEncryptionKeyRepository(database.DB)
+	var afterID int64
+	for {
+		apps, err := repo.GetAppsToRotate(ctx, r.primary, afterID, r.batch)
+		if err != nil {
+			return err
+		}
+		for _, app := range apps {
+			updated, err := r.rotateApp(ctx, app)
+			r.done(updated, err, "integration_apps", app.ID)
+		}
+		if len(apps) < r.batch {
+			return nil
+		}
+		afterID = apps[len(apps)-1].ID
+	}
+}
+
+func (r *secretRotation) rotateApp(ctx context.Context, app model.IntegrationApp) (bool, error) {
+	rec := app
+	if err := repository.OpenIntegrationApp(ctx, &rec); err != nil {
+		return false, err
+	}
+	if err := repository.SealIntegrationApp(ctx, &rec); err != nil {
+		return false, err
+	}
+	return repository.NewEncryptionKeyRepository(database.DB).RotateRecord(ctx, &model.IntegrationApp{}, app.ID, "kid", app.KID, map[string]interface{}{
+		"app_secret_enc":         rec.AppSecretEnc,
+		"sign_secret_enc":        rec.SignSecretEnc,
+		"verification_token_enc": rec.VerificationTokenEnc,
+		"kid":                    rec.KID,
+		"last_rotated_at":        rec.LastRotatedAt,
+	})
+}
+
+func (r *secretRotation) rotateAccounts(ctx context.Context) error {
+	repo := repository.NewEncryptionKeyRepository(database.DB)
+	var afterID int64
+	for {
+		accounts, err := repo.GetAccountsToRotate(ctx, r.primary, afterID, r.batch)
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			updated, err := r.rotateAccount(ctx, account)
+			r.done(updated, err, "integration_accounts", account.ID)
+		}
+		if len(accounts) < r.batch {
+			return nil
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
+
+func (r *secretRotation) rotateAccount(ctx context.Context, account model.IntegrationAccount) (bool, error) {
+	rec := account
+	if err := repository.OpenIntegrationAccount(ctx, &rec); err != nil {
+		return false, err
+	}
+	if err := repository.SealIntegrationAccount(ctx, &rec); err != nil {
+		return false, err
+	}
+	return repository.NewEncryptionKeyRepository(database.DB).RotateRecord(ctx, &model.IntegrationAccount{}, account.ID, "kid", account.KID, map[string]interface{}{
+		"access_token_enc":  rec.AccessTokenEnc,
+		"refresh_token_enc": rec.RefreshTokenEnc,
+		"kid":               rec.KID,
+	})
+}
+
+func (r *secretRotation) rotateLinks(ctx context.Context) error {
+	repo := repository.NewEncryptionKeyRepository(database.DB)
+	var afterID int64
+	for {
+		links, err := repo.GetLinksToRotate(ctx, r.primary, afterID, r.batch)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			updated, err := r.rotateLink(ctx, link)
+			r.done(updated, err, "project_external_links", link.ID)
+		}
+		if len(links) < r.batch {
+			return nil
+		}
+		afterID = links[len(links)-1].ID
+	}
+}
+
+func (r *secretRotation) rotateLink(ctx context.Context, link model.ProjectExternalLink) (bool, error) {
+	rec := link
+	if err := repository.OpenProjectLink(ctx, &rec); err != nil {
+		return false, err
+	}
+	if err := repository.SealProjectLink(ctx, &rec); err != nil {
+		return false, err
+	}
+	return repository.NewEncryptionKeyRepository(database.DB).RotateRecord(ctx, &model.ProjectExternalLink{}, link.ID, "secret_kid", link.SecretKID, map[string]interface{}{
+		"webhook_secret": rec.WebhookSecret,
+		"secret_kid":     rec.SecretKID,
+	})
+}
+
+func (r *secretRotation) rotateWebhooks(ctx context.Context) error {
+	repo := repository.NewEncryptionKeyRepository(database.DB)
+	var afterID int64
+	for {
+		subs, err := repo.GetWebhooksToRotate(ctx, r.primary, afterID, r.batch)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			updated, err := r.rotateWebhook(ctx, sub)
+			r.done(updated, err, "webhook_subscriptions", sub.ID)
+		}
+		if len(subs) < r.batch {
+			return nil
+		}
+		afterID = subs[len(subs)-1].ID
+	}
+}
+
+func (r *secretRotation) rotateWebhook(ctx context.Context, sub model.WebhookSubscription) (bool, error) {
+	rec := sub
+	if err := repository.OpenWebhookSubscription(ctx, &rec); err != nil {
+		return false, err
+	}
+	if err := repository.SealWebhookSubscription(ctx, &rec); err != nil {
+		return false, err
+	}
+	return repository.NewEncryptionKeyRepository(database.DB).RotateRecord(ctx, &model.WebhookSubscription{}, sub.ID, "secret_kid", sub.SecretKID, map[string]interface{}{
+		"secret":     rec.Secret,
+		"secret_kid": rec.SecretKID,
+	})
+}
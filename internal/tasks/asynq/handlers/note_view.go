@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/repository"
+	"gin-notebook/pkg/logger"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	noteViewFlushBatch      = 500
+	noteViewFlushMaxBatches = 20 // 单次任务最多处理的批数，剩余的留给下一轮
+)
+
+// 绑定到 mux.HandleFunc(types.TypeNoteViewFlush, HandleNoteViewFlush)
+// 把浏览事件按 (笔记, 成员) 与 (笔记, 日期) 聚合后写入；写库失败时事件放回队列
+func HandleNoteViewFlush(ctx context.Context, t *asynq.Task) error {
+	if cache.RedisInstance == nil {
+		return nil
+	}
+
+	for i := 0; i < noteViewFlushMaxBatches; i++ {
+		events, err := cache.RedisInstance.PopNoteViews(ctx, noteViewFlushBatch)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := flushNoteViews(ctx, events); err != nil {
+			if pushErr := cache.RedisInstance.PushNoteViews(ctx, events...); pushErr != nil {
+				logger.LogError(pushErr, "浏览事件放回队列失败")
+			}
+			return err
+		}
+		if len(events) < noteViewFlushBatch {
+			return nil
+		}
+	}
+	return nil
+}
+
+func flushNoteViews(ctx context.Context, events []string) error {
+	type memberKey struct{ noteID, memberID int64 }
+	type dayKey struct {
+		noteID int64
+		date   string
+	}
+	stats := make(map[memberKey]*model.NoteViewStat)
+	daily := make(map[dayKey]*model.NoteViewDaily)
+
+	for _, raw := range events {
+		var event dto.NoteViewEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			logger.LogError(err, "浏览事件格式错误，已丢弃")
+			continue
+		}
+
+		mk := memberKey{event.NoteID, event.MemberID}
+		if stat, ok := stats[mk]; ok {
+			stat.ViewCount++
+			if event.ViewedAt.Before(stat.FirstViewedAt) {
+				stat.FirstViewedAt = event.ViewedAt
+			}
+			if event.ViewedAt.After(stat.LastViewedAt) {
+				stat.LastViewedAt = event.ViewedAt
+			}
+		} else {
+			stats[mk] = &model.NoteViewStat{
+				WorkspaceID:   event.WorkspaceID,
+				NoteID:        event.NoteID,
+				MemberID:      event.MemberID,
+				ViewCount:     1,
+				FirstViewedAt: event.ViewedAt,
+				LastViewedAt:  event.ViewedAt,
+			}
+		}
+
+		dk := dayKey{event.NoteID, event.ViewedAt.UTC().Format(dto.NoteViewDateLayout)}
+		if day, ok := daily[dk]; ok {
+			day.ViewCount++
+		} else {
+			daily[dk] = &model.NoteViewDaily{
+				WorkspaceID: event.WorkspaceID,
+				NoteID:      event.NoteID,
+				Date:        dk.date,
+				ViewCount:   1,
+			}
+		}
+	}
+
+	statRows := make([]model.NoteViewStat, 0, len(stats))
+	for _, stat := range stats {
+		statRows = append(statRows, *stat)
+	}
+	dailyRows := make([]model.NoteViewDaily, 0, len(daily))
+	for _, day := range daily {
+		dailyRows = append(dailyRows, *day)
+	}
+
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		viewRepo := repository.NewNoteViewRepository(tx)
+		if err := viewRepo.UpsertStats(ctx, statRows); err != nil {
+			return err
+		}
+		return viewRepo.UpsertDaily(ctx, dailyRows)
+	})
+}
@@ -11,27 +11,19 @@ import (
 	"gin-notebook/internal/pkg/cache"
 	"gin-notebook/internal/pkg/database"
 	"gin-notebook/internal/pkg/dto"
-	"gin-notebook/internal/pkg/integration/feishu"
+	"gin-notebook/internal/pkg/integration/syncer"
 	"gin-notebook/internal/repository"
 	"gin-notebook/internal/tasks/asynq/types"
 	"gin-notebook/pkg/logger"
 	"gin-notebook/pkg/utils/tools"
-	"net/http"
 	"sort"
 	"time"
 
-	larkdocx "github.com/larksuite/oapi-sdk-go/v3/service/docx/v1"
-
 	"github.com/hibiken/asynq"
-
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
-type SyncDeps struct {
-	DB           *gorm.DB
-	FeishuClient *feishu.Client
-}
-
 func HandleInitSyncNote(ctx context.Context, t *asynq.Task) error {
 	var p types.SyncNotePayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
@@ -42,11 +34,6 @@ func HandleInitSyncNote(ctx context.Context, t *asynq.Task) error {
 		return initFail(ctx, p.LinkID, msg, cause)
 	}
 
-	client := feishu.GetClient()
-	if client == nil {
-		return fail(fmt.Errorf("Feishu integration not configured"), "Feishu integration not configured")
-	}
-
 	defer func() {
 		logger.LogInfo("HandleInitSyncNote completed", map[string]interface{}{"note_id": p.NoteID, "link_id": p.LinkID})
 	}()
@@ -69,6 +56,11 @@ func HandleInitSyncNote(ctx context.Context, t *asynq.Task) error {
 		return nil
 	}
 
+	session, err := openSyncSession(ctx, link.Provider, p.UserID)
+	if err != nil {
+		return fail(err, fmt.Sprintf("%s integration unavailable", link.Provider))
+	}
+
 	// 置为 running（CAS）
 	if err := repository.UpdateNoteSync(
 		ctx, database.DB,
@@ -106,76 +98,47 @@ func HandleInitSyncNote(ctx context.Context, t *asynq.Task) error {
 		return err // 上面已用 fail，直接把错误抛出即可
 	}
 
-	integrationRepo := repository.NewIntegrationRepository(database.DB)
-	account, err := integrationRepo.GetIntegrationAccountByUser(ctx, tools.Ptr(model.ProviderFeishu), &p.UserID)
-	if err != nil {
-		return fail(err, "Failed to get integration account")
-	}
-
-	remoteBlocks, err := client.GetNoteAllBlocks(ctx, account.AccessTokenEnc, targetID, nil)
+	doc, err := session.provider.FetchBlocks(ctx, session.token, targetID)
 	if err != nil {
-		return fail(err, "Failed to get remote blocks from Feishu")
-	}
-
-	var feishuPageUID string
-	for _, block := range remoteBlocks.Items {
-		if block.BlockType != nil && *block.BlockType == 1 {
-			feishuPageUID = *block.BlockId
-			break
-		}
-	}
-	if feishuPageUID == "" {
-		return fail(fmt.Errorf("page block not found"), "Feishu page block not found")
+		return fail(err, "Failed to get remote blocks")
 	}
 
-	// 清空旧子块（你当前是范围删除；若以后换成倒序单元素范围删除，这里同样包 fail）
-	if len(remoteBlocks.Items) > 1 {
-		if err := client.DeleteBlocks(ctx, targetID, account.AccessTokenEnc, feishuPageUID, 0, len(remoteBlocks.Items)-1); err != nil {
-			return fail(err, "Failed to delete old Feishu blocks")
+	// 只拉取的链接不改动外部文档，外部块在首次拉取时作为新块导入
+	var created []syncer.BlockRef
+	if link.Direction != model.SyncPullOnly {
+		// 清空旧的顶层块后整篇重建
+		if err := session.provider.DeleteBlocks(ctx, session.token, targetID, doc.RootID, 0, len(doc.Blocks)); err != nil {
+			return fail(err, "Failed to delete old remote blocks")
 		}
-	}
-	// 创建新块（建议带幂等键；若 SDK 支持，添加 feishu.WithIdempotencyKey(...)）
-	feishuBlocks := feishu.ParseBlockToLark(blocks)
-	chunks := tools.Chunk(feishuBlocks, 20)
-	reqID := fmt.Sprintf("sync:init:%d:%d", p.LinkID, baseVersion)
-
-	children := []*larkdocx.Block{}
-	for _, chunk := range chunks {
-		data, err := client.CreateBlocks(ctx, account.AccessTokenEnc, feishuPageUID, feishuPageUID, chunk, -1, &http.Header{
-			"Idempotency-Key": []string{reqID},
-		})
+		reqID := fmt.Sprintf("sync:init:%d:%d", p.LinkID, baseVersion)
+		created, err = session.provider.CreateBlocks(ctx, session.token, targetID, doc.RootID, -1, blocks, reqID)
 		if err != nil {
-			return fail(err, "Failed to create Feishu blocks")
+			return fail(err, "Failed to create remote blocks")
 		}
-		children = append(children, data.Children...)
 	}
 
 	// 写映射
-	if err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		syncRepo := repository.NewSyncRepository(tx)
-		ts := time.Now().UTC()
-		mappings := make([]model.NoteExternalNodeMapping, 0, len(children))
-		for i := range children {
-			if i >= len(blocks) {
-				break
-			}
-			mappings = append(mappings, model.NoteExternalNodeMapping{
-				NoteID:           p.NoteID,
-				Provider:         model.ProviderFeishu,
-				NodeUID:          blocks[i].ID,
-				ExternalDocID:    targetID,
-				ExternalBlockID:  *children[i].BlockId,
-				ExternalParentID: *children[i].ParentId,
-				SyncStatus:       model.SyncSuccess,
-				LastSyncedAt:     ts,
-			})
-		}
-		if err := syncRepo.UpsertNoteExternalNodeMappings(ctx, &mappings); err != nil {
-			return fail(err, "Upsert node mappings failed")
+	ts := time.Now().UTC()
+	mappings := make([]model.NoteExternalNodeMapping, 0, len(created))
+	for i := range created {
+		if i >= len(blocks) {
+			break
 		}
-		return nil
-	}); err != nil {
-		return err // 上面已 fail
+		mappings = append(mappings, model.NoteExternalNodeMapping{
+			NoteID:           p.NoteID,
+			Provider:         link.Provider,
+			NodeUID:          blocks[i].ID,
+			ExternalDocID:    targetID,
+			ExternalBlockID:  created[i].ID,
+			ExternalParentID: created[i].ParentID,
+			SyncStatus:       model.SyncSuccess,
+			LastSyncedAt:     ts,
+			ETag:             created[i].ETag,
+			BaseBlock:        baseBlockJSON(blocks[i]),
+		})
+	}
+	if err := repository.NewSyncRepository(database.DB).UpsertNoteExternalNodeMappings(ctx, &mappings); err != nil {
+		return fail(err, "Upsert node mappings failed")
 	}
 
 	// 标记 ready + success + 基线推进
@@ -190,6 +153,7 @@ func HandleInitSyncNote(ctx context.Context, t *asynq.Task) error {
 			"content_version": baseVersion,
 			"last_synced_at":  now,
 			"last_error":      nil,
+			"fail_streak":     0,
 			"updated_at":      now,
 			"target_note_id":  targetID,
 		},
@@ -197,44 +161,93 @@ func HandleInitSyncNote(ctx context.Context, t *asynq.Task) error {
 		return fail(err, "Update link to ready failed")
 	}
 
+	// 订阅失败不影响初始化，拉取仍可依靠定时轮询
+	if subscriber, ok := session.provider.(syncer.EventSubscriber); ok && link.Direction != model.SyncPushOnly {
+		if err := subscriber.Subscribe(ctx, session.token, targetID); err != nil {
+			logger.LogError(err, "[sync.init] 订阅外部文档事件失败 link=", link.ID)
+		}
+	}
+
 	// 门闩
 	_ = cache.RedisInstance.Set(ctx, fmt.Sprintf("sync:ready:%d", link.ID), "1", 0)
 	return nil
 }
 
+// syncSession 一次同步任务使用的外部平台与访问令牌
+type syncSession struct {
+	provider syncer.SyncProvider
+	token    string
+}
+
+func openSyncSession(ctx context.Context, name model.IntegrationProvider, userID int64) (*syncSession, error) {
+	provider, err := syncer.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	account, err := repository.NewIntegrationRepository(database.DB).
+		GetIntegrationAccountByUser(ctx, &name, &userID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.ID == 0 || !account.IsActive {
+		return nil, fmt.Errorf("integration account not bound: provider=%s user=%d", name, userID)
+	}
+	if err := refreshAccountToken(ctx, provider, account, time.Minute); err != nil {
+		return nil, err
+	}
+	return &syncSession{provider: provider, token: account.AccessTokenEnc}, nil
+}
+
+// refreshAccountToken 访问令牌在 within 内过期时通过 provider 刷新并写回账户；没有过期时间的令牌视为长期有效
+func refreshAccountToken(ctx context.Context, provider syncer.SyncProvider, account *model.IntegrationAccount, within time.Duration) error {
+	if account.AccessTokenExpiry == nil || time.Until(*account.AccessTokenExpiry) > within {
+		return nil
+	}
+	if account.RefreshTokenEnc == nil || *account.RefreshTokenEnc == "" ||
+		(account.RefreshTokenExpiry != nil && account.RefreshTokenExpiry.Before(time.Now())) {
+		return fmt.Errorf("integration account expired: provider=%s user=%d", account.Provider, account.UserID)
+	}
+
+	token, err := provider.RefreshToken(ctx, *account.RefreshTokenEnc)
+	if err != nil {
+		return err
+	}
+	account.AccessTokenEnc = token.AccessToken
+	account.RefreshTokenEnc = &token.RefreshToken
+	account.AccessTokenExpiry = &token.AccessExpiry
+	account.RefreshTokenExpiry = &token.RefreshExpiry
+	return repository.NewIntegrationRepository(database.DB).BindIntegrationAccount(ctx, account)
+}
+
 func initFail(ctx context.Context, linkID int64, reason string, cause error) error {
 	_ = repository.UpdateNoteSync(ctx, database.DB,
 		"id = ? AND init_status IN ?",
 		[]interface{}{linkID, []string{string(model.InitPending), string(model.InitRunning)}},
 		map[string]interface{}{
-			"init_status": model.InitFailed,
-			"last_status": model.SyncFailed,
-			"last_error":  reason,
-			"updated_at":  time.Now(),
+			"init_status":   model.InitFailed,
+			"last_status":   model.SyncFailed,
+			"last_error":    reason,
+			"last_error_at": time.Now(),
+			"error_count":   gorm.Expr("error_count + 1"),
+			"fail_streak":   gorm.Expr("fail_streak + 1"),
+			"updated_at":    time.Now(),
 		},
 	)
 	cache.RedisInstance.Del(ctx, fmt.Sprintf("sync:ready:%d", linkID))
 	return cause
 }
 
-func prefixEquals(full, prefix []*larkdocx.Block) bool {
-	if len(full) < len(prefix) {
-		return false
-	}
-	for i := range prefix {
-		if full[i].BlockId != prefix[i].BlockId {
-			return false
-		}
-	}
-	return true
-}
-
 func HandleSyncDelta(ctx context.Context, t *asynq.Task) error {
 	var p types.SyncDeltaPayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		logger.LogError(err, "[sync.delta] 解析 payload 失败")
 		return err
 	}
+	// 改名前以 "note:sync" 入队的索引任务没有 link_id
+	if p.LinkID == 0 {
+		logger.LogInfo(fmt.Sprintf("[sync.delta] note=%d 旧版索引任务，转交 ingest", p.NoteID))
+		return HandleIngestNote(ctx, t)
+	}
 	logger.LogInfo(fmt.Sprintf("[sync.delta] 开始处理 link=%d user=%d", p.LinkID, p.UserID))
 
 	var link *model.NoteExternalLink
@@ -274,27 +287,40 @@ func HandleSyncDelta(ctx context.Context, t *asynq.Task) error {
 		}
 	}
 
-	client := feishu.GetClient()
-	if client == nil {
-		logger.LogError(nil, "[sync.delta] Feishu client 未初始化")
-		return fmt.Errorf("Feishu integration not configured")
+	// 停用的链接保留待推送记录，重新启用后继续；只拉取的链接不应有推送，直接跳过
+	if !link.IsActive {
+		logger.LogInfo(fmt.Sprintf("[sync.delta] link=%d 已停用，不推送", link.ID))
+		return nil
+	}
+	if link.Direction == model.SyncPullOnly {
+		if _, err := repository.NewSyncRepository(database.DB).SkipPendingSyncOutbox(ctx, link.ID); err != nil {
+			logger.LogError(err, "[sync.delta] 跳过只拉取链接的 outbox 失败 link=", link.ID)
+			return err
+		}
+		logger.LogInfo(fmt.Sprintf("[sync.delta] link=%d 只拉取，跳过推送", link.ID))
+		return nil
 	}
 
-	account, err := repository.NewIntegrationRepository(database.DB).
-		GetIntegrationAccountByUser(ctx, tools.Ptr(model.ProviderFeishu), &p.UserID)
+	session, err := openSyncSession(ctx, link.Provider, p.UserID)
 	if err != nil {
-		logger.LogError(err, "[sync.delta] 获取集成账户失败")
-		return err
-	}
-	if account == nil || !account.IsActive || account.AccessTokenExpiry == nil || account.AccessTokenExpiry.Before(time.Now()) {
-		logger.LogInfo(fmt.Sprintf("[sync.delta] 集成账户失效 user=%d", p.UserID))
-		return fmt.Errorf("integration account expired")
+		logger.LogError(err, "[sync.delta] 集成不可用 user=", p.UserID)
+		// 失败记在队首 outbox 上，使其按退避重试并最终进入死信，而不是被重试扫描无限投递
+		ob, nextErr := nextOutboxForLink(ctx, database.DB, link.ID)
+		if nextErr != nil {
+			if !errors.Is(nextErr, sql.ErrNoRows) && !errors.Is(nextErr, gorm.ErrRecordNotFound) {
+				logger.LogError(nextErr, "[sync.delta] 获取 outbox 失败")
+			}
+			recordLinkFailure(ctx, link.ID, err)
+			return nil
+		}
+		return failOutbox(ctx, link.ID, &ob, err)
 	}
 
 	for {
 		ob, err := nextOutboxForLink(ctx, database.DB, link.ID)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			// 没有连续的任务，或下一条仍在退避中
+			if errors.Is(err, sql.ErrNoRows) || errors.Is(err, gorm.ErrRecordNotFound) {
 				logger.LogInfo(fmt.Sprintf("[sync.delta] link=%d 无更多任务，结束", link.ID))
 				return nil
 			}
@@ -302,29 +328,17 @@ func HandleSyncDelta(ctx context.Context, t *asynq.Task) error {
 			return err
 		}
 
-		if link.ContentVersion >= ob.NoteVersion {
-			logger.LogInfo(fmt.Sprintf("[sync.delta] outbox id=%d 版本过旧 noteVersion=%d linkVersion=%d，跳过", ob.ID, ob.NoteVersion, link.ContentVersion))
-			syncRepo := repository.NewSyncRepository(database.DB)
-			if err := syncRepo.UpdateSyncOutboxByID(ctx, database.DB, ob.ID, nil, map[string]interface{}{
-				"status": model.SyncSkipped,
-			}); err != nil {
-				logger.LogError(err, "跳过失败")
-				return err
-			}
-		}
-
 		logger.LogInfo("[sync.delta] 开始执行 outbox id=%d noteVersion=%d op=%s", ob.ID, ob.NoteVersion, ob.OpType)
 
-		if err := applyOutboxToFeishu(ctx, database.DB, client, account, link, &ob); err != nil {
-			logger.LogError(err, "[sync.delta] applyOutboxToFeishu 失败 id=", ob.ID)
-			_ = markOutboxPending(ctx, ob.ID, err)
-			return err
+		// 失败的 outbox 按自身的退避时间由重试扫描重新投递，这里不再让任务队列重试
+		if err := applyOutbox(ctx, database.DB, session, link, &ob); err != nil {
+			logger.LogError(err, "[sync.delta] applyOutbox 失败 id=", ob.ID)
+			return failOutbox(ctx, link.ID, &ob, err)
 		}
 
 		if err := advanceBaselineAndFinish(ctx, link.ID, ob.ID, ob.NoteVersion); err != nil {
 			logger.LogError(err, fmt.Sprintf("[sync.delta] advanceBaselineAndFinish 失败 link=%d ob=%d", link.ID, ob.ID))
-			_ = markOutboxPending(ctx, ob.ID, err)
-			return err
+			return failOutbox(ctx, link.ID, &ob, err)
 		}
 
 		logger.LogInfo(fmt.Sprintf("[sync.delta] 成功完成 outbox id=%d version=%d", ob.ID, ob.NoteVersion))
@@ -351,9 +365,19 @@ func nextOutboxForLink(ctx context.Context, db *gorm.DB, linkID int64) (model.Sy
 			return err
 		}
 
+		// 基线之前的 outbox 不会再被按版本取出，直接跳过，避免重试扫描反复投递
+		skipped, err := syncRepo.SkipStaleSyncOutbox(ctx, linkID, base)
+		if err != nil {
+			logger.LogError(err, "[sync.nextOutbox] 跳过过旧 outbox 失败 link=", linkID)
+			return err
+		}
+		if skipped > 0 {
+			logger.LogInfo(fmt.Sprintf("[sync.nextOutbox] link=%d 跳过 %d 条版本不超过 %d 的 outbox", linkID, skipped, base))
+		}
+
 		nextVersion := base + 1
 		logger.LogInfo("[sync.nextOutbox] 获取 content_version=", base, " 下一个版本=", nextVersion)
-		ob, err = syncRepo.GetSequenceSynOutbox(ctx, linkID, tools.Ptr(model.SyncPending), &nextVersion)
+		ob, err = syncRepo.GetSequenceSynOutbox(ctx, linkID, tools.Ptr(model.SyncPending), &nextVersion, tools.Ptr(time.Now()))
 
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -372,32 +396,19 @@ func nextOutboxForLink(ctx context.Context, db *gorm.DB, linkID int64) (model.Sy
 	return ob, err
 }
 
-func applyOutboxToFeishu(
+func applyOutbox(
 	ctx context.Context,
 	db *gorm.DB,
-	client *feishu.Client,
-	account *model.IntegrationAccount,
+	session *syncSession,
 	link *model.NoteExternalLink,
 	ob *model.SyncOutbox,
 ) error {
-	logger.LogInfo(fmt.Sprintf("[sync.apply] 开始应用 op=%s version=%d", ob.OpType, ob.NoteVersion))
+	logger.LogInfo(fmt.Sprintf("[sync.apply] 开始应用 op=%s version=%d provider=%s", ob.OpType, ob.NoteVersion, link.Provider))
 	idKey := fmt.Sprintf("delta:%d:v%d:%x", link.ID, ob.NoteVersion, md5.Sum([]byte(ob.PatchJSON)))
-	blocks, err := getProviderBlocks(ctx, model.ProviderFeishu, client, account.AccessTokenEnc, link.TargetNoteID)
-	if err != nil {
-		logger.LogError(err, "[sync.apply] 获取 page block 失败")
-		return err
-	}
 
-	var pageID string
-	var actions []dto.PatchOp
-	for _, block := range blocks {
-		if block.BlockType != nil && *block.BlockType == 1 {
-			pageID = *block.BlockId
-			break
-		}
-	}
 	switch ob.OpType {
 	case "insert", "patch":
+		var actions []dto.PatchOp
 		if len(ob.PatchJSON) > 0 {
 			if err := json.Unmarshal([]byte(ob.PatchJSON), &actions); err != nil {
 				logger.LogError(err, "[sync.apply.insert] 解析 PatchJSON 失败")
@@ -406,12 +417,12 @@ func applyOutboxToFeishu(
 		}
 		logger.LogInfo(fmt.Sprintf("[sync.apply.insert] 准备执行 %d 个 actions", len(actions)))
 
-		var options = &ApplyOptions{
-			TargetBlocks: &blocks,
-			Provider:     tools.Ptr(model.ProviderFeishu),
+		doc, err := session.provider.FetchBlocks(ctx, session.token, link.TargetNoteID)
+		if err != nil {
+			logger.LogError(err, "[sync.apply] 获取外部文档块失败")
+			return err
 		}
-
-		return doApplyActionsToFeishu(db, ctx, link.NoteID, client, account.AccessTokenEnc, link.TargetNoteID, pageID, actions, idKey, options)
+		return applyActions(ctx, db, session, link, doc, actions, idKey)
 	default:
 		logger.LogInfo("[sync.apply] 未知操作类型: %s", ob.OpType)
 		return fmt.Errorf("unsupported op_type: %s", ob.OpType)
@@ -441,6 +452,8 @@ func advanceBaselineAndFinish(ctx context.Context, linkID, outboxID, noteVersion
 				"content_version": gorm.Expr("content_version + 1"),
 				"last_status":     model.SyncSuccess,
 				"last_synced_at":  time.Now(),
+				"last_error":      nil,
+				"fail_streak":     0,
 				"updated_at":      time.Now(),
 			})
 		if res.Error != nil {
@@ -457,36 +470,63 @@ func advanceBaselineAndFinish(ctx context.Context, linkID, outboxID, noteVersion
 }
 
 // ========== 失败回退 ==========
-func markOutboxPending(ctx context.Context, outboxID int64, cause error) error {
-	logger.LogInfo("[sync.delta] 标记 outbox=", outboxID, " 回退为 pending, error=", cause)
-	syncRepo := repository.NewSyncRepository(database.DB)
-	return syncRepo.UpdateSyncOutboxByID(ctx, database.DB, outboxID, nil, map[string]interface{}{
-		"status":     model.SyncPending,
-		"updated_at": time.Now(),
-		"last_error": cause.Error(),
-	})
-}
+const (
+	maxOutboxAttempts = 8
+	outboxBackoffBase = 30 * time.Second
+	outboxBackoffMax  = time.Hour
+)
 
-// ========== 获取 Feishu 页面主 Block ==========
-func getProviderBlocks(ctx context.Context, provider model.IntegrationProvider, c *feishu.Client, userToken, docID string) ([]*larkdocx.Block, error) {
-	switch provider {
-	case model.ProviderFeishu:
-		logger.LogInfo("[sync.delta] 获取飞书文档主 block docID=", docID)
+// outboxBackoff 第 attempts 次失败后的等待时间，从 30s 起翻倍，最长 1 小时
+func outboxBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 8 {
+		return outboxBackoffMax
+	}
+	return min(outboxBackoffBase<<(attempts-1), outboxBackoffMax)
+}
 
-		blocks, err := c.GetNoteAllBlocks(ctx, userToken, docID, nil)
-		if err != nil {
-			logger.LogError(err, "[sync.delta] 获取飞书 blocks 失败")
-			return []*larkdocx.Block{}, err
-		}
-		logger.LogInfo("items lengths: ", len(blocks.Items))
-		return blocks.Items, nil
+// failOutbox 记录一次推送失败：未达上限时退避后回到 pending，达到上限进入死信等待人工处理
+func failOutbox(ctx context.Context, linkID int64, ob *model.SyncOutbox, cause error) error {
+	attempts := ob.Attempts + 1
+	now := time.Now()
+	data := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": cause.Error(),
+		"updated_at": now,
 	}
-	return []*larkdocx.Block{}, fmt.Errorf("unsupported provider: %s", provider)
+	if attempts >= maxOutboxAttempts {
+		data["status"] = model.SyncDead
+		data["next_retry_at"] = nil
+		logger.LogInfo(fmt.Sprintf("[sync.delta] outbox=%d 连续失败 %d 次，进入死信", ob.ID, attempts))
+	} else {
+		data["status"] = model.SyncPending
+		data["next_retry_at"] = now.Add(outboxBackoff(attempts))
+		logger.LogInfo(fmt.Sprintf("[sync.delta] outbox=%d 第 %d 次失败，%s 后重试", ob.ID, attempts, outboxBackoff(attempts)))
+	}
+	syncRepo := repository.NewSyncRepository(database.DB)
+	if err := syncRepo.UpdateSyncOutboxByID(ctx, database.DB, ob.ID, nil, data); err != nil {
+		logger.LogError(err, "[sync.delta] 记录 outbox 失败状态出错 id=", ob.ID)
+		return err
+	}
+	recordLinkFailure(ctx, linkID, cause)
+	return nil
 }
 
-type ApplyOptions struct {
-	TargetBlocks *[]*larkdocx.Block
-	Provider     *model.IntegrationProvider
+// recordLinkFailure 累计链接的失败指标，推送成功后 fail_streak 清零
+func recordLinkFailure(ctx context.Context, linkID int64, cause error) {
+	now := time.Now()
+	if err := repository.UpdateNoteSync(ctx, database.DB, "id = ?", []interface{}{linkID}, map[string]interface{}{
+		"last_status":   model.SyncFailed,
+		"last_error":    cause.Error(),
+		"last_error_at": now,
+		"error_count":   gorm.Expr("error_count + 1"),
+		"fail_streak":   gorm.Expr("fail_streak + 1"),
+		"updated_at":    now,
+	}); err != nil {
+		logger.LogError(err, "[sync] 记录链接失败指标出错 link=", linkID)
+	}
 }
 
 type Chain struct {
@@ -497,35 +537,34 @@ type Chain struct {
 	Tail     string // 最后一块的 NodeUID
 }
 
-func doApplyActionsToFeishu(
-	db *gorm.DB,
+// applyActions 把本地块操作应用到外部文档：update 按映射覆盖，连续的 insert 归并成链后按位置整段插入
+func applyActions(
 	ctx context.Context,
-	noteID int64,
-	client *feishu.Client,
-	userToken, docID, pageID string,
+	db *gorm.DB,
+	session *syncSession,
+	link *model.NoteExternalLink,
+	doc *syncer.Document,
 	actions []dto.PatchOp,
 	idempKey string,
-	options *ApplyOptions,
 ) error {
-	logger.LogInfo("[sync.delta] 开始 apply actions 到 Feishu noteID=", noteID, " docID=", docID, " action_count=", len(actions))
+	docID := link.TargetNoteID
+	logger.LogInfo("[sync.delta] 开始 apply actions noteID=", link.NoteID, " docID=", docID, " action_count=", len(actions))
 
 	blockIDs := make([]string, 0)
-	blockMapping := make(map[string]ExternalBlockMapping) // 本地 blockID → 飞书 blockID
-	updateBlocks := []*feishu.UpdateFeishuBlock{}
-	insertBlocks := []*feishu.CreateFeishuBlock{}
-
-	// ---------- 目标页面已有块的索引（用于定位插入位置） ----------
-	targetBlockMapping := map[string]int{}
-	if options != nil && options.TargetBlocks != nil {
-		logger.LogInfo("[sync.delta] 目标页面已有块数=", len(*options.TargetBlocks))
-		for idx, b := range *options.TargetBlocks {
-			if b.BlockId != nil {
-				targetBlockMapping[*b.BlockId] = idx
-			} else {
-				logger.LogInfo("[sync.delta] 目标页面块缺少 BlockId，跳过 idx=", b)
-			}
-		}
+	blockMapping := make(map[string]string) // 本地 blockID → 外部 blockID
+	mappedETag := make(map[string]string)   // 本地 blockID → 上次同步时的外部指纹
+	updateBlocks := make([]dto.NoteBlockDTO, 0)
+	deleteIDs := make([]string, 0)
+
+	// ---------- 目标文档顶层块的位置（用于定位插入位置） ----------
+	targetIndex := make(map[string]int, len(doc.Blocks))
+	remoteETag := make(map[string]string, len(doc.Blocks))
+	for idx, b := range doc.Blocks {
+		targetIndex[b.ID] = idx
+		remoteETag[b.ID] = b.ETag
 	}
+	logger.LogInfo("[sync.delta] 目标文档已有块数=", len(doc.Blocks))
+
 	// ---------- 乱序 insert 分组（内联“groupInsertsAnyOrder”逻辑） ----------
 	tailIdx := make(map[string]*Chain) // key: Tail NodeUID -> *Chain
 	headIdx := make(map[string]*Chain) // key: Head NodeUID -> *Chain
@@ -577,50 +616,25 @@ func doApplyActionsToFeishu(
 				right = ch
 			}
 
+			cur := &Chain{
+				Block:    dto.Blocks{*act.Block},
+				AfterID:  act.AfterID,
+				BeforeID: act.BeforeID,
+				Head:     act.NodeUID,
+				Tail:     act.NodeUID,
+			}
 			switch {
 			case left != nil && right != nil:
-				cur := &Chain{
-					Block:    dto.Blocks{*act.Block},
-					AfterID:  act.AfterID,
-					BeforeID: act.BeforeID,
-					Head:     act.NodeUID,
-					Tail:     act.NodeUID,
-				}
 				_ = mergeRight(left, cur)
 				_ = mergeRight(left, right)
-
 			case left != nil:
 				// 只有左：把当前块接到左链尾部
-				cur := &Chain{
-					Block:    dto.Blocks{*act.Block},
-					AfterID:  act.AfterID,
-					BeforeID: act.BeforeID,
-					Head:     act.NodeUID,
-					Tail:     act.NodeUID,
-				}
 				_ = mergeRight(left, cur)
-
 			case right != nil:
-				// 只有右：当前块在前，接上右链
-				cur := &Chain{
-					Block:    dto.Blocks{*act.Block},
-					AfterID:  act.AfterID,
-					BeforeID: act.BeforeID,
-					Head:     act.NodeUID,
-					Tail:     act.NodeUID,
-				}
+				// 只有右：当前块在前，接上右链；mergeRight 内部会更新索引
 				_ = mergeRight(cur, right)
-				// 注意：mergeRight 内部会更新索引，这里无需额外注册
-
 			default:
 				// 两边都接不上：新建独立链并注册索引
-				cur := &Chain{
-					Block:    dto.Blocks{*act.Block},
-					AfterID:  act.AfterID,
-					BeforeID: act.BeforeID,
-					Head:     act.NodeUID,
-					Tail:     act.NodeUID,
-				}
 				tailIdx[cur.Tail] = cur
 				headIdx[cur.Head] = cur
 			}
@@ -630,79 +644,83 @@ func doApplyActionsToFeishu(
 				logger.LogInfo("[sync.delta] 跳过空 block 的 update action")
 				continue
 			}
-			larkBlock := feishu.ParseBlockToLark(dto.Blocks{*act.Block})
-			blockMapping[act.NodeUID] = ExternalBlockMapping{}
-			updateBlocks = append(updateBlocks, &feishu.UpdateFeishuBlock{
-				LocalBlockID: act.Block.ID,
-				Block:        larkBlock[0],
-			})
-
-		case "replace":
-			logger.LogInfo("[sync.delta] 执行 replace action block=", func() string {
-				if act.Block != nil {
-					return act.Block.ID
-				}
-				return "<nil>"
-			}())
+			updateBlocks = append(updateBlocks, *act.Block)
 
 		case "delete":
-			logger.LogInfo("[sync.delta] 执行 delete action block=", func() string {
-				if act.Block != nil {
-					return act.Block.ID
-				}
-				return "<nil>"
-			}())
+			blockIDs = append(blockIDs, act.NodeUID)
+			deleteIDs = append(deleteIDs, act.NodeUID)
+
+		case "replace", "move":
+			logger.LogInfo(fmt.Sprintf("[sync.delta] 暂不同步 %s action block=%s", act.Op, act.NodeUID))
 		}
 	}
 
-	// ---------- 补齐 block 映射（仅 update 需要；insert 是新建，不必依赖映射） ----------
+	// ---------- 补齐 block 映射 ----------
 	if len(blockIDs) > 0 {
 		syncRepo := repository.NewSyncRepository(db)
 		logger.LogInfo("[sync.delta] 查询 block 映射关系数量=", len(blockIDs))
-		bmapping, err := syncRepo.GetBlockMappingByBlockIDs(ctx, noteID, tools.Ptr(model.ProviderFeishu), &blockIDs)
+		bmapping, err := syncRepo.GetBlockMappingByBlockIDs(ctx, link.NoteID, &link.Provider, &blockIDs)
 		if err != nil {
 			logger.LogError(err, "[sync.delta] 获取 block 映射失败")
 			return err
 		}
 		for _, m := range *bmapping {
-			blockMapping[m.NodeUID] = ExternalBlockMapping{
-				BlockID: m.ExternalBlockID,
-			}
-			logger.LogInfo("[sync.delta] 绑定 block 映射: ", m.NodeUID, " → ", m.ExternalBlockID)
+			blockMapping[m.NodeUID] = m.ExternalBlockID
+			mappedETag[m.NodeUID] = m.ETag
 		}
 	}
 
-	// ---------- 批量更新（保持你的逻辑） ----------
-	if len(updateBlocks) > 0 {
-		logger.LogInfo("[sync.delta] blockMapping", blockMapping)
-		write := 0
-		for _, ub := range updateBlocks {
-			if ub == nil {
+	// ---------- 批量更新：没有映射的块无法定位，直接跳过 ----------
+	updates := make([]syncer.BlockUpdate, 0, len(updateBlocks))
+	for _, block := range updateBlocks {
+		externalID, ok := blockMapping[block.ID]
+		if !ok || externalID == "" {
+			logger.LogInfo(fmt.Sprintf("[sync.delta] 未找到外部 block 映射，本地ID=%s，已从更新列表移除", block.ID))
+			continue
+		}
+		// 双向同步时外部块在上次同步后被改过或已删除，不直接覆盖，留给拉取做三方合并
+		if link.Direction == model.SyncTwoWay && mappedETag[block.ID] != "" {
+			if etag, ok := remoteETag[externalID]; !ok || etag != mappedETag[block.ID] {
+				logger.LogInfo(fmt.Sprintf("[sync.delta] 外部块已被修改，等待拉取合并 本地ID=%s", block.ID))
 				continue
 			}
-			mapping, ok := blockMapping[ub.LocalBlockID]
-			if ok && mapping.BlockID != "" {
-				ub.TargetBlockID = mapping.BlockID
-				updateBlocks[write] = ub
-				write++
-				logger.LogInfo(fmt.Sprintf("[sync.delta] 准备更新 Feishu block 本地ID=%s 飞书ID=%s", ub.LocalBlockID, mapping.BlockID))
-			} else {
-				logger.LogInfo(fmt.Sprintf("[sync.delta] 未找到 Feishu block 映射，本地ID=%s，已从更新列表移除", ub.LocalBlockID))
-			}
 		}
-		updateBlocks = updateBlocks[:write]
-
-		logger.LogInfo("[sync.delta] 批量更新 Feishu blocks 数量=", len(updateBlocks))
-		_, err := client.BatchUpdateBlocks(ctx, docID, userToken, updateBlocks, &http.Header{
-			"Idempotency-Key": []string{idempKey},
-		})
+		updates = append(updates, syncer.BlockUpdate{ExternalID: externalID, Block: block})
+	}
+	syncRepo := repository.NewSyncRepository(db)
+	if len(updates) > 0 {
+		logger.LogInfo("[sync.delta] 批量更新外部 blocks 数量=", len(updates))
+		refs, err := session.provider.UpdateBlocks(ctx, session.token, docID, updates, idempKey)
 		if err != nil {
-			logger.LogError(err, "[sync.delta] 批量更新 Feishu blocks 失败")
+			logger.LogError(err, "[sync.delta] 批量更新外部 blocks 失败")
 			return err
 		}
-		logger.LogInfo("[sync.delta] 批量更新 Feishu blocks 成功 docID=", docID)
+		// 平台重建了块时外部 ID 会变化，旧映射需要一并清理
+		replaced := make([]string, 0)
+		for _, ref := range refs {
+			if old := blockMapping[ref.LocalID]; old != "" && old != ref.ID {
+				replaced = append(replaced, old)
+			}
+		}
+		if err := syncRepo.UpsertNoteExternalNodeMappings(ctx, tools.Ptr(refMappings(link, refs, updateBlocks))); err != nil {
+			logger.LogError(err, "[sync.delta] 更新外部 blocks 后写映射失败")
+			return err
+		}
+		if err := syncRepo.DeleteNoteExternalNodeMappings(ctx, link.NoteID, link.Provider, replaced); err != nil {
+			logger.LogError(err, "[sync.delta] 清理被替换的映射失败")
+			return err
+		}
+		for _, ref := range refs {
+			blockMapping[ref.LocalID] = ref.ID
+		}
 	}
 
+	// ---------- 计算每条链的插入位置：优先 BeforeID 所在位置，其次 AfterID 之后，都找不到时插到顶部 ----------
+	type insertTask struct {
+		chain *Chain
+		index int
+	}
+	inserts := make([]insertTask, 0, len(headIdx))
 	seen := make(map[*Chain]struct{})
 	for _, ch := range headIdx {
 		if _, ok := seen[ch]; ok {
@@ -710,108 +728,140 @@ func doApplyActionsToFeishu(
 		}
 		seen[ch] = struct{}{}
 
-		// DTO → Lark blocks（一次性插入整段）
-		larkBlocks := feishu.ParseBlockToLark(ch.Block)
-
-		task := &feishu.CreateFeishuBlock{
-			// LocalBlockID 可选：若你要用于日志或调试，可取第一块的 ID
-			LocalBlockIDs: func() []string {
-				ids := make([]string, 0, len(ch.Block))
-				for _, b := range ch.Block {
-					ids = append(ids, b.ID)
-				}
-				return ids
-			}(),
-			Block: larkBlocks, // 整段
-			// After/Before 只用于计算 Index，这里直接算 Index
-		}
-
-		// 计算插入 Index：优先 AfterID（+1），其次 BeforeID（自身位置）
 		index := -1
-		logger.LogInfo("targetBlockMapping: ", targetBlockMapping)
 		if ch.BeforeID != nil {
-			logger.LogInfo(fmt.Sprintf("Before ID: %s, BlockID: %s", *ch.BeforeID, blockMapping[*ch.BeforeID].BlockID))
-
-			if beforeIndex, ok := targetBlockMapping[blockMapping[*ch.BeforeID].BlockID]; ok {
-				// !! BeforeID 指向的块会被推后，所以插入位置要 -1, 勿改
-				index = beforeIndex - 1
+			if beforeIndex, ok := targetIndex[blockMapping[*ch.BeforeID]]; ok {
+				index = beforeIndex
 			}
 		}
-
 		if ch.AfterID != nil && index == -1 {
-			logger.LogInfo(fmt.Sprintf("AfterID: %s, BlockID: %s", *ch.AfterID, blockMapping[*ch.AfterID].BlockID))
-
-			if afterIndex, ok := targetBlockMapping[blockMapping[*ch.AfterID].BlockID]; ok {
-				index = afterIndex
+			if afterIndex, ok := targetIndex[blockMapping[*ch.AfterID]]; ok {
+				index = afterIndex + 1
 			}
 		}
-
 		logger.LogInfo(fmt.Sprintf("[sync.delta] 批量插入链 Head=%s Tail=%s 计算插入 Index=%d", ch.Head, ch.Tail, index))
 		if index < 0 {
 			index = 0 // 默认从顶部插入
 		}
-		task.Index = index
-
-		insertBlocks = append(insertBlocks, task)
+		inserts = append(inserts, insertTask{chain: ch, index: index})
 	}
 
-	if len(insertBlocks) > 0 {
-		logger.LogInfo("[sync.delta] 批量插入 Feishu blocks 数量=", len(insertBlocks))
-		syncRepo := repository.NewSyncRepository(db)
-		sort.SliceStable(insertBlocks, func(i, j int) bool {
-			return insertBlocks[i].Index > insertBlocks[j].Index
-		})
+	// 从后往前插入，前面的位置不受影响
+	sort.SliceStable(inserts, func(i, j int) bool {
+		return inserts[i].index > inserts[j].index
+	})
+	for _, task := range inserts {
+		created, err := session.provider.CreateBlocks(ctx, session.token, docID, doc.RootID, task.index, task.chain.Block,
+			fmt.Sprintf("%s:%s", idempKey, task.chain.Head))
+		if err != nil {
+			logger.LogError(err, "[sync.delta] 批量插入外部 blocks 失败")
+			return err
+		}
 
-		for _, st := range insertBlocks {
-			if st == nil {
-				continue
+		for i := range created {
+			if i < len(task.chain.Block) {
+				created[i].LocalID = task.chain.Block[i].ID
 			}
-			resp, err := client.CreateBlocks(ctx, userToken, docID, pageID, st.Block, st.Index, &http.Header{
-				"Idempotency-Key": []string{idempKey},
-			})
-
-			if err != nil {
-				logger.LogError(err, "[sync.delta] 批量插入 Feishu blocks 失败")
-				return err
-			}
-			var blockMappingModels []model.NoteExternalNodeMapping
-
-			for i, b := range resp.Children {
-				logger.LogInfo(fmt.Sprintf("[sync.delta] 插入 Feishu block 本地ID=%s 飞书ID=%s", st.LocalBlockIDs, *b.BlockId))
-				blockMappingModels = append(blockMappingModels, model.NoteExternalNodeMapping{
-					NoteID:           noteID,
-					Provider:         model.ProviderFeishu,
-					NodeUID:          st.LocalBlockIDs[i],
-					ExternalDocID:    docID,
-					ExternalBlockID:  *b.BlockId,
-					ExternalParentID: *b.ParentId,
-					SyncStatus:       model.SyncSuccess,
-					LastSyncedAt:     time.Now().UTC(),
-				})
-			}
-			err = syncRepo.UpsertNoteExternalNodeMappings(ctx, &blockMappingModels)
-			if err != nil {
-				logger.LogError(err, "[sync.delta] 插入 Feishu blocks 后写映射失败")
-				return err
-			}
-
 		}
-		logger.LogInfo("[sync.delta] 批量插入 Feishu blocks 成功 docID=", docID)
+		if err := syncRepo.UpsertNoteExternalNodeMappings(ctx, tools.Ptr(refMappings(link, created, task.chain.Block))); err != nil {
+			logger.LogError(err, "[sync.delta] 插入外部 blocks 后写映射失败")
+			return err
+		}
+		for _, ref := range created {
+			blockMapping[ref.LocalID] = ref.ID
+		}
+	}
+
+	if err := deleteRemoteBlocks(ctx, db, session, link, deleteIDs, blockMapping); err != nil {
+		return err
 	}
 
 	logger.LogInfo("[sync.delta] 所有 actions 执行完毕")
 	return nil
 }
 
-// ========== 失败回退（写 last_error 并回到 pending） ==========
+// deleteRemoteBlocks 插入完成后重新读取外部文档，按下标从后往前删除已映射的顶层块并清理映射
+func deleteRemoteBlocks(
+	ctx context.Context,
+	db *gorm.DB,
+	session *syncSession,
+	link *model.NoteExternalLink,
+	nodeUIDs []string,
+	blockMapping map[string]string,
+) error {
+	targets := make(map[string]struct{}, len(nodeUIDs))
+	for _, uid := range nodeUIDs {
+		if externalID := blockMapping[uid]; externalID != "" {
+			targets[externalID] = struct{}{}
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	doc, err := session.provider.FetchBlocks(ctx, session.token, link.TargetNoteID)
+	if err != nil {
+		logger.LogError(err, "[sync.delta] 删除前获取外部文档块失败")
+		return err
+	}
+	indexes := make([]int, 0, len(targets))
+	for idx, b := range doc.Blocks {
+		if _, ok := targets[b.ID]; ok {
+			indexes = append(indexes, idx)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+	for _, idx := range indexes {
+		if err := session.provider.DeleteBlocks(ctx, session.token, link.TargetNoteID, doc.RootID, idx, idx+1); err != nil {
+			logger.LogError(err, "[sync.delta] 删除外部 block 失败")
+			return err
+		}
+	}
+
+	// 外部已不存在的块（含非顶层块）也一并清理映射
+	externalIDs := make([]string, 0, len(targets))
+	for id := range targets {
+		externalIDs = append(externalIDs, id)
+	}
+	logger.LogInfo("[sync.delta] 删除外部 blocks 数量=", len(indexes))
+	return repository.NewSyncRepository(db).DeleteNoteExternalNodeMappings(ctx, link.NoteID, link.Provider, externalIDs)
+}
 
-// 全量构建（如果你用不到可删除）
-func buildBlocksFromFull(raw []byte) ([]*larkdocx.Block, error) {
-	// TODO: 把 raw 解析为飞书 blocks；或直接从本地数据重新组装
-	return []*larkdocx.Block{}, nil
+// refMappings 把写入结果转换为块映射，blocks 为写入时双方一致的本地块，记录为三方合并的基线
+func refMappings(link *model.NoteExternalLink, refs []syncer.BlockRef, blocks dto.Blocks) []model.NoteExternalNodeMapping {
+	ts := time.Now().UTC()
+	byID := make(map[string]dto.NoteBlockDTO, len(blocks))
+	for _, block := range blocks {
+		byID[block.ID] = block
+	}
+	mappings := make([]model.NoteExternalNodeMapping, 0, len(refs))
+	for _, ref := range refs {
+		if ref.LocalID == "" {
+			continue
+		}
+		var base datatypes.JSON
+		if block, ok := byID[ref.LocalID]; ok {
+			base = baseBlockJSON(block)
+		}
+		mappings = append(mappings, model.NoteExternalNodeMapping{
+			NoteID:           link.NoteID,
+			Provider:         link.Provider,
+			NodeUID:          ref.LocalID,
+			ExternalDocID:    link.TargetNoteID,
+			ExternalBlockID:  ref.ID,
+			ExternalParentID: ref.ParentID,
+			SyncStatus:       model.SyncSuccess,
+			LastSyncedAt:     ts,
+			ETag:             ref.ETag,
+			BaseBlock:        base,
+		})
+	}
+	return mappings
 }
 
-type ExternalBlockMapping struct {
-	BlockID string
-	// Block   *larkdocx.Block
+// baseBlockJSON 基线只记录块本身，子块不参与合并
+func baseBlockJSON(block dto.NoteBlockDTO) datatypes.JSON {
+	block.Children = nil
+	raw, _ := json.Marshal(block)
+	return raw
 }
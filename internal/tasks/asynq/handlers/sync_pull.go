@@ -0,0 +1,551 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/syncer"
+	"gin-notebook/internal/pkg/realtime/bus"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/service/noteService"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"gin-notebook/pkg/utils/tools"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// pullProviders 支持拉取远端修改的平台
+var pullProviders = []model.IntegrationProvider{model.ProviderNotion, model.ProviderFeishu}
+
+func HandleSyncPullScan(ctx context.Context, t *asynq.Task) error {
+	links, err := repository.NewSyncRepository(database.DB).GetPullableNoteSyncLinks(ctx, pullProviders)
+	if err != nil {
+		logger.LogError(err, "[sync.pull] 获取可拉取的同步链接失败")
+		return err
+	}
+	for _, link := range links {
+		if _, err := enqueue.SyncPull(ctx, types.SyncPullPayload{LinkID: link.ID}); err != nil {
+			logger.LogInfo(fmt.Sprintf("[sync.pull] 投递拉取任务跳过 link=%d: %v", link.ID, err))
+		}
+	}
+	return nil
+}
+
+// HandleSyncPull 拉取外部文档的顶层块，按块指纹（ETag）比对映射后生成 PatchOp 写回笔记。
+// 本地有尚未推送的修改、笔记被锁定时本轮跳过，等待下一轮扫描
+func HandleSyncPull(ctx context.Context, t *asynq.Task) error {
+	var p types.SyncPullPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+
+	// 与推送共用一把锁，避免拉取与 outbox 推送交错
+	unlock, err := cache.RedisInstance.Lock(ctx, fmt.Sprintf("sync:delta:%d", p.LinkID), 2*time.Minute)
+	if err != nil {
+		logger.LogInfo(fmt.Sprintf("[sync.pull] link=%d 正在推送，跳过本轮", p.LinkID))
+		return nil
+	}
+	defer unlock()
+
+	link, err := repository.GetNoteSyncByID(database.DB, ctx, p.LinkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !link.IsActive || link.InitStatus != model.InitReady || link.Direction == model.SyncPushOnly {
+		return nil
+	}
+
+	syncRepo := repository.NewSyncRepository(database.DB)
+	pending, err := syncRepo.CountPendingSyncOutbox(ctx, link.ID)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		logger.LogInfo(fmt.Sprintf("[sync.pull] link=%d 仍有 %d 条待推送，跳过本轮", link.ID, pending))
+		return nil
+	}
+	if lock, err := cache.RedisInstance.GetNoteLock(ctx, link.NoteID); err != nil || lock != "" {
+		logger.LogInfo(fmt.Sprintf("[sync.pull] note=%d 已锁定或锁状态不可读，跳过本轮", link.NoteID))
+		return nil
+	}
+
+	member, err := repository.GetWorkspaceMemberByID(database.DB, link.MemberID)
+	if err != nil {
+		logger.LogError(err, "[sync.pull] 获取链接成员失败 link=", link.ID)
+		return nil
+	}
+	session, err := openSyncSession(ctx, link.Provider, member.UserID)
+	if err != nil {
+		logger.LogError(err, "[sync.pull] 集成不可用 link=", link.ID)
+		return nil
+	}
+
+	// 支持版本号的平台先比对版本，未变化时不必读取整篇文档
+	revision := ""
+	if rp, ok := session.provider.(syncer.RevisionProvider); ok {
+		revision, err = rp.Revision(ctx, session.token, link.TargetNoteID)
+		if err != nil {
+			logger.LogError(err, "[sync.pull] 获取外部文档版本失败 link=", link.ID)
+			return err
+		}
+		if link.ExternalVersion != nil && *link.ExternalVersion == revision {
+			return nil
+		}
+	}
+
+	doc, err := session.provider.FetchBlocks(ctx, session.token, link.TargetNoteID)
+	if err != nil {
+		logger.LogError(err, "[sync.pull] 获取外部文档块失败 link=", link.ID)
+		_ = repository.UpdateNoteSync(ctx, database.DB, "id = ?", []interface{}{link.ID}, map[string]interface{}{
+			"last_status": model.SyncFailed,
+			"last_error":  err.Error(),
+			"updated_at":  time.Now(),
+		})
+		return err
+	}
+	// 逐块转换，保证与 doc.Blocks 下标一一对应；无法识别的块为 nil
+	converted := make([]*dto.NoteBlockDTO, len(doc.Blocks))
+	for i, remote := range doc.Blocks {
+		blocks, err := session.provider.ToLocal([]syncer.RemoteBlock{remote})
+		if err != nil {
+			return err
+		}
+		if len(blocks) > 0 {
+			converted[i] = &blocks[0]
+		}
+	}
+
+	var (
+		note        *model.Note
+		newVersion  int64
+		applied     []dto.PatchOp
+		others      []model.NoteExternalLink
+		skipped     bool
+		contentSame bool
+		pushBack    bool
+		conflicts   int
+		effects     *noteService.ContentEffects
+	)
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewSyncRepository(tx)
+		note, err = txRepo.GetNoteForUpdate(ctx, link.NoteID)
+		if err != nil {
+			return err
+		}
+		base, err := repository.GetNoteExternalLinkContentVersion(ctx, tx, link.ID)
+		if err != nil {
+			return err
+		}
+		// 本地版本领先于链接基线，说明有修改还未生成或推送 outbox，交给推送处理
+		if note.Version != base {
+			skipped = true
+			return nil
+		}
+
+		var local dto.Blocks
+		if err := json.Unmarshal(note.Content, &local); err != nil {
+			return fmt.Errorf("unmarshal note content: %w", err)
+		}
+		mappings, err := repository.GetNoteMappingByNoteAndProvider(tx, ctx, link.NoteID, link.Provider)
+		if err != nil {
+			return err
+		}
+
+		plan := planPull(link, note.UpdatedAt, local, doc.Blocks, converted, *mappings)
+		if err := txRepo.DeleteNoteExternalNodeMappings(ctx, link.NoteID, link.Provider, plan.removed); err != nil {
+			return err
+		}
+		if err := txRepo.UpsertNoteExternalNodeMappings(ctx, tools.Ptr(refMappings(link, plan.upserts, plan.bases))); err != nil {
+			return err
+		}
+		for i := range plan.conflicts {
+			if err := txRepo.SaveOpenNoteSyncConflict(ctx, &plan.conflicts[i]); err != nil {
+				return err
+			}
+		}
+		conflicts = len(plan.conflicts)
+		if len(plan.ops) == 0 && len(plan.pushBack) == 0 {
+			contentSame = true
+			return nil
+		}
+
+		content, effective, err := dto.ApplyPatch(local, plan.ops)
+		if err != nil {
+			return err
+		}
+		if len(effective) == 0 && len(plan.pushBack) == 0 {
+			contentSame = true
+			return nil
+		}
+		raw, err := json.Marshal(content)
+		if err != nil {
+			return err
+		}
+
+		// 合并结果需要回推外部文档时，即使本地内容未变也推进版本，用新版本的 outbox 承载回推
+		newVersion = note.Version + 1
+		ok, err := txRepo.UpdateNoteByVersion(ctx, note.ID, note.Version, map[string]interface{}{
+			"content":    raw,
+			"version":    newVersion,
+			"updated_at": time.Now().UTC().Truncate(time.Microsecond),
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("note %d version changed during pull", note.ID)
+		}
+		applied = append(make([]dto.PatchOp, 0, len(effective)), effective...)
+		// 与编辑走同一套派生数据更新：出链、评论锚点、清单任务
+		if len(applied) > 0 {
+			if effects, err = noteService.ApplyContentEffects(ctx, tx, note, local, content); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"last_status":    model.SyncSuccess,
+			"last_synced_at": now,
+			"last_error":     nil,
+			"updated_at":     now,
+		}
+		// 没有回推时基线直接推进到新版本；有回推时由推送完成后推进
+		if len(plan.pushBack) == 0 {
+			updates["content_version"] = newVersion
+		}
+		if revision != "" {
+			updates["external_version"] = revision
+		}
+		if err := repository.UpdateNoteSync(ctx, tx, "id = ? AND content_version = ?", []interface{}{link.ID, base}, updates); err != nil {
+			return err
+		}
+
+		// 其它可推送的链接需要把这次变更继续同步出去
+		links, _, err := repository.GetNoteSyncList(tx, ctx, nil, &link.NoteID, nil)
+		if err != nil {
+			return err
+		}
+		patchJSON, err := json.Marshal(applied)
+		if err != nil {
+			return err
+		}
+		outboxs := make([]model.SyncOutbox, 0)
+		if len(plan.pushBack) > 0 {
+			pushBackJSON, err := json.Marshal(plan.pushBack)
+			if err != nil {
+				return err
+			}
+			pushBack = true
+			outboxs = append(outboxs, model.SyncOutbox{
+				NoteID:      link.NoteID,
+				LinkID:      link.ID,
+				NoteVersion: newVersion,
+				OpType:      "patch",
+				Status:      model.SyncPending,
+				PatchJSON:   pushBackJSON,
+			})
+		}
+		for _, other := range *links {
+			if other.ID == link.ID || !other.IsActive || other.Direction == model.SyncPullOnly {
+				continue
+			}
+			others = append(others, other)
+			outboxs = append(outboxs, model.SyncOutbox{
+				NoteID:      link.NoteID,
+				LinkID:      other.ID,
+				NoteVersion: newVersion,
+				OpType:      "patch",
+				Status:      model.SyncPending,
+				PatchJSON:   patchJSON,
+			})
+		}
+		if len(outboxs) > 0 {
+			return txRepo.CreateSyncOutboxs(ctx, &outboxs)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		logger.LogError(err, "[sync.pull] 写回笔记失败 link=", link.ID)
+		return err
+	}
+	if skipped {
+		logger.LogInfo(fmt.Sprintf("[sync.pull] note=%d 本地有未推送的修改，跳过本轮", link.NoteID))
+		return nil
+	}
+	if conflicts > 0 {
+		logger.LogInfo(fmt.Sprintf("[sync.pull] link=%d 有 %d 个块冲突待人工处理", link.ID, conflicts))
+	}
+	if contentSame {
+		updates := map[string]interface{}{
+			"last_status":    model.SyncSuccess,
+			"last_synced_at": time.Now(),
+			"updated_at":     time.Now(),
+		}
+		if revision != "" {
+			updates["external_version"] = revision
+		}
+		_ = repository.UpdateNoteSync(ctx, database.DB, "id = ?", []interface{}{link.ID}, updates)
+		return nil
+	}
+
+	logger.LogInfo(fmt.Sprintf("[sync.pull] note=%d 已写回远端修改 version=%d ops=%d", link.NoteID, newVersion, len(applied)))
+	effects.Publish(ctx, member.UserID)
+	if _, err := enqueue.IngestNote(ctx, types.IngestNotePayload{
+		NoteID:      note.ID,
+		WorkspaceID: note.WorkspaceID,
+		OwnerUserID: note.OwnerID,
+		Version:     newVersion,
+	}); err != nil {
+		logger.LogError(err, "[sync.pull] 投递笔记索引任务失败")
+	}
+	_ = bus.PublishWsNote(ctx, strconv.FormatInt(note.ID, 10), bus.WsEvent{
+		Type:    bus.WsNoteSynced,
+		NoteID:  strconv.FormatInt(note.ID, 10),
+		Payload: map[string]any{"provider": link.Provider, "version": newVersion},
+	})
+	if pushBack {
+		others = append(others, *link)
+	}
+	for _, other := range others {
+		otherMember, err := repository.GetWorkspaceMemberByID(database.DB, other.MemberID)
+		if err != nil {
+			logger.LogError(err, "[sync.pull] 获取链接成员失败 link=", other.ID)
+			continue
+		}
+		_, _ = enqueue.SyncDelta(ctx, types.SyncDeltaPayload{
+			LinkID:      other.ID,
+			NoteID:      note.ID,
+			WorkspaceID: note.WorkspaceID,
+			UserID:      otherMember.UserID,
+			MemberID:    other.MemberID,
+		})
+	}
+	return nil
+}
+
+// pullPlan 一次拉取需要应用到本地的 op、回推外部文档的 op 以及映射变化
+type pullPlan struct {
+	ops       []dto.PatchOp
+	pushBack  []dto.PatchOp            // 合并结果与外部不一致、需要推回外部的块
+	upserts   []syncer.BlockRef        // 新增或指纹变化的映射
+	bases     dto.Blocks               // 映射对应的新基线
+	removed   []string                 // 外部已删除的块
+	conflicts []model.NoteSyncConflict // manual 策略下等待人工处理的块
+}
+
+// planPull 以外部顶层块顺序为准重排已映射的块：
+// 指纹变化的块与本地、基线做三方合并，无法合并时按链接的冲突策略取舍（保留本地子块），
+// 新出现的块插入本地，外部删除的块在本地删除，没有映射的本地块保持在原来前一个块之后
+func planPull(link *model.NoteExternalLink, localEditedAt time.Time, local dto.Blocks, remote []syncer.RemoteBlock, converted []*dto.NoteBlockDTO, mappings []model.NoteExternalNodeMapping) pullPlan {
+	plan := pullPlan{}
+	canPush := link.Direction != model.SyncPullOnly
+	byExternal := make(map[string]model.NoteExternalNodeMapping, len(mappings))
+	mappedLocal := make(map[string]bool, len(mappings))
+	for _, m := range mappings {
+		byExternal[m.ExternalBlockID] = m
+		mappedLocal[m.NodeUID] = true
+	}
+	localByID := make(map[string]dto.NoteBlockDTO, len(local))
+	for _, b := range local {
+		localByID[b.ID] = b
+	}
+
+	remoteIDs := make(map[string]bool, len(remote))
+	ordered := make([]string, 0, len(remote))
+	inserted := make(map[string]*dto.NoteBlockDTO)
+	for i, r := range remote {
+		remoteIDs[r.ID] = true
+		m, mapped := byExternal[r.ID]
+		current, exists := localByID[m.NodeUID]
+		switch {
+		case mapped && exists:
+			ordered = append(ordered, m.NodeUID)
+			if m.ETag == r.ETag || converted[i] == nil {
+				continue
+			}
+			remoteBlock := *converted[i]
+			remoteBlock.ID = m.NodeUID
+			remoteBlock.Children = nil
+			// 之前没有记录指纹时只补齐基线，无法判断是否被修改
+			if m.ETag == "" {
+				plan.upserts = append(plan.upserts, syncer.BlockRef{LocalID: m.NodeUID, ID: r.ID, ParentID: r.ParentID, ETag: r.ETag})
+				plan.bases = append(plan.bases, remoteBlock)
+				continue
+			}
+
+			// 旧映射没有基线时视为本地未修改，直接采用外部内容
+			base := decodeBaseBlock(m.BaseBlock)
+			if base == nil {
+				base = &current
+			}
+			final, conflict := syncer.MergeBlock(base, current, remoteBlock)
+			if conflict {
+				switch link.ConflictPolicy {
+				case model.ConflictManual:
+					// 映射保持不变，推送会跳过该块，下次拉取时刷新冲突内容
+					plan.conflicts = append(plan.conflicts, newSyncConflict(link, m, base, &current, &remoteBlock, r.ETag))
+					continue
+				case model.ConflictLocalWins:
+					final = current
+				case model.ConflictRemoteWins:
+					final = remoteBlock
+				default:
+					// latest：平台无法提供块修改时间时以外部为准
+					final = remoteBlock
+					if !r.EditedAt.IsZero() && localEditedAt.After(r.EditedAt) {
+						final = current
+					}
+				}
+			}
+			final.ID = m.NodeUID
+			final.Children = nil
+			if !syncer.SameBlock(final, current) {
+				block := final
+				plan.ops = append(plan.ops, dto.PatchOp{Op: dto.PatchOpUpdate, NodeUID: m.NodeUID, Block: &block})
+			}
+			if canPush && !syncer.SameBlock(final, remoteBlock) {
+				block := final
+				plan.pushBack = append(plan.pushBack, dto.PatchOp{Op: dto.PatchOpUpdate, NodeUID: m.NodeUID, Block: &block})
+			}
+			plan.upserts = append(plan.upserts, syncer.BlockRef{LocalID: m.NodeUID, ID: r.ID, ParentID: r.ParentID, ETag: r.ETag})
+			plan.bases = append(plan.bases, final)
+		case mapped:
+			// 本地已删除的块不再恢复
+		case converted[i] != nil:
+			block := *converted[i]
+			if _, taken := localByID[block.ID]; block.ID == "" || taken || inserted[block.ID] != nil {
+				block.ID = uuid.NewString()
+			}
+			inserted[block.ID] = &block
+			ordered = append(ordered, block.ID)
+			plan.upserts = append(plan.upserts, syncer.BlockRef{LocalID: block.ID, ID: r.ID, ParentID: r.ParentID, ETag: r.ETag})
+			plan.bases = append(plan.bases, block)
+		}
+	}
+
+	// 外部删除的块：本地未修改时跟随删除；本地修改过时按冲突策略处理，外部删除没有时间，latest 保留本地修改
+	keep := make(map[string]bool)
+	for _, m := range mappings {
+		if remoteIDs[m.ExternalBlockID] {
+			continue
+		}
+		current, exists := localByID[m.NodeUID]
+		if !exists {
+			plan.removed = append(plan.removed, m.ExternalBlockID)
+			continue
+		}
+		base := decodeBaseBlock(m.BaseBlock)
+		if base == nil || syncer.SameBlock(*base, current) || link.ConflictPolicy == model.ConflictRemoteWins {
+			plan.removed = append(plan.removed, m.ExternalBlockID)
+			plan.ops = append(plan.ops, dto.PatchOp{Op: dto.PatchOpDelete, NodeUID: m.NodeUID})
+			continue
+		}
+		if link.ConflictPolicy == model.ConflictManual {
+			plan.conflicts = append(plan.conflicts, newSyncConflict(link, m, base, &current, nil, ""))
+			keep[m.NodeUID] = true
+			continue
+		}
+		// 保留的块作为本地新块重新推送到外部
+		plan.removed = append(plan.removed, m.ExternalBlockID)
+		keep[m.NodeUID] = true
+		if canPush {
+			block := current
+			plan.pushBack = append(plan.pushBack, dto.PatchOp{Op: dto.PatchOpInsert, NodeUID: m.NodeUID, Block: &block})
+		}
+	}
+
+	// 没有映射的本地块跟随它在本地的前一个块
+	anchored := make(map[string][]string)
+	anchor := ""
+	inOrdered := make(map[string]bool, len(ordered))
+	for _, id := range ordered {
+		inOrdered[id] = true
+	}
+	for _, b := range local {
+		switch {
+		case inOrdered[b.ID]:
+			anchor = b.ID
+		case !mappedLocal[b.ID] || keep[b.ID]:
+			anchored[anchor] = append(anchored[anchor], b.ID)
+		}
+	}
+	final := append([]string{}, anchored[""]...)
+	for _, id := range ordered {
+		final = append(final, id)
+		final = append(final, anchored[id]...)
+	}
+
+	// 依次把每个块放到前一个块之后；位置未变的 move 会被 ApplyPatch 剔除
+	position := make(map[string]*string, len(final))
+	for i, id := range final {
+		var after *string
+		if i > 0 {
+			prev := final[i-1]
+			after = &prev
+		}
+		position[id] = after
+		if block, ok := inserted[id]; ok {
+			plan.ops = append(plan.ops, dto.PatchOp{Op: dto.PatchOpInsert, NodeUID: id, Block: block, AfterID: after})
+			continue
+		}
+		plan.ops = append(plan.ops, dto.PatchOp{Op: dto.PatchOpMove, NodeUID: id, AfterID: after})
+	}
+	// 回推的插入落在它在本地的前一个块之后
+	for i := range plan.pushBack {
+		if plan.pushBack[i].Op == dto.PatchOpInsert {
+			plan.pushBack[i].AfterID = position[plan.pushBack[i].NodeUID]
+		}
+	}
+	return plan
+}
+
+func decodeBaseBlock(raw datatypes.JSON) *dto.NoteBlockDTO {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var block dto.NoteBlockDTO
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil
+	}
+	return &block
+}
+
+func newSyncConflict(link *model.NoteExternalLink, m model.NoteExternalNodeMapping, base, local, remote *dto.NoteBlockDTO, remoteETag string) model.NoteSyncConflict {
+	conflict := model.NoteSyncConflict{
+		NoteID:          link.NoteID,
+		LinkID:          link.ID,
+		Provider:        link.Provider,
+		NodeUID:         m.NodeUID,
+		ExternalBlockID: m.ExternalBlockID,
+		RemoteETag:      remoteETag,
+	}
+	if base != nil {
+		conflict.BaseBlock = baseBlockJSON(*base)
+	}
+	if local != nil {
+		conflict.LocalBlock = baseBlockJSON(*local)
+	}
+	if remote != nil {
+		conflict.RemoteBlock = baseBlockJSON(*remote)
+	}
+	return conflict
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/tasks/asynq/types"
+	"testing"
+
+	"github.com/hibiken/asynq"
+)
+
+// pullModels 拉取写回时派生数据（出链、评论锚点、清单任务）与冲突记录用到的表
+var pullModels = []any{
+	&model.WorkspaceMember{}, &model.User{}, &model.NoteSyncConflict{}, &model.NoteLink{},
+	&model.NoteComment{}, &model.NoteChecklistTask{}, &model.ToDoTask{},
+}
+
+func runPull(t *testing.T, linkID int64) {
+	t.Helper()
+	payload, _ := json.Marshal(types.SyncPullPayload{LinkID: linkID})
+	if err := HandleSyncPull(context.Background(), asynq.NewTask(types.SyncPullKey, payload)); err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+}
+
+func noteTexts(t *testing.T) []string {
+	t.Helper()
+	var note model.Note
+	if err := database.DB.First(&note, testNoteID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var blocks dto.Blocks
+	if err := json.Unmarshal(note.Content, &blocks); err != nil {
+		t.Fatal(err)
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		texts = append(texts, b.Content[0].Text)
+	}
+	return texts
+}
+
+// setupDivergedBlock 初始化同步后让本地与外部同时修改同一个块
+func setupDivergedBlock(t *testing.T, policy model.ConflictPolicy) *model.NoteExternalLink {
+	t.Helper()
+	fake := setupSyncTest(t, pullModels...)
+	testutil.UseDispatcher(t)
+	member := model.WorkspaceMember{WorkspaceID: testWorkspaceID, UserID: testUserID, Nickname: "alice", Role: []byte(`["admin"]`)}
+	member.ID = testMemberID
+	if err := database.DB.Create(&member).Error; err != nil {
+		t.Fatal(err)
+	}
+	seedNote(t, 1, dto.Blocks{paragraph("a", "base")})
+	link := seedLink(t)
+	if err := database.DB.Model(link).Update("conflict_policy", policy).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := runInit(link.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var mapping model.NoteExternalNodeMapping
+	if err := database.DB.Where("note_id = ? AND node_uid = ?", testNoteID, "a").First(&mapping).Error; err != nil {
+		t.Fatal(err)
+	}
+	fake.Edit(testDocID, mapping.ExternalBlockID, paragraph("a", "remote"))
+	// 本地修改不推进版本，拉取时视为已与链接基线对齐
+	content, _ := json.Marshal(dto.Blocks{paragraph("a", "local")})
+	if err := database.DB.Model(&model.Note{}).Where("id = ?", testNoteID).Update("content", content).Error; err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+func TestHandleSyncPullConflictPolicies(t *testing.T) {
+	cases := []struct {
+		policy   model.ConflictPolicy
+		want     string
+		pushBack bool // 结果与外部不一致时回推外部
+	}{
+		{model.ConflictLocalWins, "local", true},
+		{model.ConflictRemoteWins, "remote", false},
+		{model.ConflictLatest, "remote", false}, // 外部块没有修改时间时以外部为准
+		{model.ConflictManual, "local", false},
+	}
+	for _, c := range cases {
+		t.Run(string(c.policy), func(t *testing.T) {
+			link := setupDivergedBlock(t, c.policy)
+			runPull(t, link.ID)
+
+			if got := noteTexts(t); !equalTexts(got, c.want) {
+				t.Fatalf("note blocks = %v, want %s", got, c.want)
+			}
+			var outbox int64
+			database.DB.Model(&model.SyncOutbox{}).Where("link_id = ? AND status = ?", link.ID, model.SyncPending).Count(&outbox)
+			if (outbox > 0) != c.pushBack {
+				t.Fatalf("pending push-back outbox = %d", outbox)
+			}
+
+			var conflicts []model.NoteSyncConflict
+			if err := database.DB.Where("link_id = ?", link.ID).Find(&conflicts).Error; err != nil {
+				t.Fatal(err)
+			}
+			if c.policy != model.ConflictManual {
+				if len(conflicts) != 0 {
+					t.Fatalf("%s recorded %d conflicts", c.policy, len(conflicts))
+				}
+				return
+			}
+			if len(conflicts) != 1 || conflicts[0].NodeUID != "a" || conflicts[0].Status != model.ConflictOpen {
+				t.Fatalf("conflicts = %+v", conflicts)
+			}
+			var local, remote dto.NoteBlockDTO
+			_ = json.Unmarshal(conflicts[0].LocalBlock, &local)
+			_ = json.Unmarshal(conflicts[0].RemoteBlock, &remote)
+			if local.Content[0].Text != "local" || remote.Content[0].Text != "remote" {
+				t.Fatalf("conflict blocks local=%+v remote=%+v", local, remote)
+			}
+		})
+	}
+}
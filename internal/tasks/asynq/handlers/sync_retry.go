@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// running 超过该时长视为 worker 已退出
+	outboxRunningTimeout = 10 * time.Minute
+	// 没有退避时间的 pending 超过该时长仍未处理，视为推送任务丢失
+	outboxPendingTimeout = 2 * time.Minute
+)
+
+// HandleSyncRetryScan 回收卡住的 outbox，并为退避到期的链接重新投递推送任务
+func HandleSyncRetryScan(ctx context.Context, t *asynq.Task) error {
+	syncRepo := repository.NewSyncRepository(database.DB)
+	now := time.Now()
+
+	reclaimed, err := syncRepo.ReclaimStaleSyncOutbox(ctx, now.Add(-outboxRunningTimeout))
+	if err != nil {
+		logger.LogError(err, "[sync.retry] 回收超时 outbox 失败")
+		return err
+	}
+	if reclaimed > 0 {
+		logger.LogInfo(fmt.Sprintf("[sync.retry] 回收 %d 条超时的 outbox", reclaimed))
+	}
+
+	targets, err := syncRepo.GetRetryableSyncTargets(ctx, now, now.Add(-outboxPendingTimeout))
+	if err != nil {
+		logger.LogError(err, "[sync.retry] 获取待重试的链接失败")
+		return err
+	}
+	for _, target := range targets {
+		if _, err := enqueue.SyncDelta(ctx, types.SyncDeltaPayload{
+			LinkID:      target.LinkID,
+			NoteID:      target.NoteID,
+			WorkspaceID: target.WorkspaceID,
+			UserID:      target.UserID,
+			MemberID:    target.MemberID,
+		}); err != nil {
+			logger.LogInfo(fmt.Sprintf("[sync.retry] 投递推送任务跳过 link=%d: %v", target.LinkID, err))
+		}
+	}
+	return nil
+}
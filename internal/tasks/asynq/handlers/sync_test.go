@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/cache"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/dto"
+	"gin-notebook/internal/pkg/integration/syncer/syncertest"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/tasks/asynq/types"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	testWorkspaceID int64 = 10
+	testUserID      int64 = 20
+	testMemberID    int64 = 30
+	testNoteID      int64 = 40
+	testDocID             = "doc-1"
+)
+
+// setupSyncTest 用 sqlite 与 miniredis 替换全局 DB/Redis，并注册内存同步平台；extra 为用例额外需要的表
+func setupSyncTest(t *testing.T, extra ...any) *syncertest.FakeProvider {
+	t.Helper()
+	db := testutil.UseDB(t, append([]any{&model.Note{}, &model.NoteExternalLink{}, &model.SyncOutbox{},
+		&model.NoteExternalNodeMapping{}, &model.IntegrationAccount{}}, extra...)...)
+	testutil.UseRedis(t)
+
+	if err := db.Create(&model.IntegrationAccount{
+		UserID:         testUserID,
+		Provider:       syncertest.ProviderFake,
+		AccessTokenEnc: "token",
+		IsActive:       true,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return syncertest.RegisterFake(syncertest.NewFakeProvider())
+}
+
+func paragraph(id, text string) dto.NoteBlockDTO {
+	return dto.NoteBlockDTO{ID: id, Type: "paragraph", Content: []dto.InlineDTO{{Type: "text", Text: text}}}
+}
+
+func seedNote(t *testing.T, version int64, blocks dto.Blocks) {
+	t.Helper()
+	content, _ := json.Marshal(blocks)
+	note := model.Note{Title: "sync", Content: content, WorkspaceID: testWorkspaceID, OwnerID: testUserID, Version: version}
+	note.ID = testNoteID
+	if err := database.DB.Create(&note).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func seedLink(t *testing.T) *model.NoteExternalLink {
+	t.Helper()
+	link := model.NoteExternalLink{
+		NoteID:       testNoteID,
+		Provider:     syncertest.ProviderFake,
+		TargetNoteID: testDocID,
+		MemberID:     testMemberID,
+		Direction:    model.SyncTwoWay,
+		InitStatus:   model.InitPending,
+		IsActive:     true,
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &link
+}
+
+func seedOutbox(t *testing.T, linkID, version int64, ops ...dto.PatchOp) *model.SyncOutbox {
+	t.Helper()
+	patch, _ := json.Marshal(ops)
+	ob := model.SyncOutbox{LinkID: linkID, NoteID: testNoteID, NoteVersion: version, OpType: "patch", PatchJSON: patch, Status: model.SyncPending}
+	if err := database.DB.Create(&ob).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &ob
+}
+
+// bumpNote 模拟本地保存：写入新内容并推进版本
+func bumpNote(t *testing.T, version int64, blocks dto.Blocks) {
+	t.Helper()
+	content, _ := json.Marshal(blocks)
+	if err := database.DB.Model(&model.Note{}).Where("id = ?", testNoteID).
+		Updates(map[string]interface{}{"content": content, "version": version}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runInit(linkID int64) error {
+	payload, _ := json.Marshal(types.SyncNotePayload{
+		NoteID: testNoteID, MemberID: testMemberID, UserID: testUserID, WorkspaceID: testWorkspaceID,
+		TargetNoteID: testDocID, LinkID: linkID,
+	})
+	return HandleInitSyncNote(context.Background(), asynq.NewTask(types.InitSyncNoteKey, payload))
+}
+
+func runDelta(linkID int64) error {
+	payload, _ := json.Marshal(types.SyncDeltaPayload{
+		LinkID: linkID, NoteID: testNoteID, WorkspaceID: testWorkspaceID, UserID: testUserID, MemberID: testMemberID,
+	})
+	return HandleSyncDelta(context.Background(), asynq.NewTask(types.SyncDeltaKey, payload))
+}
+
+func loadLink(t *testing.T, id int64) model.NoteExternalLink {
+	t.Helper()
+	var link model.NoteExternalLink
+	if err := database.DB.First(&link, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return link
+}
+
+func loadOutbox(t *testing.T, id int64) model.SyncOutbox {
+	t.Helper()
+	var ob model.SyncOutbox
+	if err := database.DB.First(&ob, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return ob
+}
+
+func snapshotTexts(fake *syncertest.FakeProvider) []string {
+	texts := make([]string, 0)
+	for _, b := range fake.Snapshot(testDocID) {
+		texts = append(texts, b.Content[0].Text)
+	}
+	return texts
+}
+
+func equalTexts(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestHandleInitSyncNote(t *testing.T) {
+	fake := setupSyncTest(t)
+	seedNote(t, 3, dto.Blocks{paragraph("a", "alpha"), paragraph("b", "beta")})
+	link := seedLink(t)
+
+	fake.FailNext("create", errors.New("remote unavailable"))
+	if err := runInit(link.ID); err == nil {
+		t.Fatal("init should fail when the remote rejects the blocks")
+	}
+	failed := loadLink(t, link.ID)
+	if failed.InitStatus != model.InitFailed || failed.FailStreak != 1 || failed.ErrorCount != 1 {
+		t.Fatalf("failed init: status=%s streak=%d errors=%d", failed.InitStatus, failed.FailStreak, failed.ErrorCount)
+	}
+	if v, _ := cache.RedisInstance.Get(fmt.Sprintf("sync:ready:%d", link.ID)); v == "1" {
+		t.Fatal("ready latch must not be set after a failed init")
+	}
+
+	// 健康面板的重试会把失败的初始化退回 pending
+	if err := database.DB.Model(&model.NoteExternalLink{}).Where("id = ?", link.ID).
+		Update("init_status", model.InitPending).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := runInit(link.ID); err != nil {
+		t.Fatalf("retry init: %v", err)
+	}
+
+	ready := loadLink(t, link.ID)
+	if ready.InitStatus != model.InitReady || ready.ContentVersion != 3 || ready.FailStreak != 0 {
+		t.Fatalf("ready init: status=%s version=%d streak=%d", ready.InitStatus, ready.ContentVersion, ready.FailStreak)
+	}
+	if got := snapshotTexts(fake); !equalTexts(got, "alpha", "beta") {
+		t.Fatalf("remote blocks = %v", got)
+	}
+	var mappings int64
+	database.DB.Model(&model.NoteExternalNodeMapping{}).Where("note_id = ?", testNoteID).Count(&mappings)
+	if mappings != 2 {
+		t.Fatalf("mappings = %d, want 2", mappings)
+	}
+	if v, _ := cache.RedisInstance.Get(fmt.Sprintf("sync:ready:%d", link.ID)); v != "1" {
+		t.Fatal("ready latch should be set after init")
+	}
+}
+
+func TestHandleSyncDeltaBacksOffFailedOutbox(t *testing.T) {
+	fake := setupSyncTest(t)
+	seedNote(t, 1, dto.Blocks{paragraph("a", "alpha")})
+	link := seedLink(t)
+	if err := runInit(link.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := paragraph("a", "alpha v2")
+	bumpNote(t, 2, dto.Blocks{edited})
+	ob := seedOutbox(t, link.ID, 2, dto.PatchOp{Op: "update", NodeUID: "a", Block: &edited})
+
+	fake.FailNext("fetch", errors.New("remote timeout"))
+	before := time.Now()
+	if err := runDelta(link.ID); err != nil {
+		t.Fatalf("failed push should be retried by the outbox, not the queue: %v", err)
+	}
+	failed := loadOutbox(t, ob.ID)
+	if failed.Status != model.SyncPending || failed.Attempts != 1 || failed.NextRetryAt == nil {
+		t.Fatalf("failed outbox: status=%s attempts=%d next=%v", failed.Status, failed.Attempts, failed.NextRetryAt)
+	}
+	if wait := failed.NextRetryAt.Sub(before); wait < outboxBackoffBase || wait > outboxBackoffBase+time.Minute {
+		t.Fatalf("backoff = %s, want about %s", wait, outboxBackoffBase)
+	}
+	if streak := loadLink(t, link.ID).FailStreak; streak != 1 {
+		t.Fatalf("fail_streak = %d, want 1", streak)
+	}
+
+	// 退避未到期时不会再次推送
+	fetches := fake.Calls("fetch")
+	if err := runDelta(link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if fake.Calls("fetch") != fetches || loadOutbox(t, ob.ID).Status != model.SyncPending {
+		t.Fatal("outbox in backoff must not be picked up")
+	}
+
+	// 到期后重试成功，基线推进，失败计数清零
+	database.DB.Model(&model.SyncOutbox{}).Where("id = ?", ob.ID).Update("next_retry_at", time.Now().Add(-time.Second))
+	if err := runDelta(link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if status := loadOutbox(t, ob.ID).Status; status != model.SyncSuccess {
+		t.Fatalf("retried outbox status = %s", status)
+	}
+	done := loadLink(t, link.ID)
+	if done.ContentVersion != 2 || done.FailStreak != 0 {
+		t.Fatalf("link after retry: version=%d streak=%d", done.ContentVersion, done.FailStreak)
+	}
+	if got := snapshotTexts(fake); !equalTexts(got, "alpha v2") {
+		t.Fatalf("remote blocks = %v", got)
+	}
+}
+
+func TestHandleSyncDeltaDeadLettersAfterMaxAttempts(t *testing.T) {
+	fake := setupSyncTest(t)
+	seedNote(t, 1, dto.Blocks{paragraph("a", "alpha")})
+	link := seedLink(t)
+	if err := runInit(link.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := paragraph("a", "alpha v2")
+	bumpNote(t, 2, dto.Blocks{edited})
+	ob := seedOutbox(t, link.ID, 2, dto.PatchOp{Op: "update", NodeUID: "a", Block: &edited})
+	database.DB.Model(&model.SyncOutbox{}).Where("id = ?", ob.ID).Update("attempts", maxOutboxAttempts-1)
+
+	fake.FailNext("update", errors.New("remote rejected"))
+	if err := runDelta(link.ID); err != nil {
+		t.Fatal(err)
+	}
+	dead := loadOutbox(t, ob.ID)
+	if dead.Status != model.SyncDead || dead.Attempts != maxOutboxAttempts || dead.NextRetryAt != nil {
+		t.Fatalf("dead outbox: status=%s attempts=%d next=%v", dead.Status, dead.Attempts, dead.NextRetryAt)
+	}
+	if dead.LastError == nil || *dead.LastError != "remote rejected" {
+		t.Fatalf("last_error = %v", dead.LastError)
+	}
+}
+
+func TestHandleSyncDeltaSkipsStaleOutbox(t *testing.T) {
+	fake := setupSyncTest(t)
+	seedNote(t, 2, dto.Blocks{paragraph("a", "alpha")})
+	link := seedLink(t)
+
+	// 初始化前排队的 outbox 已包含在初始化的整篇快照里
+	first, second := paragraph("a", "alpha v1"), paragraph("a", "alpha v2")
+	stale1 := seedOutbox(t, link.ID, 1, dto.PatchOp{Op: "update", NodeUID: "a", Block: &first})
+	stale2 := seedOutbox(t, link.ID, 2, dto.PatchOp{Op: "update", NodeUID: "a", Block: &second})
+	if err := runInit(link.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	third := paragraph("a", "alpha v3")
+	bumpNote(t, 3, dto.Blocks{third})
+	fresh := seedOutbox(t, link.ID, 3, dto.PatchOp{Op: "update", NodeUID: "a", Block: &third})
+	updates := fake.Calls("update")
+
+	if err := runDelta(link.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{stale1.ID, stale2.ID} {
+		if ob := loadOutbox(t, id); ob.Status != model.SyncSkipped || ob.NextRetryAt != nil {
+			t.Fatalf("stale outbox %d: status=%s next=%v", id, ob.Status, ob.NextRetryAt)
+		}
+	}
+	if status := loadOutbox(t, fresh.ID).Status; status != model.SyncSuccess {
+		t.Fatalf("fresh outbox status = %s", status)
+	}
+	if fake.Calls("update") != updates+1 {
+		t.Fatalf("stale outboxes must not be pushed, update calls = %d", fake.Calls("update")-updates)
+	}
+	if got := snapshotTexts(fake); !equalTexts(got, "alpha v3") {
+		t.Fatalf("remote blocks = %v", got)
+	}
+	if v := loadLink(t, link.ID).ContentVersion; v != 3 {
+		t.Fatalf("content_version = %d, want 3", v)
+	}
+}
+
+func TestHandleSyncDeltaBacksOffWhenIntegrationUnavailable(t *testing.T) {
+	fake := setupSyncTest(t)
+	seedNote(t, 1, dto.Blocks{paragraph("a", "alpha")})
+	link := seedLink(t)
+	if err := runInit(link.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := paragraph("a", "alpha v2")
+	bumpNote(t, 2, dto.Blocks{edited})
+	ob := seedOutbox(t, link.ID, 2, dto.PatchOp{Op: "update", NodeUID: "a", Block: &edited})
+
+	// 账户被停用：失败记在队首 outbox 上按退避重试，任务本身不再重试
+	database.DB.Model(&model.IntegrationAccount{}).Where("user_id = ?", testUserID).Update("is_active", false)
+	before := time.Now()
+	if err := runDelta(link.ID); err != nil {
+		t.Fatalf("unavailable integration should back off the outbox, not retry the task: %v", err)
+	}
+	failed := loadOutbox(t, ob.ID)
+	if failed.Status != model.SyncPending || failed.Attempts != 1 || failed.NextRetryAt == nil || failed.LastError == nil {
+		t.Fatalf("failed outbox: status=%s attempts=%d next=%v", failed.Status, failed.Attempts, failed.NextRetryAt)
+	}
+	if wait := failed.NextRetryAt.Sub(before); wait < outboxBackoffBase || wait > outboxBackoffBase+time.Minute {
+		t.Fatalf("backoff = %s, want about %s", wait, outboxBackoffBase)
+	}
+	if streak := loadLink(t, link.ID).FailStreak; streak != 1 {
+		t.Fatalf("fail_streak = %d, want 1", streak)
+	}
+
+	// 账户恢复且退避到期后正常推送
+	database.DB.Model(&model.IntegrationAccount{}).Where("user_id = ?", testUserID).Update("is_active", true)
+	database.DB.Model(&model.SyncOutbox{}).Where("id = ?", ob.ID).Update("next_retry_at", time.Now().Add(-time.Second))
+	if err := runDelta(link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if status := loadOutbox(t, ob.ID).Status; status != model.SyncSuccess {
+		t.Fatalf("retried outbox status = %s", status)
+	}
+	if got := snapshotTexts(fake); !equalTexts(got, "alpha v2") {
+		t.Fatalf("remote blocks = %v", got)
+	}
+}
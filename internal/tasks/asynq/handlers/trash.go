@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gin-notebook/configs"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/thirdparty/qiniu"
+	"gin-notebook/pkg/logger"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// 绑定到 mux.HandleFunc(types.TypeTrashPurge, HandleTrashPurge)
+// 彻底删除超过保留期的笔记与任务，每批一个事务，直到没有过期数据
+func HandleTrashPurge(ctx context.Context, t *asynq.Task) error {
+	before := time.Now().Add(-configs.Configs.TrashRetention())
+	batch := configs.Configs.TrashPurgeBatch()
+
+	trashRepo := repository.NewTrashRepository(database.DB)
+	var purgedNotes, purgedTasks int
+
+	for {
+		noteIDs, err := trashRepo.GetExpiredNoteIDs(ctx, before, batch)
+		if err != nil {
+			return err
+		}
+		taskIDs, err := trashRepo.GetExpiredTaskIDs(ctx, before, batch)
+		if err != nil {
+			return err
+		}
+		if len(noteIDs) == 0 && len(taskIDs) == 0 {
+			break
+		}
+
+		var files []string
+		err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txRepo := repository.NewTrashRepository(tx)
+			noteFiles, err := txRepo.PurgeNotes(ctx, noteIDs)
+			if err != nil {
+				return err
+			}
+			taskFiles, err := txRepo.PurgeTasks(ctx, taskIDs)
+			if err != nil {
+				return err
+			}
+			files = append(noteFiles, taskFiles...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if failed := qiniu.DeleteByURLs(ctx, files); failed > 0 {
+			logger.LogError(fmt.Errorf("%d files not deleted", failed), "回收站附件删除失败")
+		}
+
+		purgedNotes += len(noteIDs)
+		purgedTasks += len(taskIDs)
+		if len(noteIDs) < batch && len(taskIDs) < batch {
+			break
+		}
+	}
+
+	if purgedNotes > 0 || purgedTasks > 0 {
+		logger.LogInfo("trash purged", map[string]interface{}{
+			"notes": purgedNotes,
+			"tasks": purgedTasks,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/testutil"
+	"gin-notebook/internal/tasks/asynq/types"
+	"testing"
+	"time"
+)
+
+// purgeModels 彻底删除笔记与任务时涉及的表
+var purgeModels = []interface{}{
+	&model.Note{}, &model.NoteComment{}, &model.NoteCommentMention{}, &model.NoteCommentAttachment{},
+	&model.NoteLink{}, &model.NoteShare{}, &model.NotePermission{}, &model.FavoriteNote{}, &model.DailyNote{},
+	&model.NoteChecklistTask{}, &model.NoteViewStat{}, &model.NoteViewDaily{},
+	&model.NoteExternalLink{}, &model.NoteExternalNodeMapping{}, &model.NoteSyncConflict{}, &model.SyncOutbox{},
+	&model.Document{}, &model.Chunk{},
+	&model.ToDoTask{}, &model.ToDoTaskComment{}, &model.ToDoCommentMention{}, &model.ToDoCommentAttachment{},
+	&model.ToDoCommentLike{}, &model.ToDoTaskAssignee{},
+}
+
+func seedRows(t *testing.T, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := database.DB.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// trashAt 把记录标记为在 at 时删除
+func trashAt(t *testing.T, m interface{}, id int64, at time.Time) {
+	t.Helper()
+	if err := database.DB.Model(m).Where("id = ?", id).Update("deleted_at", at).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countRows(t *testing.T, m interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := database.DB.Unscoped().Model(m).Where(query, args...).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func runTrashPurge(t *testing.T) {
+	t.Helper()
+	if err := HandleTrashPurge(context.Background(), types.NewTrashPurgeTask()); err != nil {
+		t.Fatalf("trash purge: %v", err)
+	}
+}
+
+func TestHandleTrashPurgeNotes(t *testing.T) {
+	testutil.UseDB(t, purgeModels...)
+	expired := time.Now().Add(-60 * 24 * time.Hour)
+
+	note := model.Note{Title: "old", Content: []byte(`[]`), WorkspaceID: testWorkspaceID, OwnerID: testUserID}
+	note.ID = testNoteID
+	kept := model.Note{Title: "recent", Content: []byte(`[]`), WorkspaceID: testWorkspaceID, OwnerID: testUserID}
+	kept.ID = testNoteID + 1
+	seedRows(t, &note, &kept)
+
+	comment := model.NoteComment{NoteID: note.ID, WorkspaceID: testWorkspaceID, MemberID: testMemberID, Content: "hi"}
+	seedRows(t, &comment)
+	link := model.NoteExternalLink{NoteID: note.ID, Provider: "fake", TargetNoteID: testDocID, MemberID: testMemberID, IsActive: true}
+	seedRows(t, &link)
+	doc := model.Document{WorkspaceID: testWorkspaceID, OwnerUserID: testUserID, ExternalID: "note:40", ContentHash: "h-40"}
+	seedRows(t, &doc)
+	keptDoc := model.Document{WorkspaceID: testWorkspaceID + 1, OwnerUserID: testUserID, ExternalID: "note:41", ContentHash: "h-41"}
+	seedRows(t, &keptDoc)
+	seedRows(t,
+		&model.NoteCommentMention{CommentID: comment.ID, MemberID: testMemberID, StartRune: 0, EndRune: 2},
+		&model.NoteCommentAttachment{CommentID: comment.ID, FileName: "a.png", FileURL: "https://cdn.example.com/a.png", FileSize: 1, FileType: "image/png", UploaderID: testMemberID},
+		&model.NoteLink{WorkspaceID: testWorkspaceID, SourceNoteID: note.ID, TargetType: model.NoteLinkTargetNote, TargetID: kept.ID},
+		&model.NoteLink{WorkspaceID: testWorkspaceID, SourceNoteID: kept.ID, TargetType: model.NoteLinkTargetNote, TargetID: note.ID},
+		&model.NoteExternalNodeMapping{NoteID: note.ID, Provider: "fake", NodeUID: "a", ExternalDocID: testDocID, ExternalBlockID: "r-a"},
+		&model.SyncOutbox{NoteID: note.ID, LinkID: link.ID, OpType: "delta", PatchJSON: []byte(`[]`)},
+		&model.Chunk{DocumentID: doc.ID, WorkspaceID: testWorkspaceID, OwnerUserID: testUserID, Idx: 0, Text: "old"},
+		&model.Chunk{DocumentID: keptDoc.ID, WorkspaceID: testWorkspaceID, OwnerUserID: testUserID, Idx: 0, Text: "recent"},
+	)
+	trashAt(t, &model.Note{}, note.ID, expired)
+	trashAt(t, &model.Note{}, kept.ID, time.Now())
+
+	runTrashPurge(t)
+
+	for _, c := range []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&model.Note{}, "id = ?", []interface{}{note.ID}},
+		{&model.NoteComment{}, "note_id = ?", []interface{}{note.ID}},
+		{&model.NoteCommentMention{}, "comment_id = ?", []interface{}{comment.ID}},
+		{&model.NoteCommentAttachment{}, "comment_id = ?", []interface{}{comment.ID}},
+		{&model.NoteLink{}, "1 = 1", nil},
+		{&model.NoteExternalLink{}, "note_id = ?", []interface{}{note.ID}},
+		{&model.NoteExternalNodeMapping{}, "note_id = ?", []interface{}{note.ID}},
+		{&model.SyncOutbox{}, "note_id = ?", []interface{}{note.ID}},
+		{&model.Document{}, "id = ?", []interface{}{doc.ID}},
+		{&model.Chunk{}, "document_id = ?", []interface{}{doc.ID}},
+	} {
+		if n := countRows(t, c.model, c.query, c.args...); n != 0 {
+			t.Fatalf("%T: %d rows left after purge", c.model, n)
+		}
+	}
+	// 未过保留期的笔记与其 rag 数据保留
+	if countRows(t, &model.Note{}, "id = ?", kept.ID) != 1 || countRows(t, &model.Chunk{}, "document_id = ?", keptDoc.ID) != 1 {
+		t.Fatal("recently trashed note was purged")
+	}
+}
+
+func TestHandleTrashPurgeTasks(t *testing.T) {
+	testutil.UseDB(t, purgeModels...)
+	expired := time.Now().Add(-60 * 24 * time.Hour)
+
+	task := model.ToDoTask{ProjectID: 50, ColumnID: 51, Title: "old", Creator: testUserID, OrderIndex: "0|hzzzzz:", Description: []byte(`[]`)}
+	kept := model.ToDoTask{ProjectID: 50, ColumnID: 51, Title: "recent", Creator: testUserID, OrderIndex: "0|i00000:", Description: []byte(`[]`)}
+	seedRows(t, &task, &kept)
+	comment := model.ToDoTaskComment{ToDoTaskID: task.ID, MemberID: testMemberID, Content: "hi"}
+	seedRows(t, &comment)
+	seedRows(t,
+		&model.ToDoTaskAssignee{ToDoTaskID: task.ID, AssigneeID: testMemberID},
+		&model.ToDoCommentAttachment{CommentID: comment.ID, FileName: "a.png", FileURL: "https://cdn.example.com/a.png", FileSize: 1, FileType: "image/png", UploaderID: testMemberID, SHA256Hash: "x"},
+		&model.NoteLink{WorkspaceID: testWorkspaceID, SourceNoteID: testNoteID, TargetType: model.NoteLinkTargetTask, TargetID: task.ID},
+		&model.NoteChecklistTask{WorkspaceID: testWorkspaceID, NoteID: testNoteID, BlockID: "c", TaskID: task.ID},
+	)
+	trashAt(t, &model.ToDoTask{}, task.ID, expired)
+	trashAt(t, &model.ToDoTask{}, kept.ID, time.Now())
+
+	runTrashPurge(t)
+
+	for _, c := range []struct {
+		model interface{}
+		query string
+		arg   int64
+	}{
+		{&model.ToDoTask{}, "id = ?", task.ID},
+		{&model.ToDoTaskComment{}, "to_do_task_id = ?", task.ID},
+		{&model.ToDoTaskAssignee{}, "to_do_task_id = ?", task.ID},
+		{&model.ToDoCommentAttachment{}, "comment_id = ?", comment.ID},
+		{&model.NoteLink{}, "target_id = ?", task.ID},
+		{&model.NoteChecklistTask{}, "task_id = ?", task.ID},
+	} {
+		if n := countRows(t, c.model, c.query, c.arg); n != 0 {
+			t.Fatalf("%T: %d rows left after purge", c.model, n)
+		}
+	}
+	if countRows(t, &model.ToDoTask{}, "id = ?", kept.ID) != 1 {
+		t.Fatal("recently trashed task was purged")
+	}
+}
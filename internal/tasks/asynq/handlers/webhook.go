@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/database"
+	"gin-notebook/internal/pkg/webhook"
+	"gin-notebook/internal/repository"
+	"gin-notebook/internal/tasks/asynq/enqueue"
+	"gin-notebook/internal/tasks/asynq/types"
+	"gin-notebook/pkg/logger"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// 绑定到 mux.HandleFunc(types.WebhookDispatchKey, HandleWebhookDispatch)
+// 按事件类型与项目匹配工作区启用的 webhook，每个命中的 webhook 生成一条投递记录并单独投递
+func HandleWebhookDispatch(ctx context.Context, t *asynq.Task) error {
+	var p types.WebhookDispatchPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+
+	repo := repository.NewWebhookRepository(database.DB)
+	subs, err := repo.GetActiveSubscriptions(ctx, p.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	env := webhook.Envelope{
+		ID:          p.EventID,
+		Event:       p.Event,
+		WorkspaceID: strconv.FormatInt(p.WorkspaceID, 10),
+		OccurredAt:  p.OccurredAt,
+		Data:        p.Data,
+	}
+	if p.ProjectID != 0 {
+		env.ProjectID = strconv.FormatInt(p.ProjectID, 10)
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for i := range subs {
+		if !subs[i].Matches(model.WebhookEvent(p.Event), p.ProjectID) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: subs[i].ID,
+			EventID:        p.EventID,
+			Event:          model.WebhookEvent(p.Event),
+			Payload:        body,
+			Status:         model.DeliveryPending,
+		})
+	}
+	if err := repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+
+	// 投递记录已落库，入队失败不再重试整个分发，避免重复投递
+	for _, d := range deliveries {
+		if _, err := enqueue.WebhookDeliver(ctx, types.WebhookDeliverPayload{DeliveryID: d.ID}); err != nil {
+			logger.LogError(err, "webhook 投递任务入队失败 delivery=", d.ID)
+			errMsg := err.Error()
+			_ = repo.UpdateDelivery(ctx, d.ID, map[string]interface{}{"status": model.DeliveryFailed, "error": &errMsg})
+		}
+	}
+	return nil
+}
+
+// 绑定到 mux.HandleFunc(types.WebhookDeliverKey, HandleWebhookDeliver)
+// 签名后发送投递记录中的请求体并记录最近一次响应；失败时返回错误交给 asynq 重试，
+// 最后一次重试失败后投递记录标记为失败
+func HandleWebhookDeliver(ctx context.Context, t *asynq.Task) error {
+	var p types.WebhookDeliverPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+
+	repo := repository.NewWebhookRepository(database.DB)
+	delivery, err := repo.GetDelivery(ctx, p.DeliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if delivery.Status != model.DeliveryPending {
+		return nil
+	}
+	sub, err := repo.GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !sub.IsActive && delivery.Event != model.WebhookPing {
+		errMsg := "webhook disabled"
+		return repo.UpdateDelivery(ctx, delivery.ID, map[string]interface{}{"status": model.DeliveryFailed, "error": &errMsg})
+	}
+
+	result, sendErr := webhook.Send(ctx, sub.URL, sub.Secret, string(delivery.Event), strconv.FormatInt(delivery.ID, 10), delivery.Payload)
+	now := time.Now()
+	update := map[string]interface{}{
+		"attempts":        delivery.Attempts + 1,
+		"response_status": result.Status,
+		"response_body":   &result.Body,
+		"duration_ms":     result.Duration.Milliseconds(),
+		"error":           nil,
+	}
+	if sendErr == nil {
+		update["status"], update["delivered_at"] = model.DeliverySuccess, now
+		if err := repo.UpdateDelivery(ctx, delivery.ID, update); err != nil {
+			return err
+		}
+		return repo.UpdateSubscription(ctx, sub.ID, map[string]interface{}{"last_status": model.DeliverySuccess, "last_delivery_at": now})
+	}
+
+	errMsg := sendErr.Error()
+	update["error"] = &errMsg
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	exhausted := retried >= maxRetry
+	if exhausted {
+		update["status"] = model.DeliveryFailed
+	}
+	if err := repo.UpdateDelivery(ctx, delivery.ID, update); err != nil {
+		return err
+	}
+	if !exhausted {
+		return sendErr
+	}
+	if err := repo.UpdateSubscription(ctx, sub.ID, map[string]interface{}{"last_status": model.DeliveryFailed, "last_delivery_at": now}); err != nil {
+		return err
+	}
+	// 已记录为失败，不再进入归档队列
+	return nil
+}
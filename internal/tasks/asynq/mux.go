@@ -1,6 +1,10 @@
 package asynqimpl
 
 import (
+	"gin-notebook/internal/model"
+	"gin-notebook/internal/pkg/integration/feishu"
+	"gin-notebook/internal/pkg/integration/notion"
+	"gin-notebook/internal/pkg/integration/syncer"
 	"gin-notebook/internal/tasks/asynq/handlers"
 	"gin-notebook/internal/tasks/asynq/types"
 
@@ -8,13 +12,28 @@ import (
 )
 
 func NewMux() *asynq.ServeMux {
+	// 同步任务按 link.Provider 取用外部平台实现
+	syncer.Register(model.ProviderFeishu, feishu.NewSyncProvider)
+	syncer.Register(model.ProviderNotion, notion.NewSyncProvider)
+
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(string(types.TypeEmailSend), handlers.HandleEmailSend)
 	mux.HandleFunc(string(types.KanbanActivityKey), handlers.KanbanActivity)
 	mux.HandleFunc(types.TypeFeishuRefreshAllUserTokens, handlers.HandleFeishuRefreshAllUserTokens)
 	mux.HandleFunc(types.InitSyncNoteKey, handlers.HandleInitSyncNote)
 	mux.HandleFunc(types.SyncDeltaKey, handlers.HandleSyncDelta)
+	mux.HandleFunc(types.SyncPullScanKey, handlers.HandleSyncPullScan)
+	mux.HandleFunc(types.SyncPullKey, handlers.HandleSyncPull)
+	mux.HandleFunc(types.SyncRetryScanKey, handlers.HandleSyncRetryScan)
 	mux.HandleFunc(types.IngestNoteKey, handlers.HandleIngestNote)
 	mux.HandleFunc(types.EmbedChunkKey, handlers.HandleEmbedChunk)
+	mux.HandleFunc(types.TypeTrashPurge, handlers.HandleTrashPurge)
+	mux.HandleFunc(types.TypeNoteViewFlush, handlers.HandleNoteViewFlush)
+	mux.HandleFunc(types.JiraTaskSyncKey, handlers.HandleJiraTaskSync)
+	mux.HandleFunc(types.SecretRotateKey, handlers.HandleSecretRotate)
+	mux.HandleFunc(types.WebhookDispatchKey, handlers.HandleWebhookDispatch)
+	mux.HandleFunc(types.WebhookDeliverKey, handlers.HandleWebhookDeliver)
+	mux.HandleFunc(types.FeishuNotifyKey, handlers.HandleFeishuNotify)
+	mux.HandleFunc(types.FeishuDeadlineScanKey, handlers.HandleFeishuDeadlineScan)
 	return mux
 }
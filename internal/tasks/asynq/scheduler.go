@@ -41,6 +41,30 @@ func (s *Scheduler) RegisterSpecs() error {
 		return err
 	}
 
+	if _, err := s.inner.Register("@every 1h", types.NewTrashPurgeTask()); err != nil {
+		return err
+	}
+
+	if _, err := s.inner.Register("@every 1m", types.NewNoteViewFlushTask()); err != nil {
+		return err
+	}
+
+	if _, err := s.inner.Register("@every 2m", types.NewSyncPullScanTask()); err != nil {
+		return err
+	}
+
+	if _, err := s.inner.Register("@every 1m", types.NewSyncRetryScanTask()); err != nil {
+		return err
+	}
+
+	if _, err := s.inner.Register("@every 6h", types.NewSecretRotateTask(), asynq.Queue("low")); err != nil {
+		return err
+	}
+
+	if _, err := s.inner.Register("@every 30m", types.NewFeishuDeadlineScanTask(), asynq.Queue("low")); err != nil {
+		return err
+	}
+
 	return nil
 }
 
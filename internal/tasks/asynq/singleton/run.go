@@ -49,6 +49,11 @@ func Dispatcher() contracts.Dispatcher {
 	return client
 }
 
+// UseDispatcher 替换全局 Dispatcher，测试里用于记录入队的任务
+func UseDispatcher(d contracts.Dispatcher) {
+	client = d
+}
+
 // RegisterFn 由调用方提供的注册函数，在这里面做 s.Register("cron/@every", task)
 type RegisterFn func(s *asynq.Scheduler) error
 
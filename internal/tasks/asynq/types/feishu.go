@@ -4,9 +4,28 @@ import "github.com/hibiken/asynq"
 
 const (
 	TypeFeishuRefreshAllUserTokens = "feishu:refresh_all_user_tokens"
+	FeishuNotifyKey                = "feishu:notify"
+	FeishuDeadlineScanKey          = "feishu:deadline_scan"
 )
 
 // 可以无 payload；如需按租户/批次，可加字段
 func NewFeishuRefreshAllUserTokensTask() *asynq.Task {
 	return asynq.NewTask(TypeFeishuRefreshAllUserTokens, nil)
 }
+
+// 无 payload：提前量读取配置
+func NewFeishuDeadlineScanTask() *asynq.Task {
+	return asynq.NewTask(FeishuDeadlineScanKey, nil)
+}
+
+// FeishuNotifyPayload 一条看板通知：MemberIDs 为私信接收人（工作区成员），
+// 项目配置的飞书群按 Kind 过滤后一并推送；EventID 用于飞书侧消息去重
+type FeishuNotifyPayload struct {
+	EventID      string  `json:"event_id"`
+	Kind         string  `json:"kind"`
+	TaskID       int64   `json:"task_id"`
+	ActorID      int64   `json:"actor_id,omitempty"` // 触发通知的成员，不给自己发私信
+	MemberIDs    []int64 `json:"member_ids,omitempty"`
+	CommentID    int64   `json:"comment_id,omitempty"`
+	FromColumnID int64   `json:"from_column_id,omitempty"`
+}
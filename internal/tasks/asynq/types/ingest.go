@@ -1,6 +1,8 @@
 package types
 
-const IngestNoteKey = "note:sync"
+// IngestNoteKey 早期与 SyncDeltaKey 同为 "note:sync"，两个处理器注册到同一类型会冲突，
+// 改名前已入队的索引任务仍以 "note:sync" 投递，由 HandleSyncDelta 按 payload 转交
+const IngestNoteKey = "note:ingest"
 const (
 	QIngest = "ingest"
 	QEmbed  = "embed"
@@ -0,0 +1,13 @@
+package types
+
+const JiraTaskSyncKey = "project:jira:sync"
+
+// JiraTaskSyncPayload 本地修改时带 TaskID，webhook 触发时带 IssueID；
+// 两侧都修改的字段由触发方为准
+type JiraTaskSyncPayload struct {
+	ProjectLinkID int64  `json:"project_link_id"`
+	TaskID        int64  `json:"task_id,omitempty"`
+	IssueID       string `json:"issue_id,omitempty"`
+	Remote        bool   `json:"remote"`
+	Deleted       bool   `json:"deleted,omitempty"` // 外部问题已删除，只解除映射
+}
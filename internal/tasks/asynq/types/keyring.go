@@ -0,0 +1,10 @@
+package types
+
+import "github.com/hibiken/asynq"
+
+const SecretRotateKey = "secret:rotate"
+
+// 无 payload：把所有不是主用密钥加密的集成密钥与令牌重新加密
+func NewSecretRotateTask() *asynq.Task {
+	return asynq.NewTask(SecretRotateKey, nil)
+}
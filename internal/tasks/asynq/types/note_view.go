@@ -0,0 +1,12 @@
+package types
+
+import "github.com/hibiken/asynq"
+
+const (
+	TypeNoteViewFlush = "note:view:flush"
+)
+
+// 无 payload：从 Redis 浏览队列批量落库
+func NewNoteViewFlushTask() *asynq.Task {
+	return asynq.NewTask(TypeNoteViewFlush, nil)
+}
@@ -1,9 +1,16 @@
 package types
 
-import "gin-notebook/pkg/utils/tools"
+import (
+	"gin-notebook/pkg/utils/tools"
+
+	"github.com/hibiken/asynq"
+)
 
 const InitSyncNoteKey = "note:init:sync"
 const SyncDeltaKey = "note:sync"
+const SyncPullScanKey = "note:sync:pull:scan"
+const SyncPullKey = "note:sync:pull"
+const SyncRetryScanKey = "note:sync:retry:scan"
 
 type SyncNotePayload struct {
 	NoteID       int64         `json:"note_id" validate:"required"`
@@ -22,3 +29,17 @@ type SyncDeltaPayload struct {
 	UserID      int64 `json:"user_id"`
 	MemberID    int64 `json:"member_id"`
 }
+
+type SyncPullPayload struct {
+	LinkID int64 `json:"link_id"`
+}
+
+// 无 payload：扫描允许拉取的同步链接并逐个投递拉取任务
+func NewSyncPullScanTask() *asynq.Task {
+	return asynq.NewTask(SyncPullScanKey, nil)
+}
+
+// 无 payload：回收卡住的 outbox，并为退避到期的链接重新投递推送任务
+func NewSyncRetryScanTask() *asynq.Task {
+	return asynq.NewTask(SyncRetryScanKey, nil)
+}
@@ -0,0 +1,12 @@
+package types
+
+import "github.com/hibiken/asynq"
+
+const (
+	TypeTrashPurge = "trash:purge"
+)
+
+// 无 payload：保留期与批量大小读取配置
+func NewTrashPurgeTask() *asynq.Task {
+	return asynq.NewTask(TypeTrashPurge, nil)
+}
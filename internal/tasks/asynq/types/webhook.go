@@ -0,0 +1,24 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const WebhookDispatchKey = "webhook:dispatch"
+const WebhookDeliverKey = "webhook:deliver"
+
+// WebhookDispatchPayload 业务事件，由处理端按订阅展开为投递记录
+type WebhookDispatchPayload struct {
+	EventID     string          `json:"event_id"`
+	WorkspaceID int64           `json:"workspace_id"`
+	ProjectID   int64           `json:"project_id,omitempty"`
+	Event       string          `json:"event"`
+	Data        json.RawMessage `json:"data"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+// WebhookDeliverPayload 单次投递，失败时依赖 asynq 重试
+type WebhookDeliverPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
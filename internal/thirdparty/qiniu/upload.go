@@ -2,11 +2,15 @@ package qiniu
 
 import (
 	"context"
+	"path"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/qiniu/go-sdk/v7/storagev2/credentials"
 	"github.com/qiniu/go-sdk/v7/storagev2/http_client"
+	"github.com/qiniu/go-sdk/v7/storagev2/objects"
 	"github.com/qiniu/go-sdk/v7/storagev2/region"
 	"github.com/qiniu/go-sdk/v7/storagev2/uploader"
 	"github.com/qiniu/go-sdk/v7/storagev2/uptoken"
@@ -87,3 +91,77 @@ func (q *QiniuService) GetConfig() QiniuConfig {
 func (q *QiniuService) PublicURL(key string) string {
 	return "https://" + q.cfg.Domain + "/" + key
 }
+
+// 4) 删除对象
+func (q *QiniuService) DeleteFile(ctx context.Context, key string) error {
+	manager := objects.NewObjectsManager(&objects.ObjectsManagerOptions{
+		Options: http_client.Options{
+			Credentials: q.mac,
+			Regions:     region.GetRegionByID(q.cfg.RegionID, true),
+		},
+	})
+	return manager.Bucket(q.cfg.Bucket).Object(key).Delete().Call(ctx)
+}
+
+// 5) 空间内复制对象
+func (q *QiniuService) CopyFile(ctx context.Context, fromKey, toKey string) error {
+	manager := objects.NewObjectsManager(&objects.ObjectsManagerOptions{
+		Options: http_client.Options{
+			Credentials: q.mac,
+			Regions:     region.GetRegionByID(q.cfg.RegionID, true),
+		},
+	})
+	return manager.Bucket(q.cfg.Bucket).Object(fromKey).CopyTo(q.cfg.Bucket, toKey).Call(ctx)
+}
+
+// KeyFromURL 从外链反解对象 key，非本空间域名的链接返回 false
+func (q *QiniuService) KeyFromURL(rawURL string) (string, bool) {
+	prefix := "https://" + q.cfg.Domain + "/"
+	if q.cfg.Domain == "" || !strings.HasPrefix(rawURL, prefix) {
+		return "", false
+	}
+	key := strings.TrimPrefix(rawURL, prefix)
+	if idx := strings.IndexAny(key, "?#"); idx >= 0 {
+		key = key[:idx]
+	}
+	return key, key != ""
+}
+
+// DeleteByURLs 按外链批量删除对象，尽力而为；未配置七牛时直接跳过
+func DeleteByURLs(ctx context.Context, urls []string) (failed int) {
+	q := GetQiniuService()
+	if q == nil {
+		return 0
+	}
+	for _, u := range urls {
+		key, ok := q.KeyFromURL(u)
+		if !ok {
+			continue
+		}
+		if err := q.DeleteFile(ctx, key); err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// CopyByURL 复制外链对应的对象到同目录下的新 key 并返回新外链；
+// 非本空间的链接或未配置七牛时原样返回，copied 为 false
+func CopyByURL(ctx context.Context, rawURL string) (newURL string, copied bool, err error) {
+	q := GetQiniuService()
+	if q == nil {
+		return rawURL, false, nil
+	}
+	key, ok := q.KeyFromURL(rawURL)
+	if !ok {
+		return rawURL, false, nil
+	}
+	newKey := uuid.NewString() + path.Ext(key)
+	if dir := path.Dir(key); dir != "." {
+		newKey = dir + "/" + newKey
+	}
+	if err := q.CopyFile(ctx, key, newKey); err != nil {
+		return "", false, err
+	}
+	return q.PublicURL(newKey), true, nil
+}